@@ -0,0 +1,3053 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	grpc_gateway "github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/percona/pmm/api/inventorypb"
+	backupv1beta1 "github.com/percona/pmm/api/managementpb/backup"
+	dbaasv1beta1 "github.com/percona/pmm/api/managementpb/dbaas"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/agents"
+	"github.com/percona/pmm-managed/services/alertmanager"
+	"github.com/percona/pmm-managed/services/automations"
+	"github.com/percona/pmm-managed/services/backup"
+	"github.com/percona/pmm-managed/services/capacity"
+	"github.com/percona/pmm-managed/services/cleanup"
+	"github.com/percona/pmm-managed/services/dashboards"
+	"github.com/percona/pmm-managed/services/grafana"
+	"github.com/percona/pmm-managed/services/inventory"
+	"github.com/percona/pmm-managed/services/managedfiles"
+	"github.com/percona/pmm-managed/services/management"
+	managementbackup "github.com/percona/pmm-managed/services/management/backup"
+	managementdbaas "github.com/percona/pmm-managed/services/management/dbaas"
+	"github.com/percona/pmm-managed/services/management/ia"
+	"github.com/percona/pmm-managed/services/operations"
+	"github.com/percona/pmm-managed/services/qan"
+	"github.com/percona/pmm-managed/services/reports"
+	"github.com/percona/pmm-managed/services/sandbox"
+	"github.com/percona/pmm-managed/services/scheduler"
+	"github.com/percona/pmm-managed/services/selftest"
+	"github.com/percona/pmm-managed/services/server"
+	"github.com/percona/pmm-managed/services/siem"
+	"github.com/percona/pmm-managed/services/topology"
+	"github.com/percona/pmm-managed/services/usage"
+	"github.com/percona/pmm-managed/services/victoriametrics"
+	"github.com/percona/pmm-managed/services/vmalert"
+	"github.com/percona/pmm-managed/services/watchdog"
+	"github.com/percona/pmm-managed/utils/logger"
+)
+
+// decodeJSONRequest decodes JSON request body into v; empty body leaves v unchanged.
+// On failure, it writes Bad Request response and returns false.
+func decodeJSONRequest(rw http.ResponseWriter, req *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil && err != io.EOF {
+		http.Error(rw, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeJSONResponse writes v as JSON response.
+func writeJSONResponse(rw http.ResponseWriter, l *logrus.Entry, v interface{}) {
+	rw.Header().Set(`Content-Type`, `application/json`)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		l.Errorf("%+v", err)
+	}
+}
+
+// writeErrorResponse logs err and writes it with HTTP status code corresponding to its gRPC code.
+func writeErrorResponse(rw http.ResponseWriter, l *logrus.Entry, err error) {
+	l.Errorf("%+v", err)
+	http.Error(rw, err.Error(), grpc_gateway.HTTPStatusFromCode(status.Code(err)))
+}
+
+// writeJSONResult writes err if it is not nil, and res otherwise.
+func writeJSONResult(rw http.ResponseWriter, l *logrus.Entry, res interface{}, err error) {
+	if err != nil {
+		writeErrorResponse(rw, l, err)
+		return
+	}
+	writeJSONResponse(rw, l, res)
+}
+
+// withGrafanaCredentials returns request context with Grafana credentials of the request
+// stored as gRPC metadata, the same way grpc-gateway passes them to gRPC services.
+func withGrafanaCredentials(req *http.Request) context.Context {
+	md := make(metadata.MD)
+	if v := req.Header.Get("Authorization"); v != "" {
+		md.Set("Authorization", v)
+	}
+	if v := req.Header.Values("Cookie"); len(v) != 0 {
+		md.Set("grpcgateway-cookie", v...)
+	}
+	return metadata.NewIncomingContext(req.Context(), md)
+}
+
+func addQANExportHandler(mux *http.ServeMux, qanClient *qan.Client) {
+	l := logrus.WithField("component", "qan/export")
+
+	mux.HandleFunc("/v1/qan/Export", func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		params := &qan.ExportParams{
+			GroupBy:      q.Get("group_by"),
+			ServiceNames: q["service_name"],
+			Search:       q.Get("fingerprint"),
+			Format:       qan.ExportFormat(q.Get("format")),
+		}
+		if v := q.Get("columns"); v != "" {
+			params.Columns = strings.Split(v, ",")
+		}
+		for _, label := range q["label"] {
+			parts := strings.SplitN(label, "=", 2)
+			if len(parts) != 2 {
+				http.Error(rw, fmt.Sprintf("invalid label %q, expected key=value", label), http.StatusBadRequest)
+				return
+			}
+			if params.Labels == nil {
+				params.Labels = make(map[string][]string)
+			}
+			params.Labels[parts[0]] = append(params.Labels[parts[0]], parts[1])
+		}
+		var err error
+		if params.PeriodStartFrom, err = time.Parse(time.RFC3339, q.Get("period_start_from")); err != nil {
+			http.Error(rw, "invalid period_start_from: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if params.PeriodStartTo, err = time.Parse(time.RFC3339, q.Get("period_start_to")); err != nil {
+			http.Error(rw, "invalid period_start_to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = params.Validate(); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		filename := fmt.Sprintf("qan_%s.%s", time.Now().UTC().Format("2006-01-02_15-04"), params.Format)
+		contentType := `text/csv`
+		if params.Format == qan.JSONExportFormat {
+			contentType = `application/x-ndjson`
+		}
+		rw.Header().Set(`Content-Type`, contentType)
+		rw.Header().Set(`Content-Disposition`, `attachment; filename="`+filename+`"`)
+
+		ctx := logger.Set(req.Context(), "qan-export")
+		if err = qanClient.Export(ctx, rw, params); err != nil {
+			l.Errorf("%+v", err)
+		}
+	})
+}
+
+func addCapacityForecastHandler(mux *http.ServeMux, capacityService *capacity.Service) {
+	l := logrus.WithField("component", "capacity")
+
+	mux.HandleFunc("/v1/management/Capacity/GetForecast", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID          string  `json:"service_id"`
+			Period             string  `json:"period"`
+			Method             string  `json:"method"`
+			CreateAlerts       bool    `json:"create_alerts"`
+			AlertThresholdDays float64 `json:"alert_threshold_days"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		params := &capacity.ForecastParams{
+			ServiceID:          body.ServiceID,
+			Method:             capacity.Method(body.Method),
+			CreateAlerts:       body.CreateAlerts,
+			AlertThresholdDays: body.AlertThresholdDays,
+		}
+		if body.Period != "" {
+			var err error
+			if params.Period, err = time.ParseDuration(body.Period); err != nil {
+				http.Error(rw, "invalid period: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "capacity")
+		forecasts, err := capacityService.GetCapacityForecast(ctx, params)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, map[string]interface{}{"forecasts": forecasts})
+	})
+
+	mux.HandleFunc("/v1/management/backup/Backups/SuggestBackupWindow", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID string `json:"service_id"`
+			Duration  string `json:"duration"`
+			Period    string `json:"period"`
+			Weekly    bool   `json:"weekly"`
+			Count     int    `json:"count"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		params := &capacity.BackupWindowParams{
+			ServiceID: body.ServiceID,
+			Weekly:    body.Weekly,
+			Count:     body.Count,
+		}
+		for _, d := range []struct {
+			name  string
+			value string
+			dst   *time.Duration
+		}{
+			{"duration", body.Duration, &params.Duration},
+			{"period", body.Period, &params.Period},
+		} {
+			if d.value == "" {
+				continue
+			}
+			var err error
+			if *d.dst, err = time.ParseDuration(d.value); err != nil {
+				http.Error(rw, "invalid "+d.name+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "capacity")
+		windows, err := capacityService.SuggestBackupWindow(ctx, params)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Windows []*capacity.BackupWindow `json:"windows"`
+		}{windows}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addReplicationTopologyHandler(mux *http.ServeMux, topologyService *topology.Service) {
+	l := logrus.WithField("component", "topology")
+
+	mux.HandleFunc("/v1/management/Topology/GetReplicationTopology", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID string `json:"service_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "topology")
+		clusters, err := topologyService.GetReplicationTopology(ctx, body.ServiceID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Clusters []*topology.Cluster `json:"clusters"`
+		}{clusters}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addAnomalyBaselinesHandler(mux *http.ServeMux, baselinesService *ia.BaselinesService) {
+	l := logrus.WithField("component", "management/ia/baselines")
+
+	type request struct {
+		BaselineID  string  `json:"baseline_id"`
+		ServiceID   string  `json:"service_id"`
+		Metric      string  `json:"metric"`
+		Seasonality string  `json:"seasonality"`
+		Sigma       float64 `json:"sigma"`
+		Disabled    *bool   `json:"disabled"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "baselines")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/management/ia/Baselines/List", func(ctx context.Context, r *request) (interface{}, error) {
+		baselines, err := baselinesService.ListBaselines(ctx, r.ServiceID)
+		return map[string]interface{}{"baselines": baselines}, err
+	})
+	handle("/v1/management/ia/Baselines/Add", func(ctx context.Context, r *request) (interface{}, error) {
+		return baselinesService.AddBaseline(ctx, &models.CreateAnomalyBaselineParams{
+			ServiceID:   r.ServiceID,
+			Metric:      models.BaselineMetric(r.Metric),
+			Seasonality: models.BaselineSeasonality(r.Seasonality),
+			Sigma:       r.Sigma,
+			Disabled:    pointer.GetBool(r.Disabled),
+		})
+	})
+	handle("/v1/management/ia/Baselines/Change", func(ctx context.Context, r *request) (interface{}, error) {
+		return baselinesService.ChangeBaseline(ctx, r.BaselineID, &models.ChangeAnomalyBaselineParams{
+			Seasonality: models.BaselineSeasonality(r.Seasonality),
+			Sigma:       r.Sigma,
+			Disabled:    r.Disabled,
+		})
+	})
+	handle("/v1/management/ia/Baselines/Remove", func(ctx context.Context, r *request) (interface{}, error) {
+		return struct{}{}, baselinesService.RemoveBaseline(ctx, r.BaselineID)
+	})
+}
+
+func addImportScrapeTargetsHandler(mux *http.ServeMux, externalService *management.ExternalService) {
+	l := logrus.WithField("component", "management/external")
+
+	mux.HandleFunc("/v1/management/External/ImportScrapeTargets", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// prometheus.yml or file_sd file content
+			Data                string `json:"data"`
+			Format              string `json:"format"`
+			JobName             string `json:"job_name"`
+			SkipConnectionCheck bool   `json:"skip_connection_check"`
+			DryRun              bool   `json:"dry_run"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "import")
+		res, err := externalService.ImportScrapeTargets(ctx, &management.ImportScrapeTargetsParams{
+			Data:                []byte(body.Data),
+			Format:              management.ImportFormat(body.Format),
+			JobName:             body.JobName,
+			SkipConnectionCheck: body.SkipConnectionCheck,
+			DryRun:              body.DryRun,
+		})
+		writeJSONResult(rw, l, res, err)
+	})
+}
+
+func addBackupNotificationsHandler(mux *http.ServeMux, backupsService *managementbackup.BackupsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/Backups/ChangeScheduledNotifications", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ScheduledBackupID string `json:"scheduled_backup_id"`
+			// nil disables notifications
+			Notifications *models.BackupNotifications `json:"notifications"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "backup-notifications")
+		if err := backupsService.ChangeScheduledBackupNotifications(ctx, body.ScheduledBackupID, body.Notifications); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addScheduledBackupPauseHandlers(mux *http.ServeMux, backupsService *managementbackup.BackupsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	handle := func(path string, f func(context.Context, string) error) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body struct {
+				ScheduledBackupID string `json:"scheduled_backup_id"`
+			}
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "scheduled-backup")
+			if err := f(ctx, body.ScheduledBackupID); err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, struct{}{})
+		})
+	}
+
+	handle("/v1/management/backup/Backups/EnableScheduled", backupsService.EnableScheduledBackup)
+	handle("/v1/management/backup/Backups/DisableScheduled", backupsService.DisableScheduledBackup)
+}
+
+func addScheduledTasksHandlers(mux *http.ServeMux, schedulerService *scheduler.Service, reportsService *reports.Service, cleanupService *cleanup.Service, alertmanagerService *alertmanager.Service, vmdb *victoriametrics.Service) {
+	l := logrus.WithField("component", "scheduler")
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/AddReport", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			CronExpression string                `json:"cron_expression"`
+			StartAt        time.Time             `json:"start_at"`
+			Disabled       bool                  `json:"disabled"`
+			DependsOn      string                `json:"depends_on"`
+			Data           models.ReportTaskData `json:"data"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+		if err := body.Data.Validate(); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		task := scheduler.NewReportTask(reportsService, &body.Data)
+		scheduledTask, err := schedulerService.Add(task, scheduler.AddParams{
+			CronExpression: body.CronExpression,
+			StartAt:        body.StartAt,
+			Disabled:       body.Disabled,
+			DependsOn:      body.DependsOn,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			TaskID string `json:"task_id"`
+		}{scheduledTask.ID}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/AddCleanup", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			CronExpression string                 `json:"cron_expression"`
+			StartAt        time.Time              `json:"start_at"`
+			Disabled       bool                   `json:"disabled"`
+			DependsOn      string                 `json:"depends_on"`
+			Data           models.CleanupTaskData `json:"data"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		task := scheduler.NewCleanupTask(cleanupService, &body.Data)
+		scheduledTask, err := schedulerService.Add(task, scheduler.AddParams{
+			CronExpression: body.CronExpression,
+			StartAt:        body.StartAt,
+			Disabled:       body.Disabled,
+			DependsOn:      body.DependsOn,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			TaskID string `json:"task_id"`
+		}{scheduledTask.ID}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/AddMaintenance", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			CronExpression string                     `json:"cron_expression"`
+			StartAt        time.Time                  `json:"start_at"`
+			Disabled       bool                       `json:"disabled"`
+			DependsOn      string                     `json:"depends_on"`
+			Data           models.MaintenanceTaskData `json:"data"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		task := scheduler.NewMaintenanceTask(alertmanagerService, &body.Data)
+		scheduledTask, err := schedulerService.Add(task, scheduler.AddParams{
+			CronExpression: body.CronExpression,
+			StartAt:        body.StartAt,
+			Disabled:       body.Disabled,
+			DependsOn:      body.DependsOn,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			TaskID string `json:"task_id"`
+		}{scheduledTask.ID}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/AddCompaction", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			CronExpression string                    `json:"cron_expression"`
+			StartAt        time.Time                 `json:"start_at"`
+			Disabled       bool                      `json:"disabled"`
+			DependsOn      string                    `json:"depends_on"`
+			Data           models.CompactionTaskData `json:"data"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		task := scheduler.NewCompactionTask(vmdb, &body.Data)
+		scheduledTask, err := schedulerService.Add(task, scheduler.AddParams{
+			CronExpression: body.CronExpression,
+			StartAt:        body.StartAt,
+			Disabled:       body.Disabled,
+			DependsOn:      body.DependsOn,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			TaskID string `json:"task_id"`
+		}{scheduledTask.ID}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/PreviewRuns", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			CronExpression string `json:"cron_expression"`
+			Timezone       string `json:"timezone"`
+			Count          int    `json:"count"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		runs, err := scheduler.PreviewRuns(body.CronExpression, body.Timezone, time.Now(), body.Count)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Runs []time.Time `json:"runs"`
+		}{runs}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/ListRuns", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// Runs of all tasks are returned if empty.
+			TaskID string                        `json:"task_id"`
+			Status models.ScheduledTaskRunStatus `json:"status"`
+			Limit  int                           `json:"limit"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "scheduled-task-runs")
+		runs, err := schedulerService.ListRuns(ctx, models.ScheduledTaskRunsFilter{
+			TaskID: body.TaskID,
+			Status: body.Status,
+			Limit:  body.Limit,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Runs []*models.ScheduledTaskRun `json:"runs"`
+		}{runs}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/RunNow", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			TaskID string `json:"task_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "scheduled-task-run-now")
+		runID, err := schedulerService.RunNow(ctx, body.TaskID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			RunID string `json:"run_id"`
+		}{runID}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/Change", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			TaskID         string                    `json:"task_id"`
+			CronExpression *string                   `json:"cron_expression"`
+			Data           *models.ScheduledTaskData `json:"data"`
+			Retries        *uint32                   `json:"retries"`
+			// Go duration string like "1m"; not changed if empty.
+			RetryInterval string               `json:"retry_interval"`
+			RetryBackoff  *models.RetryBackoff `json:"retry_backoff"`
+			// Go duration strings; not changed if empty.
+			MaxRetryInterval string  `json:"max_retry_interval"`
+			RetryBudget      string  `json:"retry_budget"`
+			DependsOn        *string `json:"depends_on"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		params := models.ChangeScheduledTaskParams{
+			CronExpression: body.CronExpression,
+			Data:           body.Data,
+			Retries:        body.Retries,
+			RetryBackoff:   body.RetryBackoff,
+			DependsOn:      body.DependsOn,
+		}
+		for _, d := range []struct {
+			name  string
+			value string
+			param **time.Duration
+		}{
+			{"retry_interval", body.RetryInterval, &params.RetryInterval},
+			{"max_retry_interval", body.MaxRetryInterval, &params.MaxRetryInterval},
+			{"retry_budget", body.RetryBudget, &params.RetryBudget},
+		} {
+			if d.value == "" {
+				continue
+			}
+			v, err := time.ParseDuration(d.value)
+			if err != nil {
+				http.Error(rw, "invalid "+d.name+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			*d.param = &v
+		}
+
+		if err := schedulerService.Update(body.TaskID, params); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/ChangeMisfirePolicy", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			TaskID        string               `json:"task_id"`
+			MisfirePolicy models.MisfirePolicy `json:"misfire_policy"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		err := schedulerService.Update(body.TaskID, models.ChangeScheduledTaskParams{
+			MisfirePolicy: &body.MisfirePolicy,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/ChangeConcurrencyPolicy", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			TaskID            string                   `json:"task_id"`
+			ConcurrencyPolicy models.ConcurrencyPolicy `json:"concurrency_policy"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		err := schedulerService.Update(body.TaskID, models.ChangeScheduledTaskParams{
+			ConcurrencyPolicy: &body.ConcurrencyPolicy,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+
+	mux.HandleFunc("/v1/management/ScheduledTasks/ChangeTiming", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			TaskID string `json:"task_id"`
+			// Go duration string like "5m"; not changed if empty.
+			Jitter string `json:"jitter"`
+			// Execution window bounds (HH:MM, UTC); not changed if both are nil, removed if both are empty.
+			WindowStart *string `json:"window_start"`
+			WindowEnd   *string `json:"window_end"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		params := models.ChangeScheduledTaskParams{
+			WindowStart: body.WindowStart,
+			WindowEnd:   body.WindowEnd,
+		}
+		if body.Jitter != "" {
+			jitter, err := time.ParseDuration(body.Jitter)
+			if err != nil {
+				http.Error(rw, "invalid jitter: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			params.Jitter = &jitter
+		}
+
+		if err := schedulerService.Update(body.TaskID, params); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addGroupBackupHandler(mux *http.ServeMux, backupsService *managementbackup.BackupsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/Backups/StartGroup", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceIDs []string `json:"service_ids"`
+			Cluster    string   `json:"cluster"`
+			LocationID string   `json:"location_id"`
+			Name       string   `json:"name"`
+			DataModel  string   `json:"data_model"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "group-backup")
+		groupID, artifactIDs, err := backupsService.StartGroupBackup(ctx, backup.PerformGroupBackupParams{
+			ServiceIDs: body.ServiceIDs,
+			Cluster:    body.Cluster,
+			LocationID: body.LocationID,
+			Name:       body.Name,
+			DataModel:  models.DataModel(body.DataModel),
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			GroupID     string   `json:"group_id"`
+			ArtifactIDs []string `json:"artifact_ids"`
+		}{groupID, artifactIDs}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+// addBackupDataModelHandlers replaces grpc-gateway handlers of on-demand and scheduled backups,
+// so those requests may also contain data_model field that is not available in gRPC API.
+func addBackupDataModelHandlers(mux *http.ServeMux, backupsService *managementbackup.BackupsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	// decodes gRPC request the same way as grpc-gateway, and data model
+	decode := func(rw http.ResponseWriter, req *http.Request, body interface{}) (models.DataModel, bool) {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(rw, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return "", false
+		}
+
+		var ext struct {
+			DataModel models.DataModel `json:"data_model"`
+		}
+		if len(b) != 0 {
+			if err = new(grpc_gateway.JSONPb).Unmarshal(b, body); err == nil {
+				err = json.Unmarshal(b, &ext)
+			}
+			if err != nil {
+				http.Error(rw, "invalid request: "+err.Error(), http.StatusBadRequest)
+				return "", false
+			}
+		}
+		return ext.DataModel, true
+	}
+
+	mux.HandleFunc("/v1/management/backup/Backups/Start", func(rw http.ResponseWriter, req *http.Request) {
+		var body backupv1beta1.StartBackupRequest
+		dataModel, ok := decode(rw, req, &body)
+		if !ok {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "backups")
+		res, err := backupsService.StartBackupWithDataModel(ctx, &body, dataModel)
+		writeJSONResult(rw, l, res, err)
+	})
+
+	mux.HandleFunc("/v1/management/backup/Backups/Schedule", func(rw http.ResponseWriter, req *http.Request) {
+		var body backupv1beta1.ScheduleBackupRequest
+		dataModel, ok := decode(rw, req, &body)
+		if !ok {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "backups")
+		res, err := backupsService.ScheduleBackupWithDataModel(ctx, &body, dataModel)
+		writeJSONResult(rw, l, res, err)
+	})
+}
+
+func addBackupSigningHandlers(mux *http.ServeMux, signingService *backup.SigningService) {
+	l := logrus.WithField("component", "backup-signing")
+
+	mux.HandleFunc("/v1/management/backup/Signing/SetKey", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// Armored GPG private key without passphrase; empty key disables signing.
+			SigningKey string `json:"signing_key"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "backup-signing")
+		if err := signingService.SetSigningKey(ctx, body.SigningKey); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+
+	mux.HandleFunc("/v1/management/backup/Signing/GetPublicKey", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "backup-signing")
+		key, err := signingService.PublicKey(ctx)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			PublicKey string `json:"public_key"`
+		}{key}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addClusterRestoreHandlers(mux *http.ServeMux, backupsService *managementbackup.BackupsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/Backups/GetClusterRestorePlan", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID  string `json:"service_id"`
+			ArtifactID string `json:"artifact_id"`
+			// Used if artifact_id is empty, for example, for golden artifacts.
+			ArtifactName string `json:"artifact_name"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "cluster-restore-plan")
+		artifactID, err := backupsService.FindArtifactID(body.ArtifactID, body.ArtifactName)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+		plan, err := backupsService.ClusterRestorePlan(ctx, body.ServiceID, artifactID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, plan)
+	})
+
+	mux.HandleFunc("/v1/management/backup/Backups/RestoreCluster", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID  string `json:"service_id"`
+			ArtifactID string `json:"artifact_id"`
+			// Used if artifact_id is empty, for example, for golden artifacts.
+			ArtifactName string `json:"artifact_name"`
+			Force        bool   `json:"force"`
+			// Restore artifacts without valid signature, see backup.SigningService.
+			SkipSignatureCheck bool `json:"skip_signature_check"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "cluster-restore")
+		artifactID, err := backupsService.FindArtifactID(body.ArtifactID, body.ArtifactName)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+		restoreID, err := backupsService.RestoreClusterBackup(ctx, body.ServiceID, artifactID, body.Force, body.SkipSignatureCheck)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			RestoreID string `json:"restore_id"`
+		}{restoreID}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addBackupDetailsHandlers(mux *http.ServeMux, artifactsService *managementbackup.ArtifactsService, backupsService *managementbackup.BackupsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/Artifacts/ListDetails", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "artifacts-details")
+		artifacts, err := artifactsService.ListArtifactDetails(ctx)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+		writeJSONResponse(rw, l, map[string]interface{}{"artifacts": artifacts})
+	})
+
+	mux.HandleFunc("/v1/management/backup/Backups/ListScheduledDetails", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "scheduled-backups-details")
+		backups, err := backupsService.ListScheduledBackupDetails(ctx)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+		writeJSONResponse(rw, l, map[string]interface{}{"scheduled_backups": backups})
+	})
+
+	mux.HandleFunc("/v1/management/backup/Swagger", func(rw http.ResponseWriter, req *http.Request) {
+		writeJSONResponse(rw, l, managementbackup.SwaggerSpec())
+	})
+}
+
+func addRestoreHistoryDetailsHandler(mux *http.ServeMux, restoreHistoryService *managementbackup.RestoreHistoryService) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/RestoreHistory/ListDetails", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID   string    `json:"service_id"`
+			ArtifactID  string    `json:"artifact_id"`
+			Status      string    `json:"status"`
+			StartedFrom time.Time `json:"started_from"`
+			StartedTo   time.Time `json:"started_to"`
+			Limit       int       `json:"limit"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		filters := models.RestoreHistoryItemFilters{
+			ServiceID:   body.ServiceID,
+			ArtifactID:  body.ArtifactID,
+			StartedFrom: body.StartedFrom,
+			StartedTo:   body.StartedTo,
+			Limit:       body.Limit,
+		}
+		if body.Status != "" {
+			restoreStatus := models.RestoreStatus(body.Status)
+			if err := restoreStatus.Validate(); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			filters.Status = &restoreStatus
+		}
+
+		ctx := logger.Set(req.Context(), "restore-history")
+		items, err := restoreHistoryService.ListRestoreHistoryDetails(ctx, filters)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, map[string]interface{}{"items": items})
+	})
+}
+
+func addArtifactDescriptorsHandlers(mux *http.ServeMux, artifactsService *managementbackup.ArtifactsService, grafanaClient *grafana.Client) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/Artifacts/Export", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ArtifactID string `json:"artifact_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "artifact-export")
+		descriptor, err := artifactsService.ExportArtifact(ctx, body.ArtifactID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, descriptor)
+	})
+
+	mux.HandleFunc("/v1/management/backup/Artifacts/Import", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Descriptors []*managementbackup.ArtifactDescriptor `json:"descriptors"`
+			LocationID  string                                 `json:"location_id"`
+			ServiceID   string                                 `json:"service_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "artifact-import")
+		artifacts, err := artifactsService.ImportArtifacts(ctx, &managementbackup.ImportArtifactsParams{
+			Descriptors: body.Descriptors,
+			LocationID:  body.LocationID,
+			ServiceID:   body.ServiceID,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		artifactIDs := make([]string, len(artifacts))
+		for i, a := range artifacts {
+			artifactIDs[i] = a.ID
+		}
+		writeJSONResponse(rw, l, map[string]interface{}{"artifact_ids": artifactIDs})
+	})
+
+	for path, hold := range map[string]bool{
+		"/v1/management/backup/Artifacts/Hold":        true,
+		"/v1/management/backup/Artifacts/ReleaseHold": false,
+	} {
+		hold := hold
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body struct {
+				ArtifactID string `json:"artifact_id"`
+				Reason     string `json:"reason"`
+			}
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(withGrafanaCredentials(req), "artifact-hold")
+			actor, err := grafanaClient.GetUserLogin(ctx)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			if err = artifactsService.SetArtifactHold(ctx, body.ArtifactID, hold, body.Reason, actor); err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, struct{}{})
+		})
+	}
+
+	for path, golden := range map[string]bool{
+		"/v1/management/backup/Artifacts/MarkGolden":   true,
+		"/v1/management/backup/Artifacts/UnmarkGolden": false,
+	} {
+		golden := golden
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body struct {
+				ArtifactID string `json:"artifact_id"`
+				Reason     string `json:"reason"`
+			}
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(withGrafanaCredentials(req), "artifact-golden")
+			actor, err := grafanaClient.GetUserLogin(ctx)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			if err = artifactsService.SetArtifactGolden(ctx, body.ArtifactID, golden, body.Reason, actor); err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, struct{}{})
+		})
+	}
+
+	mux.HandleFunc("/v1/management/backup/Artifacts/ListGolden", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "artifact-golden")
+		artifacts, err := artifactsService.ListGoldenArtifacts(ctx)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		type goldenArtifact struct {
+			ArtifactID string           `json:"artifact_id"`
+			Name       string           `json:"name"`
+			Vendor     string           `json:"vendor"`
+			LocationID string           `json:"location_id"`
+			ServiceID  string           `json:"service_id"`
+			DataModel  models.DataModel `json:"data_model"`
+			Size       *int64           `json:"size,omitempty"`
+			CreatedAt  time.Time        `json:"created_at"`
+		}
+		res := struct {
+			Artifacts []goldenArtifact `json:"artifacts"`
+		}{Artifacts: make([]goldenArtifact, len(artifacts))}
+		for i, a := range artifacts {
+			res.Artifacts[i] = goldenArtifact{
+				ArtifactID: a.ID,
+				Name:       a.Name,
+				Vendor:     a.Vendor,
+				LocationID: a.LocationID,
+				ServiceID:  a.ServiceID,
+				DataModel:  a.DataModel,
+				Size:       a.Size,
+				CreatedAt:  a.CreatedAt,
+			}
+		}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addLocationUsageHandler(mux *http.ServeMux, locationsService *managementbackup.LocationsService) {
+	l := logrus.WithField("component", "management/backup")
+
+	mux.HandleFunc("/v1/management/backup/Locations/GetUsage", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			LocationID string `json:"location_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "location-usage")
+		usage, err := locationsService.GetLocationUsage(ctx, body.LocationID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, usage)
+	})
+}
+
+func addMetricsHandlers(mux *http.ServeMux, vmdb *victoriametrics.Service, metricsService *managementbackup.MetricsService) {
+	l := logrus.WithField("component", "management/metrics")
+
+	mux.HandleFunc("/v1/management/Metrics/CreateSnapshot", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "metrics-snapshot")
+		name, err := vmdb.CreateSnapshot(ctx)
+		writeJSONResult(rw, l, struct {
+			Snapshot string `json:"snapshot"`
+		}{name}, err)
+	})
+
+	mux.HandleFunc("/v1/management/Metrics/ListSnapshots", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "metrics-snapshot")
+		snapshots, err := vmdb.ListSnapshots(ctx)
+		writeJSONResult(rw, l, struct {
+			Snapshots []string `json:"snapshots"`
+		}{snapshots}, err)
+	})
+
+	mux.HandleFunc("/v1/management/Metrics/DeleteSnapshot", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Snapshot string `json:"snapshot"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "metrics-snapshot")
+		writeJSONResult(rw, l, struct{}{}, vmdb.DeleteSnapshot(ctx, body.Snapshot))
+	})
+
+	mux.HandleFunc("/v1/management/Metrics/Export", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			LocationID string                       `json:"location_id"`
+			Name       string                       `json:"name"`
+			Match      []string                     `json:"match"`
+			Start      time.Time                    `json:"start"`
+			End        time.Time                    `json:"end"`
+			Format     victoriametrics.ExportFormat `json:"format"`
+			CSVFormat  string                       `json:"csv_format"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "metrics-export")
+		path, err := metricsService.ExportMetrics(ctx, &managementbackup.ExportMetricsParams{
+			LocationID: body.LocationID,
+			Name:       body.Name,
+			ExportParams: victoriametrics.ExportParams{
+				Match:     body.Match,
+				Start:     body.Start,
+				End:       body.End,
+				Format:    body.Format,
+				CSVFormat: body.CSVFormat,
+			},
+		})
+		writeJSONResult(rw, l, struct {
+			Path string `json:"path"`
+		}{path}, err)
+	})
+
+	mux.HandleFunc("/v1/management/Metrics/Import", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			LocationID string                       `json:"location_id"`
+			Name       string                       `json:"name"`
+			Format     victoriametrics.ExportFormat `json:"format"`
+			CSVFormat  string                       `json:"csv_format"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "metrics-import")
+		err := metricsService.ImportMetrics(ctx, &managementbackup.ImportMetricsParams{
+			LocationID: body.LocationID,
+			Name:       body.Name,
+			ImportParams: victoriametrics.ImportParams{
+				Format:    body.Format,
+				CSVFormat: body.CSVFormat,
+			},
+		})
+		writeJSONResult(rw, l, struct{}{}, err)
+	})
+}
+
+func addOperationsHandlers(mux *http.ServeMux, operationsService *operations.Service) {
+	l := logrus.WithField("component", "operations")
+
+	mux.HandleFunc("/v1/Operations/Get", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			OperationID string `json:"operation_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "operations")
+		op, err := operationsService.GetOperation(ctx, body.OperationID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, op)
+	})
+
+	mux.HandleFunc("/v1/Operations/List", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Type  string `json:"type"`
+			Done  *bool  `json:"done"`
+			Limit int    `json:"limit"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "operations")
+		ops, err := operationsService.ListOperations(ctx, operations.ListOperationsParams{
+			Type:  operations.OperationType(body.Type),
+			Done:  body.Done,
+			Limit: body.Limit,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct {
+			Operations []*operations.Operation `json:"operations"`
+		}{ops})
+	})
+
+	mux.HandleFunc("/v1/Operations/Cancel", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			OperationID string `json:"operation_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "operations")
+		if err := operationsService.CancelOperation(ctx, body.OperationID); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addAutomationsHandlers(mux *http.ServeMux, automationsService *automations.Service) {
+	l := logrus.WithField("component", "automations")
+
+	// Alertmanager webhook receiver, see alertmanager.automationsWebhookURL
+	mux.HandleFunc("/v1/management/ia/Automations/Webhook", func(rw http.ResponseWriter, req *http.Request) {
+		var msg automations.WebhookMessage
+		if !decodeJSONRequest(rw, req, &msg) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "automations")
+		res, err := automationsService.HandleWebhook(ctx, &msg)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct {
+			Results []*automations.Result `json:"results"`
+		}{res})
+	})
+
+	type request struct {
+		AutomationID string                   `json:"automation_id"`
+		Name         string                   `json:"name"`
+		Filters      models.Filters           `json:"filters"`
+		ActionType   string                   `json:"action_type"`
+		Params       *models.AutomationParams `json:"params"`
+		Disabled     bool                     `json:"disabled"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(withGrafanaCredentials(req), "automations")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/management/ia/Automations/List", func(ctx context.Context, r *request) (interface{}, error) {
+		automations, err := automationsService.ListAutomations(ctx)
+		return map[string]interface{}{"automations": automations}, err
+	})
+	handle("/v1/management/ia/Automations/Create", func(ctx context.Context, r *request) (interface{}, error) {
+		return automationsService.CreateAutomation(ctx, &models.CreateAutomationParams{
+			Name:       r.Name,
+			Filters:    r.Filters,
+			ActionType: models.AutomationActionType(r.ActionType),
+			Params:     r.Params,
+			Disabled:   r.Disabled,
+		})
+	})
+	handle("/v1/management/ia/Automations/Change", func(ctx context.Context, r *request) (interface{}, error) {
+		return automationsService.ChangeAutomation(ctx, r.AutomationID, r.Disabled)
+	})
+	handle("/v1/management/ia/Automations/Remove", func(ctx context.Context, r *request) (interface{}, error) {
+		return struct{}{}, automationsService.RemoveAutomation(ctx, r.AutomationID)
+	})
+}
+
+func addDashboardsHandlers(mux *http.ServeMux, dashboardsService *dashboards.Service) {
+	l := logrus.WithField("component", "dashboards")
+
+	type request struct {
+		ServiceID   string            `json:"service_id"`
+		DashboardID string            `json:"dashboard_id"`
+		Title       string            `json:"title"`
+		URL         string            `json:"url"`
+		Filters     models.Filters    `json:"filters"`
+		Labels      map[string]string `json:"labels"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "dashboards")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	// links for a single Service (like inventory Get) or for all Services keyed by Service ID (like inventory List)
+	handle("/v1/inventory/Services/Dashboards", func(ctx context.Context, r *request) (interface{}, error) {
+		if r.ServiceID != "" {
+			links, err := dashboardsService.ServiceLinks(ctx, r.ServiceID)
+			return map[string]interface{}{"links": links}, err
+		}
+		links, err := dashboardsService.ListServicesLinks(ctx)
+		return map[string]interface{}{"services": links}, err
+	})
+	handle("/v1/management/Dashboards/AlertLinks", func(ctx context.Context, r *request) (interface{}, error) {
+		links, err := dashboardsService.AlertLinks(ctx, r.Labels)
+		return map[string]interface{}{"links": links}, err
+	})
+	handle("/v1/management/Dashboards/List", func(ctx context.Context, r *request) (interface{}, error) {
+		dashboards, err := dashboardsService.ListDashboards(ctx)
+		return map[string]interface{}{"dashboards": dashboards}, err
+	})
+	handle("/v1/management/Dashboards/Create", func(ctx context.Context, r *request) (interface{}, error) {
+		return dashboardsService.CreateDashboard(ctx, &models.CreateDashboardParams{
+			Title:   r.Title,
+			URL:     r.URL,
+			Filters: r.Filters,
+		})
+	})
+	handle("/v1/management/Dashboards/Remove", func(ctx context.Context, r *request) (interface{}, error) {
+		return struct{}{}, dashboardsService.RemoveDashboard(ctx, r.DashboardID)
+	})
+}
+
+func addOwnershipHandlers(mux *http.ServeMux, nodesService *inventory.NodesService, servicesService *inventory.ServicesService) {
+	l := logrus.WithField("component", "ownership")
+
+	type request struct {
+		NodeID    string  `json:"node_id"`
+		ServiceID string  `json:"service_id"`
+		Notes     *string `json:"notes"`
+		Owner     *string `json:"owner"`
+		Contact   *string `json:"contact"`
+		Search    string  `json:"search"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "ownership")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/inventory/Nodes/ChangeOwnership", func(ctx context.Context, r *request) (interface{}, error) {
+		return nodesService.ChangeOwnership(ctx, r.NodeID, &models.ChangeOwnershipParams{
+			Notes:   r.Notes,
+			Owner:   r.Owner,
+			Contact: r.Contact,
+		})
+	})
+	handle("/v1/inventory/Services/ChangeOwnership", func(ctx context.Context, r *request) (interface{}, error) {
+		return servicesService.ChangeOwnership(ctx, r.ServiceID, &models.ChangeOwnershipParams{
+			Notes:   r.Notes,
+			Owner:   r.Owner,
+			Contact: r.Contact,
+		})
+	})
+	handle("/v1/inventory/Ownership/Search", func(ctx context.Context, r *request) (interface{}, error) {
+		nodes, err := nodesService.Search(ctx, r.Search)
+		if err != nil {
+			return nil, err
+		}
+		services, err := servicesService.Search(ctx, r.Search)
+		return map[string]interface{}{"nodes": nodes, "services": services}, err
+	})
+}
+
+func addRemovePreviewHandlers(mux *http.ServeMux, nodesService *inventory.NodesService, servicesService *inventory.ServicesService) {
+	l := logrus.WithField("component", "inventory/remove-preview")
+
+	type request struct {
+		NodeID    string `json:"node_id"`
+		ServiceID string `json:"service_id"`
+		Force     bool   `json:"force"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (*models.RemovalReport, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "remove-preview")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/inventory/Nodes/RemovePreview", func(ctx context.Context, r *request) (*models.RemovalReport, error) {
+		return nodesService.RemovePreview(ctx, r.NodeID, r.Force)
+	})
+	handle("/v1/inventory/Services/RemovePreview", func(ctx context.Context, r *request) (*models.RemovalReport, error) {
+		return servicesService.RemovePreview(ctx, r.ServiceID, r.Force)
+	})
+}
+
+func addNodeKubernetesMetadataHandler(mux *http.ServeMux, nodesService *inventory.NodesService) {
+	l := logrus.WithField("component", "kubernetes")
+
+	mux.HandleFunc("/v1/inventory/Nodes/ChangeKubernetesMetadata", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			NodeID string `json:"node_id"`
+			models.KubernetesMetadata
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "kubernetes")
+		res, err := nodesService.ChangeKubernetesMetadata(ctx, body.NodeID, &body.KubernetesMetadata)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addNodeFactsHandlers(mux *http.ServeMux, nodeFactsService *agents.NodeFactsService) {
+	l := logrus.WithField("component", "node-facts")
+
+	type request struct {
+		NodeID string `json:"node_id"`
+		Search string `json:"search"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "node-facts")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/inventory/Nodes/CollectFacts", func(ctx context.Context, r *request) (interface{}, error) {
+		actionID, err := nodeFactsService.CollectFacts(ctx, r.NodeID)
+		return map[string]string{"action_id": actionID}, err
+	})
+	handle("/v1/inventory/Nodes/GetFacts", func(ctx context.Context, r *request) (interface{}, error) {
+		facts, err := nodeFactsService.GetFacts(ctx, r.NodeID)
+		return map[string]interface{}{"facts": facts}, err
+	})
+	handle("/v1/inventory/Nodes/SearchFacts", func(ctx context.Context, r *request) (interface{}, error) {
+		nodes, err := nodeFactsService.Search(ctx, r.Search)
+		return map[string]interface{}{"nodes": nodes}, err
+	})
+}
+
+func addMongoDBDiscoveryHandlers(mux *http.ServeMux, mongoDBDiscovery *agents.MongoDBDiscoveryService) {
+	l := logrus.WithField("component", "mongodb-discovery")
+
+	type request struct {
+		ServiceID    string `json:"service_id"`
+		AutoRegister bool   `json:"auto_register"`
+		ActionID     string `json:"action_id"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "mongodb-discovery")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/management/MongoDB/DiscoverTopology", func(ctx context.Context, r *request) (interface{}, error) {
+		actionID, err := mongoDBDiscovery.Discover(ctx, r.ServiceID, r.AutoRegister)
+		return map[string]string{"action_id": actionID}, err
+	})
+	handle("/v1/management/MongoDB/GetTopologyDiscovery", func(ctx context.Context, r *request) (interface{}, error) {
+		discovery, err := mongoDBDiscovery.GetDiscovery(r.ActionID)
+		return map[string]interface{}{"discovery": discovery}, err
+	})
+}
+
+func addDatabaseAutodiscoveryHandlers(mux *http.ServeMux, autodiscovery *agents.DatabaseAutodiscoveryService) {
+	l := logrus.WithField("component", "database-autodiscovery")
+
+	type request struct {
+		ServiceID string `json:"service_id"`
+		// Nil resets settings to defaults.
+		Settings *models.DatabaseAutodiscovery `json:"settings"`
+	}
+
+	handle := func(path string, f func(context.Context, *request) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "database-autodiscovery")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/inventory/Services/GetDatabaseAutodiscovery", func(ctx context.Context, r *request) (interface{}, error) {
+		settings, err := autodiscovery.Get(ctx, r.ServiceID)
+		return map[string]interface{}{"settings": settings}, err
+	})
+	handle("/v1/inventory/Services/ChangeDatabaseAutodiscovery", func(ctx context.Context, r *request) (interface{}, error) {
+		settings, err := autodiscovery.Change(ctx, r.ServiceID, r.Settings)
+		return map[string]interface{}{"settings": settings}, err
+	})
+	handle("/v1/inventory/Services/ListDatabases", func(ctx context.Context, r *request) (interface{}, error) {
+		actionID, err := autodiscovery.ListDatabases(ctx, r.ServiceID)
+		return map[string]string{"action_id": actionID}, err
+	})
+}
+
+func addExpirationHandlers(mux *http.ServeMux, nodesService *inventory.NodesService, servicesService *inventory.ServicesService) {
+	l := logrus.WithField("component", "expiration")
+
+	type request struct {
+		NodeID    string `json:"node_id"`
+		ServiceID string `json:"service_id"`
+		// Go duration string like "12h"; empty or "0s" disables expiration.
+		TTL string `json:"ttl"`
+	}
+
+	handle := func(path string, f func(context.Context, *request, time.Duration) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body request
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+			var ttl time.Duration
+			if body.TTL != "" {
+				var err error
+				if ttl, err = time.ParseDuration(body.TTL); err != nil {
+					http.Error(rw, "invalid ttl: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			ctx := logger.Set(req.Context(), "expiration")
+			res, err := f(ctx, &body, ttl)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/inventory/Nodes/ChangeTTL", func(ctx context.Context, r *request, ttl time.Duration) (interface{}, error) {
+		return nodesService.ChangeTTL(ctx, r.NodeID, ttl)
+	})
+	handle("/v1/inventory/Services/ChangeTTL", func(ctx context.Context, r *request, ttl time.Duration) (interface{}, error) {
+		return servicesService.ChangeTTL(ctx, r.ServiceID, ttl)
+	})
+}
+
+func addMetricsResolutionsHandler(mux *http.ServeMux, servicesService *inventory.ServicesService, agentsService *inventory.AgentsService) {
+	l := logrus.WithField("component", "metrics-resolutions")
+
+	mux.HandleFunc("/v1/inventory/Services/ChangeMetricsResolutions", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID string `json:"service_id"`
+			// Go duration strings like "5s"; empty values mean global resolutions from settings.
+			HR string `json:"hr"`
+			MR string `json:"mr"`
+			LR string `json:"lr"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		var resolutions models.MetricsResolutions
+		for _, d := range []struct {
+			name  string
+			value string
+			res   *time.Duration
+		}{
+			{"hr", body.HR, &resolutions.HR},
+			{"mr", body.MR, &resolutions.MR},
+			{"lr", body.LR, &resolutions.LR},
+		} {
+			if d.value == "" {
+				continue
+			}
+			var err error
+			if *d.res, err = time.ParseDuration(d.value); err != nil {
+				http.Error(rw, "invalid "+d.name+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "metrics-resolutions")
+		res, err := servicesService.ChangeMetricsResolutions(ctx, body.ServiceID, &resolutions)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/inventory/Agents/ChangeMetricsResolutions", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			AgentID string `json:"agent_id"`
+			// "hr", "mr" or "lr" to scrape all exporter jobs with that resolution; empty means no tier.
+			Tier models.MetricsResolutionTier `json:"tier"`
+			// Go duration strings like "5s"; empty values mean Service or global resolutions.
+			HR string `json:"hr"`
+			MR string `json:"mr"`
+			LR string `json:"lr"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		var resolutions models.MetricsResolutions
+		for _, d := range []struct {
+			name  string
+			value string
+			res   *time.Duration
+		}{
+			{"hr", body.HR, &resolutions.HR},
+			{"mr", body.MR, &resolutions.MR},
+			{"lr", body.LR, &resolutions.LR},
+		} {
+			if d.value == "" {
+				continue
+			}
+			var err error
+			if *d.res, err = time.ParseDuration(d.value); err != nil {
+				http.Error(rw, "invalid "+d.name+": "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "metrics-resolutions")
+		agent, err := agentsService.ChangeMetricsResolutions(ctx, body.AgentID, body.Tier, &resolutions)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		// do not expose other Agent fields like credentials
+		res := struct {
+			AgentID               string                       `json:"agent_id"`
+			MetricsResolutions    *models.MetricsResolutions   `json:"metrics_resolutions,omitempty"`
+			MetricsResolutionTier models.MetricsResolutionTier `json:"metrics_resolution_tier,omitempty"`
+		}{agent.AgentID, agent.MetricsResolutions, agent.MetricsResolutionTier}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addMySQLQANSourceHandler(mux *http.ServeMux, mysqlService *management.MySQLService) {
+	l := logrus.WithField("component", "management/mysql")
+
+	mux.HandleFunc("/v1/management/MySQL/ChangeQANSource", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID            string               `json:"service_id"`
+			Source               management.QANSource `json:"source"`
+			MaxSlowlogFileSize   int64                `json:"max_slowlog_file_size"`
+			DisableQueryExamples *bool                `json:"disable_query_examples"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "mysql-qan-source")
+		agent, err := mysqlService.ChangeQANSource(ctx, &management.ChangeQANSourceParams{
+			ServiceID:            body.ServiceID,
+			Source:               body.Source,
+			MaxSlowlogFileSize:   body.MaxSlowlogFileSize,
+			DisableQueryExamples: body.DisableQueryExamples,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		var res interface{}
+		switch agent := agent.(type) {
+		case *inventorypb.QANMySQLPerfSchemaAgent:
+			res = map[string]interface{}{"qan_mysql_perfschema": agent}
+		case *inventorypb.QANMySQLSlowlogAgent:
+			res = map[string]interface{}{"qan_mysql_slowlog": agent}
+		default:
+			writeErrorResponse(rw, l, fmt.Errorf("unexpected Agent type %T", agent))
+			return
+		}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addExporterTLSHandler(mux *http.ServeMux, agentsService *inventory.AgentsService) {
+	l := logrus.WithField("component", "inventory/exporter-tls")
+
+	mux.HandleFunc("/v1/inventory/Agents/ChangeExporterTLS", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			AgentID string `json:"agent_id"`
+			// PEM-encoded certificates and key; all empty values mean scraping over HTTP.
+			models.ExporterTLSOptions
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "exporter-tls")
+		agent, err := agentsService.ChangeExporterTLS(ctx, body.AgentID, &body.ExporterTLSOptions)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		// do not expose other Agent fields like credentials, and TLS key
+		res := struct {
+			AgentID            string `json:"agent_id"`
+			TLS                bool   `json:"tls"`
+			ServerName         string `json:"server_name,omitempty"`
+			InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+		}{AgentID: agent.AgentID}
+		if agent.ExporterTLS != nil {
+			res.TLS = true
+			res.ServerName = agent.ExporterTLS.ServerName
+			res.InsecureSkipVerify = agent.ExporterTLS.InsecureSkipVerify
+		}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addAuditLogHandler(mux *http.ServeMux, auditService *management.AuditService) {
+	l := logrus.WithField("component", "audit")
+
+	mux.HandleFunc("/v1/management/AuditLog/List", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ObjectID string                `json:"object_id"`
+			Type     models.AuditEventType `json:"type"`
+			Limit    int                   `json:"limit"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "audit")
+		events, err := auditService.ListEvents(ctx, models.AuditEventsFilter{
+			ObjectID: body.ObjectID,
+			Type:     body.Type,
+			Limit:    body.Limit,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Events []*models.AuditEvent `json:"events"`
+		}{events}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addJobProgressHandler(mux *http.ServeMux, jobsService *management.JobsAPIService) {
+	l := logrus.WithField("component", "management/jobs")
+
+	mux.HandleFunc("/v1/management/Jobs/GetProgress", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			JobID string `json:"job_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "job-progress")
+		progress, err := jobsService.GetJobProgress(ctx, body.JobID)
+		writeJSONResult(rw, l, progress, err)
+	})
+}
+
+func addBatchGetStatusHandler(mux *http.ServeMux, statusService *management.StatusService) {
+	l := logrus.WithField("component", "status")
+
+	mux.HandleFunc("/v1/management/BatchGetStatus", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Objects []management.ObjectRef `json:"objects"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "status")
+		statuses, err := statusService.BatchGetStatus(ctx, body.Objects)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Statuses []*management.ObjectStatus `json:"statuses"`
+		}{statuses}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addAlertingEndpointsHandler(mux *http.ServeMux, server *server.Server) {
+	l := logrus.WithField("component", "alerting-endpoints")
+
+	mux.HandleFunc("/v1/Settings/ChangeAlertingEndpoints", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			VMAlert      *models.AlertingEndpoint `json:"vmalert"`
+			Alertmanager *models.AlertingEndpoint `json:"alertmanager"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "alerting-endpoints")
+		if err := server.ChangeAlertingEndpoints(ctx, body.VMAlert, body.Alertmanager); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addConfigDiffHandler(mux *http.ServeMux, vmdb *victoriametrics.Service) {
+	l := logrus.WithField("component", "victoriametrics")
+
+	mux.HandleFunc("/v1/management/VictoriaMetrics/GetConfigDiff", func(rw http.ResponseWriter, req *http.Request) {
+		diff, err := vmdb.ConfigDiff()
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, map[string]string{"diff": diff})
+	})
+}
+
+func addCardinalityEstimateHandler(mux *http.ServeMux, vmdb *victoriametrics.Service) {
+	l := logrus.WithField("component", "victoriametrics")
+
+	type agentCardinality struct {
+		AgentID    string         `json:"agent_id"`
+		AgentType  string         `json:"agent_type"`
+		ServiceID  string         `json:"service_id,omitempty"`
+		NodeID     string         `json:"node_id,omitempty"`
+		Series     int            `json:"series"`
+		Collectors map[string]int `json:"collectors,omitempty"`
+	}
+
+	type plannedCardinality struct {
+		AgentType string `json:"agent_type"`
+		Count     int    `json:"count"`
+		Series    int    `json:"series"`
+	}
+
+	mux.HandleFunc("/v1/management/VictoriaMetrics/EstimateCardinality", func(rw http.ResponseWriter, req *http.Request) {
+		// planned maps agent type to the number of exporters that are going to be added
+		var body struct {
+			Planned map[models.AgentType]int `json:"planned"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "victoriametrics")
+		estimate, err := vmdb.EstimateCardinality(ctx, body.Planned)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Agents      []*agentCardinality   `json:"agents"`
+			Planned     []*plannedCardinality `json:"planned"`
+			TotalSeries int                   `json:"total_series"`
+			MemoryBytes int64                 `json:"memory_bytes"`
+		}{
+			Agents:      make([]*agentCardinality, len(estimate.Agents)),
+			Planned:     make([]*plannedCardinality, len(estimate.Planned)),
+			TotalSeries: estimate.TotalSeries,
+			MemoryBytes: estimate.MemoryBytes,
+		}
+		for i, a := range estimate.Agents {
+			res.Agents[i] = &agentCardinality{
+				AgentID:    a.AgentID,
+				AgentType:  string(a.AgentType),
+				ServiceID:  a.ServiceID,
+				NodeID:     a.NodeID,
+				Series:     a.Series,
+				Collectors: a.Collectors,
+			}
+		}
+		for i, p := range estimate.Planned {
+			res.Planned[i] = &plannedCardinality{
+				AgentType: string(p.AgentType),
+				Count:     p.Count,
+				Series:    p.Series,
+			}
+		}
+
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addConfigStatusHandlers(mux *http.ServeMux, vmdb *victoriametrics.Service, server *server.Server) {
+	l := logrus.WithField("component", "victoriametrics")
+
+	type configStatus struct {
+		ReadOnly   bool   `json:"read_only"`
+		Path       string `json:"path"`
+		UpdatedAt  string `json:"updated_at,omitempty"`
+		ModifiedAt string `json:"modified_at,omitempty"`
+		LastError  string `json:"last_error,omitempty"`
+		Fresh      bool   `json:"fresh"`
+	}
+
+	mux.HandleFunc("/v1/management/VictoriaMetrics/GetConfigStatus", func(rw http.ResponseWriter, req *http.Request) {
+		s, err := vmdb.ConfigStatus()
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := &configStatus{
+			ReadOnly:  s.ReadOnly,
+			Path:      s.Path,
+			LastError: s.LastError,
+			Fresh:     s.Fresh,
+		}
+		if !s.UpdatedAt.IsZero() {
+			res.UpdatedAt = s.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		if !s.ModifiedAt.IsZero() {
+			res.ModifiedAt = s.ModifiedAt.UTC().Format(time.RFC3339)
+		}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/Settings/ChangeExternalConfigPath", func(rw http.ResponseWriter, req *http.Request) {
+		// empty path disables read-only external mode
+		var body struct {
+			Path string `json:"path"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "external-config")
+		if err := server.ChangeExternalConfigPath(ctx, body.Path); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addCustomScrapeConfigsHandlers(mux *http.ServeMux, vmdb *victoriametrics.Service) {
+	l := logrus.WithField("component", "custom-scrape-configs")
+
+	type customScrapeConfig struct {
+		ID          string            `json:"id"`
+		JobName     string            `json:"job_name"`
+		Targets     []string          `json:"targets"`
+		Labels      map[string]string `json:"labels,omitempty"`
+		Interval    string            `json:"interval,omitempty"`
+		MetricsPath string            `json:"metrics_path,omitempty"`
+		Scheme      string            `json:"scheme,omitempty"`
+		Username    string            `json:"username,omitempty"`
+	}
+
+	// password is never returned
+	convert := func(c *models.CustomScrapeConfig) *customScrapeConfig {
+		res := &customScrapeConfig{
+			ID:          c.ID,
+			JobName:     c.JobName,
+			Targets:     c.Targets,
+			Labels:      c.Labels,
+			MetricsPath: c.MetricsPath,
+			Scheme:      c.Scheme,
+			Username:    c.Username,
+		}
+		if c.Interval != 0 {
+			res.Interval = c.Interval.String()
+		}
+		return res
+	}
+
+	mux.HandleFunc("/v1/management/CustomScrapeConfigs/List", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "custom-scrape-configs")
+		configs, err := vmdb.ListCustomScrapeConfigs(ctx)
+		res := make([]*customScrapeConfig, len(configs))
+		for i, c := range configs {
+			res[i] = convert(c)
+		}
+		writeJSONResult(rw, l, struct {
+			ScrapeConfigs []*customScrapeConfig `json:"scrape_configs"`
+		}{res}, err)
+	})
+
+	mux.HandleFunc("/v1/management/CustomScrapeConfigs/Add", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			JobName string            `json:"job_name"`
+			Targets []string          `json:"targets"`
+			Labels  map[string]string `json:"labels"`
+			// Go duration, for example, "30s"; global scrape interval is used if empty.
+			Interval    string `json:"interval"`
+			MetricsPath string `json:"metrics_path"`
+			Scheme      string `json:"scheme"`
+			Username    string `json:"username"`
+			Password    string `json:"password"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		var interval time.Duration
+		if body.Interval != "" {
+			var err error
+			if interval, err = time.ParseDuration(body.Interval); err != nil {
+				http.Error(rw, "invalid interval: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "custom-scrape-configs")
+		c, err := vmdb.AddCustomScrapeConfig(ctx, &models.CreateCustomScrapeConfigParams{
+			JobName:     body.JobName,
+			Targets:     body.Targets,
+			Labels:      body.Labels,
+			Interval:    interval,
+			MetricsPath: body.MetricsPath,
+			Scheme:      body.Scheme,
+			Username:    body.Username,
+			Password:    body.Password,
+		})
+		if err != nil {
+			writeJSONResult(rw, l, nil, err)
+			return
+		}
+		writeJSONResult(rw, l, convert(c), nil)
+	})
+
+	mux.HandleFunc("/v1/management/CustomScrapeConfigs/Remove", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID string `json:"id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "custom-scrape-configs")
+		writeJSONResult(rw, l, struct{}{}, vmdb.RemoveCustomScrapeConfig(ctx, body.ID))
+	})
+}
+
+func addKubernetesScrapeConfigsHandlers(mux *http.ServeMux, vmdb *victoriametrics.Service) {
+	l := logrus.WithField("component", "kubernetes-scrape-configs")
+
+	type tlsOptions struct {
+		CA                 string `json:"ca,omitempty"`
+		Cert               string `json:"cert,omitempty"`
+		Key                string `json:"key,omitempty"`
+		ServerName         string `json:"server_name,omitempty"`
+		InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	}
+	type kubernetesScrapeConfig struct {
+		ID                    string   `json:"id"`
+		JobName               string   `json:"job_name"`
+		KubernetesClusterName string   `json:"kubernetes_cluster_name"`
+		Role                  string   `json:"role"`
+		Namespaces            []string `json:"namespaces,omitempty"`
+		LabelSelector         string   `json:"label_selector,omitempty"`
+		Interval              string   `json:"interval,omitempty"`
+		MetricsPath           string   `json:"metrics_path,omitempty"`
+		Scheme                string   `json:"scheme,omitempty"`
+		// TLS key is never returned
+		TLS *tlsOptions `json:"tls,omitempty"`
+	}
+
+	convert := func(c *models.KubernetesScrapeConfig) *kubernetesScrapeConfig {
+		res := &kubernetesScrapeConfig{
+			ID:                    c.ID,
+			JobName:               c.JobName,
+			KubernetesClusterName: c.KubernetesClusterName,
+			Role:                  c.Role,
+			Namespaces:            c.Namespaces,
+			LabelSelector:         c.LabelSelector,
+			MetricsPath:           c.MetricsPath,
+			Scheme:                c.Scheme,
+		}
+		if c.Interval != 0 {
+			res.Interval = c.Interval.String()
+		}
+		if c.TLS != nil {
+			res.TLS = &tlsOptions{
+				CA:                 c.TLS.CA,
+				Cert:               c.TLS.Cert,
+				ServerName:         c.TLS.ServerName,
+				InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+			}
+		}
+		return res
+	}
+
+	mux.HandleFunc("/v1/management/KubernetesScrapeConfigs/List", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "kubernetes-scrape-configs")
+		configs, err := vmdb.ListKubernetesScrapeConfigs(ctx)
+		res := make([]*kubernetesScrapeConfig, len(configs))
+		for i, c := range configs {
+			res[i] = convert(c)
+		}
+		writeJSONResult(rw, l, struct {
+			ScrapeConfigs []*kubernetesScrapeConfig `json:"scrape_configs"`
+		}{res}, err)
+	})
+
+	mux.HandleFunc("/v1/management/KubernetesScrapeConfigs/Add", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			JobName               string   `json:"job_name"`
+			KubernetesClusterName string   `json:"kubernetes_cluster_name"`
+			Role                  string   `json:"role"`
+			Namespaces            []string `json:"namespaces"`
+			// Equality-based Kubernetes label selector, for example, "app=mysql,tier!=proxy".
+			LabelSelector string `json:"label_selector"`
+			// Go duration, for example, "30s"; global scrape interval is used if empty.
+			Interval    string      `json:"interval"`
+			MetricsPath string      `json:"metrics_path"`
+			Scheme      string      `json:"scheme"`
+			TLS         *tlsOptions `json:"tls"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		var interval time.Duration
+		if body.Interval != "" {
+			var err error
+			if interval, err = time.ParseDuration(body.Interval); err != nil {
+				http.Error(rw, "invalid interval: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		params := &models.CreateKubernetesScrapeConfigParams{
+			JobName:               body.JobName,
+			KubernetesClusterName: body.KubernetesClusterName,
+			Role:                  body.Role,
+			Namespaces:            body.Namespaces,
+			LabelSelector:         body.LabelSelector,
+			Interval:              interval,
+			MetricsPath:           body.MetricsPath,
+			Scheme:                body.Scheme,
+		}
+		if body.TLS != nil {
+			params.TLS = &models.ExporterTLSOptions{
+				CA:                 body.TLS.CA,
+				Cert:               body.TLS.Cert,
+				Key:                body.TLS.Key,
+				ServerName:         body.TLS.ServerName,
+				InsecureSkipVerify: body.TLS.InsecureSkipVerify,
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "kubernetes-scrape-configs")
+		c, err := vmdb.AddKubernetesScrapeConfig(ctx, params)
+		if err != nil {
+			writeJSONResult(rw, l, nil, err)
+			return
+		}
+		writeJSONResult(rw, l, convert(c), nil)
+	})
+
+	mux.HandleFunc("/v1/management/KubernetesScrapeConfigs/Remove", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ID string `json:"id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "kubernetes-scrape-configs")
+		writeJSONResult(rw, l, struct{}{}, vmdb.RemoveKubernetesScrapeConfig(ctx, body.ID))
+	})
+}
+
+func addRemoteWriteHandler(mux *http.ServeMux, server *server.Server) {
+	l := logrus.WithField("component", "remote-write")
+
+	mux.HandleFunc("/v1/Settings/ChangeRemoteWrite", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// Replaces all configured endpoints; empty list disables remote write.
+			Endpoints []*models.RemoteWriteEndpoint `json:"endpoints"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "remote-write")
+		if err := server.ChangeRemoteWrite(ctx, body.Endpoints); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addExternalVictoriaMetricsHandler(mux *http.ServeMux, server *server.Server) {
+	l := logrus.WithField("component", "external-victoriametrics")
+
+	mux.HandleFunc("/v1/Settings/ChangeExternalVictoriaMetrics", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// nil switches back to local VictoriaMetrics.
+			External *models.ExternalVictoriaMetrics `json:"external"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "external-victoriametrics")
+		if err := server.ChangeExternalVictoriaMetrics(ctx, body.External); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addExternalLabelsHandlers(mux *http.ServeMux, server *server.Server) {
+	l := logrus.WithField("component", "external-labels")
+
+	type externalLabels struct {
+		Labels map[string]string `json:"labels"`
+	}
+
+	mux.HandleFunc("/v1/Settings/GetExternalLabels", func(rw http.ResponseWriter, req *http.Request) {
+		labels, err := server.GetExternalLabels(req.Context())
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, &externalLabels{Labels: labels})
+	})
+
+	mux.HandleFunc("/v1/Settings/ChangeExternalLabels", func(rw http.ResponseWriter, req *http.Request) {
+		// empty labels remove all external labels
+		var body externalLabels
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "external-labels")
+		if err := server.ChangeExternalLabels(ctx, body.Labels); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addChannelThrottlingHandler(mux *http.ServeMux, channelsService *ia.ChannelsService) {
+	l := logrus.WithField("component", "management/ia/channels")
+
+	mux.HandleFunc("/v1/management/ia/Channels/ChangeThrottling", func(rw http.ResponseWriter, req *http.Request) {
+		// durations are Go duration strings like "1h"; null or missing throttling disables it
+		var body struct {
+			ChannelID  string `json:"channel_id"`
+			Throttling *struct {
+				MaxNotifications uint32 `json:"max_notifications"`
+				Interval         string `json:"interval"`
+				DigestSeverity   string `json:"digest_severity"`
+				DigestInterval   string `json:"digest_interval"`
+			} `json:"throttling"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		var throttling *models.ChannelThrottling
+		if t := body.Throttling; t != nil {
+			throttling = &models.ChannelThrottling{
+				MaxNotifications: t.MaxNotifications,
+				DigestSeverity:   t.DigestSeverity,
+			}
+			var err error
+			if t.Interval != "" {
+				if throttling.Interval, err = time.ParseDuration(t.Interval); err != nil {
+					http.Error(rw, "invalid interval: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if t.DigestInterval != "" {
+				if throttling.DigestInterval, err = time.ParseDuration(t.DigestInterval); err != nil {
+					http.Error(rw, "invalid digest_interval: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+
+		ctx := logger.Set(req.Context(), "channels")
+		if err := channelsService.ChangeChannelThrottling(ctx, body.ChannelID, throttling); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addRulesFilesHandlers(mux *http.ServeMux, vmalert *vmalert.Service) {
+	l := logrus.WithField("component", "vmalert")
+
+	type rulesFile struct {
+		Name  string `json:"name"`
+		Rules string `json:"rules,omitempty"`
+	}
+
+	type rulesFileInfo struct {
+		Name       string    `json:"name"`
+		Size       int64     `json:"size"`
+		ModifiedAt time.Time `json:"modified_at"`
+	}
+
+	handle := func(path string, f func(context.Context, *rulesFile) (interface{}, error)) {
+		mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+			var body rulesFile
+			if !decodeJSONRequest(rw, req, &body) {
+				return
+			}
+
+			ctx := logger.Set(req.Context(), "vmalert")
+			res, err := f(ctx, &body)
+			if err != nil {
+				writeErrorResponse(rw, l, err)
+				return
+			}
+
+			writeJSONResponse(rw, l, res)
+		})
+	}
+
+	handle("/v1/management/ia/RulesFiles/List", func(ctx context.Context, _ *rulesFile) (interface{}, error) {
+		files, err := vmalert.ListRulesFiles()
+		if err != nil {
+			return nil, err
+		}
+		res := make([]*rulesFileInfo, len(files))
+		for i, f := range files {
+			res[i] = &rulesFileInfo{Name: f.Name, Size: f.Size, ModifiedAt: f.ModifiedAt}
+		}
+		return map[string]interface{}{"files": res}, nil
+	})
+
+	handle("/v1/management/ia/RulesFiles/Get", func(ctx context.Context, body *rulesFile) (interface{}, error) {
+		rules, err := vmalert.GetRulesFile(body.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &rulesFile{Name: body.Name, Rules: rules}, nil
+	})
+
+	// validates rules and replaces existing file with the same name, if any
+	handle("/v1/management/ia/RulesFiles/Put", func(ctx context.Context, body *rulesFile) (interface{}, error) {
+		return struct{}{}, vmalert.PutRulesFile(ctx, body.Name, body.Rules)
+	})
+
+	handle("/v1/management/ia/RulesFiles/Delete", func(ctx context.Context, body *rulesFile) (interface{}, error) {
+		return struct{}{}, vmalert.DeleteRulesFile(body.Name)
+	})
+}
+
+func addBackupLimitsHandlers(mux *http.ServeMux, srv *server.Server) {
+	l := logrus.WithField("component", "backup-limits")
+
+	// job_timeout is Go duration string like "10m"
+	type limits struct {
+		MaxParallelJobs         *int    `json:"max_parallel_jobs"`
+		MaxParallelJobsPerAgent *int    `json:"max_parallel_jobs_per_agent"`
+		MaxParallelJobsPerNode  *int    `json:"max_parallel_jobs_per_node"`
+		AllowConcurrentRestore  *bool   `json:"allow_concurrent_restore"`
+		JobTimeout              *string `json:"job_timeout"`
+	}
+
+	mux.HandleFunc("/v1/Settings/GetBackupLimits", func(rw http.ResponseWriter, req *http.Request) {
+		res, err := srv.GetBackupLimits(req.Context())
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, &limits{
+			MaxParallelJobs:         &res.MaxParallelJobs,
+			MaxParallelJobsPerAgent: &res.MaxParallelJobsPerAgent,
+			MaxParallelJobsPerNode:  &res.MaxParallelJobsPerNode,
+			AllowConcurrentRestore:  &res.AllowConcurrentRestore,
+			JobTimeout:              pointer.ToString(res.JobTimeout.String()),
+		})
+	})
+
+	mux.HandleFunc("/v1/Settings/ChangeBackupLimits", func(rw http.ResponseWriter, req *http.Request) {
+		// absent fields keep current values
+		var body limits
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		params := &server.ChangeBackupLimitsParams{
+			MaxParallelJobs:         body.MaxParallelJobs,
+			MaxParallelJobsPerAgent: body.MaxParallelJobsPerAgent,
+			MaxParallelJobsPerNode:  body.MaxParallelJobsPerNode,
+			AllowConcurrentRestore:  body.AllowConcurrentRestore,
+		}
+		if body.JobTimeout != nil {
+			jobTimeout, err := time.ParseDuration(*body.JobTimeout)
+			if err != nil {
+				http.Error(rw, "invalid job_timeout: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			params.JobTimeout = &jobTimeout
+		}
+
+		ctx := logger.Set(req.Context(), "backup-limits")
+		if err := srv.ChangeBackupLimits(ctx, params); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addSIEMHandlers(mux *http.ServeMux, forwarder *siem.Forwarder, server *server.Server) {
+	l := logrus.WithField("component", "siem")
+
+	// Alertmanager webhook receiver
+	mux.HandleFunc("/v1/management/ia/SIEM/Webhook", func(rw http.ResponseWriter, req *http.Request) {
+		var msg automations.WebhookMessage
+		if !decodeJSONRequest(rw, req, &msg) {
+			return
+		}
+
+		forwarder.HandleAlertmanagerWebhook(&msg)
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+
+	mux.HandleFunc("/v1/Settings/ChangeSIEM", func(rw http.ResponseWriter, req *http.Request) {
+		// null or missing siem disables forwarding
+		var body struct {
+			SIEM *models.SIEMSettings `json:"siem"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "siem")
+		if err := server.ChangeSIEM(ctx, body.SIEM); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addFileSDHandler(mux *http.ServeMux, server *server.Server) {
+	l := logrus.WithField("component", "file-sd")
+
+	mux.HandleFunc("/v1/Settings/ChangeFileSD", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Enabled bool `json:"enabled"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "file-sd")
+		if err := server.ChangeFileSD(ctx, body.Enabled); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addRetentionHandlers(mux *http.ServeMux, server *server.Server) {
+	l := logrus.WithField("component", "retention")
+
+	// durations are Go duration strings like "720h"
+	type period struct {
+		Offset   string `json:"offset"`
+		Interval string `json:"interval"`
+	}
+	type retention struct {
+		DataRetention string    `json:"data_retention"`
+		Downsampling  []*period `json:"downsampling"`
+	}
+
+	mux.HandleFunc("/v1/Settings/GetRetention", func(rw http.ResponseWriter, req *http.Request) {
+		dataRetention, downsampling, err := server.GetRetention(req.Context())
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := &retention{
+			DataRetention: dataRetention.String(),
+			Downsampling:  make([]*period, len(downsampling)),
+		}
+		for i, p := range downsampling {
+			res.Downsampling[i] = &period{Offset: p.Offset.String(), Interval: p.Interval.String()}
+		}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/Settings/ChangeRetention", func(rw http.ResponseWriter, req *http.Request) {
+		// empty data_retention keeps the current value; empty downsampling list disables downsampling
+		var body retention
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		var dataRetention time.Duration
+		if body.DataRetention != "" {
+			var err error
+			if dataRetention, err = time.ParseDuration(body.DataRetention); err != nil {
+				http.Error(rw, "invalid data_retention: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		downsampling := make([]*models.DownsamplingPeriod, len(body.Downsampling))
+		for i, p := range body.Downsampling {
+			if p == nil {
+				http.Error(rw, "invalid downsampling: empty period", http.StatusBadRequest)
+				return
+			}
+			offset, err := time.ParseDuration(p.Offset)
+			if err != nil {
+				http.Error(rw, "invalid downsampling offset: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			interval, err := time.ParseDuration(p.Interval)
+			if err != nil {
+				http.Error(rw, "invalid downsampling interval: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			downsampling[i] = &models.DownsamplingPeriod{Offset: offset, Interval: interval}
+		}
+
+		ctx := logger.Set(req.Context(), "retention")
+		if err := server.ChangeRetention(ctx, dataRetention, downsampling); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addHealthHistoryHandler(mux *http.ServeMux, watchdogService *watchdog.Watchdog) {
+	l := logrus.WithField("component", "health-history")
+
+	mux.HandleFunc("/v1/Health/History", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// Return only incidents of that component.
+			Component string `json:"component"`
+			// Return only incidents since that time.
+			Since time.Time `json:"since"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		res := struct {
+			Incidents []*watchdog.Incident `json:"incidents"`
+		}{watchdogService.History(body.Component, body.Since)}
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addSelfTestHandler(mux *http.ServeMux, selfTestService *selftest.Service) {
+	l := logrus.WithField("component", "selftest")
+
+	mux.HandleFunc("/v1/Server/RunSelfTest", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			// Number of randomly chosen connected pmm-agents to ping.
+			AgentsSample int `json:"agents_sample"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "selftest")
+		res, err := selfTestService.RunSelfTest(ctx, &selftest.Params{AgentsSample: body.AgentsSample})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addManagedFilesHandlers(mux *http.ServeMux, managedFiles *managedfiles.Service) {
+	l := logrus.WithField("component", "managed-files")
+
+	mux.HandleFunc("/v1/Server/ListManagedFiles", func(rw http.ResponseWriter, req *http.Request) {
+		names, err := managedFiles.List()
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Names []string `json:"names"`
+		}{names}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/Server/GetManagedFile", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		file, err := managedFiles.Get(body.Name)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, file)
+	})
+}
+
+func addDBaaSRestoreHandlers(mux *http.ServeMux, restoreService *managementdbaas.RestoreService) {
+	l := logrus.WithField("component", "dbaas_restore")
+
+	mux.HandleFunc("/v1/management/DBaaS/RestoreToNewCluster", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ArtifactID    string                                   `json:"artifact_id"`
+			XtraDBCluster *dbaasv1beta1.CreateXtraDBClusterRequest `json:"xtradb_cluster"`
+			PSMDBCluster  *dbaasv1beta1.CreatePSMDBClusterRequest  `json:"psmdb_cluster"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "dbaas-restore")
+		restoreID, err := restoreService.RestoreToNewCluster(ctx, &managementdbaas.RestoreToNewClusterParams{
+			ArtifactID:    body.ArtifactID,
+			XtraDBCluster: body.XtraDBCluster,
+			PSMDBCluster:  body.PSMDBCluster,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			RestoreID string `json:"restore_id"`
+		}{restoreID}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/DBaaS/GetNewClusterRestore", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			RestoreID string `json:"restore_id"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "dbaas-restore")
+		restore, err := restoreService.GetNewClusterRestore(ctx, body.RestoreID)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, restore)
+	})
+}
+
+func addUsageHandlers(mux *http.ServeMux, usageService *usage.Service) {
+	l := logrus.WithField("component", "usage")
+
+	mux.HandleFunc("/v1/management/Usage/GetReport", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			From time.Time `json:"from"`
+			To   time.Time `json:"to"`
+			// "json" (default) or "csv".
+			Format string `json:"format"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+		if body.Format != "" && body.Format != "json" && body.Format != "csv" {
+			http.Error(rw, "invalid request: unsupported format "+body.Format, http.StatusBadRequest)
+			return
+		}
+
+		snapshots, err := usageService.GetUsageReport(req.Context(), body.From, body.To)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		if body.Format == "csv" {
+			rw.Header().Set(`Content-Type`, `text/csv`)
+			rw.Header().Set(`Content-Disposition`, `attachment; filename="usage.csv"`)
+			if err = usage.WriteCSV(rw, snapshots); err != nil {
+				l.Errorf("%+v", err)
+			}
+			return
+		}
+
+		res := struct {
+			Snapshots []*models.UsageSnapshot `json:"snapshots"`
+		}{snapshots}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/Usage/ChangeSettings", func(rw http.ResponseWriter, req *http.Request) {
+		var body models.UsageSettings
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		if err := usageService.ChangeSettings(req.Context(), &body); err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct{}{})
+	})
+}
+
+func addGrantsHandlers(mux *http.ServeMux, connectionCheck *agents.ConnectionChecker) {
+	l := logrus.WithField("component", "grants")
+
+	mux.HandleFunc("/v1/management/GetRequiredGrants", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceType models.ServiceType `json:"service_type"`
+			Username    string             `json:"username"`
+			agents.GrantsFeatures
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		statements, err := agents.RequiredGrants(body.ServiceType, body.Username, body.GrantsFeatures)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		res := struct {
+			Statements []string `json:"statements"`
+		}{statements}
+		writeJSONResponse(rw, l, res)
+	})
+
+	mux.HandleFunc("/v1/management/CheckGrants", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			ServiceID string `json:"service_id"`
+			agents.GrantsFeatures
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "grants")
+		res, err := connectionCheck.CheckGrants(ctx, body.ServiceID, body.GrantsFeatures)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addAgentsDriftHandler(mux *http.ServeMux, agentsDrift *agents.DriftReconciler) {
+	l := logrus.WithField("component", "agents/drift")
+
+	mux.HandleFunc("/v1/inventory/Agents/Drift", func(rw http.ResponseWriter, req *http.Request) {
+		res := struct {
+			Agents []*agents.AgentDrift `json:"agents"`
+		}{agentsDrift.Drift()}
+
+		writeJSONResponse(rw, l, res)
+	})
+}
+
+func addBulkChangeLabelsHandler(mux *http.ServeMux, labelsService *inventory.LabelsService) {
+	l := logrus.WithField("component", "inventory/labels")
+
+	mux.HandleFunc("/v1/inventory/Labels/BulkChange", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Selector    models.Filters            `json:"selector"`
+			ObjectTypes []models.LabelsObjectType `json:"object_types"`
+			Add         map[string]string         `json:"add"`
+			Remove      []string                  `json:"remove"`
+			Rename      map[string]string         `json:"rename"`
+			DryRun      bool                      `json:"dry_run"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "bulk-change-labels")
+		res, report, err := labelsService.BulkChangeLabels(ctx, &models.BulkChangeLabelsParams{
+			Selector:    body.Selector,
+			ObjectTypes: body.ObjectTypes,
+			Add:         body.Add,
+			Remove:      body.Remove,
+			Rename:      body.Rename,
+		}, body.DryRun)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct {
+			*models.BulkChangeLabelsResult
+			Sandbox *sandbox.Report `json:"sandbox,omitempty"`
+		}{res, report})
+	})
+}
+
+func addBulkChangeLabelsProgressHandler(mux *http.ServeMux, labelsService *inventory.LabelsService) {
+	l := logrus.WithField("component", "inventory/labels")
+
+	mux.HandleFunc("/v1/inventory/Labels/BulkChangeProgress", func(rw http.ResponseWriter, req *http.Request) {
+		writeJSONResponse(rw, l, labelsService.Progress())
+	})
+}
+
+func addLabelValuesHandler(mux *http.ServeMux, labelValuesService *inventory.LabelValuesService) {
+	l := logrus.WithField("component", "inventory/label-values")
+
+	mux.HandleFunc("/v1/inventory/Labels/ListValues", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Label    string            `json:"label"`
+			Matchers map[string]string `json:"matchers"`
+		}
+		if !decodeJSONRequest(rw, req, &body) {
+			return
+		}
+
+		ctx := logger.Set(req.Context(), "list-label-values")
+		values, err := labelValuesService.ListLabelValues(ctx, &models.LabelValuesParams{
+			Label:    body.Label,
+			Matchers: body.Matchers,
+		})
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, struct {
+			Values []string `json:"values"`
+		}{
+			Values: values,
+		})
+	})
+}
+
+func addSandboxHandler(mux *http.ServeMux, sandboxService *sandbox.Service) {
+	l := logrus.WithField("component", "sandbox")
+
+	mux.HandleFunc("/v1/management/Sandbox/Validate", func(rw http.ResponseWriter, req *http.Request) {
+		ctx := logger.Set(req.Context(), "sandbox")
+		report, err := sandboxService.Run(ctx, nil)
+		if err != nil {
+			writeErrorResponse(rw, l, err)
+			return
+		}
+
+		writeJSONResponse(rw, l, report)
+	})
+}
@@ -0,0 +1,70 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP1Handlers(t *testing.T) {
+	mux := http.NewServeMux()
+	addHTTP1Handlers(mux, new(http1ServerDeps))
+
+	t.Run("BackupDetails", func(t *testing.T) {
+		for _, path := range []string{
+			"/v1/management/backup/Artifacts/ListDetails",
+			"/v1/management/backup/Backups/ListScheduledDetails",
+			"/v1/management/backup/Swagger",
+		} {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			_, pattern := mux.Handler(req)
+			assert.Equal(t, path, pattern)
+		}
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/management/backup/Swagger", nil))
+		require.Equal(t, http.StatusOK, rec.Code, "%s", rec.Body)
+
+		var spec struct {
+			Definitions map[string]interface{} `json:"definitions"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+		assert.Contains(t, spec.Definitions, "ArtifactDetails")
+		assert.Contains(t, spec.Definitions, "ScheduledBackupDetails")
+	})
+	t.Run("BackupDataModel", func(t *testing.T) {
+		for _, path := range []string{
+			"/v1/management/backup/Backups/Start",
+			"/v1/management/backup/Backups/Schedule",
+		} {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			_, pattern := mux.Handler(req)
+			assert.Equal(t, path, pattern)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"data_model": 1}`)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code, "%s", rec.Body)
+		}
+	})
+}
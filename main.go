@@ -66,27 +66,40 @@ import (
 	"github.com/percona/pmm-managed/services/agents"
 	agentgrpc "github.com/percona/pmm-managed/services/agents/grpc"
 	"github.com/percona/pmm-managed/services/alertmanager"
+	"github.com/percona/pmm-managed/services/automations"
 	"github.com/percona/pmm-managed/services/backup"
+	"github.com/percona/pmm-managed/services/capacity"
 	"github.com/percona/pmm-managed/services/checks"
+	"github.com/percona/pmm-managed/services/cleanup"
+	"github.com/percona/pmm-managed/services/dashboards"
 	"github.com/percona/pmm-managed/services/dbaas"
 	"github.com/percona/pmm-managed/services/grafana"
 	"github.com/percona/pmm-managed/services/inventory"
 	inventorygrpc "github.com/percona/pmm-managed/services/inventory/grpc"
+	"github.com/percona/pmm-managed/services/managedfiles"
 	"github.com/percona/pmm-managed/services/management"
 	managementbackup "github.com/percona/pmm-managed/services/management/backup"
 	managementdbaas "github.com/percona/pmm-managed/services/management/dbaas"
 	managementgrpc "github.com/percona/pmm-managed/services/management/grpc"
 	"github.com/percona/pmm-managed/services/management/ia"
 	"github.com/percona/pmm-managed/services/minio"
+	"github.com/percona/pmm-managed/services/operations"
 	"github.com/percona/pmm-managed/services/platform"
 	"github.com/percona/pmm-managed/services/qan"
+	"github.com/percona/pmm-managed/services/reports"
+	"github.com/percona/pmm-managed/services/sandbox"
 	"github.com/percona/pmm-managed/services/scheduler"
+	"github.com/percona/pmm-managed/services/selftest"
 	"github.com/percona/pmm-managed/services/server"
+	"github.com/percona/pmm-managed/services/siem"
 	"github.com/percona/pmm-managed/services/supervisord"
 	"github.com/percona/pmm-managed/services/telemetry"
+	"github.com/percona/pmm-managed/services/topology"
+	"github.com/percona/pmm-managed/services/usage"
 	"github.com/percona/pmm-managed/services/versioncache"
 	"github.com/percona/pmm-managed/services/victoriametrics"
 	"github.com/percona/pmm-managed/services/vmalert"
+	"github.com/percona/pmm-managed/services/watchdog"
 	"github.com/percona/pmm-managed/utils/clean"
 	"github.com/percona/pmm-managed/utils/interceptors"
 	"github.com/percona/pmm-managed/utils/logger"
@@ -102,6 +115,8 @@ const (
 
 	cleanInterval  = 10 * time.Minute
 	cleanOlderThan = 30 * time.Minute
+
+	replicaCheckInterval = 5 * time.Second
 )
 
 func addLogsHandler(mux *http.ServeMux, logs *supervisord.Logs) {
@@ -136,19 +151,23 @@ type gRPCServerDeps struct {
 	grafanaClient        *grafana.Client
 	checksService        *checks.Service
 	dbaasClient          *dbaas.Client
-	alertmanager         *alertmanager.Service
 	vmalert              *vmalert.Service
 	settings             *models.Settings
 	alertsService        *ia.AlertsService
 	templatesService     *ia.TemplatesService
 	rulesService         *ia.RulesService
-	jobsService          *agents.JobsService
 	versionServiceClient *managementdbaas.VersionServiceClient
-	schedulerService     *scheduler.Service
-	backupService        *backup.Service
-	backupRemovalService *backup.RemovalService
-	minioService         *minio.Service
-	versionCache         *versioncache.Service
+	nodesService         *inventory.NodesService
+	servicesService      *inventory.ServicesService
+	agentsService        *inventory.AgentsService
+	mysqlService         *management.MySQLService
+	externalService      *management.ExternalService
+	jobsAPIService       *management.JobsAPIService
+	channelsService      *ia.ChannelsService
+	backupsService       *managementbackup.BackupsService
+	locationsService     *managementbackup.LocationsService
+	artifactsService     *managementbackup.ArtifactsService
+	restoreHistory       *managementbackup.RestoreHistoryService
 }
 
 // runGRPCServer runs gRPC server until context is canceled, then gracefully stops it.
@@ -160,11 +179,13 @@ func runGRPCServer(ctx context.Context, deps *gRPCServerDeps) {
 		grpc.MaxRecvMsgSize(gRPCMessageMaxSize),
 
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+			interceptors.UnaryLocalizationInterceptor(),
 			interceptors.Unary,
 			interceptors.UnaryServiceEnabledInterceptor(),
 			grpc_validator.UnaryServerInterceptor(),
 		)),
 		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
+			interceptors.StreamLocalizationInterceptor(),
 			interceptors.Stream,
 			interceptors.StreamServiceEnabledInterceptor(),
 			grpc_validator.StreamServerInterceptor(),
@@ -175,24 +196,19 @@ func runGRPCServer(ctx context.Context, deps *gRPCServerDeps) {
 
 	agentpb.RegisterAgentServer(gRPCServer, agentgrpc.NewAgentServer(deps.handler))
 
-	nodesSvc := inventory.NewNodesService(deps.db, deps.agentsRegistry, deps.agentsStateUpdater, deps.vmdb)
-	servicesSvc := inventory.NewServicesService(deps.db, deps.agentsRegistry, deps.agentsStateUpdater, deps.vmdb, deps.versionCache)
-	agentsSvc := inventory.NewAgentsService(deps.db, deps.agentsRegistry, deps.agentsStateUpdater, deps.vmdb, deps.connectionCheck)
-
-	inventorypb.RegisterNodesServer(gRPCServer, inventorygrpc.NewNodesServer(nodesSvc))
-	inventorypb.RegisterServicesServer(gRPCServer, inventorygrpc.NewServicesServer(servicesSvc))
-	inventorypb.RegisterAgentsServer(gRPCServer, inventorygrpc.NewAgentsServer(agentsSvc))
+	inventorypb.RegisterNodesServer(gRPCServer, inventorygrpc.NewNodesServer(deps.nodesService))
+	inventorypb.RegisterServicesServer(gRPCServer, inventorygrpc.NewServicesServer(deps.servicesService))
+	inventorypb.RegisterAgentsServer(gRPCServer, inventorygrpc.NewAgentsServer(deps.agentsService))
 
 	nodeSvc := management.NewNodeService(deps.db)
 	serviceSvc := management.NewServiceService(deps.db, deps.agentsStateUpdater, deps.vmdb)
-	mysqlSvc := management.NewMySQLService(deps.db, deps.agentsStateUpdater, deps.connectionCheck, deps.versionCache)
 	mongodbSvc := management.NewMongoDBService(deps.db, deps.agentsStateUpdater, deps.connectionCheck)
 	postgresqlSvc := management.NewPostgreSQLService(deps.db, deps.agentsStateUpdater, deps.connectionCheck)
 	proxysqlSvc := management.NewProxySQLService(deps.db, deps.agentsStateUpdater, deps.connectionCheck)
 
 	managementpb.RegisterNodeServer(gRPCServer, managementgrpc.NewManagementNodeServer(nodeSvc))
 	managementpb.RegisterServiceServer(gRPCServer, managementgrpc.NewManagementServiceServer(serviceSvc))
-	managementpb.RegisterMySQLServer(gRPCServer, managementgrpc.NewManagementMySQLServer(mysqlSvc))
+	managementpb.RegisterMySQLServer(gRPCServer, managementgrpc.NewManagementMySQLServer(deps.mysqlService))
 	managementpb.RegisterMongoDBServer(gRPCServer, managementgrpc.NewManagementMongoDBServer(mongodbSvc))
 	managementpb.RegisterPostgreSQLServer(gRPCServer, managementgrpc.NewManagementPostgreSQLServer(postgresqlSvc))
 	managementpb.RegisterProxySQLServer(gRPCServer, managementgrpc.NewManagementProxySQLServer(proxysqlSvc))
@@ -200,21 +216,21 @@ func runGRPCServer(ctx context.Context, deps *gRPCServerDeps) {
 	managementpb.RegisterRDSServer(gRPCServer, management.NewRDSService(deps.db, deps.agentsStateUpdater, deps.connectionCheck))
 	azurev1beta1.RegisterAzureDatabaseServer(gRPCServer, management.NewAzureDatabaseService(deps.db, deps.agentsRegistry, deps.agentsStateUpdater, deps.connectionCheck))
 	managementpb.RegisterHAProxyServer(gRPCServer, management.NewHAProxyService(deps.db, deps.vmdb, deps.agentsStateUpdater, deps.connectionCheck))
-	managementpb.RegisterExternalServer(gRPCServer, management.NewExternalService(deps.db, deps.vmdb, deps.agentsStateUpdater, deps.connectionCheck))
+	managementpb.RegisterExternalServer(gRPCServer, deps.externalService)
 	managementpb.RegisterAnnotationServer(gRPCServer, managementgrpc.NewAnnotationServer(deps.db, deps.grafanaClient))
 	managementpb.RegisterSecurityChecksServer(gRPCServer, management.NewChecksAPIService(deps.checksService))
-	jobs1beta1.RegisterJobsServer(gRPCServer, management.NewJobsAPIServer(deps.db, deps.jobsService))
+	jobs1beta1.RegisterJobsServer(gRPCServer, deps.jobsAPIService)
 
-	iav1beta1.RegisterChannelsServer(gRPCServer, ia.NewChannelsService(deps.db, deps.alertmanager))
+	iav1beta1.RegisterChannelsServer(gRPCServer, deps.channelsService)
 	deps.templatesService.Collect(ctx)
 	iav1beta1.RegisterTemplatesServer(gRPCServer, deps.templatesService)
 	iav1beta1.RegisterRulesServer(gRPCServer, deps.rulesService)
 	iav1beta1.RegisterAlertsServer(gRPCServer, deps.alertsService)
 
-	backupv1beta1.RegisterBackupsServer(gRPCServer, managementbackup.NewBackupsService(deps.db, deps.backupService, deps.schedulerService))
-	backupv1beta1.RegisterLocationsServer(gRPCServer, managementbackup.NewLocationsService(deps.db, deps.minioService))
-	backupv1beta1.RegisterArtifactsServer(gRPCServer, managementbackup.NewArtifactsService(deps.db, deps.backupRemovalService))
-	backupv1beta1.RegisterRestoreHistoryServer(gRPCServer, managementbackup.NewRestoreHistoryService(deps.db))
+	backupv1beta1.RegisterBackupsServer(gRPCServer, deps.backupsService)
+	backupv1beta1.RegisterLocationsServer(gRPCServer, deps.locationsService)
+	backupv1beta1.RegisterArtifactsServer(gRPCServer, deps.artifactsService)
+	backupv1beta1.RegisterRestoreHistoryServer(gRPCServer, deps.restoreHistory)
 
 	dbaasv1beta1.RegisterKubernetesServer(gRPCServer, managementdbaas.NewKubernetesServer(deps.db, deps.dbaasClient, deps.grafanaClient))
 	dbaasv1beta1.RegisterXtraDBClusterServer(gRPCServer, managementdbaas.NewXtraDBClusterService(deps.db, deps.dbaasClient, deps.grafanaClient))
@@ -262,8 +278,118 @@ func runGRPCServer(ctx context.Context, deps *gRPCServerDeps) {
 }
 
 type http1ServerDeps struct {
-	logs       *supervisord.Logs
-	authServer *grafana.AuthServer
+	logs             *supervisord.Logs
+	authServer       *grafana.AuthServer
+	grafanaClient    *grafana.Client
+	qanClient        *qan.Client
+	capacityService  *capacity.Service
+	topology         *topology.Service
+	baselinesService *ia.BaselinesService
+	externalService  *management.ExternalService
+	sandboxService   *sandbox.Service
+	backupsService   *managementbackup.BackupsService
+	restoreHistory   *managementbackup.RestoreHistoryService
+	artifacts        *managementbackup.ArtifactsService
+	locations        *managementbackup.LocationsService
+	vmdb             *victoriametrics.Service
+	metrics          *managementbackup.MetricsService
+	operations       *operations.Service
+	automations      *automations.Service
+	vmalert          *vmalert.Service
+	channels         *ia.ChannelsService
+	siem             *siem.Forwarder
+	dashboards       *dashboards.Service
+	nodes            *inventory.NodesService
+	services         *inventory.ServicesService
+	agents           *inventory.AgentsService
+	agentsDrift      *agents.DriftReconciler
+	nodeFacts        *agents.NodeFactsService
+	mongoDBDiscovery *agents.MongoDBDiscoveryService
+	dbAutodiscovery  *agents.DatabaseAutodiscoveryService
+	labels           *inventory.LabelsService
+	labelValues      *inventory.LabelValuesService
+	scheduler        *scheduler.Service
+	audit            *management.AuditService
+	status           *management.StatusService
+	cleanup          *cleanup.Service
+	connectionCheck  *agents.ConnectionChecker
+	server           *server.Server
+	watchdog         *watchdog.Watchdog
+	managedFiles     *managedfiles.Service
+	alertmanager     *alertmanager.Service
+	dbaasRestore     *managementdbaas.RestoreService
+	usage            *usage.Service
+	selfTest         *selftest.Service
+	backupSigning    *backup.SigningService
+	jobs             *management.JobsAPIService
+	mysql            *management.MySQLService
+	reports          *reports.Service
+}
+
+// addHTTP1Handlers registers JSON API handlers which are not served by grpc-gateway.
+func addHTTP1Handlers(mux *http.ServeMux, deps *http1ServerDeps) {
+	addLogsHandler(mux, deps.logs)
+	addQANExportHandler(mux, deps.qanClient)
+	addCapacityForecastHandler(mux, deps.capacityService)
+	addReplicationTopologyHandler(mux, deps.topology)
+	addAnomalyBaselinesHandler(mux, deps.baselinesService)
+	addImportScrapeTargetsHandler(mux, deps.externalService)
+	addSandboxHandler(mux, deps.sandboxService)
+	addBackupNotificationsHandler(mux, deps.backupsService)
+	addScheduledBackupPauseHandlers(mux, deps.backupsService)
+	addScheduledTasksHandlers(mux, deps.scheduler, deps.reports, deps.cleanup, deps.alertmanager, deps.vmdb)
+	addGroupBackupHandler(mux, deps.backupsService)
+	addBackupDataModelHandlers(mux, deps.backupsService)
+	addClusterRestoreHandlers(mux, deps.backupsService)
+	addBackupDetailsHandlers(mux, deps.artifacts, deps.backupsService)
+	addBackupSigningHandlers(mux, deps.backupSigning)
+	addRestoreHistoryDetailsHandler(mux, deps.restoreHistory)
+	addArtifactDescriptorsHandlers(mux, deps.artifacts, deps.grafanaClient)
+	addLocationUsageHandler(mux, deps.locations)
+	addMetricsHandlers(mux, deps.vmdb, deps.metrics)
+	addOperationsHandlers(mux, deps.operations)
+	addAutomationsHandlers(mux, deps.automations)
+	addBackupLimitsHandlers(mux, deps.server)
+	addSIEMHandlers(mux, deps.siem, deps.server)
+	addRulesFilesHandlers(mux, deps.vmalert)
+	addChannelThrottlingHandler(mux, deps.channels)
+	addDashboardsHandlers(mux, deps.dashboards)
+	addOwnershipHandlers(mux, deps.nodes, deps.services)
+	addRemovePreviewHandlers(mux, deps.nodes, deps.services)
+	addExpirationHandlers(mux, deps.nodes, deps.services)
+	addMetricsResolutionsHandler(mux, deps.services, deps.agents)
+	addExporterTLSHandler(mux, deps.agents)
+	addNodeKubernetesMetadataHandler(mux, deps.nodes)
+	addNodeFactsHandlers(mux, deps.nodeFacts)
+	addMongoDBDiscoveryHandlers(mux, deps.mongoDBDiscovery)
+	addDatabaseAutodiscoveryHandlers(mux, deps.dbAutodiscovery)
+	addAuditLogHandler(mux, deps.audit)
+	addBatchGetStatusHandler(mux, deps.status)
+	addGrantsHandlers(mux, deps.connectionCheck)
+	addAlertingEndpointsHandler(mux, deps.server)
+	addRemoteWriteHandler(mux, deps.server)
+	addExternalVictoriaMetricsHandler(mux, deps.server)
+	addRetentionHandlers(mux, deps.server)
+	addFileSDHandler(mux, deps.server)
+	addExternalLabelsHandlers(mux, deps.server)
+	addCustomScrapeConfigsHandlers(mux, deps.vmdb)
+	addKubernetesScrapeConfigsHandlers(mux, deps.vmdb)
+	addConfigDiffHandler(mux, deps.vmdb)
+	addCardinalityEstimateHandler(mux, deps.vmdb)
+	addConfigStatusHandlers(mux, deps.vmdb, deps.server)
+	addHealthHistoryHandler(mux, deps.watchdog)
+	addSelfTestHandler(mux, deps.selfTest)
+	addManagedFilesHandlers(mux, deps.managedFiles)
+	addDBaaSRestoreHandlers(mux, deps.dbaasRestore)
+	addUsageHandlers(mux, deps.usage)
+	addAgentsDriftHandler(mux, deps.agentsDrift)
+	addBulkChangeLabelsHandler(mux, deps.labels)
+	addBulkChangeLabelsProgressHandler(mux, deps.labels)
+	addLabelValuesHandler(mux, deps.labelValues)
+	addJobProgressHandler(mux, deps.jobs)
+	addMySQLQANSourceHandler(mux, deps.mysql)
+	mux.Handle("/v1/reports/", http.StripPrefix("/v1/reports/", http.FileServer(http.Dir(reports.Dir))))
+	mux.Handle("/auth_request", deps.authServer)
 }
 
 // runHTTP1Server runs grpc-gateway and other HTTP 1.1 APIs (like auth_request and logs.zip)
@@ -339,8 +465,7 @@ func runHTTP1Server(ctx context.Context, deps *http1ServerDeps) {
 	}
 
 	mux := http.NewServeMux()
-	addLogsHandler(mux, deps.logs)
-	mux.Handle("/auth_request", deps.authServer)
+	addHTTP1Handlers(mux, deps)
 	mux.Handle("/", proxyMux)
 
 	server := &http.Server{
@@ -501,7 +626,7 @@ func setup(ctx context.Context, deps *setupDeps) bool {
 	return true
 }
 
-func getQANClient(ctx context.Context, sqlDB *sql.DB, dbName, qanAPIAddr string) *qan.Client {
+func getQANClient(ctx context.Context, sqlDB *sql.DB, dbName, qanAPIAddr string) (*qan.Client, *qan.Recorder) {
 	opts := []grpc.DialOption{
 		grpc.WithInsecure(),
 		grpc.WithBackoffMaxDelay(time.Second), //nolint:staticcheck
@@ -520,7 +645,7 @@ func getQANClient(ctx context.Context, sqlDB *sql.DB, dbName, qanAPIAddr string)
 	reformL := sqlmetrics.NewReform("postgres", dbName+"/qan", l.Tracef)
 	prom.MustRegister(reformL)
 	db := reform.NewDB(sqlDB, postgresql.Dialect, reformL)
-	return qan.NewClient(conn, db)
+	return qan.NewClient(conn, db), qan.NewRecorder(conn, db)
 }
 
 func main() {
@@ -541,6 +666,8 @@ func main() {
 		Default("http://127.0.0.1:8880/").String()
 	victoriaMetricsConfigF := kingpin.Flag("victoriametrics-config", "VictoriaMetrics scrape configuration file path").
 		Default("/etc/victoriametrics-promscrape.yml").String()
+	victoriaMetricsConfigValidatorF := kingpin.Flag("victoriametrics-config-validator", "VictoriaMetrics scrape configuration validator").
+		Default(string(victoriametrics.AutoConfigValidator)).Enum(victoriametrics.ConfigValidators()...)
 
 	grafanaAddrF := kingpin.Flag("grafana-addr", "Grafana HTTP API address").Default("127.0.0.1:3000").String()
 	qanAPIAddrF := kingpin.Flag("qan-api-addr", "QAN API gRPC API address").Default("127.0.0.1:9911").String()
@@ -552,6 +679,16 @@ func main() {
 	postgresDBNameF := kingpin.Flag("postgres-name", "PostgreSQL database name").Required().String()
 	postgresDBUsernameF := kingpin.Flag("postgres-username", "PostgreSQL database username").Default("pmm-managed").String()
 	postgresDBPasswordF := kingpin.Flag("postgres-password", "PostgreSQL database password").Default("pmm-managed").String()
+	postgresReplicaAddrF := kingpin.Flag("postgres-replica-addr", "PostgreSQL read replica address for heavy read-only queries (disabled if empty)").String()
+	postgresReplicaMaxLagF := kingpin.Flag("postgres-replica-max-lag", "Maximal PostgreSQL read replica lag; primary is used if it is exceeded").Default("30s").Duration()
+	postgresMigrationsDryRunF := kingpin.Flag("postgres-migrations-dry-run", "Check pending PostgreSQL database migrations without applying them, and exit").Bool()
+
+	backupGCIntervalF := kingpin.Flag("backup-gc-interval", "Interval of backup artifacts reconciliation with the storage contents").Default("1h").Duration()
+	backupGCDeleteOrphanedFilesF := kingpin.Flag("backup-gc-delete-orphaned-files", "Remove files in the backup storage that don't belong to any artifact").Bool()
+	watchdogIntervalF := kingpin.Flag("watchdog-interval", "Interval of PMM Server components health checks by watchdog (disabled if zero)").Default("1m").Duration()
+	watchdogFailureThresholdF := kingpin.Flag("watchdog-failure-threshold", "Number of consecutive failed health checks after which component is restarted").Default("3").Int()
+	schedulerMaxParallelTasksF := kingpin.Flag("scheduler-max-parallel-tasks", "Maximal number of simultaneously executing scheduled task runs, others are queued (not limited if zero)").Default("10").Int()
+	agentsDriftAutoCorrectF := kingpin.Flag("agents-drift-auto-correct", "Resend state to pmm-agents with Agents state different from the desired one").Bool()
 
 	supervisordConfigDirF := kingpin.Flag("supervisord-config-dir", "Supervisord configuration directory").Required().String()
 
@@ -581,18 +718,55 @@ func main() {
 		l.Panicf("Failed to connect to database: %+v", err)
 	}
 	defer sqlDB.Close() //nolint:errcheck
+
+	if *postgresMigrationsDryRunF {
+		_, err = models.SetupDB(sqlDB, &models.SetupDBParams{
+			Logf:          l.Infof,
+			Username:      *postgresDBUsernameF,
+			Password:      *postgresDBPasswordF,
+			SetupFixtures: models.SetupFixtures,
+			DryRun:        true,
+		})
+		if err != nil {
+			l.Fatalf("Database migrations check failed: %s.", err)
+		}
+		l.Info("Database migrations check passed.")
+		return
+	}
+
 	prom.MustRegister(sqlmetrics.NewCollector("postgres", *postgresDBNameF, sqlDB))
 	reformL := sqlmetrics.NewReform("postgres", *postgresDBNameF, logrus.WithField("component", "reform").Tracef)
 	prom.MustRegister(reformL)
+	prom.MustRegister(models.QueryMetrics())
 	db := reform.NewDB(sqlDB, postgresql.Dialect, reformL)
 
+	var replicaDB *reform.DB
+	if *postgresReplicaAddrF != "" {
+		replicaSQLDB, err := models.OpenDB(*postgresReplicaAddrF, *postgresDBNameF, *postgresDBUsernameF, *postgresDBPasswordF)
+		if err != nil {
+			l.Panicf("Failed to connect to replica database: %+v", err)
+		}
+		defer replicaSQLDB.Close() //nolint:errcheck
+		prom.MustRegister(sqlmetrics.NewCollector("postgres_replica", *postgresDBNameF, replicaSQLDB))
+		replicaDB = reform.NewDB(replicaSQLDB, postgresql.Dialect, reformL)
+	}
+	replica := models.NewReadReplica(db, replicaDB, *postgresReplicaMaxLagF)
+
 	cleaner := clean.New(db)
 	externalRules := vmalert.NewExternalRules()
+	managedFiles := managedfiles.New(map[string]string{
+		"victoriametrics":        *victoriaMetricsConfigF,
+		"alertmanager":           alertmanager.ConfigPath,
+		"vmalert_external_rules": vmalert.ExternalRulesFile,
+	}, map[string]string{
+		"ia_rules": ia.RulesDir,
+	})
 
 	vmParams, err := models.NewVictoriaMetricsParams(victoriametrics.BasePrometheusConfigPath)
 	if err != nil {
 		l.Panicf("cannot load victoriametrics params problem: %+v", err)
 	}
+	vmParams.ConfigValidator = *victoriaMetricsConfigValidatorF
 	vmdb, err := victoriametrics.NewVictoriaMetrics(*victoriaMetricsConfigF, db, *victoriaMetricsURLF, vmParams)
 	if err != nil {
 		l.Panicf("VictoriaMetrics service problem: %+v", err)
@@ -605,11 +779,14 @@ func main() {
 
 	minioService := minio.New()
 
-	qanClient := getQANClient(ctx, sqlDB, *postgresDBNameF, *qanAPIAddrF)
+	qanClient, qanRecorder := getQANClient(ctx, sqlDB, *postgresDBNameF, *qanAPIAddrF)
+	prom.MustRegister(qanRecorder)
 
 	agentsRegistry := agents.NewRegistry(db)
 	backupRemovalService := backup.NewRemovalService(db, minioService)
 	backupRetentionService := backup.NewRetentionService(db, backupRemovalService)
+	backupGCService := backup.NewGCService(db, minioService, *backupGCDeleteOrphanedFilesF)
+	prom.MustRegister(backupGCService)
 	prom.MustRegister(agentsRegistry)
 
 	connectionCheck := agents.NewConnectionChecker(agentsRegistry)
@@ -618,10 +795,16 @@ func main() {
 	// Alertmanager is special due to being added to PMM with invalid /etc/alertmanager.yml.
 	// Generate configuration file before reloading with supervisord, checking status, etc.
 	alertmanager.GenerateBaseConfigs()
+	backupNotificationService := backup.NewNotificationService(db, alertmanager)
+	backupUsageService := backup.NewUsageService(db, minioService)
+	backupSigningService := backup.NewSigningService(db, minioService)
+	backupConfigSnapshotService := backup.NewConfigSnapshotService(db, minioService)
+	actionsService := agents.NewActionsService(agentsRegistry)
+	backupMySQLDumpService := backup.NewMySQLDumpService(db, actionsService, minioService)
 
 	pmmUpdateCheck := supervisord.NewPMMUpdateChecker(logrus.WithField("component", "supervisord/pmm-update-checker"))
 
-	logs := supervisord.NewLogs(version.FullInfo(), pmmUpdateCheck)
+	logs := supervisord.NewLogs(version.FullInfo(), pmmUpdateCheck, db)
 	supervisord := supervisord.New(*supervisordConfigDirF, pmmUpdateCheck, vmParams)
 
 	telemetry, err := telemetry.NewService(db, version.Version)
@@ -631,13 +814,22 @@ func main() {
 
 	awsInstanceChecker := server.NewAWSInstanceChecker(db, telemetry)
 	grafanaClient := grafana.NewClient(*grafanaAddrF)
+	watchdogService := watchdog.New(supervisord, *watchdogFailureThresholdF,
+		watchdog.Component{Name: "alertmanager", Check: alertmanager.IsReady},
+		watchdog.Component{Name: "grafana", Check: grafanaClient.IsReady},
+		watchdog.Component{Name: "victoriametrics", Check: vmdb.IsReady},
+		watchdog.Component{Name: "vmalert", Check: vmalert.IsReady},
+	)
 	prom.MustRegister(grafanaClient)
 
 	jobsService := agents.NewJobsService(db, agentsRegistry)
 	agentsStateUpdater := agents.NewStateUpdater(db, agentsRegistry, vmdb)
-	agentsHandler := agents.NewHandler(db, qanClient, vmdb, agentsRegistry, agentsStateUpdater, backupRetentionService)
-
-	actionsService := agents.NewActionsService(agentsRegistry)
+	agentsDrift := agents.NewDriftReconciler(db, agentsRegistry, agentsStateUpdater, *agentsDriftAutoCorrectF)
+	prom.MustRegister(agentsDrift)
+	mongoDBDiscovery := agents.NewMongoDBDiscoveryService(db, agentsRegistry, agentsStateUpdater, vmdb)
+	dbAutodiscovery := agents.NewDatabaseAutodiscoveryService(db, agentsRegistry, agentsStateUpdater)
+	agentsHandler := agents.NewHandler(db, qanClient, vmdb, agentsRegistry, agentsStateUpdater, backupRetentionService, backupNotificationService, backupUsageService, backupSigningService,
+		backupConfigSnapshotService, backupMySQLDumpService, mongoDBDiscovery)
 
 	checksService, err := checks.New(actionsService, alertmanager, db)
 	if err != nil {
@@ -654,16 +846,46 @@ func main() {
 	// Integrated alerts services
 	templatesService := ia.NewTemplatesService(db)
 	rulesService := ia.NewRulesService(db, templatesService, vmalert, alertmanager)
+	baselinesService := ia.NewBaselinesService(db, rulesService, vmalert)
 	alertsService := ia.NewAlertsService(db, alertmanager, templatesService)
 
+	sandboxService := sandbox.New(db, vmdb, alertmanager, rulesService, externalRules)
+
 	versionService := managementdbaas.NewVersionServiceClient(*versionServiceAPIURLF)
 
 	dbaasClient := dbaas.NewClient(*dbaasControllerAPIAddrF)
-	backupService := backup.NewService(db, jobsService)
-	schedulerService := scheduler.New(db, backupService)
+	backupService := backup.NewService(db, jobsService, actionsService, minioService)
+	reportsService, err := reports.New(db, *victoriaMetricsURLF)
+	if err != nil {
+		l.Panicf("Reports service problem: %+v", err)
+	}
+	cleanupService := cleanup.New()
+	schedulerService := scheduler.New(db, backupService, reportsService, cleanupService, alertmanager, vmdb, *schedulerMaxParallelTasksF)
+	prom.MustRegister(schedulerService)
+	usageService := usage.New(db)
+	prom.MustRegister(usageService)
+	capacityService, err := capacity.New(db, *victoriaMetricsURLF, alertmanager)
+	if err != nil {
+		l.Panicf("Capacity service problem: %+v", err)
+	}
+	topologyService, err := topology.New(db, *victoriaMetricsURLF, vmdb)
+	if err != nil {
+		l.Panicf("Topology service problem: %+v", err)
+	}
+	siemForwarder := siem.New(db)
+	prom.MustRegister(siemForwarder)
+	selfTestService, err := selftest.New(db, *victoriaMetricsURLF, vmdb, vmalert, alertmanager, minioService, agentsRegistry)
+	if err != nil {
+		l.Panicf("Self-test service problem: %+v", err)
+	}
 	versioner := agents.NewVersionerService(agentsRegistry)
 	versionCache := versioncache.New(db, versioner)
 
+	nodesService := inventory.NewNodesService(db, replica, agentsRegistry, agentsStateUpdater, vmdb)
+	servicesService := inventory.NewServicesService(db, replica, agentsRegistry, agentsStateUpdater, vmdb, versionCache)
+	inventoryAgentsService := inventory.NewAgentsService(db, replica, agentsRegistry, agentsStateUpdater, vmdb, connectionCheck)
+	inventoryExpiration := inventory.NewExpirationService(db, agentsRegistry, nodesService, servicesService, schedulerService)
+
 	serverParams := &server.Params{
 		DB:                   db,
 		VMDB:                 vmdb,
@@ -756,6 +978,13 @@ func main() {
 		l.Fatalf("Failed to get settings: %+v.", err)
 	}
 
+	if err = vmalert.SetEndpoint(settings.IntegratedAlerting.VMAlert); err != nil {
+		l.Errorf("Failed to configure VMAlert endpoint: %+v.", err)
+	}
+	if err = alertmanager.SetEndpoint(settings.IntegratedAlerting.Alertmanager); err != nil {
+		l.Errorf("Failed to configure Alertmanager endpoint: %+v.", err)
+	}
+
 	if settings.DBaaS.Enabled {
 		l.Debug("DBaaS is enabled - creating a DBaaS client.")
 		ctx, cancel := context.WithTimeout(ctx, time.Second*20)
@@ -788,6 +1017,12 @@ func main() {
 		vmalert.Run(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		jobsService.Run(ctx)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -829,12 +1064,76 @@ func main() {
 		schedulerService.Run(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		backupService.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		agentsDrift.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		inventoryExpiration.Run(ctx)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		versionCache.Run(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		qanRecorder.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		usageService.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		topologyService.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		siemForwarder.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mongoDBDiscovery.Run(ctx)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dbAutodiscovery.Run(ctx)
+	}()
+
+	// services exposed by both gRPC and HTTP servers
+	mysqlService := management.NewMySQLService(db, agentsStateUpdater, connectionCheck, versionCache)
+	externalService := management.NewExternalService(db, vmdb, agentsStateUpdater, connectionCheck, sandboxService)
+	jobsAPIService := management.NewJobsAPIServer(db, jobsService)
+	channelsService := ia.NewChannelsService(db, alertmanager)
+	backupsService := managementbackup.NewBackupsService(db, backupService, schedulerService, alertmanager)
+	locationsService := managementbackup.NewLocationsService(db, minioService)
+	artifactsService := managementbackup.NewArtifactsService(db, replica, backupRemovalService)
+	restoreHistoryService := managementbackup.NewRestoreHistoryService(db)
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -850,19 +1149,23 @@ func main() {
 			grafanaClient:        grafanaClient,
 			checksService:        checksService,
 			dbaasClient:          dbaasClient,
-			alertmanager:         alertmanager,
 			vmalert:              vmalert,
 			settings:             settings,
 			alertsService:        alertsService,
 			templatesService:     templatesService,
 			rulesService:         rulesService,
-			jobsService:          jobsService,
 			versionServiceClient: versionService,
-			schedulerService:     schedulerService,
-			backupService:        backupService,
-			backupRemovalService: backupRemovalService,
-			minioService:         minioService,
-			versionCache:         versionCache,
+			nodesService:         nodesService,
+			servicesService:      servicesService,
+			agentsService:        inventoryAgentsService,
+			mysqlService:         mysqlService,
+			externalService:      externalService,
+			jobsAPIService:       jobsAPIService,
+			channelsService:      channelsService,
+			backupsService:       backupsService,
+			locationsService:     locationsService,
+			artifactsService:     artifactsService,
+			restoreHistory:       restoreHistoryService,
 		})
 	}()
 
@@ -870,8 +1173,52 @@ func main() {
 	go func() {
 		defer wg.Done()
 		runHTTP1Server(ctx, &http1ServerDeps{
-			logs:       logs,
-			authServer: authServer,
+			logs:             logs,
+			authServer:       authServer,
+			grafanaClient:    grafanaClient,
+			qanClient:        qanClient,
+			capacityService:  capacityService,
+			topology:         topologyService,
+			baselinesService: baselinesService,
+			externalService:  externalService,
+			sandboxService:   sandboxService,
+			backupsService:   backupsService,
+			restoreHistory:   restoreHistoryService,
+			artifacts:        artifactsService,
+			locations:        locationsService,
+			vmdb:             vmdb,
+			metrics:          managementbackup.NewMetricsService(db, vmdb, minioService),
+			operations:       operations.New(db, backupService, supervisord),
+			automations:      automations.New(db, grafanaClient, actionsService, backupService, alertmanager),
+			vmalert:          vmalert,
+			channels:         channelsService,
+			siem:             siemForwarder,
+			dashboards:       dashboards.New(db),
+			nodes:            nodesService,
+			services:         servicesService,
+			agents:           inventoryAgentsService,
+			agentsDrift:      agentsDrift,
+			nodeFacts:        agents.NewNodeFactsService(db, agentsRegistry),
+			mongoDBDiscovery: mongoDBDiscovery,
+			dbAutodiscovery:  dbAutodiscovery,
+			labels:           inventory.NewLabelsService(db, vmdb, rulesService, vmalert, sandboxService),
+			labelValues:      inventory.NewLabelValuesService(replica),
+			scheduler:        schedulerService,
+			audit:            management.NewAuditService(db),
+			status:           management.NewStatusService(replica, agentsRegistry),
+			cleanup:          cleanupService,
+			connectionCheck:  connectionCheck,
+			server:           server,
+			watchdog:         watchdogService,
+			managedFiles:     managedFiles,
+			alertmanager:     alertmanager,
+			dbaasRestore:     managementdbaas.NewRestoreService(db, dbaasClient, grafanaClient, backupService),
+			usage:            usageService,
+			selfTest:         selfTestService,
+			backupSigning:    backupSigningService,
+			jobs:             jobsAPIService,
+			mysql:            mysqlService,
+			reports:          reportsService,
 		})
 	}()
 
@@ -887,5 +1234,23 @@ func main() {
 		cleaner.Run(ctx, cleanInterval, cleanOlderThan)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		replica.Run(ctx, replicaCheckInterval)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		backupGCService.Run(ctx, *backupGCIntervalF)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		watchdogService.Run(ctx, *watchdogIntervalF)
+	}()
+
 	wg.Wait()
 }
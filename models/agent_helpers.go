@@ -17,8 +17,11 @@
 package models
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/google/uuid"
@@ -120,8 +123,8 @@ func checkUniqueAgentID(q *reform.Querier, id string) error {
 		panic("empty Agent ID")
 	}
 
-	agent := &Agent{AgentID: id}
-	switch err := q.Reload(agent); err {
+	agent := &Agent{}
+	switch err := selectOneTo(q, agent, "WHERE agent_id = $1", id); err {
 	case nil:
 		return status.Errorf(codes.AlreadyExists, "Agent with ID %q already exists.", id)
 	case reform.ErrNoRows:
@@ -141,6 +144,10 @@ type AgentFilters struct {
 	ServiceID string
 	// Return Agents with provided type.
 	AgentType *AgentType
+	// Return only Agents with ID greater than that (for keyset pagination).
+	AfterAgentID string
+	// Return at most that number of Agents; 0 means no limit.
+	Limit int
 }
 
 // FindAgents returns Agents by filters.
@@ -175,13 +182,24 @@ func FindAgents(q *reform.Querier, filters AgentFilters) ([]*Agent, error) {
 	if filters.AgentType != nil {
 		conditions = append(conditions, fmt.Sprintf("agent_type = %s", q.Placeholder(idx)))
 		args = append(args, *filters.AgentType)
+		idx++
+	}
+	if filters.AfterAgentID != "" {
+		conditions = append(conditions, fmt.Sprintf("agent_id > %s", q.Placeholder(idx)))
+		args = append(args, filters.AfterAgentID)
+		idx++
 	}
 
 	var whereClause string
 	if len(conditions) != 0 {
 		whereClause = fmt.Sprintf("WHERE %s", strings.Join(conditions, " AND "))
 	}
-	structs, err := q.SelectAllFrom(AgentTable, fmt.Sprintf("%s ORDER BY agent_id", whereClause), args...)
+	tail := fmt.Sprintf("%s ORDER BY agent_id", whereClause)
+	if filters.Limit > 0 {
+		tail += fmt.Sprintf(" LIMIT %s", q.Placeholder(idx))
+		args = append(args, filters.Limit)
+	}
+	structs, err := q.SelectAllFrom(AgentTable, tail, args...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -195,7 +213,9 @@ func FindAgents(q *reform.Querier, filters AgentFilters) ([]*Agent, error) {
 }
 
 // FindAgentByID finds Agent by ID.
-func FindAgentByID(q *reform.Querier, id string) (*Agent, error) {
+func FindAgentByID(q *reform.Querier, id string) (_ *Agent, err error) {
+	defer observeQuery("FindAgentByID", time.Now(), &err)
+
 	if id == "" {
 		return nil, status.Error(codes.InvalidArgument, "Empty Agent ID.")
 	}
@@ -316,47 +336,28 @@ func FindPMMAgentsRunningOnNode(q *reform.Querier, nodeID string) ([]*Agent, err
 }
 
 // FindPMMAgentsForService gets pmm-agents for service.
-func FindPMMAgentsForService(q *reform.Querier, serviceID string) ([]*Agent, error) {
-	_, err := q.SelectOneFrom(ServiceTable, "WHERE service_id = $1", serviceID)
-	if err != nil {
-		return nil, status.Errorf(codes.FailedPrecondition, "Couldn't get services by service_id, %s", serviceID)
-	}
+func FindPMMAgentsForService(q *reform.Querier, serviceID string) (_ []*Agent, err error) {
+	defer observeQuery("FindPMMAgentsForService", time.Now(), &err)
 
-	// First, find agents with serviceID.
-	allAgents, err := q.SelectAllFrom(AgentTable, "WHERE service_id = $1", serviceID)
+	// find pmm-agents of Service's Agents in a single round trip
+	tail := "WHERE agent_type = $1 AND agent_id IN (SELECT pmm_agent_id FROM agents WHERE service_id = $2) ORDER BY agent_id"
+	structs, err := selectAllFrom(q, AgentTable, tail, PMMAgentType, serviceID)
 	if err != nil {
-		return nil, status.Errorf(codes.FailedPrecondition, "Couldn't get all agents for service %s", serviceID)
-	}
-	pmmAgentIDs := make([]interface{}, len(allAgents))
-	for _, str := range allAgents {
-		row := str.(*Agent)
-		if row.PMMAgentID != nil {
-			for _, a := range pmmAgentIDs {
-				if a == *row.PMMAgentID {
-					break
-				}
-				pmmAgentIDs = append(pmmAgentIDs, *row.PMMAgentID)
-			}
-		}
+		return nil, status.Errorf(codes.FailedPrecondition, "Couldn't get pmm-agents for service %s", serviceID)
 	}
 
-	if len(pmmAgentIDs) == 0 {
+	if len(structs) == 0 {
+		// distinguish Service without Agents from unknown Service
+		if _, err = q.SelectOneFrom(ServiceTable, "WHERE service_id = $1", serviceID); err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "Couldn't get services by service_id, %s", serviceID)
+		}
 		return []*Agent{}, nil
 	}
 
-	// Last, find all pmm-agents.
-	ph := strings.Join(q.Placeholders(1, len(pmmAgentIDs)), ", ")
-	atail := fmt.Sprintf("WHERE agent_id IN (%s) AND agent_type = '%s' ORDER BY agent_id", ph, PMMAgentType) //nolint:gosec
-	pmmAgentRecords, err := q.SelectAllFrom(AgentTable, atail, pmmAgentIDs...)
-	if err != nil {
-		return nil, status.Errorf(codes.FailedPrecondition, "Couldn't get pmm-agents for service %s", serviceID)
-	}
-	res := make([]*Agent, 0, len(pmmAgentRecords))
-	for _, str := range pmmAgentRecords {
-		row := str.(*Agent)
-		res = append(res, row)
+	res := make([]*Agent, len(structs))
+	for i, str := range structs {
+		res[i] = str.(*Agent)
 	}
-
 	return res, nil
 }
 
@@ -567,9 +568,40 @@ type CreateExternalExporterParams struct {
 
 // CreateExternalExporter creates ExternalExporter.
 func CreateExternalExporter(q *reform.Querier, params *CreateExternalExporterParams) (*Agent, error) {
+	row, err := NewExternalExporter(q, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := FindNodeByID(q, params.RunsOnNodeID); err != nil {
+		return nil, err
+	}
+	if _, err := FindServiceByID(q, params.ServiceID); err != nil {
+		return nil, err
+	}
+
+	if err := q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return row, nil
+}
+
+// NewExternalExporter validates parameters and returns ExternalExporter without storing it.
+// Unlike CreateExternalExporter, it does not check that Node and Service exist,
+// so it can be used for Services inserted in the same batch with InsertBatch.
+func NewExternalExporter(q *reform.Querier, params *CreateExternalExporterParams) (*Agent, error) {
 	if !(params.ListenPort > 0 && params.ListenPort < 65536) {
 		return nil, status.Errorf(codes.InvalidArgument, "Listen port should be between 1 and 65535.")
 	}
+	switch params.Scheme {
+	case "", "http", "https":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Unsupported scheme %q, should be http or https.", params.Scheme)
+	}
+	if params.MetricsPath != "" && !strings.HasPrefix(params.MetricsPath, "/") {
+		return nil, status.Errorf(codes.InvalidArgument, "Metrics path %q should start with /.", params.MetricsPath)
+	}
 	var pmmAgentID *string
 	runsOnNodeID := pointer.ToString(params.RunsOnNodeID)
 	id := "/agent_id/" + uuid.New().String()
@@ -598,13 +630,6 @@ func CreateExternalExporter(q *reform.Querier, params *CreateExternalExporterPar
 		runsOnNodeID = nil
 	}
 
-	if _, err := FindNodeByID(q, params.RunsOnNodeID); err != nil {
-		return nil, err
-	}
-	if _, err := FindServiceByID(q, params.ServiceID); err != nil {
-		return nil, err
-	}
-
 	scheme := params.Scheme
 	if scheme == "" {
 		scheme = "http"
@@ -629,9 +654,6 @@ func CreateExternalExporter(q *reform.Querier, params *CreateExternalExporterPar
 	if err := row.SetCustomLabels(params.CustomLabels); err != nil {
 		return nil, err
 	}
-	if err := q.Insert(row); err != nil {
-		return nil, errors.WithStack(err)
-	}
 
 	return row, nil
 }
@@ -841,6 +863,17 @@ func ChangeAgent(q *reform.Querier, agentID string, params *ChangeCommonAgentPar
 		}
 	}
 	if params.DisablePushMetrics != nil {
+		// check version of pmm-agent the same way as on Agent creation.
+		if !*params.DisablePushMetrics && row.AgentType != VMAgentType && row.PMMAgentID != nil {
+			pmmAgent, err := FindAgentByID(q, *row.PMMAgentID)
+			if err != nil {
+				return nil, err
+			}
+			if !IsPushMetricsSupported(pmmAgent.Version) {
+				return nil, status.Errorf(codes.FailedPrecondition, "cannot use push_metrics_enabled with pmm_agent version=%q,"+
+					" it doesn't support it, minimum supported version=%q", pointer.GetString(pmmAgent.Version), PMMAgentWithPushMetricsSupport.String())
+			}
+		}
 		row.PushMetrics = !(*params.DisablePushMetrics)
 		if row.AgentType == ExternalExporterType {
 			if err := updateExternalExporterParams(q, row); err != nil {
@@ -867,6 +900,86 @@ func ChangeAgent(q *reform.Querier, agentID string, params *ChangeCommonAgentPar
 	return row, nil
 }
 
+// ChangeAgentMetricsResolutions changes Agent metrics resolutions overrides and tier;
+// nil or zero values and empty tier mean Service or global metrics resolutions.
+func ChangeAgentMetricsResolutions(q *reform.Querier, agentID string, tier MetricsResolutionTier, resolutions *MetricsResolutions) (*Agent, error) {
+	switch tier {
+	case "", HRTier, MRTier, LRTier:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Unsupported metrics resolution tier %q.", tier)
+	}
+	if resolutions != nil {
+		if err := validateMetricsResolutions(*resolutions); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if *resolutions == (MetricsResolutions{}) {
+			resolutions = nil
+		}
+	}
+
+	row, err := FindAgentByID(q, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if row.AgentType == PMMAgentType {
+		return nil, status.Error(codes.InvalidArgument, "pmm-agent metrics resolutions can't be changed.")
+	}
+
+	row.MetricsResolutions = resolutions
+	row.MetricsResolutionTier = tier
+	if err = q.Update(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// ChangeAgentExporterTLS changes TLS options used for scraping Agent over HTTPS;
+// nil or zero value means scraping over HTTP.
+func ChangeAgentExporterTLS(q *reform.Querier, agentID string, options *ExporterTLSOptions) (*Agent, error) {
+	if options != nil {
+		if *options == (ExporterTLSOptions{}) {
+			options = nil
+		} else if err := validateExporterTLSOptions(options); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err := FindAgentByID(q, agentID)
+	if err != nil {
+		return nil, err
+	}
+	switch row.AgentType {
+	case PMMAgentType, VMAgentType, QANMySQLPerfSchemaAgentType, QANMySQLSlowlogAgentType,
+		QANMongoDBProfilerAgentType, QANPostgreSQLPgStatementsAgentType, QANPostgreSQLPgStatMonitorAgentType:
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not scraped.", row.AgentType)
+	}
+
+	row.ExporterTLS = options
+	if err = q.Update(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+func validateExporterTLSOptions(options *ExporterTLSOptions) error {
+	if options.CA != "" {
+		if !x509.NewCertPool().AppendCertsFromPEM([]byte(options.CA)) {
+			return status.Error(codes.InvalidArgument, "Invalid CA certificate.")
+		}
+	}
+
+	if (options.Cert == "") != (options.Key == "") {
+		return status.Error(codes.InvalidArgument, "Both certificate and key should be specified.")
+	}
+	if options.Cert != "" {
+		if _, err := tls.X509KeyPair([]byte(options.Cert), []byte(options.Key)); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid certificate and key: %s.", err)
+		}
+	}
+
+	return nil
+}
+
 // RemoveAgent removes Agent by ID.
 func RemoveAgent(q *reform.Querier, id string, mode RemoveMode) (*Agent, error) {
 	a, err := FindAgentByID(q, id)
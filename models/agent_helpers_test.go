@@ -207,6 +207,23 @@ func TestAgentHelpers(t *testing.T) {
 		assert.Equal(t, expected, agents)
 	})
 
+	t.Run("AgentsPage", func(t *testing.T) {
+		q, teardown := setup(t)
+		defer teardown(t)
+
+		agents, err := models.FindAgents(q, models.AgentFilters{AfterAgentID: "A2", Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, agents, 2)
+		assert.Equal(t, "A3", agents[0].AgentID)
+		assert.Equal(t, "A4", agents[1].AgentID)
+
+		agents, err = models.FindAgents(q, models.AgentFilters{PMMAgentID: "A4", AfterAgentID: "A5", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, agents, 2)
+		assert.Equal(t, "A6", agents[0].AgentID)
+		assert.Equal(t, "A7", agents[1].AgentID)
+	})
+
 	t.Run("AgentsRunningByPMMAgentAndType", func(t *testing.T) {
 		q, teardown := setup(t)
 		defer teardown(t)
@@ -335,6 +352,85 @@ func TestAgentHelpers(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("ChangeAgentMetricsResolutions", func(t *testing.T) {
+		q, teardown := setup(t)
+		defer teardown(t)
+
+		resolutions := &models.MetricsResolutions{MR: 30 * time.Second}
+		agent, err := models.ChangeAgentMetricsResolutions(q, "A2", models.LRTier, resolutions)
+		require.NoError(t, err)
+		assert.Equal(t, resolutions, agent.MetricsResolutions)
+		assert.Equal(t, models.LRTier, agent.MetricsResolutionTier)
+
+		agent, err = models.FindAgentByID(q, "A2")
+		require.NoError(t, err)
+		assert.Equal(t, resolutions, agent.MetricsResolutions)
+		assert.Equal(t, models.LRTier, agent.MetricsResolutionTier)
+
+		// zero values reset overrides
+		agent, err = models.ChangeAgentMetricsResolutions(q, "A2", "", &models.MetricsResolutions{})
+		require.NoError(t, err)
+		assert.Nil(t, agent.MetricsResolutions)
+		assert.Empty(t, agent.MetricsResolutionTier)
+
+		_, err = models.ChangeAgentMetricsResolutions(q, "A2", "xr", nil)
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Unsupported metrics resolution tier "xr".`), err)
+
+		_, err = models.ChangeAgentMetricsResolutions(q, "A1", models.HRTier, nil)
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "pmm-agent metrics resolutions can't be changed."), err)
+	})
+
+	t.Run("ChangeAgentExporterTLS", func(t *testing.T) {
+		q, teardown := setup(t)
+		defer teardown(t)
+
+		options := &models.ExporterTLSOptions{ServerName: "mysql", InsecureSkipVerify: true}
+		agent, err := models.ChangeAgentExporterTLS(q, "A2", options)
+		require.NoError(t, err)
+		assert.Equal(t, options, agent.ExporterTLS)
+
+		agent, err = models.FindAgentByID(q, "A2")
+		require.NoError(t, err)
+		assert.Equal(t, options, agent.ExporterTLS)
+
+		// zero value resets options
+		agent, err = models.ChangeAgentExporterTLS(q, "A2", &models.ExporterTLSOptions{})
+		require.NoError(t, err)
+		assert.Nil(t, agent.ExporterTLS)
+
+		_, err = models.ChangeAgentExporterTLS(q, "A2", &models.ExporterTLSOptions{CA: "not a certificate"})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Invalid CA certificate."), err)
+
+		_, err = models.ChangeAgentExporterTLS(q, "A2", &models.ExporterTLSOptions{Cert: "cert"})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Both certificate and key should be specified."), err)
+
+		_, err = models.ChangeAgentExporterTLS(q, "A1", options)
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "pmm-agent is not scraped."), err)
+	})
+
+	t.Run("ChangeAgentPushMetrics", func(t *testing.T) {
+		q, teardown := setup(t)
+		defer teardown(t)
+
+		agent, err := models.ChangeAgent(q, "A6", &models.ChangeCommonAgentParams{DisablePushMetrics: pointer.ToBool(false)})
+		require.NoError(t, err)
+		assert.True(t, agent.PushMetrics)
+
+		pmmAgent, err := models.FindAgentByID(q, "A1")
+		require.NoError(t, err)
+		pmmAgent.Version = pointer.ToString("2.10.0")
+		require.NoError(t, q.Update(pmmAgent))
+
+		_, err = models.ChangeAgent(q, "A2", &models.ChangeCommonAgentParams{DisablePushMetrics: pointer.ToBool(false)})
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, `cannot use push_metrics_enabled with pmm_agent version="2.10.0",`+
+			` it doesn't support it, minimum supported version="2.11.99"`), err)
+
+		// disabling is always possible
+		agent, err = models.ChangeAgent(q, "A2", &models.ChangeCommonAgentParams{DisablePushMetrics: pointer.ToBool(true)})
+		require.NoError(t, err)
+		assert.False(t, agent.PushMetrics)
+	})
+
 	t.Run("CreateExternalExporter", func(t *testing.T) {
 		t.Run("Basic", func(t *testing.T) {
 			q, teardown := setup(t)
@@ -367,6 +463,44 @@ func TestAgentHelpers(t *testing.T) {
 			tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Listen port should be between 1 and 65535."), err)
 			require.Nil(t, agent)
 		})
+		t.Run("CustomSchemeAndPath", func(t *testing.T) {
+			q, teardown := setup(t)
+			defer teardown(t)
+			agent, err := models.CreateExternalExporter(q, &models.CreateExternalExporterParams{
+				RunsOnNodeID: "N1",
+				ServiceID:    "S1",
+				Scheme:       "https",
+				MetricsPath:  "/custom/metrics",
+				ListenPort:   9104,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, pointer.ToString("https"), agent.MetricsScheme)
+			assert.Equal(t, pointer.ToString("/custom/metrics"), agent.MetricsPath)
+		})
+		t.Run("Invalid scheme", func(t *testing.T) {
+			q, teardown := setup(t)
+			defer teardown(t)
+			agent, err := models.CreateExternalExporter(q, &models.CreateExternalExporterParams{
+				RunsOnNodeID: "N1",
+				ServiceID:    "S1",
+				Scheme:       "ftp",
+				ListenPort:   9104,
+			})
+			tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Unsupported scheme "ftp", should be http or https.`), err)
+			require.Nil(t, agent)
+		})
+		t.Run("Invalid metrics path", func(t *testing.T) {
+			q, teardown := setup(t)
+			defer teardown(t)
+			agent, err := models.CreateExternalExporter(q, &models.CreateExternalExporterParams{
+				RunsOnNodeID: "N1",
+				ServiceID:    "S1",
+				MetricsPath:  "metrics",
+				ListenPort:   9104,
+			})
+			tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Metrics path "metrics" should start with /.`), err)
+			require.Nil(t, agent)
+		})
 	})
 
 	t.Run("TestFindPMMAgentsForVersion", func(t *testing.T) {
@@ -81,6 +81,22 @@ func (c MySQLOptions) Value() (driver.Value, error) { return jsonValue(c) }
 // Scan implements database/sql.Scanner interface. Should be defined on the pointer.
 func (c *MySQLOptions) Scan(src interface{}) error { return jsonScan(c, src) }
 
+// ExporterTLSOptions represents TLS options used for scraping exporter over HTTPS.
+type ExporterTLSOptions struct {
+	// PEM-encoded certificates and key; empty values are not used.
+	CA                 string `json:"ca"`
+	Cert               string `json:"cert"`
+	Key                string `json:"key"`
+	ServerName         string `json:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (c ExporterTLSOptions) Value() (driver.Value, error) { return jsonValue(c) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (c *ExporterTLSOptions) Scan(src interface{}) error { return jsonScan(c, src) }
+
 // MongoDBOptions represents structure for special MongoDB options.
 type MongoDBOptions struct {
 	TLSCertificateKey             string `json:"tls_certificate_key"`
@@ -146,6 +162,8 @@ type Agent struct {
 	Status     string  `reform:"status"`
 	ListenPort *uint16 `reform:"listen_port"`
 	Version    *string `reform:"version"`
+	// Time of the last pmm-agent connection or disconnection.
+	LastSeenAt *time.Time `reform:"last_seen_at"`
 
 	Username      *string `reform:"username"`
 	Password      *string `reform:"password"`
@@ -180,6 +198,14 @@ type Agent struct {
 	MySQLOptions      *MySQLOptions      `reform:"mysql_options"`
 	MongoDBOptions    *MongoDBOptions    `reform:"mongo_db_tls_options"`
 	PostgreSQLOptions *PostgreSQLOptions `reform:"postgresql_options"`
+
+	// Overrides of Service and global metrics resolutions; zero values and nil mean inherited ones.
+	MetricsResolutions *MetricsResolutions `reform:"metrics_resolutions"`
+	// If set, all exporter jobs are scraped with the interval of that resolution.
+	MetricsResolutionTier MetricsResolutionTier `reform:"metrics_resolution_tier"`
+
+	// If set, exporter is scraped over HTTPS with those options.
+	ExporterTLS *ExporterTLSOptions `reform:"exporter_tls"`
 }
 
 // BeforeInsert implements reform.BeforeInserter interface.
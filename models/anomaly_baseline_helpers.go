@@ -0,0 +1,218 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+const defaultBaselineSigma = 3
+
+// AnomalyBaselineFilters represents filters for anomaly baselines list.
+type AnomalyBaselineFilters struct {
+	// Return only baselines of that Service.
+	ServiceID string
+	// Return only enabled baselines.
+	EnabledOnly bool
+}
+
+// FindAnomalyBaselines returns anomaly baselines by filters.
+func FindAnomalyBaselines(q *reform.Querier, filters AnomalyBaselineFilters) ([]*AnomalyBaseline, error) {
+	var conditions []string
+	var args []interface{}
+	if filters.ServiceID != "" {
+		conditions = append(conditions, "service_id = "+q.Placeholder(len(args)+1))
+		args = append(args, filters.ServiceID)
+	}
+	if filters.EnabledOnly {
+		conditions = append(conditions, "NOT disabled")
+	}
+
+	var tail string
+	if len(conditions) != 0 {
+		tail = "WHERE " + strings.Join(conditions, " AND ") + " "
+	}
+	tail += "ORDER BY service_id, metric"
+
+	rows, err := q.SelectAllFrom(AnomalyBaselineTable, tail, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*AnomalyBaseline, len(rows))
+	for i, r := range rows {
+		res[i] = r.(*AnomalyBaseline)
+	}
+	return res, nil
+}
+
+// FindAnomalyBaselineByID finds anomaly baseline by ID.
+func FindAnomalyBaselineByID(q *reform.Querier, id string) (*AnomalyBaseline, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty anomaly baseline ID.")
+	}
+
+	res := &AnomalyBaseline{ID: id}
+	switch err := q.Reload(res); err {
+	case nil:
+		return res, nil
+	case reform.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "Anomaly baseline with ID %q not found.", id)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// CreateAnomalyBaselineParams are params for creating anomaly baseline.
+type CreateAnomalyBaselineParams struct {
+	ServiceID   string
+	Metric      BaselineMetric
+	Seasonality BaselineSeasonality
+	Sigma       float64
+	Disabled    bool
+}
+
+// Validate validates params and fills defaults.
+func (p *CreateAnomalyBaselineParams) Validate() error {
+	switch p.Metric {
+	case QPSBaselineMetric, LatencyBaselineMetric, ReplicationLagBaselineMetric:
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported baseline metric %q.", p.Metric)
+	}
+
+	if p.Seasonality == "" {
+		p.Seasonality = DailyBaselineSeasonality
+	}
+	if err := validateBaselineSeasonality(p.Seasonality); err != nil {
+		return err
+	}
+
+	if p.Sigma == 0 {
+		p.Sigma = defaultBaselineSigma
+	}
+	return validateBaselineSigma(p.Sigma)
+}
+
+// CreateAnomalyBaseline creates anomaly baseline for a metric of a Service.
+func CreateAnomalyBaseline(q *reform.Querier, params *CreateAnomalyBaselineParams) (*AnomalyBaseline, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	service, err := FindServiceByID(q, params.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	switch service.ServiceType {
+	case MySQLServiceType, PostgreSQLServiceType, MongoDBServiceType:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Anomaly baselines are not supported for %s services.", service.ServiceType)
+	}
+
+	existing, err := FindAnomalyBaselines(q, AnomalyBaselineFilters{ServiceID: params.ServiceID})
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range existing {
+		if b.Metric == params.Metric {
+			return nil, status.Errorf(codes.AlreadyExists, "Anomaly baseline for metric %q of Service %q already exists.", params.Metric, params.ServiceID)
+		}
+	}
+
+	row := &AnomalyBaseline{
+		ID:          "/anomaly_baseline_id/" + uuid.New().String(),
+		ServiceID:   params.ServiceID,
+		Metric:      params.Metric,
+		Seasonality: params.Seasonality,
+		Sigma:       params.Sigma,
+		Disabled:    params.Disabled,
+	}
+	if err = q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// ChangeAnomalyBaselineParams are params for changing anomaly baseline.
+type ChangeAnomalyBaselineParams struct {
+	Seasonality BaselineSeasonality
+	Sigma       float64
+	Disabled    *bool
+}
+
+// ChangeAnomalyBaseline changes anomaly baseline; zero values are not changed.
+func ChangeAnomalyBaseline(q *reform.Querier, id string, params *ChangeAnomalyBaselineParams) (*AnomalyBaseline, error) {
+	row, err := FindAnomalyBaselineByID(q, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Seasonality != "" {
+		if err = validateBaselineSeasonality(params.Seasonality); err != nil {
+			return nil, err
+		}
+		row.Seasonality = params.Seasonality
+	}
+	if params.Sigma != 0 {
+		if err = validateBaselineSigma(params.Sigma); err != nil {
+			return nil, err
+		}
+		row.Sigma = params.Sigma
+	}
+	if params.Disabled != nil {
+		row.Disabled = *params.Disabled
+	}
+
+	if err = q.Update(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// RemoveAnomalyBaseline removes anomaly baseline by ID.
+func RemoveAnomalyBaseline(q *reform.Querier, id string) error {
+	if _, err := FindAnomalyBaselineByID(q, id); err != nil {
+		return err
+	}
+
+	if err := q.Delete(&AnomalyBaseline{ID: id}); err != nil {
+		return errors.Wrap(err, "failed to delete anomaly baseline")
+	}
+	return nil
+}
+
+func validateBaselineSeasonality(s BaselineSeasonality) error {
+	switch s {
+	case DailyBaselineSeasonality, WeeklyBaselineSeasonality:
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported baseline seasonality %q.", s)
+	}
+}
+
+func validateBaselineSigma(sigma float64) error {
+	if sigma <= 0 {
+		return status.Errorf(codes.InvalidArgument, "Baseline sigma should be positive.")
+	}
+	return nil
+}
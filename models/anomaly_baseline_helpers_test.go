@@ -0,0 +1,115 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestAnomalyBaselines(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	setup := func(t *testing.T) (*reform.Querier, *models.Service) {
+		t.Helper()
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+		node, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{NodeName: "node"})
+		require.NoError(t, err)
+		service, err := models.AddNewService(q, models.MySQLServiceType, &models.AddDBMSServiceParams{
+			ServiceName: "mysql",
+			NodeID:      node.NodeID,
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16(3306),
+		})
+		require.NoError(t, err)
+		return q, service
+	}
+
+	t.Run("create, change and remove", func(t *testing.T) {
+		q, service := setup(t)
+
+		b, err := models.CreateAnomalyBaseline(q, &models.CreateAnomalyBaselineParams{
+			ServiceID: service.ServiceID,
+			Metric:    models.QPSBaselineMetric,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.DailyBaselineSeasonality, b.Seasonality)
+		assert.Equal(t, float64(3), b.Sigma)
+
+		_, err = models.CreateAnomalyBaseline(q, &models.CreateAnomalyBaselineParams{
+			ServiceID: service.ServiceID,
+			Metric:    models.QPSBaselineMetric,
+		})
+		assert.EqualError(t, err, `rpc error: code = AlreadyExists desc = Anomaly baseline for metric "qps" of Service "`+service.ServiceID+`" already exists.`)
+
+		b, err = models.ChangeAnomalyBaseline(q, b.ID, &models.ChangeAnomalyBaselineParams{
+			Seasonality: models.WeeklyBaselineSeasonality,
+			Disabled:    pointer.ToBool(true),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.WeeklyBaselineSeasonality, b.Seasonality)
+		assert.Equal(t, float64(3), b.Sigma)
+		assert.True(t, b.Disabled)
+
+		baselines, err := models.FindAnomalyBaselines(q, models.AnomalyBaselineFilters{ServiceID: service.ServiceID})
+		require.NoError(t, err)
+		assert.Len(t, baselines, 1)
+		baselines, err = models.FindAnomalyBaselines(q, models.AnomalyBaselineFilters{EnabledOnly: true})
+		require.NoError(t, err)
+		assert.Empty(t, baselines)
+
+		require.NoError(t, models.RemoveAnomalyBaseline(q, b.ID))
+		_, err = models.FindAnomalyBaselineByID(q, b.ID)
+		assert.EqualError(t, err, `rpc error: code = NotFound desc = Anomaly baseline with ID "`+b.ID+`" not found.`)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		q, service := setup(t)
+
+		_, err := models.CreateAnomalyBaseline(q, &models.CreateAnomalyBaselineParams{
+			ServiceID: service.ServiceID,
+			Metric:    "cpu",
+		})
+		assert.EqualError(t, err, `rpc error: code = InvalidArgument desc = Unsupported baseline metric "cpu".`)
+
+		_, err = models.CreateAnomalyBaseline(q, &models.CreateAnomalyBaselineParams{
+			ServiceID: service.ServiceID,
+			Metric:    models.LatencyBaselineMetric,
+			Sigma:     -1,
+		})
+		assert.EqualError(t, err, `rpc error: code = InvalidArgument desc = Baseline sigma should be positive.`)
+	})
+}
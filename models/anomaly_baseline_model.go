@@ -0,0 +1,94 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// BaselineMetric represents a metric for which anomaly baseline is computed.
+type BaselineMetric string
+
+// Supported baseline metrics.
+const (
+	QPSBaselineMetric            BaselineMetric = "qps"
+	LatencyBaselineMetric        BaselineMetric = "latency"
+	ReplicationLagBaselineMetric BaselineMetric = "replication_lag"
+)
+
+// BaselineSeasonality represents a period of metric seasonality.
+type BaselineSeasonality string
+
+// Supported seasonalities.
+const (
+	DailyBaselineSeasonality  BaselineSeasonality = "daily"
+	WeeklyBaselineSeasonality BaselineSeasonality = "weekly"
+)
+
+// Period returns duration of a single season.
+func (s BaselineSeasonality) Period() time.Duration {
+	if s == WeeklyBaselineSeasonality {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// AnomalyBaseline represents anomaly detection baseline for a single metric of a Service.
+//reform:anomaly_baselines
+type AnomalyBaseline struct {
+	ID          string              `reform:"id,pk"`
+	ServiceID   string              `reform:"service_id"`
+	Metric      BaselineMetric      `reform:"metric"`
+	Seasonality BaselineSeasonality `reform:"seasonality"`
+	// Alert when metric deviates from the baseline by more than Sigma standard deviations.
+	Sigma     float64   `reform:"sigma"`
+	Disabled  bool      `reform:"disabled"`
+	CreatedAt time.Time `reform:"created_at"`
+	UpdatedAt time.Time `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (b *AnomalyBaseline) BeforeInsert() error {
+	now := Now()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (b *AnomalyBaseline) BeforeUpdate() error {
+	b.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (b *AnomalyBaseline) AfterFind() error {
+	b.CreatedAt = b.CreatedAt.UTC()
+	b.UpdatedAt = b.UpdatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*AnomalyBaseline)(nil)
+	_ reform.BeforeUpdater  = (*AnomalyBaseline)(nil)
+	_ reform.AfterFinder    = (*AnomalyBaseline)(nil)
+)
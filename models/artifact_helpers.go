@@ -19,6 +19,7 @@ package models
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -44,6 +45,10 @@ type ArtifactFilters struct {
 	ScheduleID string
 	// Return only artifacts by specified status.
 	Status BackupStatus
+	// Return only artifacts of specified backup group.
+	GroupID string
+	// Return only golden artifacts.
+	Golden bool
 }
 
 // FindArtifacts returns artifacts list.
@@ -75,6 +80,16 @@ func FindArtifacts(q *reform.Querier, filters ArtifactFilters) ([]*Artifact, err
 	if filters.Status != "" {
 		conditions = append(conditions, fmt.Sprintf("status = %s", q.Placeholder(idx)))
 		args = append(args, filters.Status)
+		idx++
+	}
+
+	if filters.GroupID != "" {
+		conditions = append(conditions, fmt.Sprintf("group_id = %s", q.Placeholder(idx)))
+		args = append(args, filters.GroupID)
+	}
+
+	if filters.Golden {
+		conditions = append(conditions, "golden")
 	}
 
 	var whereClause string
@@ -137,6 +152,61 @@ func FindArtifactByID(q *reform.Querier, id string) (*Artifact, error) {
 	}
 }
 
+// FindArtifactByName returns artifact by given name if found, ErrNotFound if not.
+func FindArtifactByName(q *reform.Querier, name string) (*Artifact, error) {
+	if name == "" {
+		return nil, errors.New("provided artifact name is empty")
+	}
+
+	var artifact Artifact
+	switch err := q.FindOneTo(&artifact, "name", name); err {
+	case nil:
+		return &artifact, nil
+	case reform.ErrNoRows:
+		return nil, errors.Wrapf(ErrNotFound, "artifact by name '%s'", name)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// ArtifactsUsage represents storage usage by artifacts of a single Service in a backup location.
+type ArtifactsUsage struct {
+	ServiceID string
+	// Total number of artifacts.
+	Artifacts int
+	// Number of artifacts with known size.
+	SizedArtifacts int
+	// Total size of artifacts with known size in bytes.
+	Size int64
+}
+
+// FindArtifactsUsage returns storage usage by artifacts in given backup location grouped by Service.
+func FindArtifactsUsage(q *reform.Querier, locationID string) ([]*ArtifactsUsage, error) {
+	if _, err := FindBackupLocationByID(q, locationID); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`SELECT service_id, COUNT(*), COUNT(size), COALESCE(SUM(size), 0)
+		FROM artifacts WHERE location_id = $1 GROUP BY service_id ORDER BY service_id`, locationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to select artifacts usage")
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var res []*ArtifactsUsage
+	for rows.Next() {
+		var u ArtifactsUsage
+		if err = rows.Scan(&u.ServiceID, &u.Artifacts, &u.SizedArtifacts, &u.Size); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res = append(res, &u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
 func checkUniqueArtifactName(q *reform.Querier, name string) error {
 	if name == "" {
 		panic("empty Location Name")
@@ -162,6 +232,10 @@ type CreateArtifactParams struct {
 	DataModel  DataModel
 	Status     BackupStatus
 	ScheduleID string
+	// Empty for artifacts outside of a backup group.
+	GroupID string
+	// May be nil if Service software versions are unknown.
+	Metadata *ArtifactMetadata
 }
 
 // Validate validates params used for creating an artifact entry.
@@ -216,6 +290,8 @@ func CreateArtifact(q *reform.Querier, params CreateArtifactParams) (*Artifact,
 		Status:     params.Status,
 		Type:       OnDemandArtifactType,
 		ScheduleID: params.ScheduleID,
+		GroupID:    params.GroupID,
+		Metadata:   params.Metadata,
 	}
 
 	if params.ScheduleID != "" {
@@ -229,11 +305,69 @@ func CreateArtifact(q *reform.Querier, params CreateArtifactParams) (*Artifact,
 	return row, nil
 }
 
+// ImportArtifactParams are params for importing artifact created by another PMM Server.
+type ImportArtifactParams struct {
+	CreateArtifactParams
+	// Creation time on the original PMM Server.
+	CreatedAt time.Time
+}
+
+// ImportArtifacts creates successful on-demand artifacts entries for artifacts created by another PMM Server
+// with a single multi-row INSERT.
+func ImportArtifacts(q *reform.Querier, params []ImportArtifactParams) ([]*Artifact, error) {
+	structs := make([]reform.Struct, 0, len(params))
+	res := make([]*Artifact, 0, len(params))
+	names := make(map[string]struct{}, len(params))
+	for _, p := range params {
+		p.Status = SuccessBackupStatus
+		p.ScheduleID = ""
+		p.GroupID = ""
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+
+		if _, ok := names[p.Name]; ok {
+			return nil, status.Errorf(codes.AlreadyExists, "Artifact with name %q already exists.", p.Name)
+		}
+		names[p.Name] = struct{}{}
+		if err := checkUniqueArtifactName(q, p.Name); err != nil {
+			return nil, err
+		}
+
+		row := &Artifact{
+			ID:         "/artifact_id/" + uuid.New().String(),
+			Name:       p.Name,
+			Vendor:     p.Vendor,
+			LocationID: p.LocationID,
+			ServiceID:  p.ServiceID,
+			DataModel:  p.DataModel,
+			Status:     p.Status,
+			Type:       OnDemandArtifactType,
+			Metadata:   p.Metadata,
+			CreatedAt:  p.CreatedAt.UTC(),
+		}
+		structs = append(structs, row)
+		res = append(res, row)
+	}
+
+	if err := InsertBatch(q, structs); err != nil {
+		return nil, errors.Wrap(err, "failed to insert artifacts")
+	}
+
+	return res, nil
+}
+
 // UpdateArtifactParams are params for changing existing artifact.
 type UpdateArtifactParams struct {
 	ServiceID  *string
 	Status     *BackupStatus
 	ScheduleID *string
+	Orphaned   *bool
+	Hold       *bool
+	Golden     *bool
+	Size       *int64
+	// Replaced as a whole.
+	Metadata *ArtifactMetadata
 }
 
 // UpdateArtifact updates existing artifact.
@@ -251,6 +385,21 @@ func UpdateArtifact(q *reform.Querier, artifactID string, params UpdateArtifactP
 	if params.ScheduleID != nil {
 		row.ScheduleID = *params.ScheduleID
 	}
+	if params.Orphaned != nil {
+		row.Orphaned = *params.Orphaned
+	}
+	if params.Hold != nil {
+		row.Hold = *params.Hold
+	}
+	if params.Golden != nil {
+		row.Golden = *params.Golden
+	}
+	if params.Size != nil {
+		row.Size = params.Size
+	}
+	if params.Metadata != nil {
+		row.Metadata = params.Metadata
+	}
 
 	if err := q.Update(row); err != nil {
 		return nil, errors.Wrap(err, "failed to update backup artifact")
@@ -17,17 +17,21 @@
 package models_test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 	"gopkg.in/reform.v1/dialects/postgresql"
 
 	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
 )
 
 func TestArtifacts(t *testing.T) {
@@ -118,6 +122,52 @@ func TestArtifacts(t *testing.T) {
 		assert.Less(t, time.Now().UTC().Unix()-a.CreatedAt.Unix(), int64(5))
 	})
 
+	t.Run("import", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+		prepareLocationsAndService(q)
+
+		createdAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+		params := []models.ImportArtifactParams{{
+			CreateArtifactParams: models.CreateArtifactParams{
+				Name:       "imported_1",
+				Vendor:     "MySQL",
+				LocationID: locationID1,
+				ServiceID:  serviceID1,
+				DataModel:  models.PhysicalDataModel,
+			},
+			CreatedAt: createdAt,
+		}, {
+			CreateArtifactParams: models.CreateArtifactParams{
+				Name:       "imported_2",
+				Vendor:     "MySQL",
+				LocationID: locationID1,
+				ServiceID:  serviceID1,
+				DataModel:  models.PhysicalDataModel,
+			},
+			CreatedAt: createdAt,
+		}}
+
+		artifacts, err := models.ImportArtifacts(q, params)
+		require.NoError(t, err)
+		require.Len(t, artifacts, 2)
+
+		a, err := models.FindArtifactByID(q, artifacts[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "imported_2", a.Name)
+		assert.Equal(t, models.SuccessBackupStatus, a.Status)
+		assert.Equal(t, models.OnDemandArtifactType, a.Type)
+		assert.Equal(t, createdAt, a.CreatedAt)
+
+		_, err = models.ImportArtifacts(q, params[:1])
+		tests.AssertGRPCError(t, status.New(codes.AlreadyExists, `Artifact with name "imported_1" already exists.`), err)
+	})
+
 	t.Run("list", func(t *testing.T) {
 		tx, err := db.Begin()
 		require.NoError(t, err)
@@ -168,6 +218,92 @@ func TestArtifacts(t *testing.T) {
 		assert.Condition(t, found(a2.ID), "The second artifact not found")
 	})
 
+	t.Run("golden", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+		prepareLocationsAndService(q)
+
+		a, err := models.CreateArtifact(q, models.CreateArtifactParams{
+			Name:       "golden_backup",
+			Vendor:     "MySQL",
+			LocationID: locationID1,
+			ServiceID:  serviceID1,
+			DataModel:  models.PhysicalDataModel,
+			Status:     models.SuccessBackupStatus,
+		})
+		require.NoError(t, err)
+
+		golden, err := models.FindArtifacts(q, models.ArtifactFilters{Golden: true})
+		require.NoError(t, err)
+		assert.Empty(t, golden)
+
+		_, err = models.UpdateArtifact(q, a.ID, models.UpdateArtifactParams{Golden: pointer.ToBool(true)})
+		require.NoError(t, err)
+
+		golden, err = models.FindArtifacts(q, models.ArtifactFilters{Golden: true})
+		require.NoError(t, err)
+		require.Len(t, golden, 1)
+		assert.Equal(t, a.ID, golden[0].ID)
+
+		actual, err := models.FindArtifactByName(q, "golden_backup")
+		require.NoError(t, err)
+		assert.Equal(t, a.ID, actual.ID)
+		assert.True(t, actual.Golden)
+
+		_, err = models.FindArtifactByName(q, "no_such_backup")
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+		prepareLocationsAndService(q)
+
+		for i, serviceID := range []string{serviceID1, serviceID1, serviceID2} {
+			a, err := models.CreateArtifact(q, models.CreateArtifactParams{
+				Name:       fmt.Sprintf("backup_name_%d", i),
+				Vendor:     "MySQL",
+				LocationID: locationID1,
+				ServiceID:  serviceID,
+				DataModel:  models.PhysicalDataModel,
+				Status:     models.SuccessBackupStatus,
+			})
+			require.NoError(t, err)
+
+			if i != 2 {
+				_, err = models.UpdateArtifact(q, a.ID, models.UpdateArtifactParams{Size: pointer.ToInt64(int64(i+1) * 100)})
+				require.NoError(t, err)
+			}
+		}
+
+		usage, err := models.FindArtifactsUsage(q, locationID1)
+		require.NoError(t, err)
+		expected := []*models.ArtifactsUsage{{
+			ServiceID:      serviceID1,
+			Artifacts:      2,
+			SizedArtifacts: 2,
+			Size:           300,
+		}, {
+			ServiceID: serviceID2,
+			Artifacts: 1,
+		}}
+		assert.Equal(t, expected, usage)
+
+		usage, err = models.FindArtifactsUsage(q, locationID2)
+		require.NoError(t, err)
+		assert.Empty(t, usage)
+	})
+
 	t.Run("remove", func(t *testing.T) {
 		tx, err := db.Begin()
 		require.NoError(t, err)
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"database/sql/driver"
 	"time"
 
 	"github.com/pkg/errors"
@@ -32,6 +33,8 @@ type DataModel string
 const (
 	PhysicalDataModel DataModel = "physical"
 	LogicalDataModel  DataModel = "logical"
+	// Configuration snapshot of HAProxy and External Services; not present in artifacts.proto.
+	SnapshotDataModel DataModel = "snapshot"
 )
 
 // Validate validates data model.
@@ -39,6 +42,7 @@ func (dm DataModel) Validate() error {
 	switch dm {
 	case PhysicalDataModel:
 	case LogicalDataModel:
+	case SnapshotDataModel:
 	default:
 		return errors.Wrapf(ErrInvalidArgument, "invalid data model '%s'", dm)
 	}
@@ -58,6 +62,8 @@ const (
 	ErrorBackupStatus          BackupStatus = "error"
 	DeletingBackupStatus       BackupStatus = "deleting"
 	FailedToDeleteBackupStatus BackupStatus = "failed_to_delete"
+	CanceledBackupStatus       BackupStatus = "canceled"
+	QueuedBackupStatus         BackupStatus = "queued"
 )
 
 // Validate validates backup status.
@@ -70,6 +76,8 @@ func (bs BackupStatus) Validate() error {
 	case ErrorBackupStatus:
 	case DeletingBackupStatus:
 	case FailedToDeleteBackupStatus:
+	case CanceledBackupStatus:
+	case QueuedBackupStatus:
 	default:
 		return errors.Wrapf(ErrInvalidArgument, "invalid status '%s'", bs)
 	}
@@ -91,24 +99,59 @@ const (
 	ScheduledArtifactType ArtifactType = "scheduled"
 )
 
+// Validate validates artifact type.
+func (t ArtifactType) Validate() error {
+	switch t {
+	case OnDemandArtifactType:
+	case ScheduledArtifactType:
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "invalid artifact type '%s'", t)
+	}
+
+	return nil
+}
+
+// ArtifactMetadata contains information about the Service software at backup time.
+type ArtifactMetadata struct {
+	// Versions of DB server and backup tools known for the Service; empty if they were not collected yet.
+	SoftwareVersions SoftwareVersions `json:"software_versions,omitempty"`
+	// ID of GPG key used to sign artifact manifest; empty if artifact is not signed.
+	SignatureKeyID string `json:"signature_key_id,omitempty"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (m ArtifactMetadata) Value() (driver.Value, error) { return jsonValue(m) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (m *ArtifactMetadata) Scan(src interface{}) error { return jsonScan(m, src) }
+
 // Artifact represents result of a backup.
 //reform:artifacts
 type Artifact struct {
-	ID         string       `reform:"id,pk"`
-	Name       string       `reform:"name"`
-	Vendor     string       `reform:"vendor"`
-	LocationID string       `reform:"location_id"`
-	ServiceID  string       `reform:"service_id"`
-	DataModel  DataModel    `reform:"data_model"`
-	Status     BackupStatus `reform:"status"`
-	Type       ArtifactType `reform:"type"`
-	ScheduleID string       `reform:"schedule_id"`
-	CreatedAt  time.Time    `reform:"created_at"`
+	ID         string            `reform:"id,pk"`
+	Name       string            `reform:"name"`
+	Vendor     string            `reform:"vendor"`
+	LocationID string            `reform:"location_id"`
+	ServiceID  string            `reform:"service_id"`
+	DataModel  DataModel         `reform:"data_model"`
+	Status     BackupStatus      `reform:"status"`
+	Type       ArtifactType      `reform:"type"`
+	ScheduleID string            `reform:"schedule_id"`
+	GroupID    string            `reform:"group_id"`
+	Orphaned   bool              `reform:"orphaned"`
+	Hold       bool              `reform:"hold"`
+	Golden     bool              `reform:"golden"`
+	Size       *int64            `reform:"size"`
+	Metadata   *ArtifactMetadata `reform:"metadata"`
+	CreatedAt  time.Time         `reform:"created_at"`
 }
 
 // BeforeInsert implements reform.BeforeInserter interface.
 func (s *Artifact) BeforeInsert() error {
-	s.CreatedAt = Now()
+	// imported artifacts keep their original creation time
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = Now()
+	}
 	return nil
 }
 
@@ -37,6 +37,12 @@ func (v *artifactTableType) Columns() []string {
 		"status",
 		"type",
 		"schedule_id",
+		"group_id",
+		"orphaned",
+		"hold",
+		"golden",
+		"size",
+		"metadata",
 		"created_at",
 	}
 }
@@ -71,6 +77,12 @@ var ArtifactTable = &artifactTableType{
 			{Name: "Status", Type: "BackupStatus", Column: "status"},
 			{Name: "Type", Type: "ArtifactType", Column: "type"},
 			{Name: "ScheduleID", Type: "string", Column: "schedule_id"},
+			{Name: "GroupID", Type: "string", Column: "group_id"},
+			{Name: "Orphaned", Type: "bool", Column: "orphaned"},
+			{Name: "Hold", Type: "bool", Column: "hold"},
+			{Name: "Golden", Type: "bool", Column: "golden"},
+			{Name: "Size", Type: "*int64", Column: "size"},
+			{Name: "Metadata", Type: "*ArtifactMetadata", Column: "metadata"},
 			{Name: "CreatedAt", Type: "time.Time", Column: "created_at"},
 		},
 		PKFieldIndex: 0,
@@ -80,7 +92,7 @@ var ArtifactTable = &artifactTableType{
 
 // String returns a string representation of this struct or record.
 func (s Artifact) String() string {
-	res := make([]string, 10)
+	res := make([]string, 16)
 	res[0] = "ID: " + reform.Inspect(s.ID, true)
 	res[1] = "Name: " + reform.Inspect(s.Name, true)
 	res[2] = "Vendor: " + reform.Inspect(s.Vendor, true)
@@ -90,7 +102,13 @@ func (s Artifact) String() string {
 	res[6] = "Status: " + reform.Inspect(s.Status, true)
 	res[7] = "Type: " + reform.Inspect(s.Type, true)
 	res[8] = "ScheduleID: " + reform.Inspect(s.ScheduleID, true)
-	res[9] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
+	res[9] = "GroupID: " + reform.Inspect(s.GroupID, true)
+	res[10] = "Orphaned: " + reform.Inspect(s.Orphaned, true)
+	res[11] = "Hold: " + reform.Inspect(s.Hold, true)
+	res[12] = "Golden: " + reform.Inspect(s.Golden, true)
+	res[13] = "Size: " + reform.Inspect(s.Size, true)
+	res[14] = "Metadata: " + reform.Inspect(s.Metadata, true)
+	res[15] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
 	return strings.Join(res, ", ")
 }
 
@@ -107,6 +125,12 @@ func (s *Artifact) Values() []interface{} {
 		s.Status,
 		s.Type,
 		s.ScheduleID,
+		s.GroupID,
+		s.Orphaned,
+		s.Hold,
+		s.Golden,
+		s.Size,
+		s.Metadata,
 		s.CreatedAt,
 	}
 }
@@ -124,6 +148,12 @@ func (s *Artifact) Pointers() []interface{} {
 		&s.Status,
 		&s.Type,
 		&s.ScheduleID,
+		&s.GroupID,
+		&s.Orphaned,
+		&s.Hold,
+		&s.Golden,
+		&s.Size,
+		&s.Metadata,
 		&s.CreatedAt,
 	}
 }
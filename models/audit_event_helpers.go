@@ -0,0 +1,97 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+// AuditEventsFilter represents filters for audit events list.
+type AuditEventsFilter struct {
+	// Return only events of that object.
+	ObjectID string
+	// Return only events of that type.
+	Type AuditEventType
+	// Return only events created after that time.
+	CreatedAfter time.Time
+	// Return at most that many latest events; all events if zero.
+	Limit int
+}
+
+// FindAuditEvents returns audit events satisfying filter, latest first.
+func FindAuditEvents(q *reform.Querier, filter AuditEventsFilter) ([]*AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ObjectID != "" {
+		args = append(args, filter.ObjectID)
+		conditions = append(conditions, "object_id = "+q.Placeholder(len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, "type = "+q.Placeholder(len(args)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		args = append(args, filter.CreatedAfter)
+		conditions = append(conditions, "created_at > "+q.Placeholder(len(args)))
+	}
+
+	var tail string
+	if len(conditions) != 0 {
+		tail = "WHERE " + strings.Join(conditions, " AND ") + " "
+	}
+	tail += "ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		tail += " LIMIT " + q.Placeholder(len(args))
+	}
+
+	structs, err := q.SelectAllFrom(AuditEventTable, tail, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*AuditEvent, len(structs))
+	for i, s := range structs {
+		res[i] = s.(*AuditEvent)
+	}
+	return res, nil
+}
+
+// CreateAuditEvent records audit event of given type for the object.
+// Actor is the Grafana user who made the change, or empty string for PMM Server itself.
+func CreateAuditEvent(q *reform.Querier, eventType AuditEventType, objectID, actor, message string) (*AuditEvent, error) {
+	if eventType == "" {
+		return nil, errors.New("empty audit event type")
+	}
+
+	e := &AuditEvent{
+		ID:       "/audit_event_id/" + uuid.New().String(),
+		Type:     eventType,
+		ObjectID: objectID,
+		Actor:    actor,
+		Message:  message,
+	}
+	if err := q.Insert(e); err != nil {
+		return nil, errors.Wrap(err, "failed to insert audit event")
+	}
+	return e, nil
+}
@@ -0,0 +1,71 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// AuditEventType represents type of audit log event.
+type AuditEventType string
+
+// Audit event types.
+const (
+	NodeExpiredAuditEventType           AuditEventType = "node_expired"
+	ServiceExpiredAuditEventType        AuditEventType = "service_expired"
+	ArtifactHoldAuditEventType          AuditEventType = "artifact_hold"
+	ArtifactHoldReleasedAuditEventType  AuditEventType = "artifact_hold_released"
+	ArtifactGoldenAuditEventType        AuditEventType = "artifact_golden"
+	ArtifactGoldenRemovedAuditEventType AuditEventType = "artifact_golden_removed"
+)
+
+// AuditEvent represents a single audit log event: a change made by PMM Server itself or by a user.
+//reform:audit_events
+type AuditEvent struct {
+	ID   string         `reform:"id,pk"`
+	Type AuditEventType `reform:"type"`
+	// ID of the changed object (Node, Service, etc.).
+	ObjectID string `reform:"object_id"`
+	// Grafana user who made the change; empty for changes made by PMM Server itself.
+	Actor     string    `reform:"actor"`
+	Message   string    `reform:"message"`
+	CreatedAt time.Time `reform:"created_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (e *AuditEvent) BeforeInsert() error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = Now()
+	}
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (e *AuditEvent) AfterFind() error {
+	e.CreatedAt = e.CreatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*AuditEvent)(nil)
+	_ reform.AfterFinder    = (*AuditEvent)(nil)
+)
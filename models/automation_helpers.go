@@ -0,0 +1,185 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"net/url"
+	"text/template"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// FindAutomations returns all automations.
+func FindAutomations(q *reform.Querier, enabledOnly bool) ([]*Automation, error) {
+	var tail string
+	if enabledOnly {
+		tail = "WHERE NOT disabled "
+	}
+	tail += "ORDER BY name"
+
+	rows, err := q.SelectAllFrom(AutomationTable, tail)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*Automation, len(rows))
+	for i, r := range rows {
+		res[i] = r.(*Automation)
+	}
+	return res, nil
+}
+
+// FindAutomationByID finds automation by ID.
+func FindAutomationByID(q *reform.Querier, id string) (*Automation, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty automation ID.")
+	}
+
+	res := &Automation{ID: id}
+	switch err := q.Reload(res); err {
+	case nil:
+		return res, nil
+	case reform.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "Automation with ID %q not found.", id)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// CreateAutomationParams are params for creating automation.
+type CreateAutomationParams struct {
+	Name       string
+	Filters    Filters
+	ActionType AutomationActionType
+	Params     *AutomationParams
+	Disabled   bool
+}
+
+// Validate validates params.
+func (p *CreateAutomationParams) Validate() error {
+	if p.Name == "" {
+		return status.Error(codes.InvalidArgument, "Empty automation name.")
+	}
+
+	if err := p.Filters.Validate(); err != nil {
+		return err
+	}
+
+	if p.Params == nil {
+		p.Params = new(AutomationParams)
+	}
+	switch p.ActionType {
+	case AnnotationAutomationAction, DiagnosticAutomationAction:
+	case WebhookAutomationAction:
+		if p.Params.Webhook == nil || p.Params.Webhook.URL == "" {
+			return status.Error(codes.InvalidArgument, "Webhook URL is required.")
+		}
+		if _, err := url.ParseRequestURI(p.Params.Webhook.URL); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid webhook URL: %s.", err)
+		}
+	case BackupAutomationAction:
+		if p.Params.Backup == nil || p.Params.Backup.LocationID == "" {
+			return status.Error(codes.InvalidArgument, "Backup location ID is required.")
+		}
+	case JiraAutomationAction:
+		return validateJiraParams(p.Params.Jira)
+	case PagerDutyAutomationAction:
+		if p.Params.PagerDuty == nil || p.Params.PagerDuty.RoutingKey == "" {
+			return status.Error(codes.InvalidArgument, "PagerDuty routing key is required.")
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported automation action type %q.", p.ActionType)
+	}
+	return nil
+}
+
+func validateJiraParams(params *JiraAutomationParams) error {
+	if params == nil {
+		return status.Error(codes.InvalidArgument, "Jira parameters are required.")
+	}
+	if _, err := url.ParseRequestURI(params.URL); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid Jira URL: %s.", err)
+	}
+	if params.Username == "" || params.APIToken == "" {
+		return status.Error(codes.InvalidArgument, "Jira username and API token are required.")
+	}
+	if params.ProjectKey == "" || params.IssueType == "" {
+		return status.Error(codes.InvalidArgument, "Jira project key and issue type are required.")
+	}
+	for field, text := range params.Fields {
+		if _, err := template.New(field).Parse(text); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid template of Jira field %q: %s.", field, err)
+		}
+	}
+	return nil
+}
+
+// CreateAutomation creates automation.
+func CreateAutomation(q *reform.Querier, params *CreateAutomationParams) (*Automation, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if params.ActionType == BackupAutomationAction {
+		if _, err := FindBackupLocationByID(q, params.Params.Backup.LocationID); err != nil {
+			return nil, err
+		}
+	}
+
+	row := &Automation{
+		ID:         "/automation_id/" + uuid.New().String(),
+		Name:       params.Name,
+		Filters:    params.Filters,
+		ActionType: params.ActionType,
+		Params:     params.Params,
+		Disabled:   params.Disabled,
+	}
+	if err := q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// ChangeAutomation enables or disables automation.
+func ChangeAutomation(q *reform.Querier, id string, disabled bool) (*Automation, error) {
+	row, err := FindAutomationByID(q, id)
+	if err != nil {
+		return nil, err
+	}
+
+	row.Disabled = disabled
+	if err = q.Update(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// RemoveAutomation removes automation by ID.
+func RemoveAutomation(q *reform.Querier, id string) error {
+	if _, err := FindAutomationByID(q, id); err != nil {
+		return err
+	}
+
+	if err := q.Delete(&Automation{ID: id}); err != nil {
+		return errors.Wrap(err, "failed to delete automation")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestAutomations(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	t.Run("create, change and remove", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		a, err := models.CreateAutomation(q, &models.CreateAutomationParams{
+			Name:       "open ticket",
+			Filters:    models.Filters{{Type: models.Equal, Key: "severity", Val: "critical"}},
+			ActionType: models.WebhookAutomationAction,
+			Params: &models.AutomationParams{
+				Webhook: &models.WebhookAutomationParams{URL: "https://tickets.example.com/api/issues"},
+			},
+		})
+		require.NoError(t, err)
+
+		a, err = models.FindAutomationByID(q, a.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "https://tickets.example.com/api/issues", a.Params.Webhook.URL)
+		assert.Equal(t, "critical", a.Filters[0].Val)
+
+		_, err = models.ChangeAutomation(q, a.ID, true)
+		require.NoError(t, err)
+
+		automations, err := models.FindAutomations(q, false)
+		require.NoError(t, err)
+		assert.Len(t, automations, 1)
+		automations, err = models.FindAutomations(q, true)
+		require.NoError(t, err)
+		assert.Empty(t, automations)
+
+		require.NoError(t, models.RemoveAutomation(q, a.ID))
+		_, err = models.FindAutomationByID(q, a.ID)
+		assert.EqualError(t, err, `rpc error: code = NotFound desc = Automation with ID "`+a.ID+`" not found.`)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		_, err = models.CreateAutomation(q, &models.CreateAutomationParams{
+			Name:       "backup",
+			ActionType: models.BackupAutomationAction,
+		})
+		assert.EqualError(t, err, `rpc error: code = InvalidArgument desc = Backup location ID is required.`)
+
+		_, err = models.CreateAutomation(q, &models.CreateAutomationParams{
+			Name:       "annotate",
+			Filters:    models.Filters{{Type: models.Regex, Key: "alertname", Val: "("}},
+			ActionType: models.AnnotationAutomationAction,
+		})
+		assert.Error(t, err)
+
+		_, err = models.CreateAutomation(q, &models.CreateAutomationParams{
+			Name:       "jira",
+			ActionType: models.JiraAutomationAction,
+			Params: &models.AutomationParams{
+				Jira: &models.JiraAutomationParams{URL: "https://example.atlassian.net", ProjectKey: "OPS", IssueType: "Bug"},
+			},
+		})
+		assert.EqualError(t, err, `rpc error: code = InvalidArgument desc = Jira username and API token are required.`)
+
+		_, err = models.CreateAutomation(q, &models.CreateAutomationParams{
+			Name:       "pagerduty",
+			ActionType: models.PagerDutyAutomationAction,
+		})
+		assert.EqualError(t, err, `rpc error: code = InvalidArgument desc = PagerDuty routing key is required.`)
+
+		_, err = models.CreateAutomation(q, &models.CreateAutomationParams{
+			Name:       "unknown",
+			ActionType: "restart",
+		})
+		assert.EqualError(t, err, `rpc error: code = InvalidArgument desc = Unsupported automation action type "restart".`)
+	})
+}
@@ -0,0 +1,142 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// AutomationActionType represents an action triggered by Alertmanager notification.
+type AutomationActionType string
+
+// Supported automation action types.
+const (
+	AnnotationAutomationAction AutomationActionType = "annotation"
+	DiagnosticAutomationAction AutomationActionType = "diagnostic"
+	WebhookAutomationAction    AutomationActionType = "webhook"
+	BackupAutomationAction     AutomationActionType = "backup"
+	JiraAutomationAction       AutomationActionType = "jira"
+	PagerDutyAutomationAction  AutomationActionType = "pagerduty"
+)
+
+// SyncsResolution returns true if action is also run for resolved alerts.
+func (t AutomationActionType) SyncsResolution() bool {
+	return t == JiraAutomationAction || t == PagerDutyAutomationAction
+}
+
+// AnnotationAutomationParams represents parameters of Grafana annotation created for alert.
+type AnnotationAutomationParams struct {
+	// Annotation text; alert summary is used if empty.
+	Text string   `json:"text,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+	// Grafana API key with Editor role created by pmm-managed for this automation,
+	// since Alertmanager notifications are received without user's credentials.
+	APIKeyID int64  `json:"api_key_id,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+}
+
+// WebhookAutomationParams represents parameters of outbound webhook (for example, to open a ticket).
+type WebhookAutomationParams struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// BackupAutomationParams represents parameters of on-demand backup of alert's Service.
+type BackupAutomationParams struct {
+	LocationID string `json:"location_id"`
+}
+
+// JiraAutomationParams represents parameters of Jira issue created for alert.
+type JiraAutomationParams struct {
+	// Jira base URL, for example, https://example.atlassian.net.
+	URL        string `json:"url"`
+	Username   string `json:"username"`
+	APIToken   string `json:"api_token"`
+	ProjectKey string `json:"project_key"`
+	IssueType  string `json:"issue_type"`
+	// Issue field ID to text/template mapping, for example, {"priority": "{{ .Labels.severity }}"}.
+	// Templates are executed with the alert; summary and description are set from alert annotations by default.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Name of the transition applied to the issue when alert is resolved; issue is not changed if empty.
+	ResolveTransition string `json:"resolve_transition,omitempty"`
+}
+
+// PagerDutyAutomationParams represents parameters of PagerDuty Events API v2 integration.
+type PagerDutyAutomationParams struct {
+	RoutingKey string `json:"routing_key"`
+}
+
+// AutomationParams represents action type specific parameters.
+type AutomationParams struct {
+	Annotation *AnnotationAutomationParams `json:"annotation,omitempty"`
+	Webhook    *WebhookAutomationParams    `json:"webhook,omitempty"`
+	Backup     *BackupAutomationParams     `json:"backup,omitempty"`
+	Jira       *JiraAutomationParams       `json:"jira,omitempty"`
+	PagerDuty  *PagerDutyAutomationParams  `json:"pagerduty,omitempty"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (p AutomationParams) Value() (driver.Value, error) { return jsonValue(p) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (p *AutomationParams) Scan(src interface{}) error { return jsonScan(p, src) }
+
+// Automation represents an action triggered by firing alerts matching filters.
+//reform:automations
+type Automation struct {
+	ID         string               `reform:"id,pk"`
+	Name       string               `reform:"name"`
+	Filters    Filters              `reform:"filters"`
+	ActionType AutomationActionType `reform:"action_type"`
+	Params     *AutomationParams    `reform:"params"`
+	Disabled   bool                 `reform:"disabled"`
+	CreatedAt  time.Time            `reform:"created_at"`
+	UpdatedAt  time.Time            `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (a *Automation) BeforeInsert() error {
+	now := Now()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (a *Automation) BeforeUpdate() error {
+	a.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (a *Automation) AfterFind() error {
+	a.CreatedAt = a.CreatedAt.UTC()
+	a.UpdatedAt = a.UpdatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*Automation)(nil)
+	_ reform.BeforeUpdater  = (*Automation)(nil)
+	_ reform.AfterFinder    = (*Automation)(nil)
+)
@@ -0,0 +1,204 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+// maxBatchParams is a maximal number of parameters of a single query supported by PostgreSQL.
+const maxBatchParams = 65535
+
+// columnTypes caches PostgreSQL types of tables' columns used for casting batch update values.
+var columnTypes sync.Map // table name -> map[column]type
+
+// batchSize returns a number of rows with given number of columns that fit in a single query.
+func batchSize(columns int) int {
+	if columns == 0 {
+		return maxBatchParams
+	}
+	return maxBatchParams / columns
+}
+
+// InsertBatch inserts structs of the same table with multi-row INSERT statements,
+// splitting them into several statements only if the query parameters limit is reached.
+// BeforeInsert hooks are called for all structs.
+func InsertBatch(q *reform.Querier, structs []reform.Struct) error {
+	if len(structs) == 0 {
+		return nil
+	}
+
+	size := batchSize(len(structs[0].View().Columns()))
+	for start := 0; start < len(structs); start += size {
+		end := start + size
+		if end > len(structs) {
+			end = len(structs)
+		}
+		if err := q.InsertMulti(structs[start:end]...); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// UpdateBatch updates given columns of records of the same table with multi-row UPDATE statements,
+// splitting them into several statements only if the query parameters limit is reached.
+// BeforeUpdate hooks are called for all records; if hooks change other columns, they should be included too.
+func UpdateBatch(q *reform.Querier, records []reform.Record, columns ...string) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if len(columns) == 0 {
+		return errors.New("no columns to update")
+	}
+
+	table := records[0].Table()
+	allColumns := table.Columns()
+	pk := allColumns[table.PKColumnIndex()]
+
+	indexes := make([]int, 0, len(columns)+1)
+	for _, c := range append([]string{pk}, columns...) {
+		idx := -1
+		for i, ac := range allColumns {
+			if ac == c {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return errors.Errorf("unknown column %q of table %s", c, table.Name())
+		}
+		indexes = append(indexes, idx)
+	}
+
+	for _, r := range records {
+		if r.Table() != table {
+			return errors.Errorf("different tables in batch: %s and %s", table.Name(), r.Table().Name())
+		}
+		if bu, ok := r.(reform.BeforeUpdater); ok {
+			if err := bu.BeforeUpdate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	types, err := getColumnTypes(q, table)
+	if err != nil {
+		return err
+	}
+	casts := make([]string, len(indexes))
+	for i, idx := range indexes {
+		casts[i] = types[allColumns[idx]]
+	}
+
+	size := batchSize(len(indexes))
+	for start := 0; start < len(records); start += size {
+		end := start + size
+		if end > len(records) {
+			end = len(records)
+		}
+		batch := records[start:end]
+
+		args := make([]interface{}, 0, len(batch)*len(indexes))
+		for _, r := range batch {
+			values := r.Values()
+			for _, idx := range indexes {
+				args = append(args, values[idx])
+			}
+		}
+
+		query := updateBatchQuery(q, table, append([]string{pk}, columns...), casts, len(batch))
+		res, err := q.Exec(query, args...)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if int(n) != len(batch) {
+			return errors.Errorf("expected %d rows of table %s to be updated, got %d", len(batch), table.Name(), n)
+		}
+	}
+	return nil
+}
+
+// updateBatchQuery returns UPDATE ... FROM (VALUES ...) query for given number of rows.
+// The first column is a primary key; values are casted to given types.
+func updateBatchQuery(q *reform.Querier, table reform.Table, columns, casts []string, rows int) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = q.QuoteIdentifier(c)
+	}
+
+	set := make([]string, 0, len(columns)-1)
+	for _, c := range quoted[1:] {
+		set = append(set, fmt.Sprintf("%s = v.%s", c, c))
+	}
+
+	placeholders := q.Placeholders(1, len(columns)*rows)
+	values := make([]string, rows)
+	for i := range values {
+		row := make([]string, len(columns))
+		for j := range columns {
+			row[j] = placeholders[i*len(columns)+j]
+			if casts[j] != "" {
+				row[j] += "::" + casts[j]
+			}
+		}
+		values[i] = "(" + strings.Join(row, ", ") + ")"
+	}
+
+	return fmt.Sprintf("UPDATE %s SET %s FROM (VALUES %s) AS v (%s) WHERE %s.%s = v.%s",
+		q.QualifiedView(table), strings.Join(set, ", "),
+		strings.Join(values, ", "), strings.Join(quoted, ", "),
+		q.QualifiedView(table), quoted[0], quoted[0])
+}
+
+// getColumnTypes returns PostgreSQL types of table columns.
+func getColumnTypes(q *reform.Querier, table reform.Table) (map[string]string, error) {
+	if types, ok := columnTypes.Load(table.Name()); ok {
+		return types.(map[string]string), nil
+	}
+
+	rows, err := q.Query("SELECT attname, format_type(atttypid, atttypmod) FROM pg_attribute "+
+		"WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped", table.Name())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	types := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err = rows.Scan(&name, &typ); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		types[name] = typ
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	columnTypes.Store(table.Name(), types)
+	return types, nil
+}
@@ -0,0 +1,91 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestBatchHelpers(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	t.Run("InsertAndUpdate", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		structs := make([]reform.Struct, 10)
+		for i := range structs {
+			structs[i] = &models.JobResult{
+				ID:         fmt.Sprintf("/job_id/%d", i),
+				PMMAgentID: "pmm_agent_id",
+				Type:       models.Echo,
+			}
+		}
+		require.NoError(t, models.InsertBatch(q, structs))
+
+		records := make([]reform.Record, len(structs))
+		for i, str := range structs {
+			res := str.(*models.JobResult)
+			res.Done = true
+			res.Result = &models.JobResultData{Echo: &models.EchoJobResult{Message: res.ID}}
+			records[i] = res
+		}
+		require.NoError(t, models.UpdateBatch(q, records, "done", "result", "updated_at"))
+
+		for i := range structs {
+			res, err := models.FindJobResultByID(q, fmt.Sprintf("/job_id/%d", i))
+			require.NoError(t, err)
+			assert.True(t, res.Done)
+			assert.Equal(t, res.ID, res.Result.Echo.Message)
+		}
+	})
+
+	t.Run("UpdateUnknown", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		err = models.UpdateBatch(q, []reform.Record{&models.JobResult{ID: "/job_id/unknown"}}, "done")
+		assert.EqualError(t, err, "expected 1 rows of table job_results to be updated, got 0")
+
+		err = models.UpdateBatch(q, []reform.Record{&models.JobResult{ID: "/job_id/unknown"}}, "no_such_column")
+		assert.EqualError(t, err, `unknown column "no_such_column" of table job_results`)
+	})
+}
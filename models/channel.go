@@ -48,6 +48,8 @@ type Channel struct {
 	SlackConfig     *SlackConfig     `reform:"slack_config"`
 	WebHookConfig   *WebHookConfig   `reform:"webhook_config"`
 
+	Throttling *ChannelThrottling `reform:"throttling"`
+
 	Disabled bool `reform:"disabled"`
 
 	CreatedAt time.Time `reform:"created_at"`
@@ -154,6 +156,25 @@ type TLSConfig struct {
 	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
 }
 
+// ChannelThrottling is notification channel rate limiting and digest mode configuration.
+// Notifications are throttled separately for each alert rule (or backup schedule) using the channel.
+type ChannelThrottling struct {
+	// Maximum number of notifications per Interval; 0 means no limit.
+	MaxNotifications uint32        `json:"max_notifications,omitempty"`
+	Interval         time.Duration `json:"interval,omitempty"`
+
+	// Alerts with DigestSeverity or lower severity are batched into a single summary notification
+	// every DigestInterval; empty value disables digest mode.
+	DigestSeverity string        `json:"digest_severity,omitempty"`
+	DigestInterval time.Duration `json:"digest_interval,omitempty"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (c ChannelThrottling) Value() (driver.Value, error) { return jsonValue(c) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (c *ChannelThrottling) Scan(src interface{}) error { return jsonScan(c, src) }
+
 // check interfaces.
 var (
 	_ reform.BeforeInserter = (*Channel)(nil)
@@ -19,8 +19,10 @@ package models
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/percona-platform/saas/pkg/common"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -93,6 +95,32 @@ func checkWebHookConfig(c *WebHookConfig) error {
 	return nil
 }
 
+func checkChannelThrottling(c *ChannelThrottling) error {
+	if c.MaxNotifications == 0 && c.DigestSeverity == "" {
+		return status.Error(codes.InvalidArgument, "Throttling should contain max notifications or digest severity.")
+	}
+
+	if c.MaxNotifications != 0 {
+		if c.Interval <= 0 {
+			return status.Error(codes.InvalidArgument, "Throttling interval should be positive.")
+		}
+		if c.Interval/time.Duration(c.MaxNotifications) < time.Second {
+			return status.Error(codes.InvalidArgument, "Throttling allows more than one notification per second.")
+		}
+	}
+
+	if c.DigestSeverity != "" {
+		if err := common.ParseSeverity(c.DigestSeverity).Validate(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid digest severity %q.", c.DigestSeverity)
+		}
+		if c.DigestInterval < time.Minute {
+			return status.Error(codes.InvalidArgument, "Digest interval should be at least one minute.")
+		}
+	}
+
+	return nil
+}
+
 // FindChannels returns saved notification channels configuration.
 func FindChannels(q *reform.Querier) ([]*Channel, error) {
 	rows, err := q.SelectAllFrom(ChannelTable, "")
@@ -339,6 +367,28 @@ func ChangeChannel(q *reform.Querier, channelID string, params *ChangeChannelPar
 	return row, nil
 }
 
+// ChangeChannelThrottling sets rate limiting and digest mode of existing notifications channel;
+// nil throttling disables both.
+func ChangeChannelThrottling(q *reform.Querier, channelID string, throttling *ChannelThrottling) (*Channel, error) {
+	row, err := FindChannelByID(q, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if throttling != nil {
+		if err = checkChannelThrottling(throttling); err != nil {
+			return nil, err
+		}
+	}
+	row.Throttling = throttling
+
+	if err = q.Update(row); err != nil {
+		return nil, errors.Wrap(err, "failed to update notifications channel")
+	}
+
+	return row, nil
+}
+
 // RemoveChannel removes notification channel with specified id.
 func RemoveChannel(q *reform.Querier, id string) error {
 	if _, err := FindChannelByID(q, id); err != nil {
@@ -18,6 +18,7 @@ package models_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/stretchr/testify/assert"
@@ -113,6 +114,51 @@ func TestNotificationChannels(t *testing.T) {
 		assert.Equal(t, updated, actual)
 	})
 
+	t.Run("change throttling", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, tx.Rollback())
+		}()
+
+		q := tx.Querier
+
+		created, err := models.CreateChannel(q, &models.CreateChannelParams{
+			Summary: "some summary",
+			SlackConfig: &models.SlackConfig{
+				Channel: "general",
+			},
+		})
+		require.NoError(t, err)
+
+		throttling := &models.ChannelThrottling{
+			MaxNotifications: 10,
+			Interval:         time.Hour,
+			DigestSeverity:   "notice",
+			DigestInterval:   30 * time.Minute,
+		}
+		updated, err := models.ChangeChannelThrottling(q, created.ID, throttling)
+		require.NoError(t, err)
+		assert.Equal(t, throttling, updated.Throttling)
+
+		actual, err := models.FindChannelByID(q, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, throttling, actual.Throttling)
+
+		_, err = models.ChangeChannelThrottling(q, created.ID, &models.ChannelThrottling{DigestSeverity: "unknown", DigestInterval: time.Hour})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Invalid digest severity "unknown".`), err)
+
+		_, err = models.ChangeChannelThrottling(q, created.ID, &models.ChannelThrottling{MaxNotifications: 100, Interval: time.Minute})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Throttling allows more than one notification per second."), err)
+
+		_, err = models.ChangeChannelThrottling(q, created.ID, &models.ChannelThrottling{})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Throttling should contain max notifications or digest severity."), err)
+
+		updated, err = models.ChangeChannelThrottling(q, created.ID, nil)
+		require.NoError(t, err)
+		assert.Nil(t, updated.Throttling)
+	})
+
 	t.Run("remove", func(t *testing.T) {
 		tx, err := db.Begin()
 		require.NoError(t, err)
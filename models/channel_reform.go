@@ -35,6 +35,7 @@ func (v *channelTableType) Columns() []string {
 		"pagerduty_config",
 		"slack_config",
 		"webhook_config",
+		"throttling",
 		"disabled",
 		"created_at",
 		"updated_at",
@@ -69,6 +70,7 @@ var ChannelTable = &channelTableType{
 			{Name: "PagerDutyConfig", Type: "*PagerDutyConfig", Column: "pagerduty_config"},
 			{Name: "SlackConfig", Type: "*SlackConfig", Column: "slack_config"},
 			{Name: "WebHookConfig", Type: "*WebHookConfig", Column: "webhook_config"},
+			{Name: "Throttling", Type: "*ChannelThrottling", Column: "throttling"},
 			{Name: "Disabled", Type: "bool", Column: "disabled"},
 			{Name: "CreatedAt", Type: "time.Time", Column: "created_at"},
 			{Name: "UpdatedAt", Type: "time.Time", Column: "updated_at"},
@@ -80,7 +82,7 @@ var ChannelTable = &channelTableType{
 
 // String returns a string representation of this struct or record.
 func (s Channel) String() string {
-	res := make([]string, 10)
+	res := make([]string, 11)
 	res[0] = "ID: " + reform.Inspect(s.ID, true)
 	res[1] = "Summary: " + reform.Inspect(s.Summary, true)
 	res[2] = "Type: " + reform.Inspect(s.Type, true)
@@ -88,9 +90,10 @@ func (s Channel) String() string {
 	res[4] = "PagerDutyConfig: " + reform.Inspect(s.PagerDutyConfig, true)
 	res[5] = "SlackConfig: " + reform.Inspect(s.SlackConfig, true)
 	res[6] = "WebHookConfig: " + reform.Inspect(s.WebHookConfig, true)
-	res[7] = "Disabled: " + reform.Inspect(s.Disabled, true)
-	res[8] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
-	res[9] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
+	res[7] = "Throttling: " + reform.Inspect(s.Throttling, true)
+	res[8] = "Disabled: " + reform.Inspect(s.Disabled, true)
+	res[9] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
+	res[10] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
 	return strings.Join(res, ", ")
 }
 
@@ -105,6 +108,7 @@ func (s *Channel) Values() []interface{} {
 		s.PagerDutyConfig,
 		s.SlackConfig,
 		s.WebHookConfig,
+		s.Throttling,
 		s.Disabled,
 		s.CreatedAt,
 		s.UpdatedAt,
@@ -122,6 +126,7 @@ func (s *Channel) Pointers() []interface{} {
 		&s.PagerDutyConfig,
 		&s.SlackConfig,
 		&s.WebHookConfig,
+		&s.Throttling,
 		&s.Disabled,
 		&s.CreatedAt,
 		&s.UpdatedAt,
@@ -0,0 +1,192 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// jobNameRE matches valid custom scrape job names.
+var jobNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+// reservedJobNames contains names of scrape jobs generated for PMM Server components.
+var reservedJobNames = map[string]struct{}{
+	"victoriametrics":  {},
+	"vmalert":          {},
+	"alertmanager":     {},
+	"grafana":          {},
+	"pmm-managed":      {},
+	"qan-api2":         {},
+	"dbaas-controller": {},
+}
+
+// FindCustomScrapeConfigs returns all custom scrape jobs.
+func FindCustomScrapeConfigs(q *reform.Querier) ([]*CustomScrapeConfig, error) {
+	rows, err := q.SelectAllFrom(CustomScrapeConfigTable, "ORDER BY job_name")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*CustomScrapeConfig, len(rows))
+	for i, r := range rows {
+		res[i] = r.(*CustomScrapeConfig)
+	}
+	return res, nil
+}
+
+// FindCustomScrapeConfigByID finds custom scrape job by ID.
+func FindCustomScrapeConfigByID(q *reform.Querier, id string) (*CustomScrapeConfig, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty custom scrape config ID.")
+	}
+
+	res := &CustomScrapeConfig{ID: id}
+	switch err := q.Reload(res); err {
+	case nil:
+		return res, nil
+	case reform.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "Custom scrape config with ID %q not found.", id)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// CreateCustomScrapeConfigParams are params for creating custom scrape job.
+type CreateCustomScrapeConfigParams struct {
+	JobName string
+	// host:port pairs.
+	Targets []string
+	Labels  map[string]string
+	// Zero value means global scrape interval.
+	Interval    time.Duration
+	MetricsPath string
+	Scheme      string
+	Username    string
+	Password    string
+}
+
+// Validate validates params.
+func (p *CreateCustomScrapeConfigParams) Validate() error {
+	if !jobNameRE.MatchString(p.JobName) {
+		return status.Errorf(codes.InvalidArgument, "Invalid job name %q.", p.JobName)
+	}
+	if _, ok := reservedJobNames[p.JobName]; ok {
+		return status.Errorf(codes.InvalidArgument, "Job name %q is reserved.", p.JobName)
+	}
+
+	if len(p.Targets) == 0 {
+		return status.Error(codes.InvalidArgument, "Empty targets.")
+	}
+	for _, t := range p.Targets {
+		if _, _, err := net.SplitHostPort(t); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid target %q: should be in host:port format.", t)
+		}
+	}
+
+	for name, value := range p.Labels {
+		if !labelNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return status.Errorf(codes.InvalidArgument, "Invalid label name %q.", name)
+		}
+		if strings.TrimSpace(value) == "" {
+			return status.Errorf(codes.InvalidArgument, "Empty value of label %q.", name)
+		}
+	}
+
+	if p.Interval != 0 && p.Interval < time.Second {
+		return status.Error(codes.InvalidArgument, "Interval: minimal resolution is 1s.")
+	}
+	if p.Interval.Truncate(time.Second) != p.Interval {
+		return status.Error(codes.InvalidArgument, "Interval: should be a natural number of seconds.")
+	}
+
+	if p.MetricsPath != "" && !strings.HasPrefix(p.MetricsPath, "/") {
+		return status.Errorf(codes.InvalidArgument, "Invalid metrics path %q.", p.MetricsPath)
+	}
+	switch p.Scheme {
+	case "", "http", "https":
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported scheme %q.", p.Scheme)
+	}
+	if p.Password != "" && p.Username == "" {
+		return status.Error(codes.InvalidArgument, "Password is set without username.")
+	}
+
+	return nil
+}
+
+// CreateCustomScrapeConfig creates custom scrape job.
+func CreateCustomScrapeConfig(q *reform.Querier, params *CreateCustomScrapeConfigParams) (*CustomScrapeConfig, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := q.FindOneFrom(CustomScrapeConfigTable, "job_name", params.JobName)
+	switch err {
+	case nil:
+		return nil, status.Errorf(codes.AlreadyExists, "Custom scrape config with job name %q already exists.", params.JobName)
+	case reform.ErrNoRows:
+		// nothing
+	default:
+		return nil, errors.WithStack(err)
+	}
+	_, err = q.FindOneFrom(KubernetesScrapeConfigTable, "job_name", params.JobName)
+	switch err {
+	case nil:
+		return nil, status.Errorf(codes.AlreadyExists, "Kubernetes scrape config with job name %q already exists.", params.JobName)
+	case reform.ErrNoRows:
+		// nothing
+	default:
+		return nil, errors.WithStack(err)
+	}
+
+	row := &CustomScrapeConfig{
+		ID:          "/custom_scrape_config_id/" + uuid.New().String(),
+		JobName:     params.JobName,
+		Targets:     params.Targets,
+		Labels:      params.Labels,
+		Interval:    params.Interval,
+		MetricsPath: params.MetricsPath,
+		Scheme:      params.Scheme,
+		Username:    params.Username,
+		Password:    params.Password,
+	}
+	if err = q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// RemoveCustomScrapeConfig removes custom scrape job by ID.
+func RemoveCustomScrapeConfig(q *reform.Querier, id string) error {
+	if _, err := FindCustomScrapeConfigByID(q, id); err != nil {
+		return err
+	}
+
+	if err := q.Delete(&CustomScrapeConfig{ID: id}); err != nil {
+		return errors.Wrap(err, "failed to delete custom scrape config")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestCustomScrapeConfigs(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	t.Run("create and remove", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		params := &models.CreateCustomScrapeConfigParams{
+			JobName:  "nginx",
+			Targets:  []string{"10.0.0.1:9113", "10.0.0.2:9113"},
+			Labels:   map[string]string{"environment": "prod"},
+			Interval: 30 * time.Second,
+			Username: "pmm",
+			Password: "secret",
+		}
+		c, err := models.CreateCustomScrapeConfig(q, params)
+		require.NoError(t, err)
+
+		_, err = models.CreateCustomScrapeConfig(q, params)
+		assert.EqualError(t, err, `rpc error: code = AlreadyExists desc = Custom scrape config with job name "nginx" already exists.`)
+
+		c, err = models.FindCustomScrapeConfigByID(q, c.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1:9113", "10.0.0.2:9113"}, []string(c.Targets))
+		assert.Equal(t, models.ScrapeLabels{"environment": "prod"}, c.Labels)
+		assert.Equal(t, 30*time.Second, c.Interval)
+
+		configs, err := models.FindCustomScrapeConfigs(q)
+		require.NoError(t, err)
+		assert.Len(t, configs, 1)
+
+		require.NoError(t, models.RemoveCustomScrapeConfig(q, c.ID))
+		_, err = models.FindCustomScrapeConfigByID(q, c.ID)
+		assert.EqualError(t, err, `rpc error: code = NotFound desc = Custom scrape config with ID "`+c.ID+`" not found.`)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		for _, tc := range []struct {
+			params   models.CreateCustomScrapeConfigParams
+			expected string
+		}{{
+			params:   models.CreateCustomScrapeConfigParams{JobName: "vmalert", Targets: []string{"vmalert:8880"}},
+			expected: `rpc error: code = InvalidArgument desc = Job name "vmalert" is reserved.`,
+		}, {
+			params:   models.CreateCustomScrapeConfigParams{JobName: "nginx", Targets: []string{"10.0.0.1"}},
+			expected: `rpc error: code = InvalidArgument desc = Invalid target "10.0.0.1": should be in host:port format.`,
+		}, {
+			params:   models.CreateCustomScrapeConfigParams{JobName: "nginx", Targets: []string{"10.0.0.1:9113"}, Labels: map[string]string{"__name__": "x"}},
+			expected: `rpc error: code = InvalidArgument desc = Invalid label name "__name__".`,
+		}, {
+			params:   models.CreateCustomScrapeConfigParams{JobName: "nginx", Targets: []string{"10.0.0.1:9113"}, Scheme: "ftp"},
+			expected: `rpc error: code = InvalidArgument desc = Unsupported scheme "ftp".`,
+		}} {
+			assert.EqualError(t, tc.params.Validate(), tc.expected)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// ScrapeLabels represents labels added to all metrics of custom scrape job.
+type ScrapeLabels map[string]string
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (l ScrapeLabels) Value() (driver.Value, error) { return jsonValue(l) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (l *ScrapeLabels) Scan(src interface{}) error { return jsonScan(l, src) }
+
+// CustomScrapeConfig represents additional scrape job registered by administrator.
+//reform:custom_scrape_configs
+type CustomScrapeConfig struct {
+	ID      string         `reform:"id,pk"`
+	JobName string         `reform:"job_name"`
+	Targets pq.StringArray `reform:"targets"`
+	Labels  ScrapeLabels   `reform:"labels"`
+	// Zero value means global scrape interval.
+	Interval    time.Duration `reform:"interval"`
+	MetricsPath string        `reform:"metrics_path"`
+	Scheme      string        `reform:"scheme"`
+	Username    string        `reform:"username"`
+	Password    string        `reform:"password"`
+	CreatedAt   time.Time     `reform:"created_at"`
+	UpdatedAt   time.Time     `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (c *CustomScrapeConfig) BeforeInsert() error {
+	now := Now()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (c *CustomScrapeConfig) BeforeUpdate() error {
+	c.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (c *CustomScrapeConfig) AfterFind() error {
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.UpdatedAt = c.UpdatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*CustomScrapeConfig)(nil)
+	_ reform.BeforeUpdater  = (*CustomScrapeConfig)(nil)
+	_ reform.AfterFinder    = (*CustomScrapeConfig)(nil)
+)
@@ -0,0 +1,129 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// FindDashboards returns all custom dashboards.
+func FindDashboards(q *reform.Querier) ([]*Dashboard, error) {
+	rows, err := q.SelectAllFrom(DashboardTable, "ORDER BY title")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*Dashboard, len(rows))
+	for i, r := range rows {
+		res[i] = r.(*Dashboard)
+	}
+	return res, nil
+}
+
+// FindDashboardByID finds custom dashboard by ID.
+func FindDashboardByID(q *reform.Querier, id string) (*Dashboard, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty dashboard ID.")
+	}
+
+	res := &Dashboard{ID: id}
+	switch err := q.Reload(res); err {
+	case nil:
+		return res, nil
+	case reform.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "Dashboard with ID %q not found.", id)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// CreateDashboardParams are params for creating custom dashboard.
+type CreateDashboardParams struct {
+	Title   string
+	URL     string
+	Filters Filters
+}
+
+// Validate validates params.
+func (p *CreateDashboardParams) Validate() error {
+	if p.Title == "" {
+		return status.Error(codes.InvalidArgument, "Empty dashboard title.")
+	}
+	if p.URL == "" {
+		return status.Error(codes.InvalidArgument, "Empty dashboard URL.")
+	}
+	if _, err := parseDashboardURL(p.URL); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid dashboard URL template: %s.", err)
+	}
+	return p.Filters.Validate()
+}
+
+// parseDashboardURL parses dashboard URL template.
+func parseDashboardURL(text string) (*template.Template, error) {
+	return template.New("url").Option("missingkey=zero").Parse(text)
+}
+
+// CreateDashboard creates custom dashboard.
+func CreateDashboard(q *reform.Querier, params *CreateDashboardParams) (*Dashboard, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	row := &Dashboard{
+		ID:      "/dashboard_id/" + uuid.New().String(),
+		Title:   params.Title,
+		URL:     params.URL,
+		Filters: params.Filters,
+	}
+	if err := q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// RemoveDashboard removes custom dashboard by ID.
+func RemoveDashboard(q *reform.Querier, id string) error {
+	if _, err := FindDashboardByID(q, id); err != nil {
+		return err
+	}
+
+	if err := q.Delete(&Dashboard{ID: id}); err != nil {
+		return errors.Wrap(err, "failed to delete dashboard")
+	}
+	return nil
+}
+
+// Link returns dashboard URL for given labels.
+func (d *Dashboard) Link(labels map[string]string) (string, error) {
+	t, err := parseDashboardURL(d.URL)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, labels); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return buf.String(), nil
+}
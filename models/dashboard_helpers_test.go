@@ -0,0 +1,85 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestDashboards(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	t.Run("create and remove", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		d, err := models.CreateDashboard(q, &models.CreateDashboardParams{
+			Title:   "Orders",
+			URL:     "/graph/d/orders/?var-service_name={{ urlquery .service_name }}&var-env={{ .environment }}",
+			Filters: models.Filters{{Type: models.Equal, Key: "environment", Val: "prod"}},
+		})
+		require.NoError(t, err)
+
+		d, err = models.FindDashboardByID(q, d.ID)
+		require.NoError(t, err)
+		link, err := d.Link(map[string]string{"service_name": "mysql 1"})
+		require.NoError(t, err)
+		assert.Equal(t, "/graph/d/orders/?var-service_name=mysql+1&var-env=", link)
+
+		dashboards, err := models.FindDashboards(q)
+		require.NoError(t, err)
+		assert.Len(t, dashboards, 1)
+
+		require.NoError(t, models.RemoveDashboard(q, d.ID))
+		_, err = models.FindDashboardByID(q, d.ID)
+		assert.EqualError(t, err, `rpc error: code = NotFound desc = Dashboard with ID "`+d.ID+`" not found.`)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		_, err = models.CreateDashboard(q, &models.CreateDashboardParams{URL: "/graph/d/orders/"})
+		assert.EqualError(t, err, "rpc error: code = InvalidArgument desc = Empty dashboard title.")
+
+		_, err = models.CreateDashboard(q, &models.CreateDashboardParams{Title: "Orders", URL: "/graph/d/{{ .uid"})
+		assert.EqualError(t, err, "rpc error: code = InvalidArgument desc = Invalid dashboard URL template: template: url:1: unclosed action.")
+	})
+}
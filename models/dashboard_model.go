@@ -0,0 +1,65 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// Dashboard represents custom Grafana dashboard link for Services and alerts with labels matching filters.
+//reform:dashboards
+type Dashboard struct {
+	ID    string `reform:"id,pk"`
+	Title string `reform:"title"`
+	// URL text/template executed with labels, for example, "/graph/d/my-uid/?var-service_name={{ .service_name }}".
+	URL       string    `reform:"url"`
+	Filters   Filters   `reform:"filters"`
+	CreatedAt time.Time `reform:"created_at"`
+	UpdatedAt time.Time `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (d *Dashboard) BeforeInsert() error {
+	now := Now()
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (d *Dashboard) BeforeUpdate() error {
+	d.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (d *Dashboard) AfterFind() error {
+	d.CreatedAt = d.CreatedAt.UTC()
+	d.UpdatedAt = d.UpdatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*Dashboard)(nil)
+	_ reform.BeforeUpdater  = (*Dashboard)(nil)
+	_ reform.AfterFinder    = (*Dashboard)(nil)
+)
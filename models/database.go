@@ -18,12 +18,11 @@ package models
 
 import (
 	"database/sql"
-	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/AlekSi/pointer"
-	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -584,6 +583,273 @@ var databaseSchema = [][]string{
 		FROM services
         WHERE service_type = 'mysql';`,
 	},
+	45: {
+		`CREATE TABLE anomaly_baselines (
+			id VARCHAR NOT NULL,
+			service_id VARCHAR NOT NULL CHECK (service_id <> ''),
+			metric VARCHAR NOT NULL CHECK (metric <> ''),
+			seasonality VARCHAR NOT NULL CHECK (seasonality <> ''),
+			sigma DOUBLE PRECISION NOT NULL,
+			disabled BOOLEAN NOT NULL,
+
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id),
+			UNIQUE (service_id, metric),
+			FOREIGN KEY (service_id) REFERENCES services (service_id) ON DELETE CASCADE
+		)`,
+	},
+	46: {
+		`ALTER TABLE restore_history
+			ADD COLUMN timeline JSONB,
+			ADD COLUMN log_tail VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE restore_history ALTER COLUMN log_tail DROP DEFAULT`,
+	},
+	47: {
+		`ALTER TABLE schema_migrations
+			ADD COLUMN checksum VARCHAR,
+			ADD COLUMN applied_at TIMESTAMP`,
+	},
+	48: {
+		`ALTER TABLE artifacts ADD COLUMN orphaned BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE artifacts ALTER COLUMN orphaned DROP DEFAULT`,
+	},
+	49: {
+		`ALTER TABLE artifacts ADD COLUMN size BIGINT`,
+	},
+	50: {
+		`ALTER TABLE artifacts ADD COLUMN group_id VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE artifacts ALTER COLUMN group_id DROP DEFAULT`,
+	},
+	51: {
+		`CREATE TABLE automations (
+			id VARCHAR NOT NULL,
+			name VARCHAR NOT NULL CHECK (name <> ''),
+			filters JSONB,
+			action_type VARCHAR NOT NULL CHECK (action_type <> ''),
+			params JSONB,
+			disabled BOOLEAN NOT NULL,
+
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id)
+		)`,
+	},
+	52: {
+		`CREATE TABLE dashboards (
+			id VARCHAR NOT NULL,
+			title VARCHAR NOT NULL CHECK (title <> ''),
+			url VARCHAR NOT NULL CHECK (url <> ''),
+			filters JSONB,
+
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id)
+		)`,
+	},
+	53: {
+		`ALTER TABLE artifacts ADD COLUMN metadata JSONB`,
+	},
+	54: {
+		`ALTER TABLE nodes
+			ADD COLUMN notes VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN owner VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN contact VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE services
+			ADD COLUMN notes VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN owner VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN contact VARCHAR NOT NULL DEFAULT ''`,
+	},
+	55: {
+		`CREATE TABLE scheduled_task_runs (
+			id VARCHAR NOT NULL,
+			task_id VARCHAR NOT NULL,
+			status VARCHAR NOT NULL CHECK (status <> ''),
+			error VARCHAR NOT NULL,
+			artifact_id VARCHAR NOT NULL,
+			started_at TIMESTAMP NOT NULL,
+			finished_at TIMESTAMP,
+
+			PRIMARY KEY (id),
+			FOREIGN KEY (task_id) REFERENCES scheduled_tasks (id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX scheduled_task_runs_task_id_started_at_idx ON scheduled_task_runs (task_id, started_at)`,
+	},
+	56: {
+		`ALTER TABLE scheduled_tasks ADD COLUMN misfire_policy VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE scheduled_tasks ALTER COLUMN misfire_policy DROP DEFAULT`,
+	},
+	57: {
+		`ALTER TABLE nodes ADD COLUMN ttl BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE services ADD COLUMN ttl BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE agents ADD COLUMN last_seen_at TIMESTAMP`,
+		`CREATE TABLE audit_events (
+			id VARCHAR NOT NULL,
+			type VARCHAR NOT NULL CHECK (type <> ''),
+			object_id VARCHAR NOT NULL,
+			message VARCHAR NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id)
+		)`,
+		`CREATE INDEX audit_events_created_at_idx ON audit_events (created_at)`,
+	},
+	58: {
+		`ALTER TABLE scheduled_tasks
+			ADD COLUMN jitter BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN window_start VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN window_end VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE scheduled_tasks
+			ALTER COLUMN jitter DROP DEFAULT,
+			ALTER COLUMN window_start DROP DEFAULT,
+			ALTER COLUMN window_end DROP DEFAULT`,
+	},
+	59: {
+		`ALTER TABLE nodes
+			ADD COLUMN k8s_namespace VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN k8s_pod_name VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN k8s_deployment VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN k8s_node_name VARCHAR NOT NULL DEFAULT ''`,
+	},
+	60: {
+		`ALTER TABLE scheduled_tasks ADD COLUMN concurrency_policy VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE scheduled_tasks ALTER COLUMN concurrency_policy DROP DEFAULT`,
+	},
+	61: {
+		`ALTER TABLE scheduled_tasks ADD COLUMN remove_after_run BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE scheduled_tasks ALTER COLUMN remove_after_run DROP DEFAULT`,
+	},
+	62: {
+		`ALTER TABLE scheduled_tasks
+			ADD COLUMN retries INTEGER NOT NULL DEFAULT 0,
+			ADD COLUMN retry_interval BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE scheduled_tasks
+			ALTER COLUMN retries DROP DEFAULT,
+			ALTER COLUMN retry_interval DROP DEFAULT`,
+	},
+	63: {
+		`ALTER TABLE scheduled_tasks
+			ADD COLUMN depends_on VARCHAR,
+			ADD FOREIGN KEY (depends_on) REFERENCES scheduled_tasks (id)`,
+	},
+	64: {
+		`ALTER TABLE scheduled_tasks
+			ADD COLUMN retry_backoff VARCHAR NOT NULL DEFAULT '',
+			ADD COLUMN max_retry_interval BIGINT NOT NULL DEFAULT 0,
+			ADD COLUMN retry_budget BIGINT NOT NULL DEFAULT 0`,
+		`ALTER TABLE scheduled_tasks
+			ALTER COLUMN retry_backoff DROP DEFAULT,
+			ALTER COLUMN max_retry_interval DROP DEFAULT,
+			ALTER COLUMN retry_budget DROP DEFAULT`,
+	},
+	65: {
+		`ALTER TABLE services ADD COLUMN metrics_resolutions JSONB`,
+	},
+	66: {
+		`ALTER TABLE artifacts ADD COLUMN hold BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE artifacts ALTER COLUMN hold DROP DEFAULT`,
+	},
+	67: {
+		`CREATE TABLE usage_snapshots (
+			date DATE NOT NULL,
+			nodes INTEGER NOT NULL,
+			services INTEGER NOT NULL,
+			service_types JSONB,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (date)
+		)`,
+	},
+	68: {
+		`ALTER TABLE agents
+			ADD COLUMN metrics_resolutions JSONB,
+			ADD COLUMN metrics_resolution_tier VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE agents ALTER COLUMN metrics_resolution_tier DROP DEFAULT`,
+	},
+	69: {
+		`ALTER TABLE agents ADD COLUMN exporter_tls JSONB`,
+	},
+	70: {
+		`ALTER TABLE artifacts ADD COLUMN golden BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE artifacts ALTER COLUMN golden DROP DEFAULT`,
+	},
+	71: {
+		`CREATE TABLE custom_scrape_configs (
+			id VARCHAR NOT NULL,
+			job_name VARCHAR NOT NULL CHECK (job_name <> ''),
+			targets VARCHAR[] NOT NULL,
+			labels JSONB,
+			interval BIGINT NOT NULL,
+			metrics_path VARCHAR NOT NULL,
+			scheme VARCHAR NOT NULL,
+			username VARCHAR NOT NULL,
+			password VARCHAR NOT NULL,
+
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id),
+			UNIQUE (job_name)
+		)`,
+	},
+	72: {
+		`CREATE TABLE job_stats (
+			id VARCHAR NOT NULL,
+			job_type VARCHAR NOT NULL CHECK (job_type <> ''),
+			size_class INTEGER NOT NULL,
+			count BIGINT NOT NULL,
+			duration_sum DOUBLE PRECISION NOT NULL,
+			duration_squares_sum DOUBLE PRECISION NOT NULL,
+			min_duration BIGINT NOT NULL,
+			max_duration BIGINT NOT NULL,
+
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id),
+			UNIQUE (job_type, size_class)
+		)`,
+	},
+	73: {
+		`ALTER TABLE nodes
+			ADD COLUMN facts JSONB,
+			ADD COLUMN facts_action_id VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE nodes ALTER COLUMN facts_action_id DROP DEFAULT`,
+	},
+	74: {
+		`ALTER TABLE services ADD COLUMN database_autodiscovery JSONB`,
+	},
+	75: {
+		`CREATE TABLE kubernetes_scrape_configs (
+			id VARCHAR NOT NULL,
+			job_name VARCHAR NOT NULL CHECK (job_name <> ''),
+			kubernetes_cluster_name VARCHAR NOT NULL,
+			role VARCHAR NOT NULL CHECK (role <> ''),
+			namespaces VARCHAR[] NOT NULL,
+			label_selector VARCHAR NOT NULL,
+			interval BIGINT NOT NULL,
+			metrics_path VARCHAR NOT NULL,
+			scheme VARCHAR NOT NULL,
+			tls JSONB,
+
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+
+			PRIMARY KEY (id),
+			UNIQUE (job_name),
+			FOREIGN KEY (kubernetes_cluster_name) REFERENCES kubernetes_clusters (kubernetes_cluster_name) ON DELETE CASCADE
+		)`,
+	},
+	76: {
+		`ALTER TABLE ia_channels ADD COLUMN throttling JSONB`,
+	},
+	77: {
+		`ALTER TABLE audit_events ADD COLUMN actor VARCHAR NOT NULL DEFAULT ''`,
+		`ALTER TABLE audit_events ALTER COLUMN actor DROP DEFAULT`,
+	},
 }
 
 // ^^^ Avoid default values in schema definition. ^^^
@@ -630,11 +896,15 @@ const (
 
 // SetupDBParams represents SetupDB parameters.
 type SetupDBParams struct {
-	Logf             reform.Printf
-	Username         string
-	Password         string
-	SetupFixtures    SetupFixturesMode
+	Logf          reform.Printf
+	Username      string
+	Password      string
+	SetupFixtures SetupFixturesMode
+	// Target schema version; the latest one if nil.
+	// Database is downgraded if it is lower than the current version.
 	MigrationVersion *int
+	// Apply migrations and roll them back, leaving the database unchanged.
+	DryRun bool
 }
 
 // SetupDB runs PostgreSQL database migrations and optionally adds initial data.
@@ -643,68 +913,150 @@ func SetupDB(sqlDB *sql.DB, params *SetupDBParams) (*reform.DB, error) {
 	if params.Logf != nil {
 		logger = reform.NewPrintfLogger(params.Logf)
 	}
+	logf := params.Logf
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
 	db := reform.NewDB(sqlDB, postgresql.Dialect, logger)
 
 	latestVersion := len(databaseSchema) - 1 // skip item 0
+	targetVersion := latestVersion
 	if params.MigrationVersion != nil {
-		latestVersion = *params.MigrationVersion
+		targetVersion = *params.MigrationVersion
 	}
-	var currentVersion int
-	err := db.QueryRow("SELECT id FROM schema_migrations ORDER BY id DESC LIMIT 1").Scan(&currentVersion)
-	if pErr, ok := err.(*pq.Error); ok && pErr.Code == "42P01" { // undefined_table (see https://www.postgresql.org/docs/current/errcodes-appendix.html)
-		err = nil
-	}
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	if params.Logf != nil {
-		params.Logf("Current database schema version: %d. Latest version: %d.", currentVersion, latestVersion)
+	if targetVersion > latestVersion {
+		return nil, errors.Errorf("unknown database schema version %d, latest version is %d", targetVersion, latestVersion)
 	}
 
+	defer setMigrationStatus(func(s *MigrationStatus) { s.Running = false })
+
 	// rollback all migrations if one of them fails; PostgreSQL supports DDL transactions
-	err = db.InTransaction(func(tx *reform.TX) error {
-		for version := currentVersion + 1; version <= latestVersion; version++ {
-			if params.Logf != nil {
-				params.Logf("Migrating database to schema version %d ...", version)
+	err := db.InTransaction(func(tx *reform.TX) error {
+		// other pmm-managed instances wait there until this transaction is finished
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationsLockID); err != nil {
+			return errors.WithStack(err)
+		}
+
+		currentVersion, err := currentSchemaVersion(tx.Querier)
+		if err != nil {
+			return err
+		}
+		logf("Current database schema version: %d. Target version: %d. Latest version: %d.", currentVersion, targetVersion, latestVersion)
+
+		// preflight checks
+		switch {
+		case currentVersion > latestVersion && params.MigrationVersion == nil:
+			// database was migrated by a newer pmm-managed instance (for example, during rolling upgrade);
+			// keep using it as is
+			logf("Database schema version %d is newer than the latest known version %d, skipping migrations.", currentVersion, latestVersion)
+			targetVersion = currentVersion
+		case currentVersion > targetVersion:
+			if err = checkDowngradePath(currentVersion, targetVersion); err != nil {
+				return err
 			}
+		}
+		if currentVersion >= checksumsSchemaVersion {
+			if err = verifyMigrationChecksums(tx.Querier); err != nil {
+				return err
+			}
+		}
+
+		setMigrationStatus(func(s *MigrationStatus) {
+			*s = MigrationStatus{
+				Running:        currentVersion != targetVersion,
+				CurrentVersion: currentVersion,
+				TargetVersion:  targetVersion,
+				StartedAt:      time.Now(),
+			}
+		})
+
+		for version := currentVersion + 1; version <= targetVersion; version++ {
+			logf("Migrating database to schema version %d ...", version)
 
 			queries := databaseSchema[version]
-			queries = append(queries, fmt.Sprintf(`INSERT INTO schema_migrations (id) VALUES (%d)`, version))
-			for _, q := range queries {
-				q = strings.TrimSpace(q)
-				if _, err = tx.Exec(q); err != nil {
-					return errors.Wrapf(err, "failed to execute statement:\n%s", q)
-				}
+			if err = execMigrationQueries(tx.Querier, logf, queries); err != nil {
+				return err
 			}
+			if version < checksumsSchemaVersion {
+				_, err = tx.Exec(`INSERT INTO schema_migrations (id) VALUES ($1)`, version)
+			} else {
+				_, err = tx.Exec(`INSERT INTO schema_migrations (id, checksum, applied_at) VALUES ($1, $2, $3)`,
+					version, migrationChecksum(version), Now())
+			}
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			setMigrationStatus(func(s *MigrationStatus) { s.CurrentVersion = version })
 		}
 
-		if params.SetupFixtures == SkipFixtures {
-			return nil
-		}
+		for version := currentVersion; version > targetVersion; version-- {
+			logf("Downgrading database from schema version %d ...", version)
 
-		// fill settings with defaults
-		s, err := GetSettings(tx)
-		if err != nil {
-			return err
+			if err = execMigrationQueries(tx.Querier, logf, databaseSchemaDown[version]); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(`DELETE FROM schema_migrations WHERE id = $1`, version); err != nil {
+				return errors.WithStack(err)
+			}
+			setMigrationStatus(func(s *MigrationStatus) { s.CurrentVersion = version - 1 })
 		}
-		if err = SaveSettings(tx, s); err != nil {
-			return err
+
+		if currentVersion < checksumsSchemaVersion && targetVersion >= checksumsSchemaVersion {
+			// record checksums of versions applied before checksums were introduced
+			if err = verifyMigrationChecksums(tx.Querier); err != nil {
+				return err
+			}
 		}
 
-		if err = setupFixture1(tx.Querier, params.Username, params.Password); err != nil {
-			return err
+		if params.SetupFixtures == SetupFixtures {
+			if err = setupFixtures(tx, params.Username, params.Password); err != nil {
+				return err
+			}
 		}
-		if err = setupFixture2(tx.Querier, params.Username, params.Password); err != nil {
-			return err
+
+		if params.DryRun {
+			logf("Dry run: rolling back.")
+			return errDryRun
 		}
 		return nil
 	})
-	if err != nil {
+	if err != nil && err != errDryRun {
 		return nil, err
 	}
 	return db, nil
 }
 
+// setupFixtures fills settings with defaults and adds initial data.
+func setupFixtures(tx *reform.TX, username, password string) error {
+	s, err := GetSettings(tx)
+	if err != nil {
+		return err
+	}
+	if err = SaveSettings(tx, s); err != nil {
+		return err
+	}
+
+	if err = setupFixture1(tx.Querier, username, password); err != nil {
+		return err
+	}
+	return setupFixture2(tx.Querier, username, password)
+}
+
+// execMigrationQueries executes migration queries, reporting slow ones.
+func execMigrationQueries(q *reform.Querier, logf reform.Printf, queries []string) error {
+	for _, query := range queries {
+		query = strings.TrimSpace(query)
+		start := time.Now()
+		if _, err := q.Exec(query); err != nil {
+			return errors.Wrapf(err, "failed to execute statement:\n%s", query)
+		}
+		if d := time.Since(start); d > slowMigrationStatement {
+			logf("Statement took %s:\n%s", d, query)
+		}
+	}
+	return nil
+}
+
 func setupFixture1(q *reform.Querier, username, password string) error {
 	// create PMM Server Node and associated Agents
 	node, err := createNodeWithID(q, PMMServerNodeID, GenericNodeType, &CreateNodeParams{
@@ -0,0 +1,111 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"path"
+
+	"github.com/AlekSi/pointer"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// ChangeDatabaseAutodiscovery changes databases autodiscovery settings of PostgreSQL Service with given ID.
+// Previously listed databases are kept; nil settings reset them to defaults.
+func ChangeDatabaseAutodiscovery(q *reform.Querier, serviceID string, settings *DatabaseAutodiscovery) (*Service, error) {
+	service, err := FindServiceByID(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if service.ServiceType != PostgreSQLServiceType {
+		return nil, status.Errorf(codes.InvalidArgument, "Databases autodiscovery can be configured only for PostgreSQL Services.")
+	}
+
+	if settings != nil {
+		if settings.MaxDatabases < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid max_databases: should not be negative.")
+		}
+		for _, p := range append(append([]string{}, settings.Include...), settings.Exclude...) {
+			if _, err = path.Match(p, ""); err != nil || p == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "Invalid database pattern %q.", p)
+			}
+		}
+
+		// listing results can't be changed by caller
+		settings.Databases, settings.DatabasesListedAt, settings.ListActionID = nil, nil, ""
+		if old := service.DatabaseAutodiscovery; old != nil {
+			settings.Databases = old.Databases
+			settings.DatabasesListedAt = old.DatabasesListedAt
+			settings.ListActionID = old.ListActionID
+		}
+	}
+
+	service.DatabaseAutodiscovery = settings
+	if err = q.Update(service); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return service, nil
+}
+
+// StartDatabasesListing stores ID of databases listing Action started for Service with given ID.
+func StartDatabasesListing(q *reform.Querier, serviceID, actionID string) (*Service, error) {
+	service, err := FindServiceByID(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.DatabaseAutodiscovery == nil {
+		service.DatabaseAutodiscovery = new(DatabaseAutodiscovery)
+	}
+	service.DatabaseAutodiscovery.ListActionID = actionID
+	if err = q.Update(service); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return service, nil
+}
+
+// FinishDatabasesListing stores databases listed by Action with given ID.
+// If databases are nil (Action failed), previously listed databases are kept.
+// It returns nil if there is no Service waiting for that Action.
+func FinishDatabasesListing(q *reform.Querier, actionID string, databases []string) (*Service, error) {
+	if actionID == "" {
+		return nil, nil
+	}
+
+	service := new(Service)
+	switch err := q.SelectOneTo(service, "WHERE database_autodiscovery->>'list_action_id' = $1", actionID); err {
+	case nil:
+		// continue
+	case reform.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+
+	d := service.DatabaseAutodiscovery
+	d.ListActionID = ""
+	if databases != nil {
+		d.Databases = databases
+		d.DatabasesListedAt = pointer.ToTime(Now())
+	}
+	if err := q.Update(service); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return service, nil
+}
@@ -74,79 +74,79 @@ func TestDatabaseChecks(t *testing.T) {
 
 		// node_id
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('1', 'generic', 'name', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('1', 'generic', 'name', '', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('1', 'generic', 'other name', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('1', 'generic', 'other name', '', '', '', '', '', $1, $2)", now, now,
 		)
 		assertUniqueViolation(t, err, "nodes_pkey")
 
 		// node_name
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('2', 'generic', 'name', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('2', 'generic', 'name', '', '', '', '', '', $1, $2)", now, now,
 		)
 		assertUniqueViolation(t, err, "nodes_node_name_key")
 
 		// machine_id for generic Node: https://jira.percona.com/browse/PMM-4196
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('31', 'generic', 'name31', 'machine-id', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('31', 'generic', 'name31', 'machine-id', '', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('32', 'generic', 'name32', 'machine-id', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('32', 'generic', 'name32', 'machine-id', '', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 
 		// machine_id for container Node
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('31-container', 'container', 'name31-container', 'machine-id', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('31-container', 'container', 'name31-container', 'machine-id', '', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('32-container', 'container', 'name32-container', 'machine-id', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, machine_id, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('32-container', 'container', 'name32-container', 'machine-id', '', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 
 		// container_id
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, container_id, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('41', 'generic', 'name41', 'docker-container-id', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, container_id, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('41', 'generic', 'name41', 'docker-container-id', '', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, container_id, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('42', 'generic', 'name42', 'docker-container-id', '', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, container_id, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('42', 'generic', 'name42', 'docker-container-id', '', '', '', '', '', $1, $2)", now, now,
 		)
 		assertUniqueViolation(t, err, "nodes_container_id_key")
 
 		// (address, region)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, address, region, distro, node_model, az, created_at, updated_at) "+
-				"VALUES ('51', 'generic', 'name51', 'instance1', 'region1', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, address, region, distro, node_model, az, facts_action_id, created_at, updated_at) "+
+				"VALUES ('51', 'generic', 'name51', 'instance1', 'region1', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, address, region, distro, node_model, az, created_at, updated_at) "+
-				"VALUES ('52', 'generic', 'name52', 'instance1', 'region1', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, address, region, distro, node_model, az, facts_action_id, created_at, updated_at) "+
+				"VALUES ('52', 'generic', 'name52', 'instance1', 'region1', '', '', '', '', $1, $2)", now, now,
 		)
 		assertUniqueViolation(t, err, "nodes_address_region_key")
 		// same address, NULL region is fine
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, address, distro, node_model, az, created_at, updated_at) "+
-				"VALUES ('53', 'generic', 'name53', 'instance1', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, address, distro, node_model, az, facts_action_id, created_at, updated_at) "+
+				"VALUES ('53', 'generic', 'name53', 'instance1', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, address, distro, node_model, az, created_at, updated_at) "+
-				"VALUES ('54', 'generic', 'name54', 'instance1', '', '', '', $1, $2)", now, now,
+			"INSERT INTO nodes (node_id, node_type, node_name, address, distro, node_model, az, facts_action_id, created_at, updated_at) "+
+				"VALUES ('54', 'generic', 'name54', 'instance1', '', '', '', '', $1, $2)", now, now,
 		)
 		require.NoError(t, err)
 	})
@@ -159,8 +159,8 @@ func TestDatabaseChecks(t *testing.T) {
 		var err error
 		now := models.Now()
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('/node_id/1', 'generic', 'name', '', '', '', '', $1, $2)",
+			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('/node_id/1', 'generic', 'name', '', '', '', '', '', $1, $2)",
 			now, now,
 		)
 		require.NoError(t, err)
@@ -215,8 +215,8 @@ func TestDatabaseChecks(t *testing.T) {
 		now := models.Now()
 
 		_, err = db.Exec(
-			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, created_at, updated_at) "+
-				"VALUES ('/node_id/1', 'generic', 'name', '', '', '', '', $1, $2)",
+			"INSERT INTO nodes (node_id, node_type, node_name, distro, node_model, az, address, facts_action_id, created_at, updated_at) "+
+				"VALUES ('/node_id/1', 'generic', 'name', '', '', '', '', '', $1, $2)",
 			now, now,
 		)
 		require.NoError(t, err)
@@ -414,3 +414,57 @@ func TestDatabaseMigrations(t *testing.T) {
 		}, settings.MetricsResolutions)
 	})
 }
+
+func TestDatabaseMigrationsFramework(t *testing.T) {
+	t.Run("DryRun", func(t *testing.T) {
+		sqlDB := testdb.Open(t, models.SkipFixtures, pointer.ToInt(44))
+		defer sqlDB.Close() //nolint:errcheck
+
+		_, err := models.SetupDB(sqlDB, &models.SetupDBParams{
+			SetupFixtures: models.SkipFixtures,
+			DryRun:        true,
+		})
+		require.NoError(t, err)
+
+		var version int
+		err = sqlDB.QueryRow("SELECT id FROM schema_migrations ORDER BY id DESC LIMIT 1").Scan(&version)
+		require.NoError(t, err)
+		assert.Equal(t, 44, version)
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+		defer sqlDB.Close() //nolint:errcheck
+
+		testdb.SetupDB(t, sqlDB, models.SkipFixtures, pointer.ToInt(44))
+		var exists bool
+		err := sqlDB.QueryRow("SELECT to_regclass('anomaly_baselines') IS NOT NULL").Scan(&exists)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		_, err = models.SetupDB(sqlDB, &models.SetupDBParams{
+			SetupFixtures:    models.SkipFixtures,
+			MigrationVersion: pointer.ToInt(10),
+		})
+		assert.EqualError(t, err, "can't downgrade database schema from version 44 to 10: version 43 has no down migration")
+
+		// upgrade again
+		testdb.SetupDB(t, sqlDB, models.SkipFixtures, nil)
+	})
+
+	t.Run("Checksums", func(t *testing.T) {
+		sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+		defer sqlDB.Close() //nolint:errcheck
+
+		var missing int
+		err := sqlDB.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE checksum IS NULL").Scan(&missing)
+		require.NoError(t, err)
+		assert.Zero(t, missing)
+
+		_, err = sqlDB.Exec("UPDATE schema_migrations SET checksum = 'invalid' WHERE id = 1")
+		require.NoError(t, err)
+		_, err = models.SetupDB(sqlDB, &models.SetupDBParams{SetupFixtures: models.SkipFixtures})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch for database schema version 1")
+	})
+}
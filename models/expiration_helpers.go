@@ -0,0 +1,78 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+func checkTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return status.Error(codes.InvalidArgument, "TTL should not be negative.")
+	}
+	return nil
+}
+
+// ChangeNodeTTL changes Node TTL; zero TTL disables expiration.
+func ChangeNodeTTL(q *reform.Querier, nodeID string, ttl time.Duration) (*Node, error) {
+	if err := checkTTL(ttl); err != nil {
+		return nil, err
+	}
+	if nodeID == PMMServerNodeID {
+		return nil, status.Error(codes.PermissionDenied, "PMM Server node can't expire.")
+	}
+
+	n, err := FindNodeByID(q, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.TTL = ttl
+	if err = q.Update(n); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return n, nil
+}
+
+// ChangeServiceTTL changes Service TTL; zero TTL disables expiration.
+func ChangeServiceTTL(q *reform.Querier, serviceID string, ttl time.Duration) (*Service, error) {
+	if err := checkTTL(ttl); err != nil {
+		return nil, err
+	}
+
+	s, err := FindServiceByID(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.TTL = ttl
+	if err = q.Update(s); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s, nil
+}
+
+// UpdatePMMAgentLastSeen records time of pmm-agent connection or disconnection.
+func UpdatePMMAgentLastSeen(q *reform.Querier, pmmAgentID string, t time.Time) error {
+	_, err := q.Exec("UPDATE agents SET last_seen_at = $1 WHERE agent_id = $2 AND agent_type = $3", t.UTC(), pmmAgentID, PMMAgentType)
+	return errors.WithStack(err)
+}
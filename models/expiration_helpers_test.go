@@ -0,0 +1,118 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestExpiration(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, tx.Rollback())
+	})
+
+	q := tx.Querier
+
+	node, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName: "ci-node",
+		Address:  "127.0.0.1",
+	})
+	require.NoError(t, err)
+	service, err := models.AddNewService(q, models.MySQLServiceType, &models.AddDBMSServiceParams{
+		ServiceName: "ci-mysql",
+		NodeID:      node.NodeID,
+		Address:     pointer.ToString("127.0.0.1"),
+		Port:        pointer.ToUint16(3306),
+	})
+	require.NoError(t, err)
+	pmmAgent, err := models.CreatePMMAgent(q, node.NodeID, nil)
+	require.NoError(t, err)
+
+	t.Run("TTL", func(t *testing.T) {
+		node, err := models.ChangeNodeTTL(q, node.NodeID, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, node.TTL)
+
+		service, err := models.ChangeServiceTTL(q, service.ServiceID, 30*time.Minute)
+		require.NoError(t, err)
+		service, err = models.FindServiceByID(q, service.ServiceID)
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Minute, service.TTL)
+
+		_, err = models.ChangeServiceTTL(q, service.ServiceID, -time.Minute)
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "TTL should not be negative."), err)
+
+		_, err = models.ChangeNodeTTL(q, models.PMMServerNodeID, time.Hour)
+		tests.AssertGRPCError(t, status.New(codes.PermissionDenied, "PMM Server node can't expire."), err)
+	})
+
+	t.Run("LastSeen", func(t *testing.T) {
+		now := time.Date(2021, 10, 20, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, models.UpdatePMMAgentLastSeen(q, pmmAgent.AgentID, now))
+
+		agent, err := models.FindAgentByID(q, pmmAgent.AgentID)
+		require.NoError(t, err)
+		require.NotNil(t, agent.LastSeenAt)
+		assert.Equal(t, now, agent.LastSeenAt.UTC())
+	})
+
+	t.Run("AuditEvents", func(t *testing.T) {
+		_, err := models.CreateAuditEvent(q, models.ServiceExpiredAuditEventType, service.ServiceID, "", "Service expired.")
+		require.NoError(t, err)
+		_, err = models.CreateAuditEvent(q, models.NodeExpiredAuditEventType, node.NodeID, "", "Node expired.")
+		require.NoError(t, err)
+
+		events, err := models.FindAuditEvents(q, models.AuditEventsFilter{})
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+
+		events, err = models.FindAuditEvents(q, models.AuditEventsFilter{ObjectID: service.ServiceID})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.ServiceExpiredAuditEventType, events[0].Type)
+		assert.Equal(t, "Service expired.", events[0].Message)
+
+		events, err = models.FindAuditEvents(q, models.AuditEventsFilter{Type: models.NodeExpiredAuditEventType, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, node.NodeID, events[0].ObjectID)
+
+		_, err = models.CreateAuditEvent(q, "", node.NodeID, "", "")
+		assert.EqualError(t, err, "empty audit event type")
+	})
+}
@@ -17,6 +17,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -43,6 +45,118 @@ func FindJobResultByID(q *reform.Querier, id string) (*JobResult, error) {
 	}
 }
 
+// FindBackupJobResultByArtifactID finds not finished backup JobResult for artifact with given ID.
+func FindBackupJobResultByArtifactID(q *reform.Querier, artifactID string) (*JobResult, error) {
+	if artifactID == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty Artifact ID.")
+	}
+
+	tail := " WHERE NOT done AND (result->'mysql_backup'->>'artifact_id' = $1 OR result->'mongo_db_backup'->>'artifact_id' = $1" +
+		" OR result->'config_snapshot_backup'->>'artifact_id' = $1 OR result->'mysql_logical_backup'->>'artifact_id' = $1)"
+	switch res, err := q.SelectOneFrom(JobResultTable, tail, artifactID); err {
+	case nil:
+		return res.(*JobResult), nil
+	case reform.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "Running backup job for artifact with ID %q not found.", artifactID)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// FindJobResultByActionID finds not finished configuration snapshot or MySQL logical backup JobResult
+// waiting for Action with given ID. It returns nil if there is no such JobResult.
+func FindJobResultByActionID(q *reform.Querier, actionID string) (*JobResult, error) {
+	if actionID == "" {
+		return nil, nil
+	}
+
+	tail := " WHERE NOT done AND (" +
+		"(type = $1 AND result->'config_snapshot_backup'->>'action_id' = $3) OR " +
+		"(type = $2 AND result->'mysql_logical_backup'->>'action_id' = $3))"
+	switch res, err := q.SelectOneFrom(JobResultTable, tail, ConfigSnapshotBackupJob, MySQLLogicalBackupJob, actionID); err {
+	case nil:
+		return res.(*JobResult), nil
+	case reform.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// JobResultFilters represents filters for job results.
+type JobResultFilters struct {
+	// Return only job results of those types.
+	Types []JobType
+	// Return only finished (true) or running (false) job results.
+	Done *bool
+	// Return at most that number of job results; 0 means no limit.
+	Limit int
+}
+
+// FindJobResults returns job results for given filters, newest first.
+func FindJobResults(q *reform.Querier, filters JobResultFilters) ([]*JobResult, error) {
+	var conditions []string
+	var args []interface{}
+
+	idx := 1
+	if len(filters.Types) != 0 {
+		for _, t := range filters.Types {
+			args = append(args, t)
+		}
+		conditions = append(conditions, fmt.Sprintf("type IN (%s)", strings.Join(q.Placeholders(idx, len(filters.Types)), ", ")))
+		idx += len(filters.Types)
+	}
+
+	if filters.Done != nil {
+		conditions = append(conditions, fmt.Sprintf("done = %s", q.Placeholder(idx)))
+		args = append(args, *filters.Done)
+		idx++
+	}
+
+	var whereClause string
+	if len(conditions) != 0 {
+		whereClause = fmt.Sprintf("WHERE %s", strings.Join(conditions, " AND "))
+	}
+	tail := fmt.Sprintf("%s ORDER BY created_at DESC", whereClause)
+	if filters.Limit > 0 {
+		tail += fmt.Sprintf(" LIMIT %s", q.Placeholder(idx))
+		args = append(args, filters.Limit)
+	}
+	structs, err := q.SelectAllFrom(JobResultTable, tail, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*JobResult, len(structs))
+	for i, s := range structs {
+		res[i] = s.(*JobResult)
+	}
+	return res, nil
+}
+
+// FindUnfinishedJobResults returns not finished JobResults of given types.
+func FindUnfinishedJobResults(q *reform.Querier, jobTypes ...JobType) ([]*JobResult, error) {
+	if len(jobTypes) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, len(jobTypes))
+	for i, t := range jobTypes {
+		args[i] = t
+	}
+	tail := fmt.Sprintf(" WHERE NOT done AND type IN (%s) ORDER BY created_at", strings.Join(q.Placeholders(1, len(args)), ", "))
+	structs, err := q.SelectAllFrom(JobResultTable, tail, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*JobResult, len(structs))
+	for i, s := range structs {
+		res[i] = s.(*JobResult)
+	}
+	return res, nil
+}
+
 // CreateJobResult stores a job result in the storage.
 func CreateJobResult(q *reform.Querier, pmmAgentID string, jobType JobType, data *JobResultData) (*JobResult, error) {
 	result := &JobResult{
@@ -57,6 +171,35 @@ func CreateJobResult(q *reform.Querier, pmmAgentID string, jobType JobType, data
 	return result, nil
 }
 
+// UpdateJobProgress stores the last reported progress of a running job.
+// Progress of finished jobs is not updated.
+func UpdateJobProgress(q *reform.Querier, id string, progress *JobProgress) (*JobResult, error) {
+	res, err := FindJobResultByID(q, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.Done {
+		return res, nil
+	}
+
+	if progress.Percentage < 0 || progress.Percentage > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid job progress percentage %v.", progress.Percentage)
+	}
+	if progress.UpdatedAt.IsZero() {
+		progress.UpdatedAt = Now()
+	}
+	progress.UpdatedAt = progress.UpdatedAt.UTC()
+
+	if res.Result == nil {
+		res.Result = new(JobResultData)
+	}
+	res.Result.Progress = progress
+	if err = q.Update(res); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
 // CleanupOldJobResults deletes jobs results older than a specified date.
 func CleanupOldJobResults(q *reform.Querier, olderThan time.Time) error {
 	_, err := q.DeleteFrom(JobResultTable, " WHERE updated_at <= $1", olderThan)
@@ -0,0 +1,132 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestJobResults(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	t.Run("update progress", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		job, err := models.CreateJobResult(q, "pmm_agent_id", models.MySQLBackupJob, &models.JobResultData{
+			MySQLBackup: &models.MySQLBackupJobResult{ArtifactID: "artifact_id"},
+		})
+		require.NoError(t, err)
+
+		updatedAt := time.Now().Truncate(time.Second)
+		job, err = models.UpdateJobProgress(q, job.ID, &models.JobProgress{
+			Percentage: 42,
+			BytesDone:  42,
+			BytesTotal: 100,
+			UpdatedAt:  updatedAt,
+		})
+		require.NoError(t, err)
+
+		job, err = models.FindJobResultByID(q, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "artifact_id", job.Result.MySQLBackup.ArtifactID)
+		require.NotNil(t, job.Result.Progress)
+		assert.Equal(t, float64(42), job.Result.Progress.Percentage)
+		assert.Equal(t, uint64(42), job.Result.Progress.BytesDone)
+		assert.Equal(t, uint64(100), job.Result.Progress.BytesTotal)
+		assert.True(t, updatedAt.Equal(job.Result.Progress.UpdatedAt))
+
+		_, err = models.UpdateJobProgress(q, job.ID, &models.JobProgress{Percentage: 101})
+		assert.EqualError(t, err, "rpc error: code = InvalidArgument desc = Invalid job progress percentage 101.")
+	})
+
+	t.Run("finished job", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		job, err := models.CreateJobResult(q, "pmm_agent_id", models.Echo, nil)
+		require.NoError(t, err)
+		job.Done = true
+		require.NoError(t, q.Update(job))
+
+		job, err = models.UpdateJobProgress(q, job.ID, &models.JobProgress{Status: "running"})
+		require.NoError(t, err)
+		assert.Nil(t, job.Result)
+	})
+
+	t.Run("find", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		backup, err := models.CreateJobResult(q, "pmm_agent_id", models.MySQLBackupJob, nil)
+		require.NoError(t, err)
+		restore, err := models.CreateJobResult(q, "pmm_agent_id", models.MySQLRestoreBackupJob, nil)
+		require.NoError(t, err)
+		restore.Done = true
+		require.NoError(t, q.Update(restore))
+		_, err = models.CreateJobResult(q, "pmm_agent_id", models.Echo, nil)
+		require.NoError(t, err)
+
+		jobs, err := models.FindJobResults(q, models.JobResultFilters{
+			Types: []models.JobType{models.MySQLBackupJob, models.MySQLRestoreBackupJob},
+		})
+		require.NoError(t, err)
+		assert.Len(t, jobs, 2)
+
+		done := false
+		jobs, err = models.FindJobResults(q, models.JobResultFilters{
+			Types: []models.JobType{models.MySQLBackupJob, models.MySQLRestoreBackupJob},
+			Done:  &done,
+		})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		assert.Equal(t, backup.ID, jobs[0].ID)
+
+		jobs, err = models.FindJobResults(q, models.JobResultFilters{Limit: 1})
+		require.NoError(t, err)
+		assert.Len(t, jobs, 1)
+	})
+}
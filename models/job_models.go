@@ -35,6 +35,8 @@ const (
 	MySQLRestoreBackupJob   = JobType("mysql_restore_backup")
 	MongoDBBackupJob        = JobType("mongodb_backup")
 	MongoDBRestoreBackupJob = JobType("mongodb_restore_backup")
+	ConfigSnapshotBackupJob = JobType("config_snapshot_backup")
+	MySQLLogicalBackupJob   = JobType("mysql_logical_backup")
 )
 
 // EchoJobResult stores echo job specific result data.
@@ -62,6 +64,49 @@ type MongoDBRestoreBackupJobResult struct {
 	RestoreID string `json:"restore_id,omitempty"`
 }
 
+// ConfigSnapshotBackupJobResult stores configuration snapshot backup job specific result data.
+// That job is performed by pt-summary Action on pmm-agent, not by pmm-agent job.
+type ConfigSnapshotBackupJobResult struct {
+	ArtifactID string `json:"artifact_id"`
+	ActionID   string `json:"action_id"`
+}
+
+// MySQLTable represents a MySQL table.
+type MySQLTable struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+}
+
+// MySQLLogicalBackupJobResult stores MySQL logical backup job specific result data.
+// That job is performed by pmm-managed with MySQL Actions on pmm-agent, one Action at a time:
+// tables are listed first, then SHOW CREATE TABLE and SELECT Actions are run for each table.
+type MySQLLogicalBackupJobResult struct {
+	ArtifactID string `json:"artifact_id"`
+	// ID of the running Action.
+	ActionID string `json:"action_id"`
+	// True if tables are listed.
+	TablesListed bool         `json:"tables_listed,omitempty"`
+	Tables       []MySQLTable `json:"tables,omitempty"`
+	// Number of tables stored in backup location.
+	TablesDone int `json:"tables_done,omitempty"`
+	// ID of finished SHOW CREATE TABLE Action for the current table; empty if it is not finished yet.
+	CreateTableActionID string `json:"create_table_action_id,omitempty"`
+}
+
+// JobProgress stores the last reported progress of a running job.
+type JobProgress struct {
+	// Percentage of work done from 0 to 100, 0 if unknown.
+	Percentage float64 `json:"percentage,omitempty"`
+	// Number of bytes processed and total number of bytes to process, 0 if unknown.
+	BytesDone  uint64 `json:"bytes_done,omitempty"`
+	BytesTotal uint64 `json:"bytes_total,omitempty"`
+	// True if percentage and bytes are estimated by pmm-managed instead of being reported by pmm-agent.
+	Estimated bool `json:"estimated,omitempty"`
+	// Free-form status reported by pmm-agent.
+	Status    string    `json:"status,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // JobResultData holds result data for different job types.
 type JobResultData struct {
 	Echo                 *EchoJobResult                 `json:"echo,omitempty"`
@@ -69,6 +114,12 @@ type JobResultData struct {
 	MySQLRestoreBackup   *MySQLRestoreBackupJobResult   `json:"mysql_restore_backup,omitempty"`
 	MongoDBBackup        *MongoDBBackupJobResult        `json:"mongo_db_backup,omitempty"`
 	MongoDBRestoreBackup *MongoDBRestoreBackupJobResult `json:"mongo_db_restore_backup,omitempty"`
+	ConfigSnapshotBackup *ConfigSnapshotBackupJobResult `json:"config_snapshot_backup,omitempty"`
+	MySQLLogicalBackup   *MySQLLogicalBackupJobResult   `json:"mysql_logical_backup,omitempty"`
+
+	Progress *JobProgress `json:"progress,omitempty"`
+	// Job is failed if it does not report progress for that duration; 0 if not limited.
+	Timeout time.Duration `json:"timeout,omitempty"`
 }
 
 // Value implements database/sql/driver.Valuer interface. Should be defined on the value.
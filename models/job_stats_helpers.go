@@ -0,0 +1,284 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// minJobStatsSamples is a minimal number of finished jobs of the same size class used for ETA prediction.
+// If there are fewer of them, jobs of all size classes are used.
+const minJobStatsSamples = 3
+
+// JobSizeClass returns size class for given processed data size in bytes:
+// the number of decimal digits in size, or 0 if size is unknown.
+func JobSizeClass(size int64) int {
+	if size <= 0 {
+		return 0
+	}
+	return int(math.Log10(float64(size))) + 1
+}
+
+// FindJobStats returns job duration statistics for given job type ordered by size class.
+func FindJobStats(q *reform.Querier, jobType JobType) ([]*JobStats, error) {
+	structs, err := q.SelectAllFrom(JobStatsTable, "WHERE job_type = $1 ORDER BY size_class", jobType)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*JobStats, len(structs))
+	for i, s := range structs {
+		res[i] = s.(*JobStats)
+	}
+	return res, nil
+}
+
+// RecordJobDuration adds duration of successfully finished job to statistics for its type and size class.
+func RecordJobDuration(q *reform.Querier, jobType JobType, sizeClass int, duration time.Duration) (*JobStats, error) {
+	if duration < 0 {
+		duration = 0
+	}
+
+	stats := &JobStats{
+		ID:          "/job_stats_id/" + uuid.New().String(),
+		JobType:     jobType,
+		SizeClass:   sizeClass,
+		MinDuration: duration,
+		MaxDuration: duration,
+	}
+	switch err := q.SelectOneTo(stats, "WHERE job_type = $1 AND size_class = $2 FOR UPDATE", jobType, sizeClass); err {
+	case nil:
+		if duration < stats.MinDuration {
+			stats.MinDuration = duration
+		}
+		if duration > stats.MaxDuration {
+			stats.MaxDuration = duration
+		}
+	case reform.ErrNoRows:
+		// new size class
+	default:
+		return nil, errors.WithStack(err)
+	}
+
+	seconds := duration.Seconds()
+	stats.Count++
+	stats.DurationSum += seconds
+	stats.DurationSquaresSum += seconds * seconds
+	if err := q.Save(stats); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return stats, nil
+}
+
+// RecordJobStats adds duration of given successfully finished job to statistics.
+// Jobs that are not finished or failed are ignored.
+func RecordJobStats(q *reform.Querier, job *JobResult) error {
+	if !job.Done || job.Error != "" || job.Type == Echo {
+		return nil
+	}
+
+	size, err := jobSizeBytes(q, job)
+	if err != nil {
+		return err
+	}
+
+	_, err = RecordJobDuration(q, job.Type, JobSizeClass(size), job.UpdatedAt.Sub(job.CreatedAt))
+	return err
+}
+
+// maxEstimatedPercentage is the upper limit of estimated job progress:
+// running job is never reported as done before pmm-agent tells so.
+const maxEstimatedPercentage = 99
+
+// PredictJobCompletion returns predicted completion time of running job
+// based on durations of previously finished jobs of the same type and size.
+// It returns nil if job is done or there is not enough data for prediction.
+func PredictJobCompletion(q *reform.Querier, job *JobResult) (*time.Time, error) {
+	if job.Done || job.Type == Echo {
+		return nil, nil
+	}
+
+	size, err := jobSizeBytes(q, job)
+	if err != nil {
+		return nil, err
+	}
+
+	mean, err := meanJobDuration(q, job.Type, size)
+	if err != nil || mean == 0 {
+		return nil, err
+	}
+
+	now := Now()
+	eta := job.CreatedAt.Add(mean)
+	if eta.Before(now) {
+		// job is running longer than usual; extrapolate reported progress, if any
+		var progress *JobProgress
+		if job.Result != nil {
+			progress = job.Result.Progress
+		}
+		if progress == nil || progress.Percentage <= 0 || progress.Estimated {
+			return nil, nil
+		}
+		elapsed := now.Sub(job.CreatedAt)
+		eta = job.CreatedAt.Add(time.Duration(float64(elapsed) * 100 / progress.Percentage))
+	}
+
+	eta = eta.UTC()
+	return &eta, nil
+}
+
+// EstimateJobProgress fills percentage and processed bytes of running job progress
+// that pmm-agent does not report (for example, for MySQL backups and restores).
+// They are estimated from the time elapsed since job start and durations of previously finished jobs
+// of the same type and size. Progress is not changed if there is not enough data for estimation.
+func EstimateJobProgress(q *reform.Querier, job *JobResult, progress *JobProgress) error {
+	if job.Done || job.Type == Echo {
+		return nil
+	}
+	if progress.Percentage > 0 && !progress.Estimated {
+		return nil
+	}
+
+	size, err := jobSizeBytes(q, job)
+	if err != nil {
+		return err
+	}
+
+	mean, err := meanJobDuration(q, job.Type, size)
+	if err != nil || mean == 0 {
+		return err
+	}
+
+	percentage := float64(Now().Sub(job.CreatedAt)) * 100 / float64(mean)
+	if percentage < 0 {
+		percentage = 0
+	}
+	if percentage > maxEstimatedPercentage {
+		percentage = maxEstimatedPercentage
+	}
+
+	progress.Percentage = percentage
+	progress.Estimated = true
+	if size > 0 {
+		progress.BytesTotal = uint64(size)
+		progress.BytesDone = uint64(float64(size) * percentage / 100)
+	}
+	return nil
+}
+
+// meanJobDuration returns mean duration of previously finished jobs of given type and size in bytes,
+// or 0 if there is no statistics yet.
+func meanJobDuration(q *reform.Querier, jobType JobType, size int64) (time.Duration, error) {
+	stats, err := FindJobStats(q, jobType)
+	if err != nil {
+		return 0, err
+	}
+
+	sizeClass := JobSizeClass(size)
+	var total, sameSize JobStats
+	for _, s := range stats {
+		total.Count += s.Count
+		total.DurationSum += s.DurationSum
+		if s.SizeClass == sizeClass {
+			sameSize = *s
+		}
+	}
+
+	switch {
+	case sameSize.Count >= minJobStatsSamples:
+		return sameSize.Mean(), nil
+	case total.Count != 0:
+		return total.Mean(), nil
+	default:
+		return 0, nil
+	}
+}
+
+// jobSizeBytes returns the size of data processed by job, or 0 if it is unknown.
+// Size reported by pmm-agent is used first; otherwise, it is the size of the previous artifact
+// of the same Service for backups and the size of the restored artifact for restores.
+func jobSizeBytes(q *reform.Querier, job *JobResult) (int64, error) {
+	r := job.Result
+	if r == nil {
+		return 0, nil
+	}
+	if r.Progress != nil && r.Progress.BytesTotal != 0 {
+		return int64(r.Progress.BytesTotal), nil
+	}
+
+	var artifactID, restoreID string
+	switch {
+	case r.MySQLBackup != nil:
+		artifactID = r.MySQLBackup.ArtifactID
+	case r.MongoDBBackup != nil:
+		artifactID = r.MongoDBBackup.ArtifactID
+	case r.MySQLRestoreBackup != nil:
+		restoreID = r.MySQLRestoreBackup.RestoreID
+	case r.MongoDBRestoreBackup != nil:
+		restoreID = r.MongoDBRestoreBackup.RestoreID
+	}
+
+	switch {
+	case artifactID != "":
+		artifact, err := FindArtifactByID(q, artifactID)
+		if err != nil {
+			return 0, ignoreNotFound(err)
+		}
+		artifacts, err := FindArtifacts(q, ArtifactFilters{
+			ServiceID: artifact.ServiceID,
+			Status:    SuccessBackupStatus,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, a := range artifacts {
+			if a.ID != artifactID && a.Size != nil {
+				return *a.Size, nil
+			}
+		}
+
+	case restoreID != "":
+		item, err := FindRestoreHistoryItemByID(q, restoreID)
+		if err != nil {
+			return 0, ignoreNotFound(err)
+		}
+		artifact, err := FindArtifactByID(q, item.ArtifactID)
+		if err != nil {
+			return 0, ignoreNotFound(err)
+		}
+		if artifact.Size != nil {
+			return *artifact.Size, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// ignoreNotFound returns nil for NotFound errors: size of removed artifacts is unknown.
+func ignoreNotFound(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
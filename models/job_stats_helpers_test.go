@@ -0,0 +1,169 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestJobSizeClass(t *testing.T) {
+	for size, expected := range map[int64]int{
+		-1:            0,
+		0:             0,
+		1:             1,
+		9:             1,
+		10:            2,
+		999:           3,
+		1000:          4,
+		1_000_000_000: 10,
+	} {
+		assert.Equal(t, expected, models.JobSizeClass(size), "size = %d", size)
+	}
+}
+
+func TestJobStats(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	t.Run("record", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		for _, d := range []time.Duration{time.Minute, 3 * time.Minute} {
+			_, err = models.RecordJobDuration(q, models.MySQLBackupJob, 3, d)
+			require.NoError(t, err)
+		}
+		_, err = models.RecordJobDuration(q, models.MySQLBackupJob, 0, time.Hour)
+		require.NoError(t, err)
+
+		stats, err := models.FindJobStats(q, models.MySQLBackupJob)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		assert.Equal(t, 0, stats[0].SizeClass)
+		assert.Equal(t, int64(1), stats[0].Count)
+		assert.Equal(t, 3, stats[1].SizeClass)
+		assert.Equal(t, int64(2), stats[1].Count)
+		assert.Equal(t, time.Minute, stats[1].MinDuration)
+		assert.Equal(t, 3*time.Minute, stats[1].MaxDuration)
+		assert.Equal(t, 2*time.Minute, stats[1].Mean())
+		assert.Equal(t, time.Minute, stats[1].StdDev())
+	})
+
+	t.Run("predict", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		job, err := models.CreateJobResult(q, "pmm_agent_id", models.MySQLBackupJob, &models.JobResultData{
+			MySQLBackup: &models.MySQLBackupJobResult{ArtifactID: "artifact_id"},
+			Progress:    &models.JobProgress{BytesTotal: 100},
+		})
+		require.NoError(t, err)
+
+		eta, err := models.PredictJobCompletion(q, job)
+		require.NoError(t, err)
+		assert.Nil(t, eta, "no statistics yet")
+
+		// not enough jobs of the same size, all sizes are used
+		_, err = models.RecordJobDuration(q, models.MySQLBackupJob, 3, time.Hour)
+		require.NoError(t, err)
+		_, err = models.RecordJobDuration(q, models.MySQLBackupJob, 5, 3*time.Hour)
+		require.NoError(t, err)
+		eta, err = models.PredictJobCompletion(q, job)
+		require.NoError(t, err)
+		require.NotNil(t, eta)
+		assert.Equal(t, job.CreatedAt.Add(2*time.Hour), *eta)
+
+		for i := 0; i < 2; i++ {
+			_, err = models.RecordJobDuration(q, models.MySQLBackupJob, 3, time.Hour)
+			require.NoError(t, err)
+		}
+		eta, err = models.PredictJobCompletion(q, job)
+		require.NoError(t, err)
+		require.NotNil(t, eta)
+		assert.Equal(t, job.CreatedAt.Add(time.Hour), *eta)
+
+		job.Done = true
+		eta, err = models.PredictJobCompletion(q, job)
+		require.NoError(t, err)
+		assert.Nil(t, eta)
+	})
+
+	t.Run("estimate", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		origNowF := models.Now
+		t.Cleanup(func() {
+			models.Now = origNowF
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+
+		job, err := models.CreateJobResult(q, "pmm_agent_id", models.MySQLBackupJob, &models.JobResultData{
+			MySQLBackup: &models.MySQLBackupJobResult{ArtifactID: "artifact_id"},
+			Progress:    &models.JobProgress{BytesTotal: 100},
+		})
+		require.NoError(t, err)
+		models.Now = func() time.Time {
+			return job.CreatedAt.Add(30 * time.Minute)
+		}
+
+		progress := new(models.JobProgress)
+		require.NoError(t, models.EstimateJobProgress(q, job, progress))
+		assert.Equal(t, new(models.JobProgress), progress, "no statistics yet")
+
+		_, err = models.RecordJobDuration(q, models.MySQLBackupJob, 3, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, models.EstimateJobProgress(q, job, progress))
+		assert.Equal(t, &models.JobProgress{Percentage: 50, BytesDone: 50, BytesTotal: 100, Estimated: true}, progress)
+
+		// running longer than usual
+		models.Now = func() time.Time {
+			return job.CreatedAt.Add(2 * time.Hour)
+		}
+		require.NoError(t, models.EstimateJobProgress(q, job, progress))
+		assert.Equal(t, &models.JobProgress{Percentage: 99, BytesDone: 99, BytesTotal: 100, Estimated: true}, progress)
+
+		// reported progress is kept
+		progress = &models.JobProgress{Percentage: 10}
+		require.NoError(t, models.EstimateJobProgress(q, job, progress))
+		assert.Equal(t, &models.JobProgress{Percentage: 10}, progress)
+	})
+}
@@ -0,0 +1,92 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// JobStats represents aggregated durations of successfully finished jobs of one type and size class.
+//reform:job_stats
+type JobStats struct {
+	ID      string  `reform:"id,pk"`
+	JobType JobType `reform:"job_type"`
+	// Decimal order of magnitude of processed data size in bytes; 0 if size is unknown.
+	SizeClass int   `reform:"size_class"`
+	Count     int64 `reform:"count"`
+	// Sum of durations and sum of squared durations in seconds, used for mean and standard deviation.
+	DurationSum        float64       `reform:"duration_sum"`
+	DurationSquaresSum float64       `reform:"duration_squares_sum"`
+	MinDuration        time.Duration `reform:"min_duration"`
+	MaxDuration        time.Duration `reform:"max_duration"`
+	CreatedAt          time.Time     `reform:"created_at"`
+	UpdatedAt          time.Time     `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (s *JobStats) BeforeInsert() error {
+	now := Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (s *JobStats) BeforeUpdate() error {
+	s.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (s *JobStats) AfterFind() error {
+	s.CreatedAt = s.CreatedAt.UTC()
+	s.UpdatedAt = s.UpdatedAt.UTC()
+	return nil
+}
+
+// Mean returns mean job duration.
+func (s *JobStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return time.Duration(s.DurationSum / float64(s.Count) * float64(time.Second))
+}
+
+// StdDev returns standard deviation of job durations.
+func (s *JobStats) StdDev() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	mean := s.DurationSum / float64(s.Count)
+	variance := s.DurationSquaresSum/float64(s.Count) - mean*mean
+	if variance <= 0 {
+		return 0
+	}
+	return time.Duration(math.Sqrt(variance) * float64(time.Second))
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*JobStats)(nil)
+	_ reform.BeforeUpdater  = (*JobStats)(nil)
+	_ reform.AfterFinder    = (*JobStats)(nil)
+)
@@ -0,0 +1,255 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// kubernetesSDRoles contains supported Kubernetes service discovery roles.
+var kubernetesSDRoles = map[string]struct{}{
+	"node":          {},
+	"service":       {},
+	"pod":           {},
+	"endpoints":     {},
+	"endpointslice": {},
+	"ingress":       {},
+}
+
+// kubernetesLabelKeyRE matches valid Kubernetes label keys (with optional DNS prefix).
+var kubernetesLabelKeyRE = regexp.MustCompile(`^([a-z0-9]([-a-z0-9.]*[a-z0-9])?/)?[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`)
+
+// kubernetesLabelValueRE matches valid Kubernetes label values.
+var kubernetesLabelValueRE = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?)?$`)
+
+// LabelSelectorOperator represents operator of Kubernetes label selector requirement.
+type LabelSelectorOperator string
+
+// Supported label selector operators.
+const (
+	LabelSelectorEquals       = LabelSelectorOperator("=")
+	LabelSelectorNotEquals    = LabelSelectorOperator("!=")
+	LabelSelectorExists       = LabelSelectorOperator("exists")
+	LabelSelectorDoesNotExist = LabelSelectorOperator("!exists")
+)
+
+// LabelSelectorRequirement represents a single requirement of Kubernetes equality-based label selector.
+type LabelSelectorRequirement struct {
+	Key      string
+	Operator LabelSelectorOperator
+	Value    string // empty for LabelSelectorExists and LabelSelectorDoesNotExist
+}
+
+// ParseLabelSelector parses Kubernetes equality-based label selector like "app=mysql,tier!=proxy,backup,!canary".
+// Set-based requirements (in, notin) are not supported.
+func ParseLabelSelector(selector string) ([]*LabelSelectorRequirement, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(selector, ",")
+	res := make([]*LabelSelectorRequirement, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+
+		var r LabelSelectorRequirement
+		switch {
+		case strings.Contains(p, "!="):
+			kv := strings.SplitN(p, "!=", 2)
+			r = LabelSelectorRequirement{Key: kv[0], Operator: LabelSelectorNotEquals, Value: kv[1]}
+		case strings.Contains(p, "=="):
+			kv := strings.SplitN(p, "==", 2)
+			r = LabelSelectorRequirement{Key: kv[0], Operator: LabelSelectorEquals, Value: kv[1]}
+		case strings.Contains(p, "="):
+			kv := strings.SplitN(p, "=", 2)
+			r = LabelSelectorRequirement{Key: kv[0], Operator: LabelSelectorEquals, Value: kv[1]}
+		case strings.HasPrefix(p, "!"):
+			r = LabelSelectorRequirement{Key: strings.TrimPrefix(p, "!"), Operator: LabelSelectorDoesNotExist}
+		default:
+			r = LabelSelectorRequirement{Key: p, Operator: LabelSelectorExists}
+		}
+
+		r.Key = strings.TrimSpace(r.Key)
+		r.Value = strings.TrimSpace(r.Value)
+		if !kubernetesLabelKeyRE.MatchString(r.Key) {
+			return nil, errors.Errorf("invalid label key %q in requirement %q", r.Key, p)
+		}
+		if !kubernetesLabelValueRE.MatchString(r.Value) {
+			return nil, errors.Errorf("invalid label value %q in requirement %q", r.Value, p)
+		}
+		res = append(res, &r)
+	}
+	return res, nil
+}
+
+// FindKubernetesScrapeConfigs returns all scrape jobs with Kubernetes service discovery.
+func FindKubernetesScrapeConfigs(q *reform.Querier) ([]*KubernetesScrapeConfig, error) {
+	rows, err := q.SelectAllFrom(KubernetesScrapeConfigTable, "ORDER BY job_name")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*KubernetesScrapeConfig, len(rows))
+	for i, r := range rows {
+		res[i] = r.(*KubernetesScrapeConfig)
+	}
+	return res, nil
+}
+
+// FindKubernetesScrapeConfigByID finds scrape job with Kubernetes service discovery by ID.
+func FindKubernetesScrapeConfigByID(q *reform.Querier, id string) (*KubernetesScrapeConfig, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty Kubernetes scrape config ID.")
+	}
+
+	res := &KubernetesScrapeConfig{ID: id}
+	switch err := q.Reload(res); err {
+	case nil:
+		return res, nil
+	case reform.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "Kubernetes scrape config with ID %q not found.", id)
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// CreateKubernetesScrapeConfigParams are params for creating scrape job with Kubernetes service discovery.
+type CreateKubernetesScrapeConfigParams struct {
+	JobName               string
+	KubernetesClusterName string
+	Role                  string
+	// Empty value means all namespaces.
+	Namespaces    []string
+	LabelSelector string
+	// Zero value means global scrape interval.
+	Interval    time.Duration
+	MetricsPath string
+	Scheme      string
+	TLS         *ExporterTLSOptions
+}
+
+// Validate validates params.
+func (p *CreateKubernetesScrapeConfigParams) Validate() error {
+	if !jobNameRE.MatchString(p.JobName) {
+		return status.Errorf(codes.InvalidArgument, "Invalid job name %q.", p.JobName)
+	}
+	if _, ok := reservedJobNames[p.JobName]; ok {
+		return status.Errorf(codes.InvalidArgument, "Job name %q is reserved.", p.JobName)
+	}
+
+	if _, ok := kubernetesSDRoles[p.Role]; !ok {
+		return status.Errorf(codes.InvalidArgument, "Unsupported role %q.", p.Role)
+	}
+	for _, ns := range p.Namespaces {
+		if !kubernetesLabelValueRE.MatchString(ns) || ns == "" {
+			return status.Errorf(codes.InvalidArgument, "Invalid namespace %q.", ns)
+		}
+	}
+	if _, err := ParseLabelSelector(p.LabelSelector); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid label selector: %s.", err)
+	}
+
+	if p.Interval != 0 && p.Interval < time.Second {
+		return status.Error(codes.InvalidArgument, "Interval: minimal resolution is 1s.")
+	}
+	if p.Interval.Truncate(time.Second) != p.Interval {
+		return status.Error(codes.InvalidArgument, "Interval: should be a natural number of seconds.")
+	}
+
+	if p.MetricsPath != "" && !strings.HasPrefix(p.MetricsPath, "/") {
+		return status.Errorf(codes.InvalidArgument, "Invalid metrics path %q.", p.MetricsPath)
+	}
+	switch p.Scheme {
+	case "", "http", "https":
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported scheme %q.", p.Scheme)
+	}
+	if p.TLS != nil {
+		if err := validateExporterTLSOptions(p.TLS); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateKubernetesScrapeConfig creates scrape job with Kubernetes service discovery.
+func CreateKubernetesScrapeConfig(q *reform.Querier, params *CreateKubernetesScrapeConfigParams) (*KubernetesScrapeConfig, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := FindKubernetesClusterByName(q, params.KubernetesClusterName); err != nil {
+		return nil, err
+	}
+
+	// job names are shared with custom scrape jobs
+	for _, table := range []reform.View{KubernetesScrapeConfigTable, CustomScrapeConfigTable} {
+		_, err := q.FindOneFrom(table, "job_name", params.JobName)
+		switch err {
+		case nil:
+			return nil, status.Errorf(codes.AlreadyExists, "Scrape config with job name %q already exists.", params.JobName)
+		case reform.ErrNoRows:
+			// nothing
+		default:
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if params.TLS != nil && *params.TLS == (ExporterTLSOptions{}) {
+		params.TLS = nil
+	}
+	row := &KubernetesScrapeConfig{
+		ID:                    "/kubernetes_scrape_config_id/" + uuid.New().String(),
+		JobName:               params.JobName,
+		KubernetesClusterName: params.KubernetesClusterName,
+		Role:                  params.Role,
+		Namespaces:            params.Namespaces,
+		LabelSelector:         params.LabelSelector,
+		Interval:              params.Interval,
+		MetricsPath:           params.MetricsPath,
+		Scheme:                params.Scheme,
+		TLS:                   params.TLS,
+	}
+	if row.Namespaces == nil {
+		row.Namespaces = []string{}
+	}
+	if err := q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return row, nil
+}
+
+// RemoveKubernetesScrapeConfig removes scrape job with Kubernetes service discovery by ID.
+func RemoveKubernetesScrapeConfig(q *reform.Querier, id string) error {
+	if _, err := FindKubernetesScrapeConfigByID(q, id); err != nil {
+		return err
+	}
+
+	if err := q.Delete(&KubernetesScrapeConfig{ID: id}); err != nil {
+		return errors.Wrap(err, "failed to delete Kubernetes scrape config")
+	}
+	return nil
+}
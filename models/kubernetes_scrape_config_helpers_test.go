@@ -0,0 +1,55 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		actual, err := models.ParseLabelSelector("app.kubernetes.io/name=pxc, tier==db,env!=dev,backup,!canary,empty=")
+		require.NoError(t, err)
+		expected := []*models.LabelSelectorRequirement{
+			{Key: "app.kubernetes.io/name", Operator: models.LabelSelectorEquals, Value: "pxc"},
+			{Key: "tier", Operator: models.LabelSelectorEquals, Value: "db"},
+			{Key: "env", Operator: models.LabelSelectorNotEquals, Value: "dev"},
+			{Key: "backup", Operator: models.LabelSelectorExists},
+			{Key: "canary", Operator: models.LabelSelectorDoesNotExist},
+			{Key: "empty", Operator: models.LabelSelectorEquals},
+		}
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		actual, err := models.ParseLabelSelector(" ")
+		require.NoError(t, err)
+		assert.Nil(t, actual)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, s := range []string{"app in (a,b)", "=value", "app=a b", "a,,b"} {
+			_, err := models.ParseLabelSelector(s)
+			assert.Error(t, err, "%q", s)
+		}
+	})
+}
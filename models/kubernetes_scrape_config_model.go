@@ -0,0 +1,77 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// KubernetesScrapeConfig represents scrape job with Kubernetes service discovery
+// in Kubernetes cluster registered in PMM.
+//reform:kubernetes_scrape_configs
+type KubernetesScrapeConfig struct {
+	ID                    string `reform:"id,pk"`
+	JobName               string `reform:"job_name"`
+	KubernetesClusterName string `reform:"kubernetes_cluster_name"`
+	// Kubernetes service discovery role: node, service, pod, endpoints, endpointslice, or ingress.
+	Role string `reform:"role"`
+	// Empty value means all namespaces.
+	Namespaces pq.StringArray `reform:"namespaces"`
+	// Kubernetes equality-based label selector, e.g. "app.kubernetes.io/name=percona-xtradb-cluster,tier!=proxy".
+	LabelSelector string `reform:"label_selector"`
+	// Zero value means global scrape interval.
+	Interval    time.Duration `reform:"interval"`
+	MetricsPath string        `reform:"metrics_path"`
+	Scheme      string        `reform:"scheme"`
+	// TLS options used for scraping discovered targets; nil if not used.
+	TLS       *ExporterTLSOptions `reform:"tls"`
+	CreatedAt time.Time           `reform:"created_at"`
+	UpdatedAt time.Time           `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (c *KubernetesScrapeConfig) BeforeInsert() error {
+	now := Now()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (c *KubernetesScrapeConfig) BeforeUpdate() error {
+	c.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (c *KubernetesScrapeConfig) AfterFind() error {
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.UpdatedAt = c.UpdatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*KubernetesScrapeConfig)(nil)
+	_ reform.BeforeUpdater  = (*KubernetesScrapeConfig)(nil)
+	_ reform.AfterFinder    = (*KubernetesScrapeConfig)(nil)
+)
@@ -0,0 +1,101 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// LabelValuesParams contains parameters for finding distinct label values.
+type LabelValuesParams struct {
+	// Label name, for example, environment, cluster, replication_set or custom label.
+	Label string
+	// Return only values of inventory objects having all those labels.
+	Matchers map[string]string
+}
+
+// FindLabelValues returns sorted distinct non-empty values of the label of Services and Nodes.
+// Service labels are combined with labels of its Node the same way as for scrape targets,
+// so values of Node labels like region are also returned.
+func FindLabelValues(q *reform.Querier, params *LabelValuesParams) ([]string, error) {
+	if !labelNameRE.MatchString(params.Label) {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid label name %q.", params.Label)
+	}
+
+	nodes, err := FindNodes(q, NodeFilters{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := FindServices(q, ServiceFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodeLabels := make(map[string]map[string]string, len(nodes))
+	for _, n := range nodes {
+		if nodeLabels[n.NodeID], err = n.UnifiedLabels(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	values := make(map[string]struct{})
+	add := func(labels map[string]string) {
+		for name, value := range params.Matchers {
+			if labels[name] != value {
+				return
+			}
+		}
+		if v := labels[params.Label]; v != "" {
+			values[v] = struct{}{}
+		}
+	}
+
+	// Nodes without Services are matched by their own labels only.
+	withServices := make(map[string]struct{}, len(nodes))
+	for _, s := range services {
+		serviceLabels, err := s.UnifiedLabels()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		labels := make(map[string]string, len(serviceLabels)+len(nodeLabels[s.NodeID]))
+		for name, value := range nodeLabels[s.NodeID] {
+			labels[name] = value
+		}
+		for name, value := range serviceLabels {
+			labels[name] = value
+		}
+		add(labels)
+		withServices[s.NodeID] = struct{}{}
+	}
+	for _, n := range nodes {
+		if _, ok := withServices[n.NodeID]; !ok {
+			add(nodeLabels[n.NodeID])
+		}
+	}
+
+	res := make([]string, 0, len(values))
+	for v := range values {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res, nil
+}
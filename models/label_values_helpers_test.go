@@ -0,0 +1,120 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestFindLabelValues(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, tx.Rollback())
+	})
+	q := tx.Querier
+
+	node1, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName: "node1",
+		Region:   pointer.ToString("eu-west-1"),
+	})
+	require.NoError(t, err)
+	_, err = models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName:     "node2",
+		Region:       pointer.ToString("us-east-1"),
+		CustomLabels: map[string]string{"team": "dba"},
+	})
+	require.NoError(t, err)
+
+	for _, params := range []*models.AddDBMSServiceParams{{
+		ServiceName:    "mysql1",
+		Environment:    "prod",
+		Cluster:        "c1",
+		ReplicationSet: "rs1",
+		CustomLabels:   map[string]string{"team": "sre"},
+	}, {
+		ServiceName:    "mysql2",
+		Environment:    "prod",
+		Cluster:        "c2",
+		ReplicationSet: "rs1",
+	}, {
+		ServiceName: "mysql3",
+		Environment: "dev",
+		Cluster:     "c1",
+	}} {
+		params.NodeID = node1.NodeID
+		params.Address = pointer.ToString("127.0.0.1")
+		params.Port = pointer.ToUint16(3306)
+		_, err = models.AddNewService(q, models.MySQLServiceType, params)
+		require.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		params   models.LabelValuesParams
+		expected []string
+	}{
+		{models.LabelValuesParams{Label: "environment"}, []string{"dev", "prod"}},
+		{models.LabelValuesParams{Label: "cluster"}, []string{"c1", "c2"}},
+		{models.LabelValuesParams{Label: "replication_set"}, []string{"rs1"}},
+		{models.LabelValuesParams{Label: "region"}, []string{"eu-west-1", "us-east-1"}},
+		{models.LabelValuesParams{Label: "team"}, []string{"dba", "sre"}},
+		{models.LabelValuesParams{Label: "unknown"}, []string{}},
+		{
+			models.LabelValuesParams{Label: "cluster", Matchers: map[string]string{"environment": "prod"}},
+			[]string{"c1", "c2"},
+		},
+		{
+			models.LabelValuesParams{Label: "service_name", Matchers: map[string]string{"environment": "prod", "cluster": "c1"}},
+			[]string{"mysql1"},
+		},
+		{
+			models.LabelValuesParams{Label: "node_name", Matchers: map[string]string{"region": "us-east-1"}},
+			[]string{"node2"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.params.Label, func(t *testing.T) {
+			actual, err := models.FindLabelValues(q, &tc.params)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("InvalidLabel", func(t *testing.T) {
+		_, err := models.FindLabelValues(q, &models.LabelValuesParams{Label: "not-valid"})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Invalid label name "not-valid".`), err)
+	})
+}
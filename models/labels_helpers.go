@@ -0,0 +1,319 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/AlekSi/pointer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// labelsBatchSize is a maximal number of objects stored with a single UpdateBatch call,
+// so progress of big changes is reported.
+const labelsBatchSize = 1000
+
+// LabelsObjectType represents type of inventory object with custom labels.
+type LabelsObjectType string
+
+// Inventory object types with custom labels.
+const (
+	NodeLabelsObjectType    LabelsObjectType = "node"
+	ServiceLabelsObjectType LabelsObjectType = "service"
+	AgentLabelsObjectType   LabelsObjectType = "agent"
+)
+
+// BulkChangeLabelsParams are params for changing custom labels of many objects at once.
+// Labels are renamed first, then removed, then added.
+type BulkChangeLabelsParams struct {
+	// Change only objects with labels (including labels of their Services and Nodes) matching all filters.
+	Selector Filters
+	// Change only objects of those types; all types if empty.
+	ObjectTypes []LabelsObjectType
+	// Custom labels to add or change.
+	Add map[string]string
+	// Custom labels to remove.
+	Remove []string
+	// Custom labels to rename, old name -> new name; alert rule filters are changed too.
+	Rename map[string]string
+	// If set, called after each stored batch of changed objects.
+	Progress func(done, total int)
+}
+
+// Validate validates params.
+func (p *BulkChangeLabelsParams) Validate() error {
+	if len(p.Add) == 0 && len(p.Remove) == 0 && len(p.Rename) == 0 {
+		return status.Error(codes.InvalidArgument, "No labels changes.")
+	}
+	if err := p.Selector.Validate(); err != nil {
+		return err
+	}
+
+	for _, t := range p.ObjectTypes {
+		switch t {
+		case NodeLabelsObjectType, ServiceLabelsObjectType, AgentLabelsObjectType:
+		default:
+			return status.Errorf(codes.InvalidArgument, "Unknown object type %q.", t)
+		}
+	}
+
+	names := make([]string, 0, len(p.Add)+len(p.Remove)+2*len(p.Rename))
+	for name, value := range p.Add {
+		if strings.TrimSpace(value) == "" {
+			return status.Errorf(codes.InvalidArgument, "Empty value of label %q.", name)
+		}
+		names = append(names, name)
+	}
+	names = append(names, p.Remove...)
+	for from, to := range p.Rename {
+		names = append(names, from, to)
+	}
+	for _, name := range names {
+		if !labelNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return status.Errorf(codes.InvalidArgument, "Invalid label name %q.", name)
+		}
+	}
+
+	return nil
+}
+
+// changeLabels returns a copy of custom labels changed according to params.
+func (p *BulkChangeLabelsParams) changeLabels(labels map[string]string) map[string]string {
+	res := make(map[string]string, len(labels)+len(p.Add))
+	for name, value := range labels {
+		res[name] = value
+	}
+
+	for from, to := range p.Rename {
+		if value, ok := labels[from]; ok {
+			delete(res, from)
+			res[to] = value
+		}
+	}
+	for _, name := range p.Remove {
+		delete(res, name)
+	}
+	for name, value := range p.Add {
+		res[name] = value
+	}
+	return res
+}
+
+// LabelsChange represents custom labels change of a single object.
+type LabelsChange struct {
+	ObjectType LabelsObjectType  `json:"object_type"`
+	ID         string            `json:"id"`
+	Before     map[string]string `json:"before"`
+	After      map[string]string `json:"after"`
+}
+
+// BulkChangeLabelsResult represents result of BulkChangeLabels.
+type BulkChangeLabelsResult struct {
+	Changes []*LabelsChange `json:"changes"`
+	// IDs of alert rules with changed filters.
+	RuleIDs []string `json:"rule_ids,omitempty"`
+}
+
+// labelsObject is an inventory object with custom labels.
+type labelsObject interface {
+	reform.Record
+	GetCustomLabels() (map[string]string, error)
+	SetCustomLabels(map[string]string) error
+}
+
+// BulkChangeLabels changes custom labels of Nodes, Services and Agents matching given selector,
+// and filters of alert rules using renamed labels.
+func BulkChangeLabels(q *reform.Querier, params *BulkChangeLabelsParams) (*BulkChangeLabelsResult, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	types := make(map[LabelsObjectType]bool, 3)
+	for _, t := range params.ObjectTypes {
+		types[t] = true
+	}
+	all := len(types) == 0
+
+	nodes, err := FindNodes(q, NodeFilters{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := FindServices(q, ServiceFilters{})
+	if err != nil {
+		return nil, err
+	}
+	agents, err := FindAgents(q, AgentFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodesByID := make(map[string]*Node, len(nodes))
+	for _, n := range nodes {
+		nodesByID[n.NodeID] = n
+	}
+	servicesByID := make(map[string]*Service, len(services))
+	for _, s := range services {
+		servicesByID[s.ServiceID] = s
+	}
+
+	var objects []labelsObject
+	var objectTypes []LabelsObjectType
+	var matchLabels []map[string]string
+	add := func(t LabelsObjectType, o labelsObject, node *Node, service *Service, agent *Agent) error {
+		if !all && !types[t] {
+			return nil
+		}
+		labels, err := MergeLabels(node, service, agent)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, o)
+		objectTypes = append(objectTypes, t)
+		matchLabels = append(matchLabels, labels)
+		return nil
+	}
+
+	for _, n := range nodes {
+		if err = add(NodeLabelsObjectType, n, n, nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range services {
+		if err = add(ServiceLabelsObjectType, s, nodesByID[s.NodeID], s, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range agents {
+		service := servicesByID[pointer.GetString(a.ServiceID)]
+		nodeID := pointer.GetString(a.NodeID)
+		if service != nil {
+			nodeID = service.NodeID
+		}
+		if nodeID == "" {
+			nodeID = pointer.GetString(a.RunsOnNodeID)
+		}
+		if err = add(AgentLabelsObjectType, a, nodesByID[nodeID], service, a); err != nil {
+			return nil, err
+		}
+	}
+
+	res := new(BulkChangeLabelsResult)
+	var changed []labelsObject
+	for i, o := range objects {
+		if !params.Selector.Match(matchLabels[i]) {
+			continue
+		}
+
+		before, err := o.GetCustomLabels()
+		if err != nil {
+			return nil, err
+		}
+		after := params.changeLabels(before)
+		if labelsEqual(before, after) {
+			continue
+		}
+
+		changed = append(changed, o)
+		res.Changes = append(res.Changes, &LabelsChange{
+			ObjectType: objectTypes[i],
+			ID:         o.PKValue().(string),
+			Before:     before,
+			After:      after,
+		})
+	}
+
+	// changed objects are grouped by type (and table) in the order of objects
+	var done int
+	for start := 0; start < len(changed); {
+		end := start + 1
+		for end < len(changed) && end-start < labelsBatchSize && res.Changes[end].ObjectType == res.Changes[start].ObjectType {
+			end++
+		}
+
+		records := make([]reform.Record, 0, end-start)
+		for i := start; i < end; i++ {
+			if err = changed[i].SetCustomLabels(res.Changes[i].After); err != nil {
+				return nil, err
+			}
+			records = append(records, changed[i])
+		}
+		if err = UpdateBatch(q, records, "custom_labels", "updated_at"); err != nil {
+			return nil, err
+		}
+
+		done += len(records)
+		if params.Progress != nil {
+			params.Progress(done, len(changed))
+		}
+		start = end
+	}
+
+	if len(params.Rename) != 0 {
+		if res.RuleIDs, err = renameRulesFiltersLabels(q, params.Rename); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// renameRulesFiltersLabels renames labels in alert rule filters and returns IDs of changed rules.
+func renameRulesFiltersLabels(q *reform.Querier, rename map[string]string) ([]string, error) {
+	rules, err := FindRules(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	var changed []reform.Record
+	for _, r := range rules {
+		var ruleChanged bool
+		for i, f := range r.Filters {
+			if to, ok := rename[f.Key]; ok {
+				r.Filters[i].Key = to
+				ruleChanged = true
+			}
+		}
+		if ruleChanged {
+			changed = append(changed, r)
+			res = append(res, r.ID)
+		}
+	}
+
+	if err = UpdateBatch(q, changed, "filters", "updated_at"); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// labelsEqual returns true if both label sets are the same.
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if v, ok := b[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona-platform/saas/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestBulkChangeLabelsParams(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		params models.BulkChangeLabelsParams
+		err    string
+	}{{
+		name:   "NoChanges",
+		params: models.BulkChangeLabelsParams{},
+		err:    "rpc error: code = InvalidArgument desc = No labels changes.",
+	}, {
+		name: "InvalidName",
+		params: models.BulkChangeLabelsParams{
+			Rename: map[string]string{"team": "team-name"},
+		},
+		err: "rpc error: code = InvalidArgument desc = Invalid label name \"team-name\".",
+	}, {
+		name: "EmptyValue",
+		params: models.BulkChangeLabelsParams{
+			Add: map[string]string{"team": " "},
+		},
+		err: "rpc error: code = InvalidArgument desc = Empty value of label \"team\".",
+	}, {
+		name: "UnknownObjectType",
+		params: models.BulkChangeLabelsParams{
+			ObjectTypes: []models.LabelsObjectType{"rule"},
+			Remove:      []string{"team"},
+		},
+		err: "rpc error: code = InvalidArgument desc = Unknown object type \"rule\".",
+	}, {
+		name: "Valid",
+		params: models.BulkChangeLabelsParams{
+			Selector:    models.Filters{{Type: models.Regex, Key: "service_name", Val: "mysql.*"}},
+			ObjectTypes: []models.LabelsObjectType{models.ServiceLabelsObjectType},
+			Add:         map[string]string{"team": "dba"},
+			Rename:      map[string]string{"owner": "team_owner"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.params.Validate()
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBulkChangeLabels(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	defer func() {
+		require.NoError(t, sqlDB.Close())
+	}()
+
+	setup := func(t *testing.T) (q *reform.Querier, teardown func(t *testing.T)) {
+		db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		q = tx.Querier
+
+		node := &models.Node{
+			NodeID:   "N1",
+			NodeType: models.GenericNodeType,
+			NodeName: "Node",
+		}
+		require.NoError(t, node.SetCustomLabels(map[string]string{"dc": "eu"}))
+		mysql := &models.Service{
+			ServiceID:   "S1",
+			ServiceType: models.MySQLServiceType,
+			ServiceName: "mysql1",
+			NodeID:      "N1",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16OrNil(3306),
+		}
+		require.NoError(t, mysql.SetCustomLabels(map[string]string{"owner": "alice"}))
+		mongo := &models.Service{
+			ServiceID:   "S2",
+			ServiceType: models.MongoDBServiceType,
+			ServiceName: "mongo1",
+			NodeID:      "N1",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16OrNil(27017),
+		}
+		require.NoError(t, mongo.SetCustomLabels(map[string]string{"owner": "bob"}))
+
+		for _, str := range []reform.Struct{
+			node,
+			mysql,
+			mongo,
+			&models.Agent{
+				AgentID:      "A1",
+				AgentType:    models.PMMAgentType,
+				RunsOnNodeID: pointer.ToString("N1"),
+			},
+			&models.Agent{
+				AgentID:    "A2",
+				AgentType:  models.MySQLdExporterType,
+				PMMAgentID: pointer.ToString("A1"),
+				ServiceID:  pointer.ToString("S1"),
+			},
+			&models.Rule{
+				ID:       "R1",
+				Severity: models.Severity(common.Warning),
+				Filters:  models.Filters{{Type: models.Equal, Key: "owner", Val: "alice"}},
+			},
+		} {
+			require.NoError(t, q.Insert(str))
+		}
+
+		teardown = func(t *testing.T) {
+			require.NoError(t, tx.Rollback())
+		}
+		return
+	}
+
+	t.Run("Rename", func(t *testing.T) {
+		q, teardown := setup(t)
+		defer teardown(t)
+
+		var progress []int
+		res, err := models.BulkChangeLabels(q, &models.BulkChangeLabelsParams{
+			Selector: models.Filters{{Type: models.Equal, Key: "dc", Val: "eu"}},
+			Rename:   map[string]string{"owner": "team"},
+			Progress: func(done, total int) {
+				assert.Equal(t, 2, total)
+				progress = append(progress, done)
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{2}, progress) // both Services are stored in a single batch
+		assert.Equal(t, []string{"R1"}, res.RuleIDs)
+		require.Len(t, res.Changes, 2)
+		assert.Equal(t, &models.LabelsChange{
+			ObjectType: models.ServiceLabelsObjectType,
+			ID:         "S1",
+			Before:     map[string]string{"owner": "alice"},
+			After:      map[string]string{"team": "alice"},
+		}, res.Changes[0])
+
+		service, err := models.FindServiceByID(q, "S2")
+		require.NoError(t, err)
+		labels, err := service.GetCustomLabels()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "bob"}, labels)
+
+		rule, err := models.FindRuleByID(q, "R1")
+		require.NoError(t, err)
+		assert.Equal(t, "team", rule.Filters[0].Key)
+	})
+
+	t.Run("AgentsOfService", func(t *testing.T) {
+		q, teardown := setup(t)
+		defer teardown(t)
+
+		res, err := models.BulkChangeLabels(q, &models.BulkChangeLabelsParams{
+			Selector:    models.Filters{{Type: models.Equal, Key: "service_name", Val: "mysql1"}},
+			ObjectTypes: []models.LabelsObjectType{models.AgentLabelsObjectType},
+			Add:         map[string]string{"team": "dba"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []*models.LabelsChange{{
+			ObjectType: models.AgentLabelsObjectType,
+			ID:         "A2",
+			After:      map[string]string{"team": "dba"},
+		}}, res.Changes)
+		assert.Empty(t, res.RuleIDs)
+	})
+}
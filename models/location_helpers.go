@@ -380,6 +380,15 @@ func RemoveBackupLocation(q *reform.Querier, id string, mode RemoveMode) error {
 		return err
 	}
 
+	for _, a := range artifacts {
+		if a.Hold {
+			return status.Errorf(codes.FailedPrecondition, "backup location with ID %q has artifacts on legal hold.", id)
+		}
+		if a.Golden {
+			return status.Errorf(codes.FailedPrecondition, "backup location with ID %q has golden artifacts.", id)
+		}
+	}
+
 	if mode == RemoveRestrict {
 		if len(artifacts) != 0 {
 			return status.Errorf(codes.FailedPrecondition, "backup location with ID %q has artifacts.", id)
@@ -0,0 +1,269 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+const (
+	// checksumsSchemaVersion is the first schema version with checksum and applied_at columns in schema_migrations.
+	checksumsSchemaVersion = 47
+
+	// migrationsLockID is an arbitrary PostgreSQL advisory lock ID used to serialize migrations
+	// performed by several pmm-managed instances sharing the same database.
+	migrationsLockID = 0x706d6d // "pmm"
+
+	// slowMigrationStatement is a statement duration after which it is reported as slow.
+	slowMigrationStatement = 10 * time.Second
+)
+
+// databaseSchemaDown maps schema version to a slice of DDL queries that revert it.
+// Downgrade to some version is only possible if all later versions have down migrations.
+var databaseSchemaDown = map[int][]string{
+	44: {
+		`DROP TABLE service_software_versions`,
+	},
+	45: {
+		`DROP TABLE anomaly_baselines`,
+	},
+	46: {
+		`ALTER TABLE restore_history
+			DROP COLUMN timeline,
+			DROP COLUMN log_tail`,
+	},
+	47: {
+		`ALTER TABLE schema_migrations
+			DROP COLUMN checksum,
+			DROP COLUMN applied_at`,
+	},
+	48: {
+		`ALTER TABLE artifacts DROP COLUMN orphaned`,
+	},
+	49: {
+		`ALTER TABLE artifacts DROP COLUMN size`,
+	},
+	50: {
+		`ALTER TABLE artifacts DROP COLUMN group_id`,
+	},
+	51: {
+		`DROP TABLE automations`,
+	},
+	52: {
+		`DROP TABLE dashboards`,
+	},
+	53: {
+		`ALTER TABLE artifacts DROP COLUMN metadata`,
+	},
+	54: {
+		`ALTER TABLE nodes DROP COLUMN notes, DROP COLUMN owner, DROP COLUMN contact`,
+		`ALTER TABLE services DROP COLUMN notes, DROP COLUMN owner, DROP COLUMN contact`,
+	},
+	55: {
+		`DROP TABLE scheduled_task_runs`,
+	},
+	56: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN misfire_policy`,
+	},
+	57: {
+		`DROP TABLE audit_events`,
+		`ALTER TABLE agents DROP COLUMN last_seen_at`,
+		`ALTER TABLE services DROP COLUMN ttl`,
+		`ALTER TABLE nodes DROP COLUMN ttl`,
+	},
+	58: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN jitter, DROP COLUMN window_start, DROP COLUMN window_end`,
+	},
+	59: {
+		`ALTER TABLE nodes DROP COLUMN k8s_namespace, DROP COLUMN k8s_pod_name, DROP COLUMN k8s_deployment, DROP COLUMN k8s_node_name`,
+	},
+	60: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN concurrency_policy`,
+	},
+	61: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN remove_after_run`,
+	},
+	62: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN retries, DROP COLUMN retry_interval`,
+	},
+	63: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN depends_on`,
+	},
+	64: {
+		`ALTER TABLE scheduled_tasks DROP COLUMN retry_backoff, DROP COLUMN max_retry_interval, DROP COLUMN retry_budget`,
+	},
+	65: {
+		`ALTER TABLE services DROP COLUMN metrics_resolutions`,
+	},
+	66: {
+		`ALTER TABLE artifacts DROP COLUMN hold`,
+	},
+	67: {
+		`DROP TABLE usage_snapshots`,
+	},
+	68: {
+		`ALTER TABLE agents DROP COLUMN metrics_resolutions, DROP COLUMN metrics_resolution_tier`,
+	},
+	69: {
+		`ALTER TABLE agents DROP COLUMN exporter_tls`,
+	},
+	70: {
+		`ALTER TABLE artifacts DROP COLUMN golden`,
+	},
+	71: {
+		`DROP TABLE custom_scrape_configs`,
+	},
+	72: {
+		`DROP TABLE job_stats`,
+	},
+	73: {
+		`ALTER TABLE nodes DROP COLUMN facts, DROP COLUMN facts_action_id`,
+	},
+	74: {
+		`ALTER TABLE services DROP COLUMN database_autodiscovery`,
+	},
+	75: {
+		`DROP TABLE kubernetes_scrape_configs`,
+	},
+	76: {
+		`ALTER TABLE ia_channels DROP COLUMN throttling`,
+	},
+	77: {
+		`ALTER TABLE audit_events DROP COLUMN actor`,
+	},
+}
+
+// errDryRun is used to rollback migrations transaction in dry-run mode.
+var errDryRun = errors.New("dry run")
+
+// MigrationStatus represents the status of database schema migration.
+type MigrationStatus struct {
+	Running        bool
+	CurrentVersion int
+	TargetVersion  int
+	StartedAt      time.Time
+}
+
+var migrationStatus struct {
+	rw sync.RWMutex
+	s  MigrationStatus
+}
+
+// GetMigrationStatus returns the status of database schema migration performed by SetupDB.
+func GetMigrationStatus() MigrationStatus {
+	migrationStatus.rw.RLock()
+	defer migrationStatus.rw.RUnlock()
+
+	return migrationStatus.s
+}
+
+// setMigrationStatus updates database schema migration status.
+func setMigrationStatus(f func(s *MigrationStatus)) {
+	migrationStatus.rw.Lock()
+	defer migrationStatus.rw.Unlock()
+
+	f(&migrationStatus.s)
+}
+
+// migrationChecksum returns a checksum of given schema version queries.
+func migrationChecksum(version int) string {
+	queries := make([]string, len(databaseSchema[version]))
+	for i, q := range databaseSchema[version] {
+		queries[i] = strings.TrimSpace(q)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(queries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// currentSchemaVersion returns the latest applied schema version, or 0 for empty database.
+func currentSchemaVersion(q *reform.Querier) (int, error) {
+	// check table existence first: a failed query aborts the whole transaction
+	var exists bool
+	if err := q.QueryRow("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	err := q.QueryRow("SELECT id FROM schema_migrations ORDER BY id DESC LIMIT 1").Scan(&version)
+	if err == reform.ErrNoRows {
+		err = nil
+	}
+	return version, errors.WithStack(err)
+}
+
+// checkDowngradePath returns an error if database can't be downgraded from current to target version.
+func checkDowngradePath(current, target int) error {
+	for version := current; version > target; version-- {
+		if _, ok := databaseSchemaDown[version]; !ok {
+			return errors.Errorf("can't downgrade database schema from version %d to %d: version %d has no down migration", current, target, version)
+		}
+	}
+	return nil
+}
+
+// verifyMigrationChecksums checks that queries of applied schema versions were not changed.
+// Versions applied before checksums were introduced get their checksums recorded.
+func verifyMigrationChecksums(q *reform.Querier) error {
+	rows, err := q.Query("SELECT id, checksum FROM schema_migrations ORDER BY id")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var missing []int
+	for rows.Next() {
+		var version int
+		var checksum *string
+		if err = rows.Scan(&version, &checksum); err != nil {
+			return errors.WithStack(err)
+		}
+		if version >= len(databaseSchema) {
+			// applied by a newer pmm-managed version
+			continue
+		}
+		if checksum == nil {
+			missing = append(missing, version)
+			continue
+		}
+		if expected := migrationChecksum(version); *checksum != expected {
+			return errors.Errorf("checksum mismatch for database schema version %d: expected %s, got %s", version, expected, *checksum)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = rows.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, version := range missing {
+		if _, err = q.Exec("UPDATE schema_migrations SET checksum = $1 WHERE id = $2", migrationChecksum(version), version); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	t.Run("DownMigrations", func(t *testing.T) {
+		for version := range databaseSchemaDown {
+			assert.Less(t, version, len(databaseSchema), "down migration for unknown version %d", version)
+		}
+		assert.Contains(t, databaseSchemaDown, len(databaseSchema)-1, "latest version should have down migration")
+	})
+
+	t.Run("DowngradePath", func(t *testing.T) {
+		assert.NoError(t, checkDowngradePath(47, 43))
+		assert.NoError(t, checkDowngradePath(47, 47))
+		assert.EqualError(t, checkDowngradePath(47, 42), "can't downgrade database schema from version 47 to 42: version 43 has no down migration")
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		assert.Len(t, migrationChecksum(1), 64)
+		assert.Equal(t, migrationChecksum(1), migrationChecksum(1))
+		assert.NotEqual(t, migrationChecksum(1), migrationChecksum(2))
+	})
+}
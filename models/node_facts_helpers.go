@@ -0,0 +1,64 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+// StartNodeFactsCollection stores ID of facts collection Action started for Node with given ID.
+func StartNodeFactsCollection(q *reform.Querier, nodeID, actionID string) (*Node, error) {
+	node, err := FindNodeByID(q, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	node.FactsActionID = actionID
+	if err = q.Update(node); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return node, nil
+}
+
+// FinishNodeFactsCollection stores facts collected by Action with given ID.
+// If facts are nil (Action failed), previously collected facts are kept.
+// It returns nil if there is no Node waiting for that Action.
+func FinishNodeFactsCollection(q *reform.Querier, actionID string, facts *NodeFacts) (*Node, error) {
+	if actionID == "" {
+		return nil, nil
+	}
+
+	node := new(Node)
+	switch err := q.SelectOneTo(node, "WHERE facts_action_id = $1", actionID); err {
+	case nil:
+		// continue
+	case reform.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+
+	node.FactsActionID = ""
+	if facts != nil {
+		node.Facts = facts
+	}
+	if err := q.Update(node); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return node, nil
+}
@@ -19,6 +19,7 @@ package models
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/google/uuid"
@@ -33,8 +34,8 @@ func checkUniqueNodeID(q *reform.Querier, id string) error {
 		panic("empty Node ID")
 	}
 
-	node := &Node{NodeID: id}
-	switch err := q.Reload(node); err {
+	node := &Node{}
+	switch err := selectOneTo(q, node, "WHERE node_id = $1", id); err {
 	case nil:
 		return status.Errorf(codes.AlreadyExists, "Node with ID %q already exists.", id)
 	case reform.ErrNoRows:
@@ -90,16 +91,32 @@ func CheckUniqueNodeInstanceRegion(q *reform.Querier, instance string, region *s
 type NodeFilters struct {
 	// Return Nodes with provided type.
 	NodeType *NodeType
+	// Return only Nodes with owner, contact or notes containing that string (case-insensitive).
+	Search string
+	// Return only Nodes with OS facts containing that string (case-insensitive).
+	Facts string
 }
 
 // FindNodes returns Nodes by filters.
 func FindNodes(q *reform.Querier, filters NodeFilters) ([]*Node, error) {
-	var whereClause string
+	var conditions []string
 	var args []interface{}
 	if filters.NodeType != nil {
-		whereClause = "WHERE node_type = $1"
+		conditions = append(conditions, fmt.Sprintf("node_type = %s", q.Placeholder(len(args)+1)))
 		args = append(args, *filters.NodeType)
 	}
+	if filters.Search != "" {
+		conditions = append(conditions, ownershipSearchCondition(q.Placeholder(len(args)+1)))
+		args = append(args, filters.Search)
+	}
+	if filters.Facts != "" {
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(facts::text), lower(%s)) > 0", q.Placeholder(len(args)+1)))
+		args = append(args, filters.Facts)
+	}
+	var whereClause string
+	if len(conditions) != 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
 	structs, err := q.SelectAllFrom(NodeTable, fmt.Sprintf("%s ORDER BY node_id", whereClause), args...)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -114,7 +131,9 @@ func FindNodes(q *reform.Querier, filters NodeFilters) ([]*Node, error) {
 }
 
 // FindNodeByID finds a Node by ID.
-func FindNodeByID(q *reform.Querier, id string) (*Node, error) {
+func FindNodeByID(q *reform.Querier, id string) (_ *Node, err error) {
+	defer observeQuery("FindNodeByID", time.Now(), &err)
+
 	if id == "" {
 		return nil, status.Error(codes.InvalidArgument, "Empty Node ID.")
 	}
@@ -183,6 +202,8 @@ type CreateNodeParams struct {
 	CustomLabels  map[string]string
 	Address       string
 	Region        *string
+	// Well-known Kubernetes custom labels are used for fields that are not set.
+	Kubernetes KubernetesMetadata
 }
 
 // createNodeWithID creates a Node with given ID.
@@ -224,7 +245,10 @@ func createNodeWithID(q *reform.Querier, id string, nodeType NodeType, params *C
 		Address:       params.Address,
 		Region:        params.Region,
 	}
-	if err := node.SetCustomLabels(params.CustomLabels); err != nil {
+	md := params.Kubernetes
+	customLabels := extractKubernetesMetadata(&md, params.CustomLabels)
+	node.setKubernetesMetadata(&md)
+	if err := node.SetCustomLabels(customLabels); err != nil {
 		return nil, err
 	}
 	if err := q.Insert(node); err != nil {
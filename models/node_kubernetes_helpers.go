@@ -0,0 +1,102 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// Labels with Kubernetes metadata of Node. Custom labels with those names set on Node registration
+// (for example, with pmm-admin --custom-labels) are stored as Node's Kubernetes metadata.
+const (
+	KubernetesNamespaceLabel  = "k8s_namespace"
+	KubernetesPodLabel        = "k8s_pod_name"
+	KubernetesDeploymentLabel = "k8s_deployment"
+	KubernetesNodeLabel       = "k8s_node_name"
+)
+
+// KubernetesMetadata represents Kubernetes metadata of Node running in a pod.
+type KubernetesMetadata struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	// Deployment (or StatefulSet) name; unlike pod name, it is not changed when pod is rescheduled.
+	Deployment string `json:"deployment"`
+	// Name of Kubernetes node the pod is running on.
+	NodeName string `json:"node_name"`
+}
+
+// extractKubernetesMetadata moves well-known Kubernetes labels from custom labels to metadata fields that are not set.
+// It returns custom labels without those labels; given map is not changed.
+func extractKubernetesMetadata(md *KubernetesMetadata, customLabels map[string]string) map[string]string {
+	fields := map[string]*string{
+		KubernetesNamespaceLabel:  &md.Namespace,
+		KubernetesPodLabel:        &md.Pod,
+		KubernetesDeploymentLabel: &md.Deployment,
+		KubernetesNodeLabel:       &md.NodeName,
+	}
+
+	res := make(map[string]string, len(customLabels))
+	for name, value := range customLabels {
+		field, ok := fields[name]
+		if !ok {
+			res[name] = value
+			continue
+		}
+		if *field == "" {
+			*field = value
+		}
+	}
+	return res
+}
+
+// KubernetesMetadata returns Kubernetes metadata of the Node.
+func (s *Node) KubernetesMetadata() *KubernetesMetadata {
+	return &KubernetesMetadata{
+		Namespace:  s.KubernetesNamespace,
+		Pod:        s.KubernetesPod,
+		Deployment: s.KubernetesDeployment,
+		NodeName:   s.KubernetesNode,
+	}
+}
+
+func (s *Node) setKubernetesMetadata(md *KubernetesMetadata) {
+	s.KubernetesNamespace = md.Namespace
+	s.KubernetesPod = md.Pod
+	s.KubernetesDeployment = md.Deployment
+	s.KubernetesNode = md.NodeName
+}
+
+// ChangeNodeKubernetesMetadata replaces Kubernetes metadata of the Node.
+func ChangeNodeKubernetesMetadata(q *reform.Querier, nodeID string, md *KubernetesMetadata) (*Node, error) {
+	if md.Pod != "" && md.Namespace == "" {
+		return nil, status.Error(codes.InvalidArgument, "Kubernetes namespace is required for pod.")
+	}
+
+	n, err := FindNodeByID(q, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	n.setKubernetesMetadata(md)
+	if err = q.Update(n); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return n, nil
+}
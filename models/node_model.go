@@ -17,6 +17,7 @@
 package models
 
 import (
+	"database/sql/driver"
 	"time"
 
 	"github.com/AlekSi/pointer"
@@ -41,6 +42,38 @@ const (
 // PMMServerNodeID is a special Node ID representing PMM Server Node.
 const PMMServerNodeID string = "pmm-server" // no /node_id/ prefix
 
+// NodeDisk represents mounted filesystem of Node.
+type NodeDisk struct {
+	Filesystem string `json:"filesystem"`
+	Type       string `json:"type,omitempty"`
+	MountPoint string `json:"mount_point"`
+	SizeBytes  uint64 `json:"size_bytes,omitempty"`
+}
+
+// NodeFacts represents OS inventory facts collected by pmm-agent running on Node.
+type NodeFacts struct {
+	Hostname       string `json:"hostname,omitempty"`
+	Platform       string `json:"platform,omitempty"`
+	Release        string `json:"release,omitempty"`
+	Kernel         string `json:"kernel,omitempty"`
+	Architecture   string `json:"architecture,omitempty"`
+	Virtualization string `json:"virtualization,omitempty"`
+	// Hardware vendor and product name.
+	System string `json:"system,omitempty"`
+	// Cloud instance type, if known.
+	InstanceType string      `json:"instance_type,omitempty"`
+	CPUCount     int         `json:"cpu_count,omitempty"`
+	MemoryBytes  uint64      `json:"memory_bytes,omitempty"`
+	Disks        []*NodeDisk `json:"disks,omitempty"`
+	CollectedAt  time.Time   `json:"collected_at"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (f NodeFacts) Value() (driver.Value, error) { return jsonValue(f) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (f *NodeFacts) Scan(src interface{}) error { return jsonScan(f, src) }
+
 // Node represents Node as stored in database.
 //reform:nodes
 type Node struct {
@@ -53,6 +86,14 @@ type Node struct {
 	AZ           string   `reform:"az"`
 	CustomLabels []byte   `reform:"custom_labels"`
 
+	// Free-form ownership information shown to on-call engineers.
+	Notes   string `reform:"notes"`
+	Owner   string `reform:"owner"`
+	Contact string `reform:"contact"`
+
+	// Node and its dependent objects are removed when none of its pmm-agents is connected for that duration; zero means never.
+	TTL time.Duration `reform:"ttl"`
+
 	// Node address. Used to construct endpoint for node_exporter.
 	// For RemoteRDS Nodes contains DBInstanceIdentifier (not DbiResourceId; not endpoint - that's Service address).
 	Address string `reform:"address"`
@@ -63,7 +104,18 @@ type Node struct {
 	ContainerID   *string `reform:"container_id"` // nil means "unknown"; non-nil value must be unique
 	ContainerName *string `reform:"container_name"`
 
+	// Kubernetes metadata of Node running in a pod, see KubernetesMetadata.
+	KubernetesNamespace  string `reform:"k8s_namespace"`
+	KubernetesPod        string `reform:"k8s_pod_name"`
+	KubernetesDeployment string `reform:"k8s_deployment"`
+	KubernetesNode       string `reform:"k8s_node_name"`
+
 	Region *string `reform:"region"` // non-nil value must be unique in combination with instance/address
+
+	// OS facts collected by pmm-agent; nil if they were not collected yet.
+	Facts *NodeFacts `reform:"facts"`
+	// ID of running facts collection Action; empty if there is none.
+	FactsActionID string `reform:"facts_action_id"`
 }
 
 // BeforeInsert implements reform.BeforeInserter interface.
@@ -123,6 +175,11 @@ func (s *Node) UnifiedLabels() (map[string]string, error) {
 		"node_model":     s.NodeModel,
 		"region":         pointer.GetString(s.Region),
 		"az":             s.AZ,
+
+		KubernetesNamespaceLabel:  s.KubernetesNamespace,
+		KubernetesPodLabel:        s.KubernetesPod,
+		KubernetesDeploymentLabel: s.KubernetesDeployment,
+		KubernetesNodeLabel:       s.KubernetesNode,
 	}
 	for name, value := range custom {
 		res[name] = value
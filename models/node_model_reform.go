@@ -36,12 +36,22 @@ func (v *nodeTableType) Columns() []string {
 		"node_model",
 		"az",
 		"custom_labels",
+		"notes",
+		"owner",
+		"contact",
+		"ttl",
 		"address",
 		"created_at",
 		"updated_at",
 		"container_id",
 		"container_name",
+		"k8s_namespace",
+		"k8s_pod_name",
+		"k8s_deployment",
+		"k8s_node_name",
 		"region",
+		"facts",
+		"facts_action_id",
 	}
 }
 
@@ -74,12 +84,22 @@ var NodeTable = &nodeTableType{
 			{Name: "NodeModel", Type: "string", Column: "node_model"},
 			{Name: "AZ", Type: "string", Column: "az"},
 			{Name: "CustomLabels", Type: "[]uint8", Column: "custom_labels"},
+			{Name: "Notes", Type: "string", Column: "notes"},
+			{Name: "Owner", Type: "string", Column: "owner"},
+			{Name: "Contact", Type: "string", Column: "contact"},
+			{Name: "TTL", Type: "time.Duration", Column: "ttl"},
 			{Name: "Address", Type: "string", Column: "address"},
 			{Name: "CreatedAt", Type: "time.Time", Column: "created_at"},
 			{Name: "UpdatedAt", Type: "time.Time", Column: "updated_at"},
 			{Name: "ContainerID", Type: "*string", Column: "container_id"},
 			{Name: "ContainerName", Type: "*string", Column: "container_name"},
+			{Name: "KubernetesNamespace", Type: "string", Column: "k8s_namespace"},
+			{Name: "KubernetesPod", Type: "string", Column: "k8s_pod_name"},
+			{Name: "KubernetesDeployment", Type: "string", Column: "k8s_deployment"},
+			{Name: "KubernetesNode", Type: "string", Column: "k8s_node_name"},
 			{Name: "Region", Type: "*string", Column: "region"},
+			{Name: "Facts", Type: "*NodeFacts", Column: "facts"},
+			{Name: "FactsActionID", Type: "string", Column: "facts_action_id"},
 		},
 		PKFieldIndex: 0,
 	},
@@ -88,7 +108,7 @@ var NodeTable = &nodeTableType{
 
 // String returns a string representation of this struct or record.
 func (s Node) String() string {
-	res := make([]string, 14)
+	res := make([]string, 24)
 	res[0] = "NodeID: " + reform.Inspect(s.NodeID, true)
 	res[1] = "NodeType: " + reform.Inspect(s.NodeType, true)
 	res[2] = "NodeName: " + reform.Inspect(s.NodeName, true)
@@ -97,12 +117,22 @@ func (s Node) String() string {
 	res[5] = "NodeModel: " + reform.Inspect(s.NodeModel, true)
 	res[6] = "AZ: " + reform.Inspect(s.AZ, true)
 	res[7] = "CustomLabels: " + reform.Inspect(s.CustomLabels, true)
-	res[8] = "Address: " + reform.Inspect(s.Address, true)
-	res[9] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
-	res[10] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
-	res[11] = "ContainerID: " + reform.Inspect(s.ContainerID, true)
-	res[12] = "ContainerName: " + reform.Inspect(s.ContainerName, true)
-	res[13] = "Region: " + reform.Inspect(s.Region, true)
+	res[8] = "Notes: " + reform.Inspect(s.Notes, true)
+	res[9] = "Owner: " + reform.Inspect(s.Owner, true)
+	res[10] = "Contact: " + reform.Inspect(s.Contact, true)
+	res[11] = "TTL: " + reform.Inspect(s.TTL, true)
+	res[12] = "Address: " + reform.Inspect(s.Address, true)
+	res[13] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
+	res[14] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
+	res[15] = "ContainerID: " + reform.Inspect(s.ContainerID, true)
+	res[16] = "ContainerName: " + reform.Inspect(s.ContainerName, true)
+	res[17] = "KubernetesNamespace: " + reform.Inspect(s.KubernetesNamespace, true)
+	res[18] = "KubernetesPod: " + reform.Inspect(s.KubernetesPod, true)
+	res[19] = "KubernetesDeployment: " + reform.Inspect(s.KubernetesDeployment, true)
+	res[20] = "KubernetesNode: " + reform.Inspect(s.KubernetesNode, true)
+	res[21] = "Region: " + reform.Inspect(s.Region, true)
+	res[22] = "Facts: " + reform.Inspect(s.Facts, true)
+	res[23] = "FactsActionID: " + reform.Inspect(s.FactsActionID, true)
 	return strings.Join(res, ", ")
 }
 
@@ -118,12 +148,22 @@ func (s *Node) Values() []interface{} {
 		s.NodeModel,
 		s.AZ,
 		s.CustomLabels,
+		s.Notes,
+		s.Owner,
+		s.Contact,
+		s.TTL,
 		s.Address,
 		s.CreatedAt,
 		s.UpdatedAt,
 		s.ContainerID,
 		s.ContainerName,
+		s.KubernetesNamespace,
+		s.KubernetesPod,
+		s.KubernetesDeployment,
+		s.KubernetesNode,
 		s.Region,
+		s.Facts,
+		s.FactsActionID,
 	}
 }
 
@@ -139,12 +179,22 @@ func (s *Node) Pointers() []interface{} {
 		&s.NodeModel,
 		&s.AZ,
 		&s.CustomLabels,
+		&s.Notes,
+		&s.Owner,
+		&s.Contact,
+		&s.TTL,
 		&s.Address,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 		&s.ContainerID,
 		&s.ContainerName,
+		&s.KubernetesNamespace,
+		&s.KubernetesPod,
+		&s.KubernetesDeployment,
+		&s.KubernetesNode,
 		&s.Region,
+		&s.Facts,
+		&s.FactsActionID,
 	}
 }
 
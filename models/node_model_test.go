@@ -40,4 +40,36 @@ func TestNode(t *testing.T) {
 		}
 		assert.Equal(t, expected, actual)
 	})
+	t.Run("KubernetesLabels", func(t *testing.T) {
+		node := &Node{
+			NodeID:               "node_id",
+			KubernetesNamespace:  "default",
+			KubernetesPod:        "mysql-0",
+			KubernetesDeployment: "mysql",
+			CustomLabels:         []byte(`{"k8s_node_name": "worker1"}`),
+		}
+		actual, err := node.UnifiedLabels()
+		require.NoError(t, err)
+		expected := map[string]string{
+			"node_id":        "node_id",
+			"k8s_namespace":  "default",
+			"k8s_pod_name":   "mysql-0",
+			"k8s_deployment": "mysql",
+			"k8s_node_name":  "worker1",
+		}
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("ExtractKubernetesMetadata", func(t *testing.T) {
+		md := &KubernetesMetadata{Pod: "mysql-1"}
+		customLabels := map[string]string{
+			"k8s_namespace": "db",
+			"k8s_pod_name":  "mysql-0",
+			"foo":           "bar",
+		}
+		actual := extractKubernetesMetadata(md, customLabels)
+		assert.Equal(t, map[string]string{"foo": "bar"}, actual)
+		assert.Equal(t, &KubernetesMetadata{Namespace: "db", Pod: "mysql-1"}, md)
+		assert.Len(t, customLabels, 3, "given map should not be changed")
+	})
 }
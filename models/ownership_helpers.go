@@ -0,0 +1,84 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+// ChangeOwnershipParams are params for changing Node or Service ownership information.
+// Nil fields are not changed.
+type ChangeOwnershipParams struct {
+	Notes   *string
+	Owner   *string
+	Contact *string
+}
+
+// ownershipSearchCondition returns SQL condition for case-insensitive search by ownership information
+// with the given placeholder. Substring search is used to avoid escaping LIKE patterns.
+func ownershipSearchCondition(placeholder string) string {
+	return fmt.Sprintf("(strpos(lower(owner), lower(%[1]s)) > 0 OR "+
+		"strpos(lower(contact), lower(%[1]s)) > 0 OR "+
+		"strpos(lower(notes), lower(%[1]s)) > 0)", placeholder)
+}
+
+// ChangeServiceOwnership changes Service ownership information.
+func ChangeServiceOwnership(q *reform.Querier, serviceID string, params *ChangeOwnershipParams) (*Service, error) {
+	s, err := FindServiceByID(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Notes != nil {
+		s.Notes = *params.Notes
+	}
+	if params.Owner != nil {
+		s.Owner = *params.Owner
+	}
+	if params.Contact != nil {
+		s.Contact = *params.Contact
+	}
+	if err = q.Update(s); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s, nil
+}
+
+// ChangeNodeOwnership changes Node ownership information.
+func ChangeNodeOwnership(q *reform.Querier, nodeID string, params *ChangeOwnershipParams) (*Node, error) {
+	n, err := FindNodeByID(q, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Notes != nil {
+		n.Notes = *params.Notes
+	}
+	if params.Owner != nil {
+		n.Owner = *params.Owner
+	}
+	if params.Contact != nil {
+		n.Contact = *params.Contact
+	}
+	if err = q.Update(n); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return n, nil
+}
@@ -0,0 +1,92 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestOwnership(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, tx.Rollback())
+	})
+
+	q := tx.Querier
+
+	node, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName: "node",
+		Address:  "127.0.0.1",
+	})
+	require.NoError(t, err)
+	service, err := models.AddNewService(q, models.MySQLServiceType, &models.AddDBMSServiceParams{
+		ServiceName: "mysql",
+		NodeID:      node.NodeID,
+		Address:     pointer.ToString("127.0.0.1"),
+		Port:        pointer.ToUint16(3306),
+	})
+	require.NoError(t, err)
+
+	service, err = models.ChangeServiceOwnership(q, service.ServiceID, &models.ChangeOwnershipParams{
+		Owner:   pointer.ToString("DBA team"),
+		Contact: pointer.ToString("dba@example.com"),
+	})
+	require.NoError(t, err)
+	service, err = models.ChangeServiceOwnership(q, service.ServiceID, &models.ChangeOwnershipParams{
+		Notes: pointer.ToString("Orders database."),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "DBA team", service.Owner)
+	assert.Equal(t, "dba@example.com", service.Contact)
+	assert.Equal(t, "Orders database.", service.Notes)
+
+	node, err = models.ChangeNodeOwnership(q, node.NodeID, &models.ChangeOwnershipParams{
+		Owner: pointer.ToString("SRE team"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "SRE team", node.Owner)
+
+	services, err := models.FindServices(q, models.ServiceFilters{Search: "ORDERS"})
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Equal(t, service.ServiceID, services[0].ServiceID)
+	services, err = models.FindServices(q, models.ServiceFilters{Search: "SRE"})
+	require.NoError(t, err)
+	assert.Empty(t, services)
+
+	nodeType := models.GenericNodeType
+	nodes, err := models.FindNodes(q, models.NodeFilters{Search: "sre", NodeType: &nodeType})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, node.NodeID, nodes[0].NodeID)
+}
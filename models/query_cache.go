@@ -0,0 +1,103 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+)
+
+// selectQueries caches SELECT queries of frequently called functions,
+// so column lists and view names are not rebuilt on every call.
+var selectQueries sync.Map // "tag|view|tail" -> query
+
+// mQueryDuration tracks duration of frequently called functions.
+var mQueryDuration = prom.NewSummaryVec(prom.SummaryOpts{
+	Namespace:  "pmm_managed",
+	Subsystem:  "models",
+	Name:       "query_duration_seconds",
+	Help:       "Duration of frequently called database functions in seconds.",
+	Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+}, []string{"function", "code"})
+
+// QueryMetrics returns a collector of database functions latency metrics.
+func QueryMetrics() prom.Collector {
+	return mQueryDuration
+}
+
+// observeQuery records duration of function call started at given time.
+// It should be deferred with a pointer to the named error result.
+func observeQuery(function string, start time.Time, err *error) {
+	code := status.Code(*err).String()
+	mQueryDuration.WithLabelValues(function, code).Observe(time.Since(start).Seconds())
+}
+
+// selectQuery returns cached full SELECT query for given view and tail.
+func selectQuery(q *reform.Querier, view reform.View, tail string) string {
+	key := q.Tag() + "|" + view.Name() + "|" + tail
+	if query, ok := selectQueries.Load(key); ok {
+		return query.(string)
+	}
+
+	command := "SELECT"
+	if tag := q.Tag(); tag != "" {
+		command += " /* " + tag + " */"
+	}
+	query := fmt.Sprintf("%s %s FROM %s %s", command, strings.Join(q.QualifiedColumns(view), ", "), q.QualifiedView(view), tail)
+	selectQueries.Store(key, query)
+	return query
+}
+
+// selectOneTo is a version of reform.Querier.SelectOneTo that uses cached query.
+func selectOneTo(q *reform.Querier, str reform.Struct, tail string, args ...interface{}) error {
+	if err := q.QueryRow(selectQuery(q, str.View(), tail), args...).Scan(str.Pointers()...); err != nil {
+		return err
+	}
+
+	if af, ok := str.(reform.AfterFinder); ok {
+		return af.AfterFind()
+	}
+	return nil
+}
+
+// selectAllFrom is a version of reform.Querier.SelectAllFrom that uses cached query.
+func selectAllFrom(q *reform.Querier, view reform.View, tail string, args ...interface{}) ([]reform.Struct, error) {
+	rows, err := q.Query(selectQuery(q, view, tail), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var res []reform.Struct
+	for {
+		str := view.NewStruct()
+		if err = q.NextRow(str, rows); err != nil {
+			break
+		}
+		res = append(res, str)
+	}
+	if err != reform.ErrNoRows {
+		return nil, err
+	}
+	return res, nil
+}
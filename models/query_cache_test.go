@@ -0,0 +1,39 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+)
+
+func TestSelectQuery(t *testing.T) {
+	q := reform.NewDB(nil, postgresql.Dialect, nil).Querier
+
+	query := selectQuery(q, JobResultTable, "WHERE id = $1")
+	expected := `SELECT "job_results"."id", "job_results"."pmm_agent_id", "job_results"."type", "job_results"."done", ` +
+		`"job_results"."error", "job_results"."result", "job_results"."created_at", "job_results"."updated_at" ` +
+		`FROM "job_results" WHERE id = $1`
+	assert.Equal(t, expected, query)
+	assert.Equal(t, expected, selectQuery(q, JobResultTable, "WHERE id = $1"), "cached query should be the same")
+
+	tagged := selectQuery(q.WithTag("test"), JobResultTable, "WHERE id = $1")
+	assert.Equal(t, `SELECT /* test */ `+expected[len("SELECT "):], tagged)
+}
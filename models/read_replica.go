@@ -0,0 +1,124 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+)
+
+// replicationLagQuery returns replication lag in seconds; it is zero if all received WAL was replayed.
+const replicationLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM (now() - pg_last_xact_replay_timestamp())), 0)
+END`
+
+// ReadReplica routes heavy read-only queries to PostgreSQL read replica
+// while its replication lag is below the threshold, and to the primary otherwise.
+type ReadReplica struct {
+	primary *reform.DB
+	replica *reform.DB
+	maxLag  time.Duration
+	l       *logrus.Entry
+
+	// 1 if replica is usable, 0 otherwise
+	ok int32
+}
+
+// NewReadReplica creates new ReadReplica for given primary and replica databases.
+// Replica may be nil; in that case all queries go to the primary.
+func NewReadReplica(primary, replica *reform.DB, maxLag time.Duration) *ReadReplica {
+	return &ReadReplica{
+		primary: primary,
+		replica: replica,
+		maxLag:  maxLag,
+		l:       logrus.WithField("component", "read-replica"),
+	}
+}
+
+// DB returns replica database if it is configured and not lagging, primary database otherwise.
+// Returned database should be used only for reads.
+func (r *ReadReplica) DB() *reform.DB {
+	if r.replica != nil && atomic.LoadInt32(&r.ok) == 1 {
+		return r.replica
+	}
+	return r.primary
+}
+
+// Run checks replication lag with given interval until context is canceled.
+// Replica is not used until the first successful check.
+func (r *ReadReplica) Run(ctx context.Context, interval time.Duration) {
+	if r.replica == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check checks replication lag and enables or disables replica usage.
+func (r *ReadReplica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ok bool
+	lag, err := replicationLag(ctx, r.replica)
+	switch {
+	case err != nil:
+		r.l.Warnf("Failed to check replication lag: %s.", err)
+	case lag > r.maxLag:
+		r.l.Debugf("Replication lag %s exceeds %s.", lag, r.maxLag)
+	default:
+		ok = true
+	}
+
+	var v int32
+	if ok {
+		v = 1
+	}
+	if old := atomic.SwapInt32(&r.ok, v); old != v {
+		if ok {
+			r.l.Info("Using read replica.")
+		} else {
+			r.l.Warn("Read replica is not available or lagging, using primary.")
+		}
+	}
+}
+
+// replicationLag returns replication lag of given replica database.
+func replicationLag(ctx context.Context, db *reform.DB) (time.Duration, error) {
+	var seconds float64
+	if err := db.QueryRowContext(ctx, replicationLagQuery).Scan(&seconds); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,48 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+)
+
+func TestReadReplica(t *testing.T) {
+	primary := reform.NewDB(nil, postgresql.Dialect, nil)
+	replica := reform.NewDB(nil, postgresql.Dialect, nil)
+
+	t.Run("NoReplica", func(t *testing.T) {
+		r := NewReadReplica(primary, nil, 0)
+		atomic.StoreInt32(&r.ok, 1)
+		assert.Same(t, primary, r.DB())
+	})
+
+	t.Run("Replica", func(t *testing.T) {
+		r := NewReadReplica(primary, replica, 0)
+		assert.Same(t, primary, r.DB(), "replica should not be used before the first check")
+
+		atomic.StoreInt32(&r.ok, 1)
+		assert.Same(t, replica, r.DB())
+
+		atomic.StoreInt32(&r.ok, 0)
+		assert.Same(t, primary, r.DB())
+	})
+}
@@ -0,0 +1,224 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+
+	"gopkg.in/reform.v1"
+)
+
+// RemovalReportItem represents a single object in removal report.
+type RemovalReportItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// RemovalReport lists objects removed or changed by removal of Nodes or Services.
+type RemovalReport struct {
+	Nodes               []RemovalReportItem `json:"nodes,omitempty"`
+	Services            []RemovalReportItem `json:"services,omitempty"`
+	Agents              []RemovalReportItem `json:"agents,omitempty"`
+	ScheduledTasks      []RemovalReportItem `json:"scheduled_tasks,omitempty"`
+	RestoreHistoryItems []RemovalReportItem `json:"restore_history_items,omitempty"`
+	// Artifacts are kept, but lose their Service reference.
+	Artifacts []RemovalReportItem `json:"artifacts,omitempty"`
+	// Alert rules are kept, but their filters match only removed Nodes and Services.
+	Rules []RemovalReportItem `json:"rules,omitempty"`
+}
+
+// identityLabels are labels identifying a single Node or Service.
+var identityLabels = map[string]struct{}{
+	"node_id":      {},
+	"node_name":    {},
+	"service_id":   {},
+	"service_name": {},
+}
+
+// removalSnapshot contains objects affected by removal.
+type removalSnapshot struct {
+	nodes          map[string]*Node
+	services       map[string]*Service
+	agents         map[string]*Agent
+	scheduledTasks map[string]*ScheduledTask
+	restoreItems   map[string]*RestoreHistoryItem
+	artifacts      map[string]*Artifact // only artifacts with Service reference
+}
+
+// takeRemovalSnapshot returns current objects affected by removal.
+func takeRemovalSnapshot(q *reform.Querier) (*removalSnapshot, error) {
+	nodes, err := FindNodes(q, NodeFilters{})
+	if err != nil {
+		return nil, err
+	}
+	services, err := FindServices(q, ServiceFilters{})
+	if err != nil {
+		return nil, err
+	}
+	agents, err := FindAgents(q, AgentFilters{})
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := FindScheduledTasks(q, ScheduledTasksFilter{})
+	if err != nil {
+		return nil, err
+	}
+	items, err := FindRestoreHistoryItems(q, RestoreHistoryItemFilters{})
+	if err != nil {
+		return nil, err
+	}
+	artifacts, err := FindArtifacts(q, ArtifactFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &removalSnapshot{
+		nodes:          make(map[string]*Node, len(nodes)),
+		services:       make(map[string]*Service, len(services)),
+		agents:         make(map[string]*Agent, len(agents)),
+		scheduledTasks: make(map[string]*ScheduledTask, len(tasks)),
+		restoreItems:   make(map[string]*RestoreHistoryItem, len(items)),
+		artifacts:      make(map[string]*Artifact, len(artifacts)),
+	}
+	for _, n := range nodes {
+		res.nodes[n.NodeID] = n
+	}
+	for _, s := range services {
+		res.services[s.ServiceID] = s
+	}
+	for _, a := range agents {
+		res.agents[a.AgentID] = a
+	}
+	for _, t := range tasks {
+		res.scheduledTasks[t.ID] = t
+	}
+	for _, i := range items {
+		res.restoreItems[i.ID] = i
+	}
+	for _, a := range artifacts {
+		if a.ServiceID != "" {
+			res.artifacts[a.ID] = a
+		}
+	}
+	return res, nil
+}
+
+// PreviewRemoval calls remove function and returns report of objects removed or changed by it.
+// Caller should roll back the transaction of given querier after that.
+func PreviewRemoval(q *reform.Querier, remove func() error) (*RemovalReport, error) {
+	before, err := takeRemovalSnapshot(q)
+	if err != nil {
+		return nil, err
+	}
+	if err = remove(); err != nil {
+		return nil, err
+	}
+	after, err := takeRemovalSnapshot(q)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := FindRules(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildRemovalReport(before, after, rules), nil
+}
+
+// buildRemovalReport returns report of differences between snapshots taken before and after removal.
+func buildRemovalReport(before, after *removalSnapshot, rules []*Rule) *RemovalReport {
+	res := new(RemovalReport)
+
+	var removedLabels []map[string]string
+	for id, n := range before.nodes {
+		if _, ok := after.nodes[id]; !ok {
+			res.Nodes = append(res.Nodes, RemovalReportItem{ID: id, Name: n.NodeName})
+			removedLabels = append(removedLabels, map[string]string{
+				"node_id":   n.NodeID,
+				"node_name": n.NodeName,
+			})
+		}
+	}
+	for id, s := range before.services {
+		if _, ok := after.services[id]; !ok {
+			res.Services = append(res.Services, RemovalReportItem{ID: id, Name: s.ServiceName})
+			labels := map[string]string{
+				"node_id":      s.NodeID,
+				"service_id":   s.ServiceID,
+				"service_name": s.ServiceName,
+			}
+			if n := before.nodes[s.NodeID]; n != nil {
+				labels["node_name"] = n.NodeName
+			}
+			removedLabels = append(removedLabels, labels)
+		}
+	}
+	for id, a := range before.agents {
+		if _, ok := after.agents[id]; !ok {
+			res.Agents = append(res.Agents, RemovalReportItem{ID: id, Name: string(a.AgentType)})
+		}
+	}
+	for id, t := range before.scheduledTasks {
+		if _, ok := after.scheduledTasks[id]; !ok {
+			res.ScheduledTasks = append(res.ScheduledTasks, RemovalReportItem{ID: id, Name: string(t.Type)})
+		}
+	}
+	for id := range before.restoreItems {
+		if _, ok := after.restoreItems[id]; !ok {
+			res.RestoreHistoryItems = append(res.RestoreHistoryItems, RemovalReportItem{ID: id})
+		}
+	}
+	for id, a := range before.artifacts {
+		if _, ok := after.artifacts[id]; !ok {
+			res.Artifacts = append(res.Artifacts, RemovalReportItem{ID: id, Name: a.Name})
+		}
+	}
+
+	for _, r := range rules {
+		if ruleMatchesOnly(r, removedLabels) {
+			res.Rules = append(res.Rules, RemovalReportItem{ID: r.ID, Name: r.Summary})
+		}
+	}
+
+	for _, items := range [][]RemovalReportItem{
+		res.Nodes, res.Services, res.Agents, res.ScheduledTasks, res.RestoreHistoryItems, res.Artifacts, res.Rules,
+	} {
+		sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	}
+	return res
+}
+
+// ruleMatchesOnly returns true if rule has Node or Service identity filters,
+// and they match one of removed objects' labels.
+func ruleMatchesOnly(r *Rule, removedLabels []map[string]string) bool {
+	var filters Filters
+	for _, f := range r.Filters {
+		if _, ok := identityLabels[f.Key]; ok {
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) == 0 {
+		return false
+	}
+
+	for _, labels := range removedLabels {
+		if filters.Match(labels) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona-platform/saas/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestPreviewRemoval(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	defer func() {
+		require.NoError(t, sqlDB.Close())
+	}()
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tx.Rollback())
+	}()
+	q := tx.Querier
+
+	for _, str := range []reform.Struct{
+		&models.Node{
+			NodeID:   "N1",
+			NodeType: models.GenericNodeType,
+			NodeName: "Node",
+		},
+		&models.Service{
+			ServiceID:   "S1",
+			ServiceType: models.MySQLServiceType,
+			ServiceName: "mysql1",
+			NodeID:      "N1",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16OrNil(3306),
+		},
+		&models.Service{
+			ServiceID:   "S2",
+			ServiceType: models.MySQLServiceType,
+			ServiceName: "mysql2",
+			NodeID:      "N1",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16OrNil(3307),
+		},
+		&models.Agent{
+			AgentID:      "A1",
+			AgentType:    models.PMMAgentType,
+			RunsOnNodeID: pointer.ToString("N1"),
+		},
+		&models.Agent{
+			AgentID:    "A2",
+			AgentType:  models.MySQLdExporterType,
+			PMMAgentID: pointer.ToString("A1"),
+			ServiceID:  pointer.ToString("S1"),
+		},
+		&models.Rule{
+			ID:       "R1",
+			Summary:  "mysql1 only",
+			Severity: models.Severity(common.Warning),
+			Filters:  models.Filters{{Type: models.Equal, Key: "service_name", Val: "mysql1"}},
+		},
+		&models.Rule{
+			ID:       "R2",
+			Summary:  "all mysql",
+			Severity: models.Severity(common.Warning),
+			Filters:  models.Filters{{Type: models.Regex, Key: "service_name", Val: "mysql.*"}},
+		},
+		&models.Rule{
+			ID:       "R3",
+			Summary:  "no identity filters",
+			Severity: models.Severity(common.Warning),
+			Filters:  models.Filters{{Type: models.Equal, Key: "environment", Val: "prod"}},
+		},
+	} {
+		require.NoError(t, q.Insert(str))
+	}
+
+	t.Run("Service", func(t *testing.T) {
+		res, err := models.PreviewRemoval(q, func() error {
+			return models.RemoveService(q, "S1", models.RemoveCascade)
+		})
+		require.NoError(t, err)
+		expected := &models.RemovalReport{
+			Services: []models.RemovalReportItem{{ID: "S1", Name: "mysql1"}},
+			Agents:   []models.RemovalReportItem{{ID: "A2", Name: string(models.MySQLdExporterType)}},
+			Rules:    []models.RemovalReportItem{{ID: "R1", Name: "mysql1 only"}},
+		}
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := models.PreviewRemoval(q, func() error {
+			return models.RemoveNode(q, "N1", models.RemoveRestrict)
+		})
+		require.Error(t, err)
+	})
+}
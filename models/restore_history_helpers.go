@@ -34,6 +34,12 @@ type RestoreHistoryItemFilters struct {
 	ArtifactID string
 	// Return only items with specified status.
 	Status *RestoreStatus
+	// Return only items started at or after that time.
+	StartedFrom time.Time
+	// Return only items started before that time.
+	StartedTo time.Time
+	// Return at most that number of items; 0 means no limit.
+	Limit int
 }
 
 // FindRestoreHistoryItems returns restore history list.
@@ -65,13 +71,31 @@ func FindRestoreHistoryItems(q *reform.Querier, filters RestoreHistoryItemFilter
 	if filters.Status != nil {
 		conditions = append(conditions, fmt.Sprintf("status = %s", q.Placeholder(idx)))
 		args = append(args, *filters.Status)
+		idx++
+	}
+
+	if !filters.StartedFrom.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("started_at >= %s", q.Placeholder(idx)))
+		args = append(args, filters.StartedFrom)
+		idx++
+	}
+
+	if !filters.StartedTo.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("started_at < %s", q.Placeholder(idx)))
+		args = append(args, filters.StartedTo)
+		idx++
 	}
 
 	var whereClause string
 	if len(conditions) != 0 {
 		whereClause = fmt.Sprintf("WHERE %s", strings.Join(conditions, " AND "))
 	}
-	rows, err := q.SelectAllFrom(RestoreHistoryItemTable, fmt.Sprintf("%s ORDER BY started_at DESC", whereClause), args...)
+	tail := fmt.Sprintf("%s ORDER BY started_at DESC", whereClause)
+	if filters.Limit > 0 {
+		tail += fmt.Sprintf(" LIMIT %s", q.Placeholder(idx))
+		args = append(args, filters.Limit)
+	}
+	rows, err := q.SelectAllFrom(RestoreHistoryItemTable, tail, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to select restore history")
 	}
@@ -153,6 +177,7 @@ func CreateRestoreHistoryItem(q *reform.Querier, params CreateRestoreHistoryItem
 type ChangeRestoreHistoryItemParams struct {
 	Status     RestoreStatus
 	FinishedAt *time.Time
+	LogTail    *string
 }
 
 // ChangeRestoreHistoryItem updates existing restore history item.
@@ -169,6 +194,11 @@ func ChangeRestoreHistoryItem(
 
 	if params.FinishedAt != nil {
 		row.FinishedAt = params.FinishedAt
+		finishRestorePhase(row, *params.FinishedAt)
+	}
+
+	if params.LogTail != nil {
+		row.LogTail = *params.LogTail
 	}
 
 	if err := q.Update(row); err != nil {
@@ -178,6 +208,51 @@ func ChangeRestoreHistoryItem(
 	return row, nil
 }
 
+// StartRestorePhase records the start of given restore phase at given time, finishing the previous phase.
+// It does nothing if that phase is already the current one.
+func StartRestorePhase(q *reform.Querier, restoreID string, phase RestorePhase, at time.Time) (*RestoreHistoryItem, error) {
+	if err := phase.Validate(); err != nil {
+		return nil, err
+	}
+
+	row, err := FindRestoreHistoryItemByID(q, restoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	if row.Timeline == nil {
+		row.Timeline = &RestoreTimeline{}
+	}
+	if n := len(row.Timeline.Phases); n != 0 && row.Timeline.Phases[n-1].Phase == phase {
+		return row, nil
+	}
+
+	finishRestorePhase(row, at)
+	row.Timeline.Phases = append(row.Timeline.Phases, &RestorePhaseTimes{
+		Phase:     phase,
+		StartedAt: at.UTC(),
+	})
+
+	if err = q.Update(row); err != nil {
+		return nil, errors.Wrap(err, "failed to update restore history item")
+	}
+
+	return row, nil
+}
+
+// finishRestorePhase sets finish time of the current restore phase, if any.
+func finishRestorePhase(row *RestoreHistoryItem, at time.Time) {
+	if row.Timeline == nil || len(row.Timeline.Phases) == 0 {
+		return
+	}
+
+	current := row.Timeline.Phases[len(row.Timeline.Phases)-1]
+	if current.FinishedAt == nil {
+		t := at.UTC()
+		current.FinishedAt = &t
+	}
+}
+
 // RemoveRestoreHistoryItem removes restore history item by ID.
 func RemoveRestoreHistoryItem(q *reform.Querier, id string) error {
 	if _, err := FindRestoreHistoryItemByID(q, id); err != nil {
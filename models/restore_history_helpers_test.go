@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/AlekSi/pointer"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/reform.v1"
@@ -159,6 +160,53 @@ func TestRestoreHistory(t *testing.T) {
 		assert.Less(t, time.Now().UTC().Unix()-i.StartedAt.Unix(), int64(5))
 	})
 
+	t.Run("timeline", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, tx.Rollback())
+		})
+
+		q := tx.Querier
+		prepareArtifactsAndService(q)
+
+		i, err := models.CreateRestoreHistoryItem(q, models.CreateRestoreHistoryItemParams{
+			ArtifactID: artifactID1,
+			ServiceID:  serviceID1,
+			Status:     models.InProgressRestoreStatus,
+		})
+		require.NoError(t, err)
+
+		start := time.Now().UTC().Truncate(time.Second)
+		_, err = models.StartRestorePhase(q, i.ID, models.DownloadRestorePhase, start)
+		require.NoError(t, err)
+		_, err = models.StartRestorePhase(q, i.ID, models.DownloadRestorePhase, start.Add(time.Second))
+		require.NoError(t, err)
+		_, err = models.StartRestorePhase(q, i.ID, models.PrepareRestorePhase, start.Add(2*time.Second))
+		require.NoError(t, err)
+		_, err = models.StartRestorePhase(q, i.ID, models.RestorePhase("unknown"), start)
+		assert.True(t, errors.Is(err, models.ErrInvalidArgument))
+
+		finish := start.Add(3 * time.Second)
+		i, err = models.ChangeRestoreHistoryItem(q, i.ID, models.ChangeRestoreHistoryItemParams{
+			Status:     models.ErrorRestoreStatus,
+			FinishedAt: &finish,
+			LogTail:    pointer.ToString("xbstream failed"),
+		})
+		require.NoError(t, err)
+
+		i, err = models.FindRestoreHistoryItemByID(q, i.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "xbstream failed", i.LogTail)
+		require.NotNil(t, i.Timeline)
+		require.Len(t, i.Timeline.Phases, 2)
+		assert.Equal(t, models.DownloadRestorePhase, i.Timeline.Phases[0].Phase)
+		assert.True(t, start.Equal(i.Timeline.Phases[0].StartedAt))
+		assert.True(t, start.Add(2*time.Second).Equal(*i.Timeline.Phases[0].FinishedAt))
+		assert.Equal(t, models.PrepareRestorePhase, i.Timeline.Phases[1].Phase)
+		assert.True(t, finish.Equal(*i.Timeline.Phases[1].FinishedAt))
+	})
+
 	t.Run("list", func(t *testing.T) {
 		tx, err := db.Begin()
 		require.NoError(t, err)
@@ -201,6 +249,23 @@ func TestRestoreHistory(t *testing.T) {
 
 		assert.Condition(t, found(i1.ID), "The first restore history item not found")
 		assert.Condition(t, found(i2.ID), "The second restore history item not found")
+
+		successStatus := models.SuccessRestoreStatus
+		actual, err = models.FindRestoreHistoryItems(q, models.RestoreHistoryItemFilters{
+			Status:      &successStatus,
+			StartedFrom: i2.StartedAt.Add(-time.Minute),
+			StartedTo:   i2.StartedAt.Add(time.Minute),
+			Limit:       1,
+		})
+		require.NoError(t, err)
+		require.Len(t, actual, 1)
+		assert.Equal(t, i2.ID, actual[0].ID)
+
+		actual, err = models.FindRestoreHistoryItems(q, models.RestoreHistoryItemFilters{
+			StartedFrom: i2.StartedAt.Add(time.Minute),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, actual)
 	})
 
 	t.Run("remove", func(t *testing.T) {
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"database/sql/driver"
 	"time"
 
 	"github.com/pkg/errors"
@@ -48,6 +49,47 @@ func (rs RestoreStatus) Validate() error {
 	return nil
 }
 
+// RestorePhase represents a phase of restore.
+type RestorePhase string
+
+// Restore phases in order of execution.
+const (
+	DownloadRestorePhase RestorePhase = "download"
+	PrepareRestorePhase  RestorePhase = "prepare"
+	ApplyRestorePhase    RestorePhase = "apply"
+)
+
+// Validate validates restore phase.
+func (p RestorePhase) Validate() error {
+	switch p {
+	case DownloadRestorePhase:
+	case PrepareRestorePhase:
+	case ApplyRestorePhase:
+	default:
+		return errors.Wrapf(ErrInvalidArgument, "invalid restore phase '%s'", p)
+	}
+
+	return nil
+}
+
+// RestorePhaseTimes contains start and finish times of a single restore phase.
+type RestorePhaseTimes struct {
+	Phase      RestorePhase `json:"phase"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// RestoreTimeline contains restore phases in order of execution.
+type RestoreTimeline struct {
+	Phases []*RestorePhaseTimes `json:"phases"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (t RestoreTimeline) Value() (driver.Value, error) { return jsonValue(t) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (t *RestoreTimeline) Scan(src interface{}) error { return jsonScan(t, src) }
+
 // RestoreHistoryItem represents a restore backup history.
 //reform:restore_history
 type RestoreHistoryItem struct {
@@ -57,6 +99,10 @@ type RestoreHistoryItem struct {
 	Status     RestoreStatus `reform:"status"`
 	StartedAt  time.Time     `reform:"started_at"`
 	FinishedAt *time.Time    `reform:"finished_at"`
+	// Timestamps of restore phases, nil if not reported by pmm-agent.
+	Timeline *RestoreTimeline `reform:"timeline"`
+	// Last lines of restore job log; filled for failed restores.
+	LogTail string `reform:"log_tail"`
 }
 
 // BeforeInsert implements reform.BeforeInserter interface.
@@ -34,6 +34,8 @@ func (v *restoreHistoryItemTableType) Columns() []string {
 		"status",
 		"started_at",
 		"finished_at",
+		"timeline",
+		"log_tail",
 	}
 }
 
@@ -64,6 +66,8 @@ var RestoreHistoryItemTable = &restoreHistoryItemTableType{
 			{Name: "Status", Type: "RestoreStatus", Column: "status"},
 			{Name: "StartedAt", Type: "time.Time", Column: "started_at"},
 			{Name: "FinishedAt", Type: "*time.Time", Column: "finished_at"},
+			{Name: "Timeline", Type: "*RestoreTimeline", Column: "timeline"},
+			{Name: "LogTail", Type: "string", Column: "log_tail"},
 		},
 		PKFieldIndex: 0,
 	},
@@ -72,13 +76,15 @@ var RestoreHistoryItemTable = &restoreHistoryItemTableType{
 
 // String returns a string representation of this struct or record.
 func (s RestoreHistoryItem) String() string {
-	res := make([]string, 6)
+	res := make([]string, 8)
 	res[0] = "ID: " + reform.Inspect(s.ID, true)
 	res[1] = "ArtifactID: " + reform.Inspect(s.ArtifactID, true)
 	res[2] = "ServiceID: " + reform.Inspect(s.ServiceID, true)
 	res[3] = "Status: " + reform.Inspect(s.Status, true)
 	res[4] = "StartedAt: " + reform.Inspect(s.StartedAt, true)
 	res[5] = "FinishedAt: " + reform.Inspect(s.FinishedAt, true)
+	res[6] = "Timeline: " + reform.Inspect(s.Timeline, true)
+	res[7] = "LogTail: " + reform.Inspect(s.LogTail, true)
 	return strings.Join(res, ", ")
 }
 
@@ -92,6 +98,8 @@ func (s *RestoreHistoryItem) Values() []interface{} {
 		s.Status,
 		s.StartedAt,
 		s.FinishedAt,
+		s.Timeline,
+		s.LogTail,
 	}
 }
 
@@ -105,6 +113,8 @@ func (s *RestoreHistoryItem) Pointers() []interface{} {
 		&s.Status,
 		&s.StartedAt,
 		&s.FinishedAt,
+		&s.Timeline,
+		&s.LogTail,
 	}
 }
 
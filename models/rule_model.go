@@ -18,8 +18,11 @@ package models
 
 import (
 	"database/sql/driver"
+	"regexp"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 )
 
@@ -94,6 +97,46 @@ func (t Filters) Value() (driver.Value, error) { return jsonValue(t) }
 // Scan implements database/sql Scanner interface.
 func (t *Filters) Scan(src interface{}) error { return jsonScan(t, src) }
 
+// Validate returns InvalidArgument error if some filter is invalid.
+func (t Filters) Validate() error {
+	for _, f := range t {
+		switch f.Type {
+		case Equal:
+		case Regex:
+			if _, err := regexp.Compile("^(?:" + f.Val + ")$"); err != nil {
+				return status.Errorf(codes.InvalidArgument, "Invalid filter regex %q: %s.", f.Val, err)
+			}
+		default:
+			return status.Errorf(codes.InvalidArgument, "Unsupported filter type %q.", f.Type)
+		}
+		if f.Key == "" {
+			return status.Error(codes.InvalidArgument, "Empty filter key.")
+		}
+	}
+	return nil
+}
+
+// Match returns true if all filters match given labels. Regex filters are anchored like in Alertmanager.
+func (t Filters) Match(labels map[string]string) bool {
+	for _, f := range t {
+		v := labels[f.Key]
+		switch f.Type {
+		case Equal:
+			if v != f.Val {
+				return false
+			}
+		case Regex:
+			re, err := regexp.Compile("^(?:" + f.Val + ")$")
+			if err != nil || !re.MatchString(v) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Filter represents rule filter.
 type Filter struct {
 	Type FilterType `json:"type"`
@@ -0,0 +1,48 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilters(t *testing.T) {
+	labels := map[string]string{"alertname": "MySQLDown", "severity": "critical"}
+
+	t.Run("Match", func(t *testing.T) {
+		assert.True(t, Filters(nil).Match(labels))
+		assert.True(t, Filters{{Type: Equal, Key: "severity", Val: "critical"}}.Match(labels))
+		assert.True(t, Filters{{Type: Regex, Key: "alertname", Val: "MySQL.*"}}.Match(labels))
+		assert.False(t, Filters{{Type: Regex, Key: "alertname", Val: "MySQL"}}.Match(labels))
+		assert.False(t, Filters{
+			{Type: Equal, Key: "severity", Val: "critical"},
+			{Type: Equal, Key: "service_name", Val: "mysql"},
+		}.Match(labels))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		assert.NoError(t, Filters{{Type: Regex, Key: "alertname", Val: "MySQL.*"}}.Validate())
+		assert.EqualError(t, Filters{{Type: Regex, Key: "alertname", Val: "("}}.Validate(),
+			"rpc error: code = InvalidArgument desc = Invalid filter regex \"(\": error parsing regexp: missing closing ): `^(?:()$`.")
+		assert.EqualError(t, Filters{{Type: "!=", Key: "alertname"}}.Validate(),
+			"rpc error: code = InvalidArgument desc = Unsupported filter type \"!=\".")
+		assert.EqualError(t, Filters{{Type: Equal}}.Validate(),
+			"rpc error: code = InvalidArgument desc = Empty filter key.")
+	})
+}
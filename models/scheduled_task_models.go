@@ -18,8 +18,11 @@ package models
 
 import (
 	"database/sql/driver"
+	"math"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 )
 
@@ -32,8 +35,102 @@ type ScheduledTaskType string
 const (
 	ScheduledMySQLBackupTask   = ScheduledTaskType("mysql_backup")
 	ScheduledMongoDBBackupTask = ScheduledTaskType("mongodb_backup")
+	ScheduledReportTask        = ScheduledTaskType("report")
+	ScheduledCleanupTask       = ScheduledTaskType("cleanup")
+	ScheduledMaintenanceTask   = ScheduledTaskType("maintenance")
+	ScheduledCompactionTask    = ScheduledTaskType("vm_compaction")
 )
 
+// MisfirePolicy defines what scheduler does with task runs missed while pmm-managed was down.
+type MisfirePolicy string
+
+// Available misfire policies.
+const (
+	// SkipMisfirePolicy skips missed runs; it is used if policy is empty.
+	SkipMisfirePolicy MisfirePolicy = "skip"
+	// RunOnceMisfirePolicy runs task once on startup if at least one run was missed.
+	RunOnceMisfirePolicy MisfirePolicy = "run_once"
+	// RunAllMisfirePolicy runs task on startup as many times as it was missed.
+	RunAllMisfirePolicy MisfirePolicy = "run_all"
+)
+
+// Validate returns InvalidArgument error if policy is unknown.
+func (p MisfirePolicy) Validate() error {
+	switch p {
+	case "", SkipMisfirePolicy, RunOnceMisfirePolicy, RunAllMisfirePolicy:
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unknown misfire policy %q.", p)
+	}
+}
+
+// ConcurrencyPolicy defines what scheduler does when task run fires while previous run of the same task is still executing.
+type ConcurrencyPolicy string
+
+// Available concurrency policies.
+const (
+	// AllowConcurrencyPolicy starts new run concurrently with previous ones.
+	AllowConcurrencyPolicy ConcurrencyPolicy = "allow"
+	// ForbidConcurrencyPolicy skips new run; it is used if policy is empty.
+	ForbidConcurrencyPolicy ConcurrencyPolicy = "forbid"
+	// ReplaceConcurrencyPolicy cancels previous run and starts new one.
+	ReplaceConcurrencyPolicy ConcurrencyPolicy = "replace"
+)
+
+// Validate returns InvalidArgument error if policy is unknown.
+func (p ConcurrencyPolicy) Validate() error {
+	switch p {
+	case "", AllowConcurrencyPolicy, ForbidConcurrencyPolicy, ReplaceConcurrencyPolicy:
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unknown concurrency policy %q.", p)
+	}
+}
+
+// RetryBackoff defines how delay between retries of failed task run changes.
+type RetryBackoff string
+
+// Available retry backoff modes.
+const (
+	// FixedRetryBackoff uses the same retry interval for all retries; it is used if mode is empty.
+	FixedRetryBackoff RetryBackoff = "fixed"
+	// ExponentialRetryBackoff doubles retry interval after each retry up to maximal retry interval.
+	ExponentialRetryBackoff RetryBackoff = "exponential"
+)
+
+// Validate returns InvalidArgument error if mode is unknown.
+func (b RetryBackoff) Validate() error {
+	switch b {
+	case "", FixedRetryBackoff, ExponentialRetryBackoff:
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unknown retry backoff %q.", b)
+	}
+}
+
+// ExecutionWindowLayout is a layout of scheduled task execution window bounds: hours and minutes in UTC.
+const ExecutionWindowLayout = "15:04"
+
+// checkExecutionWindow returns InvalidArgument error if execution window bounds are invalid.
+func checkExecutionWindow(start, end string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+
+	s, err := time.Parse(ExecutionWindowLayout, start)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid execution window start %q, expected HH:MM.", start)
+	}
+	e, err := time.Parse(ExecutionWindowLayout, end)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Invalid execution window end %q, expected HH:MM.", end)
+	}
+	if s.Equal(e) {
+		return status.Error(codes.InvalidArgument, "Execution window start and end should be different.")
+	}
+	return nil
+}
+
 // ScheduledTask describes a scheduled task.
 //reform:scheduled_tasks
 type ScheduledTask struct {
@@ -47,14 +144,84 @@ type ScheduledTask struct {
 	Data           *ScheduledTaskData `reform:"data"`
 	Running        bool               `reform:"running"`
 	Error          string             `reform:"error"`
-	CreatedAt      time.Time          `reform:"created_at"`
-	UpdatedAt      time.Time          `reform:"updated_at"`
+	MisfirePolicy  MisfirePolicy      `reform:"misfire_policy"`
+	// Policy for runs fired while previous run is still executing.
+	ConcurrencyPolicy ConcurrencyPolicy `reform:"concurrency_policy"`
+	// Remove one-shot task after its run instead of disabling it.
+	RemoveAfterRun bool `reform:"remove_after_run"`
+	// Number of retries of failed run and delay between them.
+	Retries       uint32        `reform:"retries"`
+	RetryInterval time.Duration `reform:"retry_interval"`
+	RetryBackoff  RetryBackoff  `reform:"retry_backoff"`
+	// Limit of exponential backoff retry interval; not limited if zero.
+	MaxRetryInterval time.Duration `reform:"max_retry_interval"`
+	// Limit of total time spent on retries of a single run; not limited if zero.
+	RetryBudget time.Duration `reform:"retry_budget"`
+	// ID of the task which run starts this task; nil for tasks started by their own schedule.
+	DependsOn *string `reform:"depends_on"`
+	// Maximal random delay of each run.
+	Jitter time.Duration `reform:"jitter"`
+	// Runs starting outside of that window (ExecutionWindowLayout, UTC) are skipped; empty means no window.
+	WindowStart string    `reform:"window_start"`
+	WindowEnd   string    `reform:"window_end"`
+	CreatedAt   time.Time `reform:"created_at"`
+	UpdatedAt   time.Time `reform:"updated_at"`
+}
+
+// OneShot returns true if task has no cron expression and runs exactly once at StartAt.
+func (r *ScheduledTask) OneShot() bool {
+	return r.CronExpression == "" && r.DependsOn == nil
+}
+
+// RetryDelay returns delay before retry with given zero-based number.
+func (r *ScheduledTask) RetryDelay(retry uint32) time.Duration {
+	if r.RetryBackoff != ExponentialRetryBackoff {
+		return r.RetryInterval
+	}
+
+	d := r.RetryInterval
+	for i := uint32(0); i < retry; i++ {
+		// stop doubling on limit or overflow
+		if r.MaxRetryInterval > 0 && d >= r.MaxRetryInterval || d > math.MaxInt64/2 {
+			break
+		}
+		d *= 2
+	}
+	if r.MaxRetryInterval > 0 && d > r.MaxRetryInterval {
+		d = r.MaxRetryInterval
+	}
+	return d
+}
+
+// InExecutionWindow returns true if task run is allowed to start at given time.
+// Window may cross midnight, for example, 22:00-02:00.
+func (r *ScheduledTask) InExecutionWindow(t time.Time) bool {
+	if r.WindowStart == "" || r.WindowEnd == "" {
+		return true
+	}
+
+	// bounds are validated on task creation and change
+	start, _ := time.Parse(ExecutionWindowLayout, r.WindowStart)
+	end, _ := time.Parse(ExecutionWindowLayout, r.WindowEnd)
+	t = t.UTC()
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute < endMinute {
+		return startMinute <= minute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
 }
 
 // ScheduledTaskData contains result data for different task types.
 type ScheduledTaskData struct {
 	MySQLBackupTask   *MySQLBackupTaskData `json:"mysql_backup,omitempty"`
 	MongoDBBackupTask *MongoBackupTaskData `json:"mongodb_backup,omitempty"`
+	ReportTask        *ReportTaskData      `json:"report,omitempty"`
+	CleanupTask       *CleanupTaskData     `json:"cleanup,omitempty"`
+	MaintenanceTask   *MaintenanceTaskData `json:"maintenance,omitempty"`
+	CompactionTask    *CompactionTaskData  `json:"vm_compaction,omitempty"`
 }
 
 // MySQLBackupTaskData contains data for mysql backup task.
@@ -64,6 +231,10 @@ type MySQLBackupTaskData struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Retention   uint32 `json:"retention"`
+	// Default for the Service type if empty.
+	DataModel DataModel `json:"data_model,omitempty"`
+	// Notifications about finished backups, nil if not configured.
+	Notifications *BackupNotifications `json:"notifications,omitempty"`
 }
 
 // MongoBackupTaskData contains data for mysql backup task.
@@ -73,6 +244,85 @@ type MongoBackupTaskData struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Retention   uint32 `json:"retention"`
+	// Default for the Service type if empty.
+	DataModel DataModel `json:"data_model,omitempty"`
+	// Notifications about finished backups, nil if not configured.
+	Notifications *BackupNotifications `json:"notifications,omitempty"`
+}
+
+// BackupNotifications contains settings of notifications about finished scheduled backups.
+type BackupNotifications struct {
+	// IDs of Integrated Alerting channels notifications are delivered to.
+	ChannelIDs []string `json:"channel_ids"`
+	OnSuccess  bool     `json:"on_success"`
+	OnFailure  bool     `json:"on_failure"`
+}
+
+// BackupNotifications returns notification settings of scheduled backup task, nil if not configured.
+func (r *ScheduledTask) BackupNotifications() *BackupNotifications {
+	if r.Data == nil {
+		return nil
+	}
+	switch {
+	case r.Type == ScheduledMySQLBackupTask && r.Data.MySQLBackupTask != nil:
+		return r.Data.MySQLBackupTask.Notifications
+	case r.Type == ScheduledMongoDBBackupTask && r.Data.MongoDBBackupTask != nil:
+		return r.Data.MongoDBBackupTask.Notifications
+	default:
+		return nil
+	}
+}
+
+// ReportTaskData contains data for report generation task.
+type ReportTaskData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Period of time before task run covered by the report.
+	Period  time.Duration `json:"period"`
+	Queries []ReportQuery `json:"queries"`
+	// IDs of Integrated Alerting email channels the report is delivered to.
+	ChannelIDs []string `json:"channel_ids,omitempty"`
+}
+
+// ReportQuery represents a single section of the report.
+type ReportQuery struct {
+	Title string `json:"title"`
+	// MetricsQL query evaluated over report period.
+	Query string `json:"query"`
+}
+
+// CleanupTaskData contains data for old log and temporary files cleanup task.
+type CleanupTaskData struct {
+	// Node where files are removed.
+	NodeID string `json:"node_id"`
+	// Absolute glob patterns of removed files, for example, /srv/logs/*.log.*.
+	Patterns []string `json:"patterns"`
+	// Files modified earlier than that are removed; not limited if zero.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+	// Oldest files are removed until total size of matched files in bytes fits that limit; not limited if zero.
+	MaxTotalSize int64 `json:"max_total_size,omitempty"`
+}
+
+// MaintenanceTaskData contains data for maintenance window task that silences alerts.
+type MaintenanceTaskData struct {
+	// Alerts matching all those matchers are silenced.
+	Matchers []SilenceMatcher `json:"matchers"`
+	// Duration of maintenance window started by each task run.
+	Duration time.Duration `json:"duration"`
+	Comment  string        `json:"comment,omitempty"`
+}
+
+// CompactionTaskData contains data for VictoriaMetrics forced merge task.
+type CompactionTaskData struct {
+	// Number of the latest monthly partitions to merge, including the current one; all partitions are merged if zero.
+	Months int `json:"months,omitempty"`
+}
+
+// SilenceMatcher represents Alertmanager silence label matcher.
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"is_regex,omitempty"`
 }
 
 // Value implements database/sql/driver.Valuer interface. Should be defined on the value.
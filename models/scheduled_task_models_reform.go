@@ -38,6 +38,18 @@ func (v *scheduledTaskTableType) Columns() []string {
 		"data",
 		"running",
 		"error",
+		"misfire_policy",
+		"concurrency_policy",
+		"remove_after_run",
+		"retries",
+		"retry_interval",
+		"retry_backoff",
+		"max_retry_interval",
+		"retry_budget",
+		"depends_on",
+		"jitter",
+		"window_start",
+		"window_end",
 		"created_at",
 		"updated_at",
 	}
@@ -74,6 +86,18 @@ var ScheduledTaskTable = &scheduledTaskTableType{
 			{Name: "Data", Type: "*ScheduledTaskData", Column: "data"},
 			{Name: "Running", Type: "bool", Column: "running"},
 			{Name: "Error", Type: "string", Column: "error"},
+			{Name: "MisfirePolicy", Type: "MisfirePolicy", Column: "misfire_policy"},
+			{Name: "ConcurrencyPolicy", Type: "ConcurrencyPolicy", Column: "concurrency_policy"},
+			{Name: "RemoveAfterRun", Type: "bool", Column: "remove_after_run"},
+			{Name: "Retries", Type: "uint32", Column: "retries"},
+			{Name: "RetryInterval", Type: "time.Duration", Column: "retry_interval"},
+			{Name: "RetryBackoff", Type: "RetryBackoff", Column: "retry_backoff"},
+			{Name: "MaxRetryInterval", Type: "time.Duration", Column: "max_retry_interval"},
+			{Name: "RetryBudget", Type: "time.Duration", Column: "retry_budget"},
+			{Name: "DependsOn", Type: "*string", Column: "depends_on"},
+			{Name: "Jitter", Type: "time.Duration", Column: "jitter"},
+			{Name: "WindowStart", Type: "string", Column: "window_start"},
+			{Name: "WindowEnd", Type: "string", Column: "window_end"},
 			{Name: "CreatedAt", Type: "time.Time", Column: "created_at"},
 			{Name: "UpdatedAt", Type: "time.Time", Column: "updated_at"},
 		},
@@ -84,7 +108,7 @@ var ScheduledTaskTable = &scheduledTaskTableType{
 
 // String returns a string representation of this struct or record.
 func (s ScheduledTask) String() string {
-	res := make([]string, 12)
+	res := make([]string, 24)
 	res[0] = "ID: " + reform.Inspect(s.ID, true)
 	res[1] = "CronExpression: " + reform.Inspect(s.CronExpression, true)
 	res[2] = "Disabled: " + reform.Inspect(s.Disabled, true)
@@ -95,8 +119,20 @@ func (s ScheduledTask) String() string {
 	res[7] = "Data: " + reform.Inspect(s.Data, true)
 	res[8] = "Running: " + reform.Inspect(s.Running, true)
 	res[9] = "Error: " + reform.Inspect(s.Error, true)
-	res[10] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
-	res[11] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
+	res[10] = "MisfirePolicy: " + reform.Inspect(s.MisfirePolicy, true)
+	res[11] = "ConcurrencyPolicy: " + reform.Inspect(s.ConcurrencyPolicy, true)
+	res[12] = "RemoveAfterRun: " + reform.Inspect(s.RemoveAfterRun, true)
+	res[13] = "Retries: " + reform.Inspect(s.Retries, true)
+	res[14] = "RetryInterval: " + reform.Inspect(s.RetryInterval, true)
+	res[15] = "RetryBackoff: " + reform.Inspect(s.RetryBackoff, true)
+	res[16] = "MaxRetryInterval: " + reform.Inspect(s.MaxRetryInterval, true)
+	res[17] = "RetryBudget: " + reform.Inspect(s.RetryBudget, true)
+	res[18] = "DependsOn: " + reform.Inspect(s.DependsOn, true)
+	res[19] = "Jitter: " + reform.Inspect(s.Jitter, true)
+	res[20] = "WindowStart: " + reform.Inspect(s.WindowStart, true)
+	res[21] = "WindowEnd: " + reform.Inspect(s.WindowEnd, true)
+	res[22] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
+	res[23] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
 	return strings.Join(res, ", ")
 }
 
@@ -114,6 +150,18 @@ func (s *ScheduledTask) Values() []interface{} {
 		s.Data,
 		s.Running,
 		s.Error,
+		s.MisfirePolicy,
+		s.ConcurrencyPolicy,
+		s.RemoveAfterRun,
+		s.Retries,
+		s.RetryInterval,
+		s.RetryBackoff,
+		s.MaxRetryInterval,
+		s.RetryBudget,
+		s.DependsOn,
+		s.Jitter,
+		s.WindowStart,
+		s.WindowEnd,
 		s.CreatedAt,
 		s.UpdatedAt,
 	}
@@ -133,6 +181,18 @@ func (s *ScheduledTask) Pointers() []interface{} {
 		&s.Data,
 		&s.Running,
 		&s.Error,
+		&s.MisfirePolicy,
+		&s.ConcurrencyPolicy,
+		&s.RemoveAfterRun,
+		&s.Retries,
+		&s.RetryInterval,
+		&s.RetryBackoff,
+		&s.MaxRetryInterval,
+		&s.RetryBudget,
+		&s.DependsOn,
+		&s.Jitter,
+		&s.WindowStart,
+		&s.WindowEnd,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	}
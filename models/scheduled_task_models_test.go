@@ -0,0 +1,136 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestExecutionWindow(t *testing.T) {
+	at := func(hhmm string) time.Time {
+		tm, err := time.Parse(models.ExecutionWindowLayout, hhmm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2021, 10, 20, tm.Hour(), tm.Minute(), 30, 0, time.UTC)
+	}
+
+	t.Run("NoWindow", func(t *testing.T) {
+		task := &models.ScheduledTask{}
+		assert.True(t, task.InExecutionWindow(at("12:00")))
+	})
+
+	t.Run("Day", func(t *testing.T) {
+		task := &models.ScheduledTask{WindowStart: "01:00", WindowEnd: "05:00"}
+		assert.False(t, task.InExecutionWindow(at("00:59")))
+		assert.True(t, task.InExecutionWindow(at("01:00")))
+		assert.True(t, task.InExecutionWindow(at("04:59")))
+		assert.False(t, task.InExecutionWindow(at("05:00")))
+	})
+
+	t.Run("Midnight", func(t *testing.T) {
+		task := &models.ScheduledTask{WindowStart: "22:00", WindowEnd: "02:00"}
+		assert.False(t, task.InExecutionWindow(at("21:59")))
+		assert.True(t, task.InExecutionWindow(at("23:30")))
+		assert.True(t, task.InExecutionWindow(at("01:59")))
+		assert.False(t, task.InExecutionWindow(at("02:00")))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		params := models.CreateScheduledTaskParams{
+			CronExpression: "0 * * * *",
+			Type:           models.ScheduledMySQLBackupTask,
+			Jitter:         5 * time.Minute,
+			WindowStart:    "01:00",
+			WindowEnd:      "05:00",
+		}
+		assert.NoError(t, params.Validate())
+
+		params.WindowEnd = "5am"
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Invalid execution window end "5am", expected HH:MM.`), params.Validate())
+
+		params.WindowEnd = "01:00"
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Execution window start and end should be different."), params.Validate())
+
+		params.WindowStart, params.WindowEnd = "", ""
+		params.Jitter = -time.Second
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Jitter should not be negative."), params.Validate())
+
+		windowStart := "01:00"
+		change := models.ChangeScheduledTaskParams{WindowStart: &windowStart}
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Both execution window start and end should be set."), change.Validate())
+	})
+}
+
+func TestOneShotTask(t *testing.T) {
+	params := models.CreateScheduledTaskParams{
+		Type:    models.ScheduledMySQLBackupTask,
+		StartAt: time.Date(2021, 10, 21, 2, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, params.Validate())
+	assert.True(t, (&models.ScheduledTask{StartAt: params.StartAt}).OneShot())
+
+	params.RemoveAfterRun = true
+	assert.NoError(t, params.Validate())
+
+	params.StartAt = time.Time{}
+	tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Start time is required for task without cron expression."), params.Validate())
+
+	params.CronExpression = "0 2 * * *"
+	tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Only one-shot task can be removed after run."), params.Validate())
+}
+
+func TestDependentTask(t *testing.T) {
+	params := models.CreateScheduledTaskParams{
+		Type:      models.ScheduledMySQLBackupTask,
+		DependsOn: "/scheduled_task_id/1",
+	}
+	assert.NoError(t, params.Validate())
+	assert.False(t, (&models.ScheduledTask{DependsOn: &params.DependsOn}).OneShot())
+
+	params.CronExpression = "0 2 * * *"
+	tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Dependent task can't have cron expression or start time."), params.Validate())
+
+	params.CronExpression = ""
+	params.Jitter = time.Minute
+	tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Dependent task can't have jitter."), params.Validate())
+}
+
+func TestRetryDelay(t *testing.T) {
+	task := &models.ScheduledTask{RetryInterval: time.Second}
+	assert.Equal(t, time.Second, task.RetryDelay(0))
+	assert.Equal(t, time.Second, task.RetryDelay(5))
+
+	task.RetryBackoff = models.ExponentialRetryBackoff
+	assert.Equal(t, time.Second, task.RetryDelay(0))
+	assert.Equal(t, 2*time.Second, task.RetryDelay(1))
+	assert.Equal(t, 32*time.Second, task.RetryDelay(5))
+	assert.Positive(t, task.RetryDelay(1000), "should not overflow")
+
+	task.MaxRetryInterval = 10 * time.Second
+	assert.Equal(t, 8*time.Second, task.RetryDelay(3))
+	assert.Equal(t, 10*time.Second, task.RetryDelay(4))
+	assert.Equal(t, 10*time.Second, task.RetryDelay(1000))
+}
@@ -0,0 +1,166 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+// scheduledTaskRunsLimit is a maximal number of stored runs of a single scheduled task.
+const scheduledTaskRunsLimit = 100
+
+// ScheduledTaskRunsFilter represents filters for scheduled task runs list.
+type ScheduledTaskRunsFilter struct {
+	// Return only runs of that task.
+	TaskID string
+	// Return only runs with that status.
+	Status ScheduledTaskRunStatus
+	// Return at most that many latest runs; all stored runs if zero.
+	Limit int
+}
+
+// FindScheduledTaskRuns returns scheduled task runs satisfying filter, latest first.
+func FindScheduledTaskRuns(q *reform.Querier, filter ScheduledTaskRunsFilter) ([]*ScheduledTaskRun, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.TaskID != "" {
+		args = append(args, filter.TaskID)
+		conditions = append(conditions, "task_id = "+q.Placeholder(len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, "status = "+q.Placeholder(len(args)))
+	}
+
+	var tail string
+	if len(conditions) != 0 {
+		tail = "WHERE " + strings.Join(conditions, " AND ") + " "
+	}
+	tail += "ORDER BY started_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		tail += " LIMIT " + q.Placeholder(len(args))
+	}
+
+	structs, err := q.SelectAllFrom(ScheduledTaskRunTable, tail, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*ScheduledTaskRun, len(structs))
+	for i, s := range structs {
+		res[i] = s.(*ScheduledTaskRun)
+	}
+	return res, nil
+}
+
+// CreateScheduledTaskRun records start of scheduled task run.
+// Oldest runs of the task are removed to keep at most scheduledTaskRunsLimit runs.
+func CreateScheduledTaskRun(q *reform.Querier, taskID string) (*ScheduledTaskRun, error) {
+	return createScheduledTaskRun(q, taskID, RunningScheduledTaskRunStatus)
+}
+
+// CreateQueuedScheduledTaskRun records scheduled task run waiting for a free scheduler worker.
+// It should be started by StartScheduledTaskRun.
+func CreateQueuedScheduledTaskRun(q *reform.Querier, taskID string) (*ScheduledTaskRun, error) {
+	return createScheduledTaskRun(q, taskID, QueuedScheduledTaskRunStatus)
+}
+
+func createScheduledTaskRun(q *reform.Querier, taskID string, status ScheduledTaskRunStatus) (*ScheduledTaskRun, error) {
+	if _, err := FindScheduledTaskByID(q, taskID); err != nil {
+		return nil, err
+	}
+
+	run := &ScheduledTaskRun{
+		ID:     "/scheduled_task_run_id/" + uuid.New().String(),
+		TaskID: taskID,
+		Status: status,
+	}
+	if err := q.Insert(run); err != nil {
+		return nil, errors.Wrap(err, "failed to insert scheduled task run")
+	}
+
+	_, err := q.Exec(
+		"DELETE FROM scheduled_task_runs WHERE task_id = $1 AND id NOT IN "+
+			"(SELECT id FROM scheduled_task_runs WHERE task_id = $1 ORDER BY started_at DESC LIMIT $2)",
+		taskID, scheduledTaskRunsLimit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to remove old scheduled task runs")
+	}
+
+	return run, nil
+}
+
+// StartScheduledTaskRun records start of queued scheduled task run; start time is updated.
+func StartScheduledTaskRun(q *reform.Querier, id string) (*ScheduledTaskRun, error) {
+	run := &ScheduledTaskRun{ID: id}
+	if err := q.Reload(run); err != nil {
+		return nil, errors.Wrapf(err, "failed to find scheduled task run %q", id)
+	}
+
+	run.Status = RunningScheduledTaskRunStatus
+	run.StartedAt = Now()
+	if err := q.Update(run); err != nil {
+		return nil, errors.Wrap(err, "failed to update scheduled task run")
+	}
+	return run, nil
+}
+
+// FinishScheduledTaskRun records end of scheduled task run with given error (empty on success)
+// and ID of created artifact (empty if none).
+func FinishScheduledTaskRun(q *reform.Querier, id, runErr, artifactID string) (*ScheduledTaskRun, error) {
+	run := &ScheduledTaskRun{ID: id}
+	if err := q.Reload(run); err != nil {
+		return nil, errors.Wrapf(err, "failed to find scheduled task run %q", id)
+	}
+
+	run.Status = SuccessScheduledTaskRunStatus
+	if runErr != "" {
+		run.Status = ErrorScheduledTaskRunStatus
+	}
+	run.Error = runErr
+	run.ArtifactID = artifactID
+	now := Now()
+	run.FinishedAt = &now
+
+	if err := q.Update(run); err != nil {
+		return nil, errors.Wrap(err, "failed to update scheduled task run")
+	}
+	return run, nil
+}
+
+// MissScheduledTaskRun records queued scheduled task run that was not executed with given reason.
+func MissScheduledTaskRun(q *reform.Querier, id, reason string) (*ScheduledTaskRun, error) {
+	run := &ScheduledTaskRun{ID: id}
+	if err := q.Reload(run); err != nil {
+		return nil, errors.Wrapf(err, "failed to find scheduled task run %q", id)
+	}
+
+	run.Status = MissedScheduledTaskRunStatus
+	run.Error = reason
+	now := Now()
+	run.FinishedAt = &now
+
+	if err := q.Update(run); err != nil {
+		return nil, errors.Wrap(err, "failed to update scheduled task run")
+	}
+	return run, nil
+}
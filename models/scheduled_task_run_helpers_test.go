@@ -0,0 +1,99 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestScheduledTaskRuns(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	defer func() {
+		require.NoError(t, sqlDB.Close())
+	}()
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tx.Rollback())
+	}()
+	q := tx.Querier
+
+	task, err := models.CreateScheduledTask(q, models.CreateScheduledTaskParams{
+		CronExpression: "* * * * *",
+		Type:           models.ScheduledMySQLBackupTask,
+		Data: models.ScheduledTaskData{
+			MySQLBackupTask: &models.MySQLBackupTaskData{Name: "daily"},
+		},
+	})
+	require.NoError(t, err)
+
+	failed, err := models.CreateScheduledTaskRun(q, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RunningScheduledTaskRunStatus, failed.Status)
+	failed, err = models.FinishScheduledTaskRun(q, failed.ID, "agent is not connected", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ErrorScheduledTaskRunStatus, failed.Status)
+	assert.NotNil(t, failed.FinishedAt)
+
+	succeeded, err := models.CreateScheduledTaskRun(q, task.ID)
+	require.NoError(t, err)
+	succeeded, err = models.FinishScheduledTaskRun(q, succeeded.ID, "", "/artifact_id/1")
+	require.NoError(t, err)
+	assert.Equal(t, models.SuccessScheduledTaskRunStatus, succeeded.Status)
+
+	runs, err := models.FindScheduledTaskRuns(q, models.ScheduledTaskRunsFilter{TaskID: task.ID})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, succeeded.ID, runs[0].ID)
+	assert.Equal(t, "/artifact_id/1", runs[0].ArtifactID)
+	assert.Equal(t, failed.ID, runs[1].ID)
+	assert.Equal(t, "agent is not connected", runs[1].Error)
+
+	runs, err = models.FindScheduledTaskRuns(q, models.ScheduledTaskRunsFilter{TaskID: task.ID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, succeeded.ID, runs[0].ID)
+
+	queued, err := models.CreateQueuedScheduledTaskRun(q, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.QueuedScheduledTaskRunStatus, queued.Status)
+	runs, err = models.FindScheduledTaskRuns(q, models.ScheduledTaskRunsFilter{Status: models.QueuedScheduledTaskRunStatus})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, queued.ID, runs[0].ID)
+
+	started, err := models.StartScheduledTaskRun(q, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RunningScheduledTaskRunStatus, started.Status)
+	assert.False(t, started.StartedAt.Before(queued.StartedAt))
+
+	// runs are removed with the task
+	require.NoError(t, models.RemoveScheduledTask(q, task.ID))
+	runs, err = models.FindScheduledTaskRuns(q, models.ScheduledTaskRunsFilter{TaskID: task.ID})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
@@ -0,0 +1,76 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// ScheduledTaskRunStatus represents status of a single scheduled task run.
+type ScheduledTaskRunStatus string
+
+// Scheduled task run statuses.
+const (
+	// QueuedScheduledTaskRunStatus means that run waits for a free scheduler worker.
+	QueuedScheduledTaskRunStatus  ScheduledTaskRunStatus = "queued"
+	RunningScheduledTaskRunStatus ScheduledTaskRunStatus = "running"
+	SuccessScheduledTaskRunStatus ScheduledTaskRunStatus = "success"
+	ErrorScheduledTaskRunStatus   ScheduledTaskRunStatus = "error"
+	// MissedScheduledTaskRunStatus means that run was not executed because scheduler queue was full.
+	MissedScheduledTaskRunStatus ScheduledTaskRunStatus = "missed"
+)
+
+// ScheduledTaskRun represents a single execution of a scheduled task.
+//reform:scheduled_task_runs
+type ScheduledTaskRun struct {
+	ID     string                 `reform:"id,pk"`
+	TaskID string                 `reform:"task_id"`
+	Status ScheduledTaskRunStatus `reform:"status"`
+	Error  string                 `reform:"error"`
+	// ID of the artifact created by the run, if any.
+	ArtifactID string     `reform:"artifact_id"`
+	StartedAt  time.Time  `reform:"started_at"`
+	FinishedAt *time.Time `reform:"finished_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (r *ScheduledTaskRun) BeforeInsert() error {
+	if r.StartedAt.IsZero() {
+		r.StartedAt = Now()
+	}
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (r *ScheduledTaskRun) AfterFind() error {
+	r.StartedAt = r.StartedAt.UTC()
+	if r.FinishedAt != nil {
+		finishedAt := r.FinishedAt.UTC()
+		r.FinishedAt = &finishedAt
+	}
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*ScheduledTaskRun)(nil)
+	_ reform.AfterFinder    = (*ScheduledTaskRun)(nil)
+)
@@ -18,9 +18,12 @@ package models
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/AlekSi/pointer"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
@@ -52,6 +55,8 @@ type ScheduledTasksFilter struct {
 	Types      []ScheduledTaskType
 	ServiceID  string
 	LocationID string
+	// Return only tasks started by the task with that ID.
+	DependsOn string
 }
 
 // FindScheduledTasks returns all scheduled tasks satisfying filter.
@@ -89,6 +94,11 @@ func FindScheduledTasks(q *reform.Querier, filters ScheduledTasksFilter) ([]*Sch
 		crossJoin = true
 		andConds = append(andConds, "value ->> 'location_id' = "+q.Placeholder(idx))
 		args = append(args, filters.LocationID)
+		idx++
+	}
+	if filters.DependsOn != "" {
+		andConds = append(andConds, "depends_on = "+q.Placeholder(idx))
+		args = append(args, filters.DependsOn)
 	}
 
 	var tail strings.Builder
@@ -122,6 +132,22 @@ type CreateScheduledTaskParams struct {
 	Type           ScheduledTaskType
 	Data           ScheduledTaskData
 	Disabled       bool
+	MisfirePolicy  MisfirePolicy
+	Jitter         time.Duration
+	WindowStart    string
+	WindowEnd      string
+
+	ConcurrencyPolicy ConcurrencyPolicy
+	// Remove one-shot task after its run instead of disabling it.
+	RemoveAfterRun bool
+	Retries        uint32
+	RetryInterval  time.Duration
+	RetryBackoff   RetryBackoff
+	// Limit of exponential backoff retry interval and of total time spent on retries of a single run.
+	MaxRetryInterval time.Duration
+	RetryBudget      time.Duration
+	// ID of the task which run starts this task; empty for tasks started by their own schedule.
+	DependsOn string
 }
 
 // Validate checks if required params are set and valid.
@@ -129,12 +155,120 @@ func (p CreateScheduledTaskParams) Validate() error {
 	switch p.Type {
 	case ScheduledMySQLBackupTask:
 	case ScheduledMongoDBBackupTask:
+	case ScheduledReportTask:
+		if err := p.Data.ReportTask.Validate(); err != nil {
+			return err
+		}
+	case ScheduledCleanupTask:
+		if err := p.Data.CleanupTask.Validate(); err != nil {
+			return err
+		}
+	case ScheduledMaintenanceTask:
+		if err := p.Data.MaintenanceTask.Validate(); err != nil {
+			return err
+		}
+	case ScheduledCompactionTask:
+		if err := p.Data.CompactionTask.Validate(); err != nil {
+			return err
+		}
 	default:
 		return status.Errorf(codes.InvalidArgument, "Unknown type: %s", p.Type)
 	}
-	_, err := cron.ParseStandard(p.CronExpression)
-	if err != nil {
-		return status.Errorf(codes.InvalidArgument, "Invalid cron expression: %v", err)
+	var err error
+	switch {
+	case p.DependsOn != "":
+		if p.CronExpression != "" || !p.StartAt.IsZero() {
+			return status.Error(codes.InvalidArgument, "Dependent task can't have cron expression or start time.")
+		}
+		if p.RemoveAfterRun {
+			return status.Error(codes.InvalidArgument, "Only one-shot task can be removed after run.")
+		}
+		if p.Jitter != 0 {
+			return status.Error(codes.InvalidArgument, "Dependent task can't have jitter.")
+		}
+	case p.CronExpression != "":
+		if _, err = cron.ParseStandard(p.CronExpression); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid cron expression: %v", err)
+		}
+		if p.RemoveAfterRun {
+			return status.Error(codes.InvalidArgument, "Only one-shot task can be removed after run.")
+		}
+	case p.StartAt.IsZero():
+		return status.Error(codes.InvalidArgument, "Start time is required for task without cron expression.")
+	}
+
+	if p.Jitter < 0 {
+		return status.Error(codes.InvalidArgument, "Jitter should not be negative.")
+	}
+	if p.RetryInterval < 0 || p.MaxRetryInterval < 0 || p.RetryBudget < 0 {
+		return status.Error(codes.InvalidArgument, "Retry interval and budget should not be negative.")
+	}
+	if err = p.RetryBackoff.Validate(); err != nil {
+		return err
+	}
+	if err = checkExecutionWindow(p.WindowStart, p.WindowEnd); err != nil {
+		return err
+	}
+	if err = p.ConcurrencyPolicy.Validate(); err != nil {
+		return err
+	}
+
+	return p.MisfirePolicy.Validate()
+}
+
+// validate checks if changed task data is valid for task of given type.
+func (d *ScheduledTaskData) validate(taskType ScheduledTaskType) error {
+	var ok bool
+	switch taskType {
+	case ScheduledMySQLBackupTask:
+		ok = d.MySQLBackupTask != nil
+	case ScheduledMongoDBBackupTask:
+		ok = d.MongoDBBackupTask != nil
+	case ScheduledReportTask:
+		if err := d.ReportTask.Validate(); err != nil {
+			return err
+		}
+		ok = true
+	case ScheduledCleanupTask:
+		if err := d.CleanupTask.Validate(); err != nil {
+			return err
+		}
+		ok = true
+	case ScheduledMaintenanceTask:
+		if err := d.MaintenanceTask.Validate(); err != nil {
+			return err
+		}
+		ok = true
+	case ScheduledCompactionTask:
+		if err := d.CompactionTask.Validate(); err != nil {
+			return err
+		}
+		ok = true
+	}
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "Invalid data for task type %s.", taskType)
+	}
+	return nil
+}
+
+// Validate checks if report task data is valid.
+func (d *ReportTaskData) Validate() error {
+	if d == nil {
+		return status.Error(codes.InvalidArgument, "Report task data is required.")
+	}
+	if d.Name == "" {
+		return status.Error(codes.InvalidArgument, "Report name is required.")
+	}
+	if d.Period <= 0 {
+		return status.Error(codes.InvalidArgument, "Report period should be positive.")
+	}
+	if len(d.Queries) == 0 {
+		return status.Error(codes.InvalidArgument, "At least one report query is required.")
+	}
+	for _, q := range d.Queries {
+		if q.Query == "" {
+			return status.Errorf(codes.InvalidArgument, "Empty query for report section %q.", q.Title)
+		}
 	}
 
 	return nil
@@ -149,6 +283,11 @@ func CreateScheduledTask(q *reform.Querier, params CreateScheduledTaskParams) (*
 	if err := checkUniqueScheduledTaskID(q, id); err != nil {
 		return nil, err
 	}
+	if params.DependsOn != "" {
+		if _, err := FindScheduledTaskByID(q, params.DependsOn); err != nil {
+			return nil, err
+		}
+	}
 
 	task := &ScheduledTask{
 		ID:             id,
@@ -158,6 +297,21 @@ func CreateScheduledTask(q *reform.Querier, params CreateScheduledTaskParams) (*
 		NextRun:        params.NextRun,
 		Type:           params.Type,
 		Data:           &params.Data,
+		MisfirePolicy:  params.MisfirePolicy,
+		Jitter:         params.Jitter,
+		WindowStart:    params.WindowStart,
+		WindowEnd:      params.WindowEnd,
+
+		ConcurrencyPolicy: params.ConcurrencyPolicy,
+		RemoveAfterRun:    params.RemoveAfterRun,
+		Retries:           params.Retries,
+		RetryInterval:     params.RetryInterval,
+		RetryBackoff:      params.RetryBackoff,
+		MaxRetryInterval:  params.MaxRetryInterval,
+		RetryBudget:       params.RetryBudget,
+	}
+	if params.DependsOn != "" {
+		task.DependsOn = pointer.ToString(params.DependsOn)
 	}
 	if err := q.Insert(task); err != nil {
 		return nil, errors.WithStack(err)
@@ -174,6 +328,20 @@ type ChangeScheduledTaskParams struct {
 	Error          *string
 	Data           *ScheduledTaskData
 	CronExpression *string
+	MisfirePolicy  *MisfirePolicy
+	Jitter         *time.Duration
+	// Both bounds should be set together; empty strings remove the window.
+	WindowStart *string
+	WindowEnd   *string
+
+	ConcurrencyPolicy *ConcurrencyPolicy
+	Retries           *uint32
+	RetryInterval     *time.Duration
+	RetryBackoff      *RetryBackoff
+	MaxRetryInterval  *time.Duration
+	RetryBudget       *time.Duration
+	// Only dependent task can change its dependency.
+	DependsOn *string
 }
 
 // Validate checks if params for scheduled tasks are valid.
@@ -181,9 +349,41 @@ func (p ChangeScheduledTaskParams) Validate() error {
 	if p.CronExpression != nil {
 		_, err := cron.ParseStandard(*p.CronExpression)
 		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid cron expression: %v", err)
+		}
+	}
+	if p.Jitter != nil && *p.Jitter < 0 {
+		return status.Error(codes.InvalidArgument, "Jitter should not be negative.")
+	}
+	if p.DependsOn != nil && *p.DependsOn == "" {
+		return status.Error(codes.InvalidArgument, "Empty dependency task ID.")
+	}
+	for _, d := range []*time.Duration{p.RetryInterval, p.MaxRetryInterval, p.RetryBudget} {
+		if d != nil && *d < 0 {
+			return status.Error(codes.InvalidArgument, "Retry interval and budget should not be negative.")
+		}
+	}
+	if p.RetryBackoff != nil {
+		if err := p.RetryBackoff.Validate(); err != nil {
 			return err
 		}
 	}
+	if (p.WindowStart == nil) != (p.WindowEnd == nil) {
+		return status.Error(codes.InvalidArgument, "Both execution window start and end should be set.")
+	}
+	if p.WindowStart != nil {
+		if err := checkExecutionWindow(*p.WindowStart, *p.WindowEnd); err != nil {
+			return err
+		}
+	}
+	if p.ConcurrencyPolicy != nil {
+		if err := p.ConcurrencyPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.MisfirePolicy != nil {
+		return p.MisfirePolicy.Validate()
+	}
 	return nil
 }
 
@@ -215,17 +415,70 @@ func ChangeScheduledTask(q *reform.Querier, id string, params ChangeScheduledTas
 	}
 
 	if params.Data != nil {
+		if err = params.Data.validate(row.Type); err != nil {
+			return nil, err
+		}
 		row.Data = params.Data
 	}
 
+	if row.DependsOn != nil && (params.CronExpression != nil || (params.Jitter != nil && *params.Jitter != 0)) {
+		return nil, status.Error(codes.FailedPrecondition, "Dependent task can't have cron expression or jitter.")
+	}
+
+	if params.DependsOn != nil {
+		if err = checkScheduledTaskDependency(q, row, *params.DependsOn); err != nil {
+			return nil, err
+		}
+		row.DependsOn = params.DependsOn
+	}
+
 	if params.CronExpression != nil {
 		row.CronExpression = *params.CronExpression
+		// task with cron expression is not one-shot anymore
+		row.RemoveAfterRun = false
 	}
 
 	if params.Error != nil {
 		row.Error = *params.Error
 	}
 
+	if params.MisfirePolicy != nil {
+		row.MisfirePolicy = *params.MisfirePolicy
+	}
+
+	if params.Jitter != nil {
+		row.Jitter = *params.Jitter
+	}
+
+	if params.WindowStart != nil {
+		row.WindowStart = *params.WindowStart
+		row.WindowEnd = *params.WindowEnd
+	}
+
+	if params.ConcurrencyPolicy != nil {
+		row.ConcurrencyPolicy = *params.ConcurrencyPolicy
+	}
+
+	if params.Retries != nil {
+		row.Retries = *params.Retries
+	}
+
+	if params.RetryInterval != nil {
+		row.RetryInterval = *params.RetryInterval
+	}
+
+	if params.RetryBackoff != nil {
+		row.RetryBackoff = *params.RetryBackoff
+	}
+
+	if params.MaxRetryInterval != nil {
+		row.MaxRetryInterval = *params.MaxRetryInterval
+	}
+
+	if params.RetryBudget != nil {
+		row.RetryBudget = *params.RetryBudget
+	}
+
 	if err := q.Update(row); err != nil {
 		return nil, errors.Wrap(err, "failed to update scheduled task")
 	}
@@ -233,11 +486,39 @@ func ChangeScheduledTask(q *reform.Querier, id string, params ChangeScheduledTas
 	return row, nil
 }
 
+// checkScheduledTaskDependency returns error if dependent task can't depend on the task with given ID.
+func checkScheduledTaskDependency(q *reform.Querier, task *ScheduledTask, dependsOn string) error {
+	if task.DependsOn == nil {
+		return status.Error(codes.FailedPrecondition, "Only dependent task can change its dependency.")
+	}
+
+	// walk the chain up to the task started by schedule to find cycles
+	for id := dependsOn; id != ""; {
+		if id == task.ID {
+			return status.Error(codes.FailedPrecondition, "Scheduled tasks dependency cycle.")
+		}
+		dependency, err := FindScheduledTaskByID(q, id)
+		if err != nil {
+			return err
+		}
+		id = pointer.GetString(dependency.DependsOn)
+	}
+	return nil
+}
+
 // RemoveScheduledTask removes task from DB.
+// Task with dependent tasks can't be removed.
 func RemoveScheduledTask(q *reform.Querier, id string) error {
 	if _, err := FindScheduledTaskByID(q, id); err != nil {
 		return err
 	}
+	dependents, err := FindScheduledTasks(q, ScheduledTasksFilter{DependsOn: id})
+	if err != nil {
+		return err
+	}
+	if len(dependents) != 0 {
+		return status.Errorf(codes.FailedPrecondition, "Scheduled task %q has %d dependent task(s), remove them first.", id, len(dependents))
+	}
 	if err := q.Delete(&ScheduledTask{ID: id}); err != nil {
 		return errors.Wrap(err, "failed to delete scheduled task")
 	}
@@ -260,3 +541,69 @@ func checkUniqueScheduledTaskID(q *reform.Querier, id string) error {
 		return errors.WithStack(err)
 	}
 }
+
+// Validate checks if cleanup task data is valid.
+func (d *CleanupTaskData) Validate() error {
+	if d == nil {
+		return status.Error(codes.InvalidArgument, "Cleanup task data is required.")
+	}
+	if d.NodeID == "" {
+		return status.Error(codes.InvalidArgument, "Node ID is required.")
+	}
+	if len(d.Patterns) == 0 {
+		return status.Error(codes.InvalidArgument, "At least one file pattern is required.")
+	}
+	for _, p := range d.Patterns {
+		if !filepath.IsAbs(p) {
+			return status.Errorf(codes.InvalidArgument, "File pattern %q should be absolute.", p)
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid file pattern %q.", p)
+		}
+	}
+	if d.MaxAge < 0 || d.MaxTotalSize < 0 {
+		return status.Error(codes.InvalidArgument, "Cleanup thresholds should not be negative.")
+	}
+	if d.MaxAge == 0 && d.MaxTotalSize == 0 {
+		return status.Error(codes.InvalidArgument, "Either maximal age or maximal total size is required.")
+	}
+
+	return nil
+}
+
+// Validate checks if maintenance task data is valid.
+func (d *MaintenanceTaskData) Validate() error {
+	if d == nil {
+		return status.Error(codes.InvalidArgument, "Maintenance task data is required.")
+	}
+	if len(d.Matchers) == 0 {
+		return status.Error(codes.InvalidArgument, "At least one silence matcher is required.")
+	}
+	for _, m := range d.Matchers {
+		if m.Name == "" {
+			return status.Error(codes.InvalidArgument, "Silence matcher label name is required.")
+		}
+		if m.IsRegex {
+			if _, err := regexp.Compile("^(?:" + m.Value + ")$"); err != nil {
+				return status.Errorf(codes.InvalidArgument, "Invalid silence matcher regex %q.", m.Value)
+			}
+		}
+	}
+	if d.Duration <= 0 {
+		return status.Error(codes.InvalidArgument, "Maintenance duration should be positive.")
+	}
+
+	return nil
+}
+
+// Validate checks if compaction task data is valid.
+func (d *CompactionTaskData) Validate() error {
+	if d == nil {
+		return status.Error(codes.InvalidArgument, "Compaction task data is required.")
+	}
+	if d.Months < 0 {
+		return status.Error(codes.InvalidArgument, "Number of months should not be negative.")
+	}
+
+	return nil
+}
@@ -34,8 +34,8 @@ func checkServiceUniqueID(q *reform.Querier, id string) error {
 		panic("empty Service ID")
 	}
 
-	row := &Service{ServiceID: id}
-	switch err := q.Reload(row); err {
+	row := &Service{}
+	switch err := selectOneTo(q, row, "WHERE service_id = $1", id); err {
 	case nil:
 		return status.Errorf(codes.AlreadyExists, "Service with ID %q already exists.", id)
 	case reform.ErrNoRows:
@@ -86,6 +86,10 @@ type ServiceFilters struct {
 	ServiceType *ServiceType
 	// Return only Services with given external group.
 	ExternalGroup string
+	// Return only Services of given cluster.
+	Cluster string
+	// Return only Services with owner, contact or notes containing that string (case-insensitive).
+	Search string
 }
 
 // FindServices returns Services by filters.
@@ -103,6 +107,16 @@ func FindServices(q *reform.Querier, filters ServiceFilters) ([]*Service, error)
 		args = append(args, filters.ExternalGroup)
 		idx++
 	}
+	if filters.Cluster != "" {
+		conditions = append(conditions, fmt.Sprintf("cluster = %s", q.Placeholder(idx)))
+		args = append(args, filters.Cluster)
+		idx++
+	}
+	if filters.Search != "" {
+		conditions = append(conditions, ownershipSearchCondition(q.Placeholder(idx)))
+		args = append(args, filters.Search)
+		idx++
+	}
 	if filters.ServiceType != nil {
 		conditions = append(conditions, fmt.Sprintf("service_type = %s", q.Placeholder(idx)))
 		args = append(args, filters.ServiceType)
@@ -125,7 +139,9 @@ func FindServices(q *reform.Querier, filters ServiceFilters) ([]*Service, error)
 }
 
 // FindServiceByID finds Service by ID.
-func FindServiceByID(q *reform.Querier, id string) (*Service, error) {
+func FindServiceByID(q *reform.Querier, id string) (_ *Service, err error) {
+	defer observeQuery("FindServiceByID", time.Now(), &err)
+
 	if id == "" {
 		return nil, status.Error(codes.InvalidArgument, "Empty Service ID.")
 	}
@@ -202,6 +218,33 @@ type AddDBMSServiceParams struct {
 
 // AddNewService adds new service to storage.
 func AddNewService(q *reform.Querier, serviceType ServiceType, params *AddDBMSServiceParams) (*Service, error) {
+	row, err := NewService(q, serviceType, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Insert(row); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if serviceType == MySQLServiceType {
+		if _, err := CreateServiceSoftwareVersions(q, CreateServiceSoftwareVersionsParams{
+			ServiceID:        row.ServiceID,
+			ServiceType:      serviceType,
+			SoftwareVersions: []SoftwareVersion{},
+			NextCheckAt:      time.Now(),
+		}); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return row, nil
+}
+
+// NewService validates parameters and returns Service without storing it.
+// Service name uniqueness is checked only against stored Services,
+// so callers inserting several Services with InsertBatch should check names in the batch themselves.
+// Unlike AddNewService, it does not create service software versions entry.
+func NewService(q *reform.Querier, serviceType ServiceType, params *AddDBMSServiceParams) (*Service, error) {
 	switch serviceType {
 	case MySQLServiceType, MongoDBServiceType, PostgreSQLServiceType, ProxySQLServiceType:
 		if err := validateDBConnectionOptions(params.Socket, params.Address, params.Port); err != nil {
@@ -251,20 +294,6 @@ func AddNewService(q *reform.Querier, serviceType ServiceType, params *AddDBMSSe
 	if err := row.SetCustomLabels(params.CustomLabels); err != nil {
 		return nil, err
 	}
-	if err := q.Insert(row); err != nil {
-		return nil, errors.WithStack(err)
-	}
-
-	if serviceType == MySQLServiceType {
-		if _, err := CreateServiceSoftwareVersions(q, CreateServiceSoftwareVersionsParams{
-			ServiceID:        id,
-			ServiceType:      serviceType,
-			SoftwareVersions: []SoftwareVersion{},
-			NextCheckAt:      time.Now(),
-		}); err != nil {
-			return nil, errors.WithStack(err)
-		}
-	}
 
 	return row, nil
 }
@@ -343,3 +372,27 @@ func RemoveService(q *reform.Querier, id string, mode RemoveMode) error {
 
 	return errors.Wrap(q.Delete(s), "failed to delete Service")
 }
+
+// ChangeServiceMetricsResolutions changes Service metrics resolutions overrides;
+// nil or zero values mean global metrics resolutions from settings.
+func ChangeServiceMetricsResolutions(q *reform.Querier, serviceID string, resolutions *MetricsResolutions) (*Service, error) {
+	if resolutions != nil {
+		if err := validateMetricsResolutions(*resolutions); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if *resolutions == (MetricsResolutions{}) {
+			resolutions = nil
+		}
+	}
+
+	s, err := FindServiceByID(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.MetricsResolutions = resolutions
+	if err = q.Update(s); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s, nil
+}
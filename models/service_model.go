@@ -17,6 +17,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"path"
+	"strings"
 	"time"
 
 	"gopkg.in/reform.v1"
@@ -38,6 +41,68 @@ const (
 	ExternalServiceType   ServiceType = "external"
 )
 
+// DatabaseAutodiscovery represents settings of databases autodiscovery by postgres_exporter.
+type DatabaseAutodiscovery struct {
+	// If true, only the database from DSN is monitored.
+	Disabled bool `json:"disabled,omitempty"`
+	// Only databases matching any of those patterns are monitored; all databases if empty.
+	// Patterns use path.Match syntax, for example, "app_*".
+	Include []string `json:"include,omitempty"`
+	// Databases matching any of those patterns are not monitored.
+	Exclude []string `json:"exclude,omitempty"`
+	// If positive, autodiscovery is disabled when more databases would be monitored.
+	MaxDatabases int32 `json:"max_databases,omitempty"`
+
+	// Databases of the instance as last listed by Action; nil if they were not listed yet.
+	Databases []string `json:"databases,omitempty"`
+	// Time of the last databases listing.
+	DatabasesListedAt *time.Time `json:"databases_listed_at,omitempty"`
+	// ID of running databases listing Action; empty if there is none.
+	ListActionID string `json:"list_action_id,omitempty"`
+}
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (d DatabaseAutodiscovery) Value() (driver.Value, error) { return jsonValue(d) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (d *DatabaseAutodiscovery) Scan(src interface{}) error { return jsonScan(d, src) }
+
+// NeedsDatabases returns true if databases should be listed to apply those settings.
+func (d *DatabaseAutodiscovery) NeedsDatabases() bool {
+	return !d.Disabled && (len(d.Include) != 0 || d.MaxDatabases > 0 || hasPatterns(d.Exclude))
+}
+
+// MonitoredDatabases returns listed databases matching settings' patterns.
+func (d *DatabaseAutodiscovery) MonitoredDatabases() []string {
+	var res []string
+	for _, db := range d.Databases {
+		if (len(d.Include) == 0 || matchAny(d.Include, db)) && !matchAny(d.Exclude, db) {
+			res = append(res, db)
+		}
+	}
+	return res
+}
+
+// matchAny returns true if name matches any of given path.Match patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPatterns returns true if any of given strings contains path.Match metacharacters.
+func hasPatterns(names []string) bool {
+	for _, n := range names {
+		if strings.ContainsAny(n, `*?[\`) {
+			return true
+		}
+	}
+	return false
+}
+
 // Service represents Service as stored in database.
 //reform:services
 type Service struct {
@@ -50,8 +115,21 @@ type Service struct {
 	ReplicationSet string      `reform:"replication_set"`
 	CustomLabels   []byte      `reform:"custom_labels"`
 	ExternalGroup  string      `reform:"external_group"`
-	CreatedAt      time.Time   `reform:"created_at"`
-	UpdatedAt      time.Time   `reform:"updated_at"`
+	// Free-form ownership information shown to on-call engineers.
+	Notes     string    `reform:"notes"`
+	Owner     string    `reform:"owner"`
+	Contact   string    `reform:"contact"`
+	CreatedAt time.Time `reform:"created_at"`
+	UpdatedAt time.Time `reform:"updated_at"`
+
+	// Service and its Agents are removed when none of its pmm-agents is connected for that duration; zero means never.
+	TTL time.Duration `reform:"ttl"`
+
+	// Overrides of global metrics resolutions; zero values and nil mean global ones.
+	MetricsResolutions *MetricsResolutions `reform:"metrics_resolutions"`
+
+	// Databases autodiscovery settings for PostgreSQL Services; nil means defaults.
+	DatabaseAutodiscovery *DatabaseAutodiscovery `reform:"database_autodiscovery"`
 
 	Address *string `reform:"address"`
 	Port    *uint16 `reform:"port"`
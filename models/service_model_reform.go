@@ -37,8 +37,14 @@ func (v *serviceTableType) Columns() []string {
 		"replication_set",
 		"custom_labels",
 		"external_group",
+		"notes",
+		"owner",
+		"contact",
 		"created_at",
 		"updated_at",
+		"ttl",
+		"metrics_resolutions",
+		"database_autodiscovery",
 		"address",
 		"port",
 		"socket",
@@ -75,8 +81,14 @@ var ServiceTable = &serviceTableType{
 			{Name: "ReplicationSet", Type: "string", Column: "replication_set"},
 			{Name: "CustomLabels", Type: "[]uint8", Column: "custom_labels"},
 			{Name: "ExternalGroup", Type: "string", Column: "external_group"},
+			{Name: "Notes", Type: "string", Column: "notes"},
+			{Name: "Owner", Type: "string", Column: "owner"},
+			{Name: "Contact", Type: "string", Column: "contact"},
 			{Name: "CreatedAt", Type: "time.Time", Column: "created_at"},
 			{Name: "UpdatedAt", Type: "time.Time", Column: "updated_at"},
+			{Name: "TTL", Type: "time.Duration", Column: "ttl"},
+			{Name: "MetricsResolutions", Type: "*MetricsResolutions", Column: "metrics_resolutions"},
+			{Name: "DatabaseAutodiscovery", Type: "*DatabaseAutodiscovery", Column: "database_autodiscovery"},
 			{Name: "Address", Type: "*string", Column: "address"},
 			{Name: "Port", Type: "*uint16", Column: "port"},
 			{Name: "Socket", Type: "*string", Column: "socket"},
@@ -88,7 +100,7 @@ var ServiceTable = &serviceTableType{
 
 // String returns a string representation of this struct or record.
 func (s Service) String() string {
-	res := make([]string, 14)
+	res := make([]string, 20)
 	res[0] = "ServiceID: " + reform.Inspect(s.ServiceID, true)
 	res[1] = "ServiceType: " + reform.Inspect(s.ServiceType, true)
 	res[2] = "ServiceName: " + reform.Inspect(s.ServiceName, true)
@@ -98,11 +110,17 @@ func (s Service) String() string {
 	res[6] = "ReplicationSet: " + reform.Inspect(s.ReplicationSet, true)
 	res[7] = "CustomLabels: " + reform.Inspect(s.CustomLabels, true)
 	res[8] = "ExternalGroup: " + reform.Inspect(s.ExternalGroup, true)
-	res[9] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
-	res[10] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
-	res[11] = "Address: " + reform.Inspect(s.Address, true)
-	res[12] = "Port: " + reform.Inspect(s.Port, true)
-	res[13] = "Socket: " + reform.Inspect(s.Socket, true)
+	res[9] = "Notes: " + reform.Inspect(s.Notes, true)
+	res[10] = "Owner: " + reform.Inspect(s.Owner, true)
+	res[11] = "Contact: " + reform.Inspect(s.Contact, true)
+	res[12] = "CreatedAt: " + reform.Inspect(s.CreatedAt, true)
+	res[13] = "UpdatedAt: " + reform.Inspect(s.UpdatedAt, true)
+	res[14] = "TTL: " + reform.Inspect(s.TTL, true)
+	res[15] = "MetricsResolutions: " + reform.Inspect(s.MetricsResolutions, true)
+	res[16] = "DatabaseAutodiscovery: " + reform.Inspect(s.DatabaseAutodiscovery, true)
+	res[17] = "Address: " + reform.Inspect(s.Address, true)
+	res[18] = "Port: " + reform.Inspect(s.Port, true)
+	res[19] = "Socket: " + reform.Inspect(s.Socket, true)
 	return strings.Join(res, ", ")
 }
 
@@ -119,8 +137,14 @@ func (s *Service) Values() []interface{} {
 		s.ReplicationSet,
 		s.CustomLabels,
 		s.ExternalGroup,
+		s.Notes,
+		s.Owner,
+		s.Contact,
 		s.CreatedAt,
 		s.UpdatedAt,
+		s.TTL,
+		s.MetricsResolutions,
+		s.DatabaseAutodiscovery,
 		s.Address,
 		s.Port,
 		s.Socket,
@@ -140,8 +164,14 @@ func (s *Service) Pointers() []interface{} {
 		&s.ReplicationSet,
 		&s.CustomLabels,
 		&s.ExternalGroup,
+		&s.Notes,
+		&s.Owner,
+		&s.Contact,
 		&s.CreatedAt,
 		&s.UpdatedAt,
+		&s.TTL,
+		&s.MetricsResolutions,
+		&s.DatabaseAutodiscovery,
 		&s.Address,
 		&s.Port,
 		&s.Socket,
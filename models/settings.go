@@ -17,6 +17,7 @@
 package models
 
 import (
+	"database/sql/driver"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/endpoints"
@@ -29,6 +30,56 @@ type MetricsResolutions struct {
 	LR time.Duration `json:"lr"`
 }
 
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (r MetricsResolutions) Value() (driver.Value, error) { return jsonValue(r) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (r *MetricsResolutions) Scan(src interface{}) error { return jsonScan(r, src) }
+
+// Override returns resolutions with non-zero values of overrides replacing those values.
+func (r MetricsResolutions) Override(overrides *MetricsResolutions) MetricsResolutions {
+	if overrides == nil {
+		return r
+	}
+	if overrides.HR != 0 {
+		r.HR = overrides.HR
+	}
+	if overrides.MR != 0 {
+		r.MR = overrides.MR
+	}
+	if overrides.LR != 0 {
+		r.LR = overrides.LR
+	}
+	return r
+}
+
+// MetricsResolutionTier represents one of standard metrics resolutions.
+type MetricsResolutionTier string
+
+// Metrics resolution tiers.
+const (
+	HRTier MetricsResolutionTier = "hr"
+	MRTier MetricsResolutionTier = "mr"
+	LRTier MetricsResolutionTier = "lr"
+)
+
+// Tier returns resolutions with all values replaced by the value of the given tier;
+// resolutions are returned as is for an empty tier.
+func (r MetricsResolutions) Tier(tier MetricsResolutionTier) MetricsResolutions {
+	var d time.Duration
+	switch tier {
+	case HRTier:
+		d = r.HR
+	case MRTier:
+		d = r.MR
+	case LRTier:
+		d = r.LR
+	default:
+		return r
+	}
+	return MetricsResolutions{HR: d, MR: d, LR: d}
+}
+
 // SaaS contains settings related to the SaaS platform.
 type SaaS struct {
 	// Percona Platform user email
@@ -48,6 +99,10 @@ type IntegratedAlerting struct {
 	Enabled               bool                   `json:"enabled"`
 	EmailAlertingSettings *EmailAlertingSettings `json:"email_settings"`
 	SlackAlertingSettings *SlackAlertingSettings `json:"slack_settings"`
+	// Remote VMAlert; local one is used if not set.
+	VMAlert *AlertingEndpoint `json:"vmalert,omitempty"`
+	// Remote Alertmanager; local one is used if not set.
+	Alertmanager *AlertingEndpoint `json:"alertmanager,omitempty"`
 }
 
 // Settings contains PMM Server settings.
@@ -74,6 +129,20 @@ type Settings struct {
 
 	VictoriaMetrics struct {
 		CacheEnabled bool `json:"cache_enabled"`
+		// External metrics backend; local VictoriaMetrics database is not used if set.
+		External *ExternalVictoriaMetrics `json:"external,omitempty"`
+		// Remote storages receiving copies of all collected metrics.
+		RemoteWrite []*RemoteWriteEndpoint `json:"remote_write,omitempty"`
+		// Downsampling periods; applied only if local VictoriaMetrics supports downsampling.
+		Downsampling []*DownsamplingPeriod `json:"downsampling,omitempty"`
+		// FileSD enables writing Agents' scrape targets to file_sd JSON files instead of inlining them.
+		FileSD bool `json:"file_sd,omitempty"`
+		// Path where scrape configuration is written for externally managed Prometheus-compatible server;
+		// if set, configuration is never reloaded by pmm-managed.
+		ExternalConfigPath string `json:"external_config_path,omitempty"`
+		// Labels added to all scraped metrics and to VMAlert rule evaluation results,
+		// e.g. to distinguish PMM Servers writing to the same remote storage.
+		ExternalLabels map[string]string `json:"external_labels,omitempty"`
 	} `json:"victoria_metrics"`
 
 	SaaS SaaS `json:"sass"` // sic :(
@@ -91,7 +160,118 @@ type Settings struct {
 
 	BackupManagement struct {
 		Enabled bool `json:"enabled"`
+		// Maximal number of backup jobs running at the same time; 0 means no limit.
+		MaxParallelJobs int `json:"max_parallel_jobs,omitempty"`
+		// Maximal number of backup jobs running at the same time on a single pmm-agent; 0 means no limit.
+		MaxParallelJobsPerAgent int `json:"max_parallel_jobs_per_agent,omitempty"`
+		// Maximal number of backup and restore jobs running at the same time on a single Node; 0 means no limit.
+		MaxParallelJobsPerNode int `json:"max_parallel_jobs_per_node,omitempty"`
+		// If true, backup and restore jobs may run at the same time on the same Node.
+		AllowConcurrentRestore bool `json:"allow_concurrent_restore,omitempty"`
+		// Backup jobs not reporting progress for that duration are marked as failed.
+		JobTimeout time.Duration `json:"job_timeout,omitempty"`
+		// Armored GPG private key used to sign manifests of artifacts; artifacts are not signed if empty.
+		SigningKey string `json:"signing_key,omitempty"`
 	} `json:"backup_management"`
+
+	Usage UsageSettings `json:"usage"`
+
+	// SIEM collector receiving alert and audit events; events are not forwarded if nil.
+	SIEM *SIEMSettings `json:"siem,omitempty"`
+}
+
+// SIEM message formats.
+const (
+	SIEMFormatSyslog = "syslog"
+	SIEMFormatCEF    = "cef"
+)
+
+// SIEM collector transport protocols.
+const (
+	SIEMProtocolUDP = "udp"
+	SIEMProtocolTCP = "tcp"
+	SIEMProtocolTLS = "tls"
+)
+
+// SIEMSettings contains settings of SIEM collector receiving alert firing/resolution events
+// and audit log entries as syslog (RFC 5424) messages.
+type SIEMSettings struct {
+	// Collector address in host:port format.
+	Address string `json:"address"`
+	// Transport protocol: udp, tcp, or tls.
+	Protocol string `json:"protocol"`
+	// Message format: syslog (plain RFC 5424 messages) or cef (ArcSight CEF in RFC 5424 messages).
+	Format string `json:"format"`
+	// PEM-encoded CA certificate for tls protocol; system CAs are used if empty.
+	CA                 string `json:"ca,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// UsageSettings contains settings of monitored Nodes and Services usage accounting.
+type UsageSettings struct {
+	// Webhook receiving notifications when numbers cross thresholds; notifications are not sent if empty.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// Thresholds of monitored Nodes and Services numbers; not checked if zero.
+	NodesThreshold    int `json:"nodes_threshold,omitempty"`
+	ServicesThreshold int `json:"services_threshold,omitempty"`
+}
+
+// ExternalVictoriaMetrics represents external, operator-managed VictoriaMetrics or other Prometheus-compatible metrics backend.
+type ExternalVictoriaMetrics struct {
+	// Base URL for queries, e.g. https://vm.example.com/select/0/prometheus.
+	ReadURL string `json:"read_url"`
+	// Remote write URL, e.g. https://vm.example.com/insert/0/prometheus/api/v1/write.
+	WriteURL           string `json:"write_url"`
+	Username           string `json:"username,omitempty"`
+	Password           string `json:"password,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// DownsamplingPeriod represents VictoriaMetrics downsampling period:
+// only one sample per Interval is kept for samples older than Offset.
+type DownsamplingPeriod struct {
+	Offset   time.Duration `json:"offset"`
+	Interval time.Duration `json:"interval"`
+}
+
+// RemoteWriteEndpoint represents remote storage (Cortex, Mimir, VictoriaMetrics cluster, etc)
+// receiving copies of collected metrics for long-term storage.
+type RemoteWriteEndpoint struct {
+	// Unique endpoint name.
+	Name string `json:"name"`
+	// Remote write URL, e.g. https://mimir.example.com/api/v1/push.
+	URL                 string                `json:"url"`
+	Username            string                `json:"username,omitempty"`
+	Password            string                `json:"password,omitempty"`
+	BearerToken         string                `json:"bearer_token,omitempty"`
+	InsecureSkipVerify  bool                  `json:"insecure_skip_verify,omitempty"`
+	WriteRelabelConfigs []*RemoteWriteRelabel `json:"write_relabel_configs,omitempty"`
+}
+
+// RemoteWriteRelabel represents Prometheus relabeling rule applied to metrics before sending them.
+type RemoteWriteRelabel struct {
+	SourceLabels []string `json:"source_labels,omitempty"`
+	Separator    string   `json:"separator,omitempty"`
+	Regex        string   `json:"regex,omitempty"`
+	Modulus      uint64   `json:"modulus,omitempty"`
+	TargetLabel  string   `json:"target_label,omitempty"`
+	Replacement  string   `json:"replacement,omitempty"`
+	Action       string   `json:"action,omitempty"`
+}
+
+// AlertingEndpoint represents remote, externally-hosted VMAlert or Alertmanager used by Integrated Alerting.
+type AlertingEndpoint struct {
+	// Base URL, e.g. https://vmalert.example.com/ or https://am.example.com/alertmanager.
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// PEM-encoded CA certificate used to verify server certificate; system pool is used if empty.
+	CACert string `json:"ca_cert,omitempty"`
+	// PEM-encoded client certificate and key for mutual TLS.
+	Cert               string `json:"cert,omitempty"`
+	Key                string `json:"key,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
 }
 
 // EmailAlertingSettings represents email settings for Integrated Alerting.
@@ -152,6 +332,10 @@ func (s *Settings) fillDefaults() {
 		s.SaaS.STTCheckIntervals.FrequentInterval = 4 * time.Hour
 	}
 
+	if s.BackupManagement.JobTimeout == 0 {
+		s.BackupManagement.JobTimeout = 24 * time.Hour
+	}
+
 	// AWSInstanceChecked is false by default
 	// SSHKey is empty by default
 	// AlertManagerURL is empty by default
@@ -159,6 +343,7 @@ func (s *Settings) fillDefaults() {
 	// DBaaS.Enabled is false by default
 	// IntegratedAlerting.Enabled is false by default
 	// VictoriaMetrics CacheEnable is false by default
+	// VictoriaMetrics External is nil by default
 	// PMMPublicAddress is empty by default
 	// Azurediscover.Enabled is false by default
 }
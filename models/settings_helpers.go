@@ -17,13 +17,19 @@
 package models
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
 	"gopkg.in/reform.v1"
 
 	"github.com/percona/pmm-managed/utils/validators"
@@ -102,6 +108,16 @@ type ChangeSettingsParams struct {
 	// If true removes Slack alerting settings.
 	RemoveSlackAlertingSettings bool
 
+	// Remote VMAlert config for Integrated Alerting.
+	VMAlertEndpoint *AlertingEndpoint
+	// If true, local VMAlert is used again.
+	RemoveVMAlertEndpoint bool
+
+	// Remote Alertmanager config for Integrated Alerting.
+	AlertmanagerEndpoint *AlertingEndpoint
+	// If true, local Alertmanager is used again.
+	RemoveAlertmanagerEndpoint bool
+
 	// Percona Platform user email
 	Email string
 	// Percona Platform session Id
@@ -113,6 +129,35 @@ type ChangeSettingsParams struct {
 	EnableVMCache bool
 	// DisableVMCache disables caching for vmdb search queries
 	DisableVMCache bool
+	// EnableFileSD enables file-based service discovery for Agents' scrape targets
+	EnableFileSD bool
+	// DisableFileSD disables file-based service discovery for Agents' scrape targets
+	DisableFileSD bool
+
+	// External metrics backend to use instead of local VictoriaMetrics.
+	ExternalVictoriaMetrics *ExternalVictoriaMetrics
+	// If true, local VictoriaMetrics is used again.
+	RemoveExternalVictoriaMetrics bool
+
+	// Remote storages receiving copies of collected metrics; replaces existing ones.
+	RemoteWriteEndpoints []*RemoteWriteEndpoint
+	// If true, metrics are not sent to remote storages.
+	RemoveRemoteWriteEndpoints bool
+
+	// Downsampling periods of local VictoriaMetrics; replaces existing ones.
+	Downsampling []*DownsamplingPeriod
+	// If true, downsampling is disabled.
+	RemoveDownsampling bool
+
+	// Path where scrape configuration is written without reloading (read-only external mode).
+	ExternalConfigPath string
+	// If true, configuration is written and reloaded by pmm-managed again.
+	RemoveExternalConfigPath bool
+
+	// Global external labels; replaces existing ones.
+	ExternalLabels map[string]string
+	// If true, external labels are removed.
+	RemoveExternalLabels bool
 
 	// PMM Server public address.
 	PMMPublicAddress       string
@@ -122,6 +167,28 @@ type ChangeSettingsParams struct {
 	EnableBackupManagement bool
 	// Disable Backup Management features.
 	DisableBackupManagement bool
+	// Maximal number of backup jobs running at the same time; 0 removes the limit.
+	BackupMaxParallelJobs *int
+	// Maximal number of backup jobs running at the same time on a single pmm-agent; 0 removes the limit.
+	BackupMaxParallelJobsPerAgent *int
+	// Maximal number of backup and restore jobs running at the same time on a single Node; 0 removes the limit.
+	BackupMaxParallelJobsPerNode *int
+	// Allow or disallow backup and restore jobs running at the same time on the same Node.
+	BackupAllowConcurrentRestore *bool
+	// Default backup job timeout; 0 resets it to default.
+	BackupJobTimeout *time.Duration
+	// Armored GPG private key used to sign backup artifacts.
+	BackupSigningKey string
+	// If true, backup artifacts are not signed.
+	RemoveBackupSigningKey bool
+
+	// Usage accounting settings; replaced as a whole.
+	UsageSettings *UsageSettings
+
+	// SIEM collector settings; replaced as a whole.
+	SIEM *SIEMSettings
+	// If true, events are not forwarded to SIEM collector.
+	RemoveSIEM bool
 }
 
 // UpdateSettings updates only non-zero, non-empty values.
@@ -238,6 +305,49 @@ func UpdateSettings(q reform.DBTX, params *ChangeSettingsParams) (*Settings, err
 		settings.VictoriaMetrics.CacheEnabled = true
 	}
 
+	if params.DisableFileSD {
+		settings.VictoriaMetrics.FileSD = false
+	}
+
+	if params.EnableFileSD {
+		settings.VictoriaMetrics.FileSD = true
+	}
+
+	if params.ExternalVictoriaMetrics != nil {
+		settings.VictoriaMetrics.External = params.ExternalVictoriaMetrics
+	}
+	if params.RemoveExternalVictoriaMetrics {
+		settings.VictoriaMetrics.External = nil
+	}
+
+	if params.RemoteWriteEndpoints != nil {
+		settings.VictoriaMetrics.RemoteWrite = params.RemoteWriteEndpoints
+	}
+	if params.RemoveRemoteWriteEndpoints {
+		settings.VictoriaMetrics.RemoteWrite = nil
+	}
+
+	if params.Downsampling != nil {
+		settings.VictoriaMetrics.Downsampling = params.Downsampling
+	}
+	if params.RemoveDownsampling {
+		settings.VictoriaMetrics.Downsampling = nil
+	}
+
+	if params.ExternalConfigPath != "" {
+		settings.VictoriaMetrics.ExternalConfigPath = params.ExternalConfigPath
+	}
+	if params.RemoveExternalConfigPath {
+		settings.VictoriaMetrics.ExternalConfigPath = ""
+	}
+
+	if params.ExternalLabels != nil {
+		settings.VictoriaMetrics.ExternalLabels = params.ExternalLabels
+	}
+	if params.RemoveExternalLabels {
+		settings.VictoriaMetrics.ExternalLabels = nil
+	}
+
 	if params.PMMPublicAddress != "" {
 		settings.PMMPublicAddress = params.PMMPublicAddress
 	}
@@ -276,6 +386,19 @@ func UpdateSettings(q reform.DBTX, params *ChangeSettingsParams) (*Settings, err
 		settings.IntegratedAlerting.SlackAlertingSettings = params.SlackAlertingSettings
 	}
 
+	if params.VMAlertEndpoint != nil {
+		settings.IntegratedAlerting.VMAlert = params.VMAlertEndpoint
+	}
+	if params.RemoveVMAlertEndpoint {
+		settings.IntegratedAlerting.VMAlert = nil
+	}
+	if params.AlertmanagerEndpoint != nil {
+		settings.IntegratedAlerting.Alertmanager = params.AlertmanagerEndpoint
+	}
+	if params.RemoveAlertmanagerEndpoint {
+		settings.IntegratedAlerting.Alertmanager = nil
+	}
+
 	if params.DisableBackupManagement {
 		settings.BackupManagement.Enabled = false
 	}
@@ -284,6 +407,39 @@ func UpdateSettings(q reform.DBTX, params *ChangeSettingsParams) (*Settings, err
 		settings.BackupManagement.Enabled = true
 	}
 
+	if params.BackupMaxParallelJobs != nil {
+		settings.BackupManagement.MaxParallelJobs = *params.BackupMaxParallelJobs
+	}
+	if params.BackupMaxParallelJobsPerAgent != nil {
+		settings.BackupManagement.MaxParallelJobsPerAgent = *params.BackupMaxParallelJobsPerAgent
+	}
+	if params.BackupMaxParallelJobsPerNode != nil {
+		settings.BackupManagement.MaxParallelJobsPerNode = *params.BackupMaxParallelJobsPerNode
+	}
+	if params.BackupAllowConcurrentRestore != nil {
+		settings.BackupManagement.AllowConcurrentRestore = *params.BackupAllowConcurrentRestore
+	}
+	if params.BackupJobTimeout != nil {
+		settings.BackupManagement.JobTimeout = *params.BackupJobTimeout
+	}
+	if params.BackupSigningKey != "" {
+		settings.BackupManagement.SigningKey = params.BackupSigningKey
+	}
+	if params.RemoveBackupSigningKey {
+		settings.BackupManagement.SigningKey = ""
+	}
+
+	if params.UsageSettings != nil {
+		settings.Usage = *params.UsageSettings
+	}
+
+	if params.SIEM != nil {
+		settings.SIEM = params.SIEM
+	}
+	if params.RemoveSIEM {
+		settings.SIEM = nil
+	}
+
 	err = SaveSettings(q, settings)
 	if err != nil {
 		return nil, err
@@ -302,39 +458,21 @@ func ValidateSettings(params *ChangeSettingsParams) error {
 	if params.EnableVMCache && params.DisableVMCache {
 		return fmt.Errorf("Both enable_vm_cache and disable_vm_cache are present.") //nolint:golint,stylecheck
 	}
+	if params.EnableFileSD && params.DisableFileSD {
+		return fmt.Errorf("Both enable_file_sd and disable_file_sd are present.") //nolint:golint,stylecheck
+	}
 	if params.EnableAlerting && params.DisableAlerting {
 		return fmt.Errorf("Both enable_alerting and disable_alerting are present.") //nolint:golint,stylecheck
 	}
 	if params.EnableBackupManagement && params.DisableBackupManagement {
 		return fmt.Errorf("Both enable_backup_management and disable_backup_management are present.") //nolint:golint,stylecheck
 	}
-	// TODO: consider refactoring this and the validation for STT check intervals
-	checkCases := []struct {
-		dur       time.Duration
-		fieldName string
-	}{
-		{params.MetricsResolutions.HR, "hr"},
-		{params.MetricsResolutions.MR, "mr"},
-		{params.MetricsResolutions.LR, "lr"},
-	}
-	for _, v := range checkCases {
-		if v.dur == 0 {
-			continue
-		}
-
-		if _, err := validators.ValidateMetricResolution(v.dur); err != nil {
-			switch err.(type) {
-			case validators.DurationNotAllowedError:
-				return fmt.Errorf("%s: should be a natural number of seconds", v.fieldName)
-			case validators.MinDurationError:
-				return fmt.Errorf("%s: minimal resolution is 1s", v.fieldName)
-			default:
-				return fmt.Errorf("%s: unknown error for", v.fieldName)
-			}
-		}
+	if err := validateMetricsResolutions(params.MetricsResolutions); err != nil {
+		return err
 	}
 
-	checkCases = []struct {
+	// TODO: consider refactoring this and the validation for STT check intervals
+	checkCases := []struct {
 		dur       time.Duration
 		fieldName string
 	}{
@@ -409,9 +547,349 @@ func ValidateSettings(params *ChangeSettingsParams) error {
 	if params.SlackAlertingSettings != nil && params.RemoveSlackAlertingSettings {
 		return fmt.Errorf("Both slack_alerting_settings and remove_slack_alerting_settings are present.") //nolint:golint,stylecheck
 	}
+
+	if params.ExternalVictoriaMetrics != nil {
+		if params.RemoveExternalVictoriaMetrics {
+			return fmt.Errorf("Both external_victoria_metrics and remove_external_victoria_metrics are present.") //nolint:golint,stylecheck
+		}
+		if err = validateExternalURL("read_url", params.ExternalVictoriaMetrics.ReadURL); err != nil {
+			return err
+		}
+		if err = validateExternalURL("write_url", params.ExternalVictoriaMetrics.WriteURL); err != nil {
+			return err
+		}
+		if params.ExternalVictoriaMetrics.Password != "" && params.ExternalVictoriaMetrics.Username == "" {
+			return fmt.Errorf("Invalid external_victoria_metrics: password is set without username.") //nolint:golint,stylecheck
+		}
+	}
+
+	if params.RemoteWriteEndpoints != nil {
+		if params.RemoveRemoteWriteEndpoints {
+			return fmt.Errorf("Both remote_write and remove_remote_write are present.") //nolint:golint,stylecheck
+		}
+		if err = validateRemoteWriteEndpoints(params.RemoteWriteEndpoints); err != nil {
+			return err
+		}
+	}
+
+	if params.Downsampling != nil {
+		if params.RemoveDownsampling {
+			return fmt.Errorf("Both downsampling and remove_downsampling are present.") //nolint:golint,stylecheck
+		}
+		if err = validateDownsampling(params.Downsampling); err != nil {
+			return err
+		}
+	}
+
+	if params.ExternalConfigPath != "" {
+		if params.RemoveExternalConfigPath {
+			return fmt.Errorf("Both external_config_path and remove_external_config_path are present.") //nolint:golint,stylecheck
+		}
+		if !filepath.IsAbs(params.ExternalConfigPath) {
+			return fmt.Errorf("Invalid external_config_path: %q is not an absolute path.", params.ExternalConfigPath) //nolint:golint,stylecheck
+		}
+	}
+
+	if params.ExternalLabels != nil {
+		if params.RemoveExternalLabels {
+			return fmt.Errorf("Both external_labels and remove_external_labels are present.") //nolint:golint,stylecheck
+		}
+		if err = validateExternalLabels(params.ExternalLabels); err != nil {
+			return err
+		}
+	}
+
+	if params.VMAlertEndpoint != nil {
+		if params.RemoveVMAlertEndpoint {
+			return fmt.Errorf("Both vmalert_endpoint and remove_vmalert_endpoint are present.") //nolint:golint,stylecheck
+		}
+		if err = validateAlertingEndpoint("vmalert_endpoint", params.VMAlertEndpoint); err != nil {
+			return err
+		}
+	}
+	if params.AlertmanagerEndpoint != nil {
+		if params.RemoveAlertmanagerEndpoint {
+			return fmt.Errorf("Both alertmanager_endpoint and remove_alertmanager_endpoint are present.") //nolint:golint,stylecheck
+		}
+		if err = validateAlertingEndpoint("alertmanager_endpoint", params.AlertmanagerEndpoint); err != nil {
+			return err
+		}
+	}
+
+	if params.BackupMaxParallelJobs != nil && *params.BackupMaxParallelJobs < 0 {
+		return fmt.Errorf("backup_max_parallel_jobs: should be a non-negative number") //nolint:golint,stylecheck
+	}
+	if params.BackupMaxParallelJobsPerAgent != nil && *params.BackupMaxParallelJobsPerAgent < 0 {
+		return fmt.Errorf("backup_max_parallel_jobs_per_agent: should be a non-negative number") //nolint:golint,stylecheck
+	}
+	if params.BackupMaxParallelJobsPerNode != nil && *params.BackupMaxParallelJobsPerNode < 0 {
+		return fmt.Errorf("backup_max_parallel_jobs_per_node: should be a non-negative number") //nolint:golint,stylecheck
+	}
+	if params.BackupJobTimeout != nil && *params.BackupJobTimeout < 0 {
+		return fmt.Errorf("backup_job_timeout: should be a non-negative duration") //nolint:golint,stylecheck
+	}
+	if params.BackupSigningKey != "" {
+		if params.RemoveBackupSigningKey {
+			return fmt.Errorf("Both backup_signing_key and remove_backup_signing_key are present.") //nolint:golint,stylecheck
+		}
+		if _, err = ParseBackupSigningKey(params.BackupSigningKey); err != nil {
+			return fmt.Errorf("Invalid backup_signing_key: %s.", err) //nolint:golint,stylecheck
+		}
+	}
+
+	if u := params.UsageSettings; u != nil {
+		if u.WebhookURL != "" {
+			if err = validateExternalURL("usage webhook_url", u.WebhookURL); err != nil {
+				return err
+			}
+		}
+		if u.NodesThreshold < 0 || u.ServicesThreshold < 0 {
+			return fmt.Errorf("usage thresholds: should be non-negative numbers") //nolint:golint,stylecheck
+		}
+	}
+
+	if params.SIEM != nil {
+		if params.RemoveSIEM {
+			return fmt.Errorf("Both siem and remove_siem are present.") //nolint:golint,stylecheck
+		}
+		if err = validateSIEM(params.SIEM); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSIEM validates SIEM collector settings.
+func validateSIEM(s *SIEMSettings) error {
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return fmt.Errorf("Invalid siem address %q: should be in host:port format.", s.Address) //nolint:golint,stylecheck
+	}
+
+	switch s.Protocol {
+	case SIEMProtocolUDP, SIEMProtocolTCP:
+		if s.CA != "" || s.ServerName != "" || s.InsecureSkipVerify {
+			return fmt.Errorf("Invalid siem settings: TLS options are set for %s protocol.", s.Protocol) //nolint:golint,stylecheck
+		}
+	case SIEMProtocolTLS:
+		if s.CA != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(s.CA)) {
+			return fmt.Errorf("Invalid siem ca: invalid CA certificate.") //nolint:golint,stylecheck
+		}
+	default:
+		return fmt.Errorf("Invalid siem protocol %q.", s.Protocol) //nolint:golint,stylecheck
+	}
+
+	switch s.Format {
+	case SIEMFormatSyslog, SIEMFormatCEF:
+	default:
+		return fmt.Errorf("Invalid siem format %q.", s.Format) //nolint:golint,stylecheck
+	}
+
+	return nil
+}
+
+// validateMetricsResolutions validates non-zero metrics resolutions.
+func validateMetricsResolutions(r MetricsResolutions) error {
+	checkCases := []struct {
+		dur       time.Duration
+		fieldName string
+	}{
+		{r.HR, "hr"},
+		{r.MR, "mr"},
+		{r.LR, "lr"},
+	}
+	for _, v := range checkCases {
+		if v.dur == 0 {
+			continue
+		}
+
+		if _, err := validators.ValidateMetricResolution(v.dur); err != nil {
+			switch err.(type) {
+			case validators.DurationNotAllowedError:
+				return fmt.Errorf("%s: should be a natural number of seconds", v.fieldName)
+			case validators.MinDurationError:
+				return fmt.Errorf("%s: minimal resolution is 1s", v.fieldName)
+			default:
+				return fmt.Errorf("%s: unknown error for", v.fieldName)
+			}
+		}
+	}
+	return nil
+}
+
+// validateExternalURL validates URL of external metrics backend.
+func validateExternalURL(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("Invalid %s: empty URL.", name) //nolint:golint,stylecheck
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %s.", name, err) //nolint:golint,stylecheck
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("Invalid %s: %s - unsupported protocol scheme.", name, value) //nolint:golint,stylecheck
+	}
+	if u.Host == "" {
+		return fmt.Errorf("Invalid %s: %s - missing host.", name, value) //nolint:golint,stylecheck
+	}
+	if u.User != nil {
+		return fmt.Errorf("Invalid %s: credentials should be set separately.", name) //nolint:golint,stylecheck
+	}
+	return nil
+}
+
+// validateAlertingEndpoint validates remote VMAlert or Alertmanager endpoint.
+// remoteWriteRelabelActions contains relabeling actions supported by VictoriaMetrics.
+var remoteWriteRelabelActions = map[string]struct{}{
+	"":          {},
+	"replace":   {},
+	"keep":      {},
+	"drop":      {},
+	"hashmod":   {},
+	"labelmap":  {},
+	"labeldrop": {},
+	"labelkeep": {},
+}
+
+// ParseBackupSigningKey parses armored GPG private key used to sign backup artifacts.
+func ParseBackupSigningKey(armored string) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) != 1 {
+		return nil, errors.Errorf("expected exactly one key, got %d", len(entities))
+	}
+
+	e := entities[0]
+	if e.PrivateKey == nil {
+		return nil, errors.New("private key is missing")
+	}
+	if e.PrivateKey.Encrypted {
+		return nil, errors.New("private key is protected by passphrase")
+	}
+	return e, nil
+}
+
+// validateRemoteWriteEndpoints validates remote storages receiving copies of collected metrics.
+func validateRemoteWriteEndpoints(endpoints []*RemoteWriteEndpoint) error {
+	names := make(map[string]struct{}, len(endpoints))
+	for i, e := range endpoints {
+		if e == nil {
+			return fmt.Errorf("Invalid remote_write[%d]: empty endpoint.", i) //nolint:golint,stylecheck
+		}
+		if e.Name == "" {
+			return fmt.Errorf("Invalid remote_write[%d]: name is empty.", i) //nolint:golint,stylecheck
+		}
+		if _, ok := names[e.Name]; ok {
+			return fmt.Errorf("Invalid remote_write: duplicate name %q.", e.Name) //nolint:golint,stylecheck
+		}
+		names[e.Name] = struct{}{}
+
+		name := fmt.Sprintf("remote_write %q", e.Name)
+		if err := validateExternalURL(name+" url", e.URL); err != nil {
+			return err
+		}
+		if e.Password != "" && e.Username == "" {
+			return fmt.Errorf("Invalid %s: password is set without username.", name) //nolint:golint,stylecheck
+		}
+		if e.BearerToken != "" && e.Username != "" {
+			return fmt.Errorf("Invalid %s: both bearer token and basic auth are set.", name) //nolint:golint,stylecheck
+		}
+		for _, r := range e.WriteRelabelConfigs {
+			if r == nil {
+				return fmt.Errorf("Invalid %s: empty relabeling rule.", name) //nolint:golint,stylecheck
+			}
+			if _, ok := remoteWriteRelabelActions[r.Action]; !ok {
+				return fmt.Errorf("Invalid %s: unknown relabeling action %q.", name, r.Action) //nolint:golint,stylecheck
+			}
+			if r.Regex != "" {
+				if _, err := regexp.Compile(r.Regex); err != nil {
+					return fmt.Errorf("Invalid %s: invalid relabeling regex %q.", name, r.Regex) //nolint:golint,stylecheck
+				}
+			}
+			if r.Action == "hashmod" && r.Modulus == 0 {
+				return fmt.Errorf("Invalid %s: hashmod relabeling requires non-zero modulus.", name) //nolint:golint,stylecheck
+			}
+		}
+	}
+	return nil
+}
+
+// validateExternalLabels validates global external labels.
+func validateExternalLabels(labels map[string]string) error {
+	for name, value := range labels {
+		if !labelNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("Invalid external_labels: invalid label name %q.", name) //nolint:golint,stylecheck
+		}
+		if value == "" {
+			return fmt.Errorf("Invalid external_labels: empty value of label %q.", name) //nolint:golint,stylecheck
+		}
+	}
 	return nil
 }
 
+// validateDownsampling validates VictoriaMetrics downsampling periods.
+func validateDownsampling(periods []*DownsamplingPeriod) error {
+	offsets := make(map[time.Duration]struct{}, len(periods))
+	for i, p := range periods {
+		if p == nil {
+			return fmt.Errorf("Invalid downsampling[%d]: empty period.", i) //nolint:golint,stylecheck
+		}
+		if p.Offset < time.Minute || p.Offset%time.Minute != 0 {
+			return fmt.Errorf("Invalid downsampling[%d]: offset should be a natural number of minutes.", i) //nolint:golint,stylecheck
+		}
+		if p.Interval < time.Second || p.Interval%time.Second != 0 {
+			return fmt.Errorf("Invalid downsampling[%d]: interval should be a natural number of seconds.", i) //nolint:golint,stylecheck
+		}
+		if p.Interval >= p.Offset {
+			return fmt.Errorf("Invalid downsampling[%d]: interval should be less than offset.", i) //nolint:golint,stylecheck
+		}
+		if _, ok := offsets[p.Offset]; ok {
+			return fmt.Errorf("Invalid downsampling: duplicate offset %s.", p.Offset) //nolint:golint,stylecheck
+		}
+		offsets[p.Offset] = struct{}{}
+	}
+	return nil
+}
+
+func validateAlertingEndpoint(name string, e *AlertingEndpoint) error {
+	if err := validateExternalURL(name+".url", e.URL); err != nil {
+		return err
+	}
+	if e.Password != "" && e.Username == "" {
+		return fmt.Errorf("Invalid %s: password is set without username.", name) //nolint:golint,stylecheck
+	}
+	if _, err := e.TLSConfig(); err != nil {
+		return fmt.Errorf("Invalid %s: %s.", name, err) //nolint:golint,stylecheck
+	}
+	return nil
+}
+
+// TLSConfig returns TLS configuration for connecting to the endpoint.
+func (e *AlertingEndpoint) TLSConfig() (*tls.Config, error) {
+	if (e.Cert == "") != (e.Key == "") {
+		return nil, errors.New("both client certificate and key should be set")
+	}
+
+	cfg := &tls.Config{
+		InsecureSkipVerify: e.InsecureSkipVerify, //nolint:gosec
+	}
+	if e.CACert != "" {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM([]byte(e.CACert)) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+	}
+	if e.Cert != "" {
+		cert, err := tls.X509KeyPair([]byte(e.Cert), []byte(e.Key))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
 func validateSettingsConflicts(params *ChangeSettingsParams, settings *Settings) error {
 	if params.EnableSTT && !params.EnableTelemetry && settings.Telemetry.Disabled {
 		return fmt.Errorf("Cannot enable STT while telemetry is disabled.") //nolint:golint,stylecheck
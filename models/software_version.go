@@ -51,6 +51,16 @@ func (sv SoftwareVersions) Value() (driver.Value, error) { return jsonValue(sv)
 // Scan implements database/sql.Scanner interface. Should be defined on the pointer.
 func (sv *SoftwareVersions) Scan(src interface{}) error { return jsonScan(sv, src) }
 
+// Version returns version of the given software or empty string if it is unknown.
+func (sv SoftwareVersions) Version(name SoftwareName) string {
+	for _, v := range sv {
+		if v.Name == name {
+			return v.Version
+		}
+	}
+	return ""
+}
+
 // ServiceSoftwareVersions represents service software versions.
 // It has a one-to-one relationship with the services table.
 //reform:service_software_versions
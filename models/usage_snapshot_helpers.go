@@ -0,0 +1,125 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+)
+
+// UsageSnapshotsFilter represents filters for usage snapshots list.
+type UsageSnapshotsFilter struct {
+	// Return only snapshots of days on or after that time.
+	From time.Time
+	// Return only snapshots of days on or before that time.
+	To time.Time
+}
+
+// FindUsageSnapshots returns usage snapshots satisfying filter, oldest first.
+func FindUsageSnapshots(q *reform.Querier, filter UsageSnapshotsFilter) ([]*UsageSnapshot, error) {
+	var conditions []string
+	var args []interface{}
+	if !filter.From.IsZero() {
+		args = append(args, UsageDate(filter.From))
+		conditions = append(conditions, "date >= "+q.Placeholder(len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, UsageDate(filter.To))
+		conditions = append(conditions, "date <= "+q.Placeholder(len(args)))
+	}
+
+	var tail string
+	if len(conditions) != 0 {
+		tail = "WHERE " + strings.Join(conditions, " AND ") + " "
+	}
+	tail += "ORDER BY date"
+
+	structs, err := q.SelectAllFrom(UsageSnapshotTable, tail, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*UsageSnapshot, len(structs))
+	for i, s := range structs {
+		res[i] = s.(*UsageSnapshot)
+	}
+	return res, nil
+}
+
+// FindLatestUsageSnapshot returns the latest stored usage snapshot, or nil if there are none.
+func FindLatestUsageSnapshot(q *reform.Querier) (*UsageSnapshot, error) {
+	s, err := q.SelectOneFrom(UsageSnapshotTable, "ORDER BY date DESC LIMIT 1")
+	switch err {
+	case nil:
+		return s.(*UsageSnapshot), nil
+	case reform.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+}
+
+// CountUsage returns current numbers of monitored Nodes and Services as a snapshot for the day of given time.
+// PMM Server's own Node and Services are not counted.
+func CountUsage(q *reform.Querier, t time.Time) (*UsageSnapshot, error) {
+	nodes, err := q.Count(NodeTable, "WHERE node_id <> $1", PMMServerNodeID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	rows, err := q.Query("SELECT service_type, COUNT(*) FROM services WHERE node_id <> $1 GROUP BY service_type", PMMServerNodeID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	res := &UsageSnapshot{
+		Date:         UsageDate(t),
+		Nodes:        nodes,
+		ServiceTypes: make(UsageServiceTypes),
+	}
+	for rows.Next() {
+		var serviceType ServiceType
+		var n int
+		if err = rows.Scan(&serviceType, &n); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res.ServiceTypes[serviceType] = n
+		res.Services += n
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+// SaveUsageSnapshot stores usage snapshot, replacing the one of the same day, if any.
+func SaveUsageSnapshot(q *reform.Querier, s *UsageSnapshot) error {
+	s.Date = UsageDate(s.Date)
+	if err := q.Save(s); err != nil {
+		return errors.Wrap(err, "failed to save usage snapshot")
+	}
+	return nil
+}
+
+// UsageDate returns the start of the day (UTC) of given time.
+func UsageDate(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
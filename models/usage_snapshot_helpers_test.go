@@ -0,0 +1,99 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestUsageSnapshots(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, tx.Rollback())
+	})
+	q := tx.Querier
+
+	node, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{NodeName: "node"})
+	require.NoError(t, err)
+	for _, name := range []string{"mysql1", "mysql2"} {
+		_, err = models.AddNewService(q, models.MySQLServiceType, &models.AddDBMSServiceParams{
+			ServiceName: name,
+			NodeID:      node.NodeID,
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16(3306),
+		})
+		require.NoError(t, err)
+	}
+
+	latest, err := models.FindLatestUsageSnapshot(q)
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	day1 := time.Date(2021, 8, 19, 15, 4, 5, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	s, err := models.CountUsage(q, day1)
+	require.NoError(t, err)
+	assert.Equal(t, models.UsageDate(day1), s.Date)
+	assert.Equal(t, 1, s.Nodes)
+	assert.Equal(t, 2, s.Services)
+	assert.Equal(t, models.UsageServiceTypes{models.MySQLServiceType: 2}, s.ServiceTypes)
+	require.NoError(t, models.SaveUsageSnapshot(q, s))
+
+	// the same day is replaced
+	s.Nodes = 5
+	require.NoError(t, models.SaveUsageSnapshot(q, s))
+
+	s, err = models.CountUsage(q, day2)
+	require.NoError(t, err)
+	require.NoError(t, models.SaveUsageSnapshot(q, s))
+
+	snapshots, err := models.FindUsageSnapshots(q, models.UsageSnapshotsFilter{})
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, models.UsageDate(day1), snapshots[0].Date)
+	assert.Equal(t, 5, snapshots[0].Nodes)
+	assert.Equal(t, models.UsageDate(day2), snapshots[1].Date)
+	assert.Equal(t, 1, snapshots[1].Nodes)
+
+	snapshots, err = models.FindUsageSnapshots(q, models.UsageSnapshotsFilter{From: day2})
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, models.UsageDate(day2), snapshots[0].Date)
+
+	latest, err = models.FindLatestUsageSnapshot(q)
+	require.NoError(t, err)
+	assert.Equal(t, models.UsageDate(day2), latest.Date)
+}
@@ -0,0 +1,72 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate reform
+
+// UsageServiceTypes contains numbers of monitored Services by type.
+type UsageServiceTypes map[ServiceType]int
+
+// Value implements database/sql/driver.Valuer interface. Should be defined on the value.
+func (t UsageServiceTypes) Value() (driver.Value, error) { return jsonValue(t) }
+
+// Scan implements database/sql.Scanner interface. Should be defined on the pointer.
+func (t *UsageServiceTypes) Scan(src interface{}) error { return jsonScan(t, src) }
+
+// UsageSnapshot represents numbers of monitored Nodes and Services on a single day (UTC), for license accounting.
+// PMM Server's own Node and Services are not counted.
+//reform:usage_snapshots
+type UsageSnapshot struct {
+	Date         time.Time         `reform:"date,pk"`
+	Nodes        int               `reform:"nodes"`
+	Services     int               `reform:"services"`
+	ServiceTypes UsageServiceTypes `reform:"service_types"`
+	UpdatedAt    time.Time         `reform:"updated_at"`
+}
+
+// BeforeInsert implements reform.BeforeInserter interface.
+func (s *UsageSnapshot) BeforeInsert() error {
+	s.UpdatedAt = Now()
+	return nil
+}
+
+// BeforeUpdate implements reform.BeforeUpdater interface.
+func (s *UsageSnapshot) BeforeUpdate() error {
+	s.UpdatedAt = Now()
+	return nil
+}
+
+// AfterFind implements reform.AfterFinder interface.
+func (s *UsageSnapshot) AfterFind() error {
+	s.Date = s.Date.UTC()
+	s.UpdatedAt = s.UpdatedAt.UTC()
+	return nil
+}
+
+// check interfaces.
+var (
+	_ reform.BeforeInserter = (*UsageSnapshot)(nil)
+	_ reform.BeforeUpdater  = (*UsageSnapshot)(nil)
+	_ reform.AfterFinder    = (*UsageSnapshot)(nil)
+)
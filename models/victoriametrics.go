@@ -31,6 +31,8 @@ type VictoriaMetricsParams struct {
 	VMAlertFlags []string
 	// BaseConfigPath defines path for basic prometheus config.
 	BaseConfigPath string
+	// ConfigValidator defines how scrape configuration is validated; empty value selects it automatically.
+	ConfigValidator string
 }
 
 // NewVictoriaMetricsParams - returns configuration params for VictoriaMetrics.
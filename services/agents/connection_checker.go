@@ -53,6 +53,8 @@ func NewConnectionChecker(r *Registry) *ConnectionChecker {
 }
 
 // CheckConnectionToService sends request to pmm-agent to check connection to service.
+// For MySQL, PostgreSQL and MongoDB, it also checks that server version is supported
+// and that monitoring user has required privileges.
 func (c *ConnectionChecker) CheckConnectionToService(ctx context.Context, q *reform.Querier, service *models.Service, agent *models.Agent) error {
 	l := logger.Get(ctx)
 	start := time.Now()
@@ -121,7 +123,7 @@ func (c *ConnectionChecker) CheckConnectionToService(ctx context.Context, q *ref
 	msg := resp.(*agentpb.CheckConnectionResponse).Error
 	switch msg {
 	case "":
-		return nil
+		return c.checkRequirements(ctx, q, service, agent, pmmAgentID)
 	case context.Canceled.Error(), context.DeadlineExceeded.Error():
 		msg = fmt.Sprintf("timeout (%s)", msg)
 	}
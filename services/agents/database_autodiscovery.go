@@ -0,0 +1,238 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona/pmm/api/agentpb"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	// listDatabasesQuery lists databases postgres_exporter could connect to; pmm-agent prepends SELECT.
+	listDatabasesQuery = `datname FROM pg_database WHERE datallowconn AND NOT datistemplate`
+
+	// databasesRelistInterval is an interval of databases re-listing, so new databases are handled.
+	databasesRelistInterval = time.Hour
+)
+
+// DatabaseAutodiscoveryService manages databases autodiscovery settings of PostgreSQL Services.
+// Databases are listed with PostgreSQL SELECT query Action, and postgres_exporter flags are pushed
+// to pmm-agent via desired state.
+type DatabaseAutodiscoveryService struct {
+	db      *reform.DB
+	r       *Registry
+	state   *StateUpdater
+	actions *ActionsService
+	l       *logrus.Entry
+}
+
+// NewDatabaseAutodiscoveryService creates new databases autodiscovery service.
+func NewDatabaseAutodiscoveryService(db *reform.DB, r *Registry, state *StateUpdater) *DatabaseAutodiscoveryService {
+	return &DatabaseAutodiscoveryService{
+		db:      db,
+		r:       r,
+		state:   state,
+		actions: NewActionsService(r),
+		l:       logrus.WithField("component", "database-autodiscovery"),
+	}
+}
+
+// Run periodically re-lists databases of Services which settings depend on them until ctx is canceled.
+func (s *DatabaseAutodiscoveryService) Run(ctx context.Context) {
+	s.l.Info("Starting...")
+	defer s.l.Info("Done.")
+
+	ticker := time.NewTicker(databasesRelistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.relist(ctx)
+		}
+	}
+}
+
+// Get returns databases autodiscovery settings of Service with given ID; nil means defaults.
+func (s *DatabaseAutodiscoveryService) Get(ctx context.Context, serviceID string) (*models.DatabaseAutodiscovery, error) {
+	var service *models.Service
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		service, err = models.FindServiceByID(tx.Querier, serviceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.DatabaseAutodiscovery, nil
+}
+
+// Change changes databases autodiscovery settings of Service with given ID, starts databases listing
+// if settings depend on them, and pushes new postgres_exporter flags.
+func (s *DatabaseAutodiscoveryService) Change(ctx context.Context, serviceID string, settings *models.DatabaseAutodiscovery) (*models.DatabaseAutodiscovery, error) {
+	var service *models.Service
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		service, err = models.ChangeDatabaseAutodiscovery(tx.Querier, serviceID, settings)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if settings != nil && settings.NeedsDatabases() {
+		if _, err = s.ListDatabases(ctx, serviceID); err != nil {
+			s.l.Warnf("Failed to list databases of %s: %s.", serviceID, err)
+		}
+	}
+
+	s.requestStateUpdate(ctx, serviceID)
+	return service.DatabaseAutodiscovery, nil
+}
+
+// ListDatabases starts databases listing of Service with given ID through postgres_exporter's pmm-agent.
+// It returns Action ID; databases are stored in Service's settings when Action is done.
+func (s *DatabaseAutodiscoveryService) ListDatabases(ctx context.Context, serviceID string) (string, error) {
+	var pmmAgentID, dsn string
+	var res *models.ActionResult
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		exporter, err := findPostgresExporter(tx.Querier, serviceID)
+		if err != nil {
+			return err
+		}
+		pmmAgentID = pointer.GetString(exporter.PMMAgentID)
+		if !s.r.IsConnected(pmmAgentID) {
+			return status.Errorf(codes.FailedPrecondition, "pmm-agent with ID %q is not connected.", pmmAgentID)
+		}
+
+		if dsn, _, err = models.FindDSNByServiceIDandPMMAgentID(tx.Querier, serviceID, pmmAgentID, "postgres"); err != nil {
+			return err
+		}
+
+		if res, err = models.CreateActionResult(tx.Querier, pmmAgentID); err != nil {
+			return err
+		}
+		_, err = models.StartDatabasesListing(tx.Querier, serviceID, res.ID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.actions.StartPostgreSQLQuerySelectAction(ctx, res.ID, pmmAgentID, dsn, listDatabasesQuery); err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// relist starts databases listing for all Services which settings depend on them.
+func (s *DatabaseAutodiscoveryService) relist(ctx context.Context) {
+	serviceType := models.PostgreSQLServiceType
+	services, err := models.FindServices(s.db.Querier, models.ServiceFilters{ServiceType: &serviceType})
+	if err != nil {
+		s.l.Errorf("%+v", err)
+		return
+	}
+
+	for _, service := range services {
+		if d := service.DatabaseAutodiscovery; d == nil || !d.NeedsDatabases() {
+			continue
+		}
+		if _, err = s.ListDatabases(ctx, service.ServiceID); err != nil {
+			s.l.Debugf("Failed to list databases of %s: %s.", service.ServiceID, err)
+		}
+	}
+}
+
+// requestStateUpdate pushes desired state to pmm-agent running postgres_exporter of Service with given ID.
+func (s *DatabaseAutodiscoveryService) requestStateUpdate(ctx context.Context, serviceID string) {
+	exporter, err := findPostgresExporter(s.db.Querier, serviceID)
+	if err != nil {
+		s.l.Warnf("Failed to find postgres_exporter of %s: %s.", serviceID, err)
+		return
+	}
+	s.state.RequestStateUpdate(ctx, pointer.GetString(exporter.PMMAgentID))
+}
+
+// findPostgresExporter returns postgres_exporter of Service with given ID.
+func findPostgresExporter(q *reform.Querier, serviceID string) (*models.Agent, error) {
+	agentType := models.PostgresExporterType
+	agents, err := models.FindAgents(q, models.AgentFilters{ServiceID: serviceID, AgentType: &agentType})
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "No postgres_exporter for Service with ID %q.", serviceID)
+	}
+	return agents[0], nil
+}
+
+// handleDatabasesListingActionResult stores databases if finished Action with given ID was started by ListDatabases.
+// It returns ID of pmm-agent which state should be updated, or empty string.
+func handleDatabasesListingActionResult(q *reform.Querier, actionID, output, actionError string) (string, error) {
+	var databases []string
+	if actionError == "" {
+		var err error
+		if databases, err = parseDatabasesListing([]byte(output)); err != nil {
+			return "", err
+		}
+	}
+
+	service, err := models.FinishDatabasesListing(q, actionID, databases)
+	if err != nil || service == nil {
+		return "", err
+	}
+
+	exporter, err := findPostgresExporter(q, service.ServiceID)
+	if err != nil {
+		return "", err
+	}
+	return pointer.GetString(exporter.PMMAgentID), nil
+}
+
+// parseDatabasesListing extracts sorted database names from PostgreSQL SELECT query Action output.
+func parseDatabasesListing(output []byte) ([]string, error) {
+	rows, err := agentpb.UnmarshalActionQueryResult(output)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]string, 0, len(rows))
+	for _, row := range rows {
+		name, ok := row["datname"]
+		if !ok {
+			return nil, errors.Errorf("unexpected databases listing row: %v", row)
+		}
+		res = append(res, fmt.Sprint(name))
+	}
+	sort.Strings(res)
+	return res, nil
+}
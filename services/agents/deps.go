@@ -20,6 +20,8 @@ import (
 	"context"
 
 	"github.com/percona/pmm/api/agentpb"
+
+	"github.com/percona/pmm-managed/models"
 )
 
 // prometheusService is a subset of methods of victoriametrics.Service used by this package.
@@ -42,3 +44,33 @@ type qanClient interface {
 type retentionService interface {
 	EnforceRetention(ctx context.Context, scheduleID string) error
 }
+
+// notificationService is a subset of methods of backup.NotificationService used by this package.
+// We use it instead of real type to avoid dependency cycle.
+type notificationService interface {
+	NotifyJobFinished(ctx context.Context, jobID string) error
+}
+
+// usageService is a subset of methods of backup.UsageService used by this package.
+// We use it instead of real type to avoid dependency cycle.
+type usageService interface {
+	RecordArtifactSize(ctx context.Context, artifactID string) error
+}
+
+// signingService is a subset of methods of backup.SigningService used by this package.
+// We use it instead of real type to avoid dependency cycle.
+type signingService interface {
+	SignArtifact(ctx context.Context, artifactID string) error
+}
+
+// configSnapshotService is a subset of methods of backup.ConfigSnapshotService used by this package.
+// We use it instead of real type to avoid dependency cycle.
+type configSnapshotService interface {
+	StoreSnapshot(ctx context.Context, artifactID, summary string) error
+}
+
+// mysqlDumpService is a subset of methods of backup.MySQLDumpService used by this package.
+// We use it instead of real type to avoid dependency cycle.
+type mysqlDumpService interface {
+	HandleActionResult(ctx context.Context, job *models.JobResult, output string) (bool, error)
+}
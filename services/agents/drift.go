@@ -0,0 +1,209 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona/pmm/api/inventorypb"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/logger"
+)
+
+const (
+	driftCheckInterval = time.Minute
+
+	// driftGracePeriod gives pmm-agent time to apply SetState and report Agents status.
+	driftGracePeriod = 2 * time.Minute
+)
+
+// Desired states of Agents.
+const (
+	desiredRunning = "running"
+	desiredStopped = "stopped"
+)
+
+// AgentDrift describes a difference between desired Agent state and the last state reported by pmm-agent.
+type AgentDrift struct {
+	PMMAgentID string           `json:"pmm_agent_id"`
+	AgentID    string           `json:"agent_id"`
+	AgentType  models.AgentType `json:"agent_type"`
+	Desired    string           `json:"desired"`
+	Reported   string           `json:"reported"`
+	// Time of the last Agent change, including reported status.
+	Since time.Time `json:"since"`
+}
+
+// DriftReconciler periodically compares desired state of Agents with the state reported by connected pmm-agents,
+// and optionally resends SetState requests to pmm-agents with drifted Agents.
+type DriftReconciler struct {
+	db          *reform.DB
+	r           *Registry
+	state       *StateUpdater
+	autoCorrect bool
+	l           *logrus.Entry
+
+	rw    sync.RWMutex
+	drift []*AgentDrift
+
+	mDrifted *prom.GaugeVec
+}
+
+// NewDriftReconciler creates new Agents state drift reconciler.
+// If autoCorrect is true, SetState is resent to pmm-agents with drifted Agents.
+func NewDriftReconciler(db *reform.DB, r *Registry, state *StateUpdater, autoCorrect bool) *DriftReconciler {
+	return &DriftReconciler{
+		db:          db,
+		r:           r,
+		state:       state,
+		autoCorrect: autoCorrect,
+		l:           logrus.WithField("component", "agents/drift"),
+
+		mDrifted: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "drifted",
+			Help:      "A number of Agents with reported state different from the desired one.",
+		}, []string{"pmm_agent_id"}),
+	}
+}
+
+// Run checks Agents state drift until context is canceled.
+func (d *DriftReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(driftCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		d.reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drift returns drifted Agents found by the last check.
+func (d *DriftReconciler) Drift() []*AgentDrift {
+	d.rw.RLock()
+	defer d.rw.RUnlock()
+
+	return d.drift
+}
+
+// reconcile finds drifted Agents of all connected pmm-agents and updates metrics.
+func (d *DriftReconciler) reconcile(ctx context.Context) {
+	agentType := models.PMMAgentType
+	pmmAgents, err := models.FindAgents(d.db.Querier, models.AgentFilters{AgentType: &agentType})
+	if err != nil {
+		d.l.Error(err)
+		return
+	}
+
+	now := time.Now()
+	var drift []*AgentDrift
+	d.mDrifted.Reset()
+	for _, pmmAgent := range pmmAgents {
+		if !d.r.IsConnected(pmmAgent.AgentID) {
+			continue
+		}
+
+		agents, err := models.FindAgents(d.db.Querier, models.AgentFilters{PMMAgentID: pmmAgent.AgentID})
+		if err != nil {
+			d.l.Error(err)
+			continue
+		}
+
+		agentDrift := findDrift(agents, now)
+		d.mDrifted.WithLabelValues(pmmAgent.AgentID).Set(float64(len(agentDrift)))
+		if len(agentDrift) == 0 {
+			continue
+		}
+		drift = append(drift, agentDrift...)
+
+		if d.autoCorrect {
+			d.l.Infof("pmm-agent %s has %d drifted Agents, resending state.", pmmAgent.AgentID, len(agentDrift))
+			d.state.RequestStateUpdate(logger.Set(ctx, "drift"), pmmAgent.AgentID)
+		}
+	}
+
+	d.rw.Lock()
+	d.drift = drift
+	d.rw.Unlock()
+}
+
+// findDrift returns Agents of a single pmm-agent with reported state different from the desired one
+// for longer than grace period.
+func findDrift(agents []*models.Agent, now time.Time) []*AgentDrift {
+	running := inventorypb.AgentStatus_RUNNING.String()
+
+	var res []*AgentDrift
+	for _, agent := range agents {
+		switch agent.AgentType {
+		case models.PMMAgentType, models.ExternalExporterType:
+			// not run by pmm-agent
+			continue
+		}
+		if now.Sub(agent.UpdatedAt) < driftGracePeriod {
+			continue
+		}
+
+		desired := desiredRunning
+		if agent.Disabled {
+			desired = desiredStopped
+		}
+		if (desired == desiredRunning) == (agent.Status == running) {
+			continue
+		}
+
+		res = append(res, &AgentDrift{
+			PMMAgentID: pointer.GetString(agent.PMMAgentID),
+			AgentID:    agent.AgentID,
+			AgentType:  agent.AgentType,
+			Desired:    desired,
+			Reported:   agent.Status,
+			Since:      agent.UpdatedAt,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].AgentID < res[j].AgentID })
+	return res
+}
+
+// Describe implements prometheus.Collector.
+func (d *DriftReconciler) Describe(ch chan<- *prom.Desc) {
+	d.mDrifted.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (d *DriftReconciler) Collect(ch chan<- prom.Metric) {
+	d.mDrifted.Collect(ch)
+}
+
+// check interfaces.
+var (
+	_ prom.Collector = (*DriftReconciler)(nil)
+)
@@ -0,0 +1,91 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestFindDrift(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	agents := []*models.Agent{{
+		AgentID:   "pmm-agent",
+		AgentType: models.PMMAgentType,
+		UpdatedAt: old,
+	}, {
+		AgentID:    "/agent_id/running",
+		AgentType:  models.NodeExporterType,
+		PMMAgentID: pointer.ToString("pmm-agent"),
+		Status:     "RUNNING",
+		UpdatedAt:  old,
+	}, {
+		AgentID:    "/agent_id/waiting",
+		AgentType:  models.MySQLdExporterType,
+		PMMAgentID: pointer.ToString("pmm-agent"),
+		Status:     "WAITING",
+		UpdatedAt:  old,
+	}, {
+		AgentID:    "/agent_id/starting",
+		AgentType:  models.MongoDBExporterType,
+		PMMAgentID: pointer.ToString("pmm-agent"),
+		Status:     "STARTING",
+		UpdatedAt:  now.Add(-time.Second),
+	}, {
+		AgentID:    "/agent_id/disabled",
+		AgentType:  models.QANMySQLSlowlogAgentType,
+		PMMAgentID: pointer.ToString("pmm-agent"),
+		Disabled:   true,
+		Status:     "RUNNING",
+		UpdatedAt:  old,
+	}, {
+		AgentID:    "/agent_id/stopped",
+		AgentType:  models.QANMySQLPerfSchemaAgentType,
+		PMMAgentID: pointer.ToString("pmm-agent"),
+		Disabled:   true,
+		Status:     "DONE",
+		UpdatedAt:  old,
+	}, {
+		AgentID:    "/agent_id/external",
+		AgentType:  models.ExternalExporterType,
+		PMMAgentID: pointer.ToString("pmm-agent"),
+		UpdatedAt:  old,
+	}}
+
+	expected := []*AgentDrift{{
+		PMMAgentID: "pmm-agent",
+		AgentID:    "/agent_id/disabled",
+		AgentType:  models.QANMySQLSlowlogAgentType,
+		Desired:    desiredStopped,
+		Reported:   "RUNNING",
+		Since:      old,
+	}, {
+		PMMAgentID: "pmm-agent",
+		AgentID:    "/agent_id/waiting",
+		AgentType:  models.MySQLdExporterType,
+		Desired:    desiredRunning,
+		Reported:   "WAITING",
+		Since:      old,
+	}}
+	assert.Equal(t, expected, findDrift(agents, now))
+}
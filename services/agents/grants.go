@@ -0,0 +1,275 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/AlekSi/pointer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// defaultMonitoringUser is used in generated statements when user name is not given.
+const defaultMonitoringUser = "pmm"
+
+// GrantsFeatures represents PMM features the monitoring user needs privileges for.
+type GrantsFeatures struct {
+	// Query Analytics.
+	QAN bool `json:"qan"`
+	// Backup Management.
+	Backup bool `json:"backup"`
+	// Security Threat Tool checks; they need only monitoring privileges for now.
+	Checks bool `json:"checks"`
+}
+
+// GrantsCheckResult represents the result of monitoring user privileges check.
+type GrantsCheckResult struct {
+	// Missing privileges and roles in human-readable form.
+	Missing []string `json:"missing"`
+	// Statements granting all required privileges to the monitoring user.
+	Statements []string `json:"statements"`
+}
+
+// mysql80Version is the first MySQL version with dynamic privileges.
+var mysql80Version = serverVersion{8, 0}
+
+// MySQL privileges required for QAN and backups in addition to RequiredMySQLPrivileges.
+var (
+	mysqlQANPrivileges                  = []string{"RELOAD"}
+	mysqlQANPerformanceSchemaPrivileges = []string{"SELECT", "UPDATE", "DELETE", "DROP"}
+	mysqlBackupPrivileges               = []string{"RELOAD", "LOCK TABLES"}
+	mysqlBackupPrivileges80             = []string{"BACKUP_ADMIN"}
+)
+
+// MongoDB roles required for monitoring, QAN and backups.
+const (
+	mongoDBExplainRole   = "explainRole"
+	mongoDBPBMAnyRole    = "pbmAnyAction"
+	mongoDBExplainPolicy = `{resource: {db: "", collection: ""}, ` +
+		`actions: ["listIndexes", "listCollections", "dbStats", "dbHash", "collStats", "find"]}`
+	mongoDBPBMAnyPolicy = `{resource: {anyResource: true}, actions: ["anyAction"]}`
+)
+
+// mysqlGlobalPrivileges returns global MySQL privileges required for given features.
+func mysqlGlobalPrivileges(features GrantsFeatures, mysql80 bool) []string {
+	res := append([]string{}, RequiredMySQLPrivileges...)
+	add := func(privileges []string) {
+		for _, p := range privileges {
+			if !stringsContain(res, p) {
+				res = append(res, p)
+			}
+		}
+	}
+
+	if features.QAN {
+		add(mysqlQANPrivileges)
+	}
+	if features.Backup {
+		add(mysqlBackupPrivileges)
+		if mysql80 {
+			add(mysqlBackupPrivileges80)
+		}
+	}
+	return res
+}
+
+// RequiredGrants returns statements creating the monitoring user with the least privileges
+// required for given Service type and features.
+func RequiredGrants(serviceType models.ServiceType, username string, features GrantsFeatures) ([]string, error) {
+	if username == "" {
+		username = defaultMonitoringUser
+	}
+
+	switch serviceType {
+	case models.MySQLServiceType:
+		user := quoteMySQLUser(username)
+		res := []string{
+			fmt.Sprintf("CREATE USER IF NOT EXISTS %s IDENTIFIED BY '<password>' WITH MAX_USER_CONNECTIONS 10;", user),
+			fmt.Sprintf("GRANT %s ON *.* TO %s;", strings.Join(mysqlGlobalPrivileges(features, false), ", "), user),
+		}
+		if features.QAN {
+			res = append(res, fmt.Sprintf("GRANT %s ON performance_schema.* TO %s;", strings.Join(mysqlQANPerformanceSchemaPrivileges, ", "), user))
+		}
+		if features.Backup {
+			res = append(res, fmt.Sprintf("GRANT %s ON *.* TO %s; -- MySQL 8.0 and later", strings.Join(mysqlBackupPrivileges80, ", "), user))
+		}
+		return res, nil
+
+	case models.PostgreSQLServiceType:
+		if features.Backup {
+			return nil, status.Error(codes.InvalidArgument, "Backups are not supported for PostgreSQL.")
+		}
+		res := []string{
+			fmt.Sprintf("CREATE USER %q WITH PASSWORD '<password>';", username),
+			fmt.Sprintf("GRANT %s TO %q;", PostgreSQLMonitoringRole, username),
+		}
+		if features.QAN {
+			res = append(res, "CREATE EXTENSION IF NOT EXISTS pg_stat_statements SCHEMA public;")
+		}
+		return res, nil
+
+	case models.MongoDBServiceType:
+		var res []string
+		roles := []string{`{role: "clusterMonitor", db: "admin"}`, `{role: "read", db: "local"}`}
+		if features.QAN {
+			res = append(res, fmt.Sprintf(`db.getSiblingDB("admin").createRole({role: %q, privileges: [%s], roles: []});`,
+				mongoDBExplainRole, mongoDBExplainPolicy))
+			roles = append(roles, fmt.Sprintf(`{role: %q, db: "admin"}`, mongoDBExplainRole))
+		}
+		if features.Backup {
+			res = append(res, fmt.Sprintf(`db.getSiblingDB("admin").createRole({role: %q, privileges: [%s], roles: []});`,
+				mongoDBPBMAnyRole, mongoDBPBMAnyPolicy))
+			roles = append(roles,
+				`{role: "readWrite", db: "admin"}`,
+				`{role: "backup", db: "admin"}`,
+				`{role: "restore", db: "admin"}`,
+				fmt.Sprintf(`{role: %q, db: "admin"}`, mongoDBPBMAnyRole))
+		}
+		res = append(res, fmt.Sprintf(`db.getSiblingDB("admin").createUser({user: %q, pwd: "<password>", roles: [%s]});`,
+			username, strings.Join(roles, ", ")))
+		return res, nil
+
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Required grants are not available for %s Service type.", serviceType)
+	}
+}
+
+// CheckGrants checks that the monitoring user of the Service exporter has privileges required
+// for given features by running query Action on pmm-agent.
+func (c *ConnectionChecker) CheckGrants(ctx context.Context, serviceID string, features GrantsFeatures) (*GrantsCheckResult, error) {
+	q := c.r.db.Querier
+	service, err := models.FindServiceByID(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var agentType models.AgentType
+	switch service.ServiceType {
+	case models.MySQLServiceType:
+		agentType = models.MySQLdExporterType
+	case models.PostgreSQLServiceType:
+		agentType = models.PostgresExporterType
+	case models.MongoDBServiceType:
+		return nil, status.Error(codes.Unimplemented, "MongoDB roles can't be checked yet, use required grants instead.")
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Grants check is not supported for %s Service type.", service.ServiceType)
+	}
+
+	// validate features before running the check
+	if _, err = RequiredGrants(service.ServiceType, "", features); err != nil {
+		return nil, err
+	}
+
+	agents, err := models.FindAgents(q, models.AgentFilters{ServiceID: serviceID, AgentType: &agentType})
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "No %s found for Service %q.", agentType, serviceID)
+	}
+	agent := agents[0]
+	pmmAgentID := pointer.GetString(agent.PMMAgentID)
+
+	supported, err := requirementsCheckSupported(q, pmmAgentID)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		return nil, status.Errorf(codes.FailedPrecondition, "pmm-agent %s does not support grants check, minimal supported version is %s.",
+			pmmAgentID, requirementsCheckPMMAgentVersion)
+	}
+
+	rows, err := c.runQueryAction(ctx, pmmAgentID, requirementsRequest(service, agent))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, status.Error(codes.Internal, "Empty grants check result.")
+	}
+	row := rows[0]
+
+	res := new(GrantsCheckResult)
+	switch service.ServiceType {
+	case models.MySQLServiceType:
+		res.Missing = missingMySQLGrants(row, features)
+	case models.PostgreSQLServiceType:
+		res.Missing = missingPostgreSQLGrants(row, features)
+	}
+	if res.Statements, err = RequiredGrants(service.ServiceType, queryValue(row, "user"), features); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// missingMySQLGrants returns privileges required for given features that the current user does not have.
+func missingMySQLGrants(row map[string]interface{}, features GrantsFeatures) []string {
+	split := func(column string) map[string]struct{} {
+		res := make(map[string]struct{})
+		for _, p := range strings.Split(queryValue(row, column), ",") {
+			res[strings.TrimSpace(p)] = struct{}{}
+		}
+		return res
+	}
+	global := split("privileges")
+	performanceSchema := split("performance_schema_privileges")
+
+	v, err := parseServerVersion(queryValue(row, "version"))
+	mysql80 := err == nil && !v.less(mysql80Version)
+
+	res := []string{}
+	for _, p := range mysqlGlobalPrivileges(features, mysql80) {
+		if _, ok := global[p]; !ok {
+			res = append(res, p+" ON *.*")
+		}
+	}
+	if features.QAN {
+		for _, p := range mysqlQANPerformanceSchemaPrivileges {
+			_, ok := global[p]
+			if _, schemaOK := performanceSchema[p]; !ok && !schemaOK {
+				res = append(res, p+" ON performance_schema.*")
+			}
+		}
+	}
+	return res
+}
+
+// missingPostgreSQLGrants returns roles and extensions required for given features that are missing.
+func missingPostgreSQLGrants(row map[string]interface{}, features GrantsFeatures) []string {
+	res := []string{}
+	if queryValue(row, "superuser") != "true" && queryValue(row, "monitor") != "true" {
+		res = append(res, PostgreSQLMonitoringRole+" role")
+	}
+	if features.QAN && queryValue(row, "pg_stat_statements") != "true" {
+		res = append(res, "pg_stat_statements extension")
+	}
+	return res
+}
+
+// stringsContain returns true if slice contains given string.
+func stringsContain(slice []string, s string) bool {
+	for _, e := range slice {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestRequiredGrants(t *testing.T) {
+	t.Run("MySQL", func(t *testing.T) {
+		actual, err := RequiredGrants(models.MySQLServiceType, "pmm@localhost", GrantsFeatures{QAN: true, Backup: true})
+		require.NoError(t, err)
+		expected := []string{
+			"CREATE USER IF NOT EXISTS 'pmm'@'localhost' IDENTIFIED BY '<password>' WITH MAX_USER_CONNECTIONS 10;",
+			"GRANT SELECT, PROCESS, REPLICATION CLIENT, RELOAD, LOCK TABLES ON *.* TO 'pmm'@'localhost';",
+			"GRANT SELECT, UPDATE, DELETE, DROP ON performance_schema.* TO 'pmm'@'localhost';",
+			"GRANT BACKUP_ADMIN ON *.* TO 'pmm'@'localhost'; -- MySQL 8.0 and later",
+		}
+		assert.Equal(t, expected, actual)
+
+		actual, err = RequiredGrants(models.MySQLServiceType, "", GrantsFeatures{Checks: true})
+		require.NoError(t, err)
+		expected = []string{
+			"CREATE USER IF NOT EXISTS 'pmm' IDENTIFIED BY '<password>' WITH MAX_USER_CONNECTIONS 10;",
+			"GRANT SELECT, PROCESS, REPLICATION CLIENT ON *.* TO 'pmm';",
+		}
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		actual, err := RequiredGrants(models.PostgreSQLServiceType, "pmm", GrantsFeatures{QAN: true})
+		require.NoError(t, err)
+		expected := []string{
+			`CREATE USER "pmm" WITH PASSWORD '<password>';`,
+			`GRANT pg_monitor TO "pmm";`,
+			`CREATE EXTENSION IF NOT EXISTS pg_stat_statements SCHEMA public;`,
+		}
+		assert.Equal(t, expected, actual)
+
+		_, err = RequiredGrants(models.PostgreSQLServiceType, "pmm", GrantsFeatures{Backup: true})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Backups are not supported for PostgreSQL."), err)
+	})
+
+	t.Run("MongoDB", func(t *testing.T) {
+		actual, err := RequiredGrants(models.MongoDBServiceType, "pmm", GrantsFeatures{QAN: true})
+		require.NoError(t, err)
+		require.Len(t, actual, 2)
+		assert.Contains(t, actual[0], `createRole({role: "explainRole"`)
+		assert.Equal(t, `db.getSiblingDB("admin").createUser({user: "pmm", pwd: "<password>", roles: [`+
+			`{role: "clusterMonitor", db: "admin"}, {role: "read", db: "local"}, {role: "explainRole", db: "admin"}]});`, actual[1])
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := RequiredGrants(models.ExternalServiceType, "pmm", GrantsFeatures{})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Required grants are not available for external Service type."), err)
+	})
+}
+
+func TestMissingGrants(t *testing.T) {
+	t.Run("MySQL", func(t *testing.T) {
+		row := map[string]interface{}{
+			"version":                       []byte("8.0.26-16"),
+			"user":                          "pmm@localhost",
+			"privileges":                    []byte("SELECT,PROCESS,REPLICATION CLIENT,RELOAD"),
+			"performance_schema_privileges": []byte("UPDATE,DELETE"),
+		}
+		assert.Equal(t, []string{}, missingMySQLGrants(row, GrantsFeatures{}))
+		assert.Equal(t, []string{"DROP ON performance_schema.*"}, missingMySQLGrants(row, GrantsFeatures{QAN: true}))
+		assert.Equal(t, []string{"LOCK TABLES ON *.*", "BACKUP_ADMIN ON *.*"}, missingMySQLGrants(row, GrantsFeatures{Backup: true}))
+
+		row["version"] = []byte("5.7.35-log")
+		assert.Equal(t, []string{"LOCK TABLES ON *.*"}, missingMySQLGrants(row, GrantsFeatures{Backup: true}))
+	})
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		row := map[string]interface{}{
+			"user":               "pmm",
+			"superuser":          false,
+			"monitor":            true,
+			"pg_stat_statements": false,
+		}
+		assert.Equal(t, []string{}, missingPostgreSQLGrants(row, GrantsFeatures{}))
+		assert.Equal(t, []string{"pg_stat_statements extension"}, missingPostgreSQLGrants(row, GrantsFeatures{QAN: true}))
+
+		row["monitor"] = false
+		assert.Equal(t, []string{"pg_monitor role"}, missingPostgreSQLGrants(row, GrantsFeatures{}))
+	})
+}
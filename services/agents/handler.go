@@ -19,6 +19,7 @@ package agents
 import (
 	"context"
 	"runtime/pprof"
+	"strings"
 	"time"
 
 	"github.com/AlekSi/pointer"
@@ -36,6 +37,9 @@ import (
 	"github.com/percona/pmm-managed/utils/logger"
 )
 
+// maxLogTailLines is a maximal number of job log lines stored for failed restores.
+const maxLogTailLines = 50
+
 // Handler handles agent requests.
 type Handler struct {
 	db               *reform.DB
@@ -44,11 +48,19 @@ type Handler struct {
 	qanClient        qanClient
 	state            *StateUpdater
 	retentionService retentionService
+	notifications    notificationService
+	usage            usageService
+	signing          signingService
+	configSnapshots  configSnapshotService
+	mysqlDumps       mysqlDumpService
+	nodeFacts        *NodeFactsService
+	mongoDBDiscovery *MongoDBDiscoveryService
 }
 
 // NewHandler creates new agents handler.
 func NewHandler(db *reform.DB, qanClient qanClient, vmdb prometheusService, registry *Registry, state *StateUpdater,
-	retention retentionService) *Handler {
+	retention retentionService, notifications notificationService, usage usageService, signing signingService,
+	configSnapshots configSnapshotService, mysqlDumps mysqlDumpService, mongoDBDiscovery *MongoDBDiscoveryService) *Handler {
 	h := &Handler{
 		db:               db,
 		r:                registry,
@@ -56,6 +68,13 @@ func NewHandler(db *reform.DB, qanClient qanClient, vmdb prometheusService, regi
 		qanClient:        qanClient,
 		state:            state,
 		retentionService: retention,
+		notifications:    notifications,
+		usage:            usage,
+		signing:          signing,
+		configSnapshots:  configSnapshots,
+		mysqlDumps:       mysqlDumps,
+		nodeFacts:        NewNodeFactsService(db, registry),
+		mongoDBDiscovery: mongoDBDiscovery,
 	}
 	return h
 
@@ -74,6 +93,10 @@ func (h *Handler) Run(stream agentpb.Agent_ConnectServer) error {
 	}
 	defer func() {
 		l.Infof("Disconnecting client: %s.", disconnectReason)
+		// used by inventory objects expiration
+		if err := models.UpdatePMMAgentLastSeen(h.db.Querier, agent.id, time.Now()); err != nil {
+			l.Warnf("Failed to update pmm-agent last seen time: %s.", err)
+		}
 	}()
 
 	// run pmm-agent state update loop for the current agent.
@@ -81,6 +104,9 @@ func (h *Handler) Run(stream agentpb.Agent_ConnectServer) error {
 
 	h.state.RequestStateUpdate(ctx, agent.id)
 
+	// collect OS facts of newly registered Node
+	go h.nodeFacts.collectMissingFacts(ctx, agent.id)
+
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 	for {
@@ -154,6 +180,25 @@ func (h *Handler) Run(stream agentpb.Agent_ConnectServer) error {
 					l.Warnf("Action was done with an error: %v.", p.Error)
 				}
 
+				if p.Done {
+					if err = handleNodeFactsActionResult(h.db.Querier, p.ActionId, string(p.Output), p.Error); err != nil {
+						l.Warnf("Failed to store Node facts: %+v", err)
+					}
+					if err = h.mongoDBDiscovery.handleActionResult(ctx, p.ActionId, string(p.Output), p.Error); err != nil {
+						l.Warnf("Failed to apply MongoDB discovery: %+v", err)
+					}
+					var pmmAgentID string
+					pmmAgentID, err = handleDatabasesListingActionResult(h.db.Querier, p.ActionId, string(p.Output), p.Error)
+					if err != nil {
+						l.Warnf("Failed to store listed databases: %+v", err)
+					}
+					if pmmAgentID != "" {
+						h.state.RequestStateUpdate(ctx, pmmAgentID)
+					}
+					// snapshot and dump uploads may take a while, don't block pmm-agent's stream
+					go h.handleBackupActionResult(context.Background(), l, p.ActionId, string(p.Output), p.Error)
+				}
+
 				agent.channel.Send(&channel.ServerResponse{
 					ID:      req.ID,
 					Payload: new(agentpb.ActionResultResponse),
@@ -162,7 +207,7 @@ func (h *Handler) Run(stream agentpb.Agent_ConnectServer) error {
 			case *agentpb.JobResult:
 				h.handleJobResult(ctx, l, p)
 			case *agentpb.JobProgress:
-				// TODO Handle job progress messages https://jira.percona.com/browse/PMM-7756
+				h.handleJobProgress(l, p)
 
 			case nil:
 				l.Errorf("Unexpected request: %+v.", req)
@@ -171,20 +216,83 @@ func (h *Handler) Run(stream agentpb.Agent_ConnectServer) error {
 	}
 }
 
+func (h *Handler) handleJobProgress(l *logrus.Entry, progress *agentpb.JobProgress) {
+	p := &models.JobProgress{
+		UpdatedAt: time.Now(),
+	}
+	if progress.Timestamp != nil {
+		p.UpdatedAt = progress.Timestamp.AsTime()
+	}
+	switch result := progress.Result.(type) {
+	case *agentpb.JobProgress_Echo_:
+		p.Status = result.Echo.Status
+	case *agentpb.JobProgress_MysqlBackup, *agentpb.JobProgress_MysqlRestoreBackup:
+		// pmm-agent reports only that job is alive; estimate progress from previously finished jobs
+		if err := h.estimateJobProgress(progress.JobId, p); err != nil {
+			l.Warnf("Failed to estimate job progress: %+v.", err)
+		}
+	default:
+		l.Errorf("Unexpected job progress type: %T.", result)
+		return
+	}
+
+	job, err := models.UpdateJobProgress(h.db.Querier, progress.JobId, p)
+	if err != nil {
+		l.Errorf("Failed to update job progress: %+v.", err)
+		return
+	}
+
+	// pmm-agent may report restore phase as a status
+	if !job.Done && job.Type == models.MySQLRestoreBackupJob && job.Result.MySQLRestoreBackup != nil && p.Status != "" {
+		phase := models.RestorePhase(p.Status)
+		if phase.Validate() != nil {
+			return
+		}
+		if _, err = models.StartRestorePhase(h.db.Querier, job.Result.MySQLRestoreBackup.RestoreID, phase, p.UpdatedAt); err != nil {
+			l.Errorf("Failed to update restore phase: %+v.", err)
+		}
+	}
+}
+
+// estimateJobProgress fills percentage and bytes of job progress from job statistics.
+func (h *Handler) estimateJobProgress(jobID string, p *models.JobProgress) error {
+	job, err := models.FindJobResultByID(h.db.Querier, jobID)
+	if err != nil {
+		return err
+	}
+	return models.EstimateJobProgress(h.db.Querier, job, p)
+}
+
+// logTail returns at most maxLogTailLines last lines of job log.
+func logTail(log string) string {
+	lines := strings.Split(strings.TrimRight(log, "\n"), "\n")
+	if len(lines) > maxLogTailLines {
+		lines = lines[len(lines)-maxLogTailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *agentpb.JobResult) {
-	var scheduleID string
+	var scheduleID, artifactID string
+	var notify bool
 	if e := h.db.InTransaction(func(t *reform.TX) error {
 		res, err := models.FindJobResultByID(t.Querier, result.JobId)
 		if err != nil {
 			return err
 		}
 
+		if res.Done {
+			// job was canceled or its result was already handled
+			l.Debugf("Ignoring result of finished job %s.", res.ID)
+			return nil
+		}
+
 		switch result := result.Result.(type) {
 		case *agentpb.JobResult_Error_:
+			res.Error = result.Error.Message
 			if err := h.handleJobError(res); err != nil {
 				l.Errorf("failed to handle job error: %s", err)
 			}
-			res.Error = result.Error.Message
 		case *agentpb.JobResult_Echo_:
 			if res.Type != models.Echo {
 				return errors.Errorf("result type echo doesn't match job type %s", res.Type)
@@ -205,6 +313,7 @@ func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *
 			if err != nil {
 				return err
 			}
+			artifactID = artifact.ID
 
 			if artifact.Type == models.ScheduledArtifactType {
 				scheduleID = artifact.ScheduleID
@@ -220,6 +329,7 @@ func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *
 			if err != nil {
 				return err
 			}
+			artifactID = artifact.ID
 
 			if artifact.Type == models.ScheduledArtifactType {
 				scheduleID = artifact.ScheduleID
@@ -233,7 +343,8 @@ func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *
 				t.Querier,
 				res.Result.MySQLRestoreBackup.RestoreID,
 				models.ChangeRestoreHistoryItemParams{
-					Status: models.SuccessRestoreStatus,
+					Status:     models.SuccessRestoreStatus,
+					FinishedAt: pointer.ToTime(models.Now()),
 				})
 			if err != nil {
 				return err
@@ -248,7 +359,8 @@ func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *
 				t.Querier,
 				res.Result.MongoDBRestoreBackup.RestoreID,
 				models.ChangeRestoreHistoryItemParams{
-					Status: models.SuccessRestoreStatus,
+					Status:     models.SuccessRestoreStatus,
+					FinishedAt: pointer.ToTime(models.Now()),
 				})
 			if err != nil {
 				return err
@@ -257,11 +369,110 @@ func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *
 			return errors.Errorf("unexpected job result type: %T", result)
 		}
 		res.Done = true
+		notify = res.Type != models.Echo
+		return t.Update(res)
+	}); e != nil {
+		l.Errorf("Failed to save job result: %+v", e)
+	}
+
+	h.finishJob(l, result.JobId, artifactID, scheduleID, notify)
+}
+
+// handleBackupActionResult continues or finishes backup job performed with Actions if finished Action with given ID
+// was started for it. Configuration snapshot is stored in backup location and the job is done;
+// MySQL logical backup job starts the next Action until all tables are stored.
+// When job is done, job and artifact are marked as done.
+func (h *Handler) handleBackupActionResult(ctx context.Context, l *logrus.Entry, actionID, output, actionError string) {
+	job, err := models.FindJobResultByActionID(h.db.Querier, actionID)
+	if err != nil {
+		l.Errorf("Failed to find backup job: %+v", err)
+		return
+	}
+	if job == nil {
+		return
+	}
+
+	var artifactID string
+	switch job.Type {
+	case models.ConfigSnapshotBackupJob:
+		artifactID = job.Result.ConfigSnapshotBackup.ArtifactID
+		if actionError == "" {
+			if err = h.configSnapshots.StoreSnapshot(ctx, artifactID, output); err != nil {
+				actionError = err.Error()
+			}
+		}
+	case models.MySQLLogicalBackupJob:
+		artifactID = job.Result.MySQLLogicalBackup.ArtifactID
+		if actionError == "" {
+			var done bool
+			if done, err = h.mysqlDumps.HandleActionResult(ctx, job, output); err != nil {
+				actionError = err.Error()
+			} else if !done {
+				return
+			}
+		}
+	default:
+		l.Errorf("Unexpected job type %s of Action %s.", job.Type, actionID)
+		return
+	}
+
+	var scheduleID string
+	var notify bool
+	if e := h.db.InTransaction(func(t *reform.TX) error {
+		res, err := models.FindJobResultByID(t.Querier, job.ID)
+		if err != nil {
+			return err
+		}
+
+		if res.Done {
+			// job was canceled or timed out
+			l.Debugf("Ignoring result of finished job %s.", res.ID)
+			artifactID = ""
+			return nil
+		}
+
+		if actionError != "" {
+			res.Error = actionError
+			artifactID = ""
+			if err := h.handleJobError(res); err != nil {
+				l.Errorf("failed to handle job error: %s", err)
+			}
+		} else {
+			artifact, err := models.UpdateArtifact(t.Querier, artifactID, models.UpdateArtifactParams{
+				Status: models.BackupStatusPointer(models.SuccessBackupStatus),
+			})
+			if err != nil {
+				return err
+			}
+			if artifact.Type == models.ScheduledArtifactType {
+				scheduleID = artifact.ScheduleID
+			}
+		}
+
+		res.Done = true
+		notify = true
 		return t.Update(res)
 	}); e != nil {
 		l.Errorf("Failed to save job result: %+v", e)
 	}
 
+	h.finishJob(l, job.ID, artifactID, scheduleID, notify)
+}
+
+// finishJob signs artifact, enforces retention, records job stats and sends notification after job is done.
+func (h *Handler) finishJob(l *logrus.Entry, jobID, artifactID, scheduleID string, notify bool) {
+	if artifactID != "" {
+		go func() {
+			// sign first, so artifact size includes signed manifest
+			if err := h.signing.SignArtifact(context.Background(), artifactID); err != nil {
+				l.Errorf("failed to sign artifact: %v", err)
+			}
+			if err := h.usage.RecordArtifactSize(context.Background(), artifactID); err != nil {
+				l.Errorf("failed to record artifact size: %v", err)
+			}
+		}()
+	}
+
 	if scheduleID != "" {
 		go func() {
 			if err := h.retentionService.EnforceRetention(context.Background(), scheduleID); err != nil {
@@ -269,6 +480,24 @@ func (h *Handler) handleJobResult(ctx context.Context, l *logrus.Entry, result *
 			}
 		}()
 	}
+
+	if notify {
+		if e := h.db.InTransaction(func(t *reform.TX) error {
+			res, err := models.FindJobResultByID(t.Querier, jobID)
+			if err != nil {
+				return err
+			}
+			return models.RecordJobStats(t.Querier, res)
+		}); e != nil {
+			l.Errorf("Failed to record job duration: %+v", e)
+		}
+
+		go func() {
+			if err := h.notifications.NotifyJobFinished(context.Background(), jobID); err != nil {
+				l.Errorf("failed to send job notification: %v", err)
+			}
+		}()
+	}
 }
 
 func (h *Handler) handleJobError(jobResult *models.JobResult) error {
@@ -277,26 +506,30 @@ func (h *Handler) handleJobError(jobResult *models.JobResult) error {
 	case models.Echo:
 		// nothing
 	case models.MySQLBackupJob:
-		_, err = models.UpdateArtifact(h.db.Querier, jobResult.Result.MySQLBackup.ArtifactID, models.UpdateArtifactParams{
-			Status: models.BackupStatusPointer(models.ErrorBackupStatus),
-		})
+		err = h.setArtifactError(jobResult.Result.MySQLBackup.ArtifactID)
 	case models.MongoDBBackupJob:
-		_, err = models.UpdateArtifact(h.db.Querier, jobResult.Result.MongoDBBackup.ArtifactID, models.UpdateArtifactParams{
-			Status: models.BackupStatusPointer(models.ErrorBackupStatus),
-		})
+		err = h.setArtifactError(jobResult.Result.MongoDBBackup.ArtifactID)
+	case models.ConfigSnapshotBackupJob:
+		err = h.setArtifactError(jobResult.Result.ConfigSnapshotBackup.ArtifactID)
+	case models.MySQLLogicalBackupJob:
+		err = h.setArtifactError(jobResult.Result.MySQLLogicalBackup.ArtifactID)
 	case models.MySQLRestoreBackupJob:
 		_, err = models.ChangeRestoreHistoryItem(
 			h.db.Querier,
 			jobResult.Result.MySQLRestoreBackup.RestoreID,
 			models.ChangeRestoreHistoryItemParams{
-				Status: models.ErrorRestoreStatus,
+				Status:     models.ErrorRestoreStatus,
+				FinishedAt: pointer.ToTime(models.Now()),
+				LogTail:    pointer.ToString(logTail(jobResult.Error)),
 			})
 	case models.MongoDBRestoreBackupJob:
 		_, err = models.ChangeRestoreHistoryItem(
 			h.db.Querier,
 			jobResult.Result.MongoDBRestoreBackup.RestoreID,
 			models.ChangeRestoreHistoryItemParams{
-				Status: models.ErrorRestoreStatus,
+				Status:     models.ErrorRestoreStatus,
+				FinishedAt: pointer.ToTime(models.Now()),
+				LogTail:    pointer.ToString(logTail(jobResult.Error)),
 			})
 	default:
 		// Don't do anything without explicit handling
@@ -304,6 +537,22 @@ func (h *Handler) handleJobError(jobResult *models.JobResult) error {
 	return err
 }
 
+// setArtifactError sets error status for artifact unless backup was canceled.
+func (h *Handler) setArtifactError(artifactID string) error {
+	artifact, err := models.FindArtifactByID(h.db.Querier, artifactID)
+	if err != nil {
+		return err
+	}
+	if artifact.Status == models.CanceledBackupStatus {
+		return nil
+	}
+
+	_, err = models.UpdateArtifact(h.db.Querier, artifactID, models.UpdateArtifactParams{
+		Status: models.BackupStatusPointer(models.ErrorBackupStatus),
+	})
+	return err
+}
+
 func (h *Handler) updateAgentStatusForChildren(ctx context.Context, agentID string, status inventorypb.AgentStatus, listenPort uint32) error {
 	return h.db.InTransaction(func(t *reform.TX) error {
 		agents, err := models.FindAgents(t.Querier, models.AgentFilters{
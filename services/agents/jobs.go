@@ -18,20 +18,45 @@
 package agents
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/AlekSi/pointer"
 	"github.com/percona/pmm/api/agentpb"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"gopkg.in/reform.v1"
 
 	"github.com/percona/pmm-managed/models"
 )
 
+// backupQueueInterval is an interval between attempts to start queued backup jobs.
+const backupQueueInterval = 10 * time.Second
+
+// backupJobTypes are types of jobs limited by backup concurrency settings.
+var backupJobTypes = []models.JobType{models.MySQLBackupJob, models.MongoDBBackupJob}
+
+// restoreJobTypes are types of jobs limited by per-Node concurrency settings.
+var restoreJobTypes = []models.JobType{models.MySQLRestoreBackupJob, models.MongoDBRestoreBackupJob}
+
+// queuedJob represents a backup job deferred because of concurrency limits.
+type queuedJob struct {
+	pmmAgentID string
+	req        *agentpb.StartJobRequest
+}
+
 // JobsService provides methods for managing jobs.
 type JobsService struct {
 	r  *Registry
 	db *reform.DB
+	l  *logrus.Entry
+
+	queueM sync.Mutex
+	queue  []*queuedJob // in FIFO order
 }
 
 // NewJobsService returns new jobs service.
@@ -39,6 +64,30 @@ func NewJobsService(db *reform.DB, registry *Registry) *JobsService {
 	return &JobsService{
 		r:  registry,
 		db: db,
+		l:  logrus.WithField("component", "agents/jobs"),
+	}
+}
+
+// Run starts queued backup jobs when concurrency limits allow it until context is canceled.
+func (s *JobsService) Run(ctx context.Context) {
+	s.l.Info("Starting...")
+	defer s.l.Info("Done.")
+
+	// queue is not persisted, so backups queued before restart can't be started
+	if err := s.failQueuedBackups(); err != nil {
+		s.l.Errorf("Failed to clean up queued backups: %+v.", err)
+	}
+
+	ticker := time.NewTicker(backupQueueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.startQueuedBackupJobs()
+		}
 	}
 }
 
@@ -99,20 +148,7 @@ func (s *JobsService) StartMySQLBackupJob(jobID, pmmAgentID string, timeout time
 		},
 	}
 
-	agent, err := s.r.get(pmmAgentID)
-	if err != nil {
-		return err
-	}
-
-	resp, err := agent.channel.SendAndWaitResponse(req)
-	if err != nil {
-		return err
-	}
-	if e := resp.(*agentpb.StartJobResponse).Error; e != "" {
-		return errors.Errorf("failed to start MySQL job: %s", e)
-	}
-
-	return nil
+	return s.startBackupJob(pmmAgentID, req)
 }
 
 // StartMongoDBBackupJob starts mongoDB backup job on the pmm-agent.
@@ -149,6 +185,190 @@ func (s *JobsService) StartMongoDBBackupJob(
 		},
 	}
 
+	return s.startBackupJob(pmmAgentID, req)
+}
+
+// startBackupJob starts backup job on the pmm-agent, or queues it if concurrency limits are reached.
+func (s *JobsService) startBackupJob(pmmAgentID string, req *agentpb.StartJobRequest) error {
+	s.queueM.Lock()
+	defer s.queueM.Unlock()
+
+	limited, err := s.backupLimitReached(pmmAgentID, req.JobId)
+	if err != nil {
+		return err
+	}
+	if !limited {
+		return s.sendBackupJob(pmmAgentID, req)
+	}
+
+	if err = s.setBackupStatus(req.JobId, models.QueuedBackupStatus); err != nil {
+		return err
+	}
+	s.queue = append(s.queue, &queuedJob{
+		pmmAgentID: pmmAgentID,
+		req:        req,
+	})
+	s.l.Infof("Backup job %s on pmm-agent %s is queued.", req.JobId, pmmAgentID)
+	return nil
+}
+
+// startQueuedBackupJobs starts queued backup jobs that are allowed by concurrency limits.
+func (s *JobsService) startQueuedBackupJobs() {
+	s.queueM.Lock()
+	defer s.queueM.Unlock()
+
+	for _, job := range append([]*queuedJob(nil), s.queue...) {
+		limited, err := s.backupLimitReached(job.pmmAgentID, job.req.JobId)
+		if err != nil {
+			s.l.Errorf("Failed to check backup concurrency limits: %+v.", err)
+			return
+		}
+		if limited {
+			continue
+		}
+
+		s.removeQueued(job.req.JobId)
+		s.l.Infof("Starting queued backup job %s on pmm-agent %s.", job.req.JobId, job.pmmAgentID)
+		if err = s.setBackupStatus(job.req.JobId, models.PendingBackupStatus); err == nil {
+			err = s.sendBackupJob(job.pmmAgentID, job.req)
+		}
+		if err != nil {
+			s.l.Errorf("Failed to start queued backup job %s: %+v.", job.req.JobId, err)
+			if err = s.failBackupJob(job.req.JobId, err.Error()); err != nil {
+				s.l.Error(err)
+			}
+		}
+	}
+}
+
+// runningJobs contains numbers of unfinished backup and restore jobs.
+type runningJobs struct {
+	backups      int // on all pmm-agents
+	agentBackups int // on the given pmm-agent
+	nodeBackups  int // on the Node of the given pmm-agent
+	nodeRestores int // on the Node of the given pmm-agent
+}
+
+// countRunningJobs returns numbers of unfinished backup and restore jobs, excluding queued jobs
+// and the job with given ID. Caller should hold queueM.
+func (s *JobsService) countRunningJobs(pmmAgentID, jobID string) (*runningJobs, error) {
+	jobs, err := models.FindUnfinishedJobResults(s.db.Querier, append(backupJobTypes, restoreJobTypes...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	agentType := models.PMMAgentType
+	pmmAgents, err := models.FindAgents(s.db.Querier, models.AgentFilters{AgentType: &agentType})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]string, len(pmmAgents))
+	for _, a := range pmmAgents {
+		nodes[a.AgentID] = pointer.GetString(a.RunsOnNodeID)
+	}
+
+	queued := make(map[string]struct{}, len(s.queue))
+	for _, job := range s.queue {
+		queued[job.req.JobId] = struct{}{}
+	}
+
+	var res runningJobs
+	for _, job := range jobs {
+		if _, ok := queued[job.ID]; ok || job.ID == jobID {
+			continue
+		}
+
+		sameAgent := job.PMMAgentID == pmmAgentID
+		sameNode := sameAgent || (nodes[pmmAgentID] != "" && nodes[job.PMMAgentID] == nodes[pmmAgentID])
+		switch job.Type {
+		case models.MySQLRestoreBackupJob, models.MongoDBRestoreBackupJob:
+			if sameNode {
+				res.nodeRestores++
+			}
+		default:
+			res.backups++
+			if sameAgent {
+				res.agentBackups++
+			}
+			if sameNode {
+				res.nodeBackups++
+			}
+		}
+	}
+
+	return &res, nil
+}
+
+// backupLimitReached returns true if backup job with given ID can't be started on the pmm-agent
+// because of server-wide, per-agent or per-Node concurrency limits, or because of restore running on the same Node.
+func (s *JobsService) backupLimitReached(pmmAgentID, jobID string) (bool, error) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return false, err
+	}
+	bm := settings.BackupManagement
+	if bm.MaxParallelJobs == 0 && bm.MaxParallelJobsPerAgent == 0 && bm.MaxParallelJobsPerNode == 0 && bm.AllowConcurrentRestore {
+		return false, nil
+	}
+
+	running, err := s.countRunningJobs(pmmAgentID, jobID)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case bm.MaxParallelJobs != 0 && running.backups >= bm.MaxParallelJobs:
+		return true, nil
+	case bm.MaxParallelJobsPerAgent != 0 && running.agentBackups >= bm.MaxParallelJobsPerAgent:
+		return true, nil
+	case bm.MaxParallelJobsPerNode != 0 && running.nodeBackups+running.nodeRestores >= bm.MaxParallelJobsPerNode:
+		return true, nil
+	case !bm.AllowConcurrentRestore && running.nodeRestores != 0:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// checkRestoreAllowed returns error if restore job with given ID can't be started on the pmm-agent
+// because of per-Node concurrency limit or backup running on the same Node. Restore jobs are not queued,
+// so such job is marked as failed.
+func (s *JobsService) checkRestoreAllowed(pmmAgentID, jobID string) error {
+	s.queueM.Lock()
+	defer s.queueM.Unlock()
+
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return err
+	}
+	bm := settings.BackupManagement
+	if bm.MaxParallelJobsPerNode == 0 && bm.AllowConcurrentRestore {
+		return nil
+	}
+
+	running, err := s.countRunningJobs(pmmAgentID, jobID)
+	if err != nil {
+		return err
+	}
+
+	var msg string
+	switch {
+	case !bm.AllowConcurrentRestore && running.nodeBackups != 0:
+		msg = "Backup is running on the same Node."
+	case bm.MaxParallelJobsPerNode != 0 && running.nodeBackups+running.nodeRestores >= bm.MaxParallelJobsPerNode:
+		msg = "Too many backup and restore jobs are running on the same Node."
+	default:
+		return nil
+	}
+
+	if err = s.failRestoreJob(jobID, msg); err != nil {
+		s.l.Error(err)
+	}
+	return status.Error(codes.FailedPrecondition, msg)
+}
+
+// sendBackupJob sends start backup job request to the pmm-agent.
+func (s *JobsService) sendBackupJob(pmmAgentID string, req *agentpb.StartJobRequest) error {
 	agent, err := s.r.get(pmmAgentID)
 	if err != nil {
 		return err
@@ -159,12 +379,122 @@ func (s *JobsService) StartMongoDBBackupJob(
 		return err
 	}
 	if e := resp.(*agentpb.StartJobResponse).Error; e != "" {
-		return errors.Errorf("failed to start MongoDB job: %s", e)
+		switch req.Job.(type) {
+		case *agentpb.StartJobRequest_MongodbBackup:
+			return errors.Errorf("failed to start MongoDB job: %s", e)
+		default:
+			return errors.Errorf("failed to start MySQL job: %s", e)
+		}
 	}
 
 	return nil
 }
 
+// setBackupStatus sets status of the artifact of backup job with given ID.
+func (s *JobsService) setBackupStatus(jobID string, status models.BackupStatus) error {
+	return s.db.InTransaction(func(tx *reform.TX) error {
+		res, err := models.FindJobResultByID(tx.Querier, jobID)
+		if err != nil {
+			return err
+		}
+
+		if _, err = models.UpdateArtifact(tx.Querier, backupArtifactID(res), models.UpdateArtifactParams{
+			Status: models.BackupStatusPointer(status),
+		}); err != nil {
+			return err
+		}
+
+		// bump updated_at, so job timeout is counted from that moment
+		return errors.WithStack(tx.Update(res))
+	})
+}
+
+// failBackupJob marks backup job with given ID and its artifact as failed.
+func (s *JobsService) failBackupJob(jobID, message string) error {
+	return s.db.InTransaction(func(tx *reform.TX) error {
+		res, err := models.FindJobResultByID(tx.Querier, jobID)
+		if err != nil {
+			return err
+		}
+
+		if _, err = models.UpdateArtifact(tx.Querier, backupArtifactID(res), models.UpdateArtifactParams{
+			Status: models.BackupStatusPointer(models.ErrorBackupStatus),
+		}); err != nil {
+			return err
+		}
+
+		res.Done = true
+		res.Error = message
+		return errors.WithStack(tx.Update(res))
+	})
+}
+
+// failQueuedBackups marks backup jobs queued before pmm-managed restart as failed.
+func (s *JobsService) failQueuedBackups() error {
+	artifacts, err := models.FindArtifacts(s.db.Querier, models.ArtifactFilters{Status: models.QueuedBackupStatus})
+	if err != nil {
+		return err
+	}
+
+	for _, artifact := range artifacts {
+		res, err := models.FindBackupJobResultByArtifactID(s.db.Querier, artifact.ID)
+		if err != nil {
+			s.l.Warnf("Failed to find backup job for queued artifact %s: %s.", artifact.ID, err)
+			continue
+		}
+		if err = s.failBackupJob(res.ID, "pmm-managed was restarted while backup was queued"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failRestoreJob marks restore job with given ID and its restore history item as failed.
+func (s *JobsService) failRestoreJob(jobID, message string) error {
+	return s.db.InTransaction(func(tx *reform.TX) error {
+		res, err := models.FindJobResultByID(tx.Querier, jobID)
+		if err != nil {
+			return err
+		}
+
+		var restoreID string
+		switch {
+		case res.Result == nil:
+		case res.Result.MySQLRestoreBackup != nil:
+			restoreID = res.Result.MySQLRestoreBackup.RestoreID
+		case res.Result.MongoDBRestoreBackup != nil:
+			restoreID = res.Result.MongoDBRestoreBackup.RestoreID
+		}
+		if restoreID != "" {
+			if _, err = models.ChangeRestoreHistoryItem(tx.Querier, restoreID, models.ChangeRestoreHistoryItemParams{
+				Status:     models.ErrorRestoreStatus,
+				FinishedAt: pointer.ToTime(models.Now()),
+			}); err != nil {
+				return err
+			}
+		}
+
+		res.Done = true
+		res.Error = message
+		return errors.WithStack(tx.Update(res))
+	})
+}
+
+// backupArtifactID returns artifact ID of given backup job.
+func backupArtifactID(res *models.JobResult) string {
+	if res.Result == nil {
+		return ""
+	}
+	switch {
+	case res.Result.MySQLBackup != nil:
+		return res.Result.MySQLBackup.ArtifactID
+	case res.Result.MongoDBBackup != nil:
+		return res.Result.MongoDBBackup.ArtifactID
+	default:
+		return ""
+	}
+}
+
 // StartMySQLRestoreBackupJob starts mysql restore backup job on the pmm-agent.
 func (s *JobsService) StartMySQLRestoreBackupJob(
 	jobID string,
@@ -178,6 +508,10 @@ func (s *JobsService) StartMySQLRestoreBackupJob(
 		return errors.Errorf("location config is not set")
 	}
 
+	if err := s.checkRestoreAllowed(pmmAgentID, jobID); err != nil {
+		return err
+	}
+
 	req := &agentpb.StartJobRequest{
 		JobId:   jobID,
 		Timeout: durationpb.New(timeout),
@@ -243,6 +577,10 @@ func (s *JobsService) StartMongoDBRestoreBackupJob(
 		},
 	}
 
+	if err := s.checkRestoreAllowed(pmmAgentID, jobID); err != nil {
+		return err
+	}
+
 	agent, err := s.r.get(pmmAgentID)
 	if err != nil {
 		return err
@@ -271,6 +609,11 @@ func (s *JobsService) StopJob(jobID string) error {
 		return nil
 	}
 
+	if s.dequeue(jobID) {
+		// Job was not started yet
+		return nil
+	}
+
 	agent, err := s.r.get(jobResult.PMMAgentID)
 	if err != nil {
 		return errors.WithStack(err)
@@ -290,3 +633,22 @@ func convertS3ConfigModel(config *models.S3LocationConfig) *agentpb.S3LocationCo
 		BucketRegion: config.BucketRegion,
 	}
 }
+
+// dequeue removes backup job with given ID from the queue. It returns false if job is not queued.
+func (s *JobsService) dequeue(jobID string) bool {
+	s.queueM.Lock()
+	defer s.queueM.Unlock()
+
+	return s.removeQueued(jobID)
+}
+
+// removeQueued removes backup job with given ID from the queue. Caller should hold queueM.
+func (s *JobsService) removeQueued(jobID string) bool {
+	for i, job := range s.queue {
+		if job.req.JobId == jobID {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
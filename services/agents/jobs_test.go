@@ -0,0 +1,164 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona/pmm/api/agentpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestBackupLimitReached(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	s := NewJobsService(db, nil)
+
+	createJob := func(pmmAgentID string, jobType models.JobType) *models.JobResult {
+		res, err := models.CreateJobResult(db.Querier, pmmAgentID, jobType, &models.JobResultData{
+			MySQLBackup: &models.MySQLBackupJobResult{},
+		})
+		require.NoError(t, err)
+		return res
+	}
+	setLimits := func(maxJobs, maxAgentJobs int) {
+		_, err := models.UpdateSettings(db.Querier, &models.ChangeSettingsParams{
+			BackupMaxParallelJobs:         pointer.ToInt(maxJobs),
+			BackupMaxParallelJobsPerAgent: pointer.ToInt(maxAgentJobs),
+		})
+		require.NoError(t, err)
+	}
+
+	createJob("agent1", models.MySQLBackupJob)
+	createJob("agent1", models.Echo)
+	running := createJob("agent2", models.MySQLBackupJob)
+	queued := createJob("agent2", models.MongoDBBackupJob)
+	s.queue = []*queuedJob{{pmmAgentID: "agent2", req: &agentpb.StartJobRequest{JobId: queued.ID}}}
+	current := createJob("agent1", models.MySQLBackupJob)
+
+	t.Run("NoLimits", func(t *testing.T) {
+		setLimits(0, 0)
+		limited, err := s.backupLimitReached("agent1", current.ID)
+		require.NoError(t, err)
+		assert.False(t, limited)
+	})
+
+	t.Run("ServerLimit", func(t *testing.T) {
+		// echo, queued and current jobs are not counted
+		setLimits(3, 0)
+		limited, err := s.backupLimitReached("agent1", current.ID)
+		require.NoError(t, err)
+		assert.False(t, limited)
+
+		setLimits(2, 0)
+		limited, err = s.backupLimitReached("agent1", current.ID)
+		require.NoError(t, err)
+		assert.True(t, limited)
+	})
+
+	t.Run("AgentLimit", func(t *testing.T) {
+		setLimits(0, 1)
+		limited, err := s.backupLimitReached("agent1", current.ID)
+		require.NoError(t, err)
+		assert.True(t, limited)
+
+		running.Done = true
+		require.NoError(t, db.Update(running))
+		limited, err = s.backupLimitReached("agent2", queued.ID)
+		require.NoError(t, err)
+		assert.False(t, limited)
+	})
+
+	t.Run("NodeLimit", func(t *testing.T) {
+		setLimits(0, 0)
+		_, err := models.UpdateSettings(db.Querier, &models.ChangeSettingsParams{
+			BackupMaxParallelJobsPerNode: pointer.ToInt(1),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, err := models.UpdateSettings(db.Querier, &models.ChangeSettingsParams{
+				BackupMaxParallelJobsPerNode: pointer.ToInt(0),
+			})
+			require.NoError(t, err)
+		})
+
+		limited, err := s.backupLimitReached("agent1", current.ID)
+		require.NoError(t, err)
+		assert.True(t, limited)
+
+		limited, err = s.backupLimitReached("agent3", current.ID)
+		require.NoError(t, err)
+		assert.False(t, limited)
+	})
+
+	t.Run("ConcurrentRestore", func(t *testing.T) {
+		setLimits(0, 0)
+		restore := createJob("agent3", models.MySQLRestoreBackupJob)
+
+		limited, err := s.backupLimitReached("agent3", current.ID)
+		require.NoError(t, err)
+		assert.True(t, limited)
+
+		limited, err = s.backupLimitReached("agent1", current.ID)
+		require.NoError(t, err)
+		assert.False(t, limited)
+
+		_, err = models.UpdateSettings(db.Querier, &models.ChangeSettingsParams{
+			BackupAllowConcurrentRestore: pointer.ToBool(true),
+		})
+		require.NoError(t, err)
+		limited, err = s.backupLimitReached("agent3", current.ID)
+		require.NoError(t, err)
+		assert.False(t, limited)
+
+		_, err = models.UpdateSettings(db.Querier, &models.ChangeSettingsParams{
+			BackupAllowConcurrentRestore: pointer.ToBool(false),
+		})
+		require.NoError(t, err)
+		restore.Done = true
+		require.NoError(t, db.Update(restore))
+	})
+
+	t.Run("RestoreDuringBackup", func(t *testing.T) {
+		restore := createJob("agent1", models.MySQLRestoreBackupJob)
+
+		err := s.checkRestoreAllowed("agent1", restore.ID)
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Backup is running on the same Node."), err)
+
+		restore, err = models.FindJobResultByID(db.Querier, restore.ID)
+		require.NoError(t, err)
+		assert.True(t, restore.Done)
+		assert.Equal(t, "Backup is running on the same Node.", restore.Error)
+
+		restore = createJob("agent3", models.MySQLRestoreBackupJob)
+		assert.NoError(t, s.checkRestoreAllowed("agent3", restore.ID))
+	})
+}
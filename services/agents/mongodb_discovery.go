@@ -0,0 +1,464 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	// mongoDBDiscoveryRefreshInterval is an interval of membership labels refresh for known replica sets.
+	mongoDBDiscoveryRefreshInterval = 10 * time.Minute
+	// mongoDBDiscoveryTTL is how long discovery results are kept in memory.
+	mongoDBDiscoveryTTL = time.Hour
+)
+
+// MongoDBMember represents MongoDB instance of replica set or sharded cluster.
+type MongoDBMember struct {
+	Host           string `json:"host"`
+	Port           uint16 `json:"port"`
+	Type           string `json:"type"`
+	ReplicationSet string `json:"replication_set,omitempty"`
+	// ID of existing or registered Service; empty if member is not monitored.
+	ServiceID string `json:"service_id,omitempty"`
+	// True if Service was registered by this discovery.
+	Registered bool `json:"registered"`
+}
+
+// MongoDBDiscovery represents MongoDB topology discovery started from a single Service.
+type MongoDBDiscovery struct {
+	ActionID     string           `json:"action_id"`
+	ServiceID    string           `json:"service_id"`
+	AutoRegister bool             `json:"auto_register"`
+	Done         bool             `json:"done"`
+	Error        string           `json:"error,omitempty"`
+	Members      []*MongoDBMember `json:"members"`
+
+	startedAt time.Time
+}
+
+// MongoDBDiscoveryService discovers remaining members of MongoDB replica sets and sharded clusters
+// with pt-mongodb-summary Action, registers them as Services on remote Nodes if requested,
+// and keeps cluster membership labels of Services current.
+type MongoDBDiscoveryService struct {
+	db      *reform.DB
+	r       *Registry
+	state   *StateUpdater
+	vmdb    prometheusService
+	actions *ActionsService
+	l       *logrus.Entry
+
+	rw          sync.RWMutex
+	discoveries map[string]*MongoDBDiscovery
+}
+
+// NewMongoDBDiscoveryService creates new MongoDB topology discovery service.
+func NewMongoDBDiscoveryService(db *reform.DB, r *Registry, state *StateUpdater, vmdb prometheusService) *MongoDBDiscoveryService {
+	return &MongoDBDiscoveryService{
+		db:          db,
+		r:           r,
+		state:       state,
+		vmdb:        vmdb,
+		actions:     NewActionsService(r),
+		l:           logrus.WithField("component", "mongodb-discovery"),
+		discoveries: make(map[string]*MongoDBDiscovery),
+	}
+}
+
+// Run periodically refreshes membership labels of known replica sets until ctx is canceled.
+func (s *MongoDBDiscoveryService) Run(ctx context.Context) {
+	s.l.Info("Starting...")
+	defer s.l.Info("Done.")
+
+	ticker := time.NewTicker(mongoDBDiscoveryRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// Discover starts discovery of MongoDB topology through mongodb_exporter's pmm-agent of Service with given ID.
+// If autoRegister is true, discovered members that are not monitored yet are registered as Services on remote Nodes.
+// It returns Action ID; see GetDiscovery.
+func (s *MongoDBDiscoveryService) Discover(ctx context.Context, serviceID string, autoRegister bool) (string, error) {
+	var service *models.Service
+	var exporter *models.Agent
+	var res *models.ActionResult
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if service, err = models.FindServiceByID(tx.Querier, serviceID); err != nil {
+			return err
+		}
+		if service.ServiceType != models.MongoDBServiceType {
+			return status.Errorf(codes.InvalidArgument, "Service with ID %q is not a MongoDB Service.", serviceID)
+		}
+
+		if exporter, err = findMongoDBExporter(tx.Querier, serviceID); err != nil {
+			return err
+		}
+		pmmAgentID := pointer.GetString(exporter.PMMAgentID)
+		if !s.r.IsConnected(pmmAgentID) {
+			return status.Errorf(codes.FailedPrecondition, "pmm-agent with ID %q is not connected.", pmmAgentID)
+		}
+
+		res, err = models.CreateActionResult(tx.Querier, pmmAgentID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.rw.Lock()
+	for id, d := range s.discoveries {
+		if time.Since(d.startedAt) > mongoDBDiscoveryTTL {
+			delete(s.discoveries, id)
+		}
+	}
+	s.discoveries[res.ID] = &MongoDBDiscovery{
+		ActionID:     res.ID,
+		ServiceID:    serviceID,
+		AutoRegister: autoRegister,
+		startedAt:    time.Now(),
+	}
+	s.rw.Unlock()
+
+	err = s.actions.StartPTMongoDBSummaryAction(ctx, res.ID, pointer.GetString(exporter.PMMAgentID),
+		pointer.GetString(service.Address), pointer.GetUint16(service.Port),
+		pointer.GetString(exporter.Username), pointer.GetString(exporter.Password))
+	if err != nil {
+		s.rw.Lock()
+		delete(s.discoveries, res.ID)
+		s.rw.Unlock()
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// GetDiscovery returns discovery started by Discover with given Action ID.
+func (s *MongoDBDiscoveryService) GetDiscovery(actionID string) (*MongoDBDiscovery, error) {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	d := s.discoveries[actionID]
+	if d == nil {
+		return nil, status.Errorf(codes.NotFound, "MongoDB discovery with Action ID %q not found.", actionID)
+	}
+
+	res := *d
+	return &res, nil
+}
+
+// refresh starts discovery without registration for one Service of each known replica set.
+func (s *MongoDBDiscoveryService) refresh(ctx context.Context) {
+	serviceType := models.MongoDBServiceType
+	services, err := models.FindServices(s.db.Querier, models.ServiceFilters{ServiceType: &serviceType})
+	if err != nil {
+		s.l.Errorf("%+v", err)
+		return
+	}
+
+	seen := make(map[string]struct{})
+	for _, service := range services {
+		if service.ReplicationSet == "" {
+			continue
+		}
+		key := service.Cluster + "/" + service.ReplicationSet
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		if _, err = s.Discover(ctx, service.ServiceID, false); err != nil {
+			s.l.Debugf("Failed to refresh MongoDB topology of %s: %s.", service.ServiceID, err)
+			continue
+		}
+		seen[key] = struct{}{}
+	}
+}
+
+// handleActionResult applies result of finished Action with given ID if it was started by Discover.
+// It is a no-op for nil receiver.
+func (s *MongoDBDiscoveryService) handleActionResult(ctx context.Context, actionID, output, actionError string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.rw.RLock()
+	d := s.discoveries[actionID]
+	s.rw.RUnlock()
+	if d == nil {
+		return nil
+	}
+
+	var members []*MongoDBMember
+	var pmmAgentID string
+	var changed bool
+	err := errors.New(actionError)
+	if actionError == "" {
+		members = parsePTMongoDBSummaryMembers(output)
+		err = s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+			var e error
+			pmmAgentID, changed, e = applyMongoDBMembers(tx.Querier, d.ServiceID, members, d.AutoRegister)
+			return e
+		})
+	}
+
+	s.rw.Lock()
+	d.Done = true
+	d.Members = members
+	if err != nil {
+		d.Error = err.Error()
+	}
+	s.rw.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if pmmAgentID != "" {
+		s.state.RequestStateUpdate(ctx, pmmAgentID)
+	}
+	if changed {
+		s.vmdb.RequestConfigurationUpdate()
+	}
+	return nil
+}
+
+// findMongoDBExporter returns mongodb_exporter of Service with given ID.
+func findMongoDBExporter(q *reform.Querier, serviceID string) (*models.Agent, error) {
+	agentType := models.MongoDBExporterType
+	agents, err := models.FindAgents(q, models.AgentFilters{ServiceID: serviceID, AgentType: &agentType})
+	if err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "No mongodb_exporter for Service with ID %q.", serviceID)
+	}
+	return agents[0], nil
+}
+
+// applyMongoDBMembers matches discovered members with existing Services, updates their membership labels,
+// and registers unmatched members if autoRegister is true.
+// It returns ID of pmm-agent that should be updated (if any), and true if any Service was changed.
+func applyMongoDBMembers(q *reform.Querier, seedID string, members []*MongoDBMember, autoRegister bool) (string, bool, error) {
+	seed, err := models.FindServiceByID(q, seedID)
+	if err != nil {
+		return "", false, err
+	}
+	serviceType := models.MongoDBServiceType
+	services, err := models.FindServices(q, models.ServiceFilters{ServiceType: &serviceType})
+	if err != nil {
+		return "", false, err
+	}
+	nodes, err := models.FindNodes(q, models.NodeFilters{})
+	if err != nil {
+		return "", false, err
+	}
+
+	cluster := mongoDBClusterName(seed, members)
+
+	var pmmAgentID string
+	var changed bool
+	for _, m := range members {
+		if service := findMongoDBService(m, services, nodes); service != nil {
+			m.ServiceID = service.ServiceID
+			if service.ReplicationSet == m.ReplicationSet && (service.Cluster != "" || cluster == "") {
+				continue
+			}
+
+			service.ReplicationSet = m.ReplicationSet
+			if service.Cluster == "" {
+				service.Cluster = cluster
+			}
+			if err = q.Update(service); err != nil {
+				return "", false, errors.WithStack(err)
+			}
+			changed = true
+			continue
+		}
+
+		if !autoRegister {
+			continue
+		}
+
+		agent, err := registerMongoDBMember(q, seed, m, cluster)
+		if err != nil {
+			return "", false, err
+		}
+		m.ServiceID = pointer.GetString(agent.ServiceID)
+		m.Registered = true
+		pmmAgentID = pointer.GetString(agent.PMMAgentID)
+		changed = true
+	}
+
+	return pmmAgentID, changed, nil
+}
+
+// registerMongoDBMember adds Service on remote Node for given member, and mongodb_exporter for it
+// run by the same pmm-agent and with the same credentials as seed Service's exporter.
+func registerMongoDBMember(q *reform.Querier, seed *models.Service, m *MongoDBMember, cluster string) (*models.Agent, error) {
+	seedExporter, err := findMongoDBExporter(q, seed.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := models.FindNodeByName(q, m.Host)
+	if err != nil {
+		if st, ok := status.FromError(err); !ok || st.Code() != codes.NotFound {
+			return nil, err
+		}
+		if node, err = models.CreateNode(q, models.RemoteNodeType, &models.CreateNodeParams{
+			NodeName: m.Host,
+			Address:  m.Host,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	service, err := models.AddNewService(q, models.MongoDBServiceType, &models.AddDBMSServiceParams{
+		ServiceName:    net.JoinHostPort(m.Host, strconv.Itoa(int(m.Port))),
+		NodeID:         node.NodeID,
+		Environment:    seed.Environment,
+		Cluster:        cluster,
+		ReplicationSet: m.ReplicationSet,
+		Address:        pointer.ToString(m.Host),
+		Port:           pointer.ToUint16(m.Port),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return models.CreateAgent(q, models.MongoDBExporterType, &models.CreateAgentParams{
+		PMMAgentID:        pointer.GetString(seedExporter.PMMAgentID),
+		ServiceID:         service.ServiceID,
+		Username:          pointer.GetString(seedExporter.Username),
+		Password:          pointer.GetString(seedExporter.Password),
+		AgentPassword:     pointer.GetString(seedExporter.AgentPassword),
+		TLS:               seedExporter.TLS,
+		TLSSkipVerify:     seedExporter.TLSSkipVerify,
+		MongoDBOptions:    seedExporter.MongoDBOptions,
+		PushMetrics:       seedExporter.PushMetrics,
+		DisableCollectors: seedExporter.DisabledCollectors,
+	})
+}
+
+// mongoDBClusterName returns cluster label for discovered members:
+// seed's cluster if set, replica set name for a single replica set, or seed's name for sharded cluster.
+func mongoDBClusterName(seed *models.Service, members []*MongoDBMember) string {
+	if seed.Cluster != "" {
+		return seed.Cluster
+	}
+
+	replicationSets := make(map[string]struct{})
+	for _, m := range members {
+		if m.ReplicationSet != "" {
+			replicationSets[m.ReplicationSet] = struct{}{}
+		}
+	}
+	switch len(replicationSets) {
+	case 0:
+		return ""
+	case 1:
+		for rs := range replicationSets {
+			return rs
+		}
+	}
+	return seed.ServiceName
+}
+
+// findMongoDBService returns existing Service of given member, or nil.
+func findMongoDBService(m *MongoDBMember, services []*models.Service, nodes []*models.Node) *models.Service {
+	nodeHosts := make(map[string]map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		nodeHosts[node.NodeID] = map[string]struct{}{
+			node.Address:  {},
+			node.NodeName: {},
+		}
+	}
+
+	for _, service := range services {
+		if pointer.GetUint16(service.Port) != m.Port {
+			continue
+		}
+		if _, ok := nodeHosts[service.NodeID][m.Host]; ok || pointer.GetString(service.Address) == m.Host {
+			return service
+		}
+	}
+	return nil
+}
+
+// parsePTMongoDBSummaryMembers extracts members from "Instances" section of pt-mongodb-summary output.
+func parsePTMongoDBSummaryMembers(output string) []*MongoDBMember {
+	var res []*MongoDBMember
+	var section string
+	s := bufio.NewScanner(strings.NewReader(output))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "# ") {
+			section = strings.TrimSpace(strings.Trim(line, "# "))
+			continue
+		}
+		if section != "Instances" {
+			continue
+		}
+
+		// PID Host Type ReplSet Engine; ReplSet and Engine may be empty
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] == "PID" {
+			continue
+		}
+		host, portS, err := net.SplitHostPort(fields[1])
+		if err != nil {
+			continue
+		}
+		port, err := strconv.ParseUint(portS, 10, 16)
+		if err != nil {
+			continue
+		}
+
+		m := &MongoDBMember{
+			Host: host,
+			Port: uint16(port),
+			Type: fields[2],
+		}
+		if len(fields) > 3 && m.Type != "mongos" {
+			m.ReplicationSet = fields[3]
+		}
+		res = append(res, m)
+	}
+	return res
+}
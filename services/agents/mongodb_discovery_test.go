@@ -0,0 +1,70 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestMongoDBDiscovery(t *testing.T) {
+	const output = `# Instances ##############################################################################################
+  PID    Host                         Type                      ReplSet                   Engine
+    1 mongo1:27017                   PRIMARY                    rs0                       wiredTiger
+    1 mongo2:27017                   SECONDARY                  rs0                       wiredTiger
+    1 10.0.0.3:27018                 ARBITER                    rs0
+# Report On mongo1:27017 ########################################
+                     User | mongodb
+`
+
+	members := parsePTMongoDBSummaryMembers(output)
+	assert.Equal(t, []*MongoDBMember{
+		{Host: "mongo1", Port: 27017, Type: "PRIMARY", ReplicationSet: "rs0"},
+		{Host: "mongo2", Port: 27017, Type: "SECONDARY", ReplicationSet: "rs0"},
+		{Host: "10.0.0.3", Port: 27018, Type: "ARBITER", ReplicationSet: "rs0"},
+	}, members)
+
+	t.Run("ClusterName", func(t *testing.T) {
+		seed := &models.Service{ServiceName: "mongo1"}
+		assert.Equal(t, "rs0", mongoDBClusterName(seed, members))
+
+		sharded := append([]*MongoDBMember{{Host: "mongo4", Port: 27017, Type: "PRIMARY", ReplicationSet: "rs1"}}, members...)
+		assert.Equal(t, "mongo1", mongoDBClusterName(seed, sharded))
+
+		seed.Cluster = "prod"
+		assert.Equal(t, "prod", mongoDBClusterName(seed, sharded))
+	})
+
+	t.Run("FindService", func(t *testing.T) {
+		nodes := []*models.Node{
+			{NodeID: "/node_id/1", NodeName: "mongo1", Address: "10.0.0.1"},
+			{NodeID: "/node_id/3", NodeName: "db3", Address: "10.0.0.3"},
+		}
+		services := []*models.Service{
+			{ServiceID: "/service_id/1", NodeID: "/node_id/1", Address: pointer.ToString("127.0.0.1"), Port: pointer.ToUint16(27017)},
+			{ServiceID: "/service_id/3", NodeID: "/node_id/3", Address: pointer.ToString("10.0.0.3"), Port: pointer.ToUint16(27017)},
+		}
+
+		assert.Equal(t, services[0], findMongoDBService(members[0], services, nodes))
+		assert.Nil(t, findMongoDBService(members[1], services, nodes))
+		assert.Nil(t, findMongoDBService(members[2], services, nodes), "port mismatch")
+	})
+}
@@ -0,0 +1,301 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/AlekSi/pointer"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// pseudoFilesystems are not reported as Node disks.
+var pseudoFilesystems = map[string]struct{}{
+	"tmpfs":    {},
+	"devtmpfs": {},
+	"squashfs": {},
+	"overlay":  {},
+}
+
+// NodeFactsService collects OS facts of Nodes with pt-summary Action on pmm-agents running on them.
+type NodeFactsService struct {
+	db      *reform.DB
+	r       *Registry
+	actions *ActionsService
+	l       *logrus.Entry
+}
+
+// NewNodeFactsService creates new Node facts service.
+func NewNodeFactsService(db *reform.DB, r *Registry) *NodeFactsService {
+	return &NodeFactsService{
+		db:      db,
+		r:       r,
+		actions: NewActionsService(r),
+		l:       logrus.WithField("component", "node-facts"),
+	}
+}
+
+// CollectFacts starts facts collection on connected pmm-agent running on Node with given ID.
+// It returns Action ID; facts are stored on Node when Action is done.
+func (s *NodeFactsService) CollectFacts(ctx context.Context, nodeID string) (string, error) {
+	var pmmAgentID string
+	var res *models.ActionResult
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		if _, err := models.FindNodeByID(tx.Querier, nodeID); err != nil {
+			return err
+		}
+
+		pmmAgents, err := models.FindPMMAgentsRunningOnNode(tx.Querier, nodeID)
+		if err != nil {
+			return err
+		}
+		for _, agent := range pmmAgents {
+			if s.r.IsConnected(agent.AgentID) {
+				pmmAgentID = agent.AgentID
+				break
+			}
+		}
+		if pmmAgentID == "" {
+			return status.Errorf(codes.FailedPrecondition, "No connected pmm-agent runs on Node with ID %q.", nodeID)
+		}
+
+		if res, err = models.CreateActionResult(tx.Querier, pmmAgentID); err != nil {
+			return err
+		}
+		_, err = models.StartNodeFactsCollection(tx.Querier, nodeID, res.ID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.actions.StartPTSummaryAction(ctx, res.ID, pmmAgentID); err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// GetFacts returns OS facts of Node with given ID; nil if they were not collected yet.
+func (s *NodeFactsService) GetFacts(ctx context.Context, nodeID string) (*models.NodeFacts, error) {
+	var node *models.Node
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		node, err = models.FindNodeByID(tx.Querier, nodeID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return node.Facts, nil
+}
+
+// Search returns Nodes with OS facts containing given string.
+func (s *NodeFactsService) Search(ctx context.Context, search string) ([]*models.Node, error) {
+	if search == "" {
+		return nil, status.Error(codes.InvalidArgument, "Empty search string.")
+	}
+
+	var res []*models.Node
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindNodes(tx.Querier, models.NodeFilters{Facts: search})
+		return err
+	})
+	return res, err
+}
+
+// collectMissingFacts starts facts collection for the Node of given pmm-agent if they were not collected yet.
+// It is called when pmm-agent connects, so facts of newly registered Nodes are collected.
+func (s *NodeFactsService) collectMissingFacts(ctx context.Context, pmmAgentID string) {
+	var nodeID string
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		agent, err := models.FindAgentByID(tx.Querier, pmmAgentID)
+		if err != nil {
+			return err
+		}
+		node, err := models.FindNodeByID(tx.Querier, pointer.GetString(agent.RunsOnNodeID))
+		if err != nil {
+			return err
+		}
+		if node.Facts == nil {
+			nodeID = node.NodeID
+		}
+		return nil
+	})
+	if err != nil {
+		s.l.Warnf("Failed to check Node facts of %s: %s.", pmmAgentID, err)
+		return
+	}
+	if nodeID == "" {
+		return
+	}
+
+	if _, err = s.CollectFacts(ctx, nodeID); err != nil {
+		s.l.Warnf("Failed to collect facts of Node %s: %s.", nodeID, err)
+	}
+}
+
+// handleNodeFactsActionResult stores Node facts if finished Action with given ID was started by CollectFacts.
+func handleNodeFactsActionResult(q *reform.Querier, actionID, output, actionError string) error {
+	var facts *models.NodeFacts
+	if actionError == "" {
+		facts = parsePTSummaryFacts(output)
+		facts.CollectedAt = models.Now()
+	}
+
+	_, err := models.FinishNodeFactsCollection(q, actionID, facts)
+	return err
+}
+
+// parsePTSummaryFacts extracts OS facts from pt-summary output.
+func parsePTSummaryFacts(output string) *models.NodeFacts {
+	facts := new(models.NodeFacts)
+
+	var section string
+	s := bufio.NewScanner(strings.NewReader(output))
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "# ") {
+			section = strings.TrimSpace(strings.Trim(line, "# "))
+			continue
+		}
+
+		if section == "Mounted Filesystems" {
+			if disk := parsePTSummaryDisk(line); disk != nil {
+				facts.Disks = append(facts.Disks, disk)
+			}
+			continue
+		}
+
+		parts := strings.SplitN(line, " | ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		switch section {
+		case "Processor":
+			if key == "Processors" {
+				facts.CPUCount = parsePTSummaryVirtualCPUs(value)
+			}
+		case "Memory":
+			if key == "Total" {
+				facts.MemoryBytes = parsePTSummarySize(value)
+			}
+		default:
+			switch key {
+			case "Hostname":
+				facts.Hostname = value
+			case "Platform":
+				facts.Platform = value
+			case "Release":
+				facts.Release = value
+			case "Kernel":
+				facts.Kernel = value
+			case "Architecture":
+				facts.Architecture = value
+			case "Virtualized":
+				facts.Virtualization = value
+			case "System":
+				facts.System = value
+				facts.InstanceType = instanceTypeFromSystem(value)
+			}
+		}
+	}
+
+	return facts
+}
+
+// parsePTSummaryVirtualCPUs returns the number of virtual CPUs from
+// "physical = 1, cores = 2, virtual = 4, hyperthreading = yes" string.
+func parsePTSummaryVirtualCPUs(value string) int {
+	for _, part := range strings.Split(value, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == "virtual" {
+			n, _ := strconv.Atoi(strings.TrimSpace(kv[1]))
+			return n
+		}
+	}
+	return 0
+}
+
+// parsePTSummaryDisk parses "/dev/xvda1 20G 30% ext4 rw /" line of mounted filesystems section.
+func parsePTSummaryDisk(line string) *models.NodeDisk {
+	fields := strings.Fields(line)
+	if len(fields) < 6 || fields[0] == "Filesystem" {
+		return nil
+	}
+	if _, ok := pseudoFilesystems[fields[3]]; ok {
+		return nil
+	}
+
+	return &models.NodeDisk{
+		Filesystem: fields[0],
+		SizeBytes:  parsePTSummarySize(fields[1]),
+		Type:       fields[3],
+		MountPoint: strings.Join(fields[5:], " "),
+	}
+}
+
+// parsePTSummarySize parses human-readable size like "7.8G" to bytes; it returns 0 if size can't be parsed.
+func parsePTSummarySize(value string) uint64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	multiplier := float64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		multiplier = 1 << 10
+	case 'M':
+		multiplier = 1 << 20
+	case 'G':
+		multiplier = 1 << 30
+	case 'T':
+		multiplier = 1 << 40
+	case 'P':
+		multiplier = 1 << 50
+	}
+	if multiplier != 1 {
+		value = value[:len(value)-1]
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0
+	}
+	return uint64(f * multiplier)
+}
+
+// instanceTypeFromSystem returns cloud instance type from pt-summary System value like
+// "Amazon EC2; t3.large; v (Other)", or empty string if it is not known.
+func instanceTypeFromSystem(system string) string {
+	parts := strings.Split(system, ";")
+	if len(parts) < 2 || strings.TrimSpace(parts[0]) != "Amazon EC2" {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,87 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestParsePTSummaryFacts(t *testing.T) {
+	const output = `# Percona Toolkit System Summary Report ######################
+        Date | 2021-06-01 10:00:00 UTC (local TZ: UTC +0000)
+    Hostname | db1
+      Uptime | 10 days,  2:03,  1 user,  load average: 0.00, 0.01, 0.05
+      System | Amazon EC2; t3.large; v (Other)
+ Service Tag | ec2-1234
+    Platform | Linux
+     Release | Ubuntu 20.04.2 LTS (focal)
+      Kernel | 5.4.0-1045-aws
+Architecture | CPU = 64-bit, OS = 64-bit
+   Threading | NPTL 2.31
+     SELinux | No SELinux detected
+ Virtualized | KVM
+# Processor ##################################################
+  Processors | physical = 1, cores = 1, virtual = 2, hyperthreading = yes
+      Models | 2xIntel(R) Xeon(R) Platinum 8259CL CPU @ 2.50GHz
+# Memory #####################################################
+       Total | 7.6G
+        Free | 1.2G
+# Mounted Filesystems ############################################
+  Filesystem  Size Used Type     Opts       Mountpoint
+  /dev/root    97G  12% ext4     rw         /
+  /dev/nvme1n1 500G 40% xfs      rw,noatime /var/lib/mysql
+  tmpfs       3.8G   0% tmpfs    rw         /dev/shm
+# Disk Schedulers And Queue Size #############################
+     nvme0n1 | [none] 255
+`
+
+	expected := &models.NodeFacts{
+		Hostname:       "db1",
+		Platform:       "Linux",
+		Release:        "Ubuntu 20.04.2 LTS (focal)",
+		Kernel:         "5.4.0-1045-aws",
+		Architecture:   "CPU = 64-bit, OS = 64-bit",
+		Virtualization: "KVM",
+		System:         "Amazon EC2; t3.large; v (Other)",
+		InstanceType:   "t3.large",
+		CPUCount:       2,
+		MemoryBytes:    8160437862, // 7.6G
+		Disks: []*models.NodeDisk{
+			{Filesystem: "/dev/root", Type: "ext4", MountPoint: "/", SizeBytes: 97 << 30},
+			{Filesystem: "/dev/nvme1n1", Type: "xfs", MountPoint: "/var/lib/mysql", SizeBytes: 500 << 30},
+		},
+	}
+	assert.Equal(t, expected, parsePTSummaryFacts(output))
+}
+
+func TestParsePTSummarySize(t *testing.T) {
+	for value, expected := range map[string]uint64{
+		"":     0,
+		"foo":  0,
+		"512":  512,
+		"2k":   2 << 10,
+		"1.5M": 3 << 19,
+		"16G":  16 << 30,
+		"2T":   2 << 40,
+	} {
+		assert.Equal(t, expected, parsePTSummarySize(value), "value = %q", value)
+	}
+}
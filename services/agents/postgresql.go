@@ -19,6 +19,7 @@ package agents
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/AlekSi/pointer"
@@ -32,6 +33,9 @@ import (
 
 var postgresExporterAutodiscoveryVersion = version.MustParse("2.15.99")
 
+// postgresExporterExcludeDatabases are never monitored by postgres_exporter with autodiscovery.
+var postgresExporterExcludeDatabases = []string{"template0", "template1", "postgres", "cloudsqladmin", "pmm-managed-dev", "azure_maintenance"}
+
 // postgresExporterConfig returns desired configuration of postgres_exporter process.
 func postgresExporterConfig(service *models.Service, exporter *models.Agent, redactMode redactMode,
 	pmmAgentVersion *version.Parsed) *agentpb.SetStateRequest_AgentProcess {
@@ -54,10 +58,7 @@ func postgresExporterConfig(service *models.Service, exporter *models.Agent, red
 	}
 
 	if !pmmAgentVersion.Less(postgresExporterAutodiscoveryVersion) {
-		args = append(args,
-			"--auto-discover-databases",
-			"--exclude-databases=template0,template1,postgres,cloudsqladmin,pmm-managed-dev,azure_maintenance",
-		)
+		args = append(args, postgresExporterAutodiscoveryArgs(service.DatabaseAutodiscovery)...)
 	}
 
 	if pointer.GetString(exporter.MetricsPath) != "" {
@@ -90,6 +91,55 @@ func postgresExporterConfig(service *models.Service, exporter *models.Agent, red
 	return res
 }
 
+// postgresExporterAutodiscoveryArgs returns postgres_exporter flags for given databases autodiscovery settings.
+// Autodiscovery is disabled (only the database from DSN is monitored) if settings require listed databases
+// that are not known yet, or if there are more databases than allowed; that prevents connection storms
+// against instances with thousands of databases.
+func postgresExporterAutodiscoveryArgs(d *models.DatabaseAutodiscovery) []string {
+	exclude := append([]string{}, postgresExporterExcludeDatabases...)
+
+	switch {
+	case d == nil:
+		// use defaults
+	case d.Disabled:
+		return nil
+	case d.NeedsDatabases():
+		if d.Databases == nil {
+			return nil
+		}
+
+		skip := make(map[string]struct{}, len(exclude)+len(d.Databases))
+		for _, db := range exclude {
+			skip[db] = struct{}{}
+		}
+
+		var monitored int
+		for _, db := range d.MonitoredDatabases() {
+			if _, ok := skip[db]; !ok {
+				skip[db] = struct{}{}
+				monitored++
+			}
+		}
+		if d.MaxDatabases > 0 && monitored > int(d.MaxDatabases) {
+			return nil
+		}
+
+		for _, db := range d.Databases {
+			if _, ok := skip[db]; !ok {
+				exclude = append(exclude, db)
+			}
+		}
+	default:
+		// there are no patterns, only exact names
+		exclude = append(exclude, d.Exclude...)
+	}
+
+	return []string{
+		"--auto-discover-databases",
+		"--exclude-databases=" + strings.Join(exclude, ","),
+	}
+}
+
 // qanPostgreSQLPgStatementsAgentConfig returns desired configuration of qan-mongodb-profiler-agent built-in agent.
 func qanPostgreSQLPgStatementsAgentConfig(service *models.Service, agent *models.Agent) *agentpb.SetStateRequest_BuiltinAgent {
 	tdp := agent.TemplateDelimiters(service)
@@ -200,3 +200,55 @@ func TestPostgresExporterConfig(t *testing.T) {
 		require.Equal(t, expected, actual)
 	})
 }
+
+func TestPostgresExporterAutodiscoveryArgs(t *testing.T) {
+	const defaultExclude = "--exclude-databases=template0,template1,postgres,cloudsqladmin,pmm-managed-dev,azure_maintenance"
+	databases := []string{"app_1", "app_2", "app_3", "postgres", "reports"}
+
+	for _, tc := range []struct {
+		name     string
+		settings *models.DatabaseAutodiscovery
+		expected []string
+	}{{
+		name:     "Defaults",
+		expected: []string{"--auto-discover-databases", defaultExclude},
+	}, {
+		name:     "Disabled",
+		settings: &models.DatabaseAutodiscovery{Disabled: true},
+	}, {
+		name:     "ExactExclude",
+		settings: &models.DatabaseAutodiscovery{Exclude: []string{"reports"}},
+		expected: []string{"--auto-discover-databases", defaultExclude + ",reports"},
+	}, {
+		name:     "NotListed",
+		settings: &models.DatabaseAutodiscovery{Include: []string{"app_*"}},
+	}, {
+		name:     "Patterns",
+		settings: &models.DatabaseAutodiscovery{Include: []string{"app_*"}, Exclude: []string{"*_2"}, Databases: databases},
+		expected: []string{"--auto-discover-databases", defaultExclude + ",app_2,reports"},
+	}, {
+		name:     "MaxDatabases",
+		settings: &models.DatabaseAutodiscovery{MaxDatabases: 3, Databases: databases},
+	}, {
+		name:     "WithinMaxDatabases",
+		settings: &models.DatabaseAutodiscovery{MaxDatabases: 4, Databases: databases},
+		expected: []string{"--auto-discover-databases", defaultExclude},
+	}, {
+		name:     "MaxDatabasesWithPatterns",
+		settings: &models.DatabaseAutodiscovery{MaxDatabases: 3, Include: []string{"app_*"}, Databases: databases},
+		expected: []string{"--auto-discover-databases", defaultExclude + ",reports"},
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, postgresExporterAutodiscoveryArgs(tc.settings))
+		})
+	}
+
+	t.Run("ParseListing", func(t *testing.T) {
+		b, err := agentpb.MarshalActionQuerySQLResult([]string{"datname"}, [][]interface{}{{"reports"}, {"app_1"}})
+		require.NoError(t, err)
+		actual, err := parseDatabasesListing(b)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"app_1", "reports"}, actual)
+	})
+}
@@ -236,6 +236,7 @@ func authenticate(md *agentpb.AgentConnectMetadata, q *reform.Querier) (string,
 	}
 
 	agent.Version = &md.Version
+	agent.LastSeenAt = pointer.ToTime(models.Now())
 	if err := q.Update(agent); err != nil {
 		return "", errors.Wrap(err, "failed to update agent")
 	}
@@ -263,6 +264,20 @@ func (r *Registry) unregister(pmmAgentID, disconnectReason string) *pmmAgentInfo
 	return agent
 }
 
+// Ping sends Ping message to connected pmm-agent with given ID and returns round-trip time.
+func (r *Registry) Ping(ctx context.Context, pmmAgentID string) (time.Duration, error) {
+	agent, err := r.get(pmmAgentID)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err = r.ping(ctx, agent); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
 // ping sends Ping message to given Agent, waits for Pong and observes round-trip time and clock drift.
 func (r *Registry) ping(ctx context.Context, agent *pmmAgentInfo) error {
 	l := logger.Get(ctx)
@@ -0,0 +1,327 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona/pmm/api/agentpb"
+	"github.com/percona/pmm/version"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/logger"
+)
+
+const (
+	// requirementsCheckTimeout limits duration of a single requirements check query.
+	requirementsCheckTimeout = 10 * time.Second
+	// requirementsResultInterval is an interval of checking for query Action result.
+	requirementsResultInterval = 200 * time.Millisecond
+)
+
+// pmm-agent versions with query Actions support.
+var requirementsCheckPMMAgentVersion = version.MustParse("2.6.0")
+
+// Minimal supported database server versions.
+var (
+	minMySQLVersion      = serverVersion{5, 6}
+	minPostgreSQLVersion = serverVersion{10, 0}
+	minMongoDBVersion    = serverVersion{3, 6}
+)
+
+// RequiredMySQLPrivileges are global privileges required for MySQL monitoring.
+var RequiredMySQLPrivileges = []string{"SELECT", "PROCESS", "REPLICATION CLIENT"}
+
+// PostgreSQLMonitoringRole is a role required for PostgreSQL monitoring by non-superuser.
+const PostgreSQLMonitoringRole = "pg_monitor"
+
+// mysqlRequirementsQuery returns server version, current user, its global and performance_schema privileges;
+// "SELECT " is added by pmm-agent.
+const mysqlRequirementsQuery = `@@version AS version, CURRENT_USER() AS user, ` +
+	`(SELECT GROUP_CONCAT(PRIVILEGE_TYPE) FROM information_schema.USER_PRIVILEGES ` +
+	`WHERE GRANTEE = CONCAT("'", REPLACE(CURRENT_USER(), '@', "'@'"), "'")) AS privileges, ` +
+	`(SELECT GROUP_CONCAT(PRIVILEGE_TYPE) FROM information_schema.SCHEMA_PRIVILEGES ` +
+	`WHERE GRANTEE = CONCAT("'", REPLACE(CURRENT_USER(), '@', "'@'"), "'") AND TABLE_SCHEMA = 'performance_schema') AS performance_schema_privileges`
+
+// postgresqlRequirementsQuery returns server version, current user's monitoring role membership
+// and pg_stat_statements extension presence.
+const postgresqlRequirementsQuery = `current_setting('server_version_num') AS version_num, current_user AS user, ` +
+	`(SELECT rolsuper FROM pg_roles WHERE rolname = current_user) AS superuser, ` +
+	`pg_has_role(current_user, '` + PostgreSQLMonitoringRole + `', 'MEMBER') AS monitor, ` +
+	`EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements') AS pg_stat_statements`
+
+var serverVersionRE = regexp.MustCompile(`^(\d+)\.(\d+)`)
+
+// serverVersion represents major and minor version of database server.
+type serverVersion struct {
+	major, minor int
+}
+
+func (v serverVersion) less(other serverVersion) bool {
+	return v.major < other.major || (v.major == other.major && v.minor < other.minor)
+}
+
+func (v serverVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// parseServerVersion parses major and minor version from strings like "8.0.26-16" or "5.7.35-log".
+func parseServerVersion(s string) (serverVersion, error) {
+	m := serverVersionRE.FindStringSubmatch(s)
+	if m == nil {
+		return serverVersion{}, errors.Errorf("failed to parse version %q", s)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return serverVersion{major, minor}, nil
+}
+
+// checkRequirements checks that Service version is supported and monitoring user has required privileges
+// by running query Action on pmm-agent. It returns FailedPrecondition error with remediation message
+// if requirements are not met. Failures to perform the check itself are only logged.
+func (c *ConnectionChecker) checkRequirements(ctx context.Context, q *reform.Querier, service *models.Service, agent *models.Agent, pmmAgentID string) error {
+	l := logger.Get(ctx)
+
+	request := requirementsRequest(service, agent)
+	var check func(rows []map[string]interface{}) error
+	switch service.ServiceType {
+	case models.MySQLServiceType:
+		check = checkMySQLRequirements
+	case models.PostgreSQLServiceType:
+		check = checkPostgreSQLRequirements
+	case models.MongoDBServiceType:
+		check = checkMongoDBRequirements
+	default:
+		return nil
+	}
+
+	supported, err := requirementsCheckSupported(q, pmmAgentID)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		l.Infof("Skipping requirements check: pmm-agent version does not support it.")
+		return nil
+	}
+
+	rows, err := c.runQueryAction(ctx, pmmAgentID, request)
+	if err != nil {
+		l.Warnf("Failed to check requirements: %s.", err)
+		return nil
+	}
+	if len(rows) == 0 {
+		l.Warnf("Failed to check requirements: empty result.")
+		return nil
+	}
+
+	return check(rows)
+}
+
+// requirementsRequest returns query Action request for requirements check of given Service,
+// or nil if Service type is not supported.
+func requirementsRequest(service *models.Service, agent *models.Agent) *agentpb.StartActionRequest {
+	tdp := agent.TemplateDelimiters(service)
+	files := &agentpb.TextFiles{
+		Files:              agent.Files(),
+		TemplateLeftDelim:  tdp.Left,
+		TemplateRightDelim: tdp.Right,
+	}
+	request := &agentpb.StartActionRequest{
+		Timeout: durationpb.New(requirementsCheckTimeout),
+	}
+	switch service.ServiceType {
+	case models.MySQLServiceType:
+		request.Params = &agentpb.StartActionRequest_MysqlQuerySelectParams{
+			MysqlQuerySelectParams: &agentpb.StartActionRequest_MySQLQuerySelectParams{
+				Dsn:           agent.DSN(service, 2*time.Second, "", nil),
+				Query:         mysqlRequirementsQuery,
+				TlsFiles:      files,
+				TlsSkipVerify: agent.TLSSkipVerify,
+			},
+		}
+	case models.PostgreSQLServiceType:
+		request.Params = &agentpb.StartActionRequest_PostgresqlQuerySelectParams{
+			PostgresqlQuerySelectParams: &agentpb.StartActionRequest_PostgreSQLQuerySelectParams{
+				Dsn:   agent.DSN(service, 2*time.Second, "postgres", nil),
+				Query: postgresqlRequirementsQuery,
+			},
+		}
+	case models.MongoDBServiceType:
+		request.Params = &agentpb.StartActionRequest_MongodbQueryBuildinfoParams{
+			MongodbQueryBuildinfoParams: &agentpb.StartActionRequest_MongoDBQueryBuildInfoParams{
+				Dsn:       agent.DSN(service, 2*time.Second, "", nil),
+				TextFiles: files,
+			},
+		}
+	default:
+		return nil
+	}
+	return request
+}
+
+// requirementsCheckSupported returns true if pmm-agent with given ID supports query Actions.
+func requirementsCheckSupported(q *reform.Querier, pmmAgentID string) (bool, error) {
+	pmmAgent, err := models.FindAgentByID(q, pmmAgentID)
+	if err != nil {
+		return false, err
+	}
+	pmmAgentVersion, err := version.Parse(pointer.GetString(pmmAgent.Version))
+	if err != nil {
+		return false, nil //nolint:nilerr
+	}
+	return !pmmAgentVersion.Less(requirementsCheckPMMAgentVersion), nil
+}
+
+// runQueryAction runs query Action on pmm-agent and waits for its result.
+func (c *ConnectionChecker) runQueryAction(ctx context.Context, pmmAgentID string, request *agentpb.StartActionRequest) ([]map[string]interface{}, error) {
+	res, err := models.CreateActionResult(c.r.db.Querier, pmmAgentID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.r.db.Delete(res); err != nil {
+			logger.Get(ctx).Warnf("Failed to delete action result %s: %s.", res.ID, err)
+		}
+	}()
+
+	pmmAgent, err := c.r.get(pmmAgentID)
+	if err != nil {
+		return nil, err
+	}
+	request.ActionId = res.ID
+	if _, err = pmmAgent.channel.SendAndWaitResponse(request); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requirementsCheckTimeout)
+	defer cancel()
+	ticker := time.NewTicker(requirementsResultInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		}
+
+		if res, err = models.FindActionResultByID(c.r.db.Querier, res.ID); err != nil {
+			return nil, err
+		}
+		if !res.Done {
+			continue
+		}
+		if res.Error != "" {
+			return nil, errors.New(res.Error)
+		}
+		return agentpb.UnmarshalActionQueryResult([]byte(res.Output))
+	}
+}
+
+// queryValue returns query Action result value as string.
+func queryValue(row map[string]interface{}, column string) string {
+	switch v := row[column].(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// quoteMySQLUser converts CURRENT_USER() result like "pmm@localhost" to 'pmm'@'localhost'.
+func quoteMySQLUser(user string) string {
+	i := strings.LastIndex(user, "@")
+	if i < 0 {
+		return "'" + user + "'"
+	}
+	return "'" + user[:i] + "'@'" + user[i+1:] + "'"
+}
+
+func checkMySQLRequirements(rows []map[string]interface{}) error {
+	row := rows[0]
+	if err := checkServerVersion("MySQL", queryValue(row, "version"), minMySQLVersion); err != nil {
+		return err
+	}
+
+	granted := make(map[string]struct{})
+	for _, p := range strings.Split(queryValue(row, "privileges"), ",") {
+		granted[strings.TrimSpace(p)] = struct{}{}
+	}
+	var missing []string
+	for _, p := range RequiredMySQLPrivileges {
+		if _, ok := granted[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return status.Errorf(codes.FailedPrecondition, "User %s is missing privileges %s required for monitoring. "+
+		"Grant them with: GRANT %s ON *.* TO %s;",
+		quoteMySQLUser(queryValue(row, "user")), strings.Join(missing, ", "),
+		strings.Join(RequiredMySQLPrivileges, ", "), quoteMySQLUser(queryValue(row, "user")))
+}
+
+func checkPostgreSQLRequirements(rows []map[string]interface{}) error {
+	row := rows[0]
+	versionNum, err := strconv.Atoi(queryValue(row, "version_num"))
+	if err != nil {
+		return errors.Wrap(err, "failed to parse PostgreSQL version")
+	}
+	// since PostgreSQL 10, server_version_num is major * 10000 + minor
+	v := serverVersion{versionNum / 10000, versionNum % 10000}
+	if v.less(minPostgreSQLVersion) {
+		return status.Errorf(codes.FailedPrecondition, "PostgreSQL version %d is not supported, minimal supported version is %d.",
+			versionNum, minPostgreSQLVersion.major)
+	}
+
+	if queryValue(row, "superuser") == "true" || queryValue(row, "monitor") == "true" {
+		return nil
+	}
+	user := queryValue(row, "user")
+	return status.Errorf(codes.FailedPrecondition, "User %q should be a superuser or a member of %s role for monitoring. "+
+		"Grant it with: GRANT %s TO %q;", user, PostgreSQLMonitoringRole, PostgreSQLMonitoringRole, user)
+}
+
+func checkMongoDBRequirements(rows []map[string]interface{}) error {
+	return checkServerVersion("MongoDB", queryValue(rows[0], "version"), minMongoDBVersion)
+}
+
+// checkServerVersion returns FailedPrecondition error if server version is less than minimal supported one.
+func checkServerVersion(name, s string, min serverVersion) error {
+	v, err := parseServerVersion(s)
+	if err != nil {
+		return err
+	}
+	if v.less(min) {
+		return status.Errorf(codes.FailedPrecondition, "%s version %s is not supported, minimal supported version is %s.", name, s, min)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestRequirements(t *testing.T) {
+	t.Run("MySQL", func(t *testing.T) {
+		row := map[string]interface{}{
+			"version":    []byte("8.0.26-16"),
+			"user":       "pmm@localhost",
+			"privileges": []byte("SELECT,RELOAD,PROCESS,REPLICATION CLIENT,BACKUP_ADMIN"),
+		}
+		assert.NoError(t, checkMySQLRequirements([]map[string]interface{}{row}))
+
+		row["privileges"] = []byte("SELECT")
+		expected := status.New(codes.FailedPrecondition, "User 'pmm'@'localhost' is missing privileges PROCESS, REPLICATION CLIENT required for monitoring. "+
+			"Grant them with: GRANT SELECT, PROCESS, REPLICATION CLIENT ON *.* TO 'pmm'@'localhost';")
+		tests.AssertGRPCError(t, expected, checkMySQLRequirements([]map[string]interface{}{row}))
+
+		row["version"] = "5.5.62-log"
+		expected = status.New(codes.FailedPrecondition, "MySQL version 5.5.62-log is not supported, minimal supported version is 5.6.")
+		tests.AssertGRPCError(t, expected, checkMySQLRequirements([]map[string]interface{}{row}))
+	})
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		row := map[string]interface{}{
+			"version_num": "130004",
+			"user":        "pmm",
+			"superuser":   false,
+			"monitor":     true,
+		}
+		assert.NoError(t, checkPostgreSQLRequirements([]map[string]interface{}{row}))
+
+		row["monitor"] = false
+		expected := status.New(codes.FailedPrecondition, `User "pmm" should be a superuser or a member of pg_monitor role for monitoring. `+
+			`Grant it with: GRANT pg_monitor TO "pmm";`)
+		tests.AssertGRPCError(t, expected, checkPostgreSQLRequirements([]map[string]interface{}{row}))
+
+		row["superuser"] = true
+		assert.NoError(t, checkPostgreSQLRequirements([]map[string]interface{}{row}))
+
+		row["version_num"] = "90624"
+		expected = status.New(codes.FailedPrecondition, "PostgreSQL version 90624 is not supported, minimal supported version is 10.")
+		tests.AssertGRPCError(t, expected, checkPostgreSQLRequirements([]map[string]interface{}{row}))
+	})
+
+	t.Run("MongoDB", func(t *testing.T) {
+		assert.NoError(t, checkMongoDBRequirements([]map[string]interface{}{{"version": "4.4.8"}}))
+		expected := status.New(codes.FailedPrecondition, "MongoDB version 3.4.24 is not supported, minimal supported version is 3.6.")
+		tests.AssertGRPCError(t, expected, checkMongoDBRequirements([]map[string]interface{}{{"version": "3.4.24"}}))
+	})
+}
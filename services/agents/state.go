@@ -183,7 +183,11 @@ func (u *StateUpdater) sendSetStateRequest(ctx context.Context, agent *pmmAgentI
 			if err != nil {
 				return errors.Wrapf(err, "cannot get agent scrape config for agent: %s", agent.id)
 			}
-			agentProcesses[row.AgentID] = vmAgentConfig(string(scrapeCfg))
+			settings, err := models.GetSettings(u.db.Querier)
+			if err != nil {
+				return err
+			}
+			agentProcesses[row.AgentID] = vmAgentConfig(string(scrapeCfg), settings.VictoriaMetrics.External)
 
 		case models.NodeExporterType:
 			node, err := models.FindNodeByID(u.db.Querier, pointer.GetString(row.NodeID))
@@ -18,16 +18,29 @@ package agents
 
 import (
 	"sort"
+	"strconv"
 
 	"github.com/percona/pmm/api/agentpb"
 	"github.com/percona/pmm/api/inventorypb"
+
+	"github.com/percona/pmm-managed/models"
 )
 
 // vmAgentConfig returns desired configuration of vmagent process.
-func vmAgentConfig(scrapeCfg string) *agentpb.SetStateRequest_AgentProcess {
+// If external metrics backend is configured, vmagent writes metrics directly to it.
+func vmAgentConfig(scrapeCfg string, external *models.ExternalVictoriaMetrics) *agentpb.SetStateRequest_AgentProcess {
+	remoteWriteURL := "{{.server_url}}/victoriametrics/api/v1/write"
+	insecure := "{{.server_insecure}}"
+	username, password := "{{.server_username}}", "{{.server_password}}"
+	if external != nil {
+		remoteWriteURL = external.WriteURL
+		insecure = strconv.FormatBool(external.InsecureSkipVerify)
+		username, password = external.Username, external.Password
+	}
+
 	args := []string{
-		"-remoteWrite.url={{.server_url}}/victoriametrics/api/v1/write",
-		"-remoteWrite.tlsInsecureSkipVerify={{.server_insecure}}",
+		"-remoteWrite.url=" + remoteWriteURL,
+		"-remoteWrite.tlsInsecureSkipVerify=" + insecure,
 		"-remoteWrite.tmpDataPath={{.tmp_dir}}/vmagent-temp-dir",
 		"-promscrape.config={{.TextFiles.vmagentscrapecfg}}",
 		// 1GB disk queue size.
@@ -41,8 +54,8 @@ func vmAgentConfig(scrapeCfg string) *agentpb.SetStateRequest_AgentProcess {
 	sort.Strings(args)
 
 	envs := []string{
-		"remoteWrite_basicAuth_username={{.server_username}}",
-		"remoteWrite_basicAuth_password={{.server_password}}",
+		"remoteWrite_basicAuth_username=" + username,
+		"remoteWrite_basicAuth_password=" + password,
 	}
 	sort.Strings(envs)
 
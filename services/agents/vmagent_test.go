@@ -0,0 +1,81 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package agents
+
+import (
+	"testing"
+
+	"github.com/percona/pmm/api/agentpb"
+	"github.com/percona/pmm/api/inventorypb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestVMAgentConfig(t *testing.T) {
+	t.Run("PMMServer", func(t *testing.T) {
+		actual := vmAgentConfig("scrape_configs: []", nil)
+		expected := &agentpb.SetStateRequest_AgentProcess{
+			Type:               inventorypb.AgentType_VM_AGENT,
+			TemplateLeftDelim:  "{{",
+			TemplateRightDelim: "}}",
+			Args: []string{
+				"-envflag.enable=true",
+				"-httpListenAddr=127.0.0.1:{{.listen_port}}",
+				"-loggerLevel=INFO",
+				"-promscrape.config={{.TextFiles.vmagentscrapecfg}}",
+				"-remoteWrite.maxDiskUsagePerURL=1073741824",
+				"-remoteWrite.tlsInsecureSkipVerify={{.server_insecure}}",
+				"-remoteWrite.tmpDataPath={{.tmp_dir}}/vmagent-temp-dir",
+				"-remoteWrite.url={{.server_url}}/victoriametrics/api/v1/write",
+			},
+			Env: []string{
+				"remoteWrite_basicAuth_password={{.server_password}}",
+				"remoteWrite_basicAuth_username={{.server_username}}",
+			},
+			TextFiles: map[string]string{
+				"vmagentscrapecfg": "scrape_configs: []",
+			},
+		}
+		requireNoDuplicateFlags(t, actual.Args)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("External", func(t *testing.T) {
+		actual := vmAgentConfig("scrape_configs: []", &models.ExternalVictoriaMetrics{
+			ReadURL:            "https://vmselect:8481/select/0/prometheus",
+			WriteURL:           "https://vminsert:8480/insert/0/prometheus/api/v1/write",
+			Username:           "user",
+			Password:           "secret",
+			InsecureSkipVerify: true,
+		})
+		require.Equal(t, []string{
+			"-envflag.enable=true",
+			"-httpListenAddr=127.0.0.1:{{.listen_port}}",
+			"-loggerLevel=INFO",
+			"-promscrape.config={{.TextFiles.vmagentscrapecfg}}",
+			"-remoteWrite.maxDiskUsagePerURL=1073741824",
+			"-remoteWrite.tlsInsecureSkipVerify=true",
+			"-remoteWrite.tmpDataPath={{.tmp_dir}}/vmagent-temp-dir",
+			"-remoteWrite.url=https://vminsert:8480/insert/0/prometheus/api/v1/write",
+		}, actual.Args)
+		require.Equal(t, []string{
+			"remoteWrite_basicAuth_password=secret",
+			"remoteWrite_basicAuth_username=user",
+		}, actual.Env)
+	})
+}
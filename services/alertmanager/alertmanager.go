@@ -21,15 +21,19 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/AlekSi/pointer"
 	httptransport "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
+	"github.com/percona-platform/saas/pkg/common"
 	"github.com/percona/pmm/api/alertmanager/amclient"
 	"github.com/percona/pmm/api/alertmanager/amclient/alert"
 	"github.com/percona/pmm/api/alertmanager/amclient/silence"
@@ -57,10 +61,20 @@ const (
 	alertmanagerDataDir = "/srv/alertmanager/data"
 	dirPerm             = os.FileMode(0o775)
 
-	alertmanagerConfigPath     = "/etc/alertmanager.yml"
+	// ConfigPath is a path of generated Alertmanager configuration file.
+	ConfigPath                 = "/etc/alertmanager.yml"
+	defaultBaseURL             = "http://127.0.0.1:9093/alertmanager"
 	alertmanagerBaseConfigPath = "/srv/alertmanager/alertmanager.base.yml"
 
 	receiverNameSeparator = " + "
+
+	// siemReceiver receives all alerts for forwarding to SIEM collector, see siem.Forwarder.
+	siemReceiver   = "siem"
+	siemWebhookURL = "http://127.0.0.1:7772/v1/management/ia/SIEM/Webhook"
+
+	// automationsReceiver receives all alerts while there are enabled automations, see automations.Service.
+	automationsReceiver   = "automations"
+	automationsWebhookURL = "http://127.0.0.1:7772/v1/management/ia/Automations/Webhook"
 )
 
 // Service is responsible for interactions with Alertmanager.
@@ -70,16 +84,101 @@ type Service struct {
 
 	l        *logrus.Entry
 	reloadCh chan struct{}
+
+	rw        sync.RWMutex
+	baseURL   *url.URL
+	transport http.RoundTripper
+	username  string
+	password  string
+	am        *amclient.Alertmanager
 }
 
 // New creates new service.
 func New(db *reform.DB) *Service {
-	return &Service{
+	svc := &Service{
 		db:       db,
-		client:   new(http.Client), // TODO instrument with utils/irt; see vmalert package https://jira.percona.com/browse/PMM-7229
 		l:        logrus.WithField("component", "alertmanager"),
 		reloadCh: make(chan struct{}, 1),
 	}
+	// TODO instrument with utils/irt; see vmalert package https://jira.percona.com/browse/PMM-7229
+	svc.client = &http.Client{
+		Transport: roundTripperFunc(svc.roundTrip),
+	}
+	if err := svc.SetEndpoint(nil); err != nil {
+		panic(err)
+	}
+	return svc
+}
+
+// roundTripperFunc is an adapter to allow the use of ordinary functions as HTTP round trippers.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// roundTrip sends request using current endpoint transport and credentials.
+func (svc *Service) roundTrip(req *http.Request) (*http.Response, error) {
+	svc.rw.RLock()
+	t, username, password := svc.transport, svc.username, svc.password
+	svc.rw.RUnlock()
+
+	if username != "" {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(username, password)
+	}
+	return t.RoundTrip(req)
+}
+
+// SetEndpoint configures remote Alertmanager endpoint, or switches back to the local one if endpoint is nil.
+func (svc *Service) SetEndpoint(endpoint *models.AlertingEndpoint) error {
+	u, err := url.Parse(defaultBaseURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	var username, password string
+	if endpoint != nil {
+		if u, err = url.Parse(endpoint.URL); err != nil {
+			return errors.WithStack(err)
+		}
+		if t.TLSClientConfig, err = endpoint.TLSConfig(); err != nil {
+			return err
+		}
+		username, password = endpoint.Username, endpoint.Password
+	}
+
+	rt := httptransport.NewWithClient(u.Host, path.Join("/", u.Path, "api", "v2"), []string{u.Scheme}, svc.client)
+	am := amclient.New(rt, strfmt.Default)
+
+	svc.rw.Lock()
+	old := svc.transport
+	svc.baseURL, svc.transport, svc.username, svc.password, svc.am = u, t, username, password, am
+	svc.rw.Unlock()
+
+	if old, ok := old.(*http.Transport); ok {
+		old.CloseIdleConnections()
+	}
+	svc.l.Infof("Using Alertmanager at %s.", u.Redacted())
+	return nil
+}
+
+// endpointURL returns URL of given path relative to the current base URL.
+func (svc *Service) endpointURL(elem ...string) string {
+	svc.rw.RLock()
+	u := *svc.baseURL
+	svc.rw.RUnlock()
+
+	u.Path = path.Join(append([]string{u.Path}, elem...)...)
+	return u.String()
+}
+
+// amClient returns Alertmanager API client for the current endpoint.
+func (svc *Service) amClient() *amclient.Alertmanager {
+	svc.rw.RLock()
+	defer svc.rw.RUnlock()
+	return svc.am
 }
 
 // GenerateBaseConfigs generates alertmanager.base.yml if it is absent,
@@ -118,12 +217,12 @@ receivers:
 
 	// Don't call updateConfiguration() there as Alertmanager is likely to be in the crash loop at the moment.
 	// Instead, write alertmanager.yml directly. main.go will request configuration update.
-	stat, err := os.Stat(alertmanagerConfigPath)
+	stat, err := os.Stat(ConfigPath)
 	if err != nil || int(stat.Size()) <= len("---\n") { // https://github.com/percona/pmm-server/blob/PMM-2.0/alertmanager.yml
-		svc.l.Infof("Creating %s", alertmanagerConfigPath)
-		err = ioutil.WriteFile(alertmanagerConfigPath, []byte(defaultBase), 0o644) //nolint:gosec
+		svc.l.Infof("Creating %s", ConfigPath)
+		err = ioutil.WriteFile(ConfigPath, []byte(defaultBase), 0o644) //nolint:gosec
 		if err != nil {
-			svc.l.Errorf("Failed to write %s: %s", alertmanagerConfigPath, err)
+			svc.l.Errorf("Failed to write %s: %s", ConfigPath, err)
 		}
 	}
 }
@@ -196,7 +295,7 @@ func (svc *Service) updateConfiguration(ctx context.Context) error {
 
 // reload asks Alertmanager to reload configuration.
 func (svc *Service) reload(ctx context.Context) error {
-	u := "http://127.0.0.1:9093/alertmanager/-/reload"
+	u := svc.endpointURL("-", "reload")
 	req, err := http.NewRequestWithContext(ctx, "POST", u, nil)
 	if err != nil {
 		return errors.WithStack(err)
@@ -242,8 +341,30 @@ func (svc *Service) loadBaseConfig() *alertmanager.Config {
 
 // marshalConfig marshals Alertmanager configuration.
 func (svc *Service) marshalConfig(base *alertmanager.Config) ([]byte, error) {
+	var b []byte
+	err := svc.db.InTransaction(func(tx *reform.TX) error {
+		var e error
+		b, e = svc.generateConfig(tx.Querier, base)
+		return e
+	})
+	return b, err
+}
+
+// GenerateConfig returns Alertmanager configuration for the state visible by given querier
+// without writing it and reloading Alertmanager.
+func (svc *Service) GenerateConfig(q *reform.Querier) ([]byte, error) {
+	return svc.generateConfig(q, svc.loadBaseConfig())
+}
+
+// ValidateConfig validates given Alertmanager configuration.
+func (svc *Service) ValidateConfig(ctx context.Context, cfg []byte) error {
+	return svc.validateConfig(ctx, cfg)
+}
+
+// generateConfig populates base configuration from the database and marshals it.
+func (svc *Service) generateConfig(q *reform.Querier, base *alertmanager.Config) ([]byte, error) {
 	cfg := base
-	if err := svc.populateConfig(cfg); err != nil {
+	if err := svc.populateConfig(q, cfg); err != nil {
 		return nil, err
 	}
 
@@ -288,12 +409,12 @@ func (svc *Service) validateConfig(ctx context.Context, cfg []byte) error {
 // configAndReload saves given Alertmanager configuration to file and reloads Alertmanager.
 // If configuration can't be reloaded for some reason, old file is restored, and configuration is reloaded again.
 func (svc *Service) configAndReload(ctx context.Context, b []byte) error {
-	oldCfg, err := ioutil.ReadFile(alertmanagerConfigPath)
+	oldCfg, err := ioutil.ReadFile(ConfigPath)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	fi, err := os.Stat(alertmanagerConfigPath)
+	fi, err := os.Stat(ConfigPath)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -302,7 +423,7 @@ func (svc *Service) configAndReload(ctx context.Context, b []byte) error {
 	var restore bool
 	defer func() {
 		if restore {
-			if err = ioutil.WriteFile(alertmanagerConfigPath, oldCfg, fi.Mode()); err != nil {
+			if err = ioutil.WriteFile(ConfigPath, oldCfg, fi.Mode()); err != nil {
 				svc.l.Error(err)
 			}
 			if err = svc.reload(ctx); err != nil {
@@ -316,7 +437,7 @@ func (svc *Service) configAndReload(ctx context.Context, b []byte) error {
 	}
 
 	restore = true
-	if err = ioutil.WriteFile(alertmanagerConfigPath, b, fi.Mode()); err != nil {
+	if err = ioutil.WriteFile(ConfigPath, b, fi.Mode()); err != nil {
 		return errors.WithStack(err)
 	}
 	if err = svc.reload(ctx); err != nil {
@@ -329,28 +450,42 @@ func (svc *Service) configAndReload(ctx context.Context, b []byte) error {
 }
 
 // populateConfig adds configuration from the database to cfg.
-func (svc *Service) populateConfig(cfg *alertmanager.Config) error {
+func (svc *Service) populateConfig(q *reform.Querier, cfg *alertmanager.Config) error {
 	var settings *models.Settings
 	var rules []*models.Rule
 	var channels []*models.Channel
-	e := svc.db.InTransaction(func(tx *reform.TX) error {
+	var tasks []*models.ScheduledTask
+	var automations []*models.Automation
+	e := func() error {
 		var err error
-		settings, err = models.GetSettings(tx.Querier)
+		settings, err = models.GetSettings(q)
+		if err != nil {
+			return err
+		}
+
+		rules, err = models.FindRules(q)
+		if err != nil {
+			return err
+		}
+
+		channels, err = models.FindChannels(q)
 		if err != nil {
 			return err
 		}
 
-		rules, err = models.FindRules(tx.Querier)
+		tasks, err = models.FindScheduledTasks(q, models.ScheduledTasksFilter{
+			Types: []models.ScheduledTaskType{models.ScheduledMySQLBackupTask, models.ScheduledMongoDBBackupTask},
+		})
 		if err != nil {
 			return err
 		}
 
-		channels, err = models.FindChannels(tx.Querier)
+		automations, err = models.FindAutomations(q, true)
 		if err != nil {
 			return err
 		}
 		return nil
-	})
+	}()
 	if e != nil {
 		return errors.Errorf("Failed to fetch items from database: %s", e)
 	}
@@ -392,6 +527,50 @@ func (svc *Service) populateConfig(cfg *alertmanager.Config) error {
 		}
 	}
 
+	// send all alerts to SIEM forwarder first, then continue with other routes
+	if settings.SIEM != nil {
+		siem := &alertmanager.Receiver{
+			Name: siemReceiver,
+			WebhookConfigs: []*alertmanager.WebhookConfig{{
+				NotifierConfig: alertmanager.NotifierConfig{
+					SendResolved: true,
+				},
+				URL: siemWebhookURL,
+			}},
+		}
+		if idx := findReceiverIdx(siemReceiver); idx != -1 {
+			cfg.Receivers[idx] = siem
+		} else {
+			cfg.Receivers = append(cfg.Receivers, siem)
+		}
+		cfg.Route.Routes = append([]*alertmanager.Route{{
+			Receiver: siemReceiver,
+			Continue: true,
+		}}, cfg.Route.Routes...)
+	}
+
+	// send all alerts to automations too, they are matched by automation filters
+	if len(automations) != 0 {
+		receiver := &alertmanager.Receiver{
+			Name: automationsReceiver,
+			WebhookConfigs: []*alertmanager.WebhookConfig{{
+				NotifierConfig: alertmanager.NotifierConfig{
+					SendResolved: true,
+				},
+				URL: automationsWebhookURL,
+			}},
+		}
+		if idx := findReceiverIdx(automationsReceiver); idx != -1 {
+			cfg.Receivers[idx] = receiver
+		} else {
+			cfg.Receivers = append(cfg.Receivers, receiver)
+		}
+		cfg.Route.Routes = append([]*alertmanager.Route{{
+			Receiver: automationsReceiver,
+			Continue: true,
+		}}, cfg.Route.Routes...)
+	}
+
 	if settings.IntegratedAlerting.EmailAlertingSettings != nil {
 		svc.l.Warn("Setting global email config, any user defined changes to the base config might be overwritten.")
 
@@ -414,6 +593,45 @@ func (svc *Service) populateConfig(cfg *alertmanager.Config) error {
 		chanMap[ch.ID] = ch
 	}
 	recvSet := make(map[string]models.ChannelIDs) // stores unique combinations of channel IDs
+	receiverFor := func(channelIDs []string) string {
+		enabledChannels := make(models.ChannelIDs, 0, len(channelIDs))
+		for _, chID := range channelIDs {
+			if channel, ok := chanMap[chID]; ok {
+				// throttled channels have their own routes, see throttledRoutesFor
+				if !channel.Disabled && channel.Throttling == nil {
+					enabledChannels = append(enabledChannels, chID)
+				}
+			}
+		}
+		if len(enabledChannels) == 0 {
+			return "disabled"
+		}
+
+		// make sure same slice with different order are not considered unique.
+		sort.Strings(enabledChannels)
+		recv := strings.Join(enabledChannels, receiverNameSeparator)
+		recvSet[recv] = enabledChannels
+		return recv
+	}
+
+	throttledRoutesFor := func(match, matchRE map[string]string, groupLabel string, channelIDs []string) []*alertmanager.Route {
+		ids := make([]string, len(channelIDs))
+		copy(ids, channelIDs)
+		sort.Strings(ids)
+
+		var res []*alertmanager.Route
+		for _, chID := range ids {
+			channel, ok := chanMap[chID]
+			if !ok || channel.Disabled || channel.Throttling == nil {
+				continue
+			}
+
+			recvSet[chID] = models.ChannelIDs{chID}
+			res = append(res, throttledRoute(match, matchRE, groupLabel, channel))
+		}
+		return res
+	}
+
 	for _, r := range rules {
 		// skip rules with 0 notification channels
 		if len(r.ChannelIDs) == 0 {
@@ -437,27 +655,29 @@ func (svc *Service) populateConfig(cfg *alertmanager.Config) error {
 				svc.l.Warnf("Unhandled filter: %+v", f)
 			}
 		}
-		enabledChannels := make(models.ChannelIDs, 0, len(r.ChannelIDs))
-		for _, chID := range r.ChannelIDs {
-			if channel, ok := chanMap[chID]; ok {
-				if !channel.Disabled {
-					enabledChannels = append(enabledChannels, chID)
-				}
-			}
-		}
-		// make sure same slice with different order are not considered unique.
-		sort.Strings(enabledChannels)
-		recv := strings.Join(enabledChannels, receiverNameSeparator)
-		if len(enabledChannels) == 0 {
-			recv = "disabled"
-		} else {
-			recvSet[recv] = enabledChannels
-		}
-		route.Receiver = recv
+		route.Receiver = receiverFor(r.ChannelIDs)
 
+		cfg.Route.Routes = append(cfg.Route.Routes, throttledRoutesFor(route.Match, route.MatchRE, "rule_id", r.ChannelIDs)...)
 		cfg.Route.Routes = append(cfg.Route.Routes, route)
 	}
 
+	// notifications about finished scheduled backups, see backup.NotificationService
+	for _, t := range tasks {
+		n := t.BackupNotifications()
+		if n == nil || len(n.ChannelIDs) == 0 {
+			continue
+		}
+
+		match := map[string]string{
+			"backup_schedule_id": t.ID,
+		}
+		cfg.Route.Routes = append(cfg.Route.Routes, throttledRoutesFor(match, nil, "backup_schedule_id", n.ChannelIDs)...)
+		cfg.Route.Routes = append(cfg.Route.Routes, &alertmanager.Route{
+			Match:    match,
+			Receiver: receiverFor(n.ChannelIDs),
+		})
+	}
+
 	receivers, err := svc.generateReceivers(chanMap, recvSet)
 	if err != nil {
 		return err
@@ -467,6 +687,51 @@ func (svc *Service) populateConfig(cfg *alertmanager.Config) error {
 	return nil
 }
 
+// throttledRoute returns route with given matchers for the throttled channel.
+// Other routes are checked after it.
+//
+// All matching alerts are grouped by groupLabel into a single group, so Alertmanager sends
+// at most one notification per group interval. Digest mode is implemented by the child route
+// for low-severity alerts with group wait and group interval equal to the digest interval.
+func throttledRoute(match, matchRE map[string]string, groupLabel string, channel *models.Channel) *alertmanager.Route {
+	t := channel.Throttling
+	route := &alertmanager.Route{
+		Receiver: channel.ID,
+		GroupBy:  []string{groupLabel},
+		Match:    make(map[string]string, len(match)),
+		MatchRE:  make(map[string]string, len(matchRE)),
+		Continue: true,
+	}
+	for k, v := range match {
+		route.Match[k] = v
+	}
+	for k, v := range matchRE {
+		route.MatchRE[k] = v
+	}
+
+	if t.MaxNotifications != 0 {
+		route.GroupInterval = promconfig.Duration(t.Interval / time.Duration(t.MaxNotifications))
+	}
+
+	if t.DigestSeverity != "" {
+		// severities are ordered from the highest (emergency) to the lowest (debug)
+		var severities []string
+		for s := common.ParseSeverity(t.DigestSeverity); s <= common.Debug; s++ {
+			severities = append(severities, s.String())
+		}
+
+		route.Routes = []*alertmanager.Route{{
+			MatchRE: map[string]string{
+				"severity": strings.Join(severities, "|"),
+			},
+			GroupWait:     promconfig.Duration(t.DigestInterval),
+			GroupInterval: promconfig.Duration(t.DigestInterval),
+		}}
+	}
+
+	return route
+}
+
 // generateReceivers takes the channel map and a unique set of rule combinations and generates a slice of receivers.
 func (svc *Service) generateReceivers(chanMap map[string]*models.Channel, recvSet map[string]models.ChannelIDs) ([]*alertmanager.Receiver, error) {
 	receivers := make([]*alertmanager.Receiver, 0, len(recvSet))
@@ -571,7 +836,7 @@ func (svc *Service) SendAlerts(ctx context.Context, alerts ammodels.PostableAler
 	}
 
 	svc.l.Debugf("Sending %d alerts...", len(alerts))
-	_, err := amclient.Default.Alert.PostAlerts(&alert.PostAlertsParams{
+	_, err := svc.amClient().Alert.PostAlerts(&alert.PostAlertsParams{
 		Alerts:  alerts,
 		Context: ctx,
 	})
@@ -582,7 +847,7 @@ func (svc *Service) SendAlerts(ctx context.Context, alerts ammodels.PostableAler
 
 // GetAlerts returns alerts available in alertmanager.
 func (svc *Service) GetAlerts(ctx context.Context) ([]*ammodels.GettableAlert, error) {
-	resp, err := amclient.Default.Alert.GetAlerts(&alert.GetAlertsParams{
+	resp, err := svc.amClient().Alert.GetAlerts(&alert.GetAlertsParams{
 		Context: ctx,
 	})
 	if err != nil {
@@ -632,7 +897,7 @@ func (svc *Service) Silence(ctx context.Context, id string) error {
 
 	starts := strfmt.DateTime(time.Now())
 	ends := strfmt.DateTime(time.Now().Add(100 * 365 * 24 * time.Hour)) // Mute for 100 years
-	_, err = amclient.Default.Silence.PostSilences(&silence.PostSilencesParams{
+	_, err = svc.amClient().Silence.PostSilences(&silence.PostSilencesParams{
 		Silence: &ammodels.PostableSilence{
 			Silence: ammodels.Silence{
 				Comment:   pointer.ToString(""),
@@ -656,7 +921,7 @@ func (svc *Service) Unsilence(ctx context.Context, id string) error {
 	}
 
 	for _, silenceID := range a.Status.SilencedBy {
-		_, err = amclient.Default.Silence.DeleteSilence(&silence.DeleteSilenceParams{
+		_, err = svc.amClient().Silence.DeleteSilence(&silence.DeleteSilenceParams{
 			SilenceID: strfmt.UUID(silenceID),
 			Context:   ctx,
 		})
@@ -669,9 +934,50 @@ func (svc *Service) Unsilence(ctx context.Context, id string) error {
 	return nil
 }
 
+// CreateSilence creates silence for alerts matching all given matchers from now till given time and returns its ID.
+func (svc *Service) CreateSilence(ctx context.Context, matchers []models.SilenceMatcher, endsAt time.Time, comment string) (string, error) {
+	ms := make([]*ammodels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		ms = append(ms, &ammodels.Matcher{
+			IsRegex: pointer.ToBool(m.IsRegex),
+			Name:    pointer.ToString(m.Name),
+			Value:   pointer.ToString(m.Value),
+		})
+	}
+
+	starts := strfmt.DateTime(time.Now())
+	ends := strfmt.DateTime(endsAt)
+	resp, err := svc.amClient().Silence.PostSilences(&silence.PostSilencesParams{
+		Silence: &ammodels.PostableSilence{
+			Silence: ammodels.Silence{
+				Comment:   pointer.ToString(comment),
+				CreatedBy: pointer.ToString("PMM"),
+				StartsAt:  &starts,
+				EndsAt:    &ends,
+				Matchers:  ms,
+			},
+		},
+		Context: ctx,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create silence")
+	}
+
+	return resp.Payload.SilenceID, nil
+}
+
+// DeleteSilence expires silence with given ID.
+func (svc *Service) DeleteSilence(ctx context.Context, id string) error {
+	_, err := svc.amClient().Silence.DeleteSilence(&silence.DeleteSilenceParams{
+		SilenceID: strfmt.UUID(id),
+		Context:   ctx,
+	})
+	return errors.Wrapf(err, "failed to delete silence with id %s", id)
+}
+
 // IsReady verifies that Alertmanager works.
 func (svc *Service) IsReady(ctx context.Context) error {
-	u := "http://127.0.0.1:9093/alertmanager/-/ready"
+	u := svc.endpointURL("-", "ready")
 	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return errors.WithStack(err)
@@ -693,9 +999,3 @@ func (svc *Service) IsReady(ctx context.Context) error {
 
 	return nil
 }
-
-// configure default client; we use it mainly because we can't remove it from generated code
-//nolint:gochecknoinits
-func init() {
-	amclient.Default.SetTransport(httptransport.New("127.0.0.1:9093", "/alertmanager/api/v2", []string{"http"}))
-}
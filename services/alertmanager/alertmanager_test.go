@@ -96,6 +96,44 @@ templates: []
 		assert.Equal(t, expected, actual, "actual:\n%s", actual)
 	})
 
+	t.Run("with automations", func(t *testing.T) {
+		tests.SetTestIDReader(t)
+		sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+		db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+		svc := New(db)
+
+		_, err := models.CreateAutomation(db.Querier, &models.CreateAutomationParams{
+			Name:       "diagnostic",
+			ActionType: models.DiagnosticAutomationAction,
+		})
+		require.NoError(t, err)
+
+		actual := marshalAndValidate(t, svc, svc.loadBaseConfig())
+		expected := strings.TrimSpace(`
+# Managed by pmm-managed. DO NOT EDIT.
+---
+global:
+    resolve_timeout: 0s
+    smtp_require_tls: false
+route:
+    receiver: empty
+    continue: false
+    routes:
+        - receiver: automations
+          continue: true
+receivers:
+    - name: empty
+    - name: disabled
+    - name: automations
+      webhook_configs:
+        - send_resolved: true
+          url: http://127.0.0.1:7772/v1/management/ia/Automations/Webhook
+          max_alerts: 0
+templates: []
+		`) + "\n"
+		assert.Equal(t, expected, actual, "actual:\n%s", actual)
+	})
+
 	t.Run("with receivers and routes", func(t *testing.T) {
 		tests.SetTestIDReader(t)
 		sqlDB := testdb.Open(t, models.SkipFixtures, nil)
@@ -319,6 +357,60 @@ templates: []
 	})
 }
 
+func TestThrottledRoute(t *testing.T) {
+	match := map[string]string{"rule_id": "/rule_id/1"}
+	matchRE := map[string]string{"service_name": "mysql-.*"}
+
+	t.Run("RateLimit", func(t *testing.T) {
+		channel := &models.Channel{
+			ID: "/channel_id/1",
+			Throttling: &models.ChannelThrottling{
+				MaxNotifications: 4,
+				Interval:         time.Hour,
+			},
+		}
+
+		expected := &alertmanager.Route{
+			Receiver:      "/channel_id/1",
+			GroupBy:       []string{"rule_id"},
+			Match:         map[string]string{"rule_id": "/rule_id/1"},
+			MatchRE:       map[string]string{"service_name": "mysql-.*"},
+			Continue:      true,
+			GroupInterval: promconfig.Duration(15 * time.Minute),
+		}
+		assert.Equal(t, expected, throttledRoute(match, matchRE, "rule_id", channel))
+	})
+
+	t.Run("Digest", func(t *testing.T) {
+		channel := &models.Channel{
+			ID: "/channel_id/1",
+			Throttling: &models.ChannelThrottling{
+				DigestSeverity: "notice",
+				DigestInterval: time.Hour,
+			},
+		}
+
+		expected := &alertmanager.Route{
+			Receiver: "/channel_id/1",
+			GroupBy:  []string{"backup_schedule_id"},
+			Match:    map[string]string{"rule_id": "/rule_id/1"},
+			MatchRE:  map[string]string{},
+			Continue: true,
+			Routes: []*alertmanager.Route{{
+				MatchRE:       map[string]string{"severity": "notice|info|debug"},
+				GroupWait:     promconfig.Duration(time.Hour),
+				GroupInterval: promconfig.Duration(time.Hour),
+			}},
+		}
+		actual := throttledRoute(match, nil, "backup_schedule_id", channel)
+		assert.Equal(t, expected, actual)
+
+		// matchers are copied
+		actual.Match["rule_id"] = "changed"
+		assert.Equal(t, "/rule_id/1", match["rule_id"])
+	})
+}
+
 func TestGenerateReceivers(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,483 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package automations runs actions triggered by Alertmanager webhook notifications.
+package automations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/backup"
+)
+
+const (
+	webhookTimeout = 30 * time.Second
+
+	firingStatus   = "firing"
+	resolvedStatus = "resolved"
+
+	// Alertmanager re-sends notifications for firing alerts on every repeat interval;
+	// an automation is triggered only once per alert during that period.
+	handledRetention = 24 * time.Hour
+)
+
+// WebhookMessage represents Alertmanager webhook notification payload (version 4).
+type WebhookMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []*WebhookAlert   `json:"alerts"`
+}
+
+// WebhookAlert represents a single alert of Alertmanager webhook notification.
+type WebhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Result represents a result of a single triggered automation.
+type Result struct {
+	AutomationID string `json:"automation_id"`
+	Fingerprint  string `json:"fingerprint"`
+	// Created annotation message, action ID, artifact ID, or webhook response status.
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Service runs configured automations for firing alerts.
+type Service struct {
+	db           *reform.DB
+	grafana      grafanaClient
+	actions      actionsService
+	backup       backupService
+	alertmanager alertmanagerService
+	client       *http.Client
+	l            *logrus.Entry
+
+	pagerDutyURL string
+
+	rw      sync.Mutex
+	handled map[string]time.Time
+}
+
+// New creates new automations service.
+func New(db *reform.DB, grafana grafanaClient, actions actionsService, backup backupService, alertmanager alertmanagerService) *Service {
+	return &Service{
+		db:           db,
+		grafana:      grafana,
+		actions:      actions,
+		backup:       backup,
+		alertmanager: alertmanager,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+		},
+		l:       logrus.WithField("component", "automations"),
+		handled: make(map[string]time.Time),
+
+		pagerDutyURL: pagerDutyEventsURL,
+	}
+}
+
+// HandleWebhook runs enabled automations matching firing alerts of Alertmanager notification.
+// Jira and PagerDuty automations are also run for resolved alerts to resolve incidents.
+// Notifications are sent by Alertmanager to the internal receiver configured while there are enabled automations.
+func (s *Service) HandleWebhook(ctx context.Context, msg *WebhookMessage) ([]*Result, error) {
+	var automations []*models.Automation
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if automations, err = models.FindAutomations(tx.Querier, true); err != nil {
+			return err
+		}
+
+		if len(automations) != 0 {
+			for _, alert := range msg.Alerts {
+				addOwnershipAnnotations(tx.Querier, alert)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*Result, 0)
+	for _, alert := range msg.Alerts {
+		for _, a := range automations {
+			switch {
+			case alert.Status == firingStatus:
+			case alert.Status == resolvedStatus && a.ActionType.SyncsResolution():
+			default:
+				continue
+			}
+
+			if !a.Filters.Match(alert.Labels) || !s.markHandled(a, alert) {
+				continue
+			}
+
+			r := &Result{
+				AutomationID: a.ID,
+				Fingerprint:  alert.Fingerprint,
+			}
+			r.Output, err = s.run(ctx, a, alert)
+			if err != nil {
+				s.l.Warnf("Automation %q failed for alert %s: %s.", a.Name, alert.Fingerprint, err)
+				r.Error = err.Error()
+			}
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+// ownershipAnnotations are names and titles of alert annotations with ownership information.
+var ownershipAnnotations = []struct {
+	name  string
+	title string
+}{
+	{"owner", "Owner"},
+	{"contact", "Contact"},
+	{"notes", "Notes"},
+}
+
+// addOwnershipAnnotations adds owner, contact and notes of alert's Service (or Node, if Service field is empty)
+// to alert annotations, so on-call engineers see them in tickets, incidents and webhooks.
+// Annotations set by alert rule are not changed.
+func addOwnershipAnnotations(q *reform.Querier, alert *WebhookAlert) {
+	var values [][]string
+	if id := alert.Labels["service_id"]; id != "" {
+		if service, err := models.FindServiceByID(q, id); err == nil {
+			values = append(values, []string{service.Owner, service.Contact, service.Notes})
+		}
+	}
+	if id := alert.Labels["node_id"]; id != "" {
+		if node, err := models.FindNodeByID(q, id); err == nil {
+			values = append(values, []string{node.Owner, node.Contact, node.Notes})
+		}
+	}
+
+	for i, a := range ownershipAnnotations {
+		if alert.Annotations[a.name] != "" {
+			continue
+		}
+		for _, v := range values {
+			if v[i] == "" {
+				continue
+			}
+			if alert.Annotations == nil {
+				alert.Annotations = make(map[string]string)
+			}
+			alert.Annotations[a.name] = v[i]
+			break
+		}
+	}
+}
+
+// ownershipDescription returns ownership annotations of alert as text lines.
+func ownershipDescription(alert *WebhookAlert) string {
+	var lines []string
+	for _, a := range ownershipAnnotations {
+		if v := alert.Annotations[a.name]; v != "" {
+			lines = append(lines, a.title+": "+v)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markHandled returns true if automation was not triggered yet for given alert and remembers it.
+func (s *Service) markHandled(a *models.Automation, alert *WebhookAlert) bool {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	now := time.Now()
+	for k, t := range s.handled {
+		if now.Sub(t) > handledRetention {
+			delete(s.handled, k)
+		}
+	}
+
+	key := a.ID + "/" + alert.Fingerprint + "/" + alert.StartsAt.String() + "/" + alert.Status
+	if _, ok := s.handled[key]; ok {
+		return false
+	}
+	s.handled[key] = now
+	return true
+}
+
+// run runs automation action for given firing or resolved alert and returns its output.
+func (s *Service) run(ctx context.Context, a *models.Automation, alert *WebhookAlert) (string, error) {
+	params := a.Params
+	if params == nil {
+		params = new(models.AutomationParams)
+	}
+
+	switch a.ActionType {
+	case models.AnnotationAutomationAction:
+		if params.Annotation == nil || params.Annotation.APIKey == "" {
+			return "", errors.New("Grafana API key of annotation automation is not set")
+		}
+		return s.createAnnotation(ctx, params.Annotation, alert)
+	case models.DiagnosticAutomationAction:
+		return s.startDiagnosticAction(ctx, alert)
+	case models.WebhookAutomationAction:
+		if params.Webhook == nil {
+			return "", errors.New("webhook parameters are not set")
+		}
+		return s.sendWebhook(ctx, a, params.Webhook, alert)
+	case models.BackupAutomationAction:
+		if params.Backup == nil {
+			return "", errors.New("backup parameters are not set")
+		}
+		return s.performBackup(ctx, a, params.Backup, alert)
+	case models.JiraAutomationAction:
+		if params.Jira == nil {
+			return "", errors.New("parameters of Jira automation are not set")
+		}
+		if alert.Status == resolvedStatus {
+			return s.resolveJiraIssue(ctx, params.Jira, alert)
+		}
+		return s.createJiraIssue(ctx, params.Jira, alert)
+	case models.PagerDutyAutomationAction:
+		if params.PagerDuty == nil {
+			return "", errors.New("parameters of PagerDuty automation are not set")
+		}
+		return s.sendPagerDutyEvent(ctx, params.PagerDuty, alert)
+	default:
+		return "", errors.Errorf("unsupported automation action type %q", a.ActionType)
+	}
+}
+
+// createAnnotation creates Grafana annotation for alert with automation's API key.
+func (s *Service) createAnnotation(ctx context.Context, params *models.AnnotationAutomationParams, alert *WebhookAlert) (string, error) {
+	text := alert.Annotations["summary"]
+	if params.Text != "" {
+		text = params.Text
+	}
+	tags := append([]string(nil), params.Tags...)
+	if text == "" {
+		text = alert.Labels["alertname"]
+	}
+	for _, l := range []string{"service_name", "node_name"} {
+		if v := alert.Labels[l]; v != "" {
+			tags = append(tags, v)
+		}
+	}
+
+	return s.grafana.CreateAnnotation(ctx, tags, alert.StartsAt, text, "Bearer "+params.APIKey)
+}
+
+// startDiagnosticAction starts pt-summary action on the alert's Node and returns action ID.
+func (s *Service) startDiagnosticAction(ctx context.Context, alert *WebhookAlert) (string, error) {
+	nodeID := alert.Labels["node_id"]
+	if nodeID == "" {
+		return "", errors.New("alert has no node_id label")
+	}
+
+	var pmmAgentID, actionID string
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		agents, err := models.FindPMMAgentsRunningOnNode(tx.Querier, nodeID)
+		if err != nil {
+			return err
+		}
+		if len(agents) == 0 {
+			return status.Error(codes.NotFound, "no pmm-agent running on this node")
+		}
+		pmmAgentID = agents[0].AgentID
+
+		res, err := models.CreateActionResult(tx.Querier, pmmAgentID)
+		if err != nil {
+			return err
+		}
+		actionID = res.ID
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.actions.StartPTSummaryAction(ctx, actionID, pmmAgentID); err != nil {
+		return "", err
+	}
+	return actionID, nil
+}
+
+// sendWebhook sends alert to outbound webhook (for example, to open a ticket) and returns response status.
+func (s *Service) sendWebhook(ctx context.Context, a *models.Automation, params *models.WebhookAutomationParams, alert *WebhookAlert) (string, error) {
+	b, err := json.Marshal(struct {
+		Automation string        `json:"automation"`
+		Alert      *WebhookAlert `json:"alert"`
+	}{a.Name, alert})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, params.URL, bytes.NewReader(b))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range params.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errors.Errorf("webhook returned %s", resp.Status)
+	}
+	return resp.Status, nil
+}
+
+// performBackup starts on-demand backup of alert's Service and returns artifact ID.
+func (s *Service) performBackup(ctx context.Context, a *models.Automation, params *models.BackupAutomationParams, alert *WebhookAlert) (string, error) {
+	serviceID := alert.Labels["service_id"]
+	if serviceID == "" {
+		return "", errors.New("alert has no service_id label")
+	}
+
+	return s.backup.PerformBackup(ctx, backup.PerformBackupParams{
+		ServiceID:  serviceID,
+		LocationID: params.LocationID,
+		Name:       a.Name + "-" + backup.TimestampPlaceholder,
+	})
+}
+
+// ListAutomations returns all automations.
+func (s *Service) ListAutomations(ctx context.Context) ([]*models.Automation, error) {
+	var res []*models.Automation
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindAutomations(tx.Querier, false)
+		return err
+	})
+	return res, err
+}
+
+// CreateAutomation creates automation.
+// For annotation automation, Grafana API key with Editor role is created with credentials of the current user.
+func (s *Service) CreateAutomation(ctx context.Context, params *models.CreateAutomationParams) (*models.Automation, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	var apiKeyID int64
+	if params.ActionType == models.AnnotationAutomationAction {
+		annotation := new(models.AnnotationAutomationParams)
+		if params.Params.Annotation != nil {
+			*annotation = *params.Params.Annotation
+		}
+
+		var err error
+		apiKeyName := fmt.Sprintf("pmm-automation-%s-%d", params.Name, rand.Int63()) //nolint:gosec
+		if annotation.APIKeyID, annotation.APIKey, err = s.grafana.CreateEditorAPIKey(ctx, apiKeyName); err != nil {
+			return nil, err
+		}
+		apiKeyID = annotation.APIKeyID
+
+		p := *params
+		p.Params = &models.AutomationParams{Annotation: annotation}
+		params = &p
+	}
+
+	var res *models.Automation
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.CreateAutomation(tx.Querier, params)
+		return err
+	})
+	if err != nil {
+		if apiKeyID != 0 {
+			if e := s.grafana.DeleteAPIKeyByID(ctx, apiKeyID); e != nil {
+				s.l.Warnf("Couldn't delete created API key %d: %s.", apiKeyID, e)
+			}
+		}
+		return nil, err
+	}
+
+	s.alertmanager.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// ChangeAutomation enables or disables automation.
+func (s *Service) ChangeAutomation(ctx context.Context, id string, disabled bool) (*models.Automation, error) {
+	var res *models.Automation
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeAutomation(tx.Querier, id, disabled)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.alertmanager.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// RemoveAutomation removes automation and its Grafana API key.
+func (s *Service) RemoveAutomation(ctx context.Context, id string) error {
+	var a *models.Automation
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if a, err = models.FindAutomationByID(tx.Querier, id); err != nil {
+			return err
+		}
+		return models.RemoveAutomation(tx.Querier, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	if a.Params != nil && a.Params.Annotation != nil && a.Params.Annotation.APIKeyID != 0 {
+		if err = s.grafana.DeleteAPIKeyByID(ctx, a.Params.Annotation.APIKeyID); err != nil {
+			s.l.Warnf("Couldn't delete API key %d of automation %q: %s.", a.Params.Annotation.APIKeyID, a.Name, err)
+		}
+	}
+
+	s.alertmanager.RequestConfigurationUpdate()
+	return nil
+}
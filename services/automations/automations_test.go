@@ -0,0 +1,187 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package automations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/backup"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestHandleWebhook(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	var received struct {
+		Automation string        `json:"automation"`
+		Alert      *WebhookAlert `json:"alert"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "secret", req.Header.Get("X-Token"))
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&received))
+		rw.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(ts.Close)
+
+	location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			PMMClientConfig: &models.PMMClientLocationConfig{Path: "/tmp"},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, params := range []*models.CreateAutomationParams{{
+		Name:       "annotate",
+		ActionType: models.AnnotationAutomationAction,
+		Params: &models.AutomationParams{
+			Annotation: &models.AnnotationAutomationParams{Tags: []string{"alert"}, APIKeyID: 1, APIKey: "key"},
+		},
+	}, {
+		Name:       "ticket",
+		Filters:    models.Filters{{Type: models.Equal, Key: "severity", Val: "critical"}},
+		ActionType: models.WebhookAutomationAction,
+		Params: &models.AutomationParams{
+			Webhook: &models.WebhookAutomationParams{URL: ts.URL, Headers: map[string]string{"X-Token": "secret"}},
+		},
+	}, {
+		Name:       "backup",
+		Filters:    models.Filters{{Type: models.Regex, Key: "alertname", Val: "Disk.*"}},
+		ActionType: models.BackupAutomationAction,
+		Params: &models.AutomationParams{
+			Backup: &models.BackupAutomationParams{LocationID: location.ID},
+		},
+	}} {
+		_, err = models.CreateAutomation(db.Querier, params)
+		require.NoError(t, err)
+	}
+
+	grafana := &mockGrafanaClient{}
+	backupService := &mockBackupService{}
+	t.Cleanup(func() {
+		grafana.AssertExpectations(t)
+		backupService.AssertExpectations(t)
+	})
+	s := New(db, grafana, &mockActionsService{}, backupService, &mockAlertmanagerService{})
+
+	startsAt := time.Now().Add(-time.Minute).UTC()
+	msg := &WebhookMessage{
+		Version: "4",
+		Status:  "firing",
+		Alerts: []*WebhookAlert{{
+			Status: "firing",
+			Labels: map[string]string{
+				"alertname":    "MySQLDown",
+				"severity":     "critical",
+				"service_name": "mysql",
+			},
+			Annotations: map[string]string{"summary": "MySQL is down"},
+			StartsAt:    startsAt,
+			Fingerprint: "1",
+		}, {
+			Status:      "resolved",
+			Labels:      map[string]string{"alertname": "DiskFull"},
+			StartsAt:    startsAt,
+			Fingerprint: "2",
+		}},
+	}
+
+	grafana.On("CreateAnnotation", ctx, []string{"alert", "mysql"}, startsAt, "MySQL is down", "Bearer key").
+		Return("Annotation added", nil).Once()
+	res, err := s.HandleWebhook(ctx, msg)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	for _, r := range res {
+		assert.Empty(t, r.Error)
+	}
+	assert.Equal(t, "ticket", received.Automation)
+	assert.Equal(t, "MySQLDown", received.Alert.Labels["alertname"])
+
+	// repeated notification does not trigger automations again
+	res, err = s.HandleWebhook(ctx, msg)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	msg.Alerts = []*WebhookAlert{{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "DiskFull", "service_id": "/service_id/1", "severity": "warning"},
+		StartsAt:    startsAt,
+		Fingerprint: "3",
+	}}
+	grafana.On("CreateAnnotation", ctx, []string{"alert"}, startsAt, "DiskFull", "Bearer key").Return("Annotation added", nil).Once()
+	backupService.On("PerformBackup", ctx, mock.MatchedBy(func(params backup.PerformBackupParams) bool {
+		return params.ServiceID == "/service_id/1" && params.LocationID == location.ID
+	})).Return("/artifact_id/1", nil).Once()
+	res, err = s.HandleWebhook(ctx, msg)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "/artifact_id/1", res[1].Output)
+}
+
+func TestAnnotationAutomationAPIKey(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	grafana := &mockGrafanaClient{}
+	alertmanager := &mockAlertmanagerService{}
+	t.Cleanup(func() {
+		grafana.AssertExpectations(t)
+		alertmanager.AssertExpectations(t)
+	})
+	s := New(db, grafana, &mockActionsService{}, &mockBackupService{}, alertmanager)
+
+	grafana.On("CreateEditorAPIKey", ctx, mock.MatchedBy(func(name string) bool {
+		return strings.HasPrefix(name, "pmm-automation-annotate-")
+	})).Return(int64(42), "key", nil).Once()
+	alertmanager.On("RequestConfigurationUpdate").Return().Twice()
+
+	a, err := s.CreateAutomation(ctx, &models.CreateAutomationParams{
+		Name:       "annotate",
+		ActionType: models.AnnotationAutomationAction,
+		Params: &models.AutomationParams{
+			Annotation: &models.AnnotationAutomationParams{Text: "alert", APIKey: "user-provided"},
+		},
+	})
+	require.NoError(t, err)
+	expected := &models.AnnotationAutomationParams{Text: "alert", APIKeyID: 42, APIKey: "key"}
+	assert.Equal(t, expected, a.Params.Annotation)
+
+	grafana.On("DeleteAPIKeyByID", ctx, int64(42)).Return(nil).Once()
+	require.NoError(t, s.RemoveAutomation(ctx, a.ID))
+}
@@ -0,0 +1,55 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package automations
+
+import (
+	"context"
+	"time"
+
+	"github.com/percona/pmm-managed/services/backup"
+)
+
+//go:generate mockery -name=grafanaClient -case=snake -inpkg -testonly
+//go:generate mockery -name=actionsService -case=snake -inpkg -testonly
+//go:generate mockery -name=backupService -case=snake -inpkg -testonly
+//go:generate mockery -name=alertmanagerService -case=snake -inpkg -testonly
+
+// grafanaClient is a subset of methods of grafana.Client used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type grafanaClient interface {
+	CreateAnnotation(ctx context.Context, tags []string, from time.Time, text, authorization string) (string, error)
+	CreateEditorAPIKey(ctx context.Context, name string) (int64, string, error)
+	DeleteAPIKeyByID(ctx context.Context, id int64) error
+}
+
+// actionsService is a subset of methods of agents.ActionsService used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type actionsService interface {
+	StartPTSummaryAction(ctx context.Context, id, pmmAgentID string) error
+}
+
+// backupService is a subset of methods of backup.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type backupService interface {
+	PerformBackup(ctx context.Context, params backup.PerformBackupParams) (string, error)
+}
+
+// alertmanagerService is a subset of methods of alertmanager.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type alertmanagerService interface {
+	RequestConfigurationUpdate()
+}
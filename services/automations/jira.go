@@ -0,0 +1,191 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package automations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// jiraLabelPrefix is a prefix of Jira issue label used as deduplication key tied to alert fingerprint.
+const jiraLabelPrefix = "pmm-alert-"
+
+// jiraRequest sends request to Jira REST API and decodes response into respBody if it is not nil.
+func (s *Service) jiraRequest(ctx context.Context, params *models.JiraAutomationParams, method, path string, body, respBody interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(params.URL, "/")+path, r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.SetBasicAuth(params.Username, params.APIToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected Jira response %s: %s", resp.Status, b)
+	}
+
+	if respBody == nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	return errors.WithStack(json.NewDecoder(resp.Body).Decode(respBody))
+}
+
+// findJiraIssue returns key of not resolved Jira issue for given alert, or empty string.
+func (s *Service) findJiraIssue(ctx context.Context, params *models.JiraAutomationParams, alert *WebhookAlert) (string, error) {
+	jql := fmt.Sprintf(`project = %q AND labels = %q AND statusCategory != Done`, params.ProjectKey, jiraLabelPrefix+alert.Fingerprint)
+	var resp struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	err := s.jiraRequest(ctx, params, http.MethodPost, "/rest/api/2/search", map[string]interface{}{
+		"jql":        jql,
+		"fields":     []string{"key"},
+		"maxResults": 1,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Issues) == 0 {
+		return "", nil
+	}
+	return resp.Issues[0].Key, nil
+}
+
+// jiraFields returns Jira issue fields for given alert.
+func jiraFields(params *models.JiraAutomationParams, alert *WebhookAlert) (map[string]interface{}, error) {
+	summary := alert.Annotations["summary"]
+	if summary == "" {
+		summary = alert.Labels["alertname"]
+	}
+
+	description := alert.Annotations["description"]
+	if ownership := ownershipDescription(alert); ownership != "" {
+		description = strings.TrimSpace(description + "\n\n" + ownership)
+	}
+
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": params.ProjectKey},
+		"issuetype":   map[string]string{"name": params.IssueType},
+		"summary":     summary,
+		"description": description,
+		"labels":      []string{jiraLabelPrefix + alert.Fingerprint},
+	}
+
+	for field, text := range params.Fields {
+		t, err := template.New(field).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid template of Jira field %q", field)
+		}
+		var buf bytes.Buffer
+		if err = t.Execute(&buf, alert); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template of Jira field %q", field)
+		}
+		fields[field] = buf.String()
+	}
+
+	return fields, nil
+}
+
+// createJiraIssue creates Jira issue for firing alert unless it already exists, and returns issue key.
+func (s *Service) createJiraIssue(ctx context.Context, params *models.JiraAutomationParams, alert *WebhookAlert) (string, error) {
+	key, err := s.findJiraIssue(ctx, params, alert)
+	if err != nil {
+		return "", err
+	}
+	if key != "" {
+		return key, nil
+	}
+
+	fields, err := jiraFields(params, alert)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Key string `json:"key"`
+	}
+	if err = s.jiraRequest(ctx, params, http.MethodPost, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Key, nil
+}
+
+// resolveJiraIssue applies resolve transition to Jira issue of resolved alert and returns issue key.
+func (s *Service) resolveJiraIssue(ctx context.Context, params *models.JiraAutomationParams, alert *WebhookAlert) (string, error) {
+	if params.ResolveTransition == "" {
+		return "", nil
+	}
+
+	key, err := s.findJiraIssue(ctx, params, alert)
+	if err != nil || key == "" {
+		return "", err
+	}
+
+	path := "/rest/api/2/issue/" + key + "/transitions"
+	var resp struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err = s.jiraRequest(ctx, params, http.MethodGet, path, nil, &resp); err != nil {
+		return "", err
+	}
+
+	for _, t := range resp.Transitions {
+		if strings.EqualFold(t.Name, params.ResolveTransition) {
+			body := map[string]interface{}{"transition": map[string]string{"id": t.ID}}
+			if err = s.jiraRequest(ctx, params, http.MethodPost, path, body, nil); err != nil {
+				return "", err
+			}
+			return key, nil
+		}
+	}
+	return "", errors.Errorf("transition %q is not available for Jira issue %s", params.ResolveTransition, key)
+}
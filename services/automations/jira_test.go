@@ -0,0 +1,123 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package automations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestJira(t *testing.T) {
+	ctx := context.Background()
+
+	var created map[string]interface{}
+	var transitioned bool
+	issues := []map[string]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/search", func(rw http.ResponseWriter, req *http.Request) {
+		username, password, _ := req.BasicAuth()
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "token", password)
+
+		var body struct {
+			JQL string `json:"jql"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, `project = "OPS" AND labels = "pmm-alert-abc" AND statusCategory != Done`, body.JQL)
+		assert.NoError(t, json.NewEncoder(rw).Encode(map[string]interface{}{"issues": issues}))
+	})
+	mux.HandleFunc("/rest/api/2/issue", func(rw http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		created = body.Fields
+		issues = append(issues, map[string]string{"key": "OPS-1"})
+		assert.NoError(t, json.NewEncoder(rw).Encode(map[string]string{"key": "OPS-1"}))
+	})
+	mux.HandleFunc("/rest/api/2/issue/OPS-1/transitions", func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			assert.NoError(t, json.NewEncoder(rw).Encode(map[string]interface{}{
+				"transitions": []map[string]string{{"id": "11", "name": "In Progress"}, {"id": "31", "name": "Done"}},
+			}))
+			return
+		}
+
+		var body struct {
+			Transition struct {
+				ID string `json:"id"`
+			} `json:"transition"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "31", body.Transition.ID)
+		transitioned = true
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	params := &models.JiraAutomationParams{
+		URL:               ts.URL + "/",
+		Username:          "user",
+		APIToken:          "token",
+		ProjectKey:        "OPS",
+		IssueType:         "Incident",
+		Fields:            map[string]string{"environment": "{{ .Labels.environment }}"},
+		ResolveTransition: "done",
+	}
+	alert := &WebhookAlert{
+		Status: firingStatus,
+		Labels: map[string]string{"alertname": "MySQLDown", "environment": "prod"},
+		Annotations: map[string]string{
+			"summary":     "MySQL is down",
+			"description": "MySQL is not responding.",
+			"owner":       "DBA team",
+			"contact":     "dba@example.com",
+		},
+		Fingerprint: "abc",
+	}
+
+	s := New(nil, nil, nil, nil, nil)
+	key, err := s.createJiraIssue(ctx, params, alert)
+	require.NoError(t, err)
+	assert.Equal(t, "OPS-1", key)
+	assert.Equal(t, "MySQL is down", created["summary"])
+	assert.Equal(t, "MySQL is not responding.\n\nOwner: DBA team\nContact: dba@example.com", created["description"])
+	assert.Equal(t, "prod", created["environment"])
+	assert.Equal(t, []interface{}{"pmm-alert-abc"}, created["labels"])
+
+	// existing issue is reused
+	created = nil
+	key, err = s.createJiraIssue(ctx, params, alert)
+	require.NoError(t, err)
+	assert.Equal(t, "OPS-1", key)
+	assert.Nil(t, created)
+
+	alert.Status = resolvedStatus
+	key, err = s.resolveJiraIssue(ctx, params, alert)
+	require.NoError(t, err)
+	assert.Equal(t, "OPS-1", key)
+	assert.True(t, transitioned)
+}
@@ -0,0 +1,134 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package automations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// pagerDutyEventsURL is PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutySeverities maps alert severities to PagerDuty event severities.
+var pagerDutySeverities = map[string]string{
+	"emergency": "critical",
+	"alert":     "critical",
+	"critical":  "critical",
+	"error":     "error",
+	"warning":   "warning",
+	"notice":    "info",
+	"info":      "info",
+	"debug":     "info",
+}
+
+// pagerDutyEvent represents PagerDuty Events API v2 event.
+type pagerDutyEvent struct {
+	RoutingKey  string                 `json:"routing_key"`
+	EventAction string                 `json:"event_action"`
+	DedupKey    string                 `json:"dedup_key"`
+	Payload     *pagerDutyEventPayload `json:"payload,omitempty"`
+}
+
+// pagerDutyEventPayload represents PagerDuty Events API v2 event payload.
+type pagerDutyEventPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// sendPagerDutyEvent triggers or resolves PagerDuty incident for alert, using alert fingerprint
+// as deduplication key, and returns that key.
+func (s *Service) sendPagerDutyEvent(ctx context.Context, params *models.PagerDutyAutomationParams, alert *WebhookAlert) (string, error) {
+	event := &pagerDutyEvent{
+		RoutingKey:  params.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.Fingerprint,
+	}
+
+	if alert.Status == resolvedStatus {
+		event.EventAction = "resolve"
+	} else {
+		summary := alert.Annotations["summary"]
+		if summary == "" {
+			summary = alert.Labels["alertname"]
+		}
+		source := alert.Labels["service_name"]
+		if source == "" {
+			source = alert.Labels["node_name"]
+		}
+		if source == "" {
+			source = "PMM"
+		}
+		severity := pagerDutySeverities[alert.Labels["severity"]]
+		if severity == "" {
+			severity = "error"
+		}
+
+		details := make(map[string]string, len(alert.Labels)+len(ownershipAnnotations))
+		for k, v := range alert.Labels {
+			details[k] = v
+		}
+		for _, a := range ownershipAnnotations {
+			if v := alert.Annotations[a.name]; v != "" {
+				details[a.name] = v
+			}
+		}
+
+		event.Payload = &pagerDutyEventPayload{
+			Summary:       summary,
+			Source:        source,
+			Severity:      severity,
+			Timestamp:     alert.StartsAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+			CustomDetails: details,
+		}
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.pagerDutyURL, bytes.NewReader(b))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", errors.Errorf("unexpected PagerDuty response %s: %s", resp.Status, b)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return event.DedupKey, nil
+}
@@ -0,0 +1,88 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package automations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestPagerDuty(t *testing.T) {
+	ctx := context.Background()
+
+	var events []*pagerDutyEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var event pagerDutyEvent
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&event))
+		events = append(events, &event)
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(ts.Close)
+
+	s := New(nil, nil, nil, nil, nil)
+	s.pagerDutyURL = ts.URL
+
+	params := &models.PagerDutyAutomationParams{RoutingKey: "key"}
+	alert := &WebhookAlert{
+		Status:      firingStatus,
+		Labels:      map[string]string{"alertname": "MySQLDown", "severity": "warning", "service_name": "mysql"},
+		Annotations: map[string]string{"owner": "DBA team"},
+		StartsAt:    time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+		Fingerprint: "abc",
+	}
+
+	key, err := s.sendPagerDutyEvent(ctx, params, alert)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", key)
+
+	alert.Status = resolvedStatus
+	_, err = s.sendPagerDutyEvent(ctx, params, alert)
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, &pagerDutyEvent{
+		RoutingKey:  "key",
+		EventAction: "trigger",
+		DedupKey:    "abc",
+		Payload: &pagerDutyEventPayload{
+			Summary:   "MySQLDown",
+			Source:    "mysql",
+			Severity:  "warning",
+			Timestamp: "2021-03-01T12:00:00.000Z",
+			CustomDetails: map[string]string{
+				"alertname":    "MySQLDown",
+				"severity":     "warning",
+				"service_name": "mysql",
+				"owner":        "DBA team",
+			},
+		},
+	}, events[0])
+	assert.Equal(t, &pagerDutyEvent{
+		RoutingKey:  "key",
+		EventAction: "resolve",
+		DedupKey:    "abc",
+	}, events[1])
+}
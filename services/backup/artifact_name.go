@@ -0,0 +1,104 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// Artifact name placeholders.
+const (
+	ServiceNamePlaceholder = "{service_name}"
+	ServiceTypePlaceholder = "{service_type}"
+	TimestampPlaceholder   = "{timestamp}"
+	ModePlaceholder        = "{mode}"
+	DataModelPlaceholder   = "{data_model}"
+)
+
+// artifactNameTimestampFormat is a format of TimestampPlaceholder value; it is safe for S3 keys and file names.
+const artifactNameTimestampFormat = "2006-01-02_15-04-05"
+
+var (
+	artifactNamePlaceholderRE = regexp.MustCompile(`\{[^{}]*\}`)
+	// characters that are not safe for S3 keys and file names
+	artifactNameUnsafeRE = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// artifactNameParams contains values of artifact name placeholders.
+type artifactNameParams struct {
+	service   *models.Service
+	mode      models.ArtifactType
+	dataModel models.DataModel
+	now       time.Time
+}
+
+// renderArtifactName replaces placeholders in artifact name template and characters
+// unsafe for S3 keys and file names, so different names can't refer to the same files.
+func renderArtifactName(template string, params *artifactNameParams) (string, error) {
+	values := map[string]string{
+		ServiceNamePlaceholder: params.service.ServiceName,
+		ServiceTypePlaceholder: string(params.service.ServiceType),
+		TimestampPlaceholder:   params.now.UTC().Format(artifactNameTimestampFormat),
+		ModePlaceholder:        string(params.mode),
+		DataModelPlaceholder:   string(params.dataModel),
+	}
+
+	var unknown string
+	name := artifactNamePlaceholderRE.ReplaceAllStringFunc(template, func(p string) string {
+		v, ok := values[p]
+		if !ok && unknown == "" {
+			unknown = p
+		}
+		return v
+	})
+	if unknown != "" {
+		return "", status.Errorf(codes.InvalidArgument, "Unknown artifact name placeholder %q.", unknown)
+	}
+
+	name = strings.Trim(artifactNameUnsafeRE.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		return "", status.Error(codes.InvalidArgument, "Empty artifact name.")
+	}
+	return name, nil
+}
+
+// checkArtifactNameCollision returns AlreadyExists error if files with given artifact name
+// already exist at the S3 location, for example, from removed artifact or another PMM Server.
+// Other locations are local to pmm-agents and can't be checked.
+func (s *Service) checkArtifactNameCollision(ctx context.Context, location *models.BackupLocation, name string) error {
+	c := location.S3Config
+	if c == nil || s.s3 == nil {
+		return nil
+	}
+
+	exists, err := s.s3.PrefixExists(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, name+"/")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return status.Errorf(codes.AlreadyExists, "Files of artifact %q already exist in location %q.", name, location.Name)
+	}
+	return nil
+}
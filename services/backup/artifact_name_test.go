@@ -0,0 +1,63 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestRenderArtifactName(t *testing.T) {
+	params := &artifactNameParams{
+		service: &models.Service{
+			ServiceName: "mysql 1/primary",
+			ServiceType: models.MySQLServiceType,
+		},
+		mode:      models.ScheduledArtifactType,
+		dataModel: models.PhysicalDataModel,
+		now:       time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC),
+	}
+
+	for _, tc := range []struct {
+		template string
+		expected string
+		err      string
+	}{
+		{"backup", "backup", ""},
+		{"{service_name}_{timestamp}", "mysql_1_primary_2021-06-07_08-09-10", ""},
+		{"{service_type}-{mode}-{data_model}", "mysql-scheduled-physical", ""},
+		{"../backup", "backup", ""},
+		{"daily_2021-06-07T08:09:10Z", "daily_2021-06-07T08_09_10Z", ""},
+		{"{service}", "", `rpc error: code = InvalidArgument desc = Unknown artifact name placeholder "{service}".`},
+		{"///", "", `rpc error: code = InvalidArgument desc = Empty artifact name.`},
+	} {
+		t.Run(tc.template, func(t *testing.T) {
+			name, err := renderArtifactName(tc.template, params)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, name)
+		})
+	}
+}
@@ -18,7 +18,12 @@ package backup
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/AlekSi/pointer"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
@@ -28,108 +33,517 @@ import (
 	"github.com/percona/pmm-managed/models"
 )
 
+// jobReaperInterval is an interval of checking backup jobs for timeouts.
+const jobReaperInterval = time.Minute
+
 // Service represents core logic for db backup.
 type Service struct {
-	db          *reform.DB
-	jobsService jobsService
-	l           *logrus.Entry
+	db             *reform.DB
+	jobsService    jobsService
+	actionsService actionsService
+	s3             s3
+	signing        *SigningService
+	l              *logrus.Entry
 }
 
 // NewService creates new backups logic service.
-func NewService(db *reform.DB, jobsService jobsService) *Service {
+func NewService(db *reform.DB, jobsService jobsService, actionsService actionsService, s3 s3) *Service {
 	return &Service{
-		l:           logrus.WithField("component", "management/backup/backup"),
-		db:          db,
-		jobsService: jobsService,
+		l:              logrus.WithField("component", "management/backup/backup"),
+		db:             db,
+		jobsService:    jobsService,
+		actionsService: actionsService,
+		s3:             s3,
+		signing:        NewSigningService(db, s3),
 	}
 }
 
-// PerformBackup starts on-demand backup.
-func (s *Service) PerformBackup(ctx context.Context, serviceID, locationID, name,
-	scheduleID string) (string, error) {
-	var err error
-	var artifact *models.Artifact
-	var location *models.BackupLocation
-	var svc *models.Service
-	var job *models.JobResult
-	var config *models.DBConfig
+// PerformBackupParams are params for performing backup.
+type PerformBackupParams struct {
+	ServiceID  string
+	LocationID string
+	// Artifact name template, see placeholders in artifact_name.go.
+	Name string
+	// Empty for on-demand backups.
+	ScheduleID string
+	// Default for the Service type if empty.
+	DataModel models.DataModel
+	// Empty for backups outside of a backup group.
+	GroupID string
+	// Backup job timeout; default from settings is used if zero.
+	Timeout time.Duration
+}
+
+// backupDataModels maps Service types to supported backup data models; the first one is the default.
+var backupDataModels = map[models.ServiceType][]models.DataModel{
+	// logical MySQL backups are performed with Actions, see MySQLDumpService
+	models.MySQLServiceType:   {models.PhysicalDataModel, models.LogicalDataModel},
+	models.MongoDBServiceType: {models.LogicalDataModel},
+	models.HAProxyServiceType: {models.SnapshotDataModel},
+	// External Services are backed up by configuration snapshots of their Nodes
+	models.ExternalServiceType: {models.SnapshotDataModel},
+}
+
+// backupDataModel returns data model of backup for given Service type and requested data model.
+func backupDataModel(serviceType models.ServiceType, dataModel models.DataModel) (models.DataModel, error) {
+	supported := backupDataModels[serviceType]
+	if len(supported) == 0 {
+		return "", status.Errorf(codes.Unimplemented, "unimplemented service: %s", serviceType)
+	}
+	if dataModel == "" {
+		return supported[0], nil
+	}
+	if err := dataModel.Validate(); err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	for _, dm := range supported {
+		if dm == dataModel {
+			return dm, nil
+		}
+	}
+	return "", status.Errorf(codes.Unimplemented, "%s data model is not supported for %s backups", dataModel, serviceType)
+}
 
+// ValidateDataModel returns an error if data model is not supported for backups of given Service type.
+// Empty data model means the default one.
+func ValidateDataModel(serviceType models.ServiceType, dataModel models.DataModel) error {
+	_, err := backupDataModel(serviceType, dataModel)
+	return err
+}
+
+// backupJob contains data required to start prepared backup job.
+type backupJob struct {
+	artifact *models.Artifact
+	service  *models.Service
+	location *models.BackupLocation
+	job      *models.JobResult
+	config   *models.DBConfig
+	timeout  time.Duration
+}
+
+// PerformBackup starts on-demand or scheduled backup.
+func (s *Service) PerformBackup(ctx context.Context, params PerformBackupParams) (string, error) {
+	var b *backupJob
 	errTX := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
-		svc, err = models.FindServiceByID(tx.Querier, serviceID)
-		if err != nil {
-			return err
+		var err error
+		b, err = s.prepareBackup(ctx, tx.Querier, params)
+		return err
+	})
+	if errTX != nil {
+		return "", errTX
+	}
+
+	if err := s.startBackupJob(b); err != nil {
+		return "", err
+	}
+
+	return b.artifact.ID, nil
+}
+
+// PerformGroupBackupParams are params for performing backup of a group of Services.
+type PerformGroupBackupParams struct {
+	// Services to back up; all Services of Cluster are used if empty.
+	ServiceIDs []string
+	Cluster    string
+	LocationID string
+	// Artifact name template; Service name is appended to it if template does not contain it.
+	Name string
+	// Default for each Service type if empty.
+	DataModel models.DataModel
+}
+
+// PerformGroupBackup starts on-demand backup of a group of Services (for example, all shards of a cluster)
+// as one logical set of artifacts with a common group ID.
+// Artifacts and jobs for all Services are created in a single transaction first, so nothing is started
+// if any Service can't be backed up; then all jobs are started back to back. If some job fails to start,
+// already started jobs are stopped and all artifacts of the group are marked as failed.
+// It returns group ID and IDs of created artifacts.
+func (s *Service) PerformGroupBackup(ctx context.Context, params PerformGroupBackupParams) (string, []string, error) {
+	if len(params.ServiceIDs) == 0 && params.Cluster == "" {
+		return "", nil, status.Error(codes.InvalidArgument, "Either service IDs or cluster should be specified.")
+	}
+
+	groupID := "/backup_group_id/" + uuid.New().String()
+	var backups []*backupJob
+	errTX := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		serviceIDs := params.ServiceIDs
+		if len(serviceIDs) == 0 {
+			services, err := models.FindServices(tx.Querier, models.ServiceFilters{Cluster: params.Cluster})
+			if err != nil {
+				return err
+			}
+			for _, svc := range services {
+				if _, ok := backupDataModels[svc.ServiceType]; ok {
+					serviceIDs = append(serviceIDs, svc.ServiceID)
+				}
+			}
+			if len(serviceIDs) == 0 {
+				return status.Errorf(codes.NotFound, "No Services to back up found in cluster %q.", params.Cluster)
+			}
 		}
 
-		location, err = models.FindBackupLocationByID(tx.Querier, locationID)
-		if err != nil {
-			return err
+		name := params.Name
+		if !strings.Contains(name, ServiceNamePlaceholder) {
+			name += "-" + ServiceNamePlaceholder
+		}
+		for _, serviceID := range serviceIDs {
+			b, err := s.prepareBackup(ctx, tx.Querier, PerformBackupParams{
+				ServiceID:  serviceID,
+				LocationID: params.LocationID,
+				Name:       name,
+				DataModel:  params.DataModel,
+				GroupID:    groupID,
+			})
+			if err != nil {
+				return err
+			}
+			backups = append(backups, b)
 		}
+		return nil
+	})
+	if errTX != nil {
+		return "", nil, errTX
+	}
 
-		var dataModel models.DataModel
-		var jobType models.JobType
-		switch svc.ServiceType {
-		case models.MySQLServiceType:
-			dataModel = models.PhysicalDataModel
-			jobType = models.MySQLBackupJob
-		case models.MongoDBServiceType:
-			dataModel = models.LogicalDataModel
-			jobType = models.MongoDBBackupJob
-		case models.PostgreSQLServiceType,
-			models.ProxySQLServiceType,
-			models.HAProxyServiceType,
-			models.ExternalServiceType:
-			return status.Errorf(codes.Unimplemented, "unimplemented service: %s", svc.ServiceType)
-		default:
-			return status.Errorf(codes.Unknown, "unknown service: %s", svc.ServiceType)
+	artifactIDs := make([]string, len(backups))
+	for i, b := range backups {
+		artifactIDs[i] = b.artifact.ID
+	}
+
+	for i, b := range backups {
+		err := s.startBackupJob(b)
+		if err == nil {
+			continue
 		}
 
-		artifact, err = models.CreateArtifact(tx.Querier, models.CreateArtifactParams{
-			Name:       name,
-			Vendor:     string(svc.ServiceType),
-			LocationID: location.ID,
-			ServiceID:  svc.ServiceID,
-			DataModel:  dataModel,
-			Status:     models.PendingBackupStatus,
-			ScheduleID: scheduleID,
-		})
-		if err != nil {
-			return err
+		s.l.Errorf("Failed to start backup job of group %s: %s.", groupID, err)
+		for _, started := range backups[:i] {
+			if e := s.jobsService.StopJob(started.job.ID); e != nil {
+				s.l.Errorf("Failed to stop backup job %s: %s.", started.job.ID, e)
+			}
 		}
+		if e := s.failGroupBackups(ctx, backups, err.Error()); e != nil {
+			s.l.Errorf("Failed to update artifacts of group %s: %s.", groupID, e)
+		}
+		return "", nil, err
+	}
 
-		job, config, err = s.prepareBackupJob(tx.Querier, svc, artifact.ID, jobType)
-		if err != nil {
-			return err
+	return groupID, artifactIDs, nil
+}
+
+// failGroupBackups marks artifacts and jobs of a group which failed to start as failed.
+func (s *Service) failGroupBackups(ctx context.Context, backups []*backupJob, message string) error {
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		for _, b := range backups {
+			if _, err := models.UpdateArtifact(tx.Querier, b.artifact.ID, models.UpdateArtifactParams{
+				Status: models.BackupStatusPointer(models.ErrorBackupStatus),
+			}); err != nil {
+				return err
+			}
+
+			job, err := models.FindJobResultByID(tx.Querier, b.job.ID)
+			if err != nil {
+				return err
+			}
+			if job.Done {
+				continue
+			}
+			job.Done = true
+			job.Error = message
+			if err = tx.Update(job); err != nil {
+				return errors.WithStack(err)
+			}
 		}
 		return nil
 	})
-	if errTX != nil {
-		return "", errTX
+}
+
+// prepareBackup creates artifact and job result for a backup with given parameters.
+func (s *Service) prepareBackup(ctx context.Context, q *reform.Querier, params PerformBackupParams) (*backupJob, error) {
+	svc, err := models.FindServiceByID(q, params.ServiceID)
+	if err != nil {
+		return nil, err
 	}
 
-	locationConfig := &models.BackupLocationConfig{
-		PMMServerConfig: location.PMMServerConfig,
-		PMMClientConfig: location.PMMClientConfig,
-		S3Config:        location.S3Config,
+	location, err := models.FindBackupLocationByID(q, params.LocationID)
+	if err != nil {
+		return nil, err
 	}
 
+	dataModel, err := backupDataModel(svc.ServiceType, params.DataModel)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobType models.JobType
 	switch svc.ServiceType {
 	case models.MySQLServiceType:
-		err = s.jobsService.StartMySQLBackupJob(job.ID, job.PMMAgentID, 0, name, config, locationConfig)
+		jobType = models.MySQLBackupJob
+		if dataModel == models.LogicalDataModel {
+			if location.S3Config == nil {
+				return nil, status.Error(codes.FailedPrecondition, "MySQL logical backups can be stored only in S3 locations.")
+			}
+			jobType = models.MySQLLogicalBackupJob
+		}
 	case models.MongoDBServiceType:
-		err = s.jobsService.StartMongoDBBackupJob(job.ID, job.PMMAgentID, 0, name, config, locationConfig)
+		jobType = models.MongoDBBackupJob
+	case models.HAProxyServiceType,
+		models.ExternalServiceType:
+		if location.S3Config == nil {
+			return nil, status.Error(codes.FailedPrecondition, "Configuration snapshots can be stored only in S3 locations.")
+		}
+		jobType = models.ConfigSnapshotBackupJob
 	case models.PostgreSQLServiceType,
-		models.ProxySQLServiceType,
-		models.HAProxyServiceType,
+		models.ProxySQLServiceType:
+		return nil, status.Errorf(codes.Unimplemented, "unimplemented service: %s", svc.ServiceType)
+	default:
+		return nil, status.Errorf(codes.Unknown, "unknown service: %s", svc.ServiceType)
+	}
+
+	metadata, err := artifactMetadata(q, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := models.OnDemandArtifactType
+	if params.ScheduleID != "" {
+		mode = models.ScheduledArtifactType
+	}
+	name, err := renderArtifactName(params.Name, &artifactNameParams{
+		service:   svc,
+		mode:      mode,
+		dataModel: dataModel,
+		now:       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkArtifactNameCollision(ctx, location, name); err != nil {
+		return nil, err
+	}
+
+	artifact, err := models.CreateArtifact(q, models.CreateArtifactParams{
+		Name:       name,
+		Vendor:     string(svc.ServiceType),
+		LocationID: location.ID,
+		ServiceID:  svc.ServiceID,
+		DataModel:  dataModel,
+		Status:     models.PendingBackupStatus,
+		ScheduleID: params.ScheduleID,
+		GroupID:    params.GroupID,
+		Metadata:   metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := params.Timeout
+	if timeout == 0 {
+		settings, err := models.GetSettings(q)
+		if err != nil {
+			return nil, err
+		}
+		timeout = settings.BackupManagement.JobTimeout
+	}
+
+	job, config, err := s.prepareBackupJob(q, svc, artifact.ID, jobType, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backupJob{
+		artifact: artifact,
+		service:  svc,
+		location: location,
+		job:      job,
+		config:   config,
+		timeout:  timeout,
+	}, nil
+}
+
+// startBackupJob starts prepared backup job.
+func (s *Service) startBackupJob(b *backupJob) error {
+	locationConfig := &models.BackupLocationConfig{
+		PMMServerConfig: b.location.PMMServerConfig,
+		PMMClientConfig: b.location.PMMClientConfig,
+		S3Config:        b.location.S3Config,
+	}
+
+	switch b.service.ServiceType {
+	case models.MySQLServiceType:
+		if b.job.Type == models.MySQLLogicalBackupJob {
+			// tables are dumped by pmm-managed when Actions are done, see MySQLDumpService
+			return startMySQLDumpAction(context.Background(), s.db.Querier, s.actionsService, b.job, b.service)
+		}
+		return s.jobsService.StartMySQLBackupJob(b.job.ID, b.job.PMMAgentID, b.timeout, b.artifact.Name, b.config, locationConfig)
+	case models.MongoDBServiceType:
+		return s.jobsService.StartMongoDBBackupJob(b.job.ID, b.job.PMMAgentID, b.timeout, b.artifact.Name, b.config, locationConfig)
+	case models.HAProxyServiceType,
 		models.ExternalServiceType:
-		return "", status.Errorf(codes.Unimplemented, "unimplemented service: %s", svc.ServiceType)
+		// snapshot is stored by pmm-managed when Action is done, see ConfigSnapshotService
+		return s.actionsService.StartPTSummaryAction(context.Background(), b.job.Result.ConfigSnapshotBackup.ActionID, b.job.PMMAgentID)
+	case models.PostgreSQLServiceType,
+		models.ProxySQLServiceType:
+		return status.Errorf(codes.Unimplemented, "unimplemented service: %s", b.service.ServiceType)
 	default:
-		return "", status.Errorf(codes.Unknown, "unknown service: %s", svc.ServiceType)
+		return status.Errorf(codes.Unknown, "unknown service: %s", b.service.ServiceType)
 	}
+}
+
+// CancelBackup stops running backup job and marks artifact as canceled.
+func (s *Service) CancelBackup(ctx context.Context, artifactID string) error {
+	var job *models.JobResult
+	errTX := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		artifact, err := models.FindArtifactByID(tx.Querier, artifactID)
+		if err != nil {
+			return err
+		}
+
+		switch artifact.Status {
+		case models.PendingBackupStatus, models.InProgressBackupStatus, models.QueuedBackupStatus:
+		default:
+			return status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is not being backed up, status: %q.", artifactID, artifact.Status)
+		}
+
+		job, err = models.FindBackupJobResultByArtifactID(tx.Querier, artifactID)
+		return err
+	})
+	if errTX != nil {
+		return errTX
+	}
+
+	// Actions can't be stopped; result of the canceled one is ignored
+	if !performedByActions(job.Type) {
+		if err := s.jobsService.StopJob(job.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		job, err := models.FindJobResultByID(tx.Querier, job.ID)
+		if err != nil {
+			return err
+		}
+
+		// job may be finished while we were stopping it
+		if job.Done && job.Error == "" {
+			return status.Errorf(codes.FailedPrecondition, "Backup of artifact with ID %q is already finished.", artifactID)
+		}
+
+		if _, err = models.UpdateArtifact(tx.Querier, artifactID, models.UpdateArtifactParams{
+			Status: models.BackupStatusPointer(models.CanceledBackupStatus),
+		}); err != nil {
+			return err
+		}
+
+		job.Done = true
+		if job.Error == "" {
+			job.Error = "canceled"
+		}
+		return errors.WithStack(tx.Update(job))
+	})
+}
+
+// Run marks timed out backup jobs as failed until context is canceled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(jobReaperInterval)
+	defer ticker.Stop()
+
+	for {
+		s.reapTimedOutJobs()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reapTimedOutJobs stops backup jobs that have not reported progress for longer than their timeout,
+// and marks them and their artifacts as failed. That frees concurrency slots taken by those jobs.
+// Queued jobs are not started yet, so they are skipped.
+func (s *Service) reapTimedOutJobs() {
+	jobs, err := models.FindJobResults(s.db.Querier, models.JobResultFilters{
+		Types: []models.JobType{models.MySQLBackupJob, models.MongoDBBackupJob, models.ConfigSnapshotBackupJob, models.MySQLLogicalBackupJob},
+		Done:  pointer.ToBool(false),
+	})
 	if err != nil {
-		return "", err
+		s.l.Error(err)
+		return
 	}
 
-	return artifact.ID, nil
+	now := time.Now()
+	for _, job := range jobs {
+		if job.Result == nil || job.Result.Timeout == 0 || now.Sub(job.UpdatedAt) < job.Result.Timeout {
+			continue
+		}
+
+		artifactID := backupArtifactID(job)
+		artifact, err := models.FindArtifactByID(s.db.Querier, artifactID)
+		if err != nil {
+			s.l.Errorf("Failed to find artifact %s of job %s: %s.", artifactID, job.ID, err)
+			continue
+		}
+		if artifact.Status == models.QueuedBackupStatus {
+			continue
+		}
+
+		s.l.Warnf("Backup job %s has not reported progress for %s, marking it as failed.", job.ID, job.Result.Timeout)
+
+		// pmm-agent may be disconnected, so ignore errors
+		if !performedByActions(job.Type) {
+			if err = s.jobsService.StopJob(job.ID); err != nil {
+				s.l.Debugf("Failed to stop job %s: %s.", job.ID, err)
+			}
+		}
+
+		err = s.db.InTransaction(func(tx *reform.TX) error {
+			job, err := models.FindJobResultByID(tx.Querier, job.ID)
+			if err != nil {
+				return err
+			}
+			// job may be finished while we were stopping it
+			if job.Done {
+				return nil
+			}
+
+			if _, err = models.UpdateArtifact(tx.Querier, artifactID, models.UpdateArtifactParams{
+				Status: models.BackupStatusPointer(models.ErrorBackupStatus),
+			}); err != nil {
+				return err
+			}
+
+			job.Done = true
+			job.Error = fmt.Sprintf("timed out after %s", job.Result.Timeout)
+			return errors.WithStack(tx.Update(job))
+		})
+		if err != nil {
+			s.l.Errorf("Failed to mark timed out job %s as failed: %+v.", job.ID, err)
+		}
+	}
+}
+
+// backupArtifactID returns artifact ID of backup job.
+func backupArtifactID(job *models.JobResult) string {
+	switch {
+	case job.Result.MySQLBackup != nil:
+		return job.Result.MySQLBackup.ArtifactID
+	case job.Result.MongoDBBackup != nil:
+		return job.Result.MongoDBBackup.ArtifactID
+	case job.Result.ConfigSnapshotBackup != nil:
+		return job.Result.ConfigSnapshotBackup.ArtifactID
+	case job.Result.MySQLLogicalBackup != nil:
+		return job.Result.MySQLLogicalBackup.ArtifactID
+	default:
+		return ""
+	}
+}
+
+// performedByActions returns true for backup jobs performed by pmm-managed with Actions instead of pmm-agent jobs.
+func performedByActions(jobType models.JobType) bool {
+	return jobType == models.ConfigSnapshotBackupJob || jobType == models.MySQLLogicalBackupJob
 }
 
 type prepareRestoreJobParams struct {
@@ -141,7 +555,15 @@ type prepareRestoreJobParams struct {
 }
 
 // RestoreBackup starts restore backup job.
-func (s *Service) RestoreBackup(ctx context.Context, serviceID, artifactID string) (string, error) {
+// Restore of MySQL cluster member is refused if it could split-brain a healthy cluster unless force is true;
+// see ClusterRestorePlan.
+func (s *Service) RestoreBackup(ctx context.Context, serviceID, artifactID string, force, skipSignatureCheck bool) (string, error) {
+	if !skipSignatureCheck {
+		if err := s.signing.VerifyArtifact(ctx, artifactID); err != nil {
+			return "", err
+		}
+	}
+
 	var params *prepareRestoreJobParams
 	var jobID, restoreID string
 
@@ -152,6 +574,20 @@ func (s *Service) RestoreBackup(ctx context.Context, serviceID, artifactID strin
 			return err
 		}
 
+		if !force {
+			service, err := models.FindServiceByID(tx.Querier, serviceID)
+			if err != nil {
+				return err
+			}
+			members, err := clusterMembers(tx.Querier, service)
+			if err != nil {
+				return err
+			}
+			if err = checkClusterRestore(tx.Querier, members, artifactID); err != nil {
+				return err
+			}
+		}
+
 		restore, err := models.CreateRestoreHistoryItem(tx.Querier, models.CreateRestoreHistoryItemParams{
 			ArtifactID: artifactID,
 			ServiceID:  serviceID,
@@ -229,6 +665,22 @@ func (s *Service) prepareRestoreJob(
 	if artifact.Status != models.SuccessBackupStatus {
 		return nil, errors.Errorf("artifact %q status is not successful, status: %q", artifactID, artifact.Status)
 	}
+	if artifact.Orphaned {
+		return nil, errors.Errorf("artifact %q files are missing in the storage", artifactID)
+	}
+	if artifact.Vendor == string(models.MySQLServiceType) && artifact.DataModel == models.LogicalDataModel {
+		return nil, status.Errorf(codes.FailedPrecondition, "Artifact %q is a MySQL logical backup; apply its SQL files with mysql client.", artifactID)
+	}
+
+	serviceMetadata, err := artifactMetadata(q, service)
+	if err != nil {
+		return nil, err
+	}
+	if serviceMetadata != nil {
+		if err = checkArtifactCompatibility(artifact.Metadata, serviceMetadata.SoftwareVersions); err != nil {
+			return nil, err
+		}
+	}
 
 	location, err := models.FindBackupLocationByID(q, artifact.LocationID)
 	if err != nil {
@@ -304,13 +756,24 @@ func (s *Service) prepareBackupJob(
 	service *models.Service,
 	artifactID string,
 	jobType models.JobType,
+	timeout time.Duration,
 ) (*models.JobResult, *models.DBConfig, error) {
-	dbConfig, err := models.FindDBConfigForService(q, service.ServiceID)
-	if err != nil {
-		return nil, nil, err
+	var dbConfig *models.DBConfig
+	var pmmAgents []*models.Agent
+	var err error
+	switch jobType {
+	case models.ConfigSnapshotBackupJob:
+		// snapshot is collected on Service's Node; Service's exporter may be scraped without pmm-agent
+		pmmAgents, err = models.FindPMMAgentsRunningOnNode(q, service.NodeID)
+	case models.MySQLLogicalBackupJob:
+		// Actions use DSN of Service's exporter
+		pmmAgents, err = models.FindPMMAgentsForService(q, service.ServiceID)
+	default:
+		if dbConfig, err = models.FindDBConfigForService(q, service.ServiceID); err != nil {
+			return nil, nil, err
+		}
+		pmmAgents, err = models.FindPMMAgentsForService(q, service.ServiceID)
 	}
-
-	pmmAgents, err := models.FindPMMAgentsForService(q, service.ServiceID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -333,6 +796,28 @@ func (s *Service) prepareBackupJob(
 				ArtifactID: artifactID,
 			},
 		}
+	case models.ConfigSnapshotBackupJob:
+		action, err := models.CreateActionResult(q, pmmAgents[0].AgentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		jobResultData = &models.JobResultData{
+			ConfigSnapshotBackup: &models.ConfigSnapshotBackupJobResult{
+				ArtifactID: artifactID,
+				ActionID:   action.ID,
+			},
+		}
+	case models.MySQLLogicalBackupJob:
+		action, err := models.CreateActionResult(q, pmmAgents[0].AgentID)
+		if err != nil {
+			return nil, nil, err
+		}
+		jobResultData = &models.JobResultData{
+			MySQLLogicalBackup: &models.MySQLLogicalBackupJobResult{
+				ArtifactID: artifactID,
+				ActionID:   action.ID,
+			},
+		}
 	case models.Echo,
 		models.MySQLRestoreBackupJob,
 		models.MongoDBRestoreBackupJob:
@@ -340,6 +825,7 @@ func (s *Service) prepareBackupJob(
 	default:
 		return nil, nil, errors.Errorf("unsupported backup job type: %s", jobType)
 	}
+	jobResultData.Timeout = timeout
 
 	res, err := models.CreateJobResult(q, pmmAgents[0].AgentID, jobType, jobResultData)
 	if err != nil {
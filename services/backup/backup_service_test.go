@@ -18,17 +18,23 @@ package backup
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/AlekSi/pointer"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 	"gopkg.in/reform.v1/dialects/postgresql"
 
 	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
 )
 
 func setup(t *testing.T, q *reform.Querier, serviceName string) *models.Agent {
@@ -67,7 +73,17 @@ func TestStartBackup(t *testing.T) {
 	mockedJobsService := &mockJobsService{}
 	mockedJobsService.On("StartMySQLBackupJob", mock.Anything, mock.Anything, mock.Anything,
 		mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	backupService := NewService(db, mockedJobsService)
+	mockedS3 := &mockS3{}
+	mockedS3.On("PrefixExists", ctx, "https://s3.us-west-2.amazonaws.com/", "access_key", "secret_key",
+		"example_bucket", "test_backup/").Return(false, nil).Once()
+	mockedS3.On("PrefixExists", ctx, "https://s3.us-west-2.amazonaws.com/", "access_key", "secret_key",
+		"example_bucket", "test_backup_2/").Return(true, nil).Once()
+	mockedS3.On("PrefixExists", ctx, "https://s3.us-west-2.amazonaws.com/", "access_key", "secret_key",
+		"example_bucket", "test_logical_backup/").Return(false, nil).Once()
+	mockedActionsService := &mockActionsService{}
+	mockedActionsService.On("StartMySQLQuerySelectAction", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mysqlTablesQuery, mock.Anything, mock.Anything, false).Return(nil).Once()
+	backupService := NewService(db, mockedJobsService, mockedActionsService, mockedS3)
 
 	t.Cleanup(func() {
 		_ = sqlDB.Close()
@@ -89,7 +105,11 @@ func TestStartBackup(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	artifactID, err := backupService.PerformBackup(ctx, pointer.GetString(agent.ServiceID), locationRes.ID, "test_backup", "")
+	artifactID, err := backupService.PerformBackup(ctx, PerformBackupParams{
+		ServiceID:  pointer.GetString(agent.ServiceID),
+		LocationID: locationRes.ID,
+		Name:       "test_backup",
+	})
 	assert.NoError(t, err)
 
 	assert.NoError(t, err)
@@ -99,4 +119,383 @@ func TestStartBackup(t *testing.T) {
 	assert.Equal(t, locationRes.ID, artifact.LocationID)
 	assert.Equal(t, *agent.ServiceID, artifact.ServiceID)
 	assert.EqualValues(t, models.MySQLServiceType, artifact.Vendor)
+	assert.Equal(t, models.PhysicalDataModel, artifact.DataModel)
+
+	artifactID, err = backupService.PerformBackup(ctx, PerformBackupParams{
+		ServiceID:  pointer.GetString(agent.ServiceID),
+		LocationID: locationRes.ID,
+		Name:       "test_logical_backup",
+		DataModel:  models.LogicalDataModel,
+	})
+	require.NoError(t, err)
+	job, err := models.FindBackupJobResultByArtifactID(db.Querier, artifactID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MySQLLogicalBackupJob, job.Type)
+	assert.NotEmpty(t, job.Result.MySQLLogicalBackup.ActionID)
+	mockedActionsService.AssertExpectations(t)
+
+	_, err = backupService.PerformBackup(ctx, PerformBackupParams{
+		ServiceID:  pointer.GetString(agent.ServiceID),
+		LocationID: locationRes.ID,
+		Name:       "test_backup_2",
+	})
+	tests.AssertGRPCError(t, status.New(codes.AlreadyExists, `Files of artifact "test_backup_2" already exist in location "Test location".`), err)
+	mockedS3.AssertExpectations(t)
+}
+
+func TestStartConfigSnapshotBackup(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	node, err := models.CreateNode(db.Querier, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName: "test-node",
+	})
+	require.NoError(t, err)
+	pmmAgent, err := models.CreatePMMAgent(db.Querier, node.NodeID, nil)
+	require.NoError(t, err)
+
+	haproxy, err := models.AddNewService(db.Querier, models.HAProxyServiceType, &models.AddDBMSServiceParams{
+		ServiceName: "test-haproxy",
+		NodeID:      node.NodeID,
+	})
+	require.NoError(t, err)
+	// pulled by PMM Server, so has no pmm-agent
+	_, err = models.CreateExternalExporter(db.Querier, &models.CreateExternalExporterParams{
+		RunsOnNodeID: node.NodeID,
+		ServiceID:    haproxy.ServiceID,
+		ListenPort:   8404,
+	})
+	require.NoError(t, err)
+
+	newLocation := func(name string, config models.BackupLocationConfig) *models.BackupLocation {
+		location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+			Name:                 name,
+			BackupLocationConfig: config,
+		})
+		require.NoError(t, err)
+		return location
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		location := newLocation("S3 location", models.BackupLocationConfig{
+			S3Config: &models.S3LocationConfig{
+				Endpoint:     "https://s3.us-west-2.amazonaws.com/",
+				AccessKey:    "access_key",
+				SecretKey:    "secret_key",
+				BucketName:   "example_bucket",
+				BucketRegion: "us-east-2",
+			},
+		})
+		mockedS3 := &mockS3{}
+		mockedS3.On("PrefixExists", ctx, "https://s3.us-west-2.amazonaws.com/", "access_key", "secret_key",
+			"example_bucket", "test_snapshot/").Return(false, nil).Once()
+		mockedActionsService := &mockActionsService{}
+		mockedActionsService.On("StartPTSummaryAction", mock.Anything, mock.Anything, pmmAgent.AgentID).Return(nil).Once()
+		t.Cleanup(func() {
+			mockedS3.AssertExpectations(t)
+			mockedActionsService.AssertExpectations(t)
+		})
+		backupService := NewService(db, &mockJobsService{}, mockedActionsService, mockedS3)
+
+		artifactID, err := backupService.PerformBackup(ctx, PerformBackupParams{
+			ServiceID:  haproxy.ServiceID,
+			LocationID: location.ID,
+			Name:       "test_snapshot",
+		})
+		require.NoError(t, err)
+
+		artifact, err := models.FindArtifactByID(db.Querier, artifactID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SnapshotDataModel, artifact.DataModel)
+		assert.EqualValues(t, models.HAProxyServiceType, artifact.Vendor)
+
+		job, err := models.FindBackupJobResultByArtifactID(db.Querier, artifactID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ConfigSnapshotBackupJob, job.Type)
+		assert.Equal(t, pmmAgent.AgentID, job.PMMAgentID)
+		require.NotNil(t, job.Result.ConfigSnapshotBackup)
+		mockedActionsService.AssertCalled(t, "StartPTSummaryAction", mock.Anything, job.Result.ConfigSnapshotBackup.ActionID, pmmAgent.AgentID)
+
+		actual, err := models.FindJobResultByActionID(db.Querier, job.Result.ConfigSnapshotBackup.ActionID)
+		require.NoError(t, err)
+		assert.Equal(t, job.ID, actual.ID)
+
+		require.NoError(t, backupService.CancelBackup(ctx, artifactID))
+		actual, err = models.FindJobResultByActionID(db.Querier, job.Result.ConfigSnapshotBackup.ActionID)
+		require.NoError(t, err)
+		assert.Nil(t, actual)
+	})
+
+	t.Run("unsupported location", func(t *testing.T) {
+		location := newLocation("Client location", models.BackupLocationConfig{
+			PMMClientConfig: &models.PMMClientLocationConfig{
+				Path: "/tmp",
+			},
+		})
+		backupService := NewService(db, &mockJobsService{}, &mockActionsService{}, &mockS3{})
+
+		_, err := backupService.PerformBackup(ctx, PerformBackupParams{
+			ServiceID:  haproxy.ServiceID,
+			LocationID: location.ID,
+			Name:       "test_snapshot_2",
+		})
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Configuration snapshots can be stored only in S3 locations."), err)
+	})
+}
+
+func TestStartGroupBackup(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	node, err := models.CreateNode(db.Querier, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName: "test-node",
+	})
+	require.NoError(t, err)
+	pmmAgent, err := models.CreatePMMAgent(db.Querier, node.NodeID, nil)
+	require.NoError(t, err)
+
+	serviceIDs := make([]string, 2)
+	for i := range serviceIDs {
+		svc, err := models.AddNewService(db.Querier, models.MySQLServiceType, &models.AddDBMSServiceParams{
+			ServiceName: fmt.Sprintf("shard-%d", i),
+			NodeID:      node.NodeID,
+			Cluster:     "test-cluster",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16(uint16(3306 + i)),
+		})
+		require.NoError(t, err)
+		_, err = models.CreateAgent(db.Querier, models.MySQLdExporterType, &models.CreateAgentParams{
+			PMMAgentID: pmmAgent.AgentID,
+			ServiceID:  svc.ServiceID,
+			Username:   "user",
+			Password:   "password",
+		})
+		require.NoError(t, err)
+		serviceIDs[i] = svc.ServiceID
+	}
+
+	location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			PMMClientConfig: &models.PMMClientLocationConfig{
+				Path: "/tmp",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("cluster", func(t *testing.T) {
+		mockedJobsService := &mockJobsService{}
+		mockedJobsService.On("StartMySQLBackupJob", mock.Anything, pmmAgent.AgentID, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+		t.Cleanup(func() { mockedJobsService.AssertExpectations(t) })
+		backupService := NewService(db, mockedJobsService, &mockActionsService{}, &mockS3{})
+
+		groupID, artifactIDs, err := backupService.PerformGroupBackup(ctx, PerformGroupBackupParams{
+			Cluster:    "test-cluster",
+			LocationID: location.ID,
+			Name:       "group",
+		})
+		require.NoError(t, err)
+		assert.Len(t, artifactIDs, 2)
+
+		artifacts, err := models.FindArtifacts(db.Querier, models.ArtifactFilters{GroupID: groupID})
+		require.NoError(t, err)
+		require.Len(t, artifacts, 2)
+		names := []string{artifacts[0].Name, artifacts[1].Name}
+		assert.ElementsMatch(t, []string{"group-shard-0", "group-shard-1"}, names)
+		for _, a := range artifacts {
+			assert.Equal(t, models.PendingBackupStatus, a.Status)
+		}
+	})
+
+	t.Run("start failure", func(t *testing.T) {
+		mockedJobsService := &mockJobsService{}
+		mockedJobsService.On("StartMySQLBackupJob", mock.Anything, pmmAgent.AgentID, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockedJobsService.On("StartMySQLBackupJob", mock.Anything, pmmAgent.AgentID, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything).Return(errors.New("agent is not connected")).Once()
+		mockedJobsService.On("StopJob", mock.Anything).Return(nil).Once()
+		t.Cleanup(func() { mockedJobsService.AssertExpectations(t) })
+		backupService := NewService(db, mockedJobsService, &mockActionsService{}, &mockS3{})
+
+		_, _, err := backupService.PerformGroupBackup(ctx, PerformGroupBackupParams{
+			ServiceIDs: serviceIDs,
+			LocationID: location.ID,
+			Name:       "failed-group",
+		})
+		require.EqualError(t, err, "agent is not connected")
+
+		for _, name := range []string{"failed-group-shard-0", "failed-group-shard-1"} {
+			var artifact models.Artifact
+			require.NoError(t, db.SelectOneTo(&artifact, "WHERE name = $1", name))
+			assert.Equal(t, models.ErrorBackupStatus, artifact.Status)
+
+			job, err := models.FindBackupJobResultByArtifactID(db.Querier, artifact.ID)
+			require.NoError(t, err)
+			assert.True(t, job.Done)
+		}
+	})
+
+	t.Run("no services", func(t *testing.T) {
+		backupService := NewService(db, &mockJobsService{}, &mockActionsService{}, &mockS3{})
+		_, _, err := backupService.PerformGroupBackup(ctx, PerformGroupBackupParams{
+			Cluster:    "unknown-cluster",
+			LocationID: location.ID,
+			Name:       "empty-group",
+		})
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `No Services to back up found in cluster "unknown-cluster".`), err)
+	})
+}
+
+func TestBackupDataModel(t *testing.T) {
+	for _, tc := range []struct {
+		serviceType models.ServiceType
+		dataModel   models.DataModel
+		expected    models.DataModel
+		err         string
+	}{
+		{models.MySQLServiceType, "", models.PhysicalDataModel, ""},
+		{models.MySQLServiceType, models.PhysicalDataModel, models.PhysicalDataModel, ""},
+		{models.MySQLServiceType, models.LogicalDataModel, models.LogicalDataModel, ""},
+		{models.MySQLServiceType, models.SnapshotDataModel, "", "rpc error: code = Unimplemented desc = snapshot data model is not supported for mysql backups"},
+		{models.MongoDBServiceType, "", models.LogicalDataModel, ""},
+		{models.MongoDBServiceType, "invalid", "", "rpc error: code = InvalidArgument desc = invalid data model 'invalid': invalid argument"},
+		{models.HAProxyServiceType, "", models.SnapshotDataModel, ""},
+		{models.ExternalServiceType, models.LogicalDataModel, "", "rpc error: code = Unimplemented desc = logical data model is not supported for external backups"},
+		{models.PostgreSQLServiceType, "", "", "rpc error: code = Unimplemented desc = unimplemented service: postgresql"},
+	} {
+		dataModel, err := backupDataModel(tc.serviceType, tc.dataModel)
+		if tc.err != "" {
+			assert.EqualError(t, err, tc.err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, dataModel)
+	}
+}
+
+func TestCancelBackup(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	mockedJobsService := &mockJobsService{}
+	mockedJobsService.On("StartMySQLBackupJob", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	backupService := NewService(db, mockedJobsService, &mockActionsService{}, &mockS3{})
+
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	agent := setup(t, db.Querier, "test-service")
+	locationRes, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			PMMClientConfig: &models.PMMClientLocationConfig{
+				Path: "/tmp",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	artifactID, err := backupService.PerformBackup(ctx, PerformBackupParams{
+		ServiceID:  pointer.GetString(agent.ServiceID),
+		LocationID: locationRes.ID,
+		Name:       "test_backup",
+	})
+	require.NoError(t, err)
+
+	job, err := models.FindBackupJobResultByArtifactID(db.Querier, artifactID)
+	require.NoError(t, err)
+	mockedJobsService.On("StopJob", job.ID).Return(nil).Once()
+
+	require.NoError(t, backupService.CancelBackup(ctx, artifactID))
+	mockedJobsService.AssertExpectations(t)
+
+	artifact, err := models.FindArtifactByID(db.Querier, artifactID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CanceledBackupStatus, artifact.Status)
+
+	job, err = models.FindJobResultByID(db.Querier, job.ID)
+	require.NoError(t, err)
+	assert.True(t, job.Done)
+	assert.Equal(t, "canceled", job.Error)
+
+	// second attempt fails: artifact is not being backed up anymore
+	err = backupService.CancelBackup(ctx, artifactID)
+	tests.AssertGRPCError(t, status.Newf(codes.FailedPrecondition,
+		"Artifact with ID %q is not being backed up, status: %q.", artifactID, models.CanceledBackupStatus), err)
+}
+
+func TestReapTimedOutJobs(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	mockedJobsService := &mockJobsService{}
+	mockedJobsService.On("StartMySQLBackupJob", mock.Anything, mock.Anything, time.Hour,
+		mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	backupService := NewService(db, mockedJobsService, &mockActionsService{}, &mockS3{})
+
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	agent := setup(t, db.Querier, "test-service")
+	locationRes, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			PMMClientConfig: &models.PMMClientLocationConfig{
+				Path: "/tmp",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	performBackup := func(name string) *models.JobResult {
+		artifactID, err := backupService.PerformBackup(ctx, PerformBackupParams{
+			ServiceID:  pointer.GetString(agent.ServiceID),
+			LocationID: locationRes.ID,
+			Name:       name,
+			Timeout:    time.Hour,
+		})
+		require.NoError(t, err)
+
+		job, err := models.FindBackupJobResultByArtifactID(db.Querier, artifactID)
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, job.Result.Timeout)
+		return job
+	}
+
+	stuck := performBackup("stuck")
+	running := performBackup("running")
+	_, err = db.Exec("UPDATE job_results SET updated_at = $1 WHERE id = $2", time.Now().Add(-2*time.Hour), stuck.ID)
+	require.NoError(t, err)
+
+	mockedJobsService.On("StopJob", stuck.ID).Return(errors.New("pmm-agent is not connected")).Once()
+	backupService.reapTimedOutJobs()
+	mockedJobsService.AssertExpectations(t)
+
+	job, err := models.FindJobResultByID(db.Querier, stuck.ID)
+	require.NoError(t, err)
+	assert.True(t, job.Done)
+	assert.Equal(t, "timed out after 1h0m0s", job.Error)
+	artifact, err := models.FindArtifactByID(db.Querier, backupArtifactID(job))
+	require.NoError(t, err)
+	assert.Equal(t, models.ErrorBackupStatus, artifact.Status)
+
+	job, err = models.FindJobResultByID(db.Querier, running.ID)
+	require.NoError(t, err)
+	assert.False(t, job.Done)
+	artifact, err = models.FindArtifactByID(db.Querier, backupArtifactID(job))
+	require.NoError(t, err)
+	assert.Equal(t, models.PendingBackupStatus, artifact.Status)
 }
@@ -0,0 +1,195 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/percona/pmm/api/inventorypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// ClusterRestoreStep represents restore of a single cluster member.
+type ClusterRestoreStep struct {
+	ServiceID   string `json:"service_id"`
+	ServiceName string `json:"service_name"`
+	NodeID      string `json:"node_id"`
+	// True for the member the cluster is bootstrapped from.
+	Bootstrap bool `json:"bootstrap"`
+	// True if member's mysqld_exporter is running.
+	Running bool `json:"running"`
+	// True if the latest successful restore of the member used the same artifact.
+	Restored     bool     `json:"restored"`
+	Instructions []string `json:"instructions"`
+}
+
+// ClusterRestorePlan represents node-by-node restore of Percona XtraDB Cluster or Group Replication cluster.
+type ClusterRestorePlan struct {
+	Cluster    string                `json:"cluster"`
+	ArtifactID string                `json:"artifact_id"`
+	Steps      []*ClusterRestoreStep `json:"steps"`
+}
+
+// clusterMembers returns MySQL Services of the given Service's cluster, the given Service first.
+// Service without cluster is the only member of its own.
+func clusterMembers(q *reform.Querier, service *models.Service) ([]*models.Service, error) {
+	if service.Cluster == "" || service.ServiceType != models.MySQLServiceType {
+		return []*models.Service{service}, nil
+	}
+
+	serviceType := models.MySQLServiceType
+	services, err := models.FindServices(q, models.ServiceFilters{
+		Cluster:     service.Cluster,
+		ServiceType: &serviceType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*models.Service{service}
+	for _, s := range services {
+		if s.ServiceID != service.ServiceID {
+			res = append(res, s)
+		}
+	}
+	sort.Slice(res[1:], func(i, j int) bool { return res[i+1].ServiceName < res[j+1].ServiceName })
+	return res, nil
+}
+
+// clusterRestoreSteps returns restore steps for cluster members; the first member bootstraps the cluster.
+func clusterRestoreSteps(q *reform.Querier, members []*models.Service, artifactID string) ([]*ClusterRestoreStep, error) {
+	exporterType := models.MySQLdExporterType
+	restoreStatus := models.SuccessRestoreStatus
+	res := make([]*ClusterRestoreStep, len(members))
+	for i, m := range members {
+		step := &ClusterRestoreStep{
+			ServiceID:   m.ServiceID,
+			ServiceName: m.ServiceName,
+			NodeID:      m.NodeID,
+			Bootstrap:   i == 0,
+		}
+
+		agents, err := models.FindAgents(q, models.AgentFilters{ServiceID: m.ServiceID, AgentType: &exporterType})
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range agents {
+			if !a.Disabled && a.Status == inventorypb.AgentStatus_RUNNING.String() {
+				step.Running = true
+			}
+		}
+
+		restores, err := models.FindRestoreHistoryItems(q, models.RestoreHistoryItemFilters{
+			ServiceID: m.ServiceID,
+			Status:    &restoreStatus,
+			Limit:     1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		step.Restored = len(restores) != 0 && restores[0].ArtifactID == artifactID
+
+		if step.Bootstrap {
+			step.Instructions = []string{
+				"Stop MySQL on all other cluster members.",
+				fmt.Sprintf("Restore artifact %s on this member.", artifactID),
+				"Bootstrap the cluster from this member: for Percona XtraDB Cluster, start it with `systemctl start mysql@bootstrap`; " +
+					"for Group Replication, run `SET GLOBAL group_replication_bootstrap_group=ON; START GROUP_REPLICATION; " +
+					"SET GLOBAL group_replication_bootstrap_group=OFF;`.",
+			}
+		} else {
+			step.Instructions = []string{
+				fmt.Sprintf("Restore the same artifact %s on this member, so it joins the cluster with incremental state transfer "+
+					"or distributed recovery instead of full SST or clone.", artifactID),
+				"Start MySQL normally; for Group Replication, run `START GROUP_REPLICATION;`.",
+			}
+		}
+		res[i] = step
+	}
+	return res, nil
+}
+
+// checkClusterRestore returns FailedPrecondition error if restore of the first member could split-brain
+// a healthy cluster: other members are running and were not restored from the same artifact.
+func checkClusterRestore(q *reform.Querier, members []*models.Service, artifactID string) error {
+	if len(members) < 2 {
+		return nil
+	}
+
+	steps, err := clusterRestoreSteps(q, members, artifactID)
+	if err != nil {
+		return err
+	}
+
+	var running []string
+	for _, step := range steps[1:] {
+		if step.Running && !step.Restored {
+			running = append(running, step.ServiceName)
+		}
+	}
+	if len(running) == 0 {
+		return nil
+	}
+
+	return status.Errorf(codes.FailedPrecondition, "Service %q is a member of cluster %q with running members %s. "+
+		"Restoring it may split-brain the cluster: stop other members first and follow the cluster restore plan, or force the restore.",
+		members[0].ServiceName, members[0].Cluster, strings.Join(running, ", "))
+}
+
+// ClusterRestorePlan returns node-by-node restore plan of the artifact for the cluster of given Service.
+// The given Service is restored first and bootstraps the cluster; other members join it
+// after the same artifact is restored on them to avoid full state transfer.
+func (s *Service) ClusterRestorePlan(ctx context.Context, serviceID, artifactID string) (*ClusterRestorePlan, error) {
+	var res *ClusterRestorePlan
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		if _, err := s.prepareRestoreJob(tx.Querier, serviceID, artifactID); err != nil {
+			return err
+		}
+
+		service, err := models.FindServiceByID(tx.Querier, serviceID)
+		if err != nil {
+			return err
+		}
+		if service.ServiceType != models.MySQLServiceType || service.Cluster == "" {
+			return status.Errorf(codes.FailedPrecondition, "Service %q is not a member of MySQL cluster.", service.ServiceName)
+		}
+
+		members, err := clusterMembers(tx.Querier, service)
+		if err != nil {
+			return err
+		}
+		steps, err := clusterRestoreSteps(tx.Querier, members, artifactID)
+		if err != nil {
+			return err
+		}
+
+		res = &ClusterRestorePlan{
+			Cluster:    service.Cluster,
+			ArtifactID: artifactID,
+			Steps:      steps,
+		}
+		return nil
+	})
+	return res, err
+}
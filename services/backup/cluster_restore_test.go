@@ -0,0 +1,101 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona/pmm/api/inventorypb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestClusterRestore(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	q := db.Querier
+
+	node, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{
+		NodeName: "test-node",
+	})
+	require.NoError(t, err)
+	pmmAgent, err := models.CreatePMMAgent(q, node.NodeID, nil)
+	require.NoError(t, err)
+
+	addMember := func(name string) (*models.Service, *models.Agent) {
+		service, err := models.AddNewService(q, models.MySQLServiceType, &models.AddDBMSServiceParams{
+			ServiceName: name,
+			NodeID:      node.NodeID,
+			Cluster:     "pxc",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16(3306),
+		})
+		require.NoError(t, err)
+
+		agent, err := models.CreateAgent(q, models.MySQLdExporterType, &models.CreateAgentParams{
+			PMMAgentID: pmmAgent.AgentID,
+			ServiceID:  service.ServiceID,
+			Username:   "user",
+			Password:   "password",
+		})
+		require.NoError(t, err)
+		return service, agent
+	}
+	second, secondAgent := addMember("pxc-2")
+	first, _ := addMember("pxc-1")
+
+	members, err := clusterMembers(q, second)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, second.ServiceID, members[0].ServiceID)
+	assert.Equal(t, first.ServiceID, members[1].ServiceID)
+
+	members, err = clusterMembers(q, first)
+	require.NoError(t, err)
+
+	t.Run("Stopped", func(t *testing.T) {
+		assert.NoError(t, checkClusterRestore(q, members, "artifact_id"))
+
+		steps, err := clusterRestoreSteps(q, members, "artifact_id")
+		require.NoError(t, err)
+		require.Len(t, steps, 2)
+		assert.True(t, steps[0].Bootstrap)
+		assert.False(t, steps[1].Bootstrap)
+		assert.False(t, steps[1].Running)
+	})
+
+	t.Run("Running", func(t *testing.T) {
+		secondAgent.Status = inventorypb.AgentStatus_RUNNING.String()
+		require.NoError(t, q.Update(secondAgent))
+
+		expected := status.New(codes.FailedPrecondition, `Service "pxc-1" is a member of cluster "pxc" with running members pxc-2. `+
+			`Restoring it may split-brain the cluster: stop other members first and follow the cluster restore plan, or force the restore.`)
+		tests.AssertGRPCError(t, expected, checkClusterRestore(q, members, "artifact_id"))
+	})
+}
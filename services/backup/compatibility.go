@@ -0,0 +1,104 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"fmt"
+
+	"github.com/percona/pmm/version"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// IncompatibleArtifactError is returned by RestoreBackup when Service software versions
+// are incompatible with versions that were used to take the backup.
+type IncompatibleArtifactError struct {
+	Software        models.SoftwareName
+	ArtifactVersion string
+	ServiceVersion  string
+}
+
+// Error implements error interface.
+func (e *IncompatibleArtifactError) Error() string {
+	return fmt.Sprintf("artifact was taken with %s %s and can't be restored with %s %s",
+		e.Software, e.ArtifactVersion, e.Software, e.ServiceVersion)
+}
+
+// GRPCStatus returns FailedPrecondition status for the error.
+func (e *IncompatibleArtifactError) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, e.Error())
+}
+
+// artifactMetadata returns metadata for a new artifact of the given Service.
+// It returns nil if Service software versions were not collected yet.
+func artifactMetadata(q *reform.Querier, service *models.Service) (*models.ArtifactMetadata, error) {
+	versions, err := models.FindServiceSoftwareVersionsByServiceID(q, service.ServiceID)
+	switch {
+	case err == nil:
+		return &models.ArtifactMetadata{SoftwareVersions: versions.SoftwareVersions}, nil
+	case errors.Is(err, models.ErrNotFound):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// checkArtifactCompatibility returns IncompatibleArtifactError if artifact can't be restored
+// with the given Service software versions. Unknown versions are not checked.
+func checkArtifactCompatibility(metadata *models.ArtifactMetadata, serviceVersions models.SoftwareVersions) error {
+	if metadata == nil {
+		return nil
+	}
+
+	// physical backup can be restored only to the same MySQL series, not older than the original server
+	if err := checkVersion(metadata, serviceVersions, models.MysqldSoftwareName, true); err != nil {
+		return err
+	}
+
+	// backup is prepared on restore, that requires the same or newer xtrabackup
+	return checkVersion(metadata, serviceVersions, models.XtrabackupSoftwareName, false)
+}
+
+// checkVersion returns IncompatibleArtifactError if Service version of the software is older than artifact's one,
+// or, if sameSeries is true, has different major or minor version.
+func checkVersion(metadata *models.ArtifactMetadata, serviceVersions models.SoftwareVersions, name models.SoftwareName, sameSeries bool) error {
+	artifactVersion := metadata.SoftwareVersions.Version(name)
+	serviceVersion := serviceVersions.Version(name)
+	if artifactVersion == "" || serviceVersion == "" {
+		return nil
+	}
+
+	av, errA := version.Parse(artifactVersion)
+	sv, errS := version.Parse(serviceVersion)
+	if errA != nil || errS != nil {
+		// versions in unexpected format are not checked
+		return nil
+	}
+
+	if sv.Less(av) || (sameSeries && (sv.Major != av.Major || sv.Minor != av.Minor)) {
+		return &IncompatibleArtifactError{
+			Software:        name,
+			ArtifactVersion: artifactVersion,
+			ServiceVersion:  serviceVersion,
+		}
+	}
+	return nil
+}
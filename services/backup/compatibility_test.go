@@ -0,0 +1,66 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestCheckArtifactCompatibility(t *testing.T) {
+	metadata := &models.ArtifactMetadata{
+		SoftwareVersions: models.SoftwareVersions{
+			{Name: models.MysqldSoftwareName, Version: "8.0.25"},
+			{Name: models.XtrabackupSoftwareName, Version: "8.0.25"},
+		},
+	}
+	versions := func(mysqld, xtrabackup string) models.SoftwareVersions {
+		return models.SoftwareVersions{
+			{Name: models.MysqldSoftwareName, Version: mysqld},
+			{Name: models.XtrabackupSoftwareName, Version: xtrabackup},
+		}
+	}
+
+	assert.NoError(t, checkArtifactCompatibility(nil, versions("5.7.30", "2.4.20")))
+	assert.NoError(t, checkArtifactCompatibility(metadata, versions("8.0.25", "8.0.25")))
+	assert.NoError(t, checkArtifactCompatibility(metadata, versions("8.0.26", "8.0.27")))
+	assert.NoError(t, checkArtifactCompatibility(metadata, versions("", "")))
+	assert.NoError(t, checkArtifactCompatibility(metadata, versions("unknown", "8.0.25")))
+
+	for _, tc := range []struct {
+		mysqld     string
+		xtrabackup string
+		expected   *IncompatibleArtifactError
+	}{
+		{"8.0.24", "8.0.25", &IncompatibleArtifactError{models.MysqldSoftwareName, "8.0.25", "8.0.24"}},
+		{"5.7.30", "8.0.25", &IncompatibleArtifactError{models.MysqldSoftwareName, "8.0.25", "5.7.30"}},
+		{"8.1.0", "8.0.25", &IncompatibleArtifactError{models.MysqldSoftwareName, "8.0.25", "8.1.0"}},
+		{"8.0.25", "8.0.24", &IncompatibleArtifactError{models.XtrabackupSoftwareName, "8.0.25", "8.0.24"}},
+	} {
+		err := checkArtifactCompatibility(metadata, versions(tc.mysqld, tc.xtrabackup))
+		assert.Equal(t, tc.expected, err)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	}
+
+	err := checkArtifactCompatibility(metadata, versions("8.0.24", "8.0.25"))
+	assert.EqualError(t, err, "artifact was taken with mysqld 8.0.25 and can't be restored with mysqld 8.0.24")
+}
@@ -0,0 +1,104 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// Names of objects stored in configuration snapshot artifacts.
+const (
+	snapshotInventoryObjectName = "inventory.json"
+	snapshotSummaryObjectName   = "pt-summary.txt"
+)
+
+// snapshotInventory is a PMM inventory part of configuration snapshot.
+type snapshotInventory struct {
+	Service *models.Service `json:"service"`
+	Node    *models.Node    `json:"node"`
+}
+
+// ConfigSnapshotService stores configuration snapshots of HAProxy and External Services
+// collected by pt-summary Action on pmm-agent.
+type ConfigSnapshotService struct {
+	db *reform.DB
+	s3 s3
+	l  *logrus.Entry
+}
+
+// NewConfigSnapshotService creates new configuration snapshots service.
+func NewConfigSnapshotService(db *reform.DB, s3 s3) *ConfigSnapshotService {
+	return &ConfigSnapshotService{
+		db: db,
+		s3: s3,
+		l:  logrus.WithField("component", "services/backup/config_snapshot"),
+	}
+}
+
+// StoreSnapshot uploads pt-summary output and PMM inventory of artifact's Service to artifact's location.
+func (s *ConfigSnapshotService) StoreSnapshot(ctx context.Context, artifactID, summary string) error {
+	var artifact *models.Artifact
+	var location *models.BackupLocation
+	var inventory snapshotInventory
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if artifact, err = models.FindArtifactByID(tx.Querier, artifactID); err != nil {
+			return err
+		}
+		if location, err = models.FindBackupLocationByID(tx.Querier, artifact.LocationID); err != nil {
+			return err
+		}
+		if inventory.Service, err = models.FindServiceByID(tx.Querier, artifact.ServiceID); err != nil {
+			return err
+		}
+		inventory.Node, err = models.FindNodeByID(tx.Querier, inventory.Service.NodeID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	c := location.S3Config
+	if c == nil {
+		return errors.Errorf("unsupported location config")
+	}
+
+	b, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	prefix := artifact.Name + "/"
+	if err = s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix+snapshotInventoryObjectName, bytes.NewReader(b)); err != nil {
+		return err
+	}
+	if err = s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix+snapshotSummaryObjectName, strings.NewReader(summary)); err != nil {
+		return err
+	}
+
+	s.l.Debugf("Configuration snapshot %q stored.", artifact.Name)
+	return nil
+}
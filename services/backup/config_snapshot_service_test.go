@@ -0,0 +1,93 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestConfigSnapshotService(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	agent := setup(t, db.Querier, "test-service")
+	endpoint := "https://s3.us-west-2.amazonaws.com/"
+	accessKey, secretKey, bucketName := "access_key", "secret_key", "example_bucket"
+
+	location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			S3Config: &models.S3LocationConfig{
+				Endpoint:     endpoint,
+				AccessKey:    accessKey,
+				SecretKey:    secretKey,
+				BucketName:   bucketName,
+				BucketRegion: "us-east-2",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	artifact, err := models.CreateArtifact(db.Querier, models.CreateArtifactParams{
+		Name:       "snapshot",
+		Vendor:     "mysql",
+		LocationID: location.ID,
+		ServiceID:  pointer.GetString(agent.ServiceID),
+		DataModel:  models.SnapshotDataModel,
+		Status:     models.PendingBackupStatus,
+	})
+	require.NoError(t, err)
+
+	objects := make(map[string]string)
+	mockedS3 := &mockS3{}
+	mockedS3.On("PutObject", ctx, endpoint, accessKey, secretKey, bucketName, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			b, err := ioutil.ReadAll(args.Get(6).(io.Reader))
+			require.NoError(t, err)
+			objects[args.String(5)] = string(b)
+		}).
+		Return(nil).Twice()
+	t.Cleanup(func() { mockedS3.AssertExpectations(t) })
+
+	err = NewConfigSnapshotService(db, mockedS3).StoreSnapshot(ctx, artifact.ID, "# Percona Toolkit System Summary Report")
+	require.NoError(t, err)
+
+	assert.Equal(t, "# Percona Toolkit System Summary Report", objects["snapshot/pt-summary.txt"])
+
+	var inventory snapshotInventory
+	require.NoError(t, json.Unmarshal([]byte(objects["snapshot/inventory.json"]), &inventory))
+	assert.Equal(t, "test-service", inventory.Service.ServiceName)
+	assert.Equal(t, "test-node", inventory.Node.NodeName)
+}
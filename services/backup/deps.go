@@ -18,13 +18,18 @@ package backup
 
 import (
 	"context"
+	"io"
 	"time"
 
+	"github.com/percona/pmm/api/alertmanager/ammodels"
+
 	"github.com/percona/pmm-managed/models"
 )
 
 //go:generate mockery -name=jobsService -case=snake -inpkg -testonly
+//go:generate mockery -name=actionsService -case=snake -inpkg -testonly
 //go:generate mockery -name=s3 -case=snake -inpkg -testonly
+//go:generate mockery -name=alertmanagerService -case=snake -inpkg -testonly
 
 // jobsService is a subset of methods of agents.JobsService used by this package.
 // We use it instead of real type for testing and to avoid dependency cycle.
@@ -64,10 +69,30 @@ type jobsService interface {
 	) error
 }
 
+// actionsService is a subset of methods of agents.ActionsService used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type actionsService interface {
+	StartPTSummaryAction(ctx context.Context, id, pmmAgentID string) error
+	StartMySQLShowCreateTableAction(ctx context.Context, id, pmmAgentID, dsn, table string, files map[string]string, tdp *models.DelimiterPair, tlsSkipVerify bool) error
+	StartMySQLQuerySelectAction(ctx context.Context, id, pmmAgentID, dsn, query string, files map[string]string, tdp *models.DelimiterPair, tlsSkipVerify bool) error
+}
+
 type s3 interface {
 	RemoveRecursive(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) error
+	ListPrefixes(ctx context.Context, endpoint, accessKey, secretKey, bucketName string) (map[string]time.Time, error)
+	GetPrefixSize(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) (int64, error)
+	PrefixExists(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) (bool, error)
+	ListObjectETags(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) (map[string]string, error)
+	PutObject(ctx context.Context, endpoint, accessKey, secretKey, bucketName, objectName string, r io.Reader) error
+	GetObject(ctx context.Context, endpoint, accessKey, secretKey, bucketName, objectName string) (io.ReadCloser, error)
 }
 
 type removalService interface {
 	DeleteArtifact(ctx context.Context, artifactID string, removeFiles bool) error
 }
+
+// alertmanagerService is a subset of methods of alertmanager.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type alertmanagerService interface {
+	SendAlerts(ctx context.Context, alerts ammodels.PostableAlerts)
+}
@@ -0,0 +1,218 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	prometheusNamespace = "pmm_managed"
+	prometheusSubsystem = "backup_gc"
+
+	// orphanedFilesGracePeriod protects files of just started backups and imports from being treated as orphaned.
+	orphanedFilesGracePeriod = 24 * time.Hour
+
+	// MetricsExportsDir is a directory of backup locations where metrics data exports are stored.
+	// Its files don't belong to any artifact, so they are never treated as orphaned.
+	MetricsExportsDir = "pmm_metrics_exports"
+)
+
+// GCService periodically reconciles artifacts with the actual storage contents.
+type GCService struct {
+	db                  *reform.DB
+	s3                  s3
+	deleteOrphanedFiles bool
+	l                   *logrus.Entry
+
+	mOrphanedArtifacts *prom.GaugeVec
+	mOrphanedFiles     *prom.GaugeVec
+	mDeletedFiles      prom.Counter
+}
+
+// NewGCService creates new artifacts garbage collection service.
+// If deleteOrphanedFiles is true, files in the storage without artifacts are removed.
+func NewGCService(db *reform.DB, s3 s3, deleteOrphanedFiles bool) *GCService {
+	return &GCService{
+		db:                  db,
+		s3:                  s3,
+		deleteOrphanedFiles: deleteOrphanedFiles,
+		l:                   logrus.WithField("component", "services/backup/gc"),
+
+		mOrphanedArtifacts: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "orphaned_artifacts",
+			Help:      "A number of successful artifacts without files in the storage.",
+		}, []string{"location_id"}),
+		mOrphanedFiles: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "orphaned_files",
+			Help:      "A number of top-level directories in the storage without artifacts.",
+		}, []string{"location_id"}),
+		mDeletedFiles: prom.NewCounter(prom.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "orphaned_files_deleted_total",
+			Help:      "A total number of deleted top-level directories in the storage without artifacts.",
+		}),
+	}
+}
+
+// Run reconciles artifacts with storage contents with given interval until context is canceled.
+func (s *GCService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile reconciles artifacts of all S3 locations.
+func (s *GCService) reconcile(ctx context.Context) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		s.l.Error(err)
+		return
+	}
+	if !settings.BackupManagement.Enabled {
+		return
+	}
+
+	locations, err := models.FindBackupLocations(s.db.Querier)
+	if err != nil {
+		s.l.Error(err)
+		return
+	}
+
+	s.mOrphanedArtifacts.Reset()
+	s.mOrphanedFiles.Reset()
+	for _, location := range locations {
+		// only S3 storage can be listed by pmm-managed
+		if location.S3Config == nil {
+			continue
+		}
+
+		if err = s.reconcileLocation(ctx, location); err != nil {
+			s.l.Warnf("Failed to reconcile artifacts of location %q: %s.", location.Name, err)
+		}
+	}
+}
+
+// reconcileLocation marks successful artifacts without files as orphaned (and unmarks them when files appear),
+// and optionally removes files without artifacts.
+func (s *GCService) reconcileLocation(ctx context.Context, location *models.BackupLocation) error {
+	c := location.S3Config
+	prefixes, err := s.s3.ListPrefixes(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := models.FindArtifacts(s.db.Querier, models.ArtifactFilters{LocationID: location.ID})
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{}, len(artifacts))
+	var orphanedArtifacts int
+	for _, a := range artifacts {
+		names[a.Name] = struct{}{}
+		if a.Status != models.SuccessBackupStatus {
+			continue
+		}
+
+		_, ok := prefixes[a.Name]
+		orphaned := !ok
+		if orphaned {
+			orphanedArtifacts++
+		}
+		if orphaned == a.Orphaned {
+			continue
+		}
+
+		if orphaned {
+			s.l.Warnf("Files of artifact %q (%s) are missing in location %q.", a.Name, a.ID, location.Name)
+		} else {
+			s.l.Infof("Files of artifact %q (%s) are found in location %q.", a.Name, a.ID, location.Name)
+		}
+		if _, err = models.UpdateArtifact(s.db.Querier, a.ID, models.UpdateArtifactParams{Orphaned: pointer.ToBool(orphaned)}); err != nil {
+			return err
+		}
+	}
+	s.mOrphanedArtifacts.WithLabelValues(location.ID).Set(float64(orphanedArtifacts))
+
+	now := time.Now()
+	var orphanedFiles int
+	for prefix, lastModified := range prefixes {
+		if _, ok := names[prefix]; ok || prefix == MetricsExportsDir || now.Sub(lastModified) < orphanedFilesGracePeriod {
+			continue
+		}
+
+		if !s.deleteOrphanedFiles {
+			s.l.Debugf("Files %q in location %q don't belong to any artifact.", prefix+"/", location.Name)
+			orphanedFiles++
+			continue
+		}
+
+		s.l.Infof("Removing files %q in location %q that don't belong to any artifact.", prefix+"/", location.Name)
+		// append a slash to avoid removing files of artifacts with the same name prefix, see RemovalService.DeleteArtifact
+		if err = s.s3.RemoveRecursive(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix+"/"); err != nil {
+			s.l.Warnf("Failed to remove files %q in location %q: %s.", prefix+"/", location.Name, err)
+			orphanedFiles++
+			continue
+		}
+		s.mDeletedFiles.Inc()
+	}
+	s.mOrphanedFiles.WithLabelValues(location.ID).Set(float64(orphanedFiles))
+
+	return nil
+}
+
+// Describe implements prometheus.Collector.
+func (s *GCService) Describe(ch chan<- *prom.Desc) {
+	s.mOrphanedArtifacts.Describe(ch)
+	s.mOrphanedFiles.Describe(ch)
+	s.mDeletedFiles.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (s *GCService) Collect(ch chan<- prom.Metric) {
+	s.mOrphanedArtifacts.Collect(ch)
+	s.mOrphanedFiles.Collect(ch)
+	s.mDeletedFiles.Collect(ch)
+}
+
+// check interfaces.
+var (
+	_ prom.Collector = (*GCService)(nil)
+)
@@ -0,0 +1,114 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestGCService(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	mockedS3 := &mockS3{}
+	gcService := NewGCService(db, mockedS3, true)
+
+	agent := setup(t, db.Querier, "test-service")
+	endpoint := "https://s3.us-west-2.amazonaws.com/"
+	accessKey, secretKey, bucketName := "access_key", "secret_key", "example_bucket"
+
+	location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			S3Config: &models.S3LocationConfig{
+				Endpoint:   endpoint,
+				AccessKey:  accessKey,
+				SecretKey:  secretKey,
+				BucketName: bucketName,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	createArtifact := func(name string, status models.BackupStatus) *models.Artifact {
+		artifact, err := models.CreateArtifact(db.Querier, models.CreateArtifactParams{
+			Name:       name,
+			Vendor:     "MySQL",
+			LocationID: location.ID,
+			ServiceID:  *agent.ServiceID,
+			DataModel:  models.PhysicalDataModel,
+			Status:     status,
+		})
+		require.NoError(t, err)
+		return artifact
+	}
+	present := createArtifact("present", models.SuccessBackupStatus)
+	missing := createArtifact("missing", models.SuccessBackupStatus)
+	pending := createArtifact("pending", models.PendingBackupStatus)
+
+	assertOrphaned := func(t *testing.T, expected bool, artifactID string) {
+		t.Helper()
+		artifact, err := models.FindArtifactByID(db.Querier, artifactID)
+		require.NoError(t, err)
+		assert.Equal(t, expected, artifact.Orphaned)
+	}
+
+	old := time.Now().Add(-2 * orphanedFilesGracePeriod)
+
+	t.Run("orphaned", func(t *testing.T) {
+		mockedS3.On("ListPrefixes", mock.Anything, endpoint, accessKey, secretKey, bucketName).Return(map[string]time.Time{
+			"present":   old,
+			"stray-old": old,
+			"stray-new": time.Now(),
+		}, nil).Once()
+		mockedS3.On("RemoveRecursive", mock.Anything, endpoint, accessKey, secretKey, bucketName, "stray-old/").Return(nil).Once()
+
+		err := gcService.reconcileLocation(ctx, location)
+		require.NoError(t, err)
+
+		assertOrphaned(t, false, present.ID)
+		assertOrphaned(t, true, missing.ID)
+		assertOrphaned(t, false, pending.ID)
+	})
+
+	t.Run("found", func(t *testing.T) {
+		mockedS3.On("ListPrefixes", mock.Anything, endpoint, accessKey, secretKey, bucketName).Return(map[string]time.Time{
+			"present": old,
+			"missing": old,
+		}, nil).Once()
+
+		err := gcService.reconcileLocation(ctx, location)
+		require.NoError(t, err)
+
+		assertOrphaned(t, false, present.ID)
+		assertOrphaned(t, false, missing.ID)
+	})
+
+	mock.AssertExpectationsForObjects(t, mockedS3)
+}
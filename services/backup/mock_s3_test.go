@@ -4,6 +4,8 @@ package backup
 
 import (
 	context "context"
+	io "io"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,6 +15,131 @@ type mockS3 struct {
 	mock.Mock
 }
 
+// GetObject provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, objectName
+func (_m *mockS3) GetObject(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, objectName string) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, objectName)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) io.ReadCloser); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, objectName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, accessKey, secretKey, bucketName, objectName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPrefixSize provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, prefix
+func (_m *mockS3) GetPrefixSize(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, prefix string) (int64, error) {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) int64); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListObjectETags provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, prefix
+func (_m *mockS3) ListObjectETags(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, prefix string) (map[string]string, error) {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) map[string]string); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPrefixes provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName
+func (_m *mockS3) ListPrefixes(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string) (map[string]time.Time, error) {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName)
+
+	var r0 map[string]time.Time
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) map[string]time.Time); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]time.Time)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, accessKey, secretKey, bucketName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PrefixExists provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, prefix
+func (_m *mockS3) PrefixExists(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, prefix string) (bool, error) {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) bool); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutObject provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, objectName, r
+func (_m *mockS3) PutObject(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, objectName string, r io.Reader) error {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, objectName, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string, io.Reader) error); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, objectName, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveRecursive provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, prefix
 func (_m *mockS3) RemoveRecursive(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, prefix string) error {
 	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
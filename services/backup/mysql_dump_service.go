@@ -0,0 +1,306 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/percona/pmm/api/agentpb"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	// mysqlTablesQuery lists tables of MySQL logical backup; system schemas are skipped.
+	mysqlTablesQuery = "table_schema AS `schema`, table_name AS `name` FROM information_schema.tables " +
+		"WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys') " +
+		"ORDER BY table_schema, table_name"
+
+	// mysqlDatabasesObjectName is a name of MySQL logical backup object with CREATE DATABASE statements;
+	// each table is stored in a separate object.
+	mysqlDatabasesObjectName = "databases.sql"
+)
+
+// MySQLDumpService performs MySQL logical backups with MySQL Actions on pmm-agent, so they don't require
+// mysqldump support in pmm-agent jobs. Table definitions and rows are read with DSN of Service's exporter,
+// and each table is stored as a separate SQL file in S3 backup location.
+type MySQLDumpService struct {
+	db             *reform.DB
+	actionsService actionsService
+	s3             s3
+	l              *logrus.Entry
+}
+
+// NewMySQLDumpService creates new MySQL logical backups service.
+func NewMySQLDumpService(db *reform.DB, actionsService actionsService, s3 s3) *MySQLDumpService {
+	return &MySQLDumpService{
+		db:             db,
+		actionsService: actionsService,
+		s3:             s3,
+		l:              logrus.WithField("component", "services/backup/mysql_dump"),
+	}
+}
+
+// HandleActionResult processes output of finished Action of MySQL logical backup job and starts the next one.
+// It returns true when all tables are stored in backup location and the job can be marked as done.
+func (s *MySQLDumpService) HandleActionResult(ctx context.Context, job *models.JobResult, output string) (bool, error) {
+	r := job.Result.MySQLLogicalBackup
+
+	var artifact *models.Artifact
+	var location *models.BackupLocation
+	var service *models.Service
+	var createTable *models.ActionResult
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if artifact, err = models.FindArtifactByID(tx.Querier, r.ArtifactID); err != nil {
+			return err
+		}
+		if location, err = models.FindBackupLocationByID(tx.Querier, artifact.LocationID); err != nil {
+			return err
+		}
+		if service, err = models.FindServiceByID(tx.Querier, artifact.ServiceID); err != nil {
+			return err
+		}
+		if r.CreateTableActionID != "" {
+			createTable, err = models.FindActionResultByID(tx.Querier, r.CreateTableActionID)
+		}
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	c := location.S3Config
+	if c == nil {
+		return false, errors.Errorf("unsupported location config")
+	}
+
+	prefix := artifact.Name + "/"
+	switch {
+	case !r.TablesListed:
+		if r.Tables, err = parseMySQLTables([]byte(output)); err != nil {
+			return false, err
+		}
+		r.TablesListed = true
+
+		b := mysqlDatabasesDump(r.Tables)
+		if err = s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix+mysqlDatabasesObjectName, bytes.NewReader(b)); err != nil {
+			return false, err
+		}
+
+	case r.CreateTableActionID == "":
+		r.CreateTableActionID = r.ActionID
+
+	default:
+		table := r.Tables[r.TablesDone]
+		b, err := mysqlTableDump(table, createTable.Output, []byte(output))
+		if err != nil {
+			return false, err
+		}
+		objectName := prefix + table.Schema + "." + table.Name + ".sql"
+		if err = s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, objectName, bytes.NewReader(b)); err != nil {
+			return false, err
+		}
+		r.TablesDone++
+		r.CreateTableActionID = ""
+	}
+
+	if r.TablesDone == len(r.Tables) {
+		s.l.Debugf("MySQL logical backup %q stored.", artifact.Name)
+		return true, nil
+	}
+
+	var canceled bool
+	err = s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		res, err := models.FindJobResultByID(tx.Querier, job.ID)
+		if err != nil {
+			return err
+		}
+		// job was canceled or timed out
+		if res.Done {
+			canceled = true
+			return nil
+		}
+
+		action, err := models.CreateActionResult(tx.Querier, res.PMMAgentID)
+		if err != nil {
+			return err
+		}
+		r.ActionID = action.ID
+		res.Result.MySQLLogicalBackup = r
+		res.Result.Progress = &models.JobProgress{
+			Percentage: float64(r.TablesDone) * 100 / float64(len(r.Tables)),
+			Status:     fmt.Sprintf("%d of %d tables stored", r.TablesDone, len(r.Tables)),
+			UpdatedAt:  models.Now(),
+		}
+		job = res
+		return tx.Update(res)
+	})
+	if err != nil || canceled {
+		return false, err
+	}
+
+	return false, startMySQLDumpAction(ctx, s.db.Querier, s.actionsService, job, service)
+}
+
+// startMySQLDumpAction starts the current Action of MySQL logical backup job:
+// tables listing, SHOW CREATE TABLE or SELECT of the current table.
+func startMySQLDumpAction(ctx context.Context, q *reform.Querier, actionsService actionsService, job *models.JobResult, service *models.Service) error {
+	r := job.Result.MySQLLogicalBackup
+
+	var table models.MySQLTable
+	if r.TablesListed {
+		table = r.Tables[r.TablesDone]
+	}
+
+	dsn, agent, err := models.FindDSNByServiceIDandPMMAgentID(q, service.ServiceID, job.PMMAgentID, table.Schema)
+	if err != nil {
+		return err
+	}
+	files := agent.Files()
+	tdp := agent.TemplateDelimiters(service)
+
+	switch {
+	case !r.TablesListed:
+		return actionsService.StartMySQLQuerySelectAction(ctx, r.ActionID, job.PMMAgentID, dsn, mysqlTablesQuery, files, tdp, agent.TLSSkipVerify)
+	case r.CreateTableActionID == "":
+		return actionsService.StartMySQLShowCreateTableAction(ctx, r.ActionID, job.PMMAgentID, dsn, table.Name, files, tdp, agent.TLSSkipVerify)
+	default:
+		query := "* FROM " + quoteMySQLIdentifier(table.Schema) + "." + quoteMySQLIdentifier(table.Name)
+		return actionsService.StartMySQLQuerySelectAction(ctx, r.ActionID, job.PMMAgentID, dsn, query, files, tdp, agent.TLSSkipVerify)
+	}
+}
+
+// parseMySQLTables extracts tables from output of SELECT Action with mysqlTablesQuery.
+func parseMySQLTables(output []byte) ([]models.MySQLTable, error) {
+	rows, err := agentpb.UnmarshalActionQueryResult(output)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]models.MySQLTable, 0, len(rows))
+	for _, row := range rows {
+		schema, ok1 := row["schema"].(string)
+		name, ok2 := row["name"].(string)
+		if !ok1 || !ok2 {
+			return nil, errors.Errorf("unexpected tables listing row: %v", row)
+		}
+		res = append(res, models.MySQLTable{Schema: schema, Name: name})
+	}
+	return res, nil
+}
+
+// mysqlDatabasesDump returns CREATE DATABASE statements for schemas of given tables.
+func mysqlDatabasesDump(tables []models.MySQLTable) []byte {
+	var buf bytes.Buffer
+	var last string
+	for _, t := range tables {
+		if t.Schema == last {
+			continue
+		}
+		last = t.Schema
+		fmt.Fprintf(&buf, "CREATE DATABASE IF NOT EXISTS %s;\n", quoteMySQLIdentifier(t.Schema))
+	}
+	return buf.Bytes()
+}
+
+// mysqlTableDump returns SQL statements that re-create table with given definition
+// and insert rows from output of SELECT Action.
+func mysqlTableDump(table models.MySQLTable, createTable string, rowsOutput []byte) ([]byte, error) {
+	rows, err := agentpb.UnmarshalActionQueryResult(rowsOutput)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "USE %s;\n", quoteMySQLIdentifier(table.Schema))
+	fmt.Fprintf(&buf, "DROP TABLE IF EXISTS %s;\n", quoteMySQLIdentifier(table.Name))
+	fmt.Fprintf(&buf, "%s;\n", strings.TrimRight(createTable, ";\n"))
+
+	for _, row := range rows {
+		columns := make([]string, 0, len(row))
+		for c := range row {
+			columns = append(columns, c)
+		}
+		sort.Strings(columns)
+
+		names := make([]string, len(columns))
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = quoteMySQLIdentifier(c)
+			values[i] = mysqlLiteral(row[c])
+		}
+		fmt.Fprintf(&buf, "INSERT INTO %s (%s) VALUES (%s);\n",
+			quoteMySQLIdentifier(table.Name), strings.Join(names, ", "), strings.Join(values, ", "))
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteMySQLIdentifier returns MySQL identifier quoted with backticks.
+func quoteMySQLIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// mysqlStringReplacer escapes special characters of MySQL string literals.
+var mysqlStringReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+// mysqlLiteral returns MySQL literal for value of SELECT Action output.
+func mysqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	case string:
+		// binary data can't be stored in UTF-8 dump as is
+		if !utf8.ValidString(v) {
+			return "0x" + hex.EncodeToString([]byte(v))
+		}
+		return "'" + mysqlStringReplacer.Replace(v) + "'"
+	default:
+		return "'" + mysqlStringReplacer.Replace(fmt.Sprint(v)) + "'"
+	}
+}
@@ -0,0 +1,155 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/percona/pmm/api/agentpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestMySQLDumpService(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	agent := setup(t, db.Querier, "test-service")
+	endpoint := "https://s3.us-west-2.amazonaws.com/"
+	accessKey, secretKey, bucketName := "access_key", "secret_key", "example_bucket"
+
+	location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			S3Config: &models.S3LocationConfig{
+				Endpoint:     endpoint,
+				AccessKey:    accessKey,
+				SecretKey:    secretKey,
+				BucketName:   bucketName,
+				BucketRegion: "us-east-2",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	artifact, err := models.CreateArtifact(db.Querier, models.CreateArtifactParams{
+		Name:       "dump",
+		Vendor:     "mysql",
+		LocationID: location.ID,
+		ServiceID:  pointer.GetString(agent.ServiceID),
+		DataModel:  models.LogicalDataModel,
+		Status:     models.PendingBackupStatus,
+	})
+	require.NoError(t, err)
+
+	pmmAgentID := pointer.GetString(agent.PMMAgentID)
+	action, err := models.CreateActionResult(db.Querier, pmmAgentID)
+	require.NoError(t, err)
+	job, err := models.CreateJobResult(db.Querier, pmmAgentID, models.MySQLLogicalBackupJob, &models.JobResultData{
+		MySQLLogicalBackup: &models.MySQLLogicalBackupJobResult{
+			ArtifactID: artifact.ID,
+			ActionID:   action.ID,
+		},
+	})
+	require.NoError(t, err)
+
+	objects := make(map[string]string)
+	mockedS3 := &mockS3{}
+	mockedS3.On("PutObject", ctx, endpoint, accessKey, secretKey, bucketName, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			b, err := ioutil.ReadAll(args.Get(6).(io.Reader))
+			require.NoError(t, err)
+			objects[args.String(5)] = string(b)
+		}).
+		Return(nil).Twice()
+	mockedActionsService := &mockActionsService{}
+	t.Cleanup(func() {
+		mockedS3.AssertExpectations(t)
+		mockedActionsService.AssertExpectations(t)
+	})
+	s := NewMySQLDumpService(db, mockedActionsService, mockedS3)
+
+	// handleAction passes output to the service like agents.Handler does and returns the updated job
+	handleAction := func(output []byte) bool {
+		job, err = models.FindJobResultByActionID(db.Querier, job.Result.MySQLLogicalBackup.ActionID)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		done, err := s.HandleActionResult(ctx, job, string(output))
+		require.NoError(t, err)
+		return done
+	}
+
+	tables, err := agentpb.MarshalActionQuerySQLResult([]string{"schema", "name"}, [][]interface{}{{"shop", "orders"}})
+	require.NoError(t, err)
+	mockedActionsService.On("StartMySQLShowCreateTableAction", ctx, mock.Anything, pmmAgentID, mock.Anything, "orders",
+		mock.Anything, mock.Anything, false).Return(nil).Once()
+	assert.False(t, handleAction(tables))
+	assert.Equal(t, "CREATE DATABASE IF NOT EXISTS `shop`;\n", objects["dump/databases.sql"])
+
+	mockedActionsService.On("StartMySQLQuerySelectAction", ctx, mock.Anything, pmmAgentID, mock.Anything, "* FROM `shop`.`orders`",
+		mock.Anything, mock.Anything, false).Return(nil).Once()
+	assert.False(t, handleAction([]byte("CREATE TABLE `orders` (\n  `id` int NOT NULL\n)")))
+
+	rows, err := agentpb.MarshalActionQuerySQLResult([]string{"id"}, [][]interface{}{{int64(1)}, {int64(2)}})
+	require.NoError(t, err)
+	assert.True(t, handleAction(rows))
+	expected := "USE `shop`;\n" +
+		"DROP TABLE IF EXISTS `orders`;\n" +
+		"CREATE TABLE `orders` (\n  `id` int NOT NULL\n);\n" +
+		"INSERT INTO `orders` (`id`) VALUES (1);\n" +
+		"INSERT INTO `orders` (`id`) VALUES (2);\n"
+	assert.Equal(t, expected, objects["dump/shop.orders.sql"])
+
+	job, err = models.FindJobResultByID(db.Querier, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Result.MySQLLogicalBackup.TablesDone)
+}
+
+func TestMySQLLiteral(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{true, "1"},
+		{int64(-1), "-1"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+		{1.5, "1.5"},
+		{"it's\n\\", `'it\'s\n\\'`},
+		{"\xff\x00", "0xff00"},
+		{time.Date(2021, 8, 16, 12, 54, 48, 0, time.UTC), "'2021-08-16 12:54:48'"},
+	} {
+		assert.Equal(t, tc.expected, mysqlLiteral(tc.value), "%#v", tc.value)
+	}
+
+	assert.Equal(t, "`a``b`", quoteMySQLIdentifier("a`b"))
+}
@@ -0,0 +1,212 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/percona/pmm/api/alertmanager/ammodels"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// notificationTTL is a time after which notification alert is resolved.
+// It should be greater than Alertmanager's group_wait, but less than repeat_interval.
+const notificationTTL = time.Hour
+
+// NotificationService sends notifications about finished backup and restore jobs
+// to Alertmanager, which delivers them to Integrated Alerting channels.
+type NotificationService struct {
+	db           *reform.DB
+	l            *logrus.Entry
+	alertmanager alertmanagerService
+}
+
+// NewNotificationService creates new backup notifications service.
+func NewNotificationService(db *reform.DB, alertmanager alertmanagerService) *NotificationService {
+	return &NotificationService{
+		l:            logrus.WithField("component", "management/backup/notifications"),
+		db:           db,
+		alertmanager: alertmanager,
+	}
+}
+
+// jobNotification contains data of a single notification.
+type jobNotification struct {
+	restore      bool
+	artifactID   string
+	artifactName string
+	serviceName  string
+	scheduleID   string
+	duration     time.Duration
+	err          string
+}
+
+// NotifyJobFinished sends notification about finished backup or restore job.
+// Notifications about scheduled backups are sent only if enabled in the scheduled task settings.
+func (s *NotificationService) NotifyJobFinished(ctx context.Context, jobID string) error {
+	var n *jobNotification
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		var err error
+		n, err = s.prepareNotification(tx.Querier, jobID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if n == nil {
+		return nil
+	}
+
+	s.alertmanager.SendAlerts(ctx, ammodels.PostableAlerts{notificationAlert(n, time.Now())})
+	return nil
+}
+
+// prepareNotification returns notification for given job, or nil if it should not be sent.
+func (s *NotificationService) prepareNotification(q *reform.Querier, jobID string) (*jobNotification, error) {
+	res, err := models.FindJobResultByID(q, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &jobNotification{
+		duration: res.UpdatedAt.Sub(res.CreatedAt),
+		err:      res.Error,
+	}
+
+	var restoreID string
+	switch res.Type {
+	case models.MySQLBackupJob:
+		n.artifactID = res.Result.MySQLBackup.ArtifactID
+	case models.MongoDBBackupJob:
+		n.artifactID = res.Result.MongoDBBackup.ArtifactID
+	case models.ConfigSnapshotBackupJob:
+		n.artifactID = res.Result.ConfigSnapshotBackup.ArtifactID
+	case models.MySQLLogicalBackupJob:
+		n.artifactID = res.Result.MySQLLogicalBackup.ArtifactID
+	case models.MySQLRestoreBackupJob:
+		restoreID = res.Result.MySQLRestoreBackup.RestoreID
+	case models.MongoDBRestoreBackupJob:
+		restoreID = res.Result.MongoDBRestoreBackup.RestoreID
+	default:
+		return nil, errors.Errorf("unexpected job type %s", res.Type)
+	}
+
+	var serviceID string
+	if restoreID != "" {
+		item, err := models.FindRestoreHistoryItemByID(q, restoreID)
+		if err != nil {
+			return nil, err
+		}
+		n.restore = true
+		n.artifactID = item.ArtifactID
+		serviceID = item.ServiceID
+	}
+
+	artifact, err := models.FindArtifactByID(q, n.artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if !n.restore && artifact.Status == models.CanceledBackupStatus {
+		return nil, nil
+	}
+	n.artifactName = artifact.Name
+	if serviceID == "" {
+		serviceID = artifact.ServiceID
+	}
+
+	if !n.restore && artifact.ScheduleID != "" {
+		task, err := models.FindScheduledTaskByID(q, artifact.ScheduleID)
+		if err != nil {
+			return nil, err
+		}
+		notifications := task.BackupNotifications()
+		if notifications == nil {
+			return nil, nil
+		}
+		if (n.err == "" && !notifications.OnSuccess) || (n.err != "" && !notifications.OnFailure) {
+			return nil, nil
+		}
+		n.scheduleID = task.ID
+	}
+
+	// Service could be already removed, artifact name is enough in that case
+	if service, err := models.FindServiceByID(q, serviceID); err == nil {
+		n.serviceName = service.ServiceName
+	} else {
+		s.l.Debugf("Failed to find service %s: %s.", serviceID, err)
+	}
+
+	return n, nil
+}
+
+// notificationAlert returns alert for given notification.
+func notificationAlert(n *jobNotification, now time.Time) *ammodels.PostableAlert {
+	operation := "Backup"
+	alertName := "pmm_backup_finished"
+	if n.restore {
+		operation = "Restore"
+		alertName = "pmm_restore_finished"
+	}
+
+	result := "success"
+	severity := "notice"
+	summary := fmt.Sprintf("%s of %s succeeded", operation, n.artifactName)
+	description := fmt.Sprintf("%s of %s finished in %s.", operation, n.artifactName, n.duration.Round(time.Second))
+	if n.err != "" {
+		result = "error"
+		severity = "error"
+		summary = fmt.Sprintf("%s of %s failed", operation, n.artifactName)
+		description = fmt.Sprintf("%s of %s failed after %s: %s", operation, n.artifactName, n.duration.Round(time.Second), n.err)
+	}
+
+	labels := map[string]string{
+		model.AlertNameLabel:  alertName,
+		"severity":            severity,
+		"backup_notification": "1",
+		"result":              result,
+		"artifact_id":         n.artifactID,
+		"artifact_name":       n.artifactName,
+	}
+	if n.serviceName != "" {
+		labels["service_name"] = n.serviceName
+	}
+	if n.scheduleID != "" {
+		// used for routing, see alertmanager.Service.populateConfig
+		labels["backup_schedule_id"] = n.scheduleID
+	}
+
+	return &ammodels.PostableAlert{
+		Alert: ammodels.Alert{
+			Labels: labels,
+		},
+		StartsAt: strfmt.DateTime(now.UTC()),
+		EndsAt:   strfmt.DateTime(now.Add(notificationTTL).UTC()),
+		Annotations: map[string]string{
+			"summary":     summary,
+			"description": description,
+			"duration":    n.duration.Round(time.Second).String(),
+		},
+	}
+}
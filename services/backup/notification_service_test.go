@@ -0,0 +1,79 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/percona/pmm/api/alertmanager/ammodels"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationAlert(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("ScheduledBackupSuccess", func(t *testing.T) {
+		alert := notificationAlert(&jobNotification{
+			artifactID:   "/artifact_id/1",
+			artifactName: "daily_2021-06-01",
+			serviceName:  "mysql1",
+			scheduleID:   "/scheduled_task_id/1",
+			duration:     90*time.Second + 300*time.Millisecond,
+		}, now)
+
+		expected := ammodels.LabelSet{
+			"alertname":           "pmm_backup_finished",
+			"severity":            "notice",
+			"backup_notification": "1",
+			"result":              "success",
+			"artifact_id":         "/artifact_id/1",
+			"artifact_name":       "daily_2021-06-01",
+			"service_name":        "mysql1",
+			"backup_schedule_id":  "/scheduled_task_id/1",
+		}
+		assert.Equal(t, expected, alert.Labels)
+		assert.Equal(t, "Backup of daily_2021-06-01 succeeded", alert.Annotations["summary"])
+		assert.Equal(t, "Backup of daily_2021-06-01 finished in 1m30s.", alert.Annotations["description"])
+		assert.Equal(t, "1m30s", alert.Annotations["duration"])
+		assert.Equal(t, strfmt.DateTime(now), alert.StartsAt)
+		assert.Equal(t, strfmt.DateTime(now.Add(notificationTTL)), alert.EndsAt)
+	})
+
+	t.Run("RestoreError", func(t *testing.T) {
+		alert := notificationAlert(&jobNotification{
+			restore:      true,
+			artifactID:   "/artifact_id/2",
+			artifactName: "manual",
+			duration:     time.Minute,
+			err:          "xtrabackup failed",
+		}, now)
+
+		expected := ammodels.LabelSet{
+			"alertname":           "pmm_restore_finished",
+			"severity":            "error",
+			"backup_notification": "1",
+			"result":              "error",
+			"artifact_id":         "/artifact_id/2",
+			"artifact_name":       "manual",
+		}
+		assert.Equal(t, expected, alert.Labels)
+		assert.Equal(t, "Restore of manual failed", alert.Annotations["summary"])
+		assert.Equal(t, "Restore of manual failed after 1m0s: xtrabackup failed", alert.Annotations["description"])
+	})
+}
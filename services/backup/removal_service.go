@@ -153,14 +153,23 @@ func (s *RemovalService) canDeleteArtifact(q *reform.Querier, artifactID string)
 		return nil, err
 	}
 
+	if artifact.Hold {
+		return nil, status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is on legal hold.", artifactID)
+	}
+	if artifact.Golden {
+		return nil, status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is golden.", artifactID)
+	}
+
 	switch artifact.Status {
 	case models.SuccessBackupStatus,
 		models.ErrorBackupStatus,
+		models.CanceledBackupStatus,
 		models.FailedToDeleteBackupStatus:
 	case models.DeletingBackupStatus,
 		models.InProgressBackupStatus,
 		models.PausedBackupStatus,
-		models.PendingBackupStatus:
+		models.PendingBackupStatus,
+		models.QueuedBackupStatus:
 		return nil, status.Errorf(codes.FailedPrecondition, "Artifact with ID %q isn't in the final state.", artifactID)
 	default:
 		return nil, status.Errorf(codes.Internal, "Unhandled status %q", artifact.Status)
@@ -20,15 +20,19 @@ import (
 	"context"
 	"testing"
 
+	"github.com/AlekSi/pointer"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 	"gopkg.in/reform.v1/dialects/postgresql"
 
 	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
 )
 
 func TestDeleteArtifact(t *testing.T) {
@@ -87,6 +91,28 @@ func TestDeleteArtifact(t *testing.T) {
 		assert.Equal(t, artifact.Status, models.FailedToDeleteBackupStatus)
 	})
 
+	t.Run("on legal hold", func(t *testing.T) {
+		_, err := models.UpdateArtifact(db.Querier, artifact.ID, models.UpdateArtifactParams{Hold: pointer.ToBool(true)})
+		require.NoError(t, err)
+
+		err = removalService.DeleteArtifact(ctx, artifact.ID, true)
+		tests.AssertGRPCError(t, status.Newf(codes.FailedPrecondition, "Artifact with ID %q is on legal hold.", artifact.ID), err)
+
+		_, err = models.UpdateArtifact(db.Querier, artifact.ID, models.UpdateArtifactParams{Hold: pointer.ToBool(false)})
+		require.NoError(t, err)
+	})
+
+	t.Run("golden", func(t *testing.T) {
+		_, err := models.UpdateArtifact(db.Querier, artifact.ID, models.UpdateArtifactParams{Golden: pointer.ToBool(true)})
+		require.NoError(t, err)
+
+		err = removalService.DeleteArtifact(ctx, artifact.ID, true)
+		tests.AssertGRPCError(t, status.Newf(codes.FailedPrecondition, "Artifact with ID %q is golden.", artifact.ID), err)
+
+		_, err = models.UpdateArtifact(db.Querier, artifact.ID, models.UpdateArtifactParams{Golden: pointer.ToBool(false)})
+		require.NoError(t, err)
+	})
+
 	t.Run("successful delete", func(t *testing.T) {
 		mockedS3.On("RemoveRecursive", mock.Anything, endpoint, accessKey, secretKey, bucketName,
 			artifact.Name+"/",
@@ -44,6 +44,7 @@ func NewRetentionService(db *reform.DB, removalSVC removalService) *RetentionSer
 
 // EnforceRetention enforce retention on provided scheduled backup task
 // it removes any old successful artifacts below retention threshold.
+// Artifacts on legal hold and golden artifacts are kept.
 func (s *RetentionService) EnforceRetention(ctx context.Context, scheduleID string) error {
 	artifacts, retention, err := s.findArtifacts(s.db.Querier, scheduleID)
 	if err != nil {
@@ -55,6 +56,14 @@ func (s *RetentionService) EnforceRetention(ctx context.Context, scheduleID stri
 	}
 
 	for _, artifact := range artifacts[retention:] {
+		if artifact.Hold {
+			s.l.Infof("Keeping artifact %q (%s) on legal hold.", artifact.Name, artifact.ID)
+			continue
+		}
+		if artifact.Golden {
+			s.l.Infof("Keeping golden artifact %q (%s).", artifact.Name, artifact.ID)
+			continue
+		}
 		if err := s.removalSVC.DeleteArtifact(ctx, artifact.ID, true); err != nil {
 			return err
 		}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/AlekSi/pointer"
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -136,4 +137,26 @@ func TestEnsureRetention(t *testing.T) {
 	assert.NoError(t, retentionService.EnforceRetention(ctx, task.ID))
 	assert.Equal(t, 2, countArtifacts())
 
+	// the oldest artifact on legal hold is kept
+	artifacts, err := models.FindArtifacts(db.Querier, models.ArtifactFilters{ScheduleID: task.ID})
+	require.NoError(t, err)
+	_, err = models.UpdateArtifact(db.Querier, artifacts[1].ID, models.UpdateArtifactParams{Hold: pointer.ToBool(true)})
+	require.NoError(t, err)
+	changeRetention(1)
+	assert.NoError(t, retentionService.EnforceRetention(ctx, task.ID))
+	assert.Equal(t, 2, countArtifacts())
+
+	// golden artifact is kept too
+	createArtifact()
+	artifacts, err = models.FindArtifacts(db.Querier, models.ArtifactFilters{ScheduleID: task.ID})
+	require.NoError(t, err)
+	_, err = models.UpdateArtifact(db.Querier, artifacts[1].ID, models.UpdateArtifactParams{Golden: pointer.ToBool(true)})
+	require.NoError(t, err)
+	assert.NoError(t, retentionService.EnforceRetention(ctx, task.ID))
+	assert.Equal(t, 3, countArtifacts())
+
+	golden, err := models.FindArtifacts(db.Querier, models.ArtifactFilters{Golden: true})
+	require.NoError(t, err)
+	require.Len(t, golden, 1)
+	assert.Equal(t, artifacts[1].ID, golden[0].ID)
 }
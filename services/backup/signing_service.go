@@ -0,0 +1,250 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// Names of manifest and its detached signature objects stored next to artifact files.
+const (
+	manifestObjectName  = ".pmm-manifest"
+	signatureObjectName = ".pmm-manifest.asc"
+)
+
+// SigningService signs manifests of backup artifacts with server-held GPG key
+// and verifies them before restore.
+type SigningService struct {
+	db *reform.DB
+	s3 s3
+	l  *logrus.Entry
+}
+
+// NewSigningService creates new backup artifacts signing service.
+func NewSigningService(db *reform.DB, s3 s3) *SigningService {
+	return &SigningService{
+		db: db,
+		s3: s3,
+		l:  logrus.WithField("component", "services/backup/signing"),
+	}
+}
+
+// SetSigningKey sets armored GPG private key used to sign new artifacts; empty key disables signing.
+// Artifacts signed with the previous key can be restored only with signature check skipped.
+func (s *SigningService) SetSigningKey(ctx context.Context, armoredKey string) error {
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		_, err := models.UpdateSettings(tx.Querier, &models.ChangeSettingsParams{
+			BackupSigningKey:       armoredKey,
+			RemoveBackupSigningKey: armoredKey == "",
+		})
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	})
+}
+
+// PublicKey returns armored public part of signing key, so signatures can be verified outside of PMM.
+func (s *SigningService) PublicKey(ctx context.Context) (string, error) {
+	key, err := s.signingKey()
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", status.Error(codes.FailedPrecondition, "Backup signing key is not set.")
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err = key.Serialize(w); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err = w.Close(); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return buf.String(), nil
+}
+
+// SignArtifact uploads signed manifest of artifact files next to them.
+// It does nothing if signing key is not set or artifact is not stored in S3.
+func (s *SigningService) SignArtifact(ctx context.Context, artifactID string) error {
+	key, err := s.signingKey()
+	if err != nil || key == nil {
+		return err
+	}
+
+	artifact, c, err := s.findArtifact(artifactID)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		s.l.Debugf("Artifact %q is not stored in S3, skipping signing.", artifact.Name)
+		return nil
+	}
+
+	manifest, err := s.manifest(ctx, c, artifact.Name)
+	if err != nil {
+		return err
+	}
+
+	var sig bytes.Buffer
+	if err = openpgp.ArmoredDetachSign(&sig, key, bytes.NewReader(manifest), nil); err != nil {
+		return errors.WithStack(err)
+	}
+
+	prefix := artifact.Name + "/"
+	if err = s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix+manifestObjectName, bytes.NewReader(manifest)); err != nil {
+		return err
+	}
+	if err = s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix+signatureObjectName, &sig); err != nil {
+		return err
+	}
+
+	var metadata models.ArtifactMetadata
+	if artifact.Metadata != nil {
+		metadata = *artifact.Metadata
+	}
+	metadata.SignatureKeyID = key.PrimaryKey.KeyIdString()
+	_, err = models.UpdateArtifact(s.db.Querier, artifactID, models.UpdateArtifactParams{Metadata: &metadata})
+	return err
+}
+
+// VerifyArtifact checks that artifact files match manifest signed with current signing key.
+// It does nothing if signing key is not set or artifact is not stored in S3.
+func (s *SigningService) VerifyArtifact(ctx context.Context, artifactID string) error {
+	key, err := s.signingKey()
+	if err != nil || key == nil {
+		return err
+	}
+
+	artifact, c, err := s.findArtifact(artifactID)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		s.l.Debugf("Artifact %q is not stored in S3, skipping signature check.", artifact.Name)
+		return nil
+	}
+
+	prefix := artifact.Name + "/"
+	signed, err := s.getObject(ctx, c, prefix+manifestObjectName)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Artifact %q is not signed: %s.", artifact.Name, err)
+	}
+	sig, err := s.getObject(ctx, c, prefix+signatureObjectName)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Artifact %q is not signed: %s.", artifact.Name, err)
+	}
+
+	keyring := openpgp.EntityList{key}
+	if _, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(sig)); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "Invalid signature of artifact %q: %s.", artifact.Name, err)
+	}
+
+	manifest, err := s.manifest(ctx, c, artifact.Name)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(manifest, signed) {
+		return status.Errorf(codes.FailedPrecondition, "Files of artifact %q don't match signed manifest.", artifact.Name)
+	}
+
+	return nil
+}
+
+// signingKey returns signing key from settings or nil if it is not set.
+func (s *SigningService) signingKey() (*openpgp.Entity, error) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return nil, err
+	}
+	if settings.BackupManagement.SigningKey == "" {
+		return nil, nil
+	}
+	return models.ParseBackupSigningKey(settings.BackupManagement.SigningKey)
+}
+
+// findArtifact returns artifact and S3 config of its location; config is nil for other location types.
+func (s *SigningService) findArtifact(artifactID string) (*models.Artifact, *models.S3LocationConfig, error) {
+	artifact, err := models.FindArtifactByID(s.db.Querier, artifactID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	location, err := models.FindBackupLocationByID(s.db.Querier, artifact.LocationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return artifact, location.S3Config, nil
+}
+
+// manifest returns manifest of artifact files: ETag and name of each object sorted by name.
+func (s *SigningService) manifest(ctx context.Context, c *models.S3LocationConfig, name string) ([]byte, error) {
+	// append a slash to avoid listing files of artifacts with the same name prefix, see RemovalService.DeleteArtifact
+	prefix := name + "/"
+	etags, err := s.s3.ListObjectETags(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]string, 0, len(etags))
+	for object := range etags {
+		if object == prefix+manifestObjectName || object == prefix+signatureObjectName {
+			continue
+		}
+		objects = append(objects, object)
+	}
+	if len(objects) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Artifact %q has no files.", name)
+	}
+	sort.Strings(objects)
+
+	var buf bytes.Buffer
+	for _, object := range objects {
+		fmt.Fprintf(&buf, "%s  %s\n", etags[object], strings.TrimPrefix(object, prefix))
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *SigningService) getObject(ctx context.Context, c *models.S3LocationConfig, name string) ([]byte, error) {
+	r, err := s.s3.GetObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() //nolint:errcheck
+
+	return ioutil.ReadAll(r)
+}
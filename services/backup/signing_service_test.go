@@ -0,0 +1,164 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func generateSigningKey(t *testing.T) string {
+	t.Helper()
+
+	e, err := openpgp.NewEntity("PMM Server", "", "pmm@example.com", &packet.Config{RSABits: 1024})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, e.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func TestSigningService(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+	mockedS3 := &mockS3{}
+	signingService := NewSigningService(db, mockedS3)
+
+	agent := setup(t, db.Querier, "test-service")
+	endpoint := "https://s3.us-west-2.amazonaws.com/"
+	accessKey, secretKey, bucketName := "access_key", "secret_key", "example_bucket"
+
+	location, err := models.CreateBackupLocation(db.Querier, models.CreateBackupLocationParams{
+		Name: "Test location",
+		BackupLocationConfig: models.BackupLocationConfig{
+			S3Config: &models.S3LocationConfig{
+				Endpoint:     endpoint,
+				AccessKey:    accessKey,
+				SecretKey:    secretKey,
+				BucketName:   bucketName,
+				BucketRegion: "us-east-2",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	artifact, err := models.CreateArtifact(db.Querier, models.CreateArtifactParams{
+		Name:       "artifact_name",
+		Vendor:     "MySQL",
+		LocationID: location.ID,
+		ServiceID:  *agent.ServiceID,
+		DataModel:  models.PhysicalDataModel,
+		Status:     models.SuccessBackupStatus,
+	})
+	require.NoError(t, err)
+
+	// in-memory bucket
+	objects := map[string][]byte{
+		"artifact_name/xtrabackup.stream": []byte("backup"),
+		"artifact_name/xtrabackup.info":   []byte("info"),
+	}
+	etags := func() map[string]string {
+		res := make(map[string]string, len(objects))
+		for name, data := range objects {
+			res[name] = string(data)
+		}
+		return res
+	}
+	mockedS3.On("ListObjectETags", mock.Anything, endpoint, accessKey, secretKey, bucketName, "artifact_name/").
+		Return(func(context.Context, string, string, string, string, string) map[string]string { return etags() }, nil)
+	mockedS3.On("PutObject", mock.Anything, endpoint, accessKey, secretKey, bucketName, mock.Anything, mock.Anything).
+		Return(nil).Run(func(args mock.Arguments) {
+		b, err := ioutil.ReadAll(args.Get(6).(io.Reader))
+		require.NoError(t, err)
+		objects[args.String(5)] = b
+	})
+	mockedS3.On("GetObject", mock.Anything, endpoint, accessKey, secretKey, bucketName, mock.Anything).
+		Return(func(_ context.Context, _, _, _, _, name string) io.ReadCloser {
+			return ioutil.NopCloser(bytes.NewReader(objects[name]))
+		}, func(_ context.Context, _, _, _, _, name string) error {
+			if _, ok := objects[name]; !ok {
+				return errors.New("The specified key does not exist")
+			}
+			return nil
+		})
+
+	t.Run("NoKey", func(t *testing.T) {
+		require.NoError(t, signingService.SignArtifact(ctx, artifact.ID))
+		require.NoError(t, signingService.VerifyArtifact(ctx, artifact.ID))
+		assert.Len(t, objects, 2)
+	})
+
+	require.NoError(t, signingService.SetSigningKey(ctx, generateSigningKey(t)))
+
+	t.Run("Unsigned", func(t *testing.T) {
+		err := signingService.VerifyArtifact(ctx, artifact.ID)
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, `Artifact "artifact_name" is not signed: The specified key does not exist.`), err)
+	})
+
+	t.Run("Signed", func(t *testing.T) {
+		require.NoError(t, signingService.SignArtifact(ctx, artifact.ID))
+		assert.Equal(t, "info  xtrabackup.info\nbackup  xtrabackup.stream\n", string(objects["artifact_name/.pmm-manifest"]))
+		require.NoError(t, signingService.VerifyArtifact(ctx, artifact.ID))
+
+		artifact, err := models.FindArtifactByID(db.Querier, artifact.ID)
+		require.NoError(t, err)
+		require.NotNil(t, artifact.Metadata)
+		assert.Len(t, artifact.Metadata.SignatureKeyID, 16)
+
+		publicKey, err := signingService.PublicKey(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, publicKey, "BEGIN PGP PUBLIC KEY BLOCK")
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		objects["artifact_name/xtrabackup.stream"] = []byte("tampered")
+
+		err := signingService.VerifyArtifact(ctx, artifact.ID)
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, `Files of artifact "artifact_name" don't match signed manifest.`), err)
+	})
+
+	t.Run("OtherKey", func(t *testing.T) {
+		require.NoError(t, signingService.SetSigningKey(ctx, generateSigningKey(t)))
+
+		err := signingService.VerifyArtifact(ctx, artifact.ID)
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, `Invalid signature of artifact "artifact_name": openpgp: signature made by unknown entity.`), err)
+	})
+}
@@ -0,0 +1,72 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+
+	"github.com/AlekSi/pointer"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// UsageService records storage usage of backup artifacts.
+type UsageService struct {
+	db *reform.DB
+	s3 s3
+	l  *logrus.Entry
+}
+
+// NewUsageService creates new backup storage usage service.
+func NewUsageService(db *reform.DB, s3 s3) *UsageService {
+	return &UsageService{
+		db: db,
+		s3: s3,
+		l:  logrus.WithField("component", "services/backup/usage"),
+	}
+}
+
+// RecordArtifactSize calculates the size of artifact files and stores it in the artifact.
+// Sizes of artifacts in local filesystem locations can't be calculated and stay unknown.
+func (s *UsageService) RecordArtifactSize(ctx context.Context, artifactID string) error {
+	artifact, err := models.FindArtifactByID(s.db.Querier, artifactID)
+	if err != nil {
+		return err
+	}
+
+	location, err := models.FindBackupLocationByID(s.db.Querier, artifact.LocationID)
+	if err != nil {
+		return err
+	}
+
+	c := location.S3Config
+	if c == nil {
+		s.l.Debugf("Can't calculate size of artifact %q in location %q.", artifact.Name, location.Name)
+		return nil
+	}
+
+	// append a slash to avoid counting files of artifacts with the same name prefix, see RemovalService.DeleteArtifact
+	size, err := s.s3.GetPrefixSize(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, artifact.Name+"/")
+	if err != nil {
+		return err
+	}
+
+	_, err = models.UpdateArtifact(s.db.Querier, artifactID, models.UpdateArtifactParams{Size: pointer.ToInt64(size)})
+	return err
+}
@@ -0,0 +1,325 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	defaultWindowDuration   = time.Hour
+	defaultWindowCandidates = 3
+	maxWindowCandidates     = 24
+)
+
+// BackupWindowParams represents backup window suggestion parameters.
+type BackupWindowParams struct {
+	ServiceID string
+	// Desired window duration, rounded up to whole hours; one hour by default.
+	Duration time.Duration
+	// History period used for analysis, 7 days by default.
+	Period time.Duration
+	// Suggest windows on a specific day of week instead of daily ones.
+	Weekly bool
+	// Number of returned candidates, 3 by default.
+	Count int
+}
+
+// Validate validates backup window parameters and fills defaults.
+func (p *BackupWindowParams) Validate() error {
+	if p.ServiceID == "" {
+		return status.Error(codes.InvalidArgument, "Service ID is required.")
+	}
+
+	if p.Duration == 0 {
+		p.Duration = defaultWindowDuration
+	}
+	if p.Duration < time.Minute || p.Duration > 24*time.Hour {
+		return status.Error(codes.InvalidArgument, "Window duration should be between one minute and one day.")
+	}
+
+	if p.Period == 0 {
+		p.Period = defaultPeriod
+	}
+	if p.Period < day {
+		return status.Error(codes.InvalidArgument, "Analysis period should be at least one day.")
+	}
+	if p.Weekly && p.Period < 7*day {
+		return status.Error(codes.InvalidArgument, "Analysis period for weekly windows should be at least one week.")
+	}
+
+	if p.Count == 0 {
+		p.Count = defaultWindowCandidates
+	}
+	if p.Count < 0 || p.Count > maxWindowCandidates {
+		return status.Errorf(codes.InvalidArgument, "Number of candidates should be between 1 and %d.", maxWindowCandidates)
+	}
+
+	return nil
+}
+
+// BackupWindow represents suggested low-traffic backup window.
+type BackupWindow struct {
+	// Cron expression (UTC) of window start, suitable for scheduled backups.
+	CronExpression string `json:"cron_expression"`
+	// Window start hour (UTC).
+	StartHour int `json:"start_hour"`
+	// Window day of week (0 is Sunday) for weekly windows.
+	Weekday  *int          `json:"weekday,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// Relative load during the window: 0 is the lowest, 1 is the highest observed hourly load.
+	Load float64 `json:"load"`
+	// Average queries per second during the window.
+	QPS float64 `json:"qps"`
+	// Average disk IO utilization of the Node during the window.
+	IOUtilization float64 `json:"io_utilization"`
+}
+
+// SuggestBackupWindow analyzes historical QPS and disk IO of a Service
+// and returns low-traffic windows of the desired duration, the best first.
+func (s *Service) SuggestBackupWindow(ctx context.Context, params *BackupWindowParams) ([]*BackupWindow, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	var service *models.Service
+	var node *models.Node
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		var err error
+		if service, err = models.FindServiceByID(tx.Querier, params.ServiceID); err != nil {
+			return err
+		}
+		node, err = models.FindNodeByID(tx.Querier, service.NodeID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qpsQuery, err := qpsQueryForService(service)
+	if err != nil {
+		return nil, err
+	}
+	ioQuery := fmt.Sprintf(`sum(rate(node_disk_io_time_seconds_total{node_name=%q}[5m]))`, node.NodeName)
+
+	end := time.Now()
+	r := v1.Range{
+		Start: end.Add(-params.Period),
+		End:   end,
+		Step:  params.Period / maxPoints,
+	}
+	qps, err := s.queryRange(ctx, qpsQuery, r)
+	if err != nil {
+		return nil, err
+	}
+	io, err := s.queryRange(ctx, ioQuery, r)
+	if err != nil {
+		// QPS alone is still useful
+		s.l.Warnf("Failed to query disk IO of %q: %s.", node.NodeName, err)
+	}
+
+	res := suggestWindows(qps, io, params)
+	if len(res) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Not enough metrics data for Service %q.", service.ServiceName)
+	}
+	return res, nil
+}
+
+// qpsQueryForService returns PromQL expression of Service's queries per second.
+func qpsQueryForService(service *models.Service) (string, error) {
+	name := service.ServiceName
+	switch service.ServiceType {
+	case models.MySQLServiceType:
+		return fmt.Sprintf(`sum(rate(mysql_global_status_queries{service_name=%q}[5m]))`, name), nil
+	case models.PostgreSQLServiceType:
+		return fmt.Sprintf(`sum(rate(pg_stat_database_xact_commit{service_name=%[1]q}[5m]) + rate(pg_stat_database_xact_rollback{service_name=%[1]q}[5m]))`, name), nil
+	case models.MongoDBServiceType:
+		return fmt.Sprintf(`sum(rate(mongodb_op_counters_total{service_name=%q}[5m]))`, name), nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "Unsupported service type %s.", service.ServiceType)
+	}
+}
+
+// hourlyProfile returns average values per hour of day (or per hour of week if weekly is true).
+// Hours without data are NaN.
+func hourlyProfile(values []model.SamplePair, weekly bool) []float64 {
+	slots := 24
+	if weekly {
+		slots = 7 * 24
+	}
+
+	sums := make([]float64, slots)
+	counts := make([]int, slots)
+	for _, v := range values {
+		f := float64(v.Value)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			continue
+		}
+		t := v.Timestamp.Time().UTC()
+		slot := t.Hour()
+		if weekly {
+			slot += int(t.Weekday()) * 24
+		}
+		sums[slot] += f
+		counts[slot]++
+	}
+
+	res := make([]float64, slots)
+	for i := range res {
+		if counts[i] == 0 {
+			res[i] = math.NaN()
+			continue
+		}
+		res[i] = sums[i] / float64(counts[i])
+	}
+	return res
+}
+
+// normalize returns profile values divided by the maximal one.
+func normalize(profile []float64) []float64 {
+	var max float64
+	for _, v := range profile {
+		if v > max {
+			max = v
+		}
+	}
+
+	res := make([]float64, len(profile))
+	for i, v := range profile {
+		switch {
+		case math.IsNaN(v):
+			res[i] = v
+		case max == 0:
+			res[i] = 0
+		default:
+			res[i] = v / max
+		}
+	}
+	return res
+}
+
+// suggestWindows returns non-overlapping windows with the lowest combined relative load, the best first.
+func suggestWindows(qps, io []model.SamplePair, params *BackupWindowParams) []*BackupWindow {
+	qpsProfile := hourlyProfile(qps, params.Weekly)
+	ioProfile := hourlyProfile(io, params.Weekly)
+	qpsLoad, ioLoad := normalize(qpsProfile), normalize(ioProfile)
+
+	// combined load has equal weights of QPS and IO; IO is optional
+	slots := len(qpsProfile)
+	load := make([]float64, slots)
+	for i := range load {
+		switch {
+		case math.IsNaN(qpsLoad[i]):
+			load[i] = math.NaN()
+		case math.IsNaN(ioLoad[i]):
+			load[i] = qpsLoad[i]
+		default:
+			load[i] = (qpsLoad[i] + ioLoad[i]) / 2
+		}
+	}
+
+	hours := int(math.Ceil(params.Duration.Hours()))
+	type candidate struct {
+		start         int
+		load, qps, io float64
+	}
+
+	candidates := make([]candidate, 0, slots)
+	for start := 0; start < slots; start++ {
+		c := candidate{start: start}
+		var ioSamples int
+		complete := true
+		for h := 0; h < hours; h++ {
+			i := (start + h) % slots
+			if math.IsNaN(load[i]) {
+				complete = false
+				break
+			}
+			c.load += load[i]
+			c.qps += qpsProfile[i]
+			if !math.IsNaN(ioProfile[i]) {
+				c.io += ioProfile[i]
+				ioSamples++
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		c.load /= float64(hours)
+		c.qps /= float64(hours)
+		if ioSamples != 0 {
+			c.io /= float64(ioSamples)
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].load < candidates[j].load })
+
+	// skip candidates overlapping with already selected ones
+	used := make([]bool, slots)
+	var res []*BackupWindow
+	for _, c := range candidates {
+		if len(res) == params.Count {
+			break
+		}
+
+		overlaps := false
+		for h := 0; h < hours; h++ {
+			if used[(c.start+h)%slots] {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		for h := 0; h < hours; h++ {
+			used[(c.start+h)%slots] = true
+		}
+
+		w := &BackupWindow{
+			StartHour:     c.start % 24,
+			Duration:      params.Duration,
+			Load:          c.load,
+			QPS:           c.qps,
+			IOUtilization: c.io,
+		}
+		if params.Weekly {
+			weekday := c.start / 24
+			w.Weekday = &weekday
+			w.CronExpression = fmt.Sprintf("0 %d * * %d", w.StartHour, weekday)
+		} else {
+			w.CronExpression = fmt.Sprintf("0 %d * * *", w.StartHour)
+		}
+		res = append(res, w)
+	}
+
+	return res
+}
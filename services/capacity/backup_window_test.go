@@ -0,0 +1,140 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dailyLoad returns hourly load for a single day: high during business hours, low at night, the lowest at 3:00.
+func dailyLoad() []float64 {
+	res := make([]float64, 24)
+	for h := range res {
+		switch {
+		case h == 3:
+			res[h] = 1
+		case h < 7:
+			res[h] = 10
+		case h < 20:
+			res[h] = 100
+		default:
+			res[h] = 50
+		}
+	}
+	return res
+}
+
+func TestSuggestWindows(t *testing.T) {
+	start := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC) // Sunday
+
+	var week []float64
+	for d := 0; d < 7; d++ {
+		week = append(week, dailyLoad()...)
+	}
+	qps := makeSeries(start, week...)
+
+	t.Run("Daily", func(t *testing.T) {
+		params := &BackupWindowParams{ServiceID: "id"}
+		require.NoError(t, params.Validate())
+
+		res := suggestWindows(qps, nil, params)
+		require.Len(t, res, 3)
+		assert.Equal(t, "0 3 * * *", res[0].CronExpression)
+		assert.Equal(t, 3, res[0].StartHour)
+		assert.Nil(t, res[0].Weekday)
+		assert.InDelta(t, 0.01, res[0].Load, 1e-6)
+		assert.InDelta(t, 1, res[0].QPS, 1e-6)
+		for _, w := range res[1:] {
+			assert.InDelta(t, 0.1, w.Load, 1e-6)
+			assert.Less(t, w.StartHour, 7)
+		}
+	})
+
+	t.Run("LongWindow", func(t *testing.T) {
+		params := &BackupWindowParams{ServiceID: "id", Duration: 150 * time.Minute, Count: 2}
+		require.NoError(t, params.Validate())
+
+		// 3 hours windows; the best one includes 3:00, the second one does not overlap
+		res := suggestWindows(qps, nil, params)
+		require.Len(t, res, 2)
+		assert.Contains(t, []int{1, 2, 3}, res[0].StartHour)
+		assert.Equal(t, 150*time.Minute, res[0].Duration)
+		assert.True(t, res[1].StartHour+3 <= res[0].StartHour || res[0].StartHour+3 <= res[1].StartHour)
+	})
+
+	t.Run("WithIO", func(t *testing.T) {
+		params := &BackupWindowParams{ServiceID: "id", Count: 1}
+		require.NoError(t, params.Validate())
+
+		// IO is high at 3:00 (for example, due to other jobs), so 3:00 is not the best anymore
+		ioValues := make([]float64, len(week))
+		for i := range ioValues {
+			ioValues[i] = 0.1
+			if i%24 == 3 {
+				ioValues[i] = 1
+			}
+		}
+		res := suggestWindows(qps, makeSeries(start, ioValues...), params)
+		require.Len(t, res, 1)
+		assert.NotEqual(t, 3, res[0].StartHour)
+		assert.Less(t, res[0].StartHour, 7)
+		assert.InDelta(t, 0.1, res[0].IOUtilization, 1e-6)
+	})
+
+	t.Run("Weekly", func(t *testing.T) {
+		params := &BackupWindowParams{ServiceID: "id", Weekly: true, Count: 1}
+		require.NoError(t, params.Validate())
+
+		// Wednesday is quieter
+		values := append([]float64(nil), week...)
+		for h := 0; h < 24; h++ {
+			values[3*24+h] /= 10
+		}
+		res := suggestWindows(makeSeries(start, values...), nil, params)
+		require.Len(t, res, 1)
+		assert.Equal(t, "0 3 * * 3", res[0].CronExpression)
+		require.NotNil(t, res[0].Weekday)
+		assert.Equal(t, 3, *res[0].Weekday)
+	})
+
+	t.Run("NoData", func(t *testing.T) {
+		params := &BackupWindowParams{ServiceID: "id"}
+		require.NoError(t, params.Validate())
+		assert.Empty(t, suggestWindows(nil, nil, params))
+	})
+}
+
+func TestBackupWindowParams(t *testing.T) {
+	for _, tc := range []struct {
+		params *BackupWindowParams
+		err    string
+	}{
+		{&BackupWindowParams{}, "Service ID is required."},
+		{&BackupWindowParams{ServiceID: "id", Duration: 25 * time.Hour}, "Window duration should be between one minute and one day."},
+		{&BackupWindowParams{ServiceID: "id", Period: time.Hour}, "Analysis period should be at least one day."},
+		{&BackupWindowParams{ServiceID: "id", Period: 2 * day, Weekly: true}, "Analysis period for weekly windows should be at least one week."},
+		{&BackupWindowParams{ServiceID: "id", Count: 100}, "Number of candidates should be between 1 and 24."},
+	} {
+		err := tc.params.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+}
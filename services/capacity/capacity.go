@@ -0,0 +1,364 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package capacity forecasts exhaustion of Services' resources.
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/percona/pmm/api/alertmanager/ammodels"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	defaultPeriod             = 7 * day
+	defaultAlertThresholdDays = 14
+	queryTimeout              = 30 * time.Second
+	// maximal number of points per series in a range query
+	maxPoints = 500
+	// advisory alerts are resent on each forecast, so they expire if the situation improves
+	alertTTL = 24 * time.Hour
+
+	fsFilter = `fstype!~"tmpfs|ramfs|overlay|squashfs|rootfs|devtmpfs"`
+)
+
+// Method represents forecast method.
+type Method string
+
+// Supported forecast methods.
+const (
+	LinearMethod      Method = "linear"
+	HoltWintersMethod Method = "holt_winters"
+)
+
+// Resource represents forecasted resource.
+type Resource string
+
+// Forecasted resources.
+const (
+	DiskResource        Resource = "disk"
+	ConnectionsResource Resource = "connections"
+	TablesResource      Resource = "tables"
+)
+
+// ForecastParams represents capacity forecast parameters.
+type ForecastParams struct {
+	// Forecast only this Service; all Services if empty.
+	ServiceID string
+	// History period used for forecasting, 7 days by default.
+	Period time.Duration
+	Method Method
+	// Send advisory alerts for resources exhausted sooner than AlertThresholdDays.
+	CreateAlerts       bool
+	AlertThresholdDays float64
+}
+
+// Validate validates forecast parameters and fills defaults.
+func (p *ForecastParams) Validate() error {
+	switch p.Method {
+	case "":
+		p.Method = LinearMethod
+	case LinearMethod, HoltWintersMethod:
+	default:
+		return status.Errorf(codes.InvalidArgument, "Unsupported forecast method %q.", p.Method)
+	}
+
+	if p.Period == 0 {
+		p.Period = defaultPeriod
+	}
+	if p.Period < time.Hour {
+		return status.Errorf(codes.InvalidArgument, "Forecast period should be at least one hour.")
+	}
+
+	if p.AlertThresholdDays == 0 {
+		p.AlertThresholdDays = defaultAlertThresholdDays
+	}
+	if p.AlertThresholdDays < 0 {
+		return status.Errorf(codes.InvalidArgument, "Alert threshold should be positive.")
+	}
+
+	return nil
+}
+
+// Forecast represents forecast of a single resource of a single Service.
+type Forecast struct {
+	ServiceID   string   `json:"service_id"`
+	ServiceName string   `json:"service_name"`
+	NodeName    string   `json:"node_name"`
+	Resource    Resource `json:"resource"`
+	Method      Method   `json:"method"`
+	Current     float64  `json:"current"`
+	Limit       float64  `json:"limit"`
+	// Growth of used resource per day, may be negative.
+	GrowthPerDay float64 `json:"growth_per_day"`
+	// Estimated number of days until resource is exhausted; nil if it is not growing.
+	DaysUntilExhaustion *float64 `json:"days_until_exhaustion,omitempty"`
+}
+
+// resourceQuery contains PromQL expressions for resource usage and its limit.
+type resourceQuery struct {
+	resource Resource
+	usage    string
+	limit    string
+}
+
+// Service forecasts resources usage from VictoriaMetrics data.
+type Service struct {
+	db           *reform.DB
+	api          v1.API
+	alertmanager alertmanagerService
+	l            *logrus.Entry
+}
+
+// New creates new capacity forecast service for VictoriaMetrics with given base URL.
+func New(db *reform.DB, victoriaMetricsURL string, alertmanager alertmanagerService) (*Service, error) {
+	client, err := api.NewClient(api.Config{
+		Address: victoriaMetricsURL,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Service{
+		db:           db,
+		api:          v1.NewAPI(client),
+		alertmanager: alertmanager,
+		l:            logrus.WithField("component", "capacity"),
+	}, nil
+}
+
+// GetCapacityForecast returns days-until-exhaustion estimates of disk usage, connections and table growth
+// for given parameters.
+func (s *Service) GetCapacityForecast(ctx context.Context, params *ForecastParams) ([]*Forecast, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	services, nodes, err := s.findServices(params.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	r := v1.Range{
+		Start: end.Add(-params.Period),
+		End:   end,
+		Step:  params.Period / maxPoints,
+	}
+
+	var res []*Forecast
+	for _, service := range services {
+		nodeName := nodes[service.NodeID].NodeName
+		for _, q := range queriesForService(service, nodeName) {
+			f, err := s.forecast(ctx, q, r, params.Method)
+			if err != nil {
+				// keep going: other resources may be fine
+				s.l.Warnf("Failed to forecast %s for %q: %s.", q.resource, service.ServiceName, err)
+				continue
+			}
+			if f == nil {
+				continue
+			}
+
+			f.ServiceID = service.ServiceID
+			f.ServiceName = service.ServiceName
+			f.NodeName = nodeName
+			res = append(res, f)
+		}
+	}
+
+	if params.CreateAlerts {
+		if alerts := makeAlerts(res, params.AlertThresholdDays, end); len(alerts) != 0 {
+			s.alertmanager.SendAlerts(ctx, alerts)
+		}
+	}
+
+	return res, nil
+}
+
+// findServices returns Services to forecast and their Nodes.
+func (s *Service) findServices(serviceID string) ([]*models.Service, map[string]*models.Node, error) {
+	var services []*models.Service
+	nodes := make(map[string]*models.Node)
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		if serviceID != "" {
+			service, err := models.FindServiceByID(tx.Querier, serviceID)
+			if err != nil {
+				return err
+			}
+			services = []*models.Service{service}
+		} else {
+			var err error
+			if services, err = models.FindServices(tx.Querier, models.ServiceFilters{}); err != nil {
+				return err
+			}
+		}
+
+		for _, service := range services {
+			if _, ok := nodes[service.NodeID]; ok {
+				continue
+			}
+			node, err := models.FindNodeByID(tx.Querier, service.NodeID)
+			if err != nil {
+				return err
+			}
+			nodes[service.NodeID] = node
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(services, func(i, j int) bool { return services[i].ServiceName < services[j].ServiceName })
+	return services, nodes, nil
+}
+
+// queriesForService returns resource queries for given Service.
+func queriesForService(service *models.Service, nodeName string) []resourceQuery {
+	fsSize := fmt.Sprintf(`sum(node_filesystem_size_bytes{node_name=%q,%s})`, nodeName, fsFilter)
+	fsAvail := fmt.Sprintf(`sum(node_filesystem_avail_bytes{node_name=%q,%s})`, nodeName, fsFilter)
+	res := []resourceQuery{{
+		resource: DiskResource,
+		usage:    fsSize + " - " + fsAvail,
+		limit:    fsSize,
+	}}
+
+	var connections, maxConnections, tables string
+	switch service.ServiceType {
+	case models.MySQLServiceType:
+		connections = fmt.Sprintf(`max(mysql_global_status_threads_connected{service_name=%q})`, service.ServiceName)
+		maxConnections = fmt.Sprintf(`max(mysql_global_variables_max_connections{service_name=%q})`, service.ServiceName)
+		tables = fmt.Sprintf(`sum(mysql_info_schema_table_size{service_name=%q})`, service.ServiceName)
+	case models.PostgreSQLServiceType:
+		connections = fmt.Sprintf(`sum(pg_stat_database_numbackends{service_name=%q})`, service.ServiceName)
+		maxConnections = fmt.Sprintf(`max(pg_settings_max_connections{service_name=%q})`, service.ServiceName)
+		tables = fmt.Sprintf(`sum(pg_database_size_bytes{service_name=%q})`, service.ServiceName)
+	case models.MongoDBServiceType:
+		connections = fmt.Sprintf(`max(mongodb_connections{service_name=%q,state="current"})`, service.ServiceName)
+		maxConnections = fmt.Sprintf(`%s + max(mongodb_connections{service_name=%q,state="available"})`, connections, service.ServiceName)
+		tables = fmt.Sprintf(`sum(mongodb_dbstats_dataSize{service_name=%q})`, service.ServiceName)
+	default:
+		return res
+	}
+
+	return append(res, resourceQuery{
+		resource: ConnectionsResource,
+		usage:    connections,
+		limit:    maxConnections,
+	}, resourceQuery{
+		// tables can grow until the disk is full
+		resource: TablesResource,
+		usage:    tables,
+		limit:    tables + " + " + fsAvail,
+	})
+}
+
+// forecast returns forecast for a single resource query, or nil if there is no data.
+func (s *Service) forecast(ctx context.Context, q resourceQuery, r v1.Range, method Method) (*Forecast, error) {
+	values, err := s.queryRange(ctx, q.usage, r)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+
+	limit, err := s.queryRange(ctx, q.limit, r)
+	if err != nil || len(limit) == 0 {
+		return nil, err
+	}
+
+	current, growth := growthPerDay(method, values, r.Step)
+	l := float64(limit[len(limit)-1].Value)
+	return &Forecast{
+		Resource:            q.resource,
+		Method:              method,
+		Current:             current,
+		Limit:               l,
+		GrowthPerDay:        growth,
+		DaysUntilExhaustion: daysUntil(current, l, growth),
+	}, nil
+}
+
+// queryRange executes range query returning a single series.
+func (s *Service) queryRange(ctx context.Context, query string, r v1.Range) ([]model.SamplePair, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	value, warnings, err := s.api.QueryRange(ctx, query, r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, w := range warnings {
+		s.l.Warnf("Query %q: %s.", query, w)
+	}
+
+	matrix, ok := value.(model.Matrix)
+	if !ok {
+		return nil, errors.Errorf("unexpected query result type %s", value.Type())
+	}
+	if len(matrix) == 0 {
+		return nil, nil
+	}
+	if len(matrix) > 1 {
+		return nil, errors.Errorf("expected a single series, got %d", len(matrix))
+	}
+	return matrix[0].Values, nil
+}
+
+// makeAlerts returns advisory alerts for resources exhausted sooner than threshold.
+func makeAlerts(forecasts []*Forecast, thresholdDays float64, now time.Time) ammodels.PostableAlerts {
+	var alerts ammodels.PostableAlerts
+	for _, f := range forecasts {
+		if f.DaysUntilExhaustion == nil || *f.DaysUntilExhaustion > thresholdDays {
+			continue
+		}
+
+		alerts = append(alerts, &ammodels.PostableAlert{
+			Alert: ammodels.Alert{
+				Labels: map[string]string{
+					model.AlertNameLabel: "pmm_capacity_forecast",
+					"severity":           "warning",
+					"capacity_forecast":  "1",
+					"resource":           string(f.Resource),
+					"service_id":         f.ServiceID,
+					"service_name":       f.ServiceName,
+					"node_name":          f.NodeName,
+				},
+			},
+			StartsAt: strfmt.DateTime(now.UTC()),
+			EndsAt:   strfmt.DateTime(now.Add(alertTTL).UTC()),
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s of %s may be exhausted in %.1f days", f.Resource, f.ServiceName, *f.DaysUntilExhaustion),
+				"description": fmt.Sprintf("Forecast (%s method) of %s usage of %s: current %.2f, limit %.2f, growth per day %.2f.",
+					f.Method, f.Resource, f.ServiceName, f.Current, f.Limit, f.GrowthPerDay),
+			},
+		})
+	}
+	return alerts
+}
@@ -0,0 +1,116 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package capacity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/pmm/api/alertmanager/ammodels"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+// fakeAPI implements QueryRange method of v1.API.
+type fakeAPI struct {
+	v1.API
+}
+
+// QueryRange returns disk usage growing by 1 GiB per day with 10 GiB limit, and no data for other queries.
+func (f *fakeAPI) QueryRange(ctx context.Context, query string, r v1.Range) (model.Value, v1.Warnings, error) {
+	if !strings.Contains(query, "node_filesystem") || strings.Contains(query, "pg_database_size_bytes") {
+		return model.Matrix{}, nil, nil
+	}
+
+	const gib = 1 << 30
+	stream := &model.SampleStream{}
+	for ts := r.Start; !ts.After(r.End); ts = ts.Add(r.Step) {
+		v := 10.0 * gib
+		if strings.Contains(query, " - ") {
+			v = 5*gib + float64(ts.Sub(r.End))/float64(day)*gib
+		}
+		stream.Values = append(stream.Values, model.SamplePair{
+			Timestamp: model.TimeFromUnixNano(ts.UnixNano()),
+			Value:     model.SampleValue(v),
+		})
+	}
+	return model.Matrix{stream}, nil, nil
+}
+
+func TestGetCapacityForecast(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	service, err := models.FindServiceByName(db.Querier, models.PMMServerPostgreSQLServiceName)
+	require.NoError(t, err)
+
+	ams := new(mockAlertmanagerService)
+	ams.Test(t)
+	t.Cleanup(func() { ams.AssertExpectations(t) })
+
+	s := &Service{
+		db:           db,
+		api:          &fakeAPI{},
+		alertmanager: ams,
+		l:            logrus.WithField("test", t.Name()),
+	}
+
+	ams.On("SendAlerts", mock.Anything, mock.MatchedBy(func(alerts ammodels.PostableAlerts) bool {
+		return len(alerts) == 1 &&
+			alerts[0].Labels["resource"] == string(DiskResource) &&
+			alerts[0].Labels["service_id"] == service.ServiceID
+	})).Return().Once()
+
+	forecasts, err := s.GetCapacityForecast(context.Background(), &ForecastParams{
+		ServiceID:    service.ServiceID,
+		Period:       day,
+		CreateAlerts: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, forecasts, 1)
+
+	f := forecasts[0]
+	assert.Equal(t, service.ServiceName, f.ServiceName)
+	assert.Equal(t, DiskResource, f.Resource)
+	assert.Equal(t, LinearMethod, f.Method)
+	require.NotNil(t, f.DaysUntilExhaustion)
+	assert.InDelta(t, 5, *f.DaysUntilExhaustion, 0.01)
+}
+
+func TestForecastParamsValidate(t *testing.T) {
+	params := &ForecastParams{}
+	require.NoError(t, params.Validate())
+	assert.Equal(t, LinearMethod, params.Method)
+	assert.Equal(t, 7*24*time.Hour, params.Period)
+
+	assert.Error(t, (&ForecastParams{Method: "arima"}).Validate())
+	assert.Error(t, (&ForecastParams{Period: time.Minute}).Validate())
+}
@@ -0,0 +1,100 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package capacity
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// smoothing factor for Holt-Winters (double exponential smoothing) forecast
+	holtWintersSF = 0.3
+	// trend factor for Holt-Winters forecast
+	holtWintersTF = 0.1
+
+	day = 24 * time.Hour
+)
+
+// growthPerDay returns current value (as estimated by the method) and its growth per day.
+// Values are expected to be sorted by timestamp, and step is a distance between them.
+func growthPerDay(method Method, values []model.SamplePair, step time.Duration) (current, growth float64) {
+	switch method {
+	case HoltWintersMethod:
+		level, trend := holtWinters(values, holtWintersSF, holtWintersTF)
+		return level, trend * float64(day) / float64(step)
+	default:
+		slope, intercept := linearRegression(values)
+		last := values[len(values)-1].Timestamp.Sub(values[0].Timestamp)
+		return intercept + slope*last.Seconds(), slope * day.Seconds()
+	}
+}
+
+// linearRegression returns slope (per second) and intercept of least squares fit line.
+// Intercept is a value at the first timestamp to avoid precision loss.
+func linearRegression(values []model.SamplePair) (slope, intercept float64) {
+	n := float64(len(values))
+	var sumX, sumY, sumXY, sumX2 float64
+	for _, v := range values {
+		x := v.Timestamp.Sub(values[0].Timestamp).Seconds()
+		y := float64(v.Value)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumX2 += x * x
+	}
+
+	d := n*sumX2 - sumX*sumX
+	if d == 0 {
+		return 0, sumY / n
+	}
+	slope = (n*sumXY - sumX*sumY) / d
+	intercept = (sumY - slope*sumX) / n
+	return
+}
+
+// holtWinters returns smoothed level and trend (per step) the same way as PromQL holt_winters function does.
+func holtWinters(values []model.SamplePair, sf, tf float64) (level, trend float64) {
+	if len(values) < 2 {
+		return float64(values[0].Value), 0
+	}
+
+	level = float64(values[0].Value)
+	trend = float64(values[1].Value) - float64(values[0].Value)
+	for _, v := range values[1:] {
+		prev := level
+		level = sf*float64(v.Value) + (1-sf)*(level+trend)
+		trend = tf*(level-prev) + (1-tf)*trend
+	}
+	return
+}
+
+// daysUntil returns number of days until value reaches limit with given growth per day,
+// or nil if that will never happen.
+func daysUntil(current, limit, growth float64) *float64 {
+	if limit <= 0 || growth <= 0 || math.IsNaN(growth) || math.IsInf(growth, 0) {
+		return nil
+	}
+
+	days := (limit - current) / growth
+	if days < 0 {
+		days = 0
+	}
+	return &days
+}
@@ -0,0 +1,76 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeSeries returns series with given values and one hour step.
+func makeSeries(start time.Time, values ...float64) []model.SamplePair {
+	res := make([]model.SamplePair, len(values))
+	for i, v := range values {
+		res[i] = model.SamplePair{
+			Timestamp: model.TimeFromUnixNano(start.Add(time.Duration(i) * time.Hour).UnixNano()),
+			Value:     model.SampleValue(v),
+		}
+	}
+	return res
+}
+
+func TestGrowthPerDay(t *testing.T) {
+	start := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Linear", func(t *testing.T) {
+		current, growth := growthPerDay(LinearMethod, makeSeries(start, 10, 11, 12, 13, 14), time.Hour)
+		assert.InDelta(t, 14, current, 1e-6)
+		assert.InDelta(t, 24, growth, 1e-6)
+	})
+
+	t.Run("HoltWinters", func(t *testing.T) {
+		current, growth := growthPerDay(HoltWintersMethod, makeSeries(start, 10, 11, 12, 13, 14), time.Hour)
+		assert.InDelta(t, 14, current, 1e-6)
+		assert.InDelta(t, 24, growth, 1e-6)
+	})
+
+	t.Run("SinglePoint", func(t *testing.T) {
+		for _, method := range []Method{LinearMethod, HoltWintersMethod} {
+			current, growth := growthPerDay(method, makeSeries(start, 42), time.Hour)
+			assert.InDelta(t, 42, current, 1e-6, "%s", method)
+			assert.Zero(t, growth, "%s", method)
+		}
+	})
+}
+
+func TestDaysUntil(t *testing.T) {
+	days := daysUntil(50, 100, 10)
+	require.NotNil(t, days)
+	assert.InDelta(t, 5, *days, 1e-6)
+
+	days = daysUntil(150, 100, 10)
+	require.NotNil(t, days)
+	assert.Zero(t, *days)
+
+	assert.Nil(t, daysUntil(50, 100, 0))
+	assert.Nil(t, daysUntil(50, 100, -1))
+	assert.Nil(t, daysUntil(50, 0, 10))
+}
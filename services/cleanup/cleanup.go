@@ -0,0 +1,178 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package cleanup removes old log and temporary files.
+package cleanup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/reports"
+)
+
+// defaultDirs are directories on PMM Server where files can be removed.
+var defaultDirs = []string{"/srv/logs", "/tmp", reports.Dir}
+
+// Service removes old files matching cleanup task patterns.
+type Service struct {
+	dirs []string
+	l    *logrus.Entry
+}
+
+// New creates new cleanup service.
+func New() *Service {
+	return &Service{
+		dirs: defaultDirs,
+		l:    logrus.WithField("component", "cleanup"),
+	}
+}
+
+// file represents a single file matching cleanup patterns.
+type file struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Cleanup removes files matching given patterns that are older than maximal age,
+// and then oldest files until total size of matched files fits the limit.
+func (s *Service) Cleanup(ctx context.Context, params *models.CleanupTaskData) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	// TODO remove files on other Nodes when pmm-agent supports that
+	if params.NodeID != models.PMMServerNodeID {
+		return status.Errorf(codes.Unimplemented, "Files cleanup is supported only on PMM Server Node, not on %q.", params.NodeID)
+	}
+
+	files, err := s.find(params.Patterns)
+	if err != nil {
+		return err
+	}
+
+	var removed, size int64
+	for _, f := range selectFiles(files, params.MaxAge, params.MaxTotalSize, time.Now()) {
+		if err = ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if e := os.Remove(f.path); e != nil {
+			// keep going: other files may be removed
+			s.l.Warnf("Failed to remove %s: %s.", f.path, e)
+			err = e
+			continue
+		}
+		removed++
+		size += f.size
+	}
+	s.l.Infof("Removed %d files, %d bytes.", removed, size)
+
+	return errors.WithStack(err)
+}
+
+// find returns regular files matching given patterns inside allowed directories.
+func (s *Service) find(patterns []string) ([]file, error) {
+	seen := make(map[string]struct{})
+	var res []file
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, path := range matches {
+			if _, ok := seen[path]; ok {
+				continue
+			}
+			seen[path] = struct{}{}
+
+			if !s.allowed(path) {
+				s.l.Warnf("Skipping %s outside of allowed directories %v.", path, s.dirs)
+				continue
+			}
+
+			fi, err := os.Lstat(path)
+			if err != nil {
+				s.l.Warn(err)
+				continue
+			}
+			if !fi.Mode().IsRegular() {
+				continue
+			}
+
+			res = append(res, file{
+				path:    path,
+				size:    fi.Size(),
+				modTime: fi.ModTime(),
+			})
+		}
+	}
+	return res, nil
+}
+
+// allowed returns true if file with given path is inside one of allowed directories.
+func (s *Service) allowed(path string) bool {
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+
+	for _, d := range s.dirs {
+		if dir == d || strings.HasPrefix(dir, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFiles returns files that should be removed: modified earlier than maxAge before now,
+// and oldest files that do not fit maxTotalSize. Zero thresholds are not applied.
+func selectFiles(files []file, maxAge time.Duration, maxTotalSize int64, now time.Time) []file {
+	sorted := make([]file, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].modTime.After(sorted[j].modTime) })
+
+	var res []file
+	var total int64
+	var full bool
+	for _, f := range sorted {
+		if maxAge > 0 && now.Sub(f.modTime) > maxAge {
+			res = append(res, f)
+			continue
+		}
+
+		total += f.size
+		if maxTotalSize > 0 && total > maxTotalSize {
+			full = true
+		}
+		if full {
+			res = append(res, f)
+		}
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package cleanup
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestSelectFiles(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	files := []file{
+		{path: "old", size: 10, modTime: now.Add(-48 * time.Hour)},
+		{path: "new", size: 10, modTime: now.Add(-time.Minute)},
+		{path: "middle", size: 10, modTime: now.Add(-time.Hour)},
+	}
+
+	paths := func(files []file) []string {
+		res := make([]string, len(files))
+		for i, f := range files {
+			res[i] = f.path
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{"old"}, paths(selectFiles(files, 24*time.Hour, 0, now)))
+	assert.Equal(t, []string{"middle", "old"}, paths(selectFiles(files, 0, 15, now)))
+	assert.Equal(t, []string{"old"}, paths(selectFiles(files, 24*time.Hour, 20, now)))
+	assert.Empty(t, selectFiles(files, 0, 30, now))
+}
+
+func TestCleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmm-managed-cleanup-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	s := &Service{
+		dirs: []string{dir},
+		l:    logrus.WithField("test", t.Name()),
+	}
+
+	create := func(name string, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte("test"), 0o600))
+		modTime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+	oldLog := create("slow.log.1", 48*time.Hour)
+	newLog := create("slow.log.2", time.Minute)
+	other := create("other.txt", 48*time.Hour)
+
+	t.Run("Normal", func(t *testing.T) {
+		err := s.Cleanup(context.Background(), &models.CleanupTaskData{
+			NodeID:   models.PMMServerNodeID,
+			Patterns: []string{filepath.Join(dir, "slow.log.*"), "/etc/*.conf"},
+			MaxAge:   24 * time.Hour,
+		})
+		require.NoError(t, err)
+
+		assert.NoFileExists(t, oldLog)
+		assert.FileExists(t, newLog)
+		assert.FileExists(t, other)
+	})
+
+	t.Run("RemoteNode", func(t *testing.T) {
+		err := s.Cleanup(context.Background(), &models.CleanupTaskData{
+			NodeID:   "/node_id/remote",
+			Patterns: []string{filepath.Join(dir, "*")},
+			MaxAge:   time.Hour,
+		})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.FileExists(t, other)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := s.Cleanup(context.Background(), &models.CleanupTaskData{
+			NodeID:   models.PMMServerNodeID,
+			Patterns: []string{"slow.log.*"},
+			MaxAge:   time.Hour,
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,235 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package dashboards provides links to Grafana dashboards for Services and alerts.
+package dashboards
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// builtinDashboard represents Grafana dashboard shipped with PMM.
+type builtinDashboard struct {
+	title string
+	uid   string
+	// dashboard variable and label used for its value
+	variable string
+	label    string
+}
+
+var (
+	qanDashboard  = builtinDashboard{"Query Analytics", "pmm-qan", "var-service_name", "service_name"}
+	nodeDashboard = builtinDashboard{"Node Summary", "node-instance-summary", "var-node_name", "node_name"}
+)
+
+// serviceTypeDashboards maps Service types to built-in dashboards; Node dashboard is added for all types.
+var serviceTypeDashboards = map[models.ServiceType][]builtinDashboard{
+	models.MySQLServiceType: {
+		{"MySQL Instance Summary", "mysql-instance-summary", "var-service_name", "service_name"},
+		qanDashboard,
+	},
+	models.MongoDBServiceType: {
+		{"MongoDB Instance Summary", "mongodb-instance-summary", "var-service_name", "service_name"},
+		qanDashboard,
+	},
+	models.PostgreSQLServiceType: {
+		{"PostgreSQL Instance Summary", "postgresql-instance-summary", "var-service_name", "service_name"},
+		qanDashboard,
+	},
+	models.ProxySQLServiceType: {
+		{"ProxySQL Instance Summary", "proxysql-instance-summary", "var-service_name", "service_name"},
+	},
+	models.HAProxyServiceType: {
+		{"HAProxy Instance Summary", "haproxy-instance-summary", "var-service_name", "service_name"},
+	},
+}
+
+// Link represents a link to Grafana dashboard.
+type Link struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	// Custom dashboard ID; empty for built-in dashboards.
+	DashboardID string `json:"dashboard_id,omitempty"`
+}
+
+// Service provides links to built-in and custom Grafana dashboards.
+type Service struct {
+	db *reform.DB
+	l  *logrus.Entry
+}
+
+// New creates new dashboards service.
+func New(db *reform.DB) *Service {
+	return &Service{
+		db: db,
+		l:  logrus.WithField("component", "dashboards"),
+	}
+}
+
+// ServiceLinks returns dashboard links for the Service with given ID.
+func (s *Service) ServiceLinks(ctx context.Context, serviceID string) ([]*Link, error) {
+	var res []*Link
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		service, err := models.FindServiceByID(tx.Querier, serviceID)
+		if err != nil {
+			return err
+		}
+
+		dashboards, err := models.FindDashboards(tx.Querier)
+		if err != nil {
+			return err
+		}
+
+		labels, err := serviceLabels(tx.Querier, service)
+		if err != nil {
+			return err
+		}
+
+		res = s.links(labels, dashboards)
+		return nil
+	})
+	return res, err
+}
+
+// ListServicesLinks returns dashboard links for all Services keyed by Service ID.
+func (s *Service) ListServicesLinks(ctx context.Context) (map[string][]*Link, error) {
+	var res map[string][]*Link
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		services, err := models.FindServices(tx.Querier, models.ServiceFilters{})
+		if err != nil {
+			return err
+		}
+
+		dashboards, err := models.FindDashboards(tx.Querier)
+		if err != nil {
+			return err
+		}
+
+		res = make(map[string][]*Link, len(services))
+		for _, service := range services {
+			labels, err := serviceLabels(tx.Querier, service)
+			if err != nil {
+				return err
+			}
+			res[service.ServiceID] = s.links(labels, dashboards)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// AlertLinks returns dashboard links for alert with given labels.
+func (s *Service) AlertLinks(ctx context.Context, labels map[string]string) ([]*Link, error) {
+	var res []*Link
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		dashboards, err := models.FindDashboards(tx.Querier)
+		if err != nil {
+			return err
+		}
+
+		res = s.links(labels, dashboards)
+		return nil
+	})
+	return res, err
+}
+
+// serviceLabels returns Service labels with Node name that are used for dashboard links.
+func serviceLabels(q *reform.Querier, service *models.Service) (map[string]string, error) {
+	labels, err := service.UnifiedLabels()
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := models.FindNodeByID(q, service.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	labels["node_id"] = node.NodeID
+	labels["node_name"] = node.NodeName
+	return labels, nil
+}
+
+// links returns links to built-in dashboards for labels' Service type,
+// and links to custom dashboards with filters matching labels.
+func (s *Service) links(labels map[string]string, dashboards []*models.Dashboard) []*Link {
+	builtin := serviceTypeDashboards[models.ServiceType(labels["service_type"])]
+	builtin = append(builtin[:len(builtin):len(builtin)], nodeDashboard)
+
+	var res []*Link
+	for _, d := range builtin {
+		value := labels[d.label]
+		if value == "" {
+			continue
+		}
+		res = append(res, &Link{
+			Title: d.title,
+			URL:   "/graph/d/" + d.uid + "/?" + url.Values{d.variable: {value}}.Encode(),
+		})
+	}
+
+	for _, d := range dashboards {
+		if !d.Filters.Match(labels) {
+			continue
+		}
+
+		u, err := d.Link(labels)
+		if err != nil {
+			s.l.Warnf("Failed to render URL of dashboard %q: %s.", d.Title, err)
+			continue
+		}
+		res = append(res, &Link{
+			Title:       d.Title,
+			URL:         u,
+			DashboardID: d.ID,
+		})
+	}
+	return res
+}
+
+// ListDashboards returns all custom dashboards.
+func (s *Service) ListDashboards(ctx context.Context) ([]*models.Dashboard, error) {
+	var res []*models.Dashboard
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindDashboards(tx.Querier)
+		return err
+	})
+	return res, err
+}
+
+// CreateDashboard creates custom dashboard.
+func (s *Service) CreateDashboard(ctx context.Context, params *models.CreateDashboardParams) (*models.Dashboard, error) {
+	var res *models.Dashboard
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.CreateDashboard(tx.Querier, params)
+		return err
+	})
+	return res, err
+}
+
+// RemoveDashboard removes custom dashboard.
+func (s *Service) RemoveDashboard(ctx context.Context, id string) error {
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		return models.RemoveDashboard(tx.Querier, id)
+	})
+}
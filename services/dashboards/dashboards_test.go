@@ -0,0 +1,60 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestLinks(t *testing.T) {
+	s := New(nil)
+	dashboards := []*models.Dashboard{{
+		ID:      "/dashboard_id/1",
+		Title:   "Orders",
+		URL:     "/graph/d/orders/?var-service_name={{ .service_name }}",
+		Filters: models.Filters{{Type: models.Equal, Key: "environment", Val: "prod"}},
+	}}
+
+	t.Run("Service", func(t *testing.T) {
+		links := s.links(map[string]string{
+			"service_type": "mysql",
+			"service_name": "mysql1",
+			"node_name":    "node 1",
+			"environment":  "prod",
+		}, dashboards)
+		assert.Equal(t, []*Link{
+			{Title: "MySQL Instance Summary", URL: "/graph/d/mysql-instance-summary/?var-service_name=mysql1"},
+			{Title: "Query Analytics", URL: "/graph/d/pmm-qan/?var-service_name=mysql1"},
+			{Title: "Node Summary", URL: "/graph/d/node-instance-summary/?var-node_name=node+1"},
+			{Title: "Orders", URL: "/graph/d/orders/?var-service_name=mysql1", DashboardID: "/dashboard_id/1"},
+		}, links)
+	})
+
+	t.Run("NodeAlert", func(t *testing.T) {
+		links := s.links(map[string]string{
+			"alertname": "NodeDown",
+			"node_name": "node1",
+		}, dashboards)
+		assert.Equal(t, []*Link{
+			{Title: "Node Summary", URL: "/graph/d/node-instance-summary/?var-node_name=node1"},
+		}, links)
+	})
+}
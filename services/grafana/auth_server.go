@@ -46,16 +46,19 @@ var rules = map[string]role{
 	"/server.Server/AWSInstanceCheck": none, // special case - used before Grafana can be accessed
 	"/server.":                        admin,
 
-	"/v1/inventory/":          admin,
-	"/v1/management/":         admin,
-	"/v1/management/Actions/": viewer,
-	"/v1/management/Jobs":     viewer,
-	"/v1/Updates/Check":       viewer,
-	"/v1/Updates/Status":      none, // special token-based auth
-	"/v1/AWSInstanceCheck":    none, // special case - used before Grafana can be accessed
-	"/v1/Updates/":            admin,
-	"/v1/Settings/":           admin,
-	"/v1/Platform/":           admin,
+	"/v1/inventory/":                  admin,
+	"/v1/inventory/Labels/ListValues": viewer, // used by dashboard variables
+	"/v1/management/":                 admin,
+	"/v1/management/Actions/":         viewer,
+	"/v1/management/Jobs":             viewer,
+	"/v1/Updates/Check":               viewer,
+	"/v1/Updates/Status":              none, // special token-based auth
+	"/v1/AWSInstanceCheck":            none, // special case - used before Grafana can be accessed
+	"/v1/Updates/":                    admin,
+	"/v1/Settings/":                   admin,
+	"/v1/Platform/":                   admin,
+
+	"/v1/management/backup/Artifacts/ReleaseHold": grafanaAdmin,
 
 	// must be available without authentication for health checking
 	"/v1/readyz": none,
@@ -201,8 +201,11 @@ func TestAuthServerAuthenticate(t *testing.T) {
 		"/server.Server/AWSInstanceCheck":                     none,
 
 		"/v1/inventory/Nodes/List":                         admin,
+		"/v1/inventory/Labels/ListValues":                  viewer,
 		"/v1/management/Actions/StartMySQLShowTableStatus": viewer,
 		"/v1/management/Service/Remove":                    admin,
+		"/v1/management/ia/Automations/Webhook":            admin,
+		"/v1/management/ia/Automations/List":               admin,
 		"/v1/Updates/Check":                                viewer,
 		"/v1/Updates/Start":                                admin,
 		"/v1/Updates/Status":                               none,
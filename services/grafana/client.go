@@ -221,6 +221,34 @@ func (c *Client) getRole(ctx context.Context, authHeaders http.Header) (role, er
 	return none, nil
 }
 
+// GetUserLogin returns the login of the Grafana user authenticated by credentials from ctx,
+// or the API key name prefixed with "api_key:" for API key authentication.
+func (c *Client) GetUserLogin(ctx context.Context) (string, error) {
+	authHeaders, err := c.authHeadersFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if c.isAPIKeyAuth(authHeaders.Get("Authorization")) {
+		var k map[string]interface{}
+		if err = c.do(ctx, "GET", "/api/auth/key", "", authHeaders, nil, &k); err != nil {
+			return "", err
+		}
+		name, _ := k["name"].(string)
+		return "api_key:" + name, nil
+	}
+
+	var m map[string]interface{}
+	if err = c.do(ctx, "GET", "/api/user", "", authHeaders, nil, &m); err != nil {
+		return "", err
+	}
+	login, _ := m["login"].(string)
+	if login == "" {
+		return "", status.Error(codes.Unauthenticated, "Authorization error.")
+	}
+	return login, nil
+}
+
 func (c *Client) isAPIKeyAuth(authHeader string) bool {
 	switch {
 	case strings.HasPrefix(authHeader, "Bearer"):
@@ -320,6 +348,15 @@ func (c *Client) CreateAdminAPIKey(ctx context.Context, name string) (int64, str
 	return c.createAPIKey(ctx, name, admin, authHeaders)
 }
 
+// CreateEditorAPIKey creates API key with Editor role and provided name.
+func (c *Client) CreateEditorAPIKey(ctx context.Context, name string) (int64, string, error) {
+	authHeaders, err := c.authHeadersFromContext(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	return c.createAPIKey(ctx, name, editor, authHeaders)
+}
+
 // DeleteAPIKeysWithPrefix deletes all API keys with provided prefix. If there is no api key with provided prefix just ignores it.
 func (c *Client) DeleteAPIKeysWithPrefix(ctx context.Context, prefix string) error {
 	authHeaders, err := c.authHeadersFromContext(ctx)
@@ -26,6 +26,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestClient(t *testing.T) {
@@ -206,6 +209,20 @@ func TestClient(t *testing.T) {
 		})
 	})
 
+	t.Run("GetUserLogin", func(t *testing.T) {
+		t.Run("Basic auth", func(t *testing.T) {
+			md := metadata.New(map[string]string{"Authorization": authHeaders.Get("Authorization")})
+			login, err := c.GetUserLogin(metadata.NewIncomingContext(ctx, md))
+			require.NoError(t, err)
+			assert.Equal(t, "admin", login)
+		})
+
+		t.Run("No credentials", func(t *testing.T) {
+			_, err := c.GetUserLogin(metadata.NewIncomingContext(ctx, metadata.MD{}))
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	})
+
 	t.Run("IsReady", func(t *testing.T) {
 		err := c.IsReady(ctx)
 		require.NoError(t, err)
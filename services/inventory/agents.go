@@ -18,6 +18,7 @@ package inventory
 
 import (
 	"context"
+	"encoding/base64"
 
 	"github.com/AlekSi/pointer"
 	"github.com/percona/pmm/api/inventorypb"
@@ -32,21 +33,23 @@ import (
 
 // AgentsService works with inventory API Agents.
 type AgentsService struct {
-	r     agentsRegistry
-	state agentsStateUpdater
-	vmdb  prometheusService
-	db    *reform.DB
-	cc    connectionChecker
+	r       agentsRegistry
+	state   agentsStateUpdater
+	vmdb    prometheusService
+	db      *reform.DB
+	replica *models.ReadReplica
+	cc      connectionChecker
 }
 
 // NewAgentsService creates new AgentsService
-func NewAgentsService(db *reform.DB, r agentsRegistry, state agentsStateUpdater, vmdb prometheusService, cc connectionChecker) *AgentsService {
+func NewAgentsService(db *reform.DB, replica *models.ReadReplica, r agentsRegistry, state agentsStateUpdater, vmdb prometheusService, cc connectionChecker) *AgentsService {
 	return &AgentsService{
-		r:     r,
-		state: state,
-		vmdb:  vmdb,
-		db:    db,
-		cc:    cc,
+		r:       r,
+		state:   state,
+		vmdb:    vmdb,
+		db:      db,
+		replica: replica,
+		cc:      cc,
 	}
 }
 
@@ -106,41 +109,166 @@ func (as *AgentsService) changeAgent(agentID string, common *inventorypb.ChangeC
 	return agent, e
 }
 
+// ChangeMetricsResolutions changes Agent metrics resolutions overrides and tier, so it is scraped with them
+// instead of Service or global resolutions; nil or zero values and empty tier reset overrides.
+func (as *AgentsService) ChangeMetricsResolutions(ctx context.Context, id string, tier models.MetricsResolutionTier, resolutions *models.MetricsResolutions) (*models.Agent, error) {
+	var res *models.Agent
+	e := as.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeAgentMetricsResolutions(tx.Querier, id, tier, resolutions)
+		return err
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	// vmagent of pmm-agent in push mode should use new scrape intervals too
+	if res.PMMAgentID != nil {
+		as.state.RequestStateUpdate(ctx, *res.PMMAgentID)
+	}
+	as.vmdb.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// ChangeExporterTLS changes TLS options used for scraping Agent over HTTPS; nil or zero value means HTTP.
+func (as *AgentsService) ChangeExporterTLS(ctx context.Context, id string, options *models.ExporterTLSOptions) (*models.Agent, error) {
+	var res *models.Agent
+	e := as.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeAgentExporterTLS(tx.Querier, id, options)
+		return err
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	// vmagent of pmm-agent in push mode should use new scheme too
+	if res.PMMAgentID != nil {
+		as.state.RequestStateUpdate(ctx, *res.PMMAgentID)
+	}
+	as.vmdb.RequestConfigurationUpdate()
+	return res, nil
+}
+
 // List selects all Agents in a stable order for a given service.
 //nolint:unparam
 func (as *AgentsService) List(ctx context.Context, filters models.AgentFilters) ([]inventorypb.Agent, error) {
 	var res []inventorypb.Agent
-	e := as.db.InTransaction(func(tx *reform.TX) error {
-		got := 0
-		if filters.PMMAgentID != "" {
-			got++
+	e := as.replica.DB().InTransaction(func(tx *reform.TX) error {
+		agents, err := findAgents(tx.Querier, filters)
+		if err != nil {
+			return err
 		}
-		if filters.NodeID != "" {
-			got++
+		res, err = as.toInventoryAgents(tx.Querier, agents)
+		return err
+	})
+	return res, e
+}
+
+// ListPage returns a page of Agents for given filters, page size and page token.
+// Empty page token selects the first page. Returned next page token is empty for the last page.
+func (as *AgentsService) ListPage(ctx context.Context, filters models.AgentFilters, pageSize int, pageToken string) ([]inventorypb.Agent, string, error) {
+	if pageSize <= 0 {
+		return nil, "", status.Errorf(codes.InvalidArgument, "Page size should be positive.")
+	}
+
+	after, err := decodeAgentsPageToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var res []inventorypb.Agent
+	var nextPageToken string
+	e := as.replica.DB().InTransaction(func(tx *reform.TX) error {
+		filters.AfterAgentID = after
+		// select one extra Agent to check if there is a next page
+		filters.Limit = pageSize + 1
+		agents, err := findAgents(tx.Querier, filters)
+		if err != nil {
+			return err
 		}
-		if filters.ServiceID != "" {
-			got++
+
+		if len(agents) > pageSize {
+			agents = agents[:pageSize]
+			nextPageToken = encodeAgentsPageToken(agents[pageSize-1].AgentID)
 		}
-		if got > 1 {
-			return status.Errorf(codes.InvalidArgument, "expected at most one param: pmm_agent_id, node_id or service_id")
+		res, err = as.toInventoryAgents(tx.Querier, agents)
+		return err
+	})
+	return res, nextPageToken, e
+}
+
+// ListStream passes Agents for given filters to send function in chunks of given size,
+// so they don't have to be kept in memory all at once. It stops on the first send error.
+func (as *AgentsService) ListStream(ctx context.Context, filters models.AgentFilters, chunkSize int, send func([]inventorypb.Agent) error) error {
+	var pageToken string
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		agents, err := models.FindAgents(tx.Querier, filters)
+		agents, next, err := as.ListPage(ctx, filters, chunkSize, pageToken)
 		if err != nil {
 			return err
 		}
-
-		// TODO That loop makes len(agents) SELECTs, that can be slow. Optimize when needed.
-		res = make([]inventorypb.Agent, len(agents))
-		for i, a := range agents {
-			res[i], err = toInventoryAgent(tx.Querier, a, as.r)
-			if err != nil {
+		if len(agents) != 0 {
+			if err = send(agents); err != nil {
 				return err
 			}
 		}
-		return nil
-	})
-	return res, e
+
+		if next == "" {
+			return nil
+		}
+		pageToken = next
+	}
+}
+
+// findAgents validates filters and returns Agents for them.
+func findAgents(q *reform.Querier, filters models.AgentFilters) ([]*models.Agent, error) {
+	got := 0
+	if filters.PMMAgentID != "" {
+		got++
+	}
+	if filters.NodeID != "" {
+		got++
+	}
+	if filters.ServiceID != "" {
+		got++
+	}
+	if got > 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "expected at most one param: pmm_agent_id, node_id or service_id")
+	}
+
+	return models.FindAgents(q, filters)
+}
+
+// toInventoryAgents converts Agents to API representation.
+func (as *AgentsService) toInventoryAgents(q *reform.Querier, agents []*models.Agent) ([]inventorypb.Agent, error) {
+	// TODO That loop makes len(agents) SELECTs, that can be slow. Optimize when needed.
+	res := make([]inventorypb.Agent, len(agents))
+	for i, a := range agents {
+		var err error
+		res[i], err = toInventoryAgent(q, a, as.r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// encodeAgentsPageToken returns opaque page token for Agents after given Agent ID.
+func encodeAgentsPageToken(agentID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(agentID))
+}
+
+// decodeAgentsPageToken returns Agent ID encoded in page token.
+func decodeAgentsPageToken(pageToken string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid page token.")
+	}
+	return string(b), nil
 }
 
 // Get selects a single Agent by ID.
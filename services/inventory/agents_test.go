@@ -273,6 +273,38 @@ func TestAgents(t *testing.T) {
 			assert.Nil(t, actualAgents)
 		})
 
+		t.Run("ListPage", func(t *testing.T) {
+			filters := models.AgentFilters{PMMAgentID: pmmAgent.AgentId}
+			page, next, err := as.ListPage(ctx, filters, 3, "")
+			require.NoError(t, err)
+			require.Len(t, page, 3)
+			assert.Equal(t, expectedNodeExporter, page[0])
+			assert.NotEmpty(t, next)
+
+			page, next, err = as.ListPage(ctx, filters, 3, next)
+			require.NoError(t, err)
+			require.Len(t, page, 2)
+			assert.Equal(t, expectedQANMySQLSlowlogAgent, page[0])
+			assert.Equal(t, expectedPostgresExporter, page[1])
+			assert.Empty(t, next)
+
+			_, _, err = as.ListPage(ctx, filters, 3, "!")
+			tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Invalid page token.`), err)
+		})
+
+		t.Run("ListStream", func(t *testing.T) {
+			var chunks [][]inventorypb.Agent
+			err := as.ListStream(ctx, models.AgentFilters{PMMAgentID: pmmAgent.AgentId}, 2, func(agents []inventorypb.Agent) error {
+				chunks = append(chunks, agents)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, chunks, 3)
+			assert.Len(t, chunks[0], 2)
+			assert.Len(t, chunks[1], 2)
+			assert.Equal(t, []inventorypb.Agent{expectedPostgresExporter}, chunks[2])
+		})
+
 		as.r.(*mockAgentsRegistry).On("Kick", ctx, "/agent_id/00000000-0000-4000-8000-000000000005").Return(true)
 		err = as.Remove(ctx, "/agent_id/00000000-0000-4000-8000-000000000005", true)
 		require.NoError(t, err)
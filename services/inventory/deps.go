@@ -22,6 +22,7 @@ import (
 	"gopkg.in/reform.v1"
 
 	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/sandbox"
 )
 
 //go:generate mockery -name=agentsRegistry -case=snake -inpkg -testonly
@@ -29,6 +30,10 @@ import (
 //go:generate mockery -name=prometheusService -case=snake -inpkg -testonly
 //go:generate mockery -name=connectionChecker -case=snake -inpkg -testonly
 //go:generate mockery -name=versionCache -case=snake -inpkg -testonly
+//go:generate mockery -name=rulesService -case=snake -inpkg -testonly
+//go:generate mockery -name=vmAlertService -case=snake -inpkg -testonly
+//go:generate mockery -name=scheduleService -case=snake -inpkg -testonly
+//go:generate mockery -name=sandboxService -case=snake -inpkg -testonly
 
 // agentsRegistry is a subset of methods of agents.Registry used by this package.
 // We use it instead of real type for testing and to avoid dependency cycle.
@@ -62,3 +67,27 @@ type connectionChecker interface {
 type versionCache interface {
 	RequestSoftwareVersionsUpdate()
 }
+
+// rulesService is a subset of methods of ia.RulesService used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type rulesService interface {
+	WriteVMAlertRulesFiles()
+}
+
+// vmAlertService is a subset of methods of vmalert.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type vmAlertService interface {
+	RequestConfigurationUpdate()
+}
+
+// scheduleService is a subset of methods of scheduler.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type scheduleService interface {
+	Remove(id string) error
+}
+
+// sandboxService is a subset of methods of sandbox.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type sandboxService interface {
+	Run(ctx context.Context, apply func(q *reform.Querier) error) (*sandbox.Report, error)
+}
@@ -0,0 +1,207 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const expirationCheckInterval = time.Minute
+
+// ExpirationService periodically removes Nodes and Services with TTL
+// which pmm-agents were not connected for longer than that TTL.
+type ExpirationService struct {
+	db        *reform.DB
+	r         agentsRegistry
+	nodes     *NodesService
+	services  *ServicesService
+	scheduler scheduleService
+	l         *logrus.Entry
+
+	// pmm-agents get TTL to reconnect after PMM Server start
+	start time.Time
+}
+
+// NewExpirationService creates new inventory objects expiration service.
+func NewExpirationService(db *reform.DB, r agentsRegistry, nodes *NodesService, services *ServicesService, scheduler scheduleService) *ExpirationService {
+	return &ExpirationService{
+		db:        db,
+		r:         r,
+		nodes:     nodes,
+		services:  services,
+		scheduler: scheduler,
+		l:         logrus.WithField("component", "inventory/expiration"),
+		start:     time.Now(),
+	}
+}
+
+// Run removes expired Nodes and Services until context is canceled.
+func (s *ExpirationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(expirationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expire(ctx, time.Now())
+		}
+	}
+}
+
+// expiresAt returns time when object with given TTL and creation time expires,
+// or zero time if it does not expire or one of its pmm-agents is connected.
+func expiresAt(ttl time.Duration, createdAt, since time.Time, pmmAgents []*models.Agent, isConnected func(string) bool) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	lastSeen := createdAt
+	if since.After(lastSeen) {
+		lastSeen = since
+	}
+	for _, a := range pmmAgents {
+		if isConnected(a.AgentID) {
+			return time.Time{}
+		}
+		if a.LastSeenAt != nil && a.LastSeenAt.After(lastSeen) {
+			lastSeen = *a.LastSeenAt
+		}
+	}
+	return lastSeen.Add(ttl)
+}
+
+// expired returns true if object with given TTL and creation time is expired at given time.
+func (s *ExpirationService) expired(now time.Time, ttl time.Duration, createdAt time.Time, pmmAgents []*models.Agent) bool {
+	at := expiresAt(ttl, createdAt, s.start, pmmAgents, s.r.IsConnected)
+	return !at.IsZero() && !now.Before(at)
+}
+
+// expire removes expired Services first, then expired Nodes with their remaining dependent objects.
+func (s *ExpirationService) expire(ctx context.Context, now time.Time) {
+	services, err := models.FindServices(s.db.Querier, models.ServiceFilters{})
+	if err != nil {
+		s.l.Error(err)
+		return
+	}
+	for _, service := range services {
+		if service.TTL <= 0 {
+			continue
+		}
+		pmmAgents, err := models.FindPMMAgentsForService(s.db.Querier, service.ServiceID)
+		if err != nil {
+			s.l.Error(err)
+			continue
+		}
+		if !s.expired(now, service.TTL, service.CreatedAt, pmmAgents) {
+			continue
+		}
+		if err = s.expireService(ctx, service); err != nil {
+			s.l.Errorf("Failed to remove expired Service %q: %s.", service.ServiceName, err)
+		}
+	}
+
+	nodes, err := models.FindNodes(s.db.Querier, models.NodeFilters{})
+	if err != nil {
+		s.l.Error(err)
+		return
+	}
+	for _, node := range nodes {
+		if node.TTL <= 0 || node.NodeID == models.PMMServerNodeID {
+			continue
+		}
+		pmmAgents, err := models.FindPMMAgentsRunningOnNode(s.db.Querier, node.NodeID)
+		if err != nil {
+			s.l.Error(err)
+			continue
+		}
+		servicesAgents, err := models.FindPMMAgentsForServicesOnNode(s.db.Querier, node.NodeID)
+		if err != nil {
+			s.l.Error(err)
+			continue
+		}
+		if !s.expired(now, node.TTL, node.CreatedAt, append(pmmAgents, servicesAgents...)) {
+			continue
+		}
+		if err = s.expireNode(ctx, node); err != nil {
+			s.l.Errorf("Failed to remove expired Node %q: %s.", node.NodeName, err)
+		}
+	}
+}
+
+// removeScheduledTasks removes scheduled tasks of given Services from scheduler.
+// Tasks are removed from scheduler first, otherwise they would keep running after cascade removal from database.
+func (s *ExpirationService) removeScheduledTasks(serviceIDs ...string) error {
+	for _, id := range serviceIDs {
+		tasks, err := models.FindScheduledTasks(s.db.Querier, models.ScheduledTasksFilter{ServiceID: id})
+		if err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			if err = s.scheduler.Remove(t.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expireService removes Service with its Agents and scheduled tasks, and records audit event.
+func (s *ExpirationService) expireService(ctx context.Context, service *models.Service) error {
+	if err := s.removeScheduledTasks(service.ServiceID); err != nil {
+		return err
+	}
+	if err := s.services.Remove(ctx, service.ServiceID, true); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Service %q expired: no pmm-agent was connected for %s.", service.ServiceName, service.TTL)
+	s.l.Info(msg)
+	_, err := models.CreateAuditEvent(s.db.Querier, models.ServiceExpiredAuditEventType, service.ServiceID, "", msg)
+	return err
+}
+
+// expireNode removes Node with its dependent objects and scheduled tasks, and records audit event.
+func (s *ExpirationService) expireNode(ctx context.Context, node *models.Node) error {
+	services, err := models.FindServices(s.db.Querier, models.ServiceFilters{NodeID: node.NodeID})
+	if err != nil {
+		return err
+	}
+	serviceIDs := make([]string, len(services))
+	for i, service := range services {
+		serviceIDs[i] = service.ServiceID
+	}
+	if err = s.removeScheduledTasks(serviceIDs...); err != nil {
+		return err
+	}
+	if err = s.nodes.Remove(ctx, node.NodeID, true); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Node %q expired: no pmm-agent was connected for %s.", node.NodeName, node.TTL)
+	s.l.Info(msg)
+	_, err = models.CreateAuditEvent(s.db.Querier, models.NodeExpiredAuditEventType, node.NodeID, "", msg)
+	return err
+}
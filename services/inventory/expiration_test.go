@@ -0,0 +1,69 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestExpiresAt(t *testing.T) {
+	createdAt := time.Date(2021, 10, 20, 10, 0, 0, 0, time.UTC)
+	start := createdAt.Add(time.Minute)
+	pmmAgents := []*models.Agent{
+		{AgentID: "/agent_id/1", LastSeenAt: pointer.ToTime(createdAt.Add(5 * time.Minute))},
+		{AgentID: "/agent_id/2"},
+	}
+	connected := func(ids ...string) func(string) bool {
+		return func(id string) bool {
+			for _, c := range ids {
+				if c == id {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	t.Run("NoTTL", func(t *testing.T) {
+		assert.True(t, expiresAt(0, createdAt, start, pmmAgents, connected()).IsZero())
+	})
+
+	t.Run("Connected", func(t *testing.T) {
+		assert.True(t, expiresAt(time.Hour, createdAt, start, pmmAgents, connected("/agent_id/2")).IsZero())
+	})
+
+	t.Run("LastSeen", func(t *testing.T) {
+		expected := createdAt.Add(5*time.Minute + time.Hour)
+		assert.Equal(t, expected, expiresAt(time.Hour, createdAt, start, pmmAgents, connected()))
+	})
+
+	t.Run("ServerStart", func(t *testing.T) {
+		// pmm-agents are not connected yet after PMM Server restart
+		start := createdAt.Add(24 * time.Hour)
+		assert.Equal(t, start.Add(time.Hour), expiresAt(time.Hour, createdAt, start, pmmAgents, connected()))
+	})
+
+	t.Run("NoAgents", func(t *testing.T) {
+		assert.Equal(t, start.Add(time.Hour), expiresAt(time.Hour, createdAt, start, nil, connected()))
+	})
+}
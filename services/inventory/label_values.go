@@ -0,0 +1,109 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package inventory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// labelValuesTTL is a time during which cached label values are returned without querying the database.
+const labelValuesTTL = 30 * time.Second
+
+type labelValuesEntry struct {
+	values    []string
+	expiresAt time.Time
+}
+
+// LabelValuesService returns distinct label values of inventory objects
+// for Grafana dashboard variables instead of expensive label_values PromQL queries.
+type LabelValuesService struct {
+	replica *models.ReadReplica
+	ttl     time.Duration
+
+	rw    sync.RWMutex
+	cache map[string]labelValuesEntry
+}
+
+// NewLabelValuesService creates new LabelValuesService.
+func NewLabelValuesService(replica *models.ReadReplica) *LabelValuesService {
+	return &LabelValuesService{
+		replica: replica,
+		ttl:     labelValuesTTL,
+		cache:   make(map[string]labelValuesEntry),
+	}
+}
+
+// labelValuesKey returns cache key for given parameters.
+func labelValuesKey(params *models.LabelValuesParams) string {
+	names := make([]string, 0, len(params.Matchers))
+	for name := range params.Matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(params.Label)
+	for _, name := range names {
+		b.WriteString("\x00" + name + "=" + params.Matchers[name])
+	}
+	return b.String()
+}
+
+// ListLabelValues returns sorted distinct values of the label, possibly cached.
+func (s *LabelValuesService) ListLabelValues(ctx context.Context, params *models.LabelValuesParams) ([]string, error) {
+	key := labelValuesKey(params)
+	now := time.Now()
+
+	s.rw.RLock()
+	entry, ok := s.cache[key]
+	s.rw.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.values, nil
+	}
+
+	var values []string
+	e := s.replica.DB().InTransaction(func(tx *reform.TX) error {
+		var err error
+		values, err = models.FindLabelValues(tx.Querier, params)
+		return err
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	s.rw.Lock()
+	for k, v := range s.cache {
+		if !now.Before(v.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = labelValuesEntry{
+		values:    values,
+		expiresAt: now.Add(s.ttl),
+	}
+	s.rw.Unlock()
+
+	return values, nil
+}
@@ -0,0 +1,63 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestLabelValuesKey(t *testing.T) {
+	k1 := labelValuesKey(&models.LabelValuesParams{
+		Label:    "cluster",
+		Matchers: map[string]string{"environment": "prod", "region": "eu"},
+	})
+	k2 := labelValuesKey(&models.LabelValuesParams{
+		Label:    "cluster",
+		Matchers: map[string]string{"region": "eu", "environment": "prod"},
+	})
+	assert.Equal(t, k1, k2)
+
+	k3 := labelValuesKey(&models.LabelValuesParams{
+		Label:    "cluster",
+		Matchers: map[string]string{"environment": "dev", "region": "eu"},
+	})
+	assert.NotEqual(t, k1, k3)
+
+	k4 := labelValuesKey(&models.LabelValuesParams{Label: "environment"})
+	assert.NotEqual(t, k1, k4)
+}
+
+func TestListLabelValuesCached(t *testing.T) {
+	// replica is not set, so any database query would panic
+	s := NewLabelValuesService(nil)
+	params := &models.LabelValuesParams{Label: "environment"}
+	s.cache[labelValuesKey(params)] = labelValuesEntry{
+		values:    []string{"dev", "prod"},
+		expiresAt: time.Now().Add(time.Minute),
+	}
+
+	values, err := s.ListLabelValues(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, values)
+}
@@ -0,0 +1,141 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package inventory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/sandbox"
+)
+
+// BulkChangeLabelsProgress represents progress of the last bulk labels change.
+type BulkChangeLabelsProgress struct {
+	Running bool `json:"running"`
+	// Number of stored and total number of changed objects.
+	Done      int        `json:"done"`
+	Total     int        `json:"total"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// LabelsService changes custom labels of many inventory objects at once.
+type LabelsService struct {
+	db      *reform.DB
+	vmdb    prometheusService
+	rules   rulesService
+	vmalert vmAlertService
+	sandbox sandboxService
+	l       *logrus.Entry
+
+	rw       sync.RWMutex
+	progress BulkChangeLabelsProgress
+}
+
+// NewLabelsService creates new LabelsService.
+func NewLabelsService(db *reform.DB, vmdb prometheusService, rules rulesService, vmalert vmAlertService, sandbox sandboxService) *LabelsService {
+	return &LabelsService{
+		db:      db,
+		vmdb:    vmdb,
+		rules:   rules,
+		vmalert: vmalert,
+		sandbox: sandbox,
+		l:       logrus.WithField("component", "inventory/labels"),
+	}
+}
+
+// BulkChangeLabels changes custom labels of all Nodes, Services and Agents matching the selector
+// and filters of alert rules using renamed labels in a single transaction,
+// then updates scrape configuration and alert rules once.
+// Only one change can run at a time; its progress is available via Progress method
+// and reported to params.Progress callback, if set.
+// If dryRun is true, changes are applied in the configuration sandbox only,
+// and its report of generated configuration files is returned too.
+func (s *LabelsService) BulkChangeLabels(ctx context.Context, params *models.BulkChangeLabelsParams, dryRun bool) (*models.BulkChangeLabelsResult, *sandbox.Report, error) {
+	var res *models.BulkChangeLabelsResult
+	apply := func(q *reform.Querier) error {
+		var err error
+		res, err = models.BulkChangeLabels(q, params)
+		return err
+	}
+
+	if dryRun {
+		report, err := s.sandbox.Run(ctx, apply)
+		if err != nil {
+			return nil, nil, err
+		}
+		return res, report, nil
+	}
+
+	s.rw.Lock()
+	if s.progress.Running {
+		s.rw.Unlock()
+		return nil, nil, status.Error(codes.FailedPrecondition, "Another bulk labels change is running.")
+	}
+	startedAt := time.Now()
+	s.progress = BulkChangeLabelsProgress{
+		Running:   true,
+		StartedAt: &startedAt,
+	}
+	s.rw.Unlock()
+
+	defer func() {
+		s.rw.Lock()
+		s.progress.Running = false
+		s.rw.Unlock()
+	}()
+
+	callerProgress := params.Progress
+	params.Progress = func(done, total int) {
+		s.rw.Lock()
+		s.progress.Done, s.progress.Total = done, total
+		s.rw.Unlock()
+
+		s.l.Infof("Changed labels of %d/%d objects.", done, total)
+		if callerProgress != nil {
+			callerProgress(done, total)
+		}
+	}
+	if err := s.db.InTransaction(func(tx *reform.TX) error { return apply(tx.Querier) }); err != nil {
+		return nil, nil, err
+	}
+
+	if len(res.Changes) != 0 {
+		s.vmdb.RequestConfigurationUpdate()
+	}
+	if len(res.RuleIDs) != 0 {
+		s.rules.WriteVMAlertRulesFiles()
+		s.vmalert.RequestConfigurationUpdate()
+	}
+
+	return res, nil, nil
+}
+
+// Progress returns progress of the running or last finished bulk labels change.
+func (s *LabelsService) Progress() *BulkChangeLabelsProgress {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	res := s.progress
+	return &res
+}
@@ -18,6 +18,7 @@ package inventory
 
 import (
 	"context"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/percona/pmm/api/inventorypb"
@@ -30,19 +31,21 @@ import (
 
 // NodesService works with inventory API Nodes.
 type NodesService struct {
-	db    *reform.DB
-	r     agentsRegistry
-	state agentsStateUpdater
-	vmdb  prometheusService
+	db      *reform.DB
+	replica *models.ReadReplica
+	r       agentsRegistry
+	state   agentsStateUpdater
+	vmdb    prometheusService
 }
 
 // NewNodesService returns Inventory API handler for managing Nodes.
-func NewNodesService(db *reform.DB, r agentsRegistry, state agentsStateUpdater, vmdb prometheusService) *NodesService {
+func NewNodesService(db *reform.DB, replica *models.ReadReplica, r agentsRegistry, state agentsStateUpdater, vmdb prometheusService) *NodesService {
 	return &NodesService{
-		db:    db,
-		r:     r,
-		state: state,
-		vmdb:  vmdb,
+		db:      db,
+		replica: replica,
+		r:       r,
+		state:   state,
+		vmdb:    vmdb,
 	}
 }
 
@@ -50,7 +53,7 @@ func NewNodesService(db *reform.DB, r agentsRegistry, state agentsStateUpdater,
 //nolint:unparam
 func (s *NodesService) List(ctx context.Context, filters models.NodeFilters) ([]inventorypb.Node, error) {
 	var nodes []*models.Node
-	e := s.db.InTransaction(func(tx *reform.TX) error {
+	e := s.replica.DB().InTransaction(func(tx *reform.TX) error {
 		var err error
 		nodes, err = models.FindNodes(tx.Querier, filters)
 		return err
@@ -93,6 +96,75 @@ func (s *NodesService) Get(ctx context.Context, req *inventorypb.GetNodeRequest)
 	return node, nil
 }
 
+// ChangeOwnership changes notes, owner and contact of the Node.
+func (s *NodesService) ChangeOwnership(ctx context.Context, id string, params *models.ChangeOwnershipParams) (*models.Node, error) {
+	var res *models.Node
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeNodeOwnership(tx.Querier, id, params)
+		return err
+	})
+	return res, e
+}
+
+// ChangeTTL changes Node TTL: Node is removed when none of its pmm-agents is connected for that duration.
+// Zero TTL disables expiration.
+func (s *NodesService) ChangeTTL(ctx context.Context, id string, ttl time.Duration) (*models.Node, error) {
+	var res *models.Node
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeNodeTTL(tx.Querier, id, ttl)
+		return err
+	})
+	return res, e
+}
+
+// ChangeKubernetesMetadata replaces Kubernetes metadata of the Node and updates target labels of its Agents.
+func (s *NodesService) ChangeKubernetesMetadata(ctx context.Context, id string, md *models.KubernetesMetadata) (*models.Node, error) {
+	var res *models.Node
+	pmmAgentIDs := make(map[string]struct{})
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if res, err = models.ChangeNodeKubernetesMetadata(tx.Querier, id, md); err != nil {
+			return err
+		}
+
+		// labels of Node are also used by Agents of Services running on it
+		runningOnNode, err := models.FindPMMAgentsRunningOnNode(tx.Querier, id)
+		if err != nil {
+			return err
+		}
+		forServices, err := models.FindPMMAgentsForServicesOnNode(tx.Querier, id)
+		if err != nil {
+			return err
+		}
+		for _, agent := range append(runningOnNode, forServices...) {
+			pmmAgentIDs[agent.AgentID] = struct{}{}
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	for pmmAgentID := range pmmAgentIDs {
+		s.state.RequestStateUpdate(ctx, pmmAgentID)
+	}
+	s.vmdb.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// Search returns Nodes with owner, contact or notes containing given string.
+func (s *NodesService) Search(ctx context.Context, search string) ([]*models.Node, error) {
+	var res []*models.Node
+	e := s.replica.DB().InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindNodes(tx.Querier, models.NodeFilters{Search: search})
+		return err
+	})
+	return res, e
+}
+
 // AddGenericNode adds Generic Node.
 //nolint:unparam
 func (s *NodesService) AddGenericNode(ctx context.Context, req *inventorypb.AddGenericNodeRequest) (*inventorypb.GenericNode, error) {
@@ -271,37 +343,7 @@ func (s *NodesService) Remove(ctx context.Context, id string, force bool) error
 	idsToSetState := make(map[string]struct{})
 
 	if e := s.db.InTransaction(func(tx *reform.TX) error {
-		mode := models.RemoveRestrict
-		if force {
-			mode = models.RemoveCascade
-
-			agents, err := models.FindPMMAgentsRunningOnNode(tx.Querier, id)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			for _, a := range agents {
-				idsToKick[a.AgentID] = struct{}{}
-			}
-
-			agents, err = models.FindAgents(tx.Querier, models.AgentFilters{NodeID: id})
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			for _, a := range agents {
-				if a.PMMAgentID != nil {
-					idsToSetState[pointer.GetString(a.PMMAgentID)] = struct{}{}
-				}
-			}
-
-			agents, err = models.FindPMMAgentsForServicesOnNode(tx.Querier, id)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-			for _, a := range agents {
-				idsToSetState[a.AgentID] = struct{}{}
-			}
-		}
-		return models.RemoveNode(tx.Querier, id, mode)
+		return s.remove(tx.Querier, id, force, idsToKick, idsToSetState)
 	}); e != nil {
 		return e
 	}
@@ -320,3 +362,59 @@ func (s *NodesService) Remove(ctx context.Context, id string, force bool) error
 
 	return nil
 }
+
+// RemovePreview returns objects that would be removed or changed by Remove with the same parameters.
+// Nothing is removed.
+func (s *NodesService) RemovePreview(ctx context.Context, id string, force bool) (*models.RemovalReport, error) {
+	var res *models.RemovalReport
+	e := s.db.InTransaction(func(tx *reform.TX) error {
+		var err error
+		res, err = models.PreviewRemoval(tx.Querier, func() error {
+			return s.remove(tx.Querier, id, force, make(map[string]struct{}), make(map[string]struct{}))
+		})
+		if err != nil {
+			return err
+		}
+		return errPreview
+	})
+	if e != errPreview { //nolint:errorlint
+		return nil, e
+	}
+	return res, nil
+}
+
+// remove removes Node and, if force is true, its dependent objects.
+// IDs of pmm-agents that should be disconnected or get state update are added to idsToKick and idsToSetState.
+func (s *NodesService) remove(q *reform.Querier, id string, force bool, idsToKick, idsToSetState map[string]struct{}) error {
+	mode := models.RemoveRestrict
+	if force {
+		mode = models.RemoveCascade
+
+		agents, err := models.FindPMMAgentsRunningOnNode(q, id)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, a := range agents {
+			idsToKick[a.AgentID] = struct{}{}
+		}
+
+		agents, err = models.FindAgents(q, models.AgentFilters{NodeID: id})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, a := range agents {
+			if a.PMMAgentID != nil {
+				idsToSetState[pointer.GetString(a.PMMAgentID)] = struct{}{}
+			}
+		}
+
+		agents, err = models.FindPMMAgentsForServicesOnNode(q, id)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, a := range agents {
+			idsToSetState[a.AgentID] = struct{}{}
+		}
+	}
+	return models.RemoveNode(q, id, mode)
+}
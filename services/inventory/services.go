@@ -18,38 +18,46 @@ package inventory
 
 import (
 	"context"
+	"time"
 
 	"github.com/AlekSi/pointer"
 	"github.com/percona/pmm/api/inventorypb"
+	"github.com/pkg/errors"
 	"gopkg.in/reform.v1"
 
 	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/services"
 )
 
+// errPreview is returned from transaction function to roll back changes made for preview.
+var errPreview = errors.New("preview")
+
 // ServicesService works with inventory API Services.
 type ServicesService struct {
-	db    *reform.DB
-	r     agentsRegistry
-	state agentsStateUpdater
-	vmdb  prometheusService
-	vc    versionCache
+	db      *reform.DB
+	replica *models.ReadReplica
+	r       agentsRegistry
+	state   agentsStateUpdater
+	vmdb    prometheusService
+	vc      versionCache
 }
 
 // NewServicesService creates new ServicesService
 func NewServicesService(
 	db *reform.DB,
+	replica *models.ReadReplica,
 	r agentsRegistry,
 	state agentsStateUpdater,
 	vmdb prometheusService,
 	vc versionCache,
 ) *ServicesService {
 	return &ServicesService{
-		db:    db,
-		r:     r,
-		state: state,
-		vmdb:  vmdb,
-		vc:    vc,
+		db:      db,
+		replica: replica,
+		r:       r,
+		state:   state,
+		vmdb:    vmdb,
+		vc:      vc,
 	}
 }
 
@@ -57,7 +65,7 @@ func NewServicesService(
 //nolint:unparam
 func (ss *ServicesService) List(ctx context.Context, filters models.ServiceFilters) ([]inventorypb.Service, error) {
 	var servicesM []*models.Service
-	e := ss.db.InTransaction(func(tx *reform.TX) error {
+	e := ss.replica.DB().InTransaction(func(tx *reform.TX) error {
 		var err error
 		servicesM, err = models.FindServices(tx.Querier, filters)
 		return err
@@ -96,6 +104,65 @@ func (ss *ServicesService) Get(ctx context.Context, id string) (inventorypb.Serv
 	return services.ToAPIService(service)
 }
 
+// ChangeOwnership changes notes, owner and contact of the Service.
+func (ss *ServicesService) ChangeOwnership(ctx context.Context, id string, params *models.ChangeOwnershipParams) (*models.Service, error) {
+	var res *models.Service
+	e := ss.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeServiceOwnership(tx.Querier, id, params)
+		return err
+	})
+	return res, e
+}
+
+// ChangeTTL changes Service TTL: Service is removed when none of its pmm-agents is connected for that duration.
+// Zero TTL disables expiration.
+func (ss *ServicesService) ChangeTTL(ctx context.Context, id string, ttl time.Duration) (*models.Service, error) {
+	var res *models.Service
+	e := ss.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeServiceTTL(tx.Querier, id, ttl)
+		return err
+	})
+	return res, e
+}
+
+// ChangeMetricsResolutions changes Service metrics resolutions overrides, so its exporters are scraped with them
+// instead of global resolutions from settings; nil or zero values reset overrides.
+func (ss *ServicesService) ChangeMetricsResolutions(ctx context.Context, id string, resolutions *models.MetricsResolutions) (*models.Service, error) {
+	var res *models.Service
+	var pmmAgents []*models.Agent
+	e := ss.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if res, err = models.ChangeServiceMetricsResolutions(tx.Querier, id, resolutions); err != nil {
+			return err
+		}
+		pmmAgents, err = models.FindPMMAgentsForService(tx.Querier, id)
+		return err
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	// vmagents of pmm-agents in push mode should use new scrape intervals too
+	for _, pmmAgent := range pmmAgents {
+		ss.state.RequestStateUpdate(ctx, pmmAgent.AgentID)
+	}
+	ss.vmdb.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// Search returns Services with owner, contact or notes containing given string.
+func (ss *ServicesService) Search(ctx context.Context, search string) ([]*models.Service, error) {
+	var res []*models.Service
+	e := ss.replica.DB().InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindServices(tx.Querier, models.ServiceFilters{Search: search})
+		return err
+	})
+	return res, e
+}
+
 // AddMySQL inserts MySQL Service with given parameters.
 //nolint:dupl,unparam
 func (ss *ServicesService) AddMySQL(ctx context.Context, params *models.AddDBMSServiceParams) (*inventorypb.MySQLService, error) {
@@ -240,68 +307,94 @@ func (ss *ServicesService) Remove(ctx context.Context, id string, force bool) er
 	pmmAgentIds := make(map[string]struct{})
 
 	if e := ss.db.InTransaction(func(tx *reform.TX) error {
-		service, err := models.FindServiceByID(tx.Querier, id)
+		return ss.remove(tx.Querier, id, force, pmmAgentIds)
+	}); e != nil {
+		return e
+	}
+
+	for pmmAgentID := range pmmAgentIds {
+		ss.state.RequestStateUpdate(ctx, pmmAgentID)
+	}
+
+	if force {
+		// It's required to regenerate victoriametrics config file for the agents which aren't run by pmm-agent.
+		ss.vmdb.RequestConfigurationUpdate()
+	}
+
+	return nil
+}
+
+// RemovePreview returns objects that would be removed or changed by Remove with the same parameters.
+// Nothing is removed.
+func (ss *ServicesService) RemovePreview(ctx context.Context, id string, force bool) (*models.RemovalReport, error) {
+	var res *models.RemovalReport
+	e := ss.db.InTransaction(func(tx *reform.TX) error {
+		var err error
+		res, err = models.PreviewRemoval(tx.Querier, func() error {
+			return ss.remove(tx.Querier, id, force, make(map[string]struct{}))
+		})
 		if err != nil {
 			return err
 		}
+		return errPreview
+	})
+	if e != errPreview { //nolint:errorlint
+		return nil, e
+	}
+	return res, nil
+}
 
-		mode := models.RemoveRestrict
-		if force {
-			mode = models.RemoveCascade
+// remove removes Service and, if force is true, its dependent objects.
+// IDs of pmm-agents that should get state update are added to pmmAgentIds.
+func (ss *ServicesService) remove(q *reform.Querier, id string, force bool, pmmAgentIds map[string]struct{}) error {
+	service, err := models.FindServiceByID(q, id)
+	if err != nil {
+		return err
+	}
 
-			agents, err := models.FindPMMAgentsForService(tx.Querier, id)
-			if err != nil {
-				return err
-			}
+	mode := models.RemoveRestrict
+	if force {
+		mode = models.RemoveCascade
 
-			for _, agent := range agents {
-				pmmAgentIds[agent.AgentID] = struct{}{}
-			}
+		agents, err := models.FindPMMAgentsForService(q, id)
+		if err != nil {
+			return err
 		}
 
-		err = models.RemoveService(tx.Querier, id, mode)
+		for _, agent := range agents {
+			pmmAgentIds[agent.AgentID] = struct{}{}
+		}
+	}
+
+	err = models.RemoveService(q, id, mode)
+	if err != nil {
+		return err
+	}
+
+	if force {
+		node, err := models.FindNodeByID(q, service.NodeID)
 		if err != nil {
 			return err
 		}
 
-		if force {
-			node, err := models.FindNodeByID(tx.Querier, service.NodeID)
+		// For RDS and Azure remove also node.
+		if node.NodeType == models.RemoteRDSNodeType || node.NodeType == models.RemoteAzureDatabaseNodeType {
+			agents, err := models.FindAgents(q, models.AgentFilters{NodeID: node.NodeID})
 			if err != nil {
 				return err
 			}
-
-			// For RDS and Azure remove also node.
-			if node.NodeType == models.RemoteRDSNodeType || node.NodeType == models.RemoteAzureDatabaseNodeType {
-				agents, err := models.FindAgents(tx.Querier, models.AgentFilters{NodeID: node.NodeID})
-				if err != nil {
-					return err
-				}
-				for _, agent := range agents {
-					if agent.PMMAgentID != nil {
-						pmmAgentIds[pointer.GetString(agent.PMMAgentID)] = struct{}{}
-					}
+			for _, agent := range agents {
+				if agent.PMMAgentID != nil {
+					pmmAgentIds[pointer.GetString(agent.PMMAgentID)] = struct{}{}
 				}
+			}
 
-				if len(pmmAgentIds) <= 1 {
-					if err = models.RemoveNode(tx.Querier, node.NodeID, models.RemoveCascade); err != nil {
-						return err
-					}
+			if len(pmmAgentIds) <= 1 {
+				if err = models.RemoveNode(q, node.NodeID, models.RemoveCascade); err != nil {
+					return err
 				}
 			}
 		}
-
-		return nil
-	}); e != nil {
-		return e
-	}
-
-	for pmmAgentID := range pmmAgentIds {
-		ss.state.RequestStateUpdate(ctx, pmmAgentID)
-	}
-
-	if force {
-		// It's required to regenerate victoriametrics config file for the agents which aren't run by pmm-agent.
-		ss.vmdb.RequestConfigurationUpdate()
 	}
 
 	return nil
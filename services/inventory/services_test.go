@@ -73,9 +73,10 @@ func setup(t *testing.T) (*ServicesService, *AgentsService, *NodesService, func(
 		cc.Test(t)
 	}
 
-	return NewServicesService(db, r, state, vmdb, vc),
-		NewAgentsService(db, r, state, vmdb, cc),
-		NewNodesService(db, r, state, vmdb),
+	replica := models.NewReadReplica(db, nil, 0)
+	return NewServicesService(db, replica, r, state, vmdb, vc),
+		NewAgentsService(db, replica, r, state, vmdb, cc),
+		NewNodesService(db, replica, r, state, vmdb),
 		teardown,
 		logger.Set(context.Background(), t.Name())
 }
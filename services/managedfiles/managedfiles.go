@@ -0,0 +1,177 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package managedfiles provides read-only access to configuration files generated by pmm-managed.
+package managedfiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secret values, the same way Prometheus and Alertmanager do it.
+const redactedValue = "<secret>"
+
+// secretKeyRE matches keys of YAML mappings with secret values, like password, smtp_auth_secret,
+// bearer_token, or slack_api_url (Slack webhook URL contains a token).
+var secretKeyRE = regexp.MustCompile(`(?i)(password|secret|token|credentials|api_key|api_url|service_key|routing_key)$`)
+
+// File represents generated configuration file.
+type File struct {
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	ModifiedAt time.Time `json:"modified_at"`
+	// SHA-256 checksum of the actual file content, before secrets redaction.
+	SHA256 string `json:"sha256"`
+	// File content with secrets redacted.
+	Content string `json:"content"`
+}
+
+// Service provides generated configuration files.
+type Service struct {
+	files map[string]string // name -> path
+	dirs  map[string]string // name prefix -> directory with *.yml files
+}
+
+// New creates new service for given files and directories with *.yml files.
+// Files in directories are named as "<dir name>/<file name>".
+func New(files, dirs map[string]string) *Service {
+	return &Service{
+		files: files,
+		dirs:  dirs,
+	}
+}
+
+// List returns names of existing files, sorted.
+func (s *Service) List() ([]string, error) {
+	res := make([]string, 0, len(s.files))
+	for name, path := range s.files {
+		if _, err := os.Stat(path); err == nil {
+			res = append(res, name)
+		}
+	}
+
+	for prefix, dir := range s.dirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, m := range matches {
+			res = append(res, prefix+"/"+filepath.Base(m))
+		}
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// path returns path of the file with given name.
+func (s *Service) path(name string) (string, error) {
+	if path, ok := s.files[name]; ok {
+		return path, nil
+	}
+
+	if i := strings.Index(name, "/"); i > 0 {
+		dir, ok := s.dirs[name[:i]]
+		base := name[i+1:]
+		if ok && filepath.Base(base) == base && filepath.Ext(base) == ".yml" {
+			return filepath.Join(dir, base), nil
+		}
+	}
+
+	return "", status.Errorf(codes.NotFound, "Unknown file %q.", name)
+}
+
+// Get returns file with given name with secrets redacted.
+func (s *Service) Get(name string) (*File, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, status.Errorf(codes.NotFound, "File %q is not generated yet.", name)
+		}
+		return nil, errors.WithStack(err)
+	}
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	content, err := redact(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to redact %s", path)
+	}
+
+	sum := sha256.Sum256(b)
+	return &File{
+		Name:       name,
+		Path:       path,
+		ModifiedAt: fi.ModTime().UTC(),
+		SHA256:     hex.EncodeToString(sum[:]),
+		Content:    string(content),
+	}, nil
+}
+
+// redact returns YAML document with values of secret keys replaced.
+func redact(b []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if doc.Kind == 0 {
+		// empty file
+		return b, nil
+	}
+
+	redactNode(&doc)
+
+	res, err := yaml.Marshal(&doc)
+	return res, errors.WithStack(err)
+}
+
+// redactNode recursively replaces scalar values of secret keys.
+func redactNode(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Value != "" && secretKeyRE.MatchString(key.Value) {
+				value.Value = redactedValue
+				value.Tag = "!!str"
+				value.Style = 0
+			}
+		}
+	}
+
+	for _, n := range node.Content {
+		redactNode(n)
+	}
+}
@@ -0,0 +1,105 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package managedfiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestManagedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmm-managed-managedfiles-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	rulesDir := filepath.Join(dir, "rules")
+	require.NoError(t, os.Mkdir(rulesDir, 0o755))
+
+	alertmanager := []byte(strings.TrimSpace(`
+global:
+  smtp_smarthost: smtp.example.com:587
+  smtp_auth_username: pmm
+  smtp_auth_password: pmm-password
+  slack_api_url: https://hooks.slack.com/services/T000/B000/XXXX
+receivers:
+  - name: webhook
+    webhook_configs:
+      - url: https://example.com/hook
+        http_config:
+          basic_auth:
+            username: user
+            password: "12345"
+`) + "\n")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "alertmanager.yml"), alertmanager, 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(rulesDir, "rule.yml"), []byte("groups: []\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret.yml"), []byte("password: secret\n"), 0o644))
+
+	s := New(map[string]string{
+		"alertmanager":    filepath.Join(dir, "alertmanager.yml"),
+		"victoriametrics": filepath.Join(dir, "absent.yml"),
+	}, map[string]string{
+		"ia_rules": rulesDir,
+	})
+
+	t.Run("List", func(t *testing.T) {
+		names, err := s.List()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alertmanager", "ia_rules/rule.yml"}, names)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		f, err := s.Get("alertmanager")
+		require.NoError(t, err)
+
+		sum := sha256.Sum256(alertmanager)
+		assert.Equal(t, hex.EncodeToString(sum[:]), f.SHA256)
+		assert.Equal(t, filepath.Join(dir, "alertmanager.yml"), f.Path)
+		assert.NotContains(t, f.Content, "pmm-password")
+		assert.NotContains(t, f.Content, "XXXX")
+		assert.NotContains(t, f.Content, "12345")
+		assert.Contains(t, f.Content, "smtp_auth_username: pmm\n")
+		assert.Contains(t, f.Content, "smtp_auth_password: <secret>\n")
+		assert.Contains(t, f.Content, "url: https://example.com/hook\n")
+
+		f, err = s.Get("ia_rules/rule.yml")
+		require.NoError(t, err)
+		assert.Equal(t, "groups: []\n", f.Content)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := s.Get("victoriametrics")
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `File "victoriametrics" is not generated yet.`), err)
+
+		_, err = s.Get("ia_rules/../secret.yml")
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `Unknown file "ia_rules/../secret.yml".`), err)
+
+		_, err = s.Get("unknown")
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `Unknown file "unknown".`), err)
+	})
+}
@@ -0,0 +1,48 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package management
+
+import (
+	"context"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// AuditService represents audit log API.
+type AuditService struct {
+	db *reform.DB
+}
+
+// NewAuditService creates new audit log API service.
+func NewAuditService(db *reform.DB) *AuditService {
+	return &AuditService{
+		db: db,
+	}
+}
+
+// ListEvents returns audit events satisfying filter, latest first.
+func (s *AuditService) ListEvents(ctx context.Context, filter models.AuditEventsFilter) ([]*models.AuditEvent, error) {
+	var res []*models.AuditEvent
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindAuditEvents(tx.Querier, filter)
+		return err
+	})
+	return res, e
+}
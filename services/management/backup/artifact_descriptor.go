@@ -0,0 +1,226 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// artifactDescriptorVersion is a current version of artifact descriptor format.
+const artifactDescriptorVersion = 1
+
+// ArtifactDescriptor is a portable description of backup artifact.
+// It can be imported into another PMM Server with access to the same backup location,
+// so artifact can be restored from there.
+type ArtifactDescriptor struct {
+	Version     int                `json:"version"`
+	Name        string             `json:"name"`
+	Vendor      string             `json:"vendor"`
+	DataModel   models.DataModel   `json:"data_model"`
+	CreatedAt   time.Time          `json:"created_at"`
+	ServiceName string             `json:"service_name"`
+	ServiceType models.ServiceType `json:"service_type"`
+	Location    ArtifactLocation   `json:"location"`
+	// Empty for artifacts without known software versions.
+	Metadata *models.ArtifactMetadata `json:"metadata,omitempty"`
+}
+
+// ArtifactLocation describes artifact's backup location without credentials.
+type ArtifactLocation struct {
+	Type         models.BackupLocationType `json:"type"`
+	Endpoint     string                    `json:"endpoint"`
+	BucketName   string                    `json:"bucket_name"`
+	BucketRegion string                    `json:"bucket_region,omitempty"`
+}
+
+// matches returns true if location points to the same S3 bucket.
+func (l *ArtifactLocation) matches(location *models.BackupLocation) bool {
+	if location.Type != models.S3BackupLocationType || location.S3Config == nil {
+		return false
+	}
+	return normalizeEndpoint(location.S3Config.Endpoint) == normalizeEndpoint(l.Endpoint) &&
+		location.S3Config.BucketName == l.BucketName
+}
+
+// normalizeEndpoint returns S3 endpoint without scheme and trailing slashes.
+func normalizeEndpoint(endpoint string) string {
+	endpoint = strings.TrimPrefix(endpoint, "https://")
+	endpoint = strings.TrimPrefix(endpoint, "http://")
+	return strings.TrimRight(endpoint, "/")
+}
+
+// ExportArtifact returns portable descriptor of successful artifact stored in S3.
+func (s *ArtifactsService) ExportArtifact(ctx context.Context, artifactID string) (*ArtifactDescriptor, error) {
+	var res *ArtifactDescriptor
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		artifact, err := models.FindArtifactByID(tx.Querier, artifactID)
+		if err != nil {
+			return err
+		}
+		if artifact.Status != models.SuccessBackupStatus {
+			return status.Errorf(codes.FailedPrecondition, "Artifact with status %q can't be exported.", artifact.Status)
+		}
+
+		location, err := models.FindBackupLocationByID(tx.Querier, artifact.LocationID)
+		if err != nil {
+			return err
+		}
+		if location.Type != models.S3BackupLocationType || location.S3Config == nil {
+			return status.Errorf(codes.FailedPrecondition, "Only artifacts stored in S3 can be exported.")
+		}
+
+		service, err := models.FindServiceByID(tx.Querier, artifact.ServiceID)
+		if err != nil {
+			return err
+		}
+
+		res = &ArtifactDescriptor{
+			Version:     artifactDescriptorVersion,
+			Name:        artifact.Name,
+			Vendor:      artifact.Vendor,
+			DataModel:   artifact.DataModel,
+			CreatedAt:   artifact.CreatedAt,
+			ServiceName: service.ServiceName,
+			ServiceType: service.ServiceType,
+			Location: ArtifactLocation{
+				Type:         location.Type,
+				Endpoint:     location.S3Config.Endpoint,
+				BucketName:   location.S3Config.BucketName,
+				BucketRegion: location.S3Config.BucketRegion,
+			},
+			Metadata: artifact.Metadata,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ImportArtifactsParams represents artifacts import parameters.
+type ImportArtifactsParams struct {
+	Descriptors []*ArtifactDescriptor
+	// Location pointing to the same S3 bucket; found by endpoint and bucket name if empty.
+	LocationID string
+	// Service to restore artifacts to; found by name and type from descriptor if empty.
+	ServiceID string
+}
+
+// ImportArtifacts creates artifacts from descriptors exported by another PMM Server.
+// All artifacts are imported in a single transaction.
+func (s *ArtifactsService) ImportArtifacts(ctx context.Context, params *ImportArtifactsParams) ([]*models.Artifact, error) {
+	if len(params.Descriptors) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "No artifact descriptors.")
+	}
+
+	var res []*models.Artifact
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		q := tx.Querier
+
+		var locations []*models.BackupLocation
+		if params.LocationID != "" {
+			location, err := models.FindBackupLocationByID(q, params.LocationID)
+			if err != nil {
+				return err
+			}
+			locations = []*models.BackupLocation{location}
+		} else {
+			var err error
+			if locations, err = models.FindBackupLocations(q); err != nil {
+				return err
+			}
+		}
+
+		var service *models.Service
+		if params.ServiceID != "" {
+			var err error
+			if service, err = models.FindServiceByID(q, params.ServiceID); err != nil {
+				return err
+			}
+		}
+
+		importParams := make([]models.ImportArtifactParams, 0, len(params.Descriptors))
+		for _, d := range params.Descriptors {
+			p, err := importArtifactParams(q, d, locations, service)
+			if err != nil {
+				return err
+			}
+			importParams = append(importParams, *p)
+		}
+
+		var err error
+		res, err = models.ImportArtifacts(q, importParams)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// importArtifactParams returns parameters for importing artifact with given descriptor.
+func importArtifactParams(q *reform.Querier, d *ArtifactDescriptor, locations []*models.BackupLocation, service *models.Service) (*models.ImportArtifactParams, error) {
+	if d.Version != artifactDescriptorVersion {
+		return nil, status.Errorf(codes.InvalidArgument, "Unsupported artifact descriptor version %d.", d.Version)
+	}
+	if d.Location.Type != models.S3BackupLocationType {
+		return nil, status.Errorf(codes.InvalidArgument, "Only artifacts stored in S3 can be imported.")
+	}
+
+	var location *models.BackupLocation
+	for _, l := range locations {
+		if d.Location.matches(l) {
+			location = l
+			break
+		}
+	}
+	if location == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "No backup location for S3 bucket %q at %s.", d.Location.BucketName, d.Location.Endpoint)
+	}
+
+	if service == nil {
+		var err error
+		if service, err = models.FindServiceByName(q, d.ServiceName); err != nil {
+			return nil, err
+		}
+	}
+	if service.ServiceType != d.ServiceType {
+		return nil, status.Errorf(codes.FailedPrecondition, "Artifact %q of %s service can't be imported for %s service %q.",
+			d.Name, d.ServiceType, service.ServiceType, service.ServiceName)
+	}
+
+	return &models.ImportArtifactParams{
+		CreateArtifactParams: models.CreateArtifactParams{
+			Name:       d.Name,
+			Vendor:     d.Vendor,
+			LocationID: location.ID,
+			ServiceID:  service.ServiceID,
+			DataModel:  d.DataModel,
+			Metadata:   d.Metadata,
+		},
+		CreatedAt: d.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,69 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestArtifactLocationMatches(t *testing.T) {
+	l := &ArtifactLocation{
+		Type:       models.S3BackupLocationType,
+		Endpoint:   "https://s3.us-west-2.amazonaws.com/",
+		BucketName: "backups",
+	}
+
+	for _, tc := range []struct {
+		name     string
+		location *models.BackupLocation
+		expected bool
+	}{{
+		name: "same",
+		location: &models.BackupLocation{
+			Type:     models.S3BackupLocationType,
+			S3Config: &models.S3LocationConfig{Endpoint: "https://s3.us-west-2.amazonaws.com/", BucketName: "backups"},
+		},
+		expected: true,
+	}, {
+		name: "without scheme",
+		location: &models.BackupLocation{
+			Type:     models.S3BackupLocationType,
+			S3Config: &models.S3LocationConfig{Endpoint: "s3.us-west-2.amazonaws.com", BucketName: "backups"},
+		},
+		expected: true,
+	}, {
+		name: "other bucket",
+		location: &models.BackupLocation{
+			Type:     models.S3BackupLocationType,
+			S3Config: &models.S3LocationConfig{Endpoint: "https://s3.us-west-2.amazonaws.com/", BucketName: "other"},
+		},
+	}, {
+		name: "local",
+		location: &models.BackupLocation{
+			Type:            models.PMMServerBackupLocationType,
+			PMMServerConfig: &models.PMMServerLocationConfig{Path: "/backups"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, l.matches(tc.location))
+		})
+	}
+}
@@ -19,10 +19,13 @@ package backup
 
 import (
 	"context"
+	"fmt"
 
 	backupv1beta1 "github.com/percona/pmm/api/managementpb/backup"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"gopkg.in/reform.v1"
 
@@ -33,14 +36,16 @@ import (
 type ArtifactsService struct {
 	l          *logrus.Entry
 	db         *reform.DB
+	replica    *models.ReadReplica
 	removalSVC removalService
 }
 
 // NewArtifactsService creates new artifacts API service.
-func NewArtifactsService(db *reform.DB, removalSVC removalService) *ArtifactsService {
+func NewArtifactsService(db *reform.DB, replica *models.ReadReplica, removalSVC removalService) *ArtifactsService {
 	return &ArtifactsService{
 		l:          logrus.WithField("component", "management/backup/artifacts"),
 		db:         db,
+		replica:    replica,
 		removalSVC: removalSVC,
 	}
 }
@@ -57,7 +62,7 @@ func (s *ArtifactsService) Enabled() bool {
 
 // ListArtifacts returns a list of all artifacts.
 func (s *ArtifactsService) ListArtifacts(context.Context, *backupv1beta1.ListArtifactsRequest) (*backupv1beta1.ListArtifactsResponse, error) {
-	q := s.db.Querier
+	q := s.replica.DB().Querier
 
 	artifacts, err := models.FindArtifacts(q, models.ArtifactFilters{})
 	if err != nil {
@@ -111,6 +116,99 @@ func (s *ArtifactsService) DeleteArtifact(
 	return &backupv1beta1.DeleteArtifactResponse{}, nil
 }
 
+// SetArtifactHold places artifact on legal hold or releases it, and records that in the audit log
+// on behalf of the given Grafana user.
+// Artifact on hold is excluded from retention cleanup and can't be deleted.
+func (s *ArtifactsService) SetArtifactHold(ctx context.Context, artifactID string, hold bool, reason, actor string) error {
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		artifact, err := models.FindArtifactByID(tx.Querier, artifactID)
+		switch {
+		case err == nil:
+		case errors.Is(err, models.ErrNotFound):
+			return status.Errorf(codes.NotFound, "Artifact with ID %q not found.", artifactID)
+		default:
+			return err
+		}
+
+		if artifact.Hold == hold {
+			if hold {
+				return status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is already on legal hold.", artifactID)
+			}
+			return status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is not on legal hold.", artifactID)
+		}
+
+		if _, err = models.UpdateArtifact(tx.Querier, artifactID, models.UpdateArtifactParams{Hold: &hold}); err != nil {
+			return err
+		}
+
+		eventType := models.ArtifactHoldAuditEventType
+		msg := fmt.Sprintf("Artifact %q placed on legal hold.", artifact.Name)
+		if !hold {
+			eventType = models.ArtifactHoldReleasedAuditEventType
+			msg = fmt.Sprintf("Legal hold of artifact %q released.", artifact.Name)
+		}
+		if reason != "" {
+			msg += " Reason: " + reason
+		}
+		_, err = models.CreateAuditEvent(tx.Querier, eventType, artifactID, actor, msg)
+		return err
+	})
+}
+
+// SetArtifactGolden marks successful artifact as golden or unmarks it, and records that in the audit log
+// on behalf of the given Grafana user.
+// Golden artifact is a baseline dataset: it is excluded from retention cleanup, can't be deleted,
+// and is usually referenced by its name.
+func (s *ArtifactsService) SetArtifactGolden(ctx context.Context, artifactID string, golden bool, reason, actor string) error {
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		artifact, err := models.FindArtifactByID(tx.Querier, artifactID)
+		switch {
+		case err == nil:
+		case errors.Is(err, models.ErrNotFound):
+			return status.Errorf(codes.NotFound, "Artifact with ID %q not found.", artifactID)
+		default:
+			return err
+		}
+
+		if artifact.Golden == golden {
+			if golden {
+				return status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is already golden.", artifactID)
+			}
+			return status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is not golden.", artifactID)
+		}
+		if golden && artifact.Status != models.SuccessBackupStatus {
+			return status.Errorf(codes.FailedPrecondition, "Artifact with ID %q is not successful.", artifactID)
+		}
+
+		if _, err = models.UpdateArtifact(tx.Querier, artifactID, models.UpdateArtifactParams{Golden: &golden}); err != nil {
+			return err
+		}
+
+		eventType := models.ArtifactGoldenAuditEventType
+		msg := fmt.Sprintf("Artifact %q marked as golden.", artifact.Name)
+		if !golden {
+			eventType = models.ArtifactGoldenRemovedAuditEventType
+			msg = fmt.Sprintf("Artifact %q is no longer golden.", artifact.Name)
+		}
+		if reason != "" {
+			msg += " Reason: " + reason
+		}
+		_, err = models.CreateAuditEvent(tx.Querier, eventType, artifactID, actor, msg)
+		return err
+	})
+}
+
+// ListGoldenArtifacts returns golden artifacts, newest first.
+func (s *ArtifactsService) ListGoldenArtifacts(ctx context.Context) ([]*models.Artifact, error) {
+	var res []*models.Artifact
+	err := s.replica.DB().InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindArtifacts(tx.Querier, models.ArtifactFilters{Golden: true})
+		return err
+	})
+	return res, err
+}
+
 func convertDataModel(dataModel models.DataModel) (*backupv1beta1.DataModel, error) {
 	var dm backupv1beta1.DataModel
 	switch dataModel {
@@ -118,6 +216,9 @@ func convertDataModel(dataModel models.DataModel) (*backupv1beta1.DataModel, err
 		dm = backupv1beta1.DataModel_PHYSICAL
 	case models.LogicalDataModel:
 		dm = backupv1beta1.DataModel_LOGICAL
+	case models.SnapshotDataModel:
+		// not present in API
+		dm = backupv1beta1.DataModel_DATA_MODEL_INVALID
 	default:
 		return nil, errors.Errorf("invalid data model '%s'", dataModel)
 	}
@@ -142,6 +243,12 @@ func convertBackupStatus(status models.BackupStatus) (*backupv1beta1.BackupStatu
 		s = backupv1beta1.BackupStatus_BACKUP_STATUS_DELETING
 	case models.FailedToDeleteBackupStatus:
 		s = backupv1beta1.BackupStatus_BACKUP_STATUS_FAILED_TO_DELETE
+	case models.CanceledBackupStatus:
+		// there is no separate API status for canceled backups yet
+		s = backupv1beta1.BackupStatus_BACKUP_STATUS_ERROR
+	case models.QueuedBackupStatus:
+		// there is no separate API status for queued backups yet
+		s = backupv1beta1.BackupStatus_BACKUP_STATUS_PENDING
 	default:
 		return nil, errors.Errorf("invalid status '%s'", status)
 	}
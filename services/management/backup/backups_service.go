@@ -31,6 +31,7 @@ import (
 	"gopkg.in/reform.v1"
 
 	"github.com/percona/pmm-managed/models"
+	servicesbackup "github.com/percona/pmm-managed/services/backup"
 	"github.com/percona/pmm-managed/services/scheduler"
 )
 
@@ -39,22 +40,38 @@ type BackupsService struct {
 	db              *reform.DB
 	backupService   backupService
 	scheduleService scheduleService
+	alertmanager    alertmanagerService
 	l               *logrus.Entry
 }
 
 // NewBackupsService creates new backups API service.
-func NewBackupsService(db *reform.DB, backupService backupService, scheduleService scheduleService) *BackupsService {
+func NewBackupsService(db *reform.DB, backupService backupService, scheduleService scheduleService, alertmanager alertmanagerService) *BackupsService {
 	return &BackupsService{
 		l:               logrus.WithField("component", "management/backup/backups"),
 		db:              db,
 		backupService:   backupService,
 		scheduleService: scheduleService,
+		alertmanager:    alertmanager,
 	}
 }
 
 // StartBackup starts on-demand backup.
 func (s *BackupsService) StartBackup(ctx context.Context, req *backupv1beta1.StartBackupRequest) (*backupv1beta1.StartBackupResponse, error) {
-	artifactID, err := s.backupService.PerformBackup(ctx, req.ServiceId, req.LocationId, req.Name, "")
+	return s.StartBackupWithDataModel(ctx, req, "")
+}
+
+// StartBackupWithDataModel starts on-demand backup with given data model; the default one is used if it is empty.
+func (s *BackupsService) StartBackupWithDataModel(
+	ctx context.Context,
+	req *backupv1beta1.StartBackupRequest,
+	dataModel models.DataModel,
+) (*backupv1beta1.StartBackupResponse, error) {
+	artifactID, err := s.backupService.PerformBackup(ctx, servicesbackup.PerformBackupParams{
+		ServiceID:  req.ServiceId,
+		LocationID: req.LocationId,
+		Name:       req.Name,
+		DataModel:  dataModel,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -64,13 +81,19 @@ func (s *BackupsService) StartBackup(ctx context.Context, req *backupv1beta1.Sta
 	}, nil
 }
 
+// StartGroupBackup starts on-demand backup of a group of Services as one logical set of artifacts.
+// It returns group ID and IDs of created artifacts.
+func (s *BackupsService) StartGroupBackup(ctx context.Context, params servicesbackup.PerformGroupBackupParams) (string, []string, error) {
+	return s.backupService.PerformGroupBackup(ctx, params)
+}
+
 // RestoreBackup starts restore backup job.
 func (s *BackupsService) RestoreBackup(
 	ctx context.Context,
 	req *backupv1beta1.RestoreBackupRequest,
 ) (*backupv1beta1.RestoreBackupResponse, error) {
 
-	id, err := s.backupService.RestoreBackup(ctx, req.ServiceId, req.ArtifactId)
+	id, err := s.backupService.RestoreBackup(ctx, req.ServiceId, req.ArtifactId, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -80,8 +103,53 @@ func (s *BackupsService) RestoreBackup(
 	}, nil
 }
 
+// RestoreClusterBackup starts restore backup job; if force is true, restore of MySQL cluster member
+// is started even if it could split-brain a healthy cluster. If skipSignatureCheck is true,
+// unsigned artifacts or artifacts with invalid signature are restored too.
+func (s *BackupsService) RestoreClusterBackup(ctx context.Context, serviceID, artifactID string, force, skipSignatureCheck bool) (string, error) {
+	return s.backupService.RestoreBackup(ctx, serviceID, artifactID, force, skipSignatureCheck)
+}
+
+// ClusterRestorePlan returns node-by-node restore plan of the artifact for MySQL cluster of given Service.
+func (s *BackupsService) ClusterRestorePlan(ctx context.Context, serviceID, artifactID string) (*servicesbackup.ClusterRestorePlan, error) {
+	return s.backupService.ClusterRestorePlan(ctx, serviceID, artifactID)
+}
+
+// FindArtifactID returns artifactID if it is not empty, or ID of artifact with given name otherwise,
+// so golden artifacts can be referenced by name.
+func (s *BackupsService) FindArtifactID(artifactID, artifactName string) (string, error) {
+	if artifactID != "" || artifactName == "" {
+		return artifactID, nil
+	}
+
+	artifact, err := models.FindArtifactByName(s.db.Querier, artifactName)
+	switch {
+	case err == nil:
+		return artifact.ID, nil
+	case errors.Is(err, models.ErrNotFound):
+		return "", status.Errorf(codes.NotFound, "Artifact with name %q not found.", artifactName)
+	default:
+		return "", err
+	}
+}
+
+// CancelBackup stops running backup job.
+func (s *BackupsService) CancelBackup(ctx context.Context, artifactID string) error {
+	return s.backupService.CancelBackup(ctx, artifactID)
+}
+
 // ScheduleBackup add new backup task to scheduler.
 func (s *BackupsService) ScheduleBackup(ctx context.Context, req *backupv1beta1.ScheduleBackupRequest) (*backupv1beta1.ScheduleBackupResponse, error) {
+	return s.ScheduleBackupWithDataModel(ctx, req, "")
+}
+
+// ScheduleBackupWithDataModel adds new backup task with given data model to scheduler;
+// the default data model is used if it is empty.
+func (s *BackupsService) ScheduleBackupWithDataModel(
+	ctx context.Context,
+	req *backupv1beta1.ScheduleBackupRequest,
+	dataModel models.DataModel,
+) (*backupv1beta1.ScheduleBackupResponse, error) {
 	var id string
 	err := s.db.InTransaction(func(tx *reform.TX) error {
 		svc, err := models.FindServiceByID(tx.Querier, req.ServiceId)
@@ -89,17 +157,24 @@ func (s *BackupsService) ScheduleBackup(ctx context.Context, req *backupv1beta1.
 			return err
 		}
 
-		_, err = models.FindBackupLocationByID(tx.Querier, req.LocationId)
+		location, err := models.FindBackupLocationByID(tx.Querier, req.LocationId)
 		if err != nil {
 			return err
 		}
 
+		if err = servicesbackup.ValidateDataModel(svc.ServiceType, dataModel); err != nil {
+			return err
+		}
+		if svc.ServiceType == models.MySQLServiceType && dataModel == models.LogicalDataModel && location.S3Config == nil {
+			return status.Error(codes.FailedPrecondition, "MySQL logical backups can be stored only in S3 locations.")
+		}
+
 		var task scheduler.Task
 		switch svc.ServiceType {
 		case models.MySQLServiceType:
-			task = scheduler.NewMySQLBackupTask(s.backupService, req.ServiceId, req.LocationId, req.Name, req.Description, req.Retention)
+			task = scheduler.NewMySQLBackupTask(s.backupService, req.ServiceId, req.LocationId, req.Name, req.Description, req.Retention, dataModel)
 		case models.MongoDBServiceType:
-			task = scheduler.NewMongoBackupTask(s.backupService, req.ServiceId, req.LocationId, req.Name, req.Description, req.Retention)
+			task = scheduler.NewMongoBackupTask(s.backupService, req.ServiceId, req.LocationId, req.Name, req.Description, req.Retention, dataModel)
 		case models.PostgreSQLServiceType,
 			models.ProxySQLServiceType,
 			models.HAProxyServiceType,
@@ -240,6 +315,79 @@ func (s *BackupsService) ChangeScheduledBackup(ctx context.Context, req *backupv
 	return &backupv1beta1.ChangeScheduledBackupResponse{}, nil
 }
 
+// ChangeScheduledBackupNotifications changes notifications settings of existing scheduled backup task;
+// nil notifications disable them.
+func (s *BackupsService) ChangeScheduledBackupNotifications(ctx context.Context, scheduledBackupID string, notifications *models.BackupNotifications) error {
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		scheduledTask, err := models.FindScheduledTaskByID(tx.Querier, scheduledBackupID)
+		if err != nil {
+			return err
+		}
+
+		if notifications != nil {
+			channels, err := models.FindChannelsByIDs(tx.Querier, notifications.ChannelIDs)
+			if err != nil {
+				return err
+			}
+			if len(channels) != len(notifications.ChannelIDs) {
+				return status.Errorf(codes.NotFound, "Failed to find all required channels: %v.", notifications.ChannelIDs)
+			}
+		}
+
+		switch scheduledTask.Type {
+		case models.ScheduledMySQLBackupTask:
+			scheduledTask.Data.MySQLBackupTask.Notifications = notifications
+		case models.ScheduledMongoDBBackupTask:
+			scheduledTask.Data.MongoDBBackupTask.Notifications = notifications
+		default:
+			return status.Errorf(codes.InvalidArgument, "Unknown type: %s", scheduledTask.Type)
+		}
+
+		_, err = models.ChangeScheduledTask(tx.Querier, scheduledBackupID, models.ChangeScheduledTaskParams{
+			Data: scheduledTask.Data,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.alertmanager.RequestConfigurationUpdate()
+	return nil
+}
+
+// EnableScheduledBackup resumes paused scheduled backup task.
+func (s *BackupsService) EnableScheduledBackup(ctx context.Context, scheduledBackupID string) error {
+	if err := s.checkScheduledBackup(scheduledBackupID); err != nil {
+		return err
+	}
+	return s.scheduleService.Enable(scheduledBackupID)
+}
+
+// DisableScheduledBackup pauses scheduled backup task without removing it;
+// cron expression, artifacts and run history are kept.
+func (s *BackupsService) DisableScheduledBackup(ctx context.Context, scheduledBackupID string) error {
+	if err := s.checkScheduledBackup(scheduledBackupID); err != nil {
+		return err
+	}
+	return s.scheduleService.Disable(scheduledBackupID)
+}
+
+// checkScheduledBackup returns an error if scheduled task with given ID does not exist or is not a backup task.
+func (s *BackupsService) checkScheduledBackup(id string) error {
+	task, err := models.FindScheduledTaskByID(s.db.Querier, id)
+	if err != nil {
+		return err
+	}
+	switch task.Type {
+	case models.ScheduledMySQLBackupTask:
+	case models.ScheduledMongoDBBackupTask:
+	default:
+		return status.Errorf(codes.InvalidArgument, "Non-backup task: %s.", task.Type)
+	}
+	return nil
+}
+
 // RemoveScheduledBackup stops and removes existing scheduled backup task.
 func (s *BackupsService) RemoveScheduledBackup(ctx context.Context, req *backupv1beta1.RemoveScheduledBackupRequest) (*backupv1beta1.RemoveScheduledBackupResponse, error) {
 	task, err := models.FindScheduledTaskByID(s.db.Querier, req.ScheduledBackupId)
@@ -309,6 +457,9 @@ func convertTaskToScheduledBackup(task *models.ScheduledTask,
 		backup.Name = data.Name
 		backup.Description = data.Description
 		backup.DataModel = backupv1beta1.DataModel_PHYSICAL
+		if data.DataModel == models.LogicalDataModel {
+			backup.DataModel = backupv1beta1.DataModel_LOGICAL
+		}
 		backup.Retention = data.Retention
 	case models.ScheduledMongoDBBackupTask:
 		data := task.Data.MongoDBBackupTask
@@ -317,6 +468,9 @@ func convertTaskToScheduledBackup(task *models.ScheduledTask,
 		backup.Name = data.Name
 		backup.Description = data.Description
 		backup.DataModel = backupv1beta1.DataModel_LOGICAL
+		if data.DataModel == models.PhysicalDataModel {
+			backup.DataModel = backupv1beta1.DataModel_PHYSICAL
+		}
 		backup.Retention = data.Retention
 	default:
 		return nil, fmt.Errorf("unknown task type: %s", task.Type)
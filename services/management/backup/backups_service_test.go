@@ -72,8 +72,10 @@ func TestScheduledBackups(t *testing.T) {
 	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
 	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
 	backupService := &mockBackupService{}
-	schedulerService := scheduler.New(db, backupService)
-	backupSvc := NewBackupsService(db, backupService, schedulerService)
+	schedulerService := scheduler.New(db, backupService, nil, nil, nil, nil, 0)
+	alertmanager := &mockAlertmanagerService{}
+	alertmanager.On("RequestConfigurationUpdate").Return()
+	backupSvc := NewBackupsService(db, backupService, schedulerService, alertmanager)
 	t.Cleanup(func() {
 		_ = sqlDB.Close()
 	})
@@ -137,6 +139,17 @@ func TestScheduledBackups(t *testing.T) {
 		assert.Equal(t, changeReq.Enabled.GetValue(), !task.Disabled)
 		assert.Equal(t, changeReq.Name.GetValue(), data.Name)
 		assert.Equal(t, changeReq.Description.GetValue(), data.Description)
+
+		notifications := &models.BackupNotifications{OnFailure: true}
+		err = backupSvc.ChangeScheduledBackupNotifications(ctx, task.ID, notifications)
+		require.NoError(t, err)
+		task, err = models.FindScheduledTaskByID(db.Querier, res.ScheduledBackupId)
+		require.NoError(t, err)
+		assert.Equal(t, notifications, task.Data.MySQLBackupTask.Notifications)
+		assert.Equal(t, changeReq.Name.GetValue(), task.Data.MySQLBackupTask.Name)
+
+		err = backupSvc.ChangeScheduledBackupNotifications(ctx, task.ID, &models.BackupNotifications{ChannelIDs: []string{"unknown"}})
+		tests.AssertGRPCError(t, status.New(codes.NotFound, "Failed to find all required channels: [unknown]."), err)
 	})
 
 	t.Run("list", func(t *testing.T) {
@@ -18,25 +18,35 @@ package backup
 
 import (
 	"context"
+	"io"
 
 	"github.com/percona/pmm-managed/models"
+	servicesbackup "github.com/percona/pmm-managed/services/backup"
 	"github.com/percona/pmm-managed/services/scheduler"
+	"github.com/percona/pmm-managed/services/victoriametrics"
 )
 
 //go:generate mockery -name=awsS3 -case=snake -inpkg -testonly
 //go:generate mockery -name=backupService -case=snake -inpkg -testonly
 //go:generate mockery -name=scheduleService -case=snake -inpkg -testonly
 //go:generate mockery -name=removalService -case=snake -inpkg -testonly
+//go:generate mockery -name=alertmanagerService -case=snake -inpkg -testonly
+//go:generate mockery -name=metricsDB -case=snake -inpkg -testonly
 
 type awsS3 interface {
 	GetBucketLocation(ctx context.Context, host string, accessKey, secretKey, name string) (string, error)
 	BucketExists(ctx context.Context, host string, accessKey, secretKey, name string) (bool, error)
 	RemoveRecursive(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) error
+	PutObject(ctx context.Context, endpoint, accessKey, secretKey, bucketName, objectName string, r io.Reader) error
+	GetObject(ctx context.Context, endpoint, accessKey, secretKey, bucketName, objectName string) (io.ReadCloser, error)
 }
 
 type backupService interface {
-	PerformBackup(ctx context.Context, serviceID, locationID, name, scheduleID string) (string, error)
-	RestoreBackup(ctx context.Context, serviceID, artifactID string) (string, error)
+	PerformBackup(ctx context.Context, params servicesbackup.PerformBackupParams) (string, error)
+	PerformGroupBackup(ctx context.Context, params servicesbackup.PerformGroupBackupParams) (string, []string, error)
+	RestoreBackup(ctx context.Context, serviceID, artifactID string, force, skipSignatureCheck bool) (string, error)
+	ClusterRestorePlan(ctx context.Context, serviceID, artifactID string) (*servicesbackup.ClusterRestorePlan, error)
+	CancelBackup(ctx context.Context, artifactID string) error
 }
 
 // schedulerService is a subset of method of scheduler.Service used by this package.
@@ -46,8 +56,23 @@ type scheduleService interface {
 	Add(task scheduler.Task, params scheduler.AddParams) (*models.ScheduledTask, error)
 	Remove(id string) error
 	Update(id string, params models.ChangeScheduledTaskParams) error
+	Enable(id string) error
+	Disable(id string) error
 }
 
 type removalService interface {
 	DeleteArtifact(ctx context.Context, artifactID string, removeFiles bool) error
 }
+
+// alertmanagerService is a subset of methods of alertmanager.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type alertmanagerService interface {
+	RequestConfigurationUpdate()
+}
+
+// metricsDB is a subset of methods of victoriametrics.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type metricsDB interface {
+	Export(ctx context.Context, w io.Writer, params *victoriametrics.ExportParams) error
+	Import(ctx context.Context, r io.Reader, params *victoriametrics.ImportParams) error
+}
@@ -0,0 +1,257 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// ArtifactDetails describes an artifact with fields that are not available in the v1beta1 API.
+type ArtifactDetails struct {
+	ArtifactID   string              `json:"artifact_id"`
+	Name         string              `json:"name"`
+	Vendor       string              `json:"vendor"`
+	LocationID   string              `json:"location_id"`
+	LocationName string              `json:"location_name"`
+	ServiceID    string              `json:"service_id"`
+	ServiceName  string              `json:"service_name"`
+	DataModel    models.DataModel    `json:"data_model"`
+	Status       models.BackupStatus `json:"status"`
+	Type         models.ArtifactType `json:"type"`
+	ScheduleID   string              `json:"schedule_id,omitempty"`
+	// Size in bytes, nil if unknown.
+	Size *int64 `json:"size,omitempty"`
+	// Last reported progress of the running backup job, nil if backup is not running.
+	Progress  *models.JobProgress `json:"progress,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// Validate returns InvalidArgument error if artifact has unknown enum values.
+func (a *ArtifactDetails) Validate() error {
+	if err := a.DataModel.Validate(); err != nil {
+		return err
+	}
+	if err := a.Status.Validate(); err != nil {
+		return err
+	}
+	return a.Type.Validate()
+}
+
+// ScheduledBackupDetails describes a scheduled backup with fields that are not available in the v1beta1 API.
+type ScheduledBackupDetails struct {
+	ScheduledBackupID string                   `json:"scheduled_backup_id"`
+	Name              string                   `json:"name"`
+	Description       string                   `json:"description"`
+	Vendor            string                   `json:"vendor"`
+	ServiceID         string                   `json:"service_id"`
+	ServiceName       string                   `json:"service_name"`
+	LocationID        string                   `json:"location_id"`
+	LocationName      string                   `json:"location_name"`
+	CronExpression    string                   `json:"cron_expression"`
+	Enabled           bool                     `json:"enabled"`
+	Running           bool                     `json:"running"`
+	DataModel         models.DataModel         `json:"data_model"`
+	MisfirePolicy     models.MisfirePolicy     `json:"misfire_policy"`
+	ConcurrencyPolicy models.ConcurrencyPolicy `json:"concurrency_policy"`
+	// How many artifacts to keep; 0 means unlimited.
+	Retention uint32 `json:"retention"`
+	// Error of the last run, empty if it was successful.
+	Error   string     `json:"error,omitempty"`
+	StartAt *time.Time `json:"start_at,omitempty"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// Validate returns InvalidArgument error if scheduled backup has unknown enum values.
+func (b *ScheduledBackupDetails) Validate() error {
+	if err := b.DataModel.Validate(); err != nil {
+		return err
+	}
+	if err := b.MisfirePolicy.Validate(); err != nil {
+		return err
+	}
+	return b.ConcurrencyPolicy.Validate()
+}
+
+// ListArtifactDetails returns all artifacts with progress of running backups.
+func (s *ArtifactsService) ListArtifactDetails(ctx context.Context) ([]*ArtifactDetails, error) {
+	q := s.replica.DB().Querier
+
+	artifacts, err := models.FindArtifacts(q, models.ArtifactFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	locationIDs := make([]string, 0, len(artifacts))
+	serviceIDs := make([]string, 0, len(artifacts))
+	for _, a := range artifacts {
+		locationIDs = append(locationIDs, a.LocationID)
+		if a.ServiceID != "" {
+			serviceIDs = append(serviceIDs, a.ServiceID)
+		}
+	}
+	locations, err := models.FindBackupLocationsByIDs(q, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+	services, err := models.FindServicesByIDs(q, serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*ArtifactDetails, 0, len(artifacts))
+	for _, a := range artifacts {
+		d := &ArtifactDetails{
+			ArtifactID: a.ID,
+			Name:       a.Name,
+			Vendor:     a.Vendor,
+			LocationID: a.LocationID,
+			ServiceID:  a.ServiceID,
+			DataModel:  a.DataModel,
+			Status:     a.Status,
+			Type:       a.Type,
+			ScheduleID: a.ScheduleID,
+			Size:       a.Size,
+			CreatedAt:  a.CreatedAt,
+		}
+		if l, ok := locations[a.LocationID]; ok {
+			d.LocationName = l.Name
+		}
+		if svc, ok := services[a.ServiceID]; ok {
+			d.ServiceName = svc.ServiceName
+		}
+
+		if a.Status == models.PendingBackupStatus || a.Status == models.InProgressBackupStatus {
+			job, err := models.FindBackupJobResultByArtifactID(q, a.ID)
+			switch {
+			case err == nil:
+				if job.Result != nil {
+					d.Progress = job.Result.Progress
+				}
+			case status.Code(err) == codes.NotFound:
+				// job is not started yet or already finished
+			default:
+				return nil, err
+			}
+		}
+
+		if err = d.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "artifact %s", a.ID)
+		}
+		res = append(res, d)
+	}
+
+	return res, nil
+}
+
+// ListScheduledBackupDetails returns all scheduled backups with their retention, data model and policies.
+func (s *BackupsService) ListScheduledBackupDetails(ctx context.Context) ([]*ScheduledBackupDetails, error) {
+	tasks, err := models.FindScheduledTasks(s.db.Querier, models.ScheduledTasksFilter{
+		Types: []models.ScheduledTaskType{
+			models.ScheduledMySQLBackupTask,
+			models.ScheduledMongoDBBackupTask,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	locationIDs := make([]string, 0, len(tasks))
+	serviceIDs := make([]string, 0, len(tasks))
+	res := make([]*ScheduledBackupDetails, 0, len(tasks))
+	for _, task := range tasks {
+		d := &ScheduledBackupDetails{
+			ScheduledBackupID: task.ID,
+			CronExpression:    task.CronExpression,
+			Enabled:           !task.Disabled,
+			Running:           task.Running,
+			MisfirePolicy:     task.MisfirePolicy,
+			ConcurrencyPolicy: task.ConcurrencyPolicy,
+			Error:             task.Error,
+			StartAt:           timePointer(task.StartAt),
+			LastRun:           timePointer(task.LastRun),
+			NextRun:           timePointer(task.NextRun),
+		}
+		if d.MisfirePolicy == "" {
+			d.MisfirePolicy = models.SkipMisfirePolicy
+		}
+		if d.ConcurrencyPolicy == "" {
+			d.ConcurrencyPolicy = models.ForbidConcurrencyPolicy
+		}
+
+		switch task.Type {
+		case models.ScheduledMySQLBackupTask:
+			data := task.Data.MySQLBackupTask
+			d.ServiceID, d.LocationID, d.Name, d.Description, d.Retention = data.ServiceID, data.LocationID, data.Name, data.Description, data.Retention
+			d.DataModel = data.DataModel
+			if d.DataModel == "" {
+				d.DataModel = models.PhysicalDataModel
+			}
+		case models.ScheduledMongoDBBackupTask:
+			data := task.Data.MongoDBBackupTask
+			d.ServiceID, d.LocationID, d.Name, d.Description, d.Retention = data.ServiceID, data.LocationID, data.Name, data.Description, data.Retention
+			d.DataModel = data.DataModel
+			if d.DataModel == "" {
+				d.DataModel = models.LogicalDataModel
+			}
+		default:
+			continue
+		}
+
+		if err = d.Validate(); err != nil {
+			s.l.WithError(err).Warnf("Invalid scheduled backup %s.", task.ID)
+			continue
+		}
+		serviceIDs = append(serviceIDs, d.ServiceID)
+		locationIDs = append(locationIDs, d.LocationID)
+		res = append(res, d)
+	}
+
+	locations, err := models.FindBackupLocationsByIDs(s.db.Querier, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+	services, err := models.FindServicesByIDs(s.db.Querier, serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range res {
+		if svc, ok := services[d.ServiceID]; ok {
+			d.ServiceName = svc.ServiceName
+			d.Vendor = string(svc.ServiceType)
+		}
+		if l, ok := locations[d.LocationID]; ok {
+			d.LocationName = l.Name
+		}
+	}
+
+	return res, nil
+}
+
+func timePointer(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
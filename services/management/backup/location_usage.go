@@ -0,0 +1,104 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// LocationUsage represents storage usage of a backup location.
+type LocationUsage struct {
+	LocationID string `json:"location_id"`
+	ArtifactsUsage
+	Services []*ServiceArtifactsUsage `json:"services"`
+}
+
+// ServiceArtifactsUsage represents storage usage by artifacts of a single Service.
+type ServiceArtifactsUsage struct {
+	// Empty for artifacts of removed Services.
+	ServiceID   string `json:"service_id"`
+	ServiceName string `json:"service_name,omitempty"`
+	ArtifactsUsage
+}
+
+// ArtifactsUsage represents storage usage by artifacts.
+// Sizes of artifacts created before sizes were recorded or stored on the local filesystem are unknown.
+type ArtifactsUsage struct {
+	// Total number of artifacts.
+	Artifacts int `json:"artifacts"`
+	// Number of artifacts with known size.
+	SizedArtifacts int `json:"sized_artifacts"`
+	// Total size of artifacts with known size in bytes.
+	Size int64 `json:"size"`
+}
+
+func (u *ArtifactsUsage) add(other *ArtifactsUsage) {
+	u.Artifacts += other.Artifacts
+	u.SizedArtifacts += other.SizedArtifacts
+	u.Size += other.Size
+}
+
+// GetLocationUsage returns storage usage by artifacts of given backup location, total and per Service.
+func (s *LocationsService) GetLocationUsage(ctx context.Context, locationID string) (*LocationUsage, error) {
+	res := &LocationUsage{
+		LocationID: locationID,
+		Services:   []*ServiceArtifactsUsage{},
+	}
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		usage, err := models.FindArtifactsUsage(tx.Querier, locationID)
+		if err != nil {
+			return err
+		}
+
+		serviceIDs := make([]string, 0, len(usage))
+		for _, u := range usage {
+			if u.ServiceID != "" {
+				serviceIDs = append(serviceIDs, u.ServiceID)
+			}
+		}
+		services, err := models.FindServicesByIDs(tx.Querier, serviceIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, u := range usage {
+			su := &ServiceArtifactsUsage{
+				ServiceID: u.ServiceID,
+				ArtifactsUsage: ArtifactsUsage{
+					Artifacts:      u.Artifacts,
+					SizedArtifacts: u.SizedArtifacts,
+					Size:           u.Size,
+				},
+			}
+			if service, ok := services[u.ServiceID]; ok {
+				su.ServiceName = service.ServiceName
+			}
+			res.Services = append(res.Services, su)
+			res.add(&su.ArtifactsUsage)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
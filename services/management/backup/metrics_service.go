@@ -0,0 +1,208 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	servicesbackup "github.com/percona/pmm-managed/services/backup"
+	"github.com/percona/pmm-managed/services/victoriametrics"
+)
+
+const metricsExportDirPerm = os.FileMode(0o775)
+
+var metricsExportNameRE = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// MetricsService transfers VictoriaMetrics data between servers via backup locations.
+type MetricsService struct {
+	db   *reform.DB
+	vmdb metricsDB
+	s3   awsS3
+	l    *logrus.Entry
+}
+
+// NewMetricsService creates new metrics data export API service.
+func NewMetricsService(db *reform.DB, vmdb metricsDB, s3 awsS3) *MetricsService {
+	return &MetricsService{
+		db:   db,
+		vmdb: vmdb,
+		s3:   s3,
+		l:    logrus.WithField("component", "management/backup/metrics"),
+	}
+}
+
+// ExportMetricsParams represents metrics data export parameters.
+type ExportMetricsParams struct {
+	LocationID string
+	// Export name; file extension is added according to the format.
+	Name string
+	victoriametrics.ExportParams
+}
+
+// ImportMetricsParams represents metrics data import parameters.
+type ImportMetricsParams struct {
+	LocationID string
+	// Name of previous export.
+	Name string
+	victoriametrics.ImportParams
+}
+
+// metricsExportPath returns a path of export with given name and format relative to the location root.
+func metricsExportPath(name string, format victoriametrics.ExportFormat) (string, error) {
+	if !metricsExportNameRE.MatchString(name) {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid export name %q.", name)
+	}
+	return path.Join(servicesbackup.MetricsExportsDir, name+"."+string(format)), nil
+}
+
+// findMetricsLocation returns backup location that can be accessed by PMM Server.
+func (s *MetricsService) findMetricsLocation(locationID string) (*models.BackupLocation, error) {
+	location, err := models.FindBackupLocationByID(s.db.Querier, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if location.Type == models.PMMClientBackupLocationType {
+		return nil, status.Errorf(codes.FailedPrecondition, "Location %q is not accessible by PMM Server.", location.Name)
+	}
+	return location, nil
+}
+
+// ExportMetrics writes series matching given parameters to the backup location
+// and returns a path of written file relative to the location root.
+func (s *MetricsService) ExportMetrics(ctx context.Context, params *ExportMetricsParams) (string, error) {
+	if err := params.ExportParams.Validate(); err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	name, err := metricsExportPath(params.Name, params.Format)
+	if err != nil {
+		return "", err
+	}
+	location, err := s.findMetricsLocation(params.LocationID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.exportToLocation(ctx, location, name, &params.ExportParams); err != nil {
+		return "", err
+	}
+	s.l.Infof("Metrics exported to %q in location %q.", name, location.Name)
+	return name, nil
+}
+
+// exportToLocation streams exported data to the file with given name in the location.
+func (s *MetricsService) exportToLocation(ctx context.Context, location *models.BackupLocation, name string, params *victoriametrics.ExportParams) error {
+	switch {
+	case location.S3Config != nil:
+		c := location.S3Config
+		pr, pw := io.Pipe()
+		exportErr := make(chan error, 1)
+		go func() {
+			err := s.vmdb.Export(ctx, pw, params)
+			pw.CloseWithError(err) //nolint:errcheck
+			exportErr <- err
+		}()
+
+		err := s.s3.PutObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, name, pr)
+		// unblock export if upload failed
+		pr.CloseWithError(err) //nolint:errcheck
+		if e := <-exportErr; e != nil {
+			return e
+		}
+		return err
+
+	case location.PMMServerConfig != nil:
+		p := filepath.Join(location.PMMServerConfig.Path, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), metricsExportDirPerm); err != nil {
+			return errors.WithStack(err)
+		}
+		f, err := os.Create(p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = s.vmdb.Export(ctx, f, params)
+		if e := f.Close(); err == nil {
+			err = errors.WithStack(e)
+		}
+		if err != nil {
+			// do not leave partial data
+			_ = os.Remove(p)
+		}
+		return err
+
+	default:
+		return status.Errorf(codes.FailedPrecondition, "Unsupported location type %q.", location.Type)
+	}
+}
+
+// ImportMetrics writes previously exported data from the backup location to VictoriaMetrics.
+func (s *MetricsService) ImportMetrics(ctx context.Context, params *ImportMetricsParams) error {
+	if err := params.ImportParams.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	name, err := metricsExportPath(params.Name, params.Format)
+	if err != nil {
+		return err
+	}
+	location, err := s.findMetricsLocation(params.LocationID)
+	if err != nil {
+		return err
+	}
+
+	if err = s.importFromLocation(ctx, location, name, &params.ImportParams); err != nil {
+		return err
+	}
+	s.l.Infof("Metrics imported from %q in location %q.", name, location.Name)
+	return nil
+}
+
+// importFromLocation streams the file with given name in the location to VictoriaMetrics.
+func (s *MetricsService) importFromLocation(ctx context.Context, location *models.BackupLocation, name string, params *victoriametrics.ImportParams) error {
+	var r io.ReadCloser
+	var err error
+	switch {
+	case location.S3Config != nil:
+		c := location.S3Config
+		r, err = s.s3.GetObject(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName, name)
+	case location.PMMServerConfig != nil:
+		r, err = os.Open(filepath.Join(location.PMMServerConfig.Path, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			return status.Errorf(codes.NotFound, "Export %q not found in location %q.", name, location.Name)
+		}
+		err = errors.WithStack(err)
+	default:
+		return status.Errorf(codes.FailedPrecondition, "Unsupported location type %q.", location.Type)
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close() //nolint:errcheck
+
+	return s.vmdb.Import(ctx, r, params)
+}
@@ -0,0 +1,123 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/victoriametrics"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestMetricsExportPath(t *testing.T) {
+	p, err := metricsExportPath("prod-2021.10", victoriametrics.NativeExportFormat)
+	require.NoError(t, err)
+	assert.Equal(t, "pmm_metrics_exports/prod-2021.10.native", p)
+
+	for _, name := range []string{"", "../etc/passwd", ".hidden", "a/b"} {
+		_, err = metricsExportPath(name, victoriametrics.CSVExportFormat)
+		tests.AssertGRPCError(t, status.Newf(codes.InvalidArgument, "Invalid export name %q.", name), err)
+	}
+}
+
+func TestMetricsTransfer(t *testing.T) {
+	ctx := context.Background()
+	exportParams := &victoriametrics.ExportParams{Match: []string{"up"}, Format: victoriametrics.NativeExportFormat}
+	importParams := &victoriametrics.ImportParams{Format: victoriametrics.NativeExportFormat}
+	name := "pmm_metrics_exports/test.native"
+
+	writeData := func(args mock.Arguments) {
+		_, _ = io.WriteString(args.Get(1).(io.Writer), "data")
+	}
+	readData := func(args mock.Arguments) {
+		b, _ := ioutil.ReadAll(args.Get(1).(io.Reader))
+		assert.Equal(t, "data", string(b))
+	}
+
+	t.Run("PMMServer", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "pmm-metrics-")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir) //nolint:errcheck
+
+		vmdb := &mockMetricsDB{}
+		vmdb.On("Export", ctx, mock.Anything, exportParams).Run(writeData).Return(nil).Once()
+		vmdb.On("Import", ctx, mock.Anything, importParams).Run(readData).Return(nil).Once()
+		defer vmdb.AssertExpectations(t)
+
+		s := NewMetricsService(nil, vmdb, &mockAwsS3{})
+		location := &models.BackupLocation{
+			Name:            "local",
+			Type:            models.PMMServerBackupLocationType,
+			PMMServerConfig: &models.PMMServerLocationConfig{Path: dir},
+		}
+
+		require.NoError(t, s.exportToLocation(ctx, location, name, exportParams))
+		b, err := ioutil.ReadFile(filepath.Join(dir, "pmm_metrics_exports", "test.native"))
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(b))
+
+		require.NoError(t, s.importFromLocation(ctx, location, name, importParams))
+
+		err = s.importFromLocation(ctx, location, "pmm_metrics_exports/missing.native", importParams)
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `Export "pmm_metrics_exports/missing.native" not found in location "local".`), err)
+	})
+
+	t.Run("S3", func(t *testing.T) {
+		vmdb := &mockMetricsDB{}
+		vmdb.On("Export", ctx, mock.Anything, exportParams).Run(writeData).Return(nil).Once()
+		vmdb.On("Import", ctx, mock.Anything, importParams).Run(readData).Return(nil).Once()
+		defer vmdb.AssertExpectations(t)
+
+		s3 := &mockAwsS3{}
+		s3.On("PutObject", ctx, "https://s3.us-west-2.amazonaws.com/", "access_key", "secret_key", "bucket", name, mock.Anything).
+			Run(func(args mock.Arguments) {
+				b, _ := ioutil.ReadAll(args.Get(6).(io.Reader))
+				assert.Equal(t, "data", string(b))
+			}).Return(nil).Once()
+		s3.On("GetObject", ctx, "https://s3.us-west-2.amazonaws.com/", "access_key", "secret_key", "bucket", name).
+			Return(ioutil.NopCloser(strings.NewReader("data")), nil).Once()
+		defer s3.AssertExpectations(t)
+
+		s := NewMetricsService(nil, vmdb, s3)
+		location := &models.BackupLocation{
+			Name: "s3",
+			Type: models.S3BackupLocationType,
+			S3Config: &models.S3LocationConfig{
+				Endpoint:   "https://s3.us-west-2.amazonaws.com/",
+				AccessKey:  "access_key",
+				SecretKey:  "secret_key",
+				BucketName: "bucket",
+			},
+		}
+
+		require.NoError(t, s.exportToLocation(ctx, location, name, exportParams))
+		require.NoError(t, s.importFromLocation(ctx, location, name, importParams))
+	})
+}
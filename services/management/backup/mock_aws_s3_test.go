@@ -4,6 +4,7 @@ package backup
 
 import (
 	context "context"
+	io "io"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -55,6 +56,43 @@ func (_m *mockAwsS3) GetBucketLocation(ctx context.Context, host string, accessK
 	return r0, r1
 }
 
+// GetObject provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, objectName
+func (_m *mockAwsS3) GetObject(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, objectName string) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, objectName)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string) io.ReadCloser); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, objectName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, endpoint, accessKey, secretKey, bucketName, objectName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutObject provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, objectName, r
+func (_m *mockAwsS3) PutObject(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, objectName string, r io.Reader) error {
+	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, objectName, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string, io.Reader) error); ok {
+		r0 = rf(ctx, endpoint, accessKey, secretKey, bucketName, objectName, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RemoveRecursive provides a mock function with given fields: ctx, endpoint, accessKey, secretKey, bucketName, prefix
 func (_m *mockAwsS3) RemoveRecursive(ctx context.Context, endpoint string, accessKey string, secretKey string, bucketName string, prefix string) error {
 	ret := _m.Called(ctx, endpoint, accessKey, secretKey, bucketName, prefix)
@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	servicesbackup "github.com/percona/pmm-managed/services/backup"
 )
 
 // mockBackupService is an autogenerated mock type for the backupService type
@@ -13,20 +15,57 @@ type mockBackupService struct {
 	mock.Mock
 }
 
-// PerformBackup provides a mock function with given fields: ctx, serviceID, locationID, name, scheduleID
-func (_m *mockBackupService) PerformBackup(ctx context.Context, serviceID string, locationID string, name string, scheduleID string) (string, error) {
-	ret := _m.Called(ctx, serviceID, locationID, name, scheduleID)
+// CancelBackup provides a mock function with given fields: ctx, artifactID
+func (_m *mockBackupService) CancelBackup(ctx context.Context, artifactID string) error {
+	ret := _m.Called(ctx, artifactID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, artifactID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClusterRestorePlan provides a mock function with given fields: ctx, serviceID, artifactID
+func (_m *mockBackupService) ClusterRestorePlan(ctx context.Context, serviceID string, artifactID string) (*servicesbackup.ClusterRestorePlan, error) {
+	ret := _m.Called(ctx, serviceID, artifactID)
+
+	var r0 *servicesbackup.ClusterRestorePlan
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *servicesbackup.ClusterRestorePlan); ok {
+		r0 = rf(ctx, serviceID, artifactID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*servicesbackup.ClusterRestorePlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, serviceID, artifactID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PerformBackup provides a mock function with given fields: ctx, params
+func (_m *mockBackupService) PerformBackup(ctx context.Context, params servicesbackup.PerformBackupParams) (string, error) {
+	ret := _m.Called(ctx, params)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) string); ok {
-		r0 = rf(ctx, serviceID, locationID, name, scheduleID)
+	if rf, ok := ret.Get(0).(func(context.Context, servicesbackup.PerformBackupParams) string); ok {
+		r0 = rf(ctx, params)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
-		r1 = rf(ctx, serviceID, locationID, name, scheduleID)
+	if rf, ok := ret.Get(1).(func(context.Context, servicesbackup.PerformBackupParams) error); ok {
+		r1 = rf(ctx, params)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,20 +73,50 @@ func (_m *mockBackupService) PerformBackup(ctx context.Context, serviceID string
 	return r0, r1
 }
 
-// RestoreBackup provides a mock function with given fields: ctx, serviceID, artifactID
-func (_m *mockBackupService) RestoreBackup(ctx context.Context, serviceID string, artifactID string) (string, error) {
-	ret := _m.Called(ctx, serviceID, artifactID)
+// PerformGroupBackup provides a mock function with given fields: ctx, params
+func (_m *mockBackupService) PerformGroupBackup(ctx context.Context, params servicesbackup.PerformGroupBackupParams) (string, []string, error) {
+	ret := _m.Called(ctx, params)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, serviceID, artifactID)
+	if rf, ok := ret.Get(0).(func(context.Context, servicesbackup.PerformGroupBackupParams) string); ok {
+		r0 = rf(ctx, params)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 []string
+	if rf, ok := ret.Get(1).(func(context.Context, servicesbackup.PerformGroupBackupParams) []string); ok {
+		r1 = rf(ctx, params)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]string)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, servicesbackup.PerformGroupBackupParams) error); ok {
+		r2 = rf(ctx, params)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// RestoreBackup provides a mock function with given fields: ctx, serviceID, artifactID, force, skipSignatureCheck
+func (_m *mockBackupService) RestoreBackup(ctx context.Context, serviceID string, artifactID string, force bool, skipSignatureCheck bool) (string, error) {
+	ret := _m.Called(ctx, serviceID, artifactID, force, skipSignatureCheck)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, bool) string); ok {
+		r0 = rf(ctx, serviceID, artifactID, force, skipSignatureCheck)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, serviceID, artifactID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool, bool) error); ok {
+		r1 = rf(ctx, serviceID, artifactID, force, skipSignatureCheck)
 	} else {
 		r1 = ret.Error(1)
 	}
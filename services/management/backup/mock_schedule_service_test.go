@@ -40,6 +40,34 @@ func (_m *mockScheduleService) Add(task scheduler.Task, params scheduler.AddPara
 	return r0, r1
 }
 
+// Disable provides a mock function with given fields: id
+func (_m *mockScheduleService) Disable(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Enable provides a mock function with given fields: id
+func (_m *mockScheduleService) Enable(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Remove provides a mock function with given fields: id
 func (_m *mockScheduleService) Remove(id string) error {
 	ret := _m.Called(id)
@@ -19,6 +19,7 @@ package backup
 
 import (
 	"context"
+	"time"
 
 	backupv1beta1 "github.com/percona/pmm/api/managementpb/backup"
 	"github.com/pkg/errors"
@@ -53,46 +54,47 @@ func (s *RestoreHistoryService) Enabled() bool {
 	return settings.BackupManagement.Enabled
 }
 
-// ListRestoreHistory returns a list of restore history.
-func (s *RestoreHistoryService) ListRestoreHistory(
-	context.Context,
-	*backupv1beta1.ListRestoreHistoryRequest,
-) (*backupv1beta1.ListRestoreHistoryResponse, error) {
-	var items []*models.RestoreHistoryItem
-	var services map[string]*models.Service
-	var artifacts map[string]*models.Artifact
-	var locations map[string]*models.BackupLocation
+// restoreHistory contains restore history items with related objects.
+type restoreHistory struct {
+	items     []*models.RestoreHistoryItem
+	services  map[string]*models.Service
+	artifacts map[string]*models.Artifact
+	locations map[string]*models.BackupLocation
+}
 
+// findRestoreHistory returns restore history items matching filters with related objects.
+func (s *RestoreHistoryService) findRestoreHistory(filters models.RestoreHistoryItemFilters) (*restoreHistory, error) {
+	res := new(restoreHistory)
 	err := s.db.InTransaction(func(tx *reform.TX) error {
 		q := tx.Querier
 
 		var err error
-		items, err = models.FindRestoreHistoryItems(q, models.RestoreHistoryItemFilters{})
+		res.items, err = models.FindRestoreHistoryItems(q, filters)
 		if err != nil {
 			return err
 		}
 
-		artifactIDs := make([]string, 0, len(items))
-		serviceIDs := make([]string, 0, len(items))
-		for _, i := range items {
+		artifactIDs := make([]string, 0, len(res.items))
+		serviceIDs := make([]string, 0, len(res.items))
+		for _, i := range res.items {
 			artifactIDs = append(artifactIDs, i.ArtifactID)
 			serviceIDs = append(serviceIDs, i.ServiceID)
 		}
-		artifacts, err = models.FindArtifactsByIDs(q, artifactIDs)
+		res.artifacts, err = models.FindArtifactsByIDs(q, artifactIDs)
 		if err != nil {
 			return err
 		}
 
-		locationIDs := make([]string, 0, len(artifacts))
-		for _, a := range artifacts {
+		locationIDs := make([]string, 0, len(res.artifacts))
+		for _, a := range res.artifacts {
 			locationIDs = append(locationIDs, a.LocationID)
 		}
-		locations, err = models.FindBackupLocationsByIDs(q, locationIDs)
+		res.locations, err = models.FindBackupLocationsByIDs(q, locationIDs)
 		if err != nil {
 			return err
 		}
 
-		services, err = models.FindServicesByIDs(q, serviceIDs)
+		res.services, err = models.FindServicesByIDs(q, serviceIDs)
 		if err != nil {
 			return err
 		}
@@ -102,10 +104,22 @@ func (s *RestoreHistoryService) ListRestoreHistory(
 	if err != nil {
 		return nil, err
 	}
+	return res, nil
+}
 
-	artifactsResponse := make([]*backupv1beta1.RestoreHistoryItem, 0, len(artifacts))
-	for _, i := range items {
-		convertedArtifact, err := convertRestoreHistoryItem(i, services, artifacts, locations)
+// ListRestoreHistory returns a list of restore history.
+func (s *RestoreHistoryService) ListRestoreHistory(
+	context.Context,
+	*backupv1beta1.ListRestoreHistoryRequest,
+) (*backupv1beta1.ListRestoreHistoryResponse, error) {
+	history, err := s.findRestoreHistory(models.RestoreHistoryItemFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	artifactsResponse := make([]*backupv1beta1.RestoreHistoryItem, 0, len(history.items))
+	for _, i := range history.items {
+		convertedArtifact, err := convertRestoreHistoryItem(i, history.services, history.artifacts, history.locations)
 		if err != nil {
 			return nil, err
 		}
@@ -117,6 +131,63 @@ func (s *RestoreHistoryService) ListRestoreHistory(
 	}, nil
 }
 
+// RestoreHistoryDetails represents restore history item with phases timeline and log tail.
+type RestoreHistoryDetails struct {
+	RestoreID    string                      `json:"restore_id"`
+	ArtifactID   string                      `json:"artifact_id"`
+	Name         string                      `json:"name"`
+	Vendor       string                      `json:"vendor"`
+	LocationID   string                      `json:"location_id"`
+	LocationName string                      `json:"location_name"`
+	ServiceID    string                      `json:"service_id"`
+	ServiceName  string                      `json:"service_name"`
+	DataModel    models.DataModel            `json:"data_model"`
+	Status       models.RestoreStatus        `json:"status"`
+	StartedAt    time.Time                   `json:"started_at"`
+	FinishedAt   *time.Time                  `json:"finished_at,omitempty"`
+	Timeline     []*models.RestorePhaseTimes `json:"timeline,omitempty"`
+	LogTail      string                      `json:"log_tail,omitempty"`
+}
+
+// ListRestoreHistoryDetails returns restore history items matching filters with phases timeline and log tail.
+func (s *RestoreHistoryService) ListRestoreHistoryDetails(ctx context.Context, filters models.RestoreHistoryItemFilters) ([]*RestoreHistoryDetails, error) {
+	history, err := s.findRestoreHistory(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*RestoreHistoryDetails, 0, len(history.items))
+	for _, i := range history.items {
+		d := &RestoreHistoryDetails{
+			RestoreID:  i.ID,
+			ArtifactID: i.ArtifactID,
+			ServiceID:  i.ServiceID,
+			Status:     i.Status,
+			StartedAt:  i.StartedAt,
+			FinishedAt: i.FinishedAt,
+			LogTail:    i.LogTail,
+		}
+		if i.Timeline != nil {
+			d.Timeline = i.Timeline.Phases
+		}
+		// artifact, location and service may be removed already
+		if a, ok := history.artifacts[i.ArtifactID]; ok {
+			d.Name = a.Name
+			d.Vendor = a.Vendor
+			d.LocationID = a.LocationID
+			d.DataModel = a.DataModel
+			if l, ok := history.locations[a.LocationID]; ok {
+				d.LocationName = l.Name
+			}
+		}
+		if svc, ok := history.services[i.ServiceID]; ok {
+			d.ServiceName = svc.ServiceName
+		}
+		res = append(res, d)
+	}
+	return res, nil
+}
+
 func convertRestoreStatus(status models.RestoreStatus) (*backupv1beta1.RestoreStatus, error) {
 	var s backupv1beta1.RestoreStatus
 	switch status {
@@ -0,0 +1,127 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// enumValues contains all valid values of typed enums used in JSON API models, in the same order as constants.
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(models.DataModel("")): {
+		string(models.PhysicalDataModel),
+		string(models.LogicalDataModel),
+		string(models.SnapshotDataModel),
+	},
+	reflect.TypeOf(models.BackupStatus("")): {
+		string(models.PendingBackupStatus),
+		string(models.InProgressBackupStatus),
+		string(models.PausedBackupStatus),
+		string(models.SuccessBackupStatus),
+		string(models.ErrorBackupStatus),
+		string(models.DeletingBackupStatus),
+		string(models.FailedToDeleteBackupStatus),
+		string(models.CanceledBackupStatus),
+		string(models.QueuedBackupStatus),
+	},
+	reflect.TypeOf(models.ArtifactType("")): {
+		string(models.OnDemandArtifactType),
+		string(models.ScheduledArtifactType),
+	},
+	reflect.TypeOf(models.MisfirePolicy("")): {
+		string(models.SkipMisfirePolicy),
+		string(models.RunOnceMisfirePolicy),
+		string(models.RunAllMisfirePolicy),
+	},
+	reflect.TypeOf(models.ConcurrencyPolicy("")): {
+		string(models.AllowConcurrencyPolicy),
+		string(models.ForbidConcurrencyPolicy),
+		string(models.ReplaceConcurrencyPolicy),
+	},
+}
+
+// SwaggerSpec returns OpenAPI 2.0 specification of JSON API models for artifacts and scheduled backups.
+// It is generated from Go types, so enums and fields of clients generated from it never diverge from the server.
+func SwaggerSpec() map[string]interface{} {
+	definitions := make(map[string]interface{})
+	swaggerSchema(reflect.TypeOf(ArtifactDetails{}), definitions)
+	swaggerSchema(reflect.TypeOf(ScheduledBackupDetails{}), definitions)
+
+	return map[string]interface{}{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"title":   "PMM Backup JSON API models",
+			"version": "v1",
+		},
+		"paths":       map[string]interface{}{},
+		"definitions": definitions,
+	}
+}
+
+// swaggerSchema returns schema for the given type, adding definitions of structs to the given map.
+func swaggerSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if values, ok := enumValues[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int32, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": swaggerSchema(t.Elem(), definitions)}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := definitions[name]; !ok {
+			properties := make(map[string]interface{}, t.NumField())
+			definitions[name] = map[string]interface{}{"type": "object", "properties": properties}
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				tag := strings.Split(f.Tag.Get("json"), ",")[0]
+				if tag == "" || tag == "-" {
+					continue
+				}
+				properties[tag] = swaggerSchema(f.Type, definitions)
+			}
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	default:
+		panic("unhandled type " + t.String())
+	}
+}
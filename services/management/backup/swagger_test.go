@@ -0,0 +1,96 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package backup
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestSwaggerSpec(t *testing.T) {
+	t.Run("EnumsAreValid", func(t *testing.T) {
+		validators := map[reflect.Type]func(string) error{
+			reflect.TypeOf(models.DataModel("")):         func(v string) error { return models.DataModel(v).Validate() },
+			reflect.TypeOf(models.BackupStatus("")):      func(v string) error { return models.BackupStatus(v).Validate() },
+			reflect.TypeOf(models.ArtifactType("")):      func(v string) error { return models.ArtifactType(v).Validate() },
+			reflect.TypeOf(models.MisfirePolicy("")):     func(v string) error { return models.MisfirePolicy(v).Validate() },
+			reflect.TypeOf(models.ConcurrencyPolicy("")): func(v string) error { return models.ConcurrencyPolicy(v).Validate() },
+		}
+		require.Len(t, enumValues, len(validators))
+		for typ, values := range enumValues {
+			validate := validators[typ]
+			require.NotNil(t, validate, typ.String())
+			for _, v := range values {
+				assert.NoError(t, validate(v), "%s %q", typ, v)
+			}
+			assert.Error(t, validate("unknown"), typ.String())
+		}
+	})
+
+	t.Run("Definitions", func(t *testing.T) {
+		b, err := json.Marshal(SwaggerSpec())
+		require.NoError(t, err)
+
+		var spec struct {
+			Definitions map[string]struct {
+				Properties map[string]struct {
+					Type   string   `json:"type"`
+					Format string   `json:"format"`
+					Enum   []string `json:"enum"`
+					Ref    string   `json:"$ref"`
+				} `json:"properties"`
+			} `json:"definitions"`
+		}
+		require.NoError(t, json.Unmarshal(b, &spec))
+
+		artifact := spec.Definitions["ArtifactDetails"].Properties
+		assert.Equal(t, []string{"physical", "logical", "snapshot"}, artifact["data_model"].Enum)
+		assert.Equal(t, []string{"on_demand", "scheduled"}, artifact["type"].Enum)
+		assert.Contains(t, artifact["status"].Enum, "in_progress")
+		assert.Equal(t, "int64", artifact["size"].Format)
+		assert.Equal(t, "#/definitions/JobProgress", artifact["progress"].Ref)
+		assert.Equal(t, "double", spec.Definitions["JobProgress"].Properties["percentage"].Format)
+
+		scheduled := spec.Definitions["ScheduledBackupDetails"].Properties
+		assert.Equal(t, "int32", scheduled["retention"].Format)
+		assert.Equal(t, []string{"skip", "run_once", "run_all"}, scheduled["misfire_policy"].Enum)
+		assert.Equal(t, []string{"allow", "forbid", "replace"}, scheduled["concurrency_policy"].Enum)
+		assert.Equal(t, "date-time", scheduled["next_run"].Format)
+	})
+}
+
+func TestDetailsValidate(t *testing.T) {
+	a := &ArtifactDetails{DataModel: models.LogicalDataModel, Status: models.SuccessBackupStatus, Type: models.OnDemandArtifactType}
+	assert.NoError(t, a.Validate())
+	a.Type = "manual"
+	assert.Error(t, a.Validate())
+
+	b := &ScheduledBackupDetails{
+		DataModel:         models.PhysicalDataModel,
+		MisfirePolicy:     models.SkipMisfirePolicy,
+		ConcurrencyPolicy: models.ForbidConcurrencyPolicy,
+	}
+	assert.NoError(t, b.Validate())
+	b.MisfirePolicy = "never"
+	assert.Error(t, b.Validate())
+}
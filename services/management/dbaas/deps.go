@@ -28,6 +28,7 @@ import (
 //go:generate mockery -name=dbaasClient -case=snake -inpkg -testonly
 //go:generate mockery -name=versionService -case=snake -inpkg -testonly
 //go:generate mockery -name=grafanaClient -case=snake -inpkg -testonly
+//go:generate mockery -name=backupService -case=snake -inpkg -testonly
 
 type dbaasClient interface {
 	// CheckKubernetesClusterConnection checks connection to Kubernetes cluster and returns statuses of the cluster and operators.
@@ -82,3 +83,9 @@ type grafanaClient interface {
 	DeleteAPIKeysWithPrefix(ctx context.Context, name string) error
 	DeleteAPIKeyByID(ctx context.Context, id int64) error
 }
+
+// backupService is a subset of methods of backup.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type backupService interface {
+	RestoreBackup(ctx context.Context, serviceID, artifactID string, force, skipSignatureCheck bool) (string, error)
+}
@@ -0,0 +1,386 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package dbaas
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	dbaascontrollerv1beta1 "github.com/percona-platform/dbaas-api/gen/controller"
+	dbaasv1beta1 "github.com/percona/pmm/api/managementpb/dbaas"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	newClusterPollInterval = 10 * time.Second
+	// Maximal time of cluster provisioning and registration for monitoring.
+	newClusterRestoreTimeout = time.Hour
+)
+
+// NewClusterRestoreStatus represents status of backup restore to new DBaaS cluster.
+type NewClusterRestoreStatus string
+
+// Available restore statuses.
+const (
+	// ProvisioningNewClusterRestoreStatus means that cluster is created, but not ready yet.
+	ProvisioningNewClusterRestoreStatus NewClusterRestoreStatus = "provisioning"
+	// RegisteringNewClusterRestoreStatus means that cluster is ready, but its Services are not registered for monitoring yet.
+	RegisteringNewClusterRestoreStatus NewClusterRestoreStatus = "registering"
+	// RestoringNewClusterRestoreStatus means that restore job is started; see restore history item for its status.
+	RestoringNewClusterRestoreStatus NewClusterRestoreStatus = "restoring"
+	// FailedNewClusterRestoreStatus means that cluster was not provisioned or restore was not started.
+	FailedNewClusterRestoreStatus NewClusterRestoreStatus = "failed"
+)
+
+// NewClusterRestore represents backup restore to new DBaaS cluster.
+type NewClusterRestore struct {
+	ID                    string                  `json:"id"`
+	ArtifactID            string                  `json:"artifact_id"`
+	KubernetesClusterName string                  `json:"kubernetes_cluster_name"`
+	ClusterName           string                  `json:"cluster_name"`
+	ServiceType           models.ServiceType      `json:"service_type"`
+	Status                NewClusterRestoreStatus `json:"status"`
+	Error                 string                  `json:"error,omitempty"`
+	// Service of the new cluster the artifact is restored to and restore history item; empty until restore is started.
+	ServiceID     string               `json:"service_id,omitempty"`
+	RestoreID     string               `json:"restore_id,omitempty"`
+	RestoreStatus models.RestoreStatus `json:"restore_status,omitempty"`
+	// Connection parameters of the new cluster; nil until cluster is ready.
+	Endpoint  *NewClusterEndpoint `json:"endpoint,omitempty"`
+	StartedAt time.Time           `json:"started_at"`
+}
+
+// NewClusterEndpoint contains connection parameters of new DBaaS cluster.
+type NewClusterEndpoint struct {
+	Host       string `json:"host"`
+	Port       int32  `json:"port"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	Replicaset string `json:"replicaset,omitempty"`
+}
+
+// RestoreToNewClusterParams contains parameters of backup restore to new DBaaS cluster.
+type RestoreToNewClusterParams struct {
+	ArtifactID string
+	// Parameters of created cluster: XtraDB cluster for MySQL artifacts, PSMDB cluster for MongoDB artifacts.
+	XtraDBCluster *dbaasv1beta1.CreateXtraDBClusterRequest
+	PSMDBCluster  *dbaasv1beta1.CreatePSMDBClusterRequest
+}
+
+// RestoreService restores backup artifacts to new DBaaS clusters.
+type RestoreService struct {
+	db               *reform.DB
+	l                *logrus.Entry
+	controllerClient dbaasClient
+	xtradb           *XtraDBClusterService
+	psmdb            *PSMDBClusterService
+	backupService    backupService
+	pollInterval     time.Duration
+
+	rw       sync.RWMutex
+	restores map[string]*NewClusterRestore
+}
+
+// NewRestoreService creates new RestoreService.
+func NewRestoreService(db *reform.DB, client dbaasClient, grafanaClient grafanaClient, backupService backupService) *RestoreService {
+	return &RestoreService{
+		db:               db,
+		l:                logrus.WithField("component", "dbaas_restore"),
+		controllerClient: client,
+		xtradb:           NewXtraDBClusterService(db, client, grafanaClient).(*XtraDBClusterService),
+		psmdb:            NewPSMDBClusterService(db, client, grafanaClient).(*PSMDBClusterService),
+		backupService:    backupService,
+		pollInterval:     newClusterPollInterval,
+		restores:         make(map[string]*NewClusterRestore),
+	}
+}
+
+// RestoreToNewCluster creates new DBaaS cluster and restores given artifact to it
+// once cluster is ready and registered for monitoring.
+// It returns restore ID; progress is reported by GetNewClusterRestore.
+func (s *RestoreService) RestoreToNewCluster(ctx context.Context, params *RestoreToNewClusterParams) (string, error) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return "", err
+	}
+	if !settings.DBaaS.Enabled {
+		return "", status.Error(codes.FailedPrecondition, "DBaaS is disabled.")
+	}
+	if settings.PMMPublicAddress == "" {
+		return "", status.Error(codes.FailedPrecondition, "PMM public address is required to register new cluster for monitoring.")
+	}
+
+	artifact, err := models.FindArtifactByID(s.db.Querier, params.ArtifactID)
+	if err != nil {
+		return "", err
+	}
+	if artifact.Status != models.SuccessBackupStatus {
+		return "", status.Errorf(codes.FailedPrecondition, "Artifact %q is not successfully created.", artifact.Name)
+	}
+
+	r := &NewClusterRestore{
+		ID:          "/new_cluster_restore_id/" + uuid.New().String(),
+		ArtifactID:  artifact.ID,
+		ServiceType: models.ServiceType(artifact.Vendor),
+		Status:      ProvisioningNewClusterRestoreStatus,
+		StartedAt:   models.Now(),
+	}
+	switch r.ServiceType {
+	case models.MySQLServiceType:
+		if params.XtraDBCluster == nil || params.PSMDBCluster != nil {
+			return "", status.Error(codes.InvalidArgument, "XtraDB cluster parameters are required for MySQL artifact.")
+		}
+		r.KubernetesClusterName = params.XtraDBCluster.KubernetesClusterName
+		r.ClusterName = params.XtraDBCluster.Name
+	case models.MongoDBServiceType:
+		if params.PSMDBCluster == nil || params.XtraDBCluster != nil {
+			return "", status.Error(codes.InvalidArgument, "PSMDB cluster parameters are required for MongoDB artifact.")
+		}
+		r.KubernetesClusterName = params.PSMDBCluster.KubernetesClusterName
+		r.ClusterName = params.PSMDBCluster.Name
+	default:
+		return "", status.Errorf(codes.FailedPrecondition, "Restore of %s artifact to new cluster is not supported.", artifact.Vendor)
+	}
+
+	kubernetesCluster, err := models.FindKubernetesClusterByName(s.db.Querier, r.KubernetesClusterName)
+	if err != nil {
+		return "", err
+	}
+
+	if r.ServiceType == models.MySQLServiceType {
+		_, err = s.xtradb.CreateXtraDBCluster(ctx, params.XtraDBCluster)
+	} else {
+		_, err = s.psmdb.CreatePSMDBCluster(ctx, params.PSMDBCluster)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.rw.Lock()
+	s.restores[r.ID] = r
+	s.rw.Unlock()
+
+	go s.run(r, kubernetesCluster.KubeConfig)
+
+	return r.ID, nil
+}
+
+// GetNewClusterRestore returns restore to new DBaaS cluster by ID with connection parameters of that cluster.
+func (s *RestoreService) GetNewClusterRestore(ctx context.Context, id string) (*NewClusterRestore, error) {
+	s.rw.RLock()
+	r, ok := s.restores[id]
+	var res NewClusterRestore
+	if ok {
+		res = *r
+	}
+	s.rw.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Restore with ID %q not found.", id)
+	}
+
+	if res.RestoreID != "" {
+		item, err := models.FindRestoreHistoryItemByID(s.db.Querier, res.RestoreID)
+		if err != nil {
+			return nil, err
+		}
+		res.RestoreStatus = item.Status
+	}
+
+	if res.Status == RegisteringNewClusterRestoreStatus || res.Status == RestoringNewClusterRestoreStatus {
+		kubernetesCluster, err := models.FindKubernetesClusterByName(s.db.Querier, res.KubernetesClusterName)
+		if err != nil {
+			return nil, err
+		}
+		if res.Endpoint, err = s.clusterEndpoint(ctx, &res, kubernetesCluster.KubeConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return &res, nil
+}
+
+// run waits for new cluster and starts restore.
+func (s *RestoreService) run(r *NewClusterRestore, kubeConfig string) {
+	ctx, cancel := context.WithTimeout(context.Background(), newClusterRestoreTimeout)
+	defer cancel()
+
+	l := s.l.WithField("restore_id", r.ID)
+	if err := s.restore(ctx, r, kubeConfig); err != nil {
+		l.Errorf("Failed to restore artifact %s to cluster %q: %+v.", r.ArtifactID, r.ClusterName, err)
+		s.update(r.ID, func(r *NewClusterRestore) {
+			r.Status = FailedNewClusterRestoreStatus
+			r.Error = err.Error()
+		})
+		return
+	}
+	l.Infof("Restore of artifact %s to cluster %q started.", r.ArtifactID, r.ClusterName)
+}
+
+// restore waits for new cluster to be ready and registered for monitoring, then starts restore to one of its Services.
+func (s *RestoreService) restore(ctx context.Context, r *NewClusterRestore, kubeConfig string) error {
+	err := s.poll(ctx, func() (bool, error) {
+		return s.clusterReady(ctx, r, kubeConfig)
+	})
+	if err != nil {
+		return errors.Wrap(err, "cluster is not ready")
+	}
+	s.update(r.ID, func(r *NewClusterRestore) { r.Status = RegisteringNewClusterRestoreStatus })
+
+	// DBaaS operators register Services of the cluster for monitoring with cluster name as a cluster label
+	var serviceID string
+	err = s.poll(ctx, func() (bool, error) {
+		services, err := models.FindServices(s.db.Querier, models.ServiceFilters{
+			ServiceType: &r.ServiceType,
+			Cluster:     r.ClusterName,
+		})
+		if err != nil || len(services) == 0 {
+			return false, err
+		}
+		serviceID = services[0].ServiceID
+		return true, nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "cluster is not registered for monitoring")
+	}
+
+	// new cluster is empty, so there is nothing to split-brain
+	restoreID, err := s.backupService.RestoreBackup(ctx, serviceID, r.ArtifactID, true, false)
+	if err != nil {
+		return err
+	}
+	s.update(r.ID, func(r *NewClusterRestore) {
+		r.Status = RestoringNewClusterRestoreStatus
+		r.ServiceID = serviceID
+		r.RestoreID = restoreID
+	})
+	return nil
+}
+
+// poll calls f until it returns true or error, or context is canceled.
+func (s *RestoreService) poll(ctx context.Context, f func() (bool, error)) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := f()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+	}
+}
+
+// clusterReady returns true if new cluster is ready, and error if it failed.
+func (s *RestoreService) clusterReady(ctx context.Context, r *NewClusterRestore, kubeConfig string) (bool, error) {
+	kubeAuth := &dbaascontrollerv1beta1.KubeAuth{Kubeconfig: kubeConfig}
+	if r.ServiceType == models.MySQLServiceType {
+		out, err := s.controllerClient.ListXtraDBClusters(ctx, &dbaascontrollerv1beta1.ListXtraDBClustersRequest{KubeAuth: kubeAuth})
+		if err != nil {
+			return false, err
+		}
+		for _, c := range out.Clusters {
+			if c.Name != r.ClusterName {
+				continue
+			}
+			switch c.State {
+			case dbaascontrollerv1beta1.XtraDBClusterState_XTRA_DB_CLUSTER_STATE_READY:
+				return true, nil
+			case dbaascontrollerv1beta1.XtraDBClusterState_XTRA_DB_CLUSTER_STATE_FAILED:
+				return false, errors.Errorf("cluster failed: %s", c.Operation.GetMessage())
+			}
+		}
+		return false, nil
+	}
+
+	out, err := s.controllerClient.ListPSMDBClusters(ctx, &dbaascontrollerv1beta1.ListPSMDBClustersRequest{KubeAuth: kubeAuth})
+	if err != nil {
+		return false, err
+	}
+	for _, c := range out.Clusters {
+		if c.Name != r.ClusterName {
+			continue
+		}
+		switch c.State {
+		case dbaascontrollerv1beta1.PSMDBClusterState_PSMDB_CLUSTER_STATE_READY:
+			return true, nil
+		case dbaascontrollerv1beta1.PSMDBClusterState_PSMDB_CLUSTER_STATE_FAILED:
+			return false, errors.Errorf("cluster failed: %s", c.Operation.GetMessage())
+		}
+	}
+	return false, nil
+}
+
+// clusterEndpoint returns connection parameters of new cluster.
+func (s *RestoreService) clusterEndpoint(ctx context.Context, r *NewClusterRestore, kubeConfig string) (*NewClusterEndpoint, error) {
+	kubeAuth := &dbaascontrollerv1beta1.KubeAuth{Kubeconfig: kubeConfig}
+	if r.ServiceType == models.MySQLServiceType {
+		out, err := s.controllerClient.GetXtraDBClusterCredentials(ctx, &dbaascontrollerv1beta1.GetXtraDBClusterCredentialsRequest{
+			KubeAuth: kubeAuth,
+			Name:     r.ClusterName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &NewClusterEndpoint{
+			Host:     out.Credentials.Host,
+			Port:     out.Credentials.Port,
+			Username: out.Credentials.Username,
+			Password: out.Credentials.Password,
+		}, nil
+	}
+
+	out, err := s.controllerClient.GetPSMDBClusterCredentials(ctx, &dbaascontrollerv1beta1.GetPSMDBClusterCredentialsRequest{
+		KubeAuth: kubeAuth,
+		Name:     r.ClusterName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &NewClusterEndpoint{
+		Host:       out.Credentials.Host,
+		Port:       out.Credentials.Port,
+		Username:   out.Credentials.Username,
+		Password:   out.Credentials.Password,
+		Replicaset: out.Credentials.Replicaset,
+	}, nil
+}
+
+// update changes restore with given ID under lock.
+func (s *RestoreService) update(id string, f func(*NewClusterRestore)) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	if r, ok := s.restores[id]; ok {
+		f(r)
+	}
+}
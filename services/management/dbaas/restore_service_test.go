@@ -0,0 +1,170 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package dbaas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	controllerv1beta1 "github.com/percona-platform/dbaas-api/gen/controller"
+	dbaasv1beta1 "github.com/percona/pmm/api/managementpb/dbaas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestRestoreService(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	q := db.Querier
+
+	_, err := models.CreateKubernetesCluster(q, &models.CreateKubernetesClusterParams{
+		KubernetesClusterName: pxcKubernetesClusterNameTest,
+		KubeConfig:            pxcKubeconfigTest,
+	})
+	require.NoError(t, err)
+
+	artifact, err := models.CreateArtifact(q, models.CreateArtifactParams{
+		Name:       "backup",
+		Vendor:     string(models.MySQLServiceType),
+		LocationID: "location_id",
+		ServiceID:  "service_id",
+		DataModel:  models.PhysicalDataModel,
+		Status:     models.SuccessBackupStatus,
+	})
+	require.NoError(t, err)
+
+	xtraDBCluster := &dbaasv1beta1.CreateXtraDBClusterRequest{
+		KubernetesClusterName: pxcKubernetesClusterNameTest,
+		Name:                  "restored",
+		Params: &dbaasv1beta1.XtraDBClusterParams{
+			ClusterSize: 1,
+			Pxc:         &dbaasv1beta1.XtraDBClusterParams_PXC{DiskSize: 1024},
+			Haproxy:     &dbaasv1beta1.XtraDBClusterParams_HAProxy{},
+		},
+	}
+
+	t.Run("NoPublicAddress", func(t *testing.T) {
+		s := NewRestoreService(db, &mockDbaasClient{}, &mockGrafanaClient{}, &mockBackupService{})
+		_, err := models.UpdateSettings(q, &models.ChangeSettingsParams{EnableDBaaS: true})
+		require.NoError(t, err)
+
+		_, err = s.RestoreToNewCluster(ctx, &RestoreToNewClusterParams{ArtifactID: artifact.ID, XtraDBCluster: xtraDBCluster})
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "PMM public address is required to register new cluster for monitoring."), err)
+	})
+
+	_, err = models.UpdateSettings(q, &models.ChangeSettingsParams{PMMPublicAddress: "pmm.example.com"})
+	require.NoError(t, err)
+
+	t.Run("WrongClusterType", func(t *testing.T) {
+		s := NewRestoreService(db, &mockDbaasClient{}, &mockGrafanaClient{}, &mockBackupService{})
+		_, err := s.RestoreToNewCluster(ctx, &RestoreToNewClusterParams{
+			ArtifactID:   artifact.ID,
+			PSMDBCluster: &dbaasv1beta1.CreatePSMDBClusterRequest{KubernetesClusterName: pxcKubernetesClusterNameTest, Name: "restored"},
+		})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "XtraDB cluster parameters are required for MySQL artifact."), err)
+	})
+
+	t.Run("Normal", func(t *testing.T) {
+		node, err := models.CreateNode(q, models.GenericNodeType, &models.CreateNodeParams{NodeName: "restored-pxc-0"})
+		require.NoError(t, err)
+		service, err := models.AddNewService(q, models.MySQLServiceType, &models.AddDBMSServiceParams{
+			ServiceName: "restored-pxc-0",
+			NodeID:      node.NodeID,
+			Cluster:     "restored",
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16(3306),
+		})
+		require.NoError(t, err)
+		restore, err := models.CreateRestoreHistoryItem(q, models.CreateRestoreHistoryItemParams{
+			ArtifactID: artifact.ID,
+			ServiceID:  service.ServiceID,
+			Status:     models.InProgressRestoreStatus,
+		})
+		require.NoError(t, err)
+
+		dbaasClient := &mockDbaasClient{}
+		grafanaClient := &mockGrafanaClient{}
+		backupService := &mockBackupService{}
+		s := NewRestoreService(db, dbaasClient, grafanaClient, backupService)
+		s.pollInterval = 10 * time.Millisecond
+
+		grafanaClient.On("CreateAdminAPIKey", mock.Anything, mock.Anything).Return(int64(1), "api-key", nil)
+		dbaasClient.On("CreateXtraDBCluster", mock.Anything, mock.Anything).Return(&controllerv1beta1.CreateXtraDBClusterResponse{}, nil)
+		listResponse := func(state controllerv1beta1.XtraDBClusterState) *controllerv1beta1.ListXtraDBClustersResponse {
+			return &controllerv1beta1.ListXtraDBClustersResponse{
+				Clusters: []*controllerv1beta1.ListXtraDBClustersResponse_Cluster{{Name: "restored", State: state}},
+			}
+		}
+		dbaasClient.On("ListXtraDBClusters", mock.Anything, mock.Anything).
+			Return(listResponse(controllerv1beta1.XtraDBClusterState_XTRA_DB_CLUSTER_STATE_CHANGING), nil).Once()
+		dbaasClient.On("ListXtraDBClusters", mock.Anything, mock.Anything).
+			Return(listResponse(controllerv1beta1.XtraDBClusterState_XTRA_DB_CLUSTER_STATE_READY), nil).Once()
+		backupService.On("RestoreBackup", mock.Anything, service.ServiceID, artifact.ID, true, false).Return(restore.ID, nil).Once()
+		dbaasClient.On("GetXtraDBClusterCredentials", mock.Anything, mock.Anything).Return(&controllerv1beta1.GetXtraDBClusterCredentialsResponse{
+			Credentials: &controllerv1beta1.XtraDBCredentials{
+				Host:     "restored-haproxy.default",
+				Port:     3306,
+				Username: "root",
+				Password: "root_password",
+			},
+		}, nil)
+
+		id, err := s.RestoreToNewCluster(ctx, &RestoreToNewClusterParams{ArtifactID: artifact.ID, XtraDBCluster: xtraDBCluster})
+		require.NoError(t, err)
+
+		var r *NewClusterRestore
+		assert.Eventually(t, func() bool {
+			r, err = s.GetNewClusterRestore(ctx, id)
+			require.NoError(t, err)
+			return r.Status == RestoringNewClusterRestoreStatus
+		}, 5*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, service.ServiceID, r.ServiceID)
+		assert.Equal(t, restore.ID, r.RestoreID)
+		assert.Equal(t, models.InProgressRestoreStatus, r.RestoreStatus)
+		assert.Equal(t, &NewClusterEndpoint{
+			Host:     "restored-haproxy.default",
+			Port:     3306,
+			Username: "root",
+			Password: "root_password",
+		}, r.Endpoint)
+
+		dbaasClient.AssertExpectations(t)
+		backupService.AssertExpectations(t)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		s := NewRestoreService(db, &mockDbaasClient{}, &mockGrafanaClient{}, &mockBackupService{})
+		_, err := s.GetNewClusterRestore(ctx, "unknown")
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `Restore with ID "unknown" not found.`), err)
+	})
+}
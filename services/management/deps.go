@@ -25,6 +25,7 @@ import (
 
 	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/services"
+	"github.com/percona/pmm-managed/services/sandbox"
 )
 
 //go:generate mockery -name=agentsRegistry -case=snake -inpkg -testonly
@@ -34,6 +35,7 @@ import (
 //go:generate mockery -name=grafanaClient -case=snake -inpkg -testonly
 //go:generate mockery -name=jobsService -case=snake -inpkg -testonly
 //go:generate mockery -name=connectionChecker -case=snake -inpkg -testonly
+//go:generate mockery -name=sandboxService -case=snake -inpkg -testonly
 
 // agentsRegistry is a subset of methods of agents.Registry used by this package.
 // We use it instead of real type for testing and to avoid dependency cycle.
@@ -92,3 +94,9 @@ type connectionChecker interface {
 type versionCache interface {
 	RequestSoftwareVersionsUpdate()
 }
+
+// sandboxService is a subset of methods of sandbox.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type sandboxService interface {
+	Run(ctx context.Context, apply func(q *reform.Querier) error) (*sandbox.Report, error)
+}
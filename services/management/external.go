@@ -32,19 +32,21 @@ import (
 // ExternalService External Management Service.
 //nolint:unused
 type ExternalService struct {
-	db    *reform.DB
-	vmdb  prometheusService
-	state agentsStateUpdater
-	cc    connectionChecker
+	db      *reform.DB
+	vmdb    prometheusService
+	state   agentsStateUpdater
+	cc      connectionChecker
+	sandbox sandboxService
 }
 
 // NewExternalService creates new External Management Service.
-func NewExternalService(db *reform.DB, vmdb prometheusService, state agentsStateUpdater, cc connectionChecker) *ExternalService {
+func NewExternalService(db *reform.DB, vmdb prometheusService, state agentsStateUpdater, cc connectionChecker, sandbox sandboxService) *ExternalService {
 	return &ExternalService{
-		db:    db,
-		vmdb:  vmdb,
-		state: state,
-		cc:    cc,
+		db:      db,
+		vmdb:    vmdb,
+		state:   state,
+		cc:      cc,
+		sandbox: sandbox,
 	}
 }
 
@@ -0,0 +1,281 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package ia
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/percona/promconfig"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	baselinesRulesFile = "anomaly_baselines.yml"
+	// number of past seasons used to compute baseline
+	baselineSeasons = 3
+	// window around the same point of each past season
+	baselineWindow = time.Hour
+	// window for current value
+	baselineCurrentWindow = 10 * time.Minute
+	baselineAlertFor      = 10 * time.Minute
+)
+
+// BaselinesService manages anomaly detection baselines of Services.
+// Baselines are computed by VMAlert recording rules from the same time of past seasons,
+// and alerting rules fire when a metric deviates from the baseline by more than configured number of sigmas.
+type BaselinesService struct {
+	db      *reform.DB
+	rules   *RulesService
+	vmalert vmAlert
+	l       *logrus.Entry
+}
+
+// NewBaselinesService creates new anomaly baselines service.
+func NewBaselinesService(db *reform.DB, rules *RulesService, vmalert vmAlert) *BaselinesService {
+	return &BaselinesService{
+		db:      db,
+		rules:   rules,
+		vmalert: vmalert,
+		l:       logrus.WithField("component", "management/ia/baselines"),
+	}
+}
+
+// ListBaselines returns anomaly baselines of given Service, or of all Services if serviceID is empty.
+func (s *BaselinesService) ListBaselines(ctx context.Context, serviceID string) ([]*models.AnomalyBaseline, error) {
+	var res []*models.AnomalyBaseline
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindAnomalyBaselines(tx.Querier, models.AnomalyBaselineFilters{ServiceID: serviceID})
+		return err
+	})
+	return res, e
+}
+
+// AddBaseline adds anomaly baseline for a metric of a Service.
+func (s *BaselinesService) AddBaseline(ctx context.Context, params *models.CreateAnomalyBaselineParams) (*models.AnomalyBaseline, error) {
+	var res *models.AnomalyBaseline
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.CreateAnomalyBaseline(tx.Querier, params)
+		return err
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	s.updateRules()
+	return res, nil
+}
+
+// ChangeBaseline changes anomaly baseline.
+func (s *BaselinesService) ChangeBaseline(ctx context.Context, id string, params *models.ChangeAnomalyBaselineParams) (*models.AnomalyBaseline, error) {
+	var res *models.AnomalyBaseline
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.ChangeAnomalyBaseline(tx.Querier, id, params)
+		return err
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	s.updateRules()
+	return res, nil
+}
+
+// RemoveBaseline removes anomaly baseline.
+func (s *BaselinesService) RemoveBaseline(ctx context.Context, id string) error {
+	e := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		return models.RemoveAnomalyBaseline(tx.Querier, id)
+	})
+	if e != nil {
+		return e
+	}
+
+	s.updateRules()
+	return nil
+}
+
+// updateRules rewrites VMAlert rules files and reloads VMAlert.
+func (s *BaselinesService) updateRules() {
+	if !s.rules.Enabled() {
+		return
+	}
+
+	s.rules.WriteVMAlertRulesFiles()
+	s.vmalert.RequestConfigurationUpdate()
+}
+
+// baselineRulesFile represents VMAlert rules file with both recording and alerting rules.
+type baselineRulesFile struct {
+	Group []baselineRuleGroup `yaml:"groups"`
+}
+
+type baselineRuleGroup struct {
+	Name  string         `yaml:"name"`
+	Rules []baselineRule `yaml:"rules"`
+}
+
+type baselineRule struct {
+	Record      string              `yaml:"record,omitempty"`
+	Alert       string              `yaml:"alert,omitempty"`
+	Expr        string              `yaml:"expr"`
+	Duration    promconfig.Duration `yaml:"for,omitempty"`
+	Labels      map[string]string   `yaml:"labels,omitempty"`
+	Annotations map[string]string   `yaml:"annotations,omitempty"`
+}
+
+// marshalBaselinesRules returns rules file content for all enabled anomaly baselines,
+// or nil if there are none.
+func (s *RulesService) marshalBaselinesRules(q *reform.Querier) ([]byte, error) {
+	baselines, err := models.FindAnomalyBaselines(q, models.AnomalyBaselineFilters{EnabledOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(baselines) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(baselines))
+	for i, b := range baselines {
+		ids[i] = b.ServiceID
+	}
+	services, err := models.FindServicesByIDs(q, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	group := baselineRuleGroup{Name: "PMM Anomaly Baselines"}
+	for _, b := range baselines {
+		service := services[b.ServiceID]
+		if service == nil {
+			continue
+		}
+
+		rules, err := makeBaselineRules(b, service)
+		if err != nil {
+			s.l.Warnf("Skipping anomaly baseline %s: %s.", b.ID, err)
+			continue
+		}
+		group.Rules = append(group.Rules, rules...)
+	}
+
+	b, err := yaml.Marshal(&baselineRulesFile{Group: []baselineRuleGroup{group}})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal anomaly baselines rules")
+	}
+	return append([]byte("---\n"), b...), nil
+}
+
+// baselineRecord returns recording rule name and expression of a metric for a Service.
+func baselineRecord(metric models.BaselineMetric, service *models.Service) (string, string, error) {
+	name := service.ServiceName
+	switch metric {
+	case models.QPSBaselineMetric:
+		var expr string
+		switch service.ServiceType {
+		case models.MySQLServiceType:
+			expr = fmt.Sprintf(`rate(mysql_global_status_queries{service_name=%q}[5m])`, name)
+		case models.PostgreSQLServiceType:
+			expr = fmt.Sprintf(`rate(pg_stat_database_xact_commit{service_name=%[1]q}[5m]) + rate(pg_stat_database_xact_rollback{service_name=%[1]q}[5m])`, name)
+		case models.MongoDBServiceType:
+			expr = fmt.Sprintf(`rate(mongodb_op_counters_total{service_name=%q}[5m])`, name)
+		default:
+			return "", "", errors.Errorf("unsupported service type %s", service.ServiceType)
+		}
+		return "pmm_baseline:qps", "sum by (service_name) (" + expr + ")", nil
+
+	case models.LatencyBaselineMetric:
+		// recorded from QAN by pmm-managed itself, so it does not depend on service type
+		expr := fmt.Sprintf(`avg by (service_name) (pmm_managed_qan_query_time_avg_seconds{service_name=%q})`, name)
+		return "pmm_baseline:latency_seconds", expr, nil
+
+	case models.ReplicationLagBaselineMetric:
+		var expr string
+		switch service.ServiceType {
+		case models.MySQLServiceType:
+			expr = fmt.Sprintf(`mysql_slave_status_seconds_behind_master{service_name=%q}`, name)
+		case models.PostgreSQLServiceType:
+			expr = fmt.Sprintf(`pg_replication_lag{service_name=%q}`, name)
+		case models.MongoDBServiceType:
+			expr = fmt.Sprintf(`mongodb_mongod_replset_member_replication_lag{service_name=%q}`, name)
+		default:
+			return "", "", errors.Errorf("unsupported service type %s", service.ServiceType)
+		}
+		return "pmm_baseline:replication_lag_seconds", "max by (service_name) (" + expr + ")", nil
+
+	default:
+		return "", "", errors.Errorf("unsupported metric %q", metric)
+	}
+}
+
+// makeBaselineRules returns recording rule for the metric and alerting rule for deviation from its seasonal baseline.
+func makeBaselineRules(b *models.AnomalyBaseline, service *models.Service) ([]baselineRule, error) {
+	record, recordExpr, err := baselineRecord(b.Metric, service)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := fmt.Sprintf(`%s{service_name=%q}`, record, service.ServiceName)
+	period := b.Seasonality.Period()
+	means := make([]string, baselineSeasons)
+	stddevs := make([]string, baselineSeasons)
+	for i := range means {
+		offset := promDuration(period * time.Duration(i+1))
+		means[i] = fmt.Sprintf("avg_over_time(%s[%s] offset %s)", selector, promDuration(baselineWindow), offset)
+		stddevs[i] = fmt.Sprintf("stddev_over_time(%s[%s] offset %s)", selector, promDuration(baselineWindow), offset)
+	}
+	current := fmt.Sprintf("avg_over_time(%s[%s])", selector, promDuration(baselineCurrentWindow))
+	mean := fmt.Sprintf("((%s) / %d)", strings.Join(means, " + "), baselineSeasons)
+	stddev := fmt.Sprintf("((%s) / %d)", strings.Join(stddevs, " + "), baselineSeasons)
+
+	return []baselineRule{{
+		Record: record,
+		Expr:   recordExpr,
+	}, {
+		Alert:    "pmm_anomaly_" + string(b.Metric),
+		Expr:     fmt.Sprintf("abs(%s - %s) > %v * %s and %s > 0", current, mean, b.Sigma, stddev, stddev),
+		Duration: promconfig.Duration(baselineAlertFor),
+		Labels: map[string]string{
+			"anomaly_baseline_id": b.ID,
+			"severity":            "warning",
+			"service_name":        service.ServiceName,
+			"metric":              string(b.Metric),
+		},
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Anomalous %s of %s", strings.ReplaceAll(string(b.Metric), "_", " "), service.ServiceName),
+			"description": fmt.Sprintf("%s of %s deviates from its %s baseline by more than %v standard deviations.",
+				b.Metric, service.ServiceName, b.Seasonality, b.Sigma),
+		},
+	}}, nil
+}
+
+// promDuration formats duration in PromQL format.
+func promDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
@@ -0,0 +1,74 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package ia
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestMakeBaselineRules(t *testing.T) {
+	service := &models.Service{
+		ServiceID:   "/service_id/1",
+		ServiceType: models.MySQLServiceType,
+		ServiceName: "mysql1",
+	}
+
+	t.Run("QPS", func(t *testing.T) {
+		rules, err := makeBaselineRules(&models.AnomalyBaseline{
+			ID:          "/anomaly_baseline_id/1",
+			ServiceID:   service.ServiceID,
+			Metric:      models.QPSBaselineMetric,
+			Seasonality: models.DailyBaselineSeasonality,
+			Sigma:       2.5,
+		}, service)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+
+		assert.Equal(t, "pmm_baseline:qps", rules[0].Record)
+		assert.Equal(t, `sum by (service_name) (rate(mysql_global_status_queries{service_name="mysql1"}[5m]))`, rules[0].Expr)
+
+		s := `pmm_baseline:qps{service_name="mysql1"}`
+		mean := `((avg_over_time(` + s + `[1h] offset 24h) + avg_over_time(` + s + `[1h] offset 48h) + avg_over_time(` + s + `[1h] offset 72h)) / 3)`
+		stddev := `((stddev_over_time(` + s + `[1h] offset 24h) + stddev_over_time(` + s + `[1h] offset 48h) + stddev_over_time(` + s + `[1h] offset 72h)) / 3)`
+		assert.Equal(t, "pmm_anomaly_qps", rules[1].Alert)
+		assert.Equal(t, `abs(avg_over_time(`+s+`[10m]) - `+mean+`) > 2.5 * `+stddev+` and `+stddev+` > 0`, rules[1].Expr)
+		assert.Equal(t, "/anomaly_baseline_id/1", rules[1].Labels["anomaly_baseline_id"])
+	})
+
+	t.Run("WeeklyLatency", func(t *testing.T) {
+		rules, err := makeBaselineRules(&models.AnomalyBaseline{
+			Metric:      models.LatencyBaselineMetric,
+			Seasonality: models.WeeklyBaselineSeasonality,
+			Sigma:       3,
+		}, service)
+		require.NoError(t, err)
+		assert.Equal(t, "pmm_baseline:latency_seconds", rules[0].Record)
+		assert.Contains(t, rules[1].Expr, "offset 504h")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := makeBaselineRules(&models.AnomalyBaseline{
+			Metric: models.ReplicationLagBaselineMetric,
+		}, &models.Service{ServiceType: models.ProxySQLServiceType})
+		assert.EqualError(t, err, "unsupported service type proxysql")
+	})
+}
@@ -250,6 +250,21 @@ func (s *ChannelsService) ChangeChannel(ctx context.Context, req *iav1beta1.Chan
 	return &iav1beta1.ChangeChannelResponse{}, nil
 }
 
+// ChangeChannelThrottling changes notification channel rate limiting and digest mode.
+func (s *ChannelsService) ChangeChannelThrottling(ctx context.Context, channelID string, throttling *models.ChannelThrottling) error {
+	e := s.db.InTransaction(func(tx *reform.TX) error {
+		_, err := models.ChangeChannelThrottling(tx.Querier, channelID, throttling)
+		return err
+	})
+	if e != nil {
+		return e
+	}
+
+	s.alertManager.RequestConfigurationUpdate()
+
+	return nil
+}
+
 // RemoveChannel removes notification channel.
 func (s *ChannelsService) RemoveChannel(ctx context.Context, req *iav1beta1.RemoveChannelRequest) (*iav1beta1.RemoveChannelResponse, error) {
 	e := s.db.InTransaction(func(tx *reform.TX) error {
@@ -43,7 +43,8 @@ import (
 )
 
 const (
-	rulesDir = "/etc/ia/rules"
+	// RulesDir is a directory of generated Integrated Alerting rule files.
+	RulesDir = "/etc/ia/rules"
 )
 
 // RulesService represents API for Integrated Alerting Rules.
@@ -60,7 +61,7 @@ type RulesService struct {
 func NewRulesService(db *reform.DB, templates *TemplatesService, vmalert vmAlert, alertManager alertManager) *RulesService {
 	l := logrus.WithField("component", "management/ia/rules")
 
-	err := dir.CreateDataDir(rulesDir, "pmm", "pmm", dirPerm)
+	err := dir.CreateDataDir(RulesDir, "pmm", "pmm", dirPerm)
 	if err != nil {
 		l.Error(err)
 	}
@@ -71,7 +72,7 @@ func NewRulesService(db *reform.DB, templates *TemplatesService, vmalert vmAlert
 		templates:    templates,
 		vmalert:      vmalert,
 		alertManager: alertManager,
-		rulesPath:    rulesDir,
+		rulesPath:    RulesDir,
 	}
 }
 
@@ -121,13 +122,12 @@ func (s *RulesService) RemoveVMAlertRulesFiles() error {
 
 // WriteVMAlertRulesFiles converts all available rules to VMAlert rule files.
 func (s *RulesService) WriteVMAlertRulesFiles() {
-	rules, err := s.getAlertRules()
-	if err != nil {
-		s.l.Errorf("Failed to get available alert rules: %+v", err)
-		return
-	}
-
-	ruleFiles, err := s.prepareRulesFiles(rules)
+	var files map[string][]byte
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		var e error
+		files, e = s.GenerateVMAlertRulesFiles(tx.Querier)
+		return e
+	})
 	if err != nil {
 		s.l.Errorf("Failed to prepare alert rule files: %+v", err)
 		return
@@ -138,12 +138,45 @@ func (s *RulesService) WriteVMAlertRulesFiles() {
 		return
 	}
 
+	for name, b := range files {
+		path := filepath.Join(s.rulesPath, name)
+		if err = ioutil.WriteFile(path, b, 0o644); err != nil { //nolint:gosec
+			s.l.Errorf("Failed to write alert rule file %s: %s", path, err)
+		}
+	}
+}
+
+// GenerateVMAlertRulesFiles returns content of VMAlert rule files by file name
+// for all available rules and anomaly baselines, without writing them.
+func (s *RulesService) GenerateVMAlertRulesFiles(q *reform.Querier) (map[string][]byte, error) {
+	rules, err := s.getAlertRules(q)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get available alert rules")
+	}
+
+	ruleFiles, err := s.prepareRulesFiles(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string][]byte, len(ruleFiles)+1)
 	for _, file := range ruleFiles {
-		err = s.writeRuleFile(&file) //nolint:gosec
+		name, b, err := marshalRuleFile(&file) //nolint:gosec
 		if err != nil {
-			s.l.Errorf("Failed to write alert rule file: %+v", err)
+			return nil, err
 		}
+		res[name] = b
+	}
+
+	b, err := s.marshalBaselinesRules(q)
+	if err != nil {
+		return nil, err
 	}
+	if b != nil {
+		res[baselinesRulesFile] = b
+	}
+
+	return res, nil
 }
 
 // prepareRulesFiles converts collected IA rules to Alertmanager rule files content.
@@ -237,26 +270,21 @@ func transformMaps(src map[string]string, dest map[string]string, data map[strin
 	return nil
 }
 
-// dump the transformed IA templates to a file.
-func (s *RulesService) writeRuleFile(rule *ruleFile) error {
+// marshalRuleFile returns file name and content of the transformed IA template.
+func marshalRuleFile(rule *ruleFile) (string, []byte, error) {
 	b, err := yaml.Marshal(rule)
 	if err != nil {
-		return errors.Errorf("failed to marshal rule %s", err)
+		return "", nil, errors.Errorf("failed to marshal rule %s", err)
 	}
 	b = append([]byte("---\n"), b...)
 
 	alertRule := rule.Group[0].Rules[0]
 	if alertRule.Alert == "" {
-		return errors.New("alert rule not initialized")
+		return "", nil, errors.New("alert rule not initialized")
 	}
 
 	fileName := strings.TrimPrefix(alertRule.Alert, "/rule_id/")
-	path := s.rulesPath + "/" + fileName + ".yml"
-	if err = ioutil.WriteFile(path, b, 0o644); err != nil {
-		return errors.Errorf("failed to dump rule to file %s: %s", s.rulesPath, err)
-	}
-
-	return nil
+	return fileName + ".yml", b, nil
 }
 
 // ListAlertRules returns a list of all Integrated Alerting rules.
@@ -273,7 +301,11 @@ func (s *RulesService) ListAlertRules(ctx context.Context, req *iav1beta1.ListAl
 		TotalPages: 1,
 	}
 	if pageSize == 0 {
-		rules, err = s.getAlertRules()
+		err = s.db.InTransaction(func(tx *reform.TX) error {
+			var e error
+			rules, e = s.getAlertRules(tx.Querier)
+			return e
+		})
 		pageTotals.TotalItems = int32(len(rules))
 	} else {
 		rules, pageTotals, err = s.getAlertRulesPage(pageIndex, pageSize)
@@ -360,25 +392,15 @@ func (s *RulesService) getAlertRulesPage(pageIndex, pageSize int) ([]*iav1beta1.
 }
 
 // getAlertRules returns list of available alert rules.
-func (s *RulesService) getAlertRules() ([]*iav1beta1.Rule, error) {
-	var rules []*models.Rule
-	var channels []*models.Channel
-	e := s.db.InTransaction(func(tx *reform.TX) error {
-		var err error
-		rules, err = models.FindRules(tx.Querier)
-		if err != nil {
-			return err
-		}
-
-		channels, err = models.FindChannels(tx.Querier)
-		if err != nil {
-			return err
-		}
-		return nil
-	})
+func (s *RulesService) getAlertRules(q *reform.Querier) ([]*iav1beta1.Rule, error) {
+	rules, err := models.FindRules(q)
+	if err != nil {
+		return nil, err
+	}
 
-	if e != nil {
-		return nil, e
+	channels, err := models.FindChannels(q)
+	if err != nil {
+		return nil, err
 	}
 
 	res, err := s.convertAlertRules(rules, channels)
@@ -0,0 +1,396 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package management
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	config "github.com/percona/promconfig"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/sandbox"
+)
+
+// ImportFormat represents format of imported scrape targets.
+type ImportFormat string
+
+// Supported import formats.
+const (
+	PrometheusConfigImportFormat ImportFormat = "prometheus"
+	FileSDImportFormat           ImportFormat = "file_sd"
+)
+
+// defaultImportJobName is used for file_sd targets when job name is not given.
+const defaultImportJobName = "imported"
+
+// ImportScrapeTargetsParams represents scrape targets import parameters.
+type ImportScrapeTargetsParams struct {
+	// prometheus.yml or file_sd JSON/YAML file content.
+	Data []byte
+	// Data format; detected from content if empty.
+	Format ImportFormat
+	// Job name (used as External Service group) for file_sd targets.
+	JobName             string
+	SkipConnectionCheck bool
+	// If true, targets are imported in the configuration sandbox only.
+	DryRun bool
+}
+
+// ImportScrapeTargetsResult represents scrape targets import result.
+type ImportScrapeTargetsResult struct {
+	Targets  []*ImportedTarget `json:"targets"`
+	Warnings []string          `json:"warnings"`
+	// Generated configuration files validation report; set only for dry run.
+	Sandbox *sandbox.Report `json:"sandbox,omitempty"`
+}
+
+// ImportedTarget represents result of a single scrape target import.
+type ImportedTarget struct {
+	JobName   string `json:"job_name"`
+	Target    string `json:"target"`
+	NodeID    string `json:"node_id,omitempty"`
+	ServiceID string `json:"service_id,omitempty"`
+	AgentID   string `json:"agent_id,omitempty"`
+	Skipped   bool   `json:"skipped,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// scrapeTarget represents a single parsed static scrape target.
+type scrapeTarget struct {
+	jobName     string
+	target      string
+	scheme      string
+	metricsPath string
+	username    string
+	password    string
+	labels      map[string]string
+}
+
+// parseScrapeTargets returns static scrape targets from prometheus.yml or file_sd file content.
+// Unsupported parts of the configuration are returned as warnings.
+func parseScrapeTargets(params *ImportScrapeTargetsParams) ([]scrapeTarget, []string, error) {
+	format := params.Format
+	if format == "" {
+		var groups []*config.Group
+		if yaml.Unmarshal(params.Data, &groups) == nil {
+			format = FileSDImportFormat
+		} else {
+			format = PrometheusConfigImportFormat
+		}
+	}
+
+	switch format {
+	case FileSDImportFormat:
+		var groups []*config.Group
+		if err := yaml.Unmarshal(params.Data, &groups); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "Failed to parse file_sd data: %s.", err)
+		}
+		jobName := params.JobName
+		if jobName == "" {
+			jobName = defaultImportJobName
+		}
+		return groupsTargets(&config.ScrapeConfig{JobName: jobName}, groups), nil, nil
+
+	case PrometheusConfigImportFormat:
+		var cfg config.Config
+		if err := yaml.Unmarshal(params.Data, &cfg); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "Failed to parse Prometheus configuration: %s.", err)
+		}
+
+		var res []scrapeTarget
+		var warnings []string
+		for _, sc := range cfg.ScrapeConfigs {
+			if len(sc.ServiceDiscoveryConfig.FileSDConfigs) != 0 || len(sc.ServiceDiscoveryConfig.KubernetesSDConfigs) != 0 {
+				warnings = append(warnings, fmt.Sprintf("Job %q: only static_configs are imported.", sc.JobName))
+			}
+			if len(sc.RelabelConfigs) != 0 || len(sc.MetricRelabelConfigs) != 0 {
+				warnings = append(warnings, fmt.Sprintf("Job %q: relabeling configuration is ignored.", sc.JobName))
+			}
+			if sc.HTTPClientConfig.BearerToken != "" || sc.HTTPClientConfig.BearerTokenFile != "" {
+				warnings = append(warnings, fmt.Sprintf("Job %q: bearer token authentication is not supported.", sc.JobName))
+			}
+			res = append(res, groupsTargets(sc, sc.ServiceDiscoveryConfig.StaticConfigs)...)
+		}
+		return res, warnings, nil
+
+	default:
+		return nil, nil, status.Errorf(codes.InvalidArgument, "Unsupported import format %q.", format)
+	}
+}
+
+// groupsTargets returns scrape targets of given target groups with scrape config parameters.
+func groupsTargets(sc *config.ScrapeConfig, groups []*config.Group) []scrapeTarget {
+	var res []scrapeTarget
+	for _, g := range groups {
+		labels := g.Labels
+		if _, ok := labels["instance"]; ok && len(g.Targets) > 1 {
+			// instance label can't be used as Service name for several targets
+			labels = make(map[string]string, len(g.Labels))
+			for k, v := range g.Labels {
+				if k != "instance" {
+					labels[k] = v
+				}
+			}
+		}
+
+		for _, t := range g.Targets {
+			target := scrapeTarget{
+				jobName:     sc.JobName,
+				target:      t,
+				scheme:      sc.Scheme,
+				metricsPath: sc.MetricsPath,
+				labels:      labels,
+			}
+			if ba := sc.HTTPClientConfig.BasicAuth; ba != nil {
+				target.username = ba.Username
+				target.password = ba.Password
+			}
+			res = append(res, target)
+		}
+	}
+	return res
+}
+
+// ImportScrapeTargets creates Remote Nodes, External Services and External Exporters
+// for static targets of given prometheus.yml or file_sd file in a single transaction.
+// Targets with already existing Services are skipped, so import can be safely repeated.
+// Invalid targets and targets failing connection check are reported with errors and not imported.
+// For dry run, targets are imported in the configuration sandbox, and returned IDs are not stored.
+func (e *ExternalService) ImportScrapeTargets(ctx context.Context, params *ImportScrapeTargetsParams) (*ImportScrapeTargetsResult, error) {
+	targets, warnings, err := parseScrapeTargets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImportScrapeTargetsResult{
+		Warnings: warnings,
+	}
+	apply := func(q *reform.Querier) error {
+		var e2 error
+		res.Targets, e2 = e.importScrapeTargets(ctx, q, targets, params.SkipConnectionCheck)
+		return e2
+	}
+
+	if params.DryRun {
+		if res.Sandbox, err = e.sandbox.Run(ctx, apply); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	err = e.db.InTransaction(func(tx *reform.TX) error {
+		return apply(tx.Querier)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, imported := range res.Targets {
+		if imported.AgentID != "" {
+			e.vmdb.RequestConfigurationUpdate()
+			break
+		}
+	}
+	return res, nil
+}
+
+// importedRows contains rows prepared for a single scrape target.
+type importedRows struct {
+	imported *ImportedTarget
+	service  *models.Service
+	agent    *models.Agent
+}
+
+// importScrapeTargets creates Nodes, Services and Agents for given scrape targets.
+// Services and Agents are inserted with batch inserts; Nodes are shared between targets and created one by one.
+func (e *ExternalService) importScrapeTargets(ctx context.Context, q *reform.Querier, targets []scrapeTarget, skipConnectionCheck bool) ([]*ImportedTarget, error) {
+	res := make([]*ImportedTarget, 0, len(targets))
+	var prepared []*importedRows
+	newNodes := make(map[string]struct{})
+	serviceNames := make(map[string]struct{})
+	for i := range targets {
+		imported := &ImportedTarget{
+			JobName: targets[i].jobName,
+			Target:  targets[i].target,
+		}
+		res = append(res, imported)
+
+		rows, err := prepareScrapeTarget(q, &targets[i], imported, newNodes, serviceNames)
+		switch {
+		case err != nil:
+			imported.Error = err.Error()
+		case rows != nil:
+			prepared = append(prepared, rows)
+		}
+	}
+
+	services := make([]reform.Struct, len(prepared))
+	agents := make([]reform.Struct, len(prepared))
+	for i, rows := range prepared {
+		services[i] = rows.service
+		agents[i] = rows.agent
+	}
+	if err := models.InsertBatch(q, services); err != nil {
+		return nil, err
+	}
+	if err := models.InsertBatch(q, agents); err != nil {
+		return nil, err
+	}
+
+	nodeServices := make(map[string]int, len(newNodes))
+	for _, rows := range prepared {
+		nodeServices[rows.service.NodeID]++
+	}
+
+	if !skipConnectionCheck {
+		for _, rows := range prepared {
+			err := e.cc.CheckConnectionToService(ctx, q, rows.service, rows.agent)
+			if err == nil {
+				continue
+			}
+
+			*rows.imported = ImportedTarget{
+				JobName: rows.imported.JobName,
+				Target:  rows.imported.Target,
+				Error:   err.Error(),
+			}
+			if err = models.RemoveService(q, rows.service.ServiceID, models.RemoveCascade); err != nil {
+				return nil, err
+			}
+			nodeServices[rows.service.NodeID]--
+		}
+	}
+
+	// remove created Nodes left without Services due to errors
+	for nodeID := range newNodes {
+		if nodeServices[nodeID] == 0 {
+			if err := models.RemoveNode(q, nodeID, models.RemoveRestrict); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// prepareScrapeTarget finds or creates Node and returns Service and Agent for a single scrape target
+// that are not inserted yet. It returns nil rows if Service already exists.
+// IDs of created Nodes and names of prepared Services are added to given sets.
+func prepareScrapeTarget(q *reform.Querier, t *scrapeTarget, imported *ImportedTarget, newNodes, serviceNames map[string]struct{}) (*importedRows, error) {
+	scheme := t.scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	host, portS, err := net.SplitHostPort(t.target)
+	if err != nil {
+		// Prometheus uses default port for scheme when it is not specified
+		host = t.target
+		portS = "80"
+		if scheme == "https" {
+			portS = "443"
+		}
+	}
+	port, err := strconv.ParseUint(portS, 10, 16)
+	if err != nil || host == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid target %q.", t.target)
+	}
+
+	params := &models.AddDBMSServiceParams{
+		ServiceName:   t.target,
+		ExternalGroup: t.jobName,
+		CustomLabels:  make(map[string]string),
+	}
+	for k, v := range t.labels {
+		switch {
+		case k == "instance":
+			params.ServiceName = v
+		case k == "environment":
+			params.Environment = v
+		case k == "cluster":
+			params.Cluster = v
+		case k == "replication_set":
+			params.ReplicationSet = v
+		case k == "job" || strings.HasPrefix(k, "__"):
+			// set by PMM or reserved
+		default:
+			params.CustomLabels[k] = v
+		}
+	}
+
+	if service, err := models.FindServiceByName(q, params.ServiceName); err == nil {
+		imported.NodeID = service.NodeID
+		imported.ServiceID = service.ServiceID
+		imported.Skipped = true
+		return nil, nil
+	}
+	if _, ok := serviceNames[params.ServiceName]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "Service with name %q already exists.", params.ServiceName)
+	}
+
+	node, err := models.FindNodeByName(q, host)
+	newNode := status.Code(err) == codes.NotFound
+	if newNode {
+		node, err = models.CreateNode(q, models.RemoteNodeType, &models.CreateNodeParams{
+			NodeName: host,
+			Address:  host,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if newNode {
+		newNodes[node.NodeID] = struct{}{}
+	}
+	params.NodeID = node.NodeID
+
+	service, err := models.NewService(q, models.ExternalServiceType, params)
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err := models.NewExternalExporter(q, &models.CreateExternalExporterParams{
+		RunsOnNodeID: node.NodeID,
+		ServiceID:    service.ServiceID,
+		Username:     t.username,
+		Password:     t.password,
+		Scheme:       scheme,
+		MetricsPath:  t.metricsPath,
+		ListenPort:   uint32(port),
+		CustomLabels: params.CustomLabels,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	serviceNames[params.ServiceName] = struct{}{}
+	imported.NodeID = node.NodeID
+	imported.ServiceID = service.ServiceID
+	imported.AgentID = agent.AgentID
+	return &importedRows{
+		imported: imported,
+		service:  service,
+		agent:    agent,
+	}, nil
+}
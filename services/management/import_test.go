@@ -0,0 +1,108 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package management
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestParseScrapeTargets(t *testing.T) {
+	t.Run("Prometheus", func(t *testing.T) {
+		data := []byte(`
+global:
+  scrape_interval: 15s
+scrape_configs:
+  - job_name: node
+    static_configs:
+      - targets: ["db1:9100", "db2:9100"]
+        labels:
+          environment: prod
+          instance: ignored
+  - job_name: app
+    scheme: https
+    metrics_path: /custom
+    basic_auth:
+      username: user
+      password: secret
+    relabel_configs:
+      - source_labels: [__address__]
+        target_label: host
+    file_sd_configs:
+      - files: ["/etc/prometheus/app.json"]
+    static_configs:
+      - targets: ["app:8443"]
+        labels:
+          instance: app-1
+`)
+		targets, warnings, err := parseScrapeTargets(&ImportScrapeTargetsParams{Data: data})
+		require.NoError(t, err)
+		expected := []scrapeTarget{{
+			jobName: "node",
+			target:  "db1:9100",
+			labels:  map[string]string{"environment": "prod"},
+		}, {
+			jobName: "node",
+			target:  "db2:9100",
+			labels:  map[string]string{"environment": "prod"},
+		}, {
+			jobName:     "app",
+			target:      "app:8443",
+			scheme:      "https",
+			metricsPath: "/custom",
+			username:    "user",
+			password:    "secret",
+			labels:      map[string]string{"instance": "app-1"},
+		}}
+		assert.Equal(t, expected, targets)
+		assert.Equal(t, []string{
+			`Job "app": only static_configs are imported.`,
+			`Job "app": relabeling configuration is ignored.`,
+		}, warnings)
+	})
+
+	t.Run("FileSD", func(t *testing.T) {
+		data := []byte(`[{"targets": ["10.0.0.1:9104"], "labels": {"cluster": "c1"}}]`)
+		targets, warnings, err := parseScrapeTargets(&ImportScrapeTargetsParams{Data: data, JobName: "mysql"})
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		assert.Equal(t, []scrapeTarget{{
+			jobName: "mysql",
+			target:  "10.0.0.1:9104",
+			labels:  map[string]string{"cluster": "c1"},
+		}}, targets)
+
+		targets, _, err = parseScrapeTargets(&ImportScrapeTargetsParams{Data: data, Format: FileSDImportFormat})
+		require.NoError(t, err)
+		assert.Equal(t, defaultImportJobName, targets[0].jobName)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, _, err := parseScrapeTargets(&ImportScrapeTargetsParams{Data: []byte(`scrape_configs: 42`)})
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, _, err = parseScrapeTargets(&ImportScrapeTargetsParams{Data: []byte(`[]`), Format: "consul"})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Unsupported import format "consul".`), err)
+	})
+}
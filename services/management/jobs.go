@@ -86,6 +86,35 @@ func (s *JobsAPIService) GetJob(_ context.Context, req *jobsAPI.GetJobRequest) (
 	return resp, nil
 }
 
+// GetJobProgress returns the last reported progress of the job.
+// Progress of running jobs that is not reported by pmm-agent is estimated from job statistics.
+// Successfully finished jobs are reported as 100% done.
+func (s *JobsAPIService) GetJobProgress(_ context.Context, jobID string) (*models.JobProgress, error) {
+	result, err := models.FindJobResultByID(s.db.Querier, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &models.JobProgress{
+		UpdatedAt: result.UpdatedAt,
+	}
+	if result.Result != nil && result.Result.Progress != nil {
+		*progress = *result.Result.Progress
+	}
+	if err = models.EstimateJobProgress(s.db.Querier, result, progress); err != nil {
+		return nil, err
+	}
+	if result.Done && result.Error == "" {
+		progress.Percentage = 100
+		progress.BytesDone = progress.BytesTotal
+	}
+	if result.Done {
+		progress.UpdatedAt = result.UpdatedAt
+	}
+
+	return progress, nil
+}
+
 // StartEchoJob starts echo job. Its purpose is testing.
 func (s *JobsAPIService) StartEchoJob(_ context.Context, req *jobsAPI.StartEchoJobRequest) (*jobsAPI.StartEchoJobResponse, error) {
 	res, err := s.prepareAgentJob(req.PmmAgentId, models.Echo)
@@ -22,6 +22,9 @@ import (
 	"github.com/AlekSi/pointer"
 	"github.com/percona/pmm/api/inventorypb"
 	"github.com/percona/pmm/api/managementpb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 
 	"github.com/percona/pmm-managed/models"
@@ -189,3 +192,131 @@ func (s *MySQLService) Add(ctx context.Context, req *managementpb.AddMySQLReques
 
 	return res, nil
 }
+
+// QANSource represents MySQL Query Analytics data source.
+type QANSource string
+
+// Supported MySQL QAN sources.
+const (
+	QANSourcePerfSchema QANSource = "perfschema"
+	QANSourceSlowlog    QANSource = "slowlog"
+)
+
+// ChangeQANSourceParams contains parameters for switching MySQL QAN source.
+type ChangeQANSourceParams struct {
+	ServiceID string
+	Source    QANSource
+	// Slowlog rotation size for slowlog source: 0 means default, negative value disables rotation.
+	MaxSlowlogFileSize int64
+	// Overrides query examples setting of the previous QAN Agent if set.
+	DisableQueryExamples *bool
+}
+
+// ChangeQANSource switches QAN Agent of given MySQL Service between slowlog and performance schema sources.
+// The new QAN Agent inherits connection parameters of the existing one (or of mysqld_exporter),
+// the previous QAN Agent is removed, and pmm-agent state is updated.
+func (s *MySQLService) ChangeQANSource(ctx context.Context, params *ChangeQANSourceParams) (inventorypb.Agent, error) {
+	var newType, oldType models.AgentType
+	switch params.Source {
+	case QANSourcePerfSchema:
+		newType, oldType = models.QANMySQLPerfSchemaAgentType, models.QANMySQLSlowlogAgentType
+	case QANSourceSlowlog:
+		newType, oldType = models.QANMySQLSlowlogAgentType, models.QANMySQLPerfSchemaAgentType
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Unsupported QAN source %q.", params.Source)
+	}
+
+	// tweak according to AddMySQLRequest API docs
+	maxSlowlogFileSize := params.MaxSlowlogFileSize
+	if maxSlowlogFileSize == 0 {
+		maxSlowlogFileSize = defaultMaxSlowlogFileSize
+	}
+	if maxSlowlogFileSize < 0 {
+		maxSlowlogFileSize = 0
+	}
+
+	var res inventorypb.Agent
+	var pmmAgentID string
+	if e := s.db.InTransaction(func(tx *reform.TX) error {
+		service, err := models.FindServiceByID(tx.Querier, params.ServiceID)
+		if err != nil {
+			return err
+		}
+		if service.ServiceType != models.MySQLServiceType {
+			return status.Errorf(codes.InvalidArgument, "Service %q is not a MySQL Service.", service.ServiceName)
+		}
+
+		agents, err := models.FindAgents(tx.Querier, models.AgentFilters{ServiceID: service.ServiceID})
+		if err != nil {
+			return err
+		}
+
+		var current, template *models.Agent
+		var previous []*models.Agent
+		for _, agent := range agents {
+			switch agent.AgentType {
+			case newType:
+				current = agent
+			case oldType:
+				previous = append(previous, agent)
+				template = agent
+			case models.MySQLdExporterType:
+				if template == nil {
+					template = agent
+				}
+			}
+		}
+
+		if current == nil {
+			if template == nil {
+				return status.Errorf(codes.FailedPrecondition, "Service %q has no Agents to copy connection parameters from.", service.ServiceName)
+			}
+
+			queryExamplesDisabled := template.QueryExamplesDisabled
+			if params.DisableQueryExamples != nil {
+				queryExamplesDisabled = *params.DisableQueryExamples
+			}
+			createParams := &models.CreateAgentParams{
+				PMMAgentID:            pointer.GetString(template.PMMAgentID),
+				ServiceID:             service.ServiceID,
+				Username:              pointer.GetString(template.Username),
+				Password:              pointer.GetString(template.Password),
+				TLS:                   template.TLS,
+				TLSSkipVerify:         template.TLSSkipVerify,
+				MySQLOptions:          template.MySQLOptions,
+				QueryExamplesDisabled: queryExamplesDisabled,
+			}
+			if newType == models.QANMySQLSlowlogAgentType {
+				createParams.MaxQueryLogSize = maxSlowlogFileSize
+			}
+			if current, err = models.CreateAgent(tx.Querier, newType, createParams); err != nil {
+				return err
+			}
+		} else {
+			if params.DisableQueryExamples != nil {
+				current.QueryExamplesDisabled = *params.DisableQueryExamples
+			}
+			if newType == models.QANMySQLSlowlogAgentType {
+				current.MaxQueryLogSize = maxSlowlogFileSize
+			}
+			if err = tx.Update(current); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		for _, agent := range previous {
+			if _, err = models.RemoveAgent(tx.Querier, agent.AgentID, models.RemoveRestrict); err != nil {
+				return err
+			}
+		}
+
+		pmmAgentID = pointer.GetString(current.PMMAgentID)
+		res, err = services.ToAPIAgent(tx.Querier, current)
+		return err
+	}); e != nil {
+		return nil, e
+	}
+
+	s.state.RequestStateUpdate(ctx, pmmAgentID)
+	return res, nil
+}
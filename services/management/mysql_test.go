@@ -0,0 +1,117 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package management
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/google/uuid"
+	"github.com/percona/pmm/api/inventorypb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/logger"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestMySQLService(t *testing.T) {
+	setup := func(t *testing.T) (ctx context.Context, s *MySQLService, service *models.Service, teardown func(t *testing.T)) {
+		t.Helper()
+
+		ctx = logger.Set(context.Background(), t.Name())
+		uuid.SetRand(new(tests.IDReader))
+
+		sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+		db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+
+		state := new(mockAgentsStateUpdater)
+		state.Test(t)
+
+		teardown = func(t *testing.T) {
+			uuid.SetRand(nil)
+
+			require.NoError(t, sqlDB.Close())
+			state.AssertExpectations(t)
+		}
+		s = NewMySQLService(db, state, nil, nil)
+
+		service, err := models.AddNewService(db.Querier, models.MySQLServiceType, &models.AddDBMSServiceParams{
+			ServiceName: "test-mysql",
+			NodeID:      models.PMMServerNodeID,
+			Address:     pointer.ToString("127.0.0.1"),
+			Port:        pointer.ToUint16(3306),
+		})
+		require.NoError(t, err)
+
+		_, err = models.CreateAgent(db.Querier, models.QANMySQLPerfSchemaAgentType, &models.CreateAgentParams{
+			PMMAgentID:            models.PMMServerAgentID,
+			ServiceID:             service.ServiceID,
+			Username:              "username",
+			Password:              "password",
+			QueryExamplesDisabled: true,
+		})
+		require.NoError(t, err)
+
+		return
+	}
+
+	t.Run("ChangeQANSource", func(t *testing.T) {
+		t.Run("Slowlog", func(t *testing.T) {
+			ctx, s, service, teardown := setup(t)
+			defer teardown(t)
+
+			s.state.(*mockAgentsStateUpdater).On("RequestStateUpdate", ctx, models.PMMServerAgentID)
+			agent, err := s.ChangeQANSource(ctx, &ChangeQANSourceParams{
+				ServiceID:          service.ServiceID,
+				Source:             QANSourceSlowlog,
+				MaxSlowlogFileSize: 1024,
+			})
+			require.NoError(t, err)
+
+			slowlog, ok := agent.(*inventorypb.QANMySQLSlowlogAgent)
+			require.True(t, ok)
+			assert.Equal(t, "username", slowlog.Username)
+			assert.Equal(t, int64(1024), slowlog.MaxSlowlogFileSize)
+			assert.True(t, slowlog.QueryExamplesDisabled)
+
+			agents, err := models.FindAgents(s.db.Querier, models.AgentFilters{ServiceID: service.ServiceID})
+			require.NoError(t, err)
+			require.Len(t, agents, 1)
+			assert.Equal(t, models.QANMySQLSlowlogAgentType, agents[0].AgentType)
+		})
+
+		t.Run("Unsupported source", func(t *testing.T) {
+			ctx, s, service, teardown := setup(t)
+			defer teardown(t)
+
+			agent, err := s.ChangeQANSource(ctx, &ChangeQANSourceParams{
+				ServiceID: service.ServiceID,
+				Source:    "binlog",
+			})
+			assert.Nil(t, agent)
+			tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Unsupported QAN source "binlog".`), err)
+		})
+	})
+}
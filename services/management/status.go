@@ -0,0 +1,200 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package management
+
+import (
+	"context"
+
+	"github.com/percona/pmm/api/inventorypb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// maxStatusObjects limits number of objects in a single BatchGetStatus call.
+const maxStatusObjects = 1000
+
+// ObjectType represents type of the object referenced in BatchGetStatus call.
+type ObjectType string
+
+// Supported object types.
+const (
+	ServiceObjectType  ObjectType = "service"
+	AgentObjectType    ObjectType = "agent"
+	ArtifactObjectType ObjectType = "artifact"
+	JobObjectType      ObjectType = "job"
+)
+
+// Statuses of objects without own status field.
+const (
+	ConnectedStatus    = "CONNECTED"
+	DisconnectedStatus = "DISCONNECTED"
+	NoAgentsStatus     = "NO_AGENTS"
+	RunningJobStatus   = "RUNNING"
+	DoneJobStatus      = "DONE"
+	ErrorJobStatus     = "ERROR"
+)
+
+// ObjectRef references a single object.
+type ObjectRef struct {
+	Type ObjectType `json:"type"`
+	ID   string     `json:"id"`
+}
+
+// ObjectStatus represents current status of a single object.
+type ObjectStatus struct {
+	Type   ObjectType `json:"type"`
+	ID     string     `json:"id"`
+	Status string     `json:"status,omitempty"`
+	// Set if status can't be returned, for example, if object is not found.
+	Error string `json:"error,omitempty"`
+}
+
+// StatusService returns statuses of various objects.
+type StatusService struct {
+	replica *models.ReadReplica
+	r       agentsRegistry
+}
+
+// NewStatusService creates new status API service.
+func NewStatusService(replica *models.ReadReplica, r agentsRegistry) *StatusService {
+	return &StatusService{
+		replica: replica,
+		r:       r,
+	}
+}
+
+// BatchGetStatus returns current statuses of given objects in the same order.
+// Errors for individual objects are returned in their statuses.
+func (s *StatusService) BatchGetStatus(ctx context.Context, refs []ObjectRef) ([]*ObjectStatus, error) {
+	if len(refs) > maxStatusObjects {
+		return nil, status.Errorf(codes.InvalidArgument, "Too many objects: %d, maximum is %d.", len(refs), maxStatusObjects)
+	}
+
+	res := make([]*ObjectStatus, len(refs))
+	e := s.replica.DB().InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		for i, ref := range refs {
+			st := &ObjectStatus{
+				Type: ref.Type,
+				ID:   ref.ID,
+			}
+			var err error
+			if st.Status, err = s.getStatus(tx.Querier, ref); err != nil {
+				if _, ok := status.FromError(err); !ok {
+					return err
+				}
+				st.Error = status.Convert(err).Message()
+			}
+			res[i] = st
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return res, nil
+}
+
+// getStatus returns status of a single object.
+// It returns gRPC error for invalid references and unexpected errors otherwise.
+func (s *StatusService) getStatus(q *reform.Querier, ref ObjectRef) (string, error) {
+	if ref.ID == "" {
+		return "", status.Error(codes.InvalidArgument, "Empty object ID.")
+	}
+
+	switch ref.Type {
+	case ServiceObjectType:
+		agents, err := models.FindAgents(q, models.AgentFilters{ServiceID: ref.ID})
+		if err != nil {
+			return "", err
+		}
+		return serviceStatus(agents), nil
+
+	case AgentObjectType:
+		agent, err := models.FindAgentByID(q, ref.ID)
+		if err != nil {
+			return "", err
+		}
+		return s.agentStatus(agent), nil
+
+	case ArtifactObjectType:
+		artifact, err := models.FindArtifactByID(q, ref.ID)
+		if errors.Is(err, models.ErrNotFound) {
+			return "", status.Errorf(codes.NotFound, "Artifact with ID %q not found.", ref.ID)
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(artifact.Status), nil
+
+	case JobObjectType:
+		job, err := models.FindJobResultByID(q, ref.ID)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case !job.Done:
+			return RunningJobStatus, nil
+		case job.Error != "":
+			return ErrorJobStatus, nil
+		default:
+			return DoneJobStatus, nil
+		}
+
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "Unsupported object type %q.", ref.Type)
+	}
+}
+
+// agentStatus returns Agent status; pmm-agent status is its connection state.
+func (s *StatusService) agentStatus(agent *models.Agent) string {
+	switch {
+	case agent.AgentType == models.PMMAgentType:
+		if s.r.IsConnected(agent.AgentID) {
+			return ConnectedStatus
+		}
+		return DisconnectedStatus
+	case agent.Status == "":
+		// external exporters do not report status
+		return models.AgentStatusUnknown
+	default:
+		return agent.Status
+	}
+}
+
+// serviceStatus returns status of the first enabled Service Agent that is not running,
+// or running status if all of them are running.
+func serviceStatus(agents []*models.Agent) string {
+	running := inventorypb.AgentStatus_RUNNING.String()
+	res := NoAgentsStatus
+	for _, agent := range agents {
+		if agent.Disabled {
+			continue
+		}
+		if agent.Status != running {
+			if agent.Status == "" {
+				return models.AgentStatusUnknown
+			}
+			return agent.Status
+		}
+		res = running
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package management
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestObjectStatus(t *testing.T) {
+	t.Run("Agent", func(t *testing.T) {
+		r := &mockAgentsRegistry{}
+		r.Test(t)
+		t.Cleanup(func() { r.AssertExpectations(t) })
+		r.On("IsConnected", "pmm-agent-1").Return(true)
+		r.On("IsConnected", "pmm-agent-2").Return(false)
+		s := NewStatusService(nil, r)
+
+		assert.Equal(t, ConnectedStatus, s.agentStatus(&models.Agent{AgentID: "pmm-agent-1", AgentType: models.PMMAgentType}))
+		assert.Equal(t, DisconnectedStatus, s.agentStatus(&models.Agent{AgentID: "pmm-agent-2", AgentType: models.PMMAgentType}))
+		assert.Equal(t, "WAITING", s.agentStatus(&models.Agent{AgentType: models.MySQLdExporterType, Status: "WAITING"}))
+		assert.Equal(t, models.AgentStatusUnknown, s.agentStatus(&models.Agent{AgentType: models.ExternalExporterType}))
+	})
+
+	t.Run("Service", func(t *testing.T) {
+		assert.Equal(t, NoAgentsStatus, serviceStatus(nil))
+		assert.Equal(t, "RUNNING", serviceStatus([]*models.Agent{
+			{Status: "RUNNING"},
+			{Status: "STOPPING", Disabled: true},
+		}))
+		assert.Equal(t, "WAITING", serviceStatus([]*models.Agent{
+			{Status: "RUNNING"},
+			{Status: "WAITING"},
+		}))
+	})
+}
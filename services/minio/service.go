@@ -19,6 +19,9 @@ package minio
 
 import (
 	"context"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -112,6 +115,127 @@ func (s *Service) RemoveRecursive(ctx context.Context, endpoint, accessKey, secr
 	return nil
 }
 
+// ListPrefixes returns top-level prefixes (without trailing slash) of all objects in the bucket
+// with the modification time of the newest object with that prefix.
+func (s *Service) ListPrefixes(ctx context.Context, endpoint, accessKey, secretKey, bucketName string) (map[string]time.Time, error) {
+	minioClient, err := newClient(endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]time.Time)
+	for object := range minioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return nil, errors.WithStack(object.Err)
+		}
+
+		parts := strings.SplitN(object.Key, "/", 2)
+		if len(parts) != 2 {
+			// object in the bucket root
+			continue
+		}
+		if t, ok := res[parts[0]]; !ok || object.LastModified.After(t) {
+			res[parts[0]] = object.LastModified
+		}
+	}
+
+	return res, nil
+}
+
+// GetPrefixSize returns total size of objects in the bucket with given prefix in bytes.
+func (s *Service) GetPrefixSize(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) (int64, error) {
+	minioClient, err := newClient(endpoint, accessKey, secretKey)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	options := minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}
+	for object := range minioClient.ListObjects(ctx, bucketName, options) {
+		if object.Err != nil {
+			return 0, errors.WithStack(object.Err)
+		}
+		size += object.Size
+	}
+
+	return size, nil
+}
+
+// ListObjectETags returns ETags of objects in the bucket with given prefix keyed by object name.
+func (s *Service) ListObjectETags(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) (map[string]string, error) {
+	minioClient, err := newClient(endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string)
+	options := minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}
+	for object := range minioClient.ListObjects(ctx, bucketName, options) {
+		if object.Err != nil {
+			return nil, errors.WithStack(object.Err)
+		}
+		res[object.Key] = object.ETag
+	}
+
+	return res, nil
+}
+
+// PrefixExists returns true if there is at least one object in the bucket with given prefix.
+func (s *Service) PrefixExists(ctx context.Context, endpoint, accessKey, secretKey, bucketName, prefix string) (bool, error) {
+	minioClient, err := newClient(endpoint, accessKey, secretKey)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	options := minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+		MaxKeys:   1,
+	}
+	for object := range minioClient.ListObjects(ctx, bucketName, options) {
+		if object.Err != nil {
+			return false, errors.WithStack(object.Err)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// PutObject uploads data of unknown size from r to the bucket as object with given name.
+func (s *Service) PutObject(ctx context.Context, endpoint, accessKey, secretKey, bucketName, objectName string, r io.Reader) error {
+	minioClient, err := newClient(endpoint, accessKey, secretKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = minioClient.PutObject(ctx, bucketName, objectName, r, -1, minio.PutObjectOptions{})
+	return errors.WithStack(err)
+}
+
+// GetObject returns reader of object with given name from the bucket. Caller should close it.
+func (s *Service) GetObject(ctx context.Context, endpoint, accessKey, secretKey, bucketName, objectName string) (io.ReadCloser, error) {
+	minioClient, err := newClient(endpoint, accessKey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := minioClient.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return object, nil
+}
+
 func newClient(endpoint, accessKey, secretKey string) (*minio.Client, error) {
 	url, err := models.ParseEndpoint(endpoint)
 	if err != nil {
@@ -0,0 +1,266 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package operations provides a uniform view of long-running operations
+// like backups, restores and PMM Server updates.
+package operations
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// UpdateOperationID is an ID of PMM Server update operation.
+// There can be only one update running at a time, so it is constant.
+const UpdateOperationID = "/operation_id/pmm-update"
+
+// OperationType represents long-running operation type.
+type OperationType string
+
+// Supported operation types.
+const (
+	BackupOperationType  OperationType = "backup"
+	RestoreOperationType OperationType = "restore"
+	UpdateOperationType  OperationType = "pmm_update"
+)
+
+// jobOperationTypes maps job types to operation types.
+var jobOperationTypes = map[models.JobType]OperationType{
+	models.MySQLBackupJob:          BackupOperationType,
+	models.MongoDBBackupJob:        BackupOperationType,
+	models.ConfigSnapshotBackupJob: BackupOperationType,
+	models.MySQLLogicalBackupJob:   BackupOperationType,
+	models.MySQLRestoreBackupJob:   RestoreOperationType,
+	models.MongoDBRestoreBackupJob: RestoreOperationType,
+}
+
+// Operation represents a single long-running operation.
+type Operation struct {
+	ID         string              `json:"id"`
+	Type       OperationType       `json:"type"`
+	Done       bool                `json:"done"`
+	Error      string              `json:"error,omitempty"`
+	ArtifactID string              `json:"artifact_id,omitempty"`
+	RestoreID  string              `json:"restore_id,omitempty"`
+	Progress   *models.JobProgress `json:"progress,omitempty"`
+	CreatedAt  *time.Time          `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time          `json:"updated_at,omitempty"`
+	// Predicted completion time of running operation based on durations of previous ones, if known.
+	ETA *time.Time `json:"eta,omitempty"`
+}
+
+// ListOperationsParams represents operations list filters.
+type ListOperationsParams struct {
+	// Return only operations of that type.
+	Type OperationType
+	// Return only finished (true) or running (false) operations.
+	Done *bool
+	// Return at most that number of job operations; 0 means no limit.
+	Limit int
+}
+
+// Service provides uniform access to long-running operations.
+type Service struct {
+	db          *reform.DB
+	backup      backupService
+	supervisord supervisordService
+	l           *logrus.Entry
+}
+
+// New creates new operations service.
+func New(db *reform.DB, backup backupService, supervisord supervisordService) *Service {
+	return &Service{
+		db:          db,
+		backup:      backup,
+		supervisord: supervisord,
+		l:           logrus.WithField("component", "operations"),
+	}
+}
+
+// GetOperation returns operation with given ID.
+func (s *Service) GetOperation(ctx context.Context, id string) (*Operation, error) {
+	if id == UpdateOperationID {
+		return s.updateOperation(), nil
+	}
+
+	var op *Operation
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		job, err := findOperationJob(tx.Querier, id)
+		if err != nil {
+			return err
+		}
+
+		op, err = jobOperationWithETA(tx.Querier, job)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// ListOperations returns operations matching given parameters, newest first.
+// PMM Server update operation is returned only while update is running.
+func (s *Service) ListOperations(ctx context.Context, params ListOperationsParams) ([]*Operation, error) {
+	var types []models.JobType
+	for jobType, t := range jobOperationTypes {
+		if params.Type == "" || params.Type == t {
+			types = append(types, jobType)
+		}
+	}
+
+	var res []*Operation
+	if params.Type == "" || params.Type == UpdateOperationType {
+		if op := s.updateOperation(); !op.Done && (params.Done == nil || !*params.Done) {
+			res = append(res, op)
+		}
+	}
+
+	if len(types) == 0 {
+		switch params.Type {
+		case UpdateOperationType:
+			return res, nil
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown operation type %q.", params.Type)
+		}
+	}
+
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		jobs, err := models.FindJobResults(tx.Querier, models.JobResultFilters{
+			Types: types,
+			Done:  params.Done,
+			Limit: params.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, job := range jobs {
+			op, err := jobOperationWithETA(tx.Querier, job)
+			if err != nil {
+				return err
+			}
+			res = append(res, op)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CancelOperation cancels running operation with given ID.
+// Only backups can be canceled; restores and PMM Server updates can't be safely interrupted.
+func (s *Service) CancelOperation(ctx context.Context, id string) error {
+	op, err := s.GetOperation(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if op.Done {
+		return status.Errorf(codes.FailedPrecondition, "Operation with ID %q is already done.", id)
+	}
+
+	switch op.Type {
+	case BackupOperationType:
+		return s.backup.CancelBackup(ctx, op.ArtifactID)
+	default:
+		return status.Errorf(codes.FailedPrecondition, "Operation of type %q can't be canceled.", op.Type)
+	}
+}
+
+// updateOperation returns PMM Server update operation.
+func (s *Service) updateOperation() *Operation {
+	return &Operation{
+		ID:   UpdateOperationID,
+		Type: UpdateOperationType,
+		Done: !s.supervisord.UpdateRunning(),
+	}
+}
+
+// jobOperationWithETA converts job result to operation with predicted completion time.
+func jobOperationWithETA(q *reform.Querier, job *models.JobResult) (*Operation, error) {
+	op := jobOperation(job)
+	if op.Done {
+		return op, nil
+	}
+
+	eta, err := models.PredictJobCompletion(q, job)
+	if err != nil {
+		return nil, err
+	}
+	op.ETA = eta
+	return op, nil
+}
+
+// findOperationJob returns job result with given ID that represents an operation.
+func findOperationJob(q *reform.Querier, id string) (*models.JobResult, error) {
+	job, err := models.FindJobResultByID(q, id)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "Operation with ID %q not found.", id)
+		}
+		return nil, err
+	}
+
+	if _, ok := jobOperationTypes[job.Type]; !ok {
+		return nil, status.Errorf(codes.NotFound, "Operation with ID %q not found.", id)
+	}
+	return job, nil
+}
+
+// jobOperation converts job result to operation.
+func jobOperation(job *models.JobResult) *Operation {
+	createdAt, updatedAt := job.CreatedAt, job.UpdatedAt
+	op := &Operation{
+		ID:        job.ID,
+		Type:      jobOperationTypes[job.Type],
+		Done:      job.Done,
+		Error:     job.Error,
+		CreatedAt: &createdAt,
+		UpdatedAt: &updatedAt,
+	}
+
+	if r := job.Result; r != nil {
+		switch {
+		case r.MySQLBackup != nil:
+			op.ArtifactID = r.MySQLBackup.ArtifactID
+		case r.MongoDBBackup != nil:
+			op.ArtifactID = r.MongoDBBackup.ArtifactID
+		case r.ConfigSnapshotBackup != nil:
+			op.ArtifactID = r.ConfigSnapshotBackup.ArtifactID
+		case r.MySQLLogicalBackup != nil:
+			op.ArtifactID = r.MySQLLogicalBackup.ArtifactID
+		case r.MySQLRestoreBackup != nil:
+			op.RestoreID = r.MySQLRestoreBackup.RestoreID
+		case r.MongoDBRestoreBackup != nil:
+			op.RestoreID = r.MongoDBRestoreBackup.RestoreID
+		}
+		op.Progress = r.Progress
+	}
+
+	return op
+}
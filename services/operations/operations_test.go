@@ -0,0 +1,131 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package operations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestOperations(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	backup, err := models.CreateJobResult(db.Querier, "pmm_agent_id", models.MySQLBackupJob, &models.JobResultData{
+		MySQLBackup: &models.MySQLBackupJobResult{ArtifactID: "artifact_id"},
+	})
+	require.NoError(t, err)
+	restore, err := models.CreateJobResult(db.Querier, "pmm_agent_id", models.MongoDBRestoreBackupJob, &models.JobResultData{
+		MongoDBRestoreBackup: &models.MongoDBRestoreBackupJobResult{RestoreID: "restore_id"},
+	})
+	require.NoError(t, err)
+	restore.Done = true
+	require.NoError(t, db.Update(restore))
+	echo, err := models.CreateJobResult(db.Querier, "pmm_agent_id", models.Echo, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		for _, job := range []*models.JobResult{backup, restore, echo} {
+			require.NoError(t, db.Delete(job))
+		}
+	})
+
+	backupService := &mockBackupService{}
+	supervisord := &mockSupervisordService{}
+	t.Cleanup(func() {
+		backupService.AssertExpectations(t)
+		supervisord.AssertExpectations(t)
+	})
+	s := New(db, backupService, supervisord)
+
+	t.Run("Get", func(t *testing.T) {
+		op, err := s.GetOperation(ctx, backup.ID)
+		require.NoError(t, err)
+		assert.Equal(t, BackupOperationType, op.Type)
+		assert.Equal(t, "artifact_id", op.ArtifactID)
+		assert.False(t, op.Done)
+
+		op, err = s.GetOperation(ctx, restore.ID)
+		require.NoError(t, err)
+		assert.Equal(t, RestoreOperationType, op.Type)
+		assert.Equal(t, "restore_id", op.RestoreID)
+		assert.True(t, op.Done)
+
+		_, err = s.GetOperation(ctx, echo.ID)
+		tests.AssertGRPCError(t, status.Newf(codes.NotFound, "Operation with ID %q not found.", echo.ID), err)
+
+		supervisord.On("UpdateRunning").Return(true).Once()
+		op, err = s.GetOperation(ctx, UpdateOperationID)
+		require.NoError(t, err)
+		assert.Equal(t, UpdateOperationType, op.Type)
+		assert.False(t, op.Done)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		supervisord.On("UpdateRunning").Return(false).Once()
+		ops, err := s.ListOperations(ctx, ListOperationsParams{})
+		require.NoError(t, err)
+		ids := make([]string, len(ops))
+		for i, op := range ops {
+			ids[i] = op.ID
+		}
+		assert.ElementsMatch(t, []string{backup.ID, restore.ID}, ids)
+
+		supervisord.On("UpdateRunning").Return(true).Once()
+		ops, err = s.ListOperations(ctx, ListOperationsParams{Done: pointer.ToBool(false)})
+		require.NoError(t, err)
+		require.Len(t, ops, 2)
+		assert.Equal(t, UpdateOperationID, ops[0].ID)
+		assert.Equal(t, backup.ID, ops[1].ID)
+
+		ops, err = s.ListOperations(ctx, ListOperationsParams{Type: RestoreOperationType})
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		assert.Equal(t, restore.ID, ops[0].ID)
+
+		_, err = s.ListOperations(ctx, ListOperationsParams{Type: "unknown"})
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Unknown operation type "unknown".`), err)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		backupService.On("CancelBackup", mock.Anything, "artifact_id").Return(nil).Once()
+		require.NoError(t, s.CancelOperation(ctx, backup.ID))
+
+		err := s.CancelOperation(ctx, restore.ID)
+		tests.AssertGRPCError(t, status.Newf(codes.FailedPrecondition, "Operation with ID %q is already done.", restore.ID), err)
+
+		supervisord.On("UpdateRunning").Return(true).Once()
+		err = s.CancelOperation(ctx, UpdateOperationID)
+		tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, `Operation of type "pmm_update" can't be canceled.`), err)
+	})
+}
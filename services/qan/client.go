@@ -36,6 +36,7 @@ import (
 // Client represents qan-api client for data collection.
 type Client struct {
 	c  qanCollectorClient
+	pc qanProfileClient
 	db *reform.DB
 	l  *logrus.Entry
 }
@@ -44,6 +45,7 @@ type Client struct {
 func NewClient(cc *grpc.ClientConn, db *reform.DB) *Client {
 	return &Client{
 		c:  qanpb.NewCollectorClient(cc),
+		pc: qanpb.NewProfileClient(cc),
 		db: db,
 		l:  logrus.WithField("component", "qan"),
 	}
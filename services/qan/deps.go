@@ -24,9 +24,16 @@ import (
 )
 
 //go:generate mockery -name=qanCollectorClient  -case=snake -inpkg -testonly
+//go:generate mockery -name=qanProfileClient -case=snake -inpkg -testonly
 
 // qanClient is a subset of methods of qanpb.CollectorClient used by this package.
 // We use it instead of real type for testing.
 type qanCollectorClient interface {
 	Collect(ctx context.Context, in *qanpb.CollectRequest, opts ...grpc.CallOption) (*qanpb.CollectResponse, error)
 }
+
+// qanProfileClient is a subset of methods of qanpb.ProfileClient used by this package.
+// We use it instead of real type for testing.
+type qanProfileClient interface {
+	GetReport(ctx context.Context, in *qanpb.ReportRequest, opts ...grpc.CallOption) (*qanpb.ReportReply, error)
+}
@@ -0,0 +1,264 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package qan
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/percona/pmm/api/qanpb"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// exportChunkSize is a number of rows requested from qan-api at once.
+const exportChunkSize = 500
+
+// ExportFormat represents QAN data export format.
+type ExportFormat string
+
+// Supported export formats.
+const (
+	CSVExportFormat  ExportFormat = "csv"
+	JSONExportFormat ExportFormat = "json"
+)
+
+var (
+	defaultExportGroupBy = "queryid"
+	defaultExportColumns = []string{"load", "num_queries", "query_time"}
+)
+
+// ExportParams represents QAN data export parameters.
+type ExportParams struct {
+	PeriodStartFrom time.Time
+	PeriodStartTo   time.Time
+	// Dimension to group by: queryid (default), service_name, database, schema, username, client_host, etc.
+	GroupBy string
+	// Return only rows of those Services.
+	ServiceNames []string
+	// Return only rows with those label values.
+	Labels map[string][]string
+	// Return only rows with dimension or fingerprint matching that string.
+	Search string
+	// Metrics to include, see defaultExportColumns.
+	Columns []string
+	Format  ExportFormat
+}
+
+// Validate validates export parameters and fills defaults.
+func (p *ExportParams) Validate() error {
+	if p.PeriodStartFrom.IsZero() || p.PeriodStartTo.IsZero() {
+		return errors.New("both period start from and period start to are required")
+	}
+	if !p.PeriodStartFrom.Before(p.PeriodStartTo) {
+		return errors.New("period start from should be before period start to")
+	}
+
+	switch p.Format {
+	case "":
+		p.Format = CSVExportFormat
+	case CSVExportFormat, JSONExportFormat:
+	default:
+		return errors.Errorf("unsupported export format %q", p.Format)
+	}
+
+	if p.GroupBy == "" {
+		p.GroupBy = defaultExportGroupBy
+	}
+	if len(p.Columns) == 0 {
+		p.Columns = defaultExportColumns
+	}
+
+	return nil
+}
+
+// exportRow represents a single row of exported data.
+type exportRow struct {
+	Dimension   string                `json:"dimension"`
+	Database    string                `json:"database,omitempty"`
+	Fingerprint string                `json:"fingerprint,omitempty"`
+	NumQueries  uint32                `json:"num_queries"`
+	QPS         float32               `json:"qps"`
+	Load        float32               `json:"load"`
+	Metrics     map[string]exportStat `json:"metrics,omitempty"`
+}
+
+// exportStat represents aggregated values of a single metric.
+type exportStat struct {
+	Cnt float32 `json:"cnt"`
+	Sum float32 `json:"sum"`
+	Min float32 `json:"min"`
+	Max float32 `json:"max"`
+	Avg float32 `json:"avg"`
+	P99 float32 `json:"p99"`
+}
+
+// exportStatFields are names of exportStat fields in CSV header.
+var exportStatFields = []string{"cnt", "sum", "min", "max", "avg", "p99"}
+
+func (s exportStat) values() []float32 {
+	return []float32{s.Cnt, s.Sum, s.Min, s.Max, s.Avg, s.P99}
+}
+
+// Export writes aggregated QAN data for given parameters to w.
+// Data is requested from qan-api and written in chunks, so it does not have to fit in memory.
+func (c *Client) Export(ctx context.Context, w io.Writer, params *ExportParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	labels := make([]*qanpb.ReportMapFieldEntry, 0, len(params.Labels)+1)
+	if len(params.ServiceNames) != 0 {
+		labels = append(labels, &qanpb.ReportMapFieldEntry{Key: "service_name", Value: params.ServiceNames})
+	}
+	keys := make([]string, 0, len(params.Labels))
+	for k := range params.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		labels = append(labels, &qanpb.ReportMapFieldEntry{Key: k, Value: params.Labels[k]})
+	}
+
+	var csvW *csv.Writer
+	var jsonE *json.Encoder
+	var metricColumns []string
+	for _, column := range params.Columns {
+		if column != "load" && column != "num_queries" {
+			metricColumns = append(metricColumns, column)
+		}
+	}
+
+	switch params.Format {
+	case CSVExportFormat:
+		csvW = csv.NewWriter(w)
+		header := []string{"dimension", "database", "fingerprint", "num_queries", "qps", "load"}
+		for _, column := range metricColumns {
+			for _, f := range exportStatFields {
+				header = append(header, column+"_"+f)
+			}
+		}
+		if err := csvW.Write(header); err != nil {
+			return errors.WithStack(err)
+		}
+	case JSONExportFormat:
+		jsonE = json.NewEncoder(w)
+	}
+
+	var offset uint32
+	for {
+		resp, err := c.pc.GetReport(ctx, &qanpb.ReportRequest{
+			PeriodStartFrom: timestamppb.New(params.PeriodStartFrom),
+			PeriodStartTo:   timestamppb.New(params.PeriodStartTo),
+			GroupBy:         params.GroupBy,
+			Labels:          labels,
+			Columns:         params.Columns,
+			OrderBy:         "-load",
+			MainMetric:      "load",
+			Search:          params.Search,
+			Offset:          offset,
+			Limit:           exportChunkSize,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to get QAN report")
+		}
+
+		var n uint32
+		for _, r := range resp.Rows {
+			// skip total row
+			if r.Dimension == "" {
+				continue
+			}
+			n++
+
+			row := convertExportRow(r, metricColumns)
+			switch params.Format {
+			case CSVExportFormat:
+				record := []string{
+					row.Dimension,
+					row.Database,
+					row.Fingerprint,
+					strconv.FormatUint(uint64(row.NumQueries), 10),
+					formatFloat(row.QPS),
+					formatFloat(row.Load),
+				}
+				for _, column := range metricColumns {
+					for _, v := range row.Metrics[column].values() {
+						record = append(record, formatFloat(v))
+					}
+				}
+				err = csvW.Write(record)
+			case JSONExportFormat:
+				err = jsonE.Encode(row)
+			}
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if csvW != nil {
+			csvW.Flush()
+			if err = csvW.Error(); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		offset += exportChunkSize
+		if n == 0 || offset >= resp.TotalRows {
+			return nil
+		}
+	}
+}
+
+// convertExportRow converts qan-api report row.
+func convertExportRow(r *qanpb.Row, metricColumns []string) *exportRow {
+	row := &exportRow{
+		Dimension:   r.Dimension,
+		Database:    r.Database,
+		Fingerprint: r.Fingerprint,
+		NumQueries:  r.NumQueries,
+		QPS:         r.Qps,
+		Load:        r.Load,
+		Metrics:     make(map[string]exportStat, len(metricColumns)),
+	}
+
+	for _, column := range metricColumns {
+		m := r.Metrics[column]
+		if m == nil || m.Stats == nil {
+			continue
+		}
+		row.Metrics[column] = exportStat{
+			Cnt: m.Stats.Cnt,
+			Sum: m.Stats.Sum,
+			Min: m.Stats.Min,
+			Max: m.Stats.Max,
+			Avg: m.Stats.Avg,
+			P99: m.Stats.P99,
+		}
+	}
+
+	return row
+}
+
+func formatFloat(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
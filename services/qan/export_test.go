@@ -0,0 +1,129 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package qan
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/percona/pmm/api/qanpb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	reply := &qanpb.ReportReply{
+		TotalRows: 1,
+		Rows: []*qanpb.Row{
+			{
+				// total
+				NumQueries: 100,
+			},
+			{
+				Dimension:   "ABCDEF",
+				Database:    "sbtest",
+				Fingerprint: "SELECT ?",
+				NumQueries:  100,
+				Qps:         1.5,
+				Load:        0.25,
+				Metrics: map[string]*qanpb.Metric{
+					"query_time": {Stats: &qanpb.Stat{Cnt: 100, Sum: 10, Min: 0.01, Max: 1, Avg: 0.1, P99: 0.5}},
+				},
+			},
+		},
+	}
+
+	setup := func(t *testing.T) *Client {
+		t.Helper()
+
+		pc := new(mockQanProfileClient)
+		pc.Test(t)
+		t.Cleanup(func() { pc.AssertExpectations(t) })
+
+		pc.On("GetReport", ctx, mock.MatchedBy(func(req *qanpb.ReportRequest) bool {
+			return req.GroupBy == "queryid" &&
+				req.Offset == 0 &&
+				req.Limit == exportChunkSize &&
+				len(req.Labels) == 2 &&
+				req.Labels[0].Key == "service_name" &&
+				req.Labels[1].Key == "environment" &&
+				req.PeriodStartFrom.AsTime().Equal(from) &&
+				req.PeriodStartTo.AsTime().Equal(to)
+		})).Return(reply, nil).Once()
+
+		return &Client{
+			pc: pc,
+			l:  logrus.WithField("test", t.Name()),
+		}
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		c := setup(t)
+		var buf bytes.Buffer
+		err := c.Export(ctx, &buf, &ExportParams{
+			PeriodStartFrom: from,
+			PeriodStartTo:   to,
+			ServiceNames:    []string{"mysql1"},
+			Labels:          map[string][]string{"environment": {"prod"}},
+		})
+		require.NoError(t, err)
+		expected := "dimension,database,fingerprint,num_queries,qps,load," +
+			"query_time_cnt,query_time_sum,query_time_min,query_time_max,query_time_avg,query_time_p99\n" +
+			"ABCDEF,sbtest,SELECT ?,100,1.5,0.25,100,10,0.01,1,0.1,0.5\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		c := setup(t)
+		var buf bytes.Buffer
+		err := c.Export(ctx, &buf, &ExportParams{
+			PeriodStartFrom: from,
+			PeriodStartTo:   to,
+			ServiceNames:    []string{"mysql1"},
+			Labels:          map[string][]string{"environment": {"prod"}},
+			Format:          JSONExportFormat,
+		})
+		require.NoError(t, err)
+		expected := `{"dimension":"ABCDEF","database":"sbtest","fingerprint":"SELECT ?","num_queries":100,"qps":1.5,"load":0.25,` +
+			`"metrics":{"query_time":{"cnt":100,"sum":10,"min":0.01,"max":1,"avg":0.1,"p99":0.5}}}` + "\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		c := &Client{}
+		err := c.Export(ctx, &bytes.Buffer{}, &ExportParams{
+			PeriodStartFrom: to,
+			PeriodStartTo:   from,
+		})
+		assert.EqualError(t, err, "period start from should be before period start to")
+
+		err = c.Export(ctx, &bytes.Buffer{}, &ExportParams{
+			PeriodStartFrom: from,
+			PeriodStartTo:   to,
+			Format:          "xml",
+		})
+		assert.EqualError(t, err, `unsupported export format "xml"`)
+	})
+}
@@ -0,0 +1,245 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package qan
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/percona/pmm/api/qanpb"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/stringset"
+)
+
+const (
+	prometheusNamespace = "pmm_managed"
+	prometheusSubsystem = "qan"
+
+	recordInterval   = time.Minute
+	recordTimeout    = 30 * time.Second
+	recordTopQueries = 10
+)
+
+var (
+	qanAgentTypes = []models.AgentType{
+		models.QANMySQLPerfSchemaAgentType,
+		models.QANMySQLSlowlogAgentType,
+		models.QANMongoDBProfilerAgentType,
+		models.QANPostgreSQLPgStatementsAgentType,
+		models.QANPostgreSQLPgStatMonitorAgentType,
+	}
+
+	recordLabels = []string{"service_id", "service_name", "queryid"}
+
+	mQueriesPerSecondDesc = prom.NewDesc(
+		prom.BuildFQName(prometheusNamespace, prometheusSubsystem, "query_qps"),
+		"Queries per second for the query over the last recording interval.",
+		recordLabels,
+		nil,
+	)
+	mLoadDesc = prom.NewDesc(
+		prom.BuildFQName(prometheusNamespace, prometheusSubsystem, "query_load"),
+		"Load (average number of active queries) for the query over the last recording interval.",
+		recordLabels,
+		nil,
+	)
+	mQueryTimeAvgDesc = prom.NewDesc(
+		prom.BuildFQName(prometheusNamespace, prometheusSubsystem, "query_time_avg_seconds"),
+		"Average query execution time over the last recording interval.",
+		recordLabels,
+		nil,
+	)
+	mQueryTimeP99Desc = prom.NewDesc(
+		prom.BuildFQName(prometheusNamespace, prometheusSubsystem, "query_time_p99_seconds"),
+		"99th percentile of query execution time over the last recording interval.",
+		recordLabels,
+		nil,
+	)
+)
+
+// recordedQuery contains QAN aggregates of a single query of a single Service.
+type recordedQuery struct {
+	serviceID    string
+	serviceName  string
+	queryID      string
+	qps          float64
+	load         float64
+	queryTimeAvg float64
+	queryTimeP99 float64
+}
+
+// Recorder periodically materializes QAN aggregates of top queries as pmm-managed metrics.
+// Those metrics are scraped into VictoriaMetrics, so Integrated Alerting rule templates can use them.
+type Recorder struct {
+	c  qanProfileClient
+	db *reform.DB
+	l  *logrus.Entry
+
+	rw      sync.RWMutex
+	queries []recordedQuery
+}
+
+// NewRecorder returns new recorder for given gRPC connection.
+func NewRecorder(cc *grpc.ClientConn, db *reform.DB) *Recorder {
+	return &Recorder{
+		c:  qanpb.NewProfileClient(cc),
+		db: db,
+		l:  logrus.WithField("component", "qan/recorder"),
+	}
+}
+
+// Run records QAN aggregates until context is canceled.
+func (r *Recorder) Run(ctx context.Context) {
+	r.l.Info("Starting...")
+	defer r.l.Info("Done.")
+
+	ticker := time.NewTicker(recordInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			nCtx, cancel := context.WithTimeout(ctx, recordTimeout)
+			if err := r.record(nCtx, time.Now()); err != nil {
+				r.l.Errorf("Failed to record QAN aggregates: %+v.", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// record fetches top queries for all Services with QAN Agents for the interval ending at given time.
+func (r *Recorder) record(ctx context.Context, now time.Time) error {
+	services, err := r.findServices()
+	if err != nil {
+		return err
+	}
+
+	var queries []recordedQuery
+	for _, service := range services {
+		resp, err := r.c.GetReport(ctx, &qanpb.ReportRequest{
+			PeriodStartFrom: timestamppb.New(now.Add(-recordInterval)),
+			PeriodStartTo:   timestamppb.New(now),
+			GroupBy:         "queryid",
+			Labels: []*qanpb.ReportMapFieldEntry{{
+				Key:   "service_name",
+				Value: []string{service.ServiceName},
+			}},
+			Columns:    []string{"load", "num_queries", "query_time"},
+			OrderBy:    "-load",
+			MainMetric: "load",
+			Limit:      recordTopQueries,
+		})
+		if err != nil {
+			// keep going: other Services may be fine
+			r.l.Warnf("Failed to get QAN report for %q: %s.", service.ServiceName, err)
+			continue
+		}
+
+		for _, row := range resp.Rows {
+			// skip total row
+			if row.Dimension == "" {
+				continue
+			}
+
+			q := recordedQuery{
+				serviceID:   service.ServiceID,
+				serviceName: service.ServiceName,
+				queryID:     row.Dimension,
+				qps:         float64(row.Qps),
+				load:        float64(row.Load),
+			}
+			if m := row.Metrics["query_time"]; m != nil && m.Stats != nil {
+				q.queryTimeAvg = float64(m.Stats.Avg)
+				q.queryTimeP99 = float64(m.Stats.P99)
+			}
+			queries = append(queries, q)
+		}
+	}
+
+	r.rw.Lock()
+	r.queries = queries
+	r.rw.Unlock()
+
+	return nil
+}
+
+// findServices returns Services with at least one QAN Agent.
+func (r *Recorder) findServices() ([]*models.Service, error) {
+	var services []*models.Service
+	err := r.db.InTransaction(func(tx *reform.TX) error {
+		serviceIDs := make(map[string]struct{})
+		for _, agentType := range qanAgentTypes {
+			agentType := agentType
+			agents, err := models.FindAgents(tx.Querier, models.AgentFilters{AgentType: &agentType})
+			if err != nil {
+				return err
+			}
+			for _, agent := range agents {
+				if agent.ServiceID != nil && !agent.Disabled {
+					serviceIDs[*agent.ServiceID] = struct{}{}
+				}
+			}
+		}
+
+		m, err := models.FindServicesByIDs(tx.Querier, stringset.ToSlice(serviceIDs))
+		if err != nil {
+			return err
+		}
+		for _, service := range m {
+			services = append(services, service)
+		}
+		return nil
+	})
+	return services, err
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prom.Desc) {
+	ch <- mQueriesPerSecondDesc
+	ch <- mLoadDesc
+	ch <- mQueryTimeAvgDesc
+	ch <- mQueryTimeP99Desc
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prom.Metric) {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+
+	for _, q := range r.queries {
+		labels := []string{q.serviceID, q.serviceName, q.queryID}
+		ch <- prom.MustNewConstMetric(mQueriesPerSecondDesc, prom.GaugeValue, q.qps, labels...)
+		ch <- prom.MustNewConstMetric(mLoadDesc, prom.GaugeValue, q.load, labels...)
+		ch <- prom.MustNewConstMetric(mQueryTimeAvgDesc, prom.GaugeValue, q.queryTimeAvg, labels...)
+		ch <- prom.MustNewConstMetric(mQueryTimeP99Desc, prom.GaugeValue, q.queryTimeP99, labels...)
+	}
+}
+
+// Check interfaces.
+var (
+	_ prom.Collector = (*Recorder)(nil)
+)
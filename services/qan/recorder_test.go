@@ -0,0 +1,103 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package qan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/percona/pmm/api/qanpb"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestRecorder(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	service, err := models.FindServiceByName(db.Querier, models.PMMServerPostgreSQLServiceName)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	c := new(mockQanProfileClient)
+	c.Test(t)
+	t.Cleanup(func() { c.AssertExpectations(t) })
+
+	c.On("GetReport", ctx, mock.MatchedBy(func(req *qanpb.ReportRequest) bool {
+		return req.GroupBy == "queryid" &&
+			len(req.Labels) == 1 &&
+			req.Labels[0].Key == "service_name" &&
+			req.Labels[0].Value[0] == service.ServiceName &&
+			req.PeriodStartTo.AsTime().Equal(now)
+	})).Return(&qanpb.ReportReply{
+		Rows: []*qanpb.Row{
+			{
+				// total
+				Qps:  10,
+				Load: 2,
+			},
+			{
+				Dimension: "ABCDEF",
+				Qps:       4,
+				Load:      0.5,
+				Metrics: map[string]*qanpb.Metric{
+					"query_time": {Stats: &qanpb.Stat{Avg: 0.25, P99: 1}},
+				},
+			},
+		},
+	}, nil)
+
+	r := &Recorder{
+		c:  c,
+		db: db,
+		l:  logrus.WithField("component", "qan/recorder"),
+	}
+	require.NoError(t, r.record(ctx, now))
+
+	labels := fmt.Sprintf(`queryid="ABCDEF",service_id=%q,service_name=%q`, service.ServiceID, service.ServiceName)
+	expected := strings.NewReader(fmt.Sprintf(`
+		# HELP pmm_managed_qan_query_load Load (average number of active queries) for the query over the last recording interval.
+		# TYPE pmm_managed_qan_query_load gauge
+		pmm_managed_qan_query_load{%[1]s} 0.5
+		# HELP pmm_managed_qan_query_qps Queries per second for the query over the last recording interval.
+		# TYPE pmm_managed_qan_query_qps gauge
+		pmm_managed_qan_query_qps{%[1]s} 4
+		# HELP pmm_managed_qan_query_time_avg_seconds Average query execution time over the last recording interval.
+		# TYPE pmm_managed_qan_query_time_avg_seconds gauge
+		pmm_managed_qan_query_time_avg_seconds{%[1]s} 0.25
+		# HELP pmm_managed_qan_query_time_p99_seconds 99th percentile of query execution time over the last recording interval.
+		# TYPE pmm_managed_qan_query_time_p99_seconds gauge
+		pmm_managed_qan_query_time_p99_seconds{%[1]s} 1
+	`, labels))
+	assert.NoError(t, promtest.CollectAndCompare(r, expected))
+}
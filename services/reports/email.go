@@ -0,0 +1,157 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package reports
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// send delivers HTML report to recipients of given email channels
+// using Integrated Alerting email settings.
+func (s *Service) send(channelIDs []string, subject string, body []byte) error {
+	var settings *models.EmailAlertingSettings
+	var to []string
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		st, err := models.GetSettings(tx)
+		if err != nil {
+			return err
+		}
+		settings = st.IntegratedAlerting.EmailAlertingSettings
+
+		channels, err := models.FindChannelsByIDs(tx.Querier, channelIDs)
+		if err != nil {
+			return err
+		}
+		to = emailRecipients(channels)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if settings == nil {
+		return errors.New("email settings are not configured")
+	}
+	if len(to) == 0 {
+		s.l.Warnf("No email recipients found for channels %v.", channelIDs)
+		return nil
+	}
+
+	return sendEmail(settings, to, subject, body)
+}
+
+// emailRecipients returns recipients of enabled email channels.
+func emailRecipients(channels []*models.Channel) []string {
+	seen := make(map[string]struct{})
+	var res []string
+	for _, c := range channels {
+		if c.Disabled || c.Type != models.Email || c.EmailConfig == nil {
+			continue
+		}
+		for _, addr := range c.EmailConfig.To {
+			if _, ok := seen[addr]; ok {
+				continue
+			}
+			seen[addr] = struct{}{}
+			res = append(res, addr)
+		}
+	}
+	return res
+}
+
+// buildMessage returns RFC 5322 message with HTML body.
+func buildMessage(from string, to []string, subject string, body []byte, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// sendEmail sends message via SMTP smarthost the same way Alertmanager does it.
+func sendEmail(settings *models.EmailAlertingSettings, to []string, subject string, body []byte) error {
+	host, _, err := net.SplitHostPort(settings.Smarthost)
+	if err != nil {
+		return errors.Wrapf(err, "invalid smarthost %q", settings.Smarthost)
+	}
+
+	c, err := smtp.Dial(settings.Smarthost)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer c.Close() //nolint:errcheck
+
+	if settings.Hello != "" {
+		if err = c.Hello(settings.Hello); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: host}); err != nil { //nolint:gosec
+			return errors.WithStack(err)
+		}
+	}
+
+	switch {
+	case settings.Secret != "":
+		err = c.Auth(smtp.CRAMMD5Auth(settings.Username, settings.Secret))
+	case settings.Username != "":
+		err = c.Auth(smtp.PlainAuth(settings.Identity, settings.Username, settings.Password, host))
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = c.Mail(settings.From); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, addr := range to {
+		if err = c.Rcpt(addr); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = w.Write(buildMessage(settings.From, to, subject, body, time.Now())); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = w.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(c.Quit())
+}
@@ -0,0 +1,257 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package reports generates metrics reports.
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/dir"
+)
+
+const (
+	// Dir is a directory where generated reports are stored.
+	Dir     = "/srv/pmm-managed/reports"
+	dirPerm = os.FileMode(0o775)
+
+	queryTimeout = 30 * time.Second
+	// maximal number of points per series in a range query
+	maxPoints = 300
+)
+
+var fileNameRE = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Service generates reports from VictoriaMetrics data and delivers them.
+type Service struct {
+	db  *reform.DB
+	api v1.API
+	dir string
+	l   *logrus.Entry
+}
+
+// New creates new reports service for VictoriaMetrics with given base URL.
+func New(db *reform.DB, victoriaMetricsURL string) (*Service, error) {
+	client, err := api.NewClient(api.Config{
+		Address: victoriaMetricsURL,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Service{
+		db:  db,
+		api: v1.NewAPI(client),
+		dir: Dir,
+		l:   logrus.WithField("component", "reports"),
+	}, nil
+}
+
+// seriesSummary contains aggregated values of a single series over report period.
+type seriesSummary struct {
+	Labels string
+	Min    float64
+	Avg    float64
+	Max    float64
+	Last   float64
+}
+
+// section contains a single rendered report section.
+type section struct {
+	Title  string
+	Query  string
+	Error  string
+	Series []seriesSummary
+}
+
+// report contains data for report template.
+type report struct {
+	Name        string
+	Description string
+	From        time.Time
+	To          time.Time
+	Sections    []section
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Name }}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+td.value { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{ .Name }}</h1>
+{{ if .Description }}<p>{{ .Description }}</p>{{ end }}
+<p>From {{ .From.Format "2006-01-02 15:04:05 MST" }} to {{ .To.Format "2006-01-02 15:04:05 MST" }}.</p>
+{{ range .Sections }}
+<h2>{{ .Title }}</h2>
+<p><code>{{ .Query }}</code></p>
+{{ if .Error }}<p>Failed to execute query: {{ .Error }}</p>{{ else if not .Series }}<p>No data.</p>{{ else }}
+<table>
+<tr><th>Series</th><th>Min</th><th>Avg</th><th>Max</th><th>Last</th></tr>
+{{ range .Series }}<tr><td>{{ .Labels }}</td><td class="value">{{ printf "%.3f" .Min }}</td><td class="value">{{ printf "%.3f" .Avg }}</td><td class="value">{{ printf "%.3f" .Max }}</td><td class="value">{{ printf "%.3f" .Last }}</td></tr>
+{{ end }}</table>
+{{ end }}
+{{ end }}
+</body>
+</html>
+`))
+
+// Generate renders the report for the period ending now, stores it in the reports directory,
+// and delivers it to configured email channels. It returns a path of the stored report.
+func (s *Service) Generate(ctx context.Context, params *models.ReportTaskData) (string, error) {
+	if err := params.Validate(); err != nil {
+		return "", err
+	}
+
+	to := time.Now().UTC()
+	b, err := s.render(ctx, params, to)
+	if err != nil {
+		return "", err
+	}
+
+	if err = dir.CreateDataDir(s.dir, "pmm", "pmm", dirPerm); err != nil {
+		s.l.Error(err)
+	}
+	name := fileNameRE.ReplaceAllString(params.Name, "_")
+	path := filepath.Join(s.dir, fmt.Sprintf("%s_%s.html", name, to.Format("2006-01-02_15-04")))
+	if err = ioutil.WriteFile(path, b, 0o644); err != nil { //nolint:gosec
+		return "", errors.WithStack(err)
+	}
+	s.l.Infof("Report %q saved to %s.", params.Name, path)
+
+	if len(params.ChannelIDs) != 0 {
+		subject := fmt.Sprintf("PMM report: %s", params.Name)
+		if err = s.send(params.ChannelIDs, subject, b); err != nil {
+			return path, err
+		}
+	}
+
+	return path, nil
+}
+
+// render executes report queries and renders HTML report for the period ending at given time.
+func (s *Service) render(ctx context.Context, params *models.ReportTaskData, to time.Time) ([]byte, error) {
+	from := to.Add(-params.Period)
+	step := params.Period / maxPoints
+	if step < time.Minute {
+		step = time.Minute
+	}
+
+	r := &report{
+		Name:        params.Name,
+		Description: params.Description,
+		From:        from,
+		To:          to,
+		Sections:    make([]section, 0, len(params.Queries)),
+	}
+	for _, q := range params.Queries {
+		sec := section{
+			Title: q.Title,
+			Query: q.Query,
+		}
+		if sec.Title == "" {
+			sec.Title = q.Query
+		}
+
+		series, err := s.queryRange(ctx, q.Query, v1.Range{Start: from, End: to, Step: step})
+		if err != nil {
+			// keep going: other sections may be fine
+			s.l.Warnf("Report %q: query %q failed: %s.", params.Name, q.Query, err)
+			sec.Error = err.Error()
+		}
+		sec.Series = series
+		r.Sections = append(r.Sections, sec)
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, r); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// queryRange executes range query and summarizes each returned series.
+func (s *Service) queryRange(ctx context.Context, query string, r v1.Range) ([]seriesSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	value, warnings, err := s.api.QueryRange(ctx, query, r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, w := range warnings {
+		s.l.Warnf("Query %q: %s.", query, w)
+	}
+
+	matrix, ok := value.(model.Matrix)
+	if !ok {
+		return nil, errors.Errorf("unexpected query result type %s", value.Type())
+	}
+
+	res := make([]seriesSummary, 0, len(matrix))
+	for _, stream := range matrix {
+		if len(stream.Values) == 0 {
+			continue
+		}
+		res = append(res, summarize(stream))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Labels < res[j].Labels })
+	return res, nil
+}
+
+// summarize returns min, average, max and last values of the series.
+func summarize(stream *model.SampleStream) seriesSummary {
+	summary := seriesSummary{
+		Labels: stream.Metric.String(),
+		Min:    math.Inf(1),
+		Max:    math.Inf(-1),
+	}
+	var sum float64
+	for _, v := range stream.Values {
+		f := float64(v.Value)
+		sum += f
+		summary.Min = math.Min(summary.Min, f)
+		summary.Max = math.Max(summary.Max, f)
+	}
+	summary.Avg = sum / float64(len(stream.Values))
+	summary.Last = float64(stream.Values[len(stream.Values)-1].Value)
+	return summary
+}
@@ -0,0 +1,96 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package reports
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// fakeAPI implements QueryRange method of v1.API.
+type fakeAPI struct {
+	v1.API
+	matrix model.Matrix
+}
+
+func (f *fakeAPI) QueryRange(ctx context.Context, query string, r v1.Range) (model.Value, v1.Warnings, error) {
+	return f.matrix, nil, nil
+}
+
+func TestRender(t *testing.T) {
+	s := &Service{
+		api: &fakeAPI{
+			matrix: model.Matrix{{
+				Metric: model.Metric{"service_name": "mysql1"},
+				Values: []model.SamplePair{{Value: 1}, {Value: 5}, {Value: 3}},
+			}},
+		},
+		l: logrus.WithField("test", t.Name()),
+	}
+
+	b, err := s.render(context.Background(), &models.ReportTaskData{
+		Name:   "Weekly <SLA>",
+		Period: 7 * 24 * time.Hour,
+		Queries: []models.ReportQuery{{
+			Title: "Connections",
+			Query: "mysql_global_status_threads_connected",
+		}},
+	}, time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	actual := string(b)
+	assert.Contains(t, actual, "<h1>Weekly &lt;SLA&gt;</h1>")
+	assert.Contains(t, actual, "From 2021-07-25 00:00:00 UTC to 2021-08-01 00:00:00 UTC.")
+	assert.Contains(t, actual, "<h2>Connections</h2>")
+	assert.Contains(t, actual, `<td class="value">1.000</td><td class="value">3.000</td><td class="value">5.000</td><td class="value">3.000</td>`)
+}
+
+func TestEmailRecipients(t *testing.T) {
+	channels := []*models.Channel{
+		{Type: models.Email, EmailConfig: &models.EmailConfig{To: []string{"a@example.com", "b@example.com"}}},
+		{Type: models.Email, EmailConfig: &models.EmailConfig{To: []string{"b@example.com"}}},
+		{Type: models.Email, EmailConfig: &models.EmailConfig{To: []string{"c@example.com"}}, Disabled: true},
+		{Type: models.Slack, SlackConfig: &models.SlackConfig{Channel: "#alerts"}},
+	}
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, emailRecipients(channels))
+}
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	msg := string(buildMessage("pmm@example.com", []string{"a@example.com", "b@example.com"}, "PMM report: SLA", []byte("<p>body</p>"), date))
+	expected := strings.Join([]string{
+		"From: pmm@example.com",
+		"To: a@example.com, b@example.com",
+		"Subject: PMM report: SLA",
+		"Date: Sun, 01 Aug 2021 00:00:00 +0000",
+		"MIME-Version: 1.0",
+		`Content-Type: text/html; charset="utf-8"`,
+		"",
+		"<p>body</p>",
+	}, "\r\n")
+	assert.Equal(t, expected, msg)
+}
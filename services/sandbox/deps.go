@@ -0,0 +1,46 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package sandbox
+
+import (
+	"context"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate mockery -name=configGenerator -case=snake -inpkg -testonly
+//go:generate mockery -name=rulesGenerator -case=snake -inpkg -testonly
+//go:generate mockery -name=rulesValidator -case=snake -inpkg -testonly
+
+// configGenerator is a subset of methods of victoriametrics.Service and alertmanager.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type configGenerator interface {
+	GenerateConfig(q *reform.Querier) ([]byte, error)
+	ValidateConfig(ctx context.Context, cfg []byte) error
+}
+
+// rulesGenerator is a subset of methods of ia.RulesService used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type rulesGenerator interface {
+	GenerateVMAlertRulesFiles(q *reform.Querier) (map[string][]byte, error)
+}
+
+// rulesValidator is a subset of methods of vmalert.ExternalRules used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type rulesValidator interface {
+	ValidateRules(ctx context.Context, rules string) error
+}
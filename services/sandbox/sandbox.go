@@ -0,0 +1,198 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package sandbox generates and validates configuration files without applying them.
+package sandbox
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/utils/dir"
+)
+
+const (
+	// Dir is a directory where staging directories are created.
+	Dir     = "/srv/pmm-managed/sandbox"
+	dirPerm = os.FileMode(0o775)
+
+	// staging directories older than that are removed
+	stagingDirTTL = 24 * time.Hour
+)
+
+// FileReport contains validation result of a single generated configuration file.
+type FileReport struct {
+	Component string `json:"component"`
+	Path      string `json:"path,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report contains consolidated validation result of all generated configuration files.
+type Report struct {
+	Dir   string        `json:"dir"`
+	Valid bool          `json:"valid"`
+	Files []*FileReport `json:"files"`
+}
+
+// Service generates VictoriaMetrics, Alertmanager and VMAlert configuration files into a staging directory
+// and validates them without touching live files.
+type Service struct {
+	db             *reform.DB
+	vmdb           configGenerator
+	alertmanager   configGenerator
+	rules          rulesGenerator
+	rulesValidator rulesValidator
+	dir            string
+	l              *logrus.Entry
+}
+
+// New creates new configuration sandbox service.
+func New(db *reform.DB, vmdb, alertmanager configGenerator, rules rulesGenerator, rulesValidator rulesValidator) *Service {
+	return &Service{
+		db:             db,
+		vmdb:           vmdb,
+		alertmanager:   alertmanager,
+		rules:          rules,
+		rulesValidator: rulesValidator,
+		dir:            Dir,
+		l:              logrus.WithField("component", "sandbox"),
+	}
+}
+
+// Run applies changes with given function (if any) in a database transaction that is always rolled back,
+// generates all configuration files for the resulting state into a new staging directory, and validates them.
+// Error is returned only if changes can't be applied or files can't be written;
+// validation errors are returned in the report.
+func (s *Service) Run(ctx context.Context, apply func(q *reform.Querier) error) (*Report, error) {
+	if err := dir.CreateDataDir(s.dir, "pmm", "pmm", dirPerm); err != nil {
+		s.l.Error(err)
+	}
+	s.removeStaleDirs(time.Now())
+
+	stagingDir, err := ioutil.TempDir(s.dir, time.Now().UTC().Format("20060102-150405-"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		if e := tx.Rollback(); e != nil {
+			s.l.Error(e)
+		}
+	}()
+
+	if apply != nil {
+		if err = apply(tx.Querier); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{
+		Dir:   stagingDir,
+		Valid: true,
+	}
+	add := func(component, name string, b []byte, genErr error, validate func([]byte) error) error {
+		file := &FileReport{
+			Component: component,
+		}
+		report.Files = append(report.Files, file)
+
+		if genErr == nil {
+			file.Path = filepath.Join(stagingDir, name)
+			if err := os.MkdirAll(filepath.Dir(file.Path), dirPerm); err != nil {
+				return errors.WithStack(err)
+			}
+			if err := ioutil.WriteFile(file.Path, b, 0o644); err != nil { //nolint:gosec
+				return errors.WithStack(err)
+			}
+			genErr = validate(b)
+		}
+
+		if genErr != nil {
+			file.Error = genErr.Error()
+			report.Valid = false
+		}
+		return nil
+	}
+
+	b, genErr := s.vmdb.GenerateConfig(tx.Querier)
+	err = add("victoriametrics", "prometheus.yml", b, genErr, func(b []byte) error {
+		return s.vmdb.ValidateConfig(ctx, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, genErr = s.alertmanager.GenerateConfig(tx.Querier)
+	err = add("alertmanager", "alertmanager.yml", b, genErr, func(b []byte) error {
+		return s.alertmanager.ValidateConfig(ctx, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files, genErr := s.rules.GenerateVMAlertRulesFiles(tx.Querier)
+	if genErr != nil {
+		if err = add("vmalert", "", nil, genErr, nil); err != nil {
+			return nil, err
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err = add("vmalert", filepath.Join("rules", name), files[name], nil, func(b []byte) error {
+			return s.rulesValidator.ValidateRules(ctx, string(b))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.l.Infof("Configuration files generated in %s, valid: %t.", stagingDir, report.Valid)
+	return report, nil
+}
+
+// removeStaleDirs removes staging directories older than stagingDirTTL.
+func (s *Service) removeStaleDirs(now time.Time) {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		s.l.Warn(err)
+		return
+	}
+
+	for _, fi := range fis {
+		if !fi.IsDir() || now.Sub(fi.ModTime()) < stagingDirTTL {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(s.dir, fi.Name())); err != nil {
+			s.l.Warn(err)
+		}
+	}
+}
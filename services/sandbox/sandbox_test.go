@@ -0,0 +1,117 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package sandbox
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	vmdb := new(mockConfigGenerator)
+	vmdb.Test(t)
+	alertmanager := new(mockConfigGenerator)
+	alertmanager.Test(t)
+	rules := new(mockRulesGenerator)
+	rules.Test(t)
+	rulesValidator := new(mockRulesValidator)
+	rulesValidator.Test(t)
+	t.Cleanup(func() {
+		vmdb.AssertExpectations(t)
+		alertmanager.AssertExpectations(t)
+		rules.AssertExpectations(t)
+		rulesValidator.AssertExpectations(t)
+	})
+
+	s := &Service{
+		db:             db,
+		vmdb:           vmdb,
+		alertmanager:   alertmanager,
+		rules:          rules,
+		rulesValidator: rulesValidator,
+		dir:            t.TempDir(),
+		l:              logrus.WithField("component", "sandbox"),
+	}
+
+	// generated configuration should see changes made by apply function
+	hasNode := mock.MatchedBy(func(q *reform.Querier) bool {
+		_, err := models.FindNodeByName(q, "sandbox-node")
+		return err == nil
+	})
+	vmdb.On("GenerateConfig", hasNode).Return([]byte("scrape_configs: []\n"), nil)
+	vmdb.On("ValidateConfig", ctx, []byte("scrape_configs: []\n")).Return(nil)
+	alertmanager.On("GenerateConfig", hasNode).Return([]byte("route: {}\n"), nil)
+	alertmanager.On("ValidateConfig", ctx, []byte("route: {}\n")).Return(errors.New("invalid route"))
+	rules.On("GenerateVMAlertRulesFiles", hasNode).Return(map[string][]byte{"rule.yml": []byte("groups: []\n")}, nil)
+	rulesValidator.On("ValidateRules", ctx, "groups: []\n").Return(nil)
+
+	report, err := s.Run(ctx, func(q *reform.Querier) error {
+		_, err := models.CreateNode(q, models.RemoteNodeType, &models.CreateNodeParams{
+			NodeName: "sandbox-node",
+			Address:  "127.0.0.1",
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	expected := &Report{
+		Dir:   report.Dir,
+		Valid: false,
+		Files: []*FileReport{{
+			Component: "victoriametrics",
+			Path:      filepath.Join(report.Dir, "prometheus.yml"),
+		}, {
+			Component: "alertmanager",
+			Path:      filepath.Join(report.Dir, "alertmanager.yml"),
+			Error:     "invalid route",
+		}, {
+			Component: "vmalert",
+			Path:      filepath.Join(report.Dir, "rules", "rule.yml"),
+		}},
+	}
+	assert.Equal(t, expected, report)
+
+	b, err := ioutil.ReadFile(filepath.Join(report.Dir, "rules", "rule.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "groups: []\n", string(b))
+
+	// changes should be rolled back
+	_, err = models.FindNodeByName(db.Querier, "sandbox-node")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -16,10 +16,37 @@
 
 package scheduler
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/backup"
+)
 
 //go:generate mockery -name=backupService -case=snake -inpkg -testonly
+//go:generate mockery -name=reportService -case=snake -inpkg -testonly
+//go:generate mockery -name=cleanupService -case=snake -inpkg -testonly
+//go:generate mockery -name=alertmanagerService -case=snake -inpkg -testonly
+//go:generate mockery -name=victoriaMetricsService -case=snake -inpkg -testonly
 
 type backupService interface {
-	PerformBackup(ctx context.Context, serviceID, locationID, name, scheduleID string) (string, error)
+	PerformBackup(ctx context.Context, params backup.PerformBackupParams) (string, error)
+}
+
+type reportService interface {
+	Generate(ctx context.Context, params *models.ReportTaskData) (string, error)
+}
+
+type cleanupService interface {
+	Cleanup(ctx context.Context, params *models.CleanupTaskData) error
+}
+
+type alertmanagerService interface {
+	CreateSilence(ctx context.Context, matchers []models.SilenceMatcher, endsAt time.Time, comment string) (string, error)
+	DeleteSilence(ctx context.Context, id string) error
+}
+
+type victoriaMetricsService interface {
+	ForceMerge(ctx context.Context, partitionPrefix string) error
 }
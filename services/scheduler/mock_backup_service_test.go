@@ -5,6 +5,8 @@ package scheduler
 import (
 	context "context"
 
+	backup "github.com/percona/pmm-managed/services/backup"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -13,20 +15,20 @@ type mockBackupService struct {
 	mock.Mock
 }
 
-// PerformBackup provides a mock function with given fields: ctx, serviceID, locationID, name, scheduleID
-func (_m *mockBackupService) PerformBackup(ctx context.Context, serviceID string, locationID string, name string, scheduleID string) (string, error) {
-	ret := _m.Called(ctx, serviceID, locationID, name, scheduleID)
+// PerformBackup provides a mock function with given fields: ctx, params
+func (_m *mockBackupService) PerformBackup(ctx context.Context, params backup.PerformBackupParams) (string, error) {
+	ret := _m.Called(ctx, params)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) string); ok {
-		r0 = rf(ctx, serviceID, locationID, name, scheduleID)
+	if rf, ok := ret.Get(0).(func(context.Context, backup.PerformBackupParams) string); ok {
+		r0 = rf(ctx, params)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
-		r1 = rf(ctx, serviceID, locationID, name, scheduleID)
+	if rf, ok := ret.Get(1).(func(context.Context, backup.PerformBackupParams) error); ok {
+		r1 = rf(ctx, params)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,65 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Number of fire times returned by PreviewRuns by default and at most.
+const (
+	defaultPreviewRuns = 5
+	maxPreviewRuns     = 100
+)
+
+// PreviewRuns returns next n fire times of cron expression after from.
+// Times are computed the same way scheduler does it: expression is evaluated in UTC;
+// returned times are converted to given timezone (UTC if empty) for display.
+func PreviewRuns(cronExpression, timezone string, from time.Time, n int) ([]time.Time, error) {
+	switch {
+	case n == 0:
+		n = defaultPreviewRuns
+	case n < 0 || n > maxPreviewRuns:
+		return nil, status.Errorf(codes.InvalidArgument, "Number of runs should be between 1 and %d.", maxPreviewRuns)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Unknown timezone %q.", timezone)
+	}
+
+	// gocron adds the same prefix with scheduler's location
+	schedule, err := cron.ParseStandard("CRON_TZ=UTC " + cronExpression)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid cron expression: %v", err)
+	}
+
+	res := make([]time.Time, 0, n)
+	for t := from; len(res) < n; {
+		t = schedule.Next(t)
+		if t.IsZero() {
+			// expression never fires, for example, on February 30
+			break
+		}
+		res = append(res, t.In(loc))
+	}
+	return res, nil
+}
@@ -0,0 +1,65 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestPreviewRuns(t *testing.T) {
+	from := time.Date(2021, 6, 7, 12, 30, 0, 0, time.UTC)
+
+	t.Run("Normal", func(t *testing.T) {
+		runs, err := PreviewRuns("0 2 * * *", "", from, 0)
+		require.NoError(t, err)
+		require.Len(t, runs, defaultPreviewRuns)
+		assert.Equal(t, time.Date(2021, 6, 8, 2, 0, 0, 0, time.UTC), runs[0])
+		assert.Equal(t, time.Date(2021, 6, 12, 2, 0, 0, 0, time.UTC), runs[4])
+	})
+
+	t.Run("Timezone", func(t *testing.T) {
+		runs, err := PreviewRuns("0 2 * * *", "Europe/Berlin", from, 1)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, "2021-06-08T04:00:00+02:00", runs[0].Format(time.RFC3339))
+	})
+
+	t.Run("Never", func(t *testing.T) {
+		runs, err := PreviewRuns("0 0 30 2 *", "", from, 3)
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := PreviewRuns("0 2 * *", "", from, 1)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = PreviewRuns("0 2 * * *", "Mars/Olympus", from, 1)
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, `Unknown timezone "Mars/Olympus".`), err)
+
+		_, err = PreviewRuns("0 2 * * *", "", from, 1000)
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Number of runs should be between 1 and 100."), err)
+	})
+}
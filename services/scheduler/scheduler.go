@@ -18,6 +18,10 @@ package scheduler
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -26,60 +30,330 @@ import (
 	"github.com/AlekSi/pointer"
 	"github.com/go-co-op/gocron"
 	"github.com/pkg/errors"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 )
 
+const (
+	prometheusNamespace = "pmm_managed"
+	prometheusSubsystem = "scheduler"
+
+	// leaderLockID is an arbitrary PostgreSQL advisory lock ID held by the pmm-managed instance executing tasks
+	// when several instances share the same database.
+	leaderLockID = 0x706d6d73 // "pmms"
+
+	// leaderCheckInterval is an interval of leader lock acquisition attempts by standby instances,
+	// and of leader's lock connection checks and reloads of tasks changed by standby instances.
+	leaderCheckInterval = 10 * time.Second
+
+	// queueSizePerWorker is a number of runs waiting for a free worker per worker;
+	// runs fired when the queue is full are missed.
+	queueSizePerWorker = 10
+)
+
 // Service is responsible for executing tasks and storing them to DB.
 type Service struct {
-	db            *reform.DB
-	l             *logrus.Entry
-	backupService backupService
+	db             *reform.DB
+	l              *logrus.Entry
+	backupService  backupService
+	reportService  reportService
+	cleanupService cleanupService
+	alertmanager   alertmanagerService
+	vmdb           victoriaMetricsService
 
 	mx        sync.Mutex
 	scheduler *gocron.Scheduler
+	leader    bool
 
 	taskMx sync.RWMutex
-	tasks  map[string]context.CancelFunc
+	tasks  map[string][]*taskRun
+
+	// protects tasks changes by Add, Update, Remove and syncFromDB
+	changeMx sync.Mutex
 
 	jobsMx sync.RWMutex
 	jobs   map[string]*gocron.Job
+	// schedule configuration of loaded tasks (see scheduleConfig) used to detect changes made by other instances
+	loaded map[string]string
+
+	// number of workers executing queued runs; runs are executed without queue if zero
+	workers int
+	// runs waiting for a free worker; nil if number of workers is not limited
+	queue chan *queuedRun
+
+	// connection holding leader lock; nil for standby instance
+	leaderConn *sql.Conn
+
+	mRuns        *prom.CounterVec
+	mFailures    *prom.CounterVec
+	mDuration    *prom.HistogramVec
+	mRunning     *prom.GaugeVec
+	mQueued      prom.Gauge
+	mMissed      *prom.CounterVec
+	mLastSuccess *prom.GaugeVec
+	mLeader      prom.Gauge
 }
 
 // New creates new scheduler service.
-func New(db *reform.DB, backupService backupService) *Service {
+// At most maxParallelTasks runs are executed simultaneously by a fixed pool of workers, others are queued;
+// runs fired when the queue is full are missed. Runs are executed without queue if maxParallelTasks is zero.
+func New(db *reform.DB, backupService backupService, reportService reportService, cleanupService cleanupService, alertmanager alertmanagerService, vmdb victoriaMetricsService, maxParallelTasks int) *Service {
 	scheduler := gocron.NewScheduler(time.UTC)
 	scheduler.TagsUnique()
 	scheduler.WaitForScheduleAll()
+
+	var queue chan *queuedRun
+	if maxParallelTasks > 0 {
+		queue = make(chan *queuedRun, maxParallelTasks*queueSizePerWorker)
+	}
+
 	return &Service{
-		db:            db,
-		scheduler:     scheduler,
-		l:             logrus.WithField("component", "scheduler"),
-		backupService: backupService,
-		tasks:         make(map[string]context.CancelFunc),
-		jobs:          make(map[string]*gocron.Job),
+		db:             db,
+		scheduler:      scheduler,
+		l:              logrus.WithField("component", "scheduler"),
+		backupService:  backupService,
+		reportService:  reportService,
+		cleanupService: cleanupService,
+		alertmanager:   alertmanager,
+		vmdb:           vmdb,
+		tasks:          make(map[string][]*taskRun),
+		jobs:           make(map[string]*gocron.Job),
+		loaded:         make(map[string]string),
+		workers:        maxParallelTasks,
+		queue:          queue,
+
+		mRuns: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "runs_total",
+			Help:      "A total number of finished scheduled task runs.",
+		}, []string{"task_type"}),
+		mFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "failures_total",
+			Help:      "A total number of failed scheduled task runs (after all retries).",
+		}, []string{"task_type"}),
+		mDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "run_duration_seconds",
+			Help:      "Scheduled task run duration, including retries.",
+			Buckets:   []float64{1, 5, 15, 60, 300, 900, 3600, 4 * 3600},
+		}, []string{"task_type"}),
+		mRunning: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "running",
+			Help:      "A number of currently executing scheduled task runs.",
+		}, []string{"task_type"}),
+		mQueued: prom.NewGauge(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "queued",
+			Help:      "A number of scheduled task runs waiting for a free worker.",
+		}),
+		mMissed: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "missed_total",
+			Help:      "A total number of scheduled task runs missed because the queue was full.",
+		}, []string{"task_type"}),
+		mLastSuccess: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Time of the last successful scheduled task run.",
+		}, []string{"task_type"}),
+		mLeader: prom.NewGauge(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "leader",
+			Help:      "1 if this pmm-managed instance executes scheduled tasks, 0 if it is a standby.",
+		}),
 	}
 }
 
-// Run loads tasks from DB and starts scheduler.
+// Run executes tasks until ctx is canceled.
+//
+// When several pmm-managed instances share the same database, only the leader holding PostgreSQL advisory lock
+// loads tasks from DB and executes them; others stand by and take over when the leader's lock is released.
+// Tasks added, changed or removed via standby instances are stored in DB only
+// and periodically reloaded by the leader.
 func (s *Service) Run(ctx context.Context) {
-	if err := s.loadFromDB(); err != nil {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	workersCtx, cancelWorkers := context.WithCancel(ctx)
+	defer cancelWorkers()
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(workersCtx)
+		}()
+	}
+
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.checkLeadership(ctx); err != nil {
+			s.l.Warn(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.stepDown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkLeadership tries to acquire leader lock for standby instance and starts executing tasks if it succeeded.
+// For leader instance, it checks that the lock connection is alive and stops executing tasks if it is not,
+// and reloads tasks changed by standby instances.
+func (s *Service) checkLeadership(ctx context.Context) error {
+	if s.leaderConn != nil {
+		if err := s.leaderConn.PingContext(ctx); err != nil {
+			s.l.Errorf("Leader lock connection is lost, stopping tasks execution: %s.", err)
+			s.stepDown()
+			return nil
+		}
+		return s.syncFromDB()
+	}
+
+	sqlDB, ok := s.db.DBInterface().(*sql.DB)
+	if !ok {
+		return errors.Errorf("unexpected DB type %T", s.db.DBInterface())
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var acquired bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockID).Scan(&acquired); err != nil || !acquired {
+		_ = conn.Close()
+		return errors.WithStack(err)
+	}
+
+	s.l.Info("Acquired leader lock, starting tasks execution.")
+	s.leaderConn = conn
+	s.mLeader.Set(1)
+
+	s.jobsMx.Lock()
+	s.jobs = make(map[string]*gocron.Job)
+	s.loaded = make(map[string]string)
+	s.jobsMx.Unlock()
+
+	if err = s.loadFromDB(); err != nil {
 		s.l.Warn(err)
 	}
+
+	s.mx.Lock()
 	s.scheduler.StartAsync()
-	<-ctx.Done()
+	s.leader = true
+	s.mx.Unlock()
+	return nil
+}
+
+// stepDown stops executing tasks, cancels executing runs, and releases leader lock.
+func (s *Service) stepDown() {
+	if s.leaderConn == nil {
+		return
+	}
+
+	s.mx.Lock()
 	s.scheduler.Stop()
+	s.scheduler.Clear()
+	s.leader = false
+	s.mx.Unlock()
+
+	// runs will be started again by the new leader according to misfire policies
+	s.taskMx.RLock()
+	for _, runs := range s.tasks {
+		for _, run := range runs {
+			run.cancel()
+		}
+	}
+	s.taskMx.RUnlock()
+
+	// closed connection is returned to the pool, so release session-level lock explicitly;
+	// broken connection is discarded and lock is released by PostgreSQL
+	ctx, cancel := context.WithTimeout(context.Background(), leaderCheckInterval)
+	if _, err := s.leaderConn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", leaderLockID); err != nil {
+		s.l.Warnf("Failed to release leader lock: %s.", err)
+	}
+	cancel()
+	_ = s.leaderConn.Close()
+	s.leaderConn = nil
+	s.mLeader.Set(0)
 }
 
+// taskRun represents a single executing run of the task.
+type taskRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// queuedRun represents task run registered by startRun and waiting for execution.
+type queuedRun struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	task   Task
+	dbTask *models.ScheduledTask
+	tr     *taskRun
+	// may be nil if it failed to be recorded
+	run *models.ScheduledTaskRun
+	// true if run waits in the queue for a free worker
+	queued bool
+	// true if one-shot task should be disabled or removed after run
+	finishOneShot bool
+	l             *logrus.Entry
+}
+
+// maxMissedRuns limits number of missed task runs started on startup with RunAllMisfirePolicy.
+const maxMissedRuns = 100
+
 // AddParams contains parameters for adding new add to service.
 type AddParams struct {
+	// Empty for one-shot task that runs once at StartAt.
 	CronExpression string
 	Disabled       bool
 	StartAt        time.Time
+	MisfirePolicy  models.MisfirePolicy
+	// Maximal random delay of each run.
+	Jitter time.Duration
+	// Execution window bounds in models.ExecutionWindowLayout, UTC; runs outside of the window are skipped.
+	WindowStart string
+	WindowEnd   string
+	// Policy for runs fired while previous run is still executing.
+	ConcurrencyPolicy models.ConcurrencyPolicy
+	// Remove one-shot task after its run instead of disabling it.
+	RemoveAfterRun bool
+	// Number of retries of failed run and delay between them.
+	Retries       uint32
+	RetryInterval time.Duration
+	// With ExponentialRetryBackoff, delay is doubled after each retry up to MaxRetryInterval.
+	RetryBackoff     models.RetryBackoff
+	MaxRetryInterval time.Duration
+	// Limit of total time spent on retries of a single run; not limited if zero.
+	RetryBudget time.Duration
+	// ID of the task which successful run starts this task; such task has no cron expression and start time.
+	DependsOn string
 }
 
 // Add adds task to scheduler and save it to DB.
 func (s *Service) Add(task Task, params AddParams) (*models.ScheduledTask, error) {
+	s.changeMx.Lock()
+	defer s.changeMx.Unlock()
+
 	var scheduledTask *models.ScheduledTask
 	var err error
 
@@ -90,6 +364,19 @@ func (s *Service) Add(task Task, params AddParams) (*models.ScheduledTask, error
 			Type:           task.Type(),
 			Data:           task.Data(),
 			Disabled:       params.Disabled,
+			MisfirePolicy:  params.MisfirePolicy,
+			Jitter:         params.Jitter,
+			WindowStart:    params.WindowStart,
+			WindowEnd:      params.WindowEnd,
+
+			ConcurrencyPolicy: params.ConcurrencyPolicy,
+			RemoveAfterRun:    params.RemoveAfterRun,
+			Retries:           params.Retries,
+			RetryInterval:     params.RetryInterval,
+			RetryBackoff:      params.RetryBackoff,
+			MaxRetryInterval:  params.MaxRetryInterval,
+			RetryBudget:       params.RetryBudget,
+			DependsOn:         params.DependsOn,
 		})
 		if err != nil {
 			return err
@@ -125,16 +412,10 @@ func (s *Service) Add(task Task, params AddParams) (*models.ScheduledTask, error
 
 // Remove stops task specified by id and removes it from DB and scheduler.
 func (s *Service) Remove(id string) error {
-	s.taskMx.RLock()
-	if cancel, ok := s.tasks[id]; ok {
-		cancel()
-	}
-	s.taskMx.RUnlock()
-
-	s.jobsMx.Lock()
-	delete(s.jobs, id)
-	s.jobsMx.Unlock()
+	s.changeMx.Lock()
+	defer s.changeMx.Unlock()
 
+	// task with dependent tasks can't be removed, so check that first
 	err := s.db.InTransaction(func(tx *reform.TX) error {
 		return models.RemoveScheduledTask(tx.Querier, id)
 	})
@@ -142,34 +423,149 @@ func (s *Service) Remove(id string) error {
 		return err
 	}
 
+	s.unload(id)
+	return nil
+}
+
+// unload cancels executing runs of the task and removes it from scheduler.
+func (s *Service) unload(id string) {
+	s.taskMx.RLock()
+	for _, run := range s.tasks[id] {
+		run.cancel()
+	}
+	s.taskMx.RUnlock()
+
+	s.unschedule(id)
+
+	s.jobsMx.Lock()
+	delete(s.loaded, id)
+	s.jobsMx.Unlock()
+}
+
+// unschedule removes task's scheduler job, so it is not run anymore; executing runs are not stopped.
+func (s *Service) unschedule(id string) {
 	s.mx.Lock()
 	_ = s.scheduler.RemoveByTag(id)
 	s.mx.Unlock()
 
-	return nil
+	s.jobsMx.Lock()
+	delete(s.jobs, id)
+	s.jobsMx.Unlock()
 }
 
-// Update changes scheduled task in DB and re-add it to scheduler.
+// Update changes scheduled task in DB and re-adds it to scheduler, so new cron expression, data,
+// retry and other settings are used for next runs. Already running task is not stopped.
 func (s *Service) Update(id string, params models.ChangeScheduledTaskParams) error {
-	txErr := s.db.InTransaction(func(tx *reform.TX) error {
+	s.changeMx.Lock()
+	defer s.changeMx.Unlock()
+
+	return s.db.InTransaction(func(tx *reform.TX) error {
 		dbTask, err := models.ChangeScheduledTask(tx.Querier, id, params)
 		if err != nil {
 			return err
 		}
-		s.mx.Lock()
-		_ = s.scheduler.RemoveByTag(id)
-		s.mx.Unlock()
 
-		return s.addDBTask(dbTask)
+		return s.reload(tx.Querier, dbTask)
 	})
+}
+
+// reload re-adds changed task to scheduler and stores its next run.
+func (s *Service) reload(q *reform.Querier, dbTask *models.ScheduledTask) error {
+	s.unschedule(dbTask.ID)
+	if err := s.addDBTask(dbTask); err != nil {
+		return err
+	}
+
+	// disabled task has no next run
+	nextRun := time.Time{}
+	s.jobsMx.RLock()
+	if job := s.jobs[dbTask.ID]; job != nil {
+		nextRun = job.NextRun().UTC()
+	}
+	s.jobsMx.RUnlock()
 
-	return txErr
+	_, err := models.ChangeScheduledTask(q, dbTask.ID, models.ChangeScheduledTaskParams{
+		NextRun: &nextRun,
+	})
+	return err
 }
 
-func (s *Service) loadFromDB() error {
-	dbTasks, err := models.FindScheduledTasks(s.db.Querier, models.ScheduledTasksFilter{
-		Disabled: pointer.ToBool(false),
+// syncFromDB reloads tasks added, changed or removed via other pmm-managed instances since they were loaded.
+// Changes of task state made by runs (like last and next run times) are ignored.
+func (s *Service) syncFromDB() error {
+	s.changeMx.Lock()
+	defer s.changeMx.Unlock()
+
+	return s.db.InTransaction(func(tx *reform.TX) error {
+		dbTasks, err := models.FindScheduledTasks(tx.Querier, models.ScheduledTasksFilter{})
+		if err != nil {
+			return err
+		}
+
+		found := make(map[string]struct{}, len(dbTasks))
+		for _, dbTask := range dbTasks {
+			found[dbTask.ID] = struct{}{}
+
+			s.jobsMx.RLock()
+			loaded, ok := s.loaded[dbTask.ID]
+			s.jobsMx.RUnlock()
+			if ok && loaded == scheduleConfig(dbTask) {
+				continue
+			}
+
+			l := s.l.WithField("id", dbTask.ID)
+			l.Info("Reloading task changed by another pmm-managed instance.")
+			if err = s.reload(tx.Querier, dbTask); err != nil {
+				l.Errorf("failed to reload task: %v", err)
+			}
+		}
+
+		s.jobsMx.RLock()
+		var removed []string
+		for id := range s.loaded {
+			if _, ok := found[id]; !ok {
+				removed = append(removed, id)
+			}
+		}
+		s.jobsMx.RUnlock()
+
+		for _, id := range removed {
+			s.l.WithField("id", id).Info("Unloading task removed by another pmm-managed instance.")
+			s.unload(id)
+		}
+		return nil
 	})
+}
+
+// scheduleConfig returns task's configuration without fields changed by its runs, serialized for comparison.
+// JSON is used the same way as for storing task's data, so loaded and stored tasks are compared correctly.
+func scheduleConfig(dbTask *models.ScheduledTask) string {
+	c := *dbTask
+	c.LastRun = time.Time{}
+	c.NextRun = time.Time{}
+	c.Running = false
+	c.Error = ""
+	c.CreatedAt = time.Time{}
+	c.UpdatedAt = time.Time{}
+	c.StartAt = c.StartAt.UTC().Truncate(time.Microsecond) // PostgreSQL precision
+
+	b, _ := json.Marshal(c) // can't fail for ScheduledTask
+	return string(b)
+}
+
+// Enable enables task specified by id, so it is run by scheduler again with the same cron expression.
+func (s *Service) Enable(id string) error {
+	return s.Update(id, models.ChangeScheduledTaskParams{Disable: pointer.ToBool(false)})
+}
+
+// Disable disables task specified by id, so it is not run by scheduler until enabled.
+// Task configuration and run history are kept; already running task is not stopped.
+func (s *Service) Disable(id string) error {
+	return s.Update(id, models.ChangeScheduledTaskParams{Disable: pointer.ToBool(true)})
+}
+
+func (s *Service) loadFromDB() error {
+	dbTasks, err := models.FindScheduledTasks(s.db.Querier, models.ScheduledTasksFilter{})
 	if err != nil {
 		return err
 	}
@@ -178,16 +574,102 @@ func (s *Service) loadFromDB() error {
 	s.scheduler.Clear()
 	s.mx.Unlock()
 
+	now := time.Now()
 	for _, dbTask := range dbTasks {
+		if dbTask.Disabled {
+			// only remember disabled task to detect changes made by other instances
+			if err := s.addDBTask(dbTask); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if dbTask.OneShot() && dbTask.StartAt.Before(now) && !runMissedOneShot(dbTask.MisfirePolicy) {
+			s.l.WithField("id", dbTask.ID).Infof("Skipping one-shot task missed at %s.", dbTask.StartAt)
+			s.oneShotFinished(dbTask)
+			continue
+		}
+
 		if err := s.addDBTask(dbTask); err != nil {
 			return err
 		}
+		if err := s.runMissed(dbTask, now); err != nil {
+			s.l.WithField("id", dbTask.ID).Errorf("failed to run missed task: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// runMissedOneShot returns true if one-shot task missed while pmm-managed was down should be run.
+func runMissedOneShot(policy models.MisfirePolicy) bool {
+	switch policy {
+	case models.RunOnceMisfirePolicy, models.RunAllMisfirePolicy:
+		return true
+	default:
+		return false
+	}
+}
+
+// runMissed starts task runs missed while pmm-managed was down according to task's misfire policy.
+// Missed one-shot tasks are handled by addDBTask.
+func (s *Service) runMissed(dbTask *models.ScheduledTask, now time.Time) error {
+	switch {
+	case dbTask.OneShot(), dbTask.DependsOn != nil:
+		return nil
+	case dbTask.MisfirePolicy == models.RunOnceMisfirePolicy, dbTask.MisfirePolicy == models.RunAllMisfirePolicy:
+	default:
+		return nil
 	}
 
+	n, err := missedRuns(dbTask.CronExpression, dbTask.NextRun, now)
+	if err != nil || n == 0 {
+		return err
+	}
+	if dbTask.MisfirePolicy == models.RunOnceMisfirePolicy {
+		n = 1
+	}
+
+	task, err := s.convertDBTask(dbTask)
+	if err != nil {
+		return err
+	}
+
+	s.l.WithField("id", dbTask.ID).Infof("Running task %d time(s): missed since %s.", n, dbTask.NextRun)
+	go func() {
+		for i := 0; i < n; i++ {
+			if tr := s.fire(task, dbTask); tr != nil {
+				<-tr.done
+			}
+		}
+	}()
 	return nil
 }
 
+// missedRuns returns number of task runs missed since nextRun scheduled before pmm-managed was stopped,
+// up to maxMissedRuns.
+func missedRuns(cronExpression string, nextRun, now time.Time) (int, error) {
+	if nextRun.IsZero() || !nextRun.Before(now) {
+		return 0, nil
+	}
+
+	schedule, err := cron.ParseStandard(cronExpression)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	n := 1
+	for t := schedule.Next(nextRun); t.Before(now) && n < maxMissedRuns; t = schedule.Next(t) {
+		n++
+	}
+	return n, nil
+}
+
 func (s *Service) addDBTask(dbTask *models.ScheduledTask) error {
+	s.jobsMx.Lock()
+	s.loaded[dbTask.ID] = scheduleConfig(dbTask)
+	s.jobsMx.Unlock()
+
 	if dbTask.Disabled {
 		return nil
 	}
@@ -197,11 +679,27 @@ func (s *Service) addDBTask(dbTask *models.ScheduledTask) error {
 		return err
 	}
 
+	// dependent task is started by runDependents
+	if dbTask.DependsOn != nil {
+		return nil
+	}
+
 	s.mx.Lock()
-	fn := s.wrapTask(task, dbTask.ID)
-	j := s.scheduler.Cron(dbTask.CronExpression).SingletonMode()
-	if !dbTask.StartAt.IsZero() {
-		j = j.StartAt(dbTask.StartAt)
+	fn := s.wrapTask(task, dbTask)
+	var j *gocron.Scheduler
+	if dbTask.OneShot() {
+		// start overdue task as soon as possible
+		startAt := dbTask.StartAt
+		if now := time.Now(); startAt.Before(now) {
+			startAt = now
+		}
+		j = s.scheduler.Every(1).Second().StartAt(startAt).LimitRunsTo(1)
+	} else {
+		// concurrent runs are handled by wrapTask according to task's concurrency policy
+		j = s.scheduler.Cron(dbTask.CronExpression)
+		if !dbTask.StartAt.IsZero() {
+			j = j.StartAt(dbTask.StartAt)
+		}
 	}
 	scheduleJob, err := j.Tag(dbTask.ID).Do(fn)
 	if err != nil {
@@ -216,47 +714,497 @@ func (s *Service) addDBTask(dbTask *models.ScheduledTask) error {
 
 	return nil
 }
-func (s *Service) wrapTask(task Task, id string) func() {
+
+func (s *Service) wrapTask(task Task, dbTask *models.ScheduledTask) func() {
 	return func() {
+		s.fire(task, dbTask)
+	}
+}
+
+// fire starts scheduled run of the task: executes it directly if number of workers is not limited,
+// or adds it to the queue of runs waiting for a free worker.
+// It returns registered run, or nil if the run was skipped.
+func (s *Service) fire(task Task, dbTask *models.ScheduledTask) *taskRun {
+	id := dbTask.ID
+	l := s.l.WithFields(logrus.Fields{
+		"id":       id,
+		"taskType": task.Type(),
+	})
+
+	if !s.waitJitter(dbTask, l) {
+		return nil
+	}
+	if !dbTask.InExecutionWindow(time.Now()) {
+		l.Infof("Skipping run outside of execution window %s-%s UTC.", dbTask.WindowStart, dbTask.WindowEnd)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tr := &taskRun{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	if !s.startRun(id, dbTask.ConcurrencyPolicy, tr, l) {
+		cancel()
+		return nil
+	}
+
+	qr := &queuedRun{
+		ctx:           ctx,
+		cancel:        cancel,
+		task:          task,
+		dbTask:        dbTask,
+		tr:            tr,
+		queued:        s.queue != nil,
+		finishOneShot: dbTask.OneShot(),
+		l:             l,
+	}
+	var err error
+	if qr.run, err = s.createRun(id, qr.queued); err != nil {
+		l.Errorf("failed to record task run: %v", err)
+	}
+
+	if !qr.queued {
+		s.execute(qr)
+		return tr
+	}
+	if !s.enqueue(qr) {
+		s.miss(qr)
+	}
+	return tr
+}
+
+// execute runs task registered by startRun, records results of the run, and starts dependent tasks.
+// Queued run canceled while waiting for a free worker is not started.
+func (s *Service) execute(qr *queuedRun) {
+	defer qr.cancel()
+
+	id := qr.dbTask.ID
+	l := qr.l
+	if qr.queued {
+		if qr.ctx.Err() != nil {
+			l.Info("Queued run is canceled.")
+			err := errors.New("canceled while queued")
+			last := s.finishRun(id, qr.tr)
+			s.taskFinished(id, err, last)
+			if qr.run != nil {
+				s.runFinished(qr.run, err)
+			}
+			return
+		}
+
+		if qr.run != nil {
+			started, err := models.StartScheduledTaskRun(s.db.Querier, qr.run.ID)
+			if err != nil {
+				l.Errorf("failed to record task run start: %v", err)
+			} else {
+				qr.run = started
+			}
+		}
+	}
+
+	taskType := string(qr.task.Type())
+	s.mRunning.WithLabelValues(taskType).Inc()
+
+	t := time.Now()
+	l.Debug("Starting task")
+	_, err := models.ChangeScheduledTask(s.db.Querier, id, models.ChangeScheduledTaskParams{
+		Running: pointer.ToBool(true),
+	})
+	if err != nil {
+		l.Errorf("failed to change running state: %v", err)
+	}
+
+	taskErr := s.runWithRetries(qr.ctx, qr.task, qr.dbTask, l)
+	l.WithField("duration", time.Since(t)).Debug("Ended task")
+	s.mRunning.WithLabelValues(taskType).Dec()
+	s.observeRun(taskType, time.Since(t), taskErr)
+
+	last := s.finishRun(id, qr.tr)
+	s.taskFinished(id, taskErr, last)
+	if qr.run != nil {
+		s.runFinished(qr.run, taskErr)
+	}
+	s.runDependents(id, taskErr)
+	if qr.finishOneShot {
+		s.oneShotFinished(qr.dbTask)
+	}
+}
+
+// RunNow starts run of the task with given ID immediately, out of its schedule, and returns run ID.
+// Jitter and execution window are ignored, but task's concurrency policy is respected;
+// one-shot task is not disabled or removed after such run.
+func (s *Service) RunNow(ctx context.Context, id string) (string, error) {
+	s.mx.Lock()
+	leader := s.leader
+	s.mx.Unlock()
+	if !leader {
+		return "", status.Error(codes.FailedPrecondition, "Scheduled tasks are executed by another pmm-managed instance.")
+	}
+
+	var dbTask *models.ScheduledTask
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
 		var err error
-		l := s.l.WithFields(logrus.Fields{
-			"id":       id,
-			"taskType": task.Type(),
-		})
-		ctx, cancel := context.WithCancel(context.Background())
+		dbTask, err = models.FindScheduledTaskByID(tx.Querier, id)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	task, err := s.convertDBTask(dbTask)
+	if err != nil {
+		return "", err
+	}
+
+	l := s.l.WithFields(logrus.Fields{
+		"id":       id,
+		"taskType": task.Type(),
+	})
+
+	// run is not bound to the request context
+	runCtx, cancel := context.WithCancel(context.Background())
+	tr := &taskRun{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	if !s.startRun(id, dbTask.ConcurrencyPolicy, tr, l) {
+		cancel()
+		return "", status.Error(codes.FailedPrecondition, "Previous run is still executing.")
+	}
+
+	qr := &queuedRun{
+		ctx:    runCtx,
+		cancel: cancel,
+		task:   task,
+		dbTask: dbTask,
+		tr:     tr,
+		queued: s.queue != nil,
+		l:      l,
+	}
+	if qr.run, err = s.createRun(id, qr.queued); err != nil {
+		s.finishRun(id, tr)
+		cancel()
+		return "", err
+	}
+
+	l.Info("Starting run on demand.")
+	if !qr.queued {
+		go s.execute(qr)
+		return qr.run.ID, nil
+	}
+	if !s.enqueue(qr) {
+		s.miss(qr)
+		return "", status.Error(codes.ResourceExhausted, "Scheduler queue is full.")
+	}
+	return qr.run.ID, nil
+}
+
+// createRun records started or queued task run.
+func (s *Service) createRun(id string, queued bool) (*models.ScheduledTaskRun, error) {
+	if queued {
+		return models.CreateQueuedScheduledTaskRun(s.db.Querier, id)
+	}
+	return models.CreateScheduledTaskRun(s.db.Querier, id)
+}
+
+// enqueue adds run to the queue of runs waiting for a free worker without waiting.
+// It returns false if the queue is full.
+func (s *Service) enqueue(qr *queuedRun) bool {
+	s.mQueued.Inc()
+	select {
+	case s.queue <- qr:
+		return true
+	default:
+		s.mQueued.Dec()
+		return false
+	}
+}
+
+// miss unregisters run that doesn't fit in the full queue and records it as missed.
+func (s *Service) miss(qr *queuedRun) {
+	defer qr.cancel()
+
+	id := qr.dbTask.ID
+	qr.l.Warnf("All %d workers are busy and %d runs are queued, run is missed.", s.workers, cap(s.queue))
+	s.mMissed.WithLabelValues(string(qr.task.Type())).Inc()
+
+	reason := "Missed: scheduler queue is full."
+	last := s.finishRun(id, qr.tr)
+	s.taskFinished(id, errors.New(reason), last)
+	if qr.run != nil {
+		if _, err := models.MissScheduledTaskRun(s.db.Querier, qr.run.ID, reason); err != nil {
+			qr.l.Errorf("failed to record missed task run: %v", err)
+		}
+	}
+	if qr.finishOneShot {
+		s.oneShotFinished(qr.dbTask)
+	}
+}
+
+// worker executes queued runs until ctx is canceled.
+func (s *Service) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case qr := <-s.queue:
+			s.mQueued.Dec()
+			s.execute(qr)
+		}
+	}
+}
+
+// observeRun updates metrics of finished task run.
+func (s *Service) observeRun(taskType string, duration time.Duration, taskErr error) {
+	s.mRuns.WithLabelValues(taskType).Inc()
+	s.mDuration.WithLabelValues(taskType).Observe(duration.Seconds())
+	if taskErr != nil {
+		s.mFailures.WithLabelValues(taskType).Inc()
+		return
+	}
+	s.mLastSuccess.WithLabelValues(taskType).SetToCurrentTime()
+}
+
+// runDependents starts enabled tasks depending on the task with given ID after its successful run.
+// If the run failed, dependent tasks and their dependents are skipped.
+func (s *Service) runDependents(id string, taskErr error) {
+	dependents, err := models.FindScheduledTasks(s.db.Querier, models.ScheduledTasksFilter{
+		Disabled:  pointer.ToBool(false),
+		DependsOn: id,
+	})
+	if err != nil {
+		s.l.WithField("id", id).Errorf("failed to find dependent tasks: %v", err)
+		return
+	}
+
+	for _, dbTask := range dependents {
+		l := s.l.WithField("id", dbTask.ID)
+		if taskErr != nil {
+			l.Infof("Skipping run: dependency %s failed.", id)
+			s.runSkipped(dbTask.ID, fmt.Sprintf("Skipped: dependency %s failed.", id))
+			s.runDependents(dbTask.ID, errors.Errorf("dependency %s failed", id))
+			continue
+		}
+
+		task, err := s.convertDBTask(dbTask)
+		if err != nil {
+			l.Errorf("failed to start dependent task: %v", err)
+			continue
+		}
+		l.Infof("Starting after dependency %s.", id)
+		go s.wrapTask(task, dbTask)()
+	}
+}
+
+// runSkipped records skipped run of the task with given reason.
+func (s *Service) runSkipped(id, reason string) {
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		run, err := models.CreateScheduledTaskRun(tx.Querier, id)
+		if err != nil {
+			return err
+		}
+		_, err = models.FinishScheduledTaskRun(tx.Querier, run.ID, reason, "")
+		return err
+	})
+	if err != nil {
+		s.l.WithField("id", id).Errorf("failed to record skipped task run: %v", err)
+	}
+}
+
+// runWithRetries runs task, retrying failed runs according to task's retry settings.
+// Retries stop when their number or total time budget is exhausted.
+// It returns error of the last attempt.
+func (s *Service) runWithRetries(ctx context.Context, task Task, dbTask *models.ScheduledTask, l *logrus.Entry) error {
+	// retries budget is counted from the first failure
+	var firstFailure time.Time
+	for attempt := uint32(0); ; attempt++ {
+		err := task.Run(ctx)
+		if err == nil {
+			return nil
+		}
+		l.Error(err)
+
+		if attempt >= dbTask.Retries {
+			return err
+		}
+		if firstFailure.IsZero() {
+			firstFailure = time.Now()
+		}
+		delay := dbTask.RetryDelay(attempt)
+		if dbTask.RetryBudget > 0 && time.Since(firstFailure)+delay > dbTask.RetryBudget {
+			l.Infof("Retry budget %s is exhausted.", dbTask.RetryBudget)
+			return err
+		}
+
+		l.Infof("Retrying in %s (%d/%d).", delay, attempt+1, dbTask.Retries)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
 
+// startRun registers task run according to task's concurrency policy.
+// With ReplaceConcurrencyPolicy, it cancels executing runs and waits for them to finish.
+// It returns false if the run should be skipped.
+func (s *Service) startRun(id string, policy models.ConcurrencyPolicy, tr *taskRun, l *logrus.Entry) bool {
+	for {
 		s.taskMx.Lock()
-		s.tasks[id] = cancel
+		running := s.tasks[id]
+		if len(running) == 0 || policy == models.AllowConcurrencyPolicy {
+			s.tasks[id] = append(running, tr)
+			s.taskMx.Unlock()
+			return true
+		}
+		running = append([]*taskRun(nil), running...)
 		s.taskMx.Unlock()
 
-		defer func() {
-			cancel()
-			s.taskMx.Lock()
-			delete(s.tasks, id)
-			s.taskMx.Unlock()
-		}()
+		if policy != models.ReplaceConcurrencyPolicy {
+			l.Infof("Skipping run: previous run is still executing.")
+			return false
+		}
 
-		t := time.Now()
-		l.Debug("Starting task")
-		_, err = models.ChangeScheduledTask(s.db.Querier, id, models.ChangeScheduledTaskParams{
-			Running: pointer.ToBool(true),
-		})
+		l.Infof("Cancelling %d executing run(s).", len(running))
+		for _, r := range running {
+			r.cancel()
+		}
+		for _, r := range running {
+			<-r.done
+		}
+	}
+}
+
+// finishRun unregisters finished task run. It returns true if there are no other executing runs of the task.
+func (s *Service) finishRun(id string, tr *taskRun) bool {
+	s.taskMx.Lock()
+	defer s.taskMx.Unlock()
+
+	close(tr.done)
+	running := s.tasks[id]
+	for i, r := range running {
+		if r == tr {
+			running = append(running[:i], running[i+1:]...)
+			break
+		}
+	}
+	if len(running) == 0 {
+		delete(s.tasks, id)
+		return true
+	}
+	s.tasks[id] = running
+	return false
+}
+
+// waitJitter delays task run for a random duration up to task's jitter,
+// so tasks with the same schedule do not start at exactly the same time.
+// It returns false if the task was removed or disabled during delay.
+func (s *Service) waitJitter(dbTask *models.ScheduledTask, l *logrus.Entry) bool {
+	if dbTask.Jitter <= 0 {
+		return true
+	}
+
+	d := time.Duration(rand.Int63n(int64(dbTask.Jitter))) //nolint:gosec
+	l.Debugf("Delaying run for %s.", d)
+	time.Sleep(d)
+
+	s.jobsMx.RLock()
+	_, ok := s.jobs[dbTask.ID]
+	s.jobsMx.RUnlock()
+	return ok
+}
+
+// oneShotFinished disables or removes one-shot task after its run, so it is not run again.
+func (s *Service) oneShotFinished(dbTask *models.ScheduledTask) {
+	s.jobsMx.Lock()
+	delete(s.jobs, dbTask.ID)
+	s.jobsMx.Unlock()
+
+	l := s.l.WithField("id", dbTask.ID)
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		// task may be removed during run
+		if _, err := models.FindScheduledTaskByID(tx.Querier, dbTask.ID); err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return err
+		}
 
+		if dbTask.RemoveAfterRun {
+			l.Info("Removing finished one-shot task.")
+			if err := models.RemoveScheduledTask(tx.Querier, dbTask.ID); err != nil {
+				return err
+			}
+			s.jobsMx.Lock()
+			delete(s.loaded, dbTask.ID)
+			s.jobsMx.Unlock()
+			return nil
+		}
+
+		disabled, err := models.ChangeScheduledTask(tx.Querier, dbTask.ID, models.ChangeScheduledTaskParams{
+			Disable: pointer.ToBool(true),
+			NextRun: &time.Time{},
+		})
 		if err != nil {
-			l.Errorf("failed to change running state: %v", err)
+			return err
 		}
+		s.jobsMx.Lock()
+		s.loaded[dbTask.ID] = scheduleConfig(disabled)
+		s.jobsMx.Unlock()
+		return nil
+	})
+	if err != nil {
+		l.Errorf("failed to finish one-shot task: %v", err)
+	}
+}
 
-		taskErr := task.Run(ctx)
-		if taskErr != nil {
-			l.Error(taskErr)
+// runFinished records end of scheduled task run and artifact created by it.
+func (s *Service) runFinished(run *models.ScheduledTaskRun, taskErr error) {
+	var runErr string
+	if taskErr != nil {
+		runErr = taskErr.Error()
+	}
+
+	txErr := s.db.InTransaction(func(tx *reform.TX) error {
+		// backup tasks create artifacts with schedule ID; the latest one is returned first
+		artifacts, err := models.FindArtifacts(tx.Querier, models.ArtifactFilters{ScheduleID: run.TaskID})
+		if err != nil {
+			return err
+		}
+		var artifactID string
+		if len(artifacts) != 0 && !artifacts[0].CreatedAt.Before(run.StartedAt) {
+			artifactID = artifacts[0].ID
 		}
-		l.WithField("duration", time.Since(t)).Debug("Ended task")
 
-		s.taskFinished(id, taskErr)
+		_, err = models.FinishScheduledTaskRun(tx.Querier, run.ID, runErr, artifactID)
+		return err
+	})
+	if txErr != nil {
+		s.l.WithField("id", run.TaskID).Errorf("failed to record finished task run: %v", txErr)
 	}
 }
 
-func (s *Service) taskFinished(id string, taskErr error) {
+// ListRuns returns latest scheduled task runs satisfying filter, latest first.
+// Runs of all tasks are returned if filter has no task ID; that allows to list all queued runs, for example.
+func (s *Service) ListRuns(ctx context.Context, filter models.ScheduledTaskRunsFilter) ([]*models.ScheduledTaskRun, error) {
+	var res []*models.ScheduledTaskRun
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		if filter.TaskID != "" {
+			if _, err := models.FindScheduledTaskByID(tx.Querier, filter.TaskID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		res, err = models.FindScheduledTaskRuns(tx.Querier, filter)
+		return err
+	})
+	return res, err
+}
+
+// taskFinished stores results of finished task run.
+// Running state is reset only by the last of concurrently executing runs.
+func (s *Service) taskFinished(id string, taskErr error, last bool) {
 	s.jobsMx.RLock()
 	job := s.jobs[id]
 	s.jobsMx.RUnlock()
@@ -264,8 +1212,9 @@ func (s *Service) taskFinished(id string, taskErr error) {
 	l := s.l.WithField("id", id)
 
 	txErr := s.db.InTransaction(func(tx *reform.TX) error {
-		params := models.ChangeScheduledTaskParams{
-			Running: pointer.ToBool(false),
+		var params models.ChangeScheduledTaskParams
+		if last {
+			params.Running = pointer.ToBool(false)
 		}
 
 		if taskErr != nil {
@@ -278,7 +1227,8 @@ func (s *Service) taskFinished(id string, taskErr error) {
 			params.NextRun = pointer.ToTime(job.NextRun().UTC())
 			params.LastRun = pointer.ToTime(job.LastRun().UTC())
 		} else {
-			l.Errorf("failed to find scheduled task")
+			// dependent task has no scheduler job
+			params.LastRun = pointer.ToTime(time.Now().UTC())
 		}
 
 		_, err := models.ChangeScheduledTask(tx.Querier, id, params)
@@ -298,10 +1248,18 @@ func (s *Service) convertDBTask(dbTask *models.ScheduledTask) (Task, error) {
 	switch dbTask.Type {
 	case models.ScheduledMySQLBackupTask:
 		data := dbTask.Data.MySQLBackupTask
-		task = NewMySQLBackupTask(s.backupService, data.ServiceID, data.LocationID, data.Name, data.Description, data.Retention)
+		task = NewMySQLBackupTask(s.backupService, data.ServiceID, data.LocationID, data.Name, data.Description, data.Retention, data.DataModel)
 	case models.ScheduledMongoDBBackupTask:
 		data := dbTask.Data.MongoDBBackupTask
-		task = NewMongoBackupTask(s.backupService, data.ServiceID, data.LocationID, data.Name, data.Description, data.Retention)
+		task = NewMongoBackupTask(s.backupService, data.ServiceID, data.LocationID, data.Name, data.Description, data.Retention, data.DataModel)
+	case models.ScheduledReportTask:
+		task = NewReportTask(s.reportService, dbTask.Data.ReportTask)
+	case models.ScheduledCleanupTask:
+		task = NewCleanupTask(s.cleanupService, dbTask.Data.CleanupTask)
+	case models.ScheduledMaintenanceTask:
+		task = NewMaintenanceTask(s.alertmanager, dbTask.Data.MaintenanceTask)
+	case models.ScheduledCompactionTask:
+		task = NewCompactionTask(s.vmdb, dbTask.Data.CompactionTask)
 	default:
 		return task, errors.Errorf("unknown task type: %s", dbTask.Type)
 	}
@@ -309,3 +1267,32 @@ func (s *Service) convertDBTask(dbTask *models.ScheduledTask) (Task, error) {
 	task.SetID(dbTask.ID)
 	return task, nil
 }
+
+// Describe implements prometheus.Collector.
+func (s *Service) Describe(ch chan<- *prom.Desc) {
+	s.mRuns.Describe(ch)
+	s.mFailures.Describe(ch)
+	s.mDuration.Describe(ch)
+	s.mRunning.Describe(ch)
+	s.mQueued.Describe(ch)
+	s.mMissed.Describe(ch)
+	s.mLastSuccess.Describe(ch)
+	s.mLeader.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (s *Service) Collect(ch chan<- prom.Metric) {
+	s.mRuns.Collect(ch)
+	s.mFailures.Collect(ch)
+	s.mDuration.Collect(ch)
+	s.mRunning.Collect(ch)
+	s.mQueued.Collect(ch)
+	s.mMissed.Collect(ch)
+	s.mLastSuccess.Collect(ch)
+	s.mLeader.Collect(ch)
+}
+
+// Check interfaces.
+var (
+	_ prom.Collector = (*Service)(nil)
+)
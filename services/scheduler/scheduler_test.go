@@ -21,7 +21,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/AlekSi/pointer"
+	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
@@ -37,7 +42,11 @@ func setup(t *testing.T) *Service {
 	sqlDB := testdb.Open(t, models.SkipFixtures, nil)
 	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
 	backupService := &mockBackupService{}
-	return New(db, backupService)
+	reportService := &mockReportService{}
+	cleanupService := &mockCleanupService{}
+	alertmanager := &mockAlertmanagerService{}
+	vmdb := &mockVictoriaMetricsService{}
+	return New(db, backupService, reportService, cleanupService, alertmanager, vmdb, 0)
 }
 
 type dummyTask struct {
@@ -100,6 +109,23 @@ func TestService(t *testing.T) {
 	assert.Equal(t, cronExpr, findJob.CronExpression)
 	assert.Truef(t, dbTask.NextRun.After(startAt), "next run %s is before startAt %s", dbTask.NextRun, startAt)
 
+	err = svc.Disable(dbTask.ID)
+	assert.NoError(t, err)
+	assert.Len(t, svc.scheduler.Jobs(), 0)
+	findJob, err = models.FindScheduledTaskByID(svc.db.Querier, dbTask.ID)
+	assert.NoError(t, err)
+	assert.True(t, findJob.Disabled)
+	assert.True(t, findJob.NextRun.IsZero())
+	assert.Equal(t, cronExpr, findJob.CronExpression)
+
+	err = svc.Enable(dbTask.ID)
+	assert.NoError(t, err)
+	assert.Len(t, svc.scheduler.Jobs(), 1)
+	findJob, err = models.FindScheduledTaskByID(svc.db.Querier, dbTask.ID)
+	assert.NoError(t, err)
+	assert.False(t, findJob.Disabled)
+	assert.Truef(t, findJob.NextRun.After(startAt), "next run %s is before startAt %s", findJob.NextRun, startAt)
+
 	err = svc.Remove(dbTask.ID)
 	assert.NoError(t, err)
 	assert.Len(t, svc.scheduler.Jobs(), 0)
@@ -107,3 +133,299 @@ func TestService(t *testing.T) {
 	tests.AssertGRPCError(t, status.Newf(codes.NotFound, `ScheduledTask with ID "%s" not found.`, dbTask.ID), err)
 
 }
+
+func TestLeaderElection(t *testing.T) {
+	ctx := context.Background()
+	leader := setup(t)
+	standby := New(leader.db, nil, nil, nil, nil, nil, 0)
+
+	require.NoError(t, leader.checkLeadership(ctx))
+	require.NoError(t, standby.checkLeadership(ctx))
+	assert.True(t, leader.scheduler.IsRunning())
+	assert.False(t, standby.scheduler.IsRunning())
+
+	// leader keeps the lock
+	require.NoError(t, leader.checkLeadership(ctx))
+	assert.True(t, leader.scheduler.IsRunning())
+
+	// tasks changed via standby are reloaded by leader
+	task := NewCleanupTask(standby.cleanupService, &models.CleanupTaskData{})
+	dbTask, err := standby.Add(task, AddParams{CronExpression: "0 2 * * *"})
+	require.NoError(t, err)
+	require.NoError(t, leader.checkLeadership(ctx))
+	assert.Contains(t, leader.jobs, dbTask.ID)
+
+	require.NoError(t, standby.Disable(dbTask.ID))
+	require.NoError(t, leader.checkLeadership(ctx))
+	assert.NotContains(t, leader.jobs, dbTask.ID)
+	assert.Contains(t, leader.loaded, dbTask.ID)
+
+	require.NoError(t, standby.Remove(dbTask.ID))
+	require.NoError(t, leader.checkLeadership(ctx))
+	assert.NotContains(t, leader.loaded, dbTask.ID)
+
+	leader.stepDown()
+	assert.False(t, leader.scheduler.IsRunning())
+	require.NoError(t, standby.checkLeadership(ctx))
+	assert.True(t, standby.scheduler.IsRunning())
+	standby.stepDown()
+}
+
+func TestScheduleConfig(t *testing.T) {
+	startAt := time.Date(2021, 1, 1, 2, 0, 0, 123456789, time.UTC)
+	task := &models.ScheduledTask{
+		ID:             "id",
+		CronExpression: "0 2 * * *",
+		StartAt:        startAt,
+		Data:           &models.ScheduledTaskData{CleanupTask: &models.CleanupTaskData{}},
+	}
+	expected := scheduleConfig(task)
+
+	ran := *task
+	ran.LastRun = time.Now()
+	ran.NextRun = time.Now()
+	ran.Running = true
+	ran.Error = "failed"
+	ran.UpdatedAt = time.Now()
+	ran.StartAt = startAt.Truncate(time.Microsecond).In(time.Local)
+	ran.Data = &models.ScheduledTaskData{CleanupTask: &models.CleanupTaskData{}}
+	assert.Equal(t, expected, scheduleConfig(&ran))
+
+	changed := *task
+	changed.CronExpression = "0 3 * * *"
+	assert.NotEqual(t, expected, scheduleConfig(&changed))
+}
+
+func TestDependentTasks(t *testing.T) {
+	svc := setup(t)
+
+	first, err := svc.Add(&dummyTask{}, AddParams{CronExpression: "0 2 * * *"})
+	require.NoError(t, err)
+	second, err := svc.Add(&dummyTask{}, AddParams{DependsOn: first.ID})
+	require.NoError(t, err)
+	third, err := svc.Add(&dummyTask{}, AddParams{DependsOn: second.ID})
+	require.NoError(t, err)
+
+	// only the first task is started by schedule
+	assert.Len(t, svc.scheduler.Jobs(), 1)
+	assert.False(t, second.OneShot())
+	assert.True(t, second.NextRun.IsZero())
+
+	err = svc.Update(second.ID, models.ChangeScheduledTaskParams{DependsOn: pointer.ToString(third.ID)})
+	tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Scheduled tasks dependency cycle."), err)
+	err = svc.Update(second.ID, models.ChangeScheduledTaskParams{CronExpression: pointer.ToString("0 3 * * *")})
+	tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Dependent task can't have cron expression or jitter."), err)
+	err = svc.Update(first.ID, models.ChangeScheduledTaskParams{DependsOn: pointer.ToString(third.ID)})
+	tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Only dependent task can change its dependency."), err)
+
+	svc.runDependents(first.ID, errors.New("failed"))
+	for _, id := range []string{second.ID, third.ID} {
+		runs, err := svc.ListRuns(context.Background(), models.ScheduledTaskRunsFilter{TaskID: id})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Contains(t, runs[0].Error, "Skipped: dependency")
+	}
+
+	err = svc.Remove(first.ID)
+	tests.AssertGRPCError(t, status.Newf(codes.FailedPrecondition, "Scheduled task %q has 1 dependent task(s), remove them first.", first.ID), err)
+	assert.Len(t, svc.scheduler.Jobs(), 1)
+
+	for _, id := range []string{third.ID, second.ID, first.ID} {
+		require.NoError(t, svc.Remove(id))
+	}
+}
+
+func TestMissedRuns(t *testing.T) {
+	now := time.Date(2021, 6, 7, 12, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		cron     string
+		nextRun  time.Time
+		expected int
+	}{
+		{"NeverScheduled", "0 * * * *", time.Time{}, 0},
+		{"NotMissed", "0 * * * *", time.Date(2021, 6, 7, 13, 0, 0, 0, time.UTC), 0},
+		{"MissedOnce", "0 * * * *", time.Date(2021, 6, 7, 12, 0, 0, 0, time.UTC), 1},
+		{"MissedThreeTimes", "0 * * * *", time.Date(2021, 6, 7, 10, 0, 0, 0, time.UTC), 3},
+		{"Limit", "* * * * *", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), maxMissedRuns},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := missedRuns(tc.cron, tc.nextRun, now)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, n)
+		})
+	}
+}
+
+func TestConcurrencyPolicy(t *testing.T) {
+	newRun := func() (*taskRun, context.Context) {
+		ctx, cancel := context.WithCancel(context.Background())
+		return &taskRun{cancel: cancel, done: make(chan struct{})}, ctx
+	}
+
+	for _, policy := range []models.ConcurrencyPolicy{"", models.ForbidConcurrencyPolicy} {
+		t.Run("Forbid"+string(policy), func(t *testing.T) {
+			svc := New(nil, nil, nil, nil, nil, nil, 0)
+			first, _ := newRun()
+			require.True(t, svc.startRun("id", policy, first, svc.l))
+			second, _ := newRun()
+			assert.False(t, svc.startRun("id", policy, second, svc.l))
+			assert.True(t, svc.finishRun("id", first))
+
+			assert.True(t, svc.startRun("id", policy, second, svc.l))
+			assert.True(t, svc.finishRun("id", second))
+		})
+	}
+
+	t.Run("Allow", func(t *testing.T) {
+		svc := New(nil, nil, nil, nil, nil, nil, 0)
+		first, _ := newRun()
+		require.True(t, svc.startRun("id", models.AllowConcurrencyPolicy, first, svc.l))
+		second, _ := newRun()
+		require.True(t, svc.startRun("id", models.AllowConcurrencyPolicy, second, svc.l))
+		assert.False(t, svc.finishRun("id", first))
+		assert.True(t, svc.finishRun("id", second))
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		svc := New(nil, nil, nil, nil, nil, nil, 0)
+		first, firstCtx := newRun()
+		require.True(t, svc.startRun("id", models.ReplaceConcurrencyPolicy, first, svc.l))
+		go func() {
+			<-firstCtx.Done()
+			svc.finishRun("id", first)
+		}()
+
+		second, secondCtx := newRun()
+		require.True(t, svc.startRun("id", models.ReplaceConcurrencyPolicy, second, svc.l))
+		assert.NoError(t, secondCtx.Err())
+		assert.True(t, svc.finishRun("id", second))
+	})
+}
+
+type failingTask struct {
+	dummyTask
+	failures int
+	runs     int
+}
+
+func (t *failingTask) Run(ctx context.Context) error {
+	t.runs++
+	if t.runs <= t.failures {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunWithRetries(t *testing.T) {
+	svc := New(nil, nil, nil, nil, nil, nil, 0)
+	dbTask := &models.ScheduledTask{Retries: 2, RetryInterval: time.Millisecond}
+
+	t.Run("Succeeded", func(t *testing.T) {
+		task := &failingTask{failures: 2}
+		assert.NoError(t, svc.runWithRetries(context.Background(), task, dbTask, svc.l))
+		assert.Equal(t, 3, task.runs)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		task := &failingTask{failures: 3}
+		assert.EqualError(t, svc.runWithRetries(context.Background(), task, dbTask, svc.l), "failed")
+		assert.Equal(t, 3, task.runs)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		task := &failingTask{failures: 3}
+		assert.EqualError(t, svc.runWithRetries(ctx, task, &models.ScheduledTask{Retries: 2, RetryInterval: time.Hour}, svc.l), "failed")
+		assert.Equal(t, 1, task.runs)
+	})
+
+	t.Run("Budget", func(t *testing.T) {
+		task := &failingTask{failures: 10}
+		dbTask := &models.ScheduledTask{
+			Retries:       10,
+			RetryInterval: 10 * time.Millisecond,
+			RetryBackoff:  models.ExponentialRetryBackoff,
+			RetryBudget:   50 * time.Millisecond,
+		}
+		// 10ms + 20ms fit the budget, 40ms more does not
+		assert.EqualError(t, svc.runWithRetries(context.Background(), task, dbTask, svc.l), "failed")
+		assert.Equal(t, 3, task.runs)
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	svc := New(nil, nil, nil, nil, nil, nil, 0)
+	reportType := string(models.ScheduledReportTask)
+	cleanupType := string(models.ScheduledCleanupTask)
+
+	svc.observeRun(reportType, time.Second, nil)
+	svc.observeRun(reportType, time.Minute, errors.New("failed"))
+	svc.observeRun(cleanupType, time.Second, errors.New("failed"))
+
+	assert.Equal(t, 2.0, promtest.ToFloat64(svc.mRuns.WithLabelValues(reportType)))
+	assert.Equal(t, 1.0, promtest.ToFloat64(svc.mFailures.WithLabelValues(reportType)))
+	assert.Equal(t, 1.0, promtest.ToFloat64(svc.mFailures.WithLabelValues(cleanupType)))
+	assert.InDelta(t, time.Now().Unix(), promtest.ToFloat64(svc.mLastSuccess.WithLabelValues(reportType)), 5)
+	assert.Equal(t, 2, promtest.CollectAndCount(svc, "pmm_managed_scheduler_run_duration_seconds"))
+	assert.Equal(t, 1, promtest.CollectAndCount(svc, "pmm_managed_scheduler_last_success_timestamp_seconds"))
+}
+
+func TestRunNow(t *testing.T) {
+	ctx := context.Background()
+	svc := setup(t)
+
+	_, err := svc.RunNow(ctx, "id")
+	tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Scheduled tasks are executed by another pmm-managed instance."), err)
+
+	require.NoError(t, svc.checkLeadership(ctx))
+	defer svc.stepDown()
+
+	data := &models.CleanupTaskData{}
+	dbTask, err := svc.Add(NewCleanupTask(svc.cleanupService, data), AddParams{CronExpression: "0 2 * * *"})
+	require.NoError(t, err)
+
+	cleanupService := svc.cleanupService.(*mockCleanupService)
+	release := make(chan struct{})
+	cleanupService.On("Cleanup", mock.Anything, mock.Anything).Return(func(context.Context, *models.CleanupTaskData) error {
+		<-release
+		return nil
+	}).Once()
+
+	runID, err := svc.RunNow(ctx, dbTask.ID)
+	require.NoError(t, err)
+
+	// previous run is still executing
+	_, err = svc.RunNow(ctx, dbTask.ID)
+	tests.AssertGRPCError(t, status.New(codes.FailedPrecondition, "Previous run is still executing."), err)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		runs, err := svc.ListRuns(ctx, models.ScheduledTaskRunsFilter{TaskID: dbTask.ID})
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, runID, runs[0].ID)
+		return runs[0].FinishedAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	cleanupService.AssertExpectations(t)
+
+	require.NoError(t, svc.Remove(dbTask.ID))
+}
+
+func TestQueue(t *testing.T) {
+	t.Run("Limited", func(t *testing.T) {
+		svc := New(nil, nil, nil, nil, nil, nil, 1)
+		for i := 0; i < queueSizePerWorker; i++ {
+			require.True(t, svc.enqueue(&queuedRun{}))
+		}
+		assert.False(t, svc.enqueue(&queuedRun{}))
+		assert.Equal(t, float64(queueSizePerWorker), promtest.ToFloat64(svc.mQueued))
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		svc := New(nil, nil, nil, nil, nil, nil, 0)
+		assert.Nil(t, svc.queue)
+		assert.Zero(t, svc.workers)
+	})
+}
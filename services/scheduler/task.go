@@ -18,9 +18,14 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/backup"
 )
 
 // Task represents task which will be run inside scheduler.
@@ -53,10 +58,20 @@ type mySQLBackupTask struct {
 	Name          string
 	Description   string
 	Retention     uint32
+	DataModel     models.DataModel
+}
+
+// scheduledArtifactName returns artifact name template for scheduled backup task name.
+// Timestamp is appended if template does not contain it, so names of backups are unique.
+func scheduledArtifactName(name string) string {
+	if strings.Contains(name, backup.TimestampPlaceholder) {
+		return name
+	}
+	return name + "_" + backup.TimestampPlaceholder
 }
 
 // NewMySQLBackupTask create new task for mysql backup.
-func NewMySQLBackupTask(backupService backupService, serviceID, locationID, name, description string, retention uint32) Task {
+func NewMySQLBackupTask(backupService backupService, serviceID, locationID, name, description string, retention uint32, dataModel models.DataModel) Task {
 	return &mySQLBackupTask{
 		common:        &common{},
 		backupService: backupService,
@@ -65,12 +80,18 @@ func NewMySQLBackupTask(backupService backupService, serviceID, locationID, name
 		Name:          name,
 		Description:   description,
 		Retention:     retention,
+		DataModel:     dataModel,
 	}
 }
 
 func (t *mySQLBackupTask) Run(ctx context.Context) error {
-	name := t.Name + "_" + time.Now().Format(time.RFC3339)
-	_, err := t.backupService.PerformBackup(ctx, t.ServiceID, t.LocationID, name, t.ID())
+	_, err := t.backupService.PerformBackup(ctx, backup.PerformBackupParams{
+		ServiceID:  t.ServiceID,
+		LocationID: t.LocationID,
+		Name:       scheduledArtifactName(t.Name),
+		ScheduleID: t.ID(),
+		DataModel:  t.DataModel,
+	})
 	return err
 }
 
@@ -86,6 +107,7 @@ func (t *mySQLBackupTask) Data() models.ScheduledTaskData {
 			Name:        t.Name,
 			Description: t.Description,
 			Retention:   t.Retention,
+			DataModel:   t.DataModel,
 		},
 	}
 }
@@ -98,10 +120,11 @@ type mongoBackupTask struct {
 	Name          string
 	Description   string
 	Retention     uint32
+	DataModel     models.DataModel
 }
 
 // NewMongoBackupTask create new task for mongo backup.
-func NewMongoBackupTask(backupService backupService, serviceID, locationID, name, description string, retention uint32) Task {
+func NewMongoBackupTask(backupService backupService, serviceID, locationID, name, description string, retention uint32, dataModel models.DataModel) Task {
 	return &mongoBackupTask{
 		common:        &common{},
 		backupService: backupService,
@@ -110,12 +133,18 @@ func NewMongoBackupTask(backupService backupService, serviceID, locationID, name
 		Name:          name,
 		Description:   description,
 		Retention:     retention,
+		DataModel:     dataModel,
 	}
 }
 
 func (t *mongoBackupTask) Run(ctx context.Context) error {
-	name := t.Name + "_" + time.Now().Format(time.RFC3339)
-	_, err := t.backupService.PerformBackup(ctx, t.ServiceID, t.LocationID, name, t.ID())
+	_, err := t.backupService.PerformBackup(ctx, backup.PerformBackupParams{
+		ServiceID:  t.ServiceID,
+		LocationID: t.LocationID,
+		Name:       scheduledArtifactName(t.Name),
+		ScheduleID: t.ID(),
+		DataModel:  t.DataModel,
+	})
 	return err
 }
 
@@ -131,6 +160,173 @@ func (t *mongoBackupTask) Data() models.ScheduledTaskData {
 			Name:        t.Name,
 			Description: t.Description,
 			Retention:   t.Retention,
+			DataModel:   t.DataModel,
 		},
 	}
 }
+
+type reportTask struct {
+	*common
+	reportService reportService
+	params        *models.ReportTaskData
+}
+
+// NewReportTask creates new task for report generation.
+func NewReportTask(reportService reportService, params *models.ReportTaskData) Task {
+	return &reportTask{
+		common:        &common{},
+		reportService: reportService,
+		params:        params,
+	}
+}
+
+func (t *reportTask) Run(ctx context.Context) error {
+	_, err := t.reportService.Generate(ctx, t.params)
+	return err
+}
+
+func (t *reportTask) Type() models.ScheduledTaskType {
+	return models.ScheduledReportTask
+}
+
+func (t *reportTask) Data() models.ScheduledTaskData {
+	return models.ScheduledTaskData{
+		ReportTask: t.params,
+	}
+}
+
+type cleanupTask struct {
+	*common
+	cleanupService cleanupService
+	params         *models.CleanupTaskData
+}
+
+// NewCleanupTask creates new task for old log and temporary files cleanup.
+func NewCleanupTask(cleanupService cleanupService, params *models.CleanupTaskData) Task {
+	return &cleanupTask{
+		common:         &common{},
+		cleanupService: cleanupService,
+		params:         params,
+	}
+}
+
+func (t *cleanupTask) Run(ctx context.Context) error {
+	return t.cleanupService.Cleanup(ctx, t.params)
+}
+
+func (t *cleanupTask) Type() models.ScheduledTaskType {
+	return models.ScheduledCleanupTask
+}
+
+func (t *cleanupTask) Data() models.ScheduledTaskData {
+	return models.ScheduledTaskData{
+		CleanupTask: t.params,
+	}
+}
+
+// silenceExpirationMargin is added to the maintenance window silence end time,
+// so silence is removed by the task, but still expires if pmm-managed is stopped.
+const silenceExpirationMargin = time.Minute
+
+type maintenanceTask struct {
+	*common
+	alertmanager alertmanagerService
+	params       *models.MaintenanceTaskData
+}
+
+// NewMaintenanceTask creates new task that silences matching alerts for the maintenance window duration.
+func NewMaintenanceTask(alertmanager alertmanagerService, params *models.MaintenanceTaskData) Task {
+	return &maintenanceTask{
+		common:       &common{},
+		alertmanager: alertmanager,
+		params:       params,
+	}
+}
+
+func (t *maintenanceTask) Run(ctx context.Context) error {
+	comment := t.params.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("Maintenance window of scheduled task %s.", t.ID())
+	}
+
+	ends := time.Now().Add(t.params.Duration)
+	id, err := t.alertmanager.CreateSilence(ctx, t.params.Matchers, ends.Add(silenceExpirationMargin), comment)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(time.Until(ends))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		// keep silence on shutdown or run cancellation: it expires by itself
+		return ctx.Err()
+	}
+
+	if err = t.alertmanager.DeleteSilence(ctx, id); err != nil {
+		// don't fail the run: retry would silence alerts again, and silence expires by itself soon
+		logrus.WithField("component", "scheduler").Warnf("Failed to remove maintenance window silence: %s.", err)
+	}
+	return nil
+}
+
+func (t *maintenanceTask) Type() models.ScheduledTaskType {
+	return models.ScheduledMaintenanceTask
+}
+
+func (t *maintenanceTask) Data() models.ScheduledTaskData {
+	return models.ScheduledTaskData{
+		MaintenanceTask: t.params,
+	}
+}
+
+type compactionTask struct {
+	*common
+	vmdb   victoriaMetricsService
+	params *models.CompactionTaskData
+}
+
+// NewCompactionTask creates new task that forces VictoriaMetrics to merge data parts,
+// enforcing retention and freeing disk space.
+func NewCompactionTask(vmdb victoriaMetricsService, params *models.CompactionTaskData) Task {
+	return &compactionTask{
+		common: &common{},
+		vmdb:   vmdb,
+		params: params,
+	}
+}
+
+func (t *compactionTask) Run(ctx context.Context) error {
+	if t.params.Months == 0 {
+		return t.vmdb.ForceMerge(ctx, "")
+	}
+
+	for _, prefix := range compactionPartitions(time.Now(), t.params.Months) {
+		if err := t.vmdb.ForceMerge(ctx, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactionPartitions returns names of monthly VictoriaMetrics partitions for the given number of months
+// up to and including the month of now, the latest first.
+func compactionPartitions(now time.Time, months int) []string {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	res := make([]string, months)
+	for i := range res {
+		res[i] = month.AddDate(0, -i, 0).Format("2006_01")
+	}
+	return res
+}
+
+func (t *compactionTask) Type() models.ScheduledTaskType {
+	return models.ScheduledCompactionTask
+}
+
+func (t *compactionTask) Data() models.ScheduledTaskData {
+	return models.ScheduledTaskData{
+		CompactionTask: t.params,
+	}
+}
@@ -0,0 +1,106 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestMaintenanceTask(t *testing.T) {
+	params := &models.MaintenanceTaskData{
+		Matchers: []models.SilenceMatcher{{Name: "node_name", Value: "db-.*", IsRegex: true}},
+		Duration: 50 * time.Millisecond,
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		alertmanager := &mockAlertmanagerService{}
+		alertmanager.On("CreateSilence", mock.Anything, params.Matchers, mock.Anything, "Maintenance window of scheduled task /scheduled_task_id/1.").
+			Return("silence-1", nil).Once()
+		alertmanager.On("DeleteSilence", mock.Anything, "silence-1").Return(nil).Once()
+
+		task := NewMaintenanceTask(alertmanager, params)
+		task.SetID("/scheduled_task_id/1")
+		start := time.Now()
+		require.NoError(t, task.Run(context.Background()))
+		assert.True(t, time.Since(start) >= params.Duration)
+
+		endsAt := alertmanager.Calls[0].Arguments.Get(2).(time.Time)
+		assert.WithinDuration(t, start.Add(params.Duration+silenceExpirationMargin), endsAt, time.Second)
+		alertmanager.AssertExpectations(t)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		alertmanager := &mockAlertmanagerService{}
+		alertmanager.On("CreateSilence", mock.Anything, params.Matchers, mock.Anything, mock.Anything).Return("silence-2", nil).Once()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := NewMaintenanceTask(alertmanager, params).Run(ctx)
+		assert.Equal(t, context.Canceled, err)
+		alertmanager.AssertExpectations(t)
+	})
+
+	t.Run("DeleteFailed", func(t *testing.T) {
+		alertmanager := &mockAlertmanagerService{}
+		alertmanager.On("CreateSilence", mock.Anything, params.Matchers, mock.Anything, mock.Anything).Return("silence-3", nil).Once()
+		alertmanager.On("DeleteSilence", mock.Anything, "silence-3").Return(errors.New("unavailable")).Once()
+
+		require.NoError(t, NewMaintenanceTask(alertmanager, params).Run(context.Background()))
+		alertmanager.AssertExpectations(t)
+	})
+}
+
+func TestCompactionTask(t *testing.T) {
+	t.Run("All", func(t *testing.T) {
+		vmdb := &mockVictoriaMetricsService{}
+		vmdb.On("ForceMerge", mock.Anything, "").Return(nil).Once()
+
+		require.NoError(t, NewCompactionTask(vmdb, &models.CompactionTaskData{}).Run(context.Background()))
+		vmdb.AssertExpectations(t)
+	})
+
+	t.Run("Months", func(t *testing.T) {
+		vmdb := &mockVictoriaMetricsService{}
+		vmdb.On("ForceMerge", mock.Anything, mock.Anything).Return(nil).Twice()
+
+		require.NoError(t, NewCompactionTask(vmdb, &models.CompactionTaskData{Months: 2}).Run(context.Background()))
+		vmdb.AssertExpectations(t)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		vmdb := &mockVictoriaMetricsService{}
+		vmdb.On("ForceMerge", mock.Anything, mock.Anything).Return(errors.New("unavailable")).Once()
+
+		err := NewCompactionTask(vmdb, &models.CompactionTaskData{Months: 3}).Run(context.Background())
+		assert.EqualError(t, err, "unavailable")
+		vmdb.AssertExpectations(t)
+	})
+
+	t.Run("Partitions", func(t *testing.T) {
+		now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+		assert.Equal(t, []string{"2021_03", "2021_02", "2021_01", "2020_12"}, compactionPartitions(now, 4))
+	})
+}
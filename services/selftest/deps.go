@@ -0,0 +1,55 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package selftest
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/reform.v1"
+)
+
+//go:generate mockery -name=victoriaMetricsService -case=snake -inpkg -testonly
+//go:generate mockery -name=healthChecker -case=snake -inpkg -testonly
+//go:generate mockery -name=s3Service -case=snake -inpkg -testonly
+//go:generate mockery -name=agentsRegistry -case=snake -inpkg -testonly
+
+// victoriaMetricsService is a subset of methods of victoriametrics.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type victoriaMetricsService interface {
+	IsReady(ctx context.Context) error
+	GenerateConfig(q *reform.Querier) ([]byte, error)
+	ValidateConfig(ctx context.Context, cfg []byte) error
+}
+
+// healthChecker is a subset of methods of vmalert.Service and alertmanager.Service used by this package.
+type healthChecker interface {
+	IsReady(ctx context.Context) error
+}
+
+// s3Service is a subset of methods of minio.Service used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type s3Service interface {
+	BucketExists(ctx context.Context, endpoint, accessKey, secretKey, name string) (bool, error)
+}
+
+// agentsRegistry is a subset of methods of agents.Registry used by this package.
+// We use it instead of real type for testing and to avoid dependency cycle.
+type agentsRegistry interface {
+	IsConnected(pmmAgentID string) bool
+	Ping(ctx context.Context, pmmAgentID string) (time.Duration, error)
+}
@@ -0,0 +1,267 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package selftest checks critical paths of PMM Server without changing its state.
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	checkTimeout = 10 * time.Second
+
+	// DefaultAgentsSample is a default number of connected pmm-agents to ping.
+	DefaultAgentsSample = 5
+)
+
+// CheckStatus represents self-test check status.
+type CheckStatus string
+
+// Check statuses.
+const (
+	PassedCheckStatus  CheckStatus = "passed"
+	FailedCheckStatus  CheckStatus = "failed"
+	SkippedCheckStatus CheckStatus = "skipped"
+)
+
+// CheckResult represents a result of a single self-test check.
+type CheckResult struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	// Check duration in milliseconds.
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Report represents self-test report.
+type Report struct {
+	// True if no check failed.
+	Passed bool           `json:"passed"`
+	Checks []*CheckResult `json:"checks"`
+}
+
+// Params represents self-test parameters.
+type Params struct {
+	// Number of randomly chosen connected pmm-agents to ping; DefaultAgentsSample if zero.
+	AgentsSample int
+}
+
+// Service runs self-test of PMM Server.
+type Service struct {
+	db           *reform.DB
+	api          v1.API
+	vmdb         victoriaMetricsService
+	vmalert      healthChecker
+	alertmanager healthChecker
+	s3           s3Service
+	registry     agentsRegistry
+	l            *logrus.Entry
+}
+
+// New creates new self-test service.
+func New(
+	db *reform.DB,
+	victoriaMetricsURL string,
+	vmdb victoriaMetricsService,
+	vmalert healthChecker,
+	alertmanager healthChecker,
+	s3 s3Service,
+	registry agentsRegistry,
+) (*Service, error) {
+	client, err := api.NewClient(api.Config{
+		Address: victoriaMetricsURL,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Service{
+		db:           db,
+		api:          v1.NewAPI(client),
+		vmdb:         vmdb,
+		vmalert:      vmalert,
+		alertmanager: alertmanager,
+		s3:           s3,
+		registry:     registry,
+		l:            logrus.WithField("component", "selftest"),
+	}, nil
+}
+
+// RunSelfTest runs all checks one by one and returns report; failed checks do not stop it.
+func (s *Service) RunSelfTest(ctx context.Context, params *Params) (*Report, error) {
+	sample := params.AgentsSample
+	if sample <= 0 {
+		sample = DefaultAgentsSample
+	}
+
+	res := &Report{Passed: true}
+	run := func(name string, check func(ctx context.Context) error) {
+		start := time.Now()
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := check(checkCtx)
+		cancel()
+
+		r := &CheckResult{
+			Name:       name,
+			Status:     PassedCheckStatus,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			s.l.Warnf("Self-test check %q failed: %s.", name, err)
+			r.Status = FailedCheckStatus
+			r.Error = err.Error()
+			res.Passed = false
+		}
+		res.Checks = append(res.Checks, r)
+	}
+	skip := func(name, reason string) {
+		res.Checks = append(res.Checks, &CheckResult{
+			Name:   name,
+			Status: SkippedCheckStatus,
+			Error:  reason,
+		})
+	}
+
+	run("database", s.checkDatabase)
+	run("victoriametrics", s.vmdb.IsReady)
+	run("victoriametrics_query", s.checkQuery)
+	run("victoriametrics_config", s.checkConfig)
+	run("vmalert", s.vmalert.IsReady)
+	run("alertmanager", s.alertmanager.IsReady)
+
+	locations, pmmAgentIDs, err := s.findLocationsAndAgents()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, location := range locations {
+		name := fmt.Sprintf("location %s", location.Name)
+		if location.Type != models.S3BackupLocationType || location.S3Config == nil {
+			skip(name, "Not an S3 location.")
+			continue
+		}
+		c := location.S3Config
+		run(name, func(ctx context.Context) error {
+			exists, err := s.s3.BucketExists(ctx, c.Endpoint, c.AccessKey, c.SecretKey, c.BucketName)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return errors.Errorf("bucket %q doesn't exist", c.BucketName)
+			}
+			return nil
+		})
+	}
+
+	rand.Shuffle(len(pmmAgentIDs), func(i, j int) { pmmAgentIDs[i], pmmAgentIDs[j] = pmmAgentIDs[j], pmmAgentIDs[i] })
+	if len(pmmAgentIDs) > sample {
+		pmmAgentIDs = pmmAgentIDs[:sample]
+	}
+	if len(pmmAgentIDs) == 0 {
+		skip("pmm-agents", "No connected pmm-agents.")
+	}
+	for _, id := range pmmAgentIDs {
+		id := id
+		run(fmt.Sprintf("pmm-agent %s", id), func(ctx context.Context) error {
+			_, err := s.registry.Ping(ctx, id)
+			return err
+		})
+	}
+
+	return res, nil
+}
+
+// checkDatabase reads and writes settings in a transaction that is always rolled back.
+func (s *Service) checkDatabase(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err = models.GetSettings(tx.Querier); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("UPDATE settings SET settings = settings"); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// checkQuery runs a trivial instant query.
+func (s *Service) checkQuery(ctx context.Context) error {
+	v, _, err := s.api.Query(ctx, "vector(1)", time.Now())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	vector, ok := v.(model.Vector)
+	if !ok || len(vector) != 1 || vector[0].Value != 1 {
+		return errors.Errorf("unexpected query result: %s", v)
+	}
+	return nil
+}
+
+// checkConfig generates VictoriaMetrics configuration and validates it without writing and reloading.
+func (s *Service) checkConfig(ctx context.Context) error {
+	var cfg []byte
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		var e error
+		cfg, e = s.vmdb.GenerateConfig(tx.Querier)
+		return e
+	})
+	if err != nil {
+		return err
+	}
+	return s.vmdb.ValidateConfig(ctx, cfg)
+}
+
+// findLocationsAndAgents returns all backup locations and IDs of connected pmm-agents.
+func (s *Service) findLocationsAndAgents() ([]*models.BackupLocation, []string, error) {
+	var locations []*models.BackupLocation
+	var pmmAgentIDs []string
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		var err error
+		if locations, err = models.FindBackupLocations(tx.Querier); err != nil {
+			return err
+		}
+
+		agentType := models.PMMAgentType
+		agents, err := models.FindAgents(tx.Querier, models.AgentFilters{AgentType: &agentType})
+		if err != nil {
+			return err
+		}
+		for _, agent := range agents {
+			if s.registry.IsConnected(agent.AgentID) {
+				pmmAgentIDs = append(pmmAgentIDs, agent.AgentID)
+			}
+		}
+		return nil
+	})
+	return locations, pmmAgentIDs, err
+}
@@ -0,0 +1,122 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package selftest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/testdb"
+)
+
+// fakeAPI implements Query method of v1.API.
+type fakeAPI struct {
+	v1.API
+}
+
+// Query returns a single sample with value 1.
+func (f *fakeAPI) Query(ctx context.Context, query string, ts time.Time) (model.Value, v1.Warnings, error) {
+	return model.Vector{{Value: 1}}, nil, nil
+}
+
+func TestRunSelfTest(t *testing.T) {
+	sqlDB := testdb.Open(t, models.SetupFixtures, nil)
+	db := reform.NewDB(sqlDB, postgresql.Dialect, reform.NewPrintfLogger(t.Logf))
+	t.Cleanup(func() {
+		require.NoError(t, sqlDB.Close())
+	})
+
+	location := &models.BackupLocation{
+		ID:   "/location_id/selftest",
+		Name: "s3",
+		Type: models.S3BackupLocationType,
+		S3Config: &models.S3LocationConfig{
+			Endpoint:   "https://s3.example.com",
+			AccessKey:  "access",
+			SecretKey:  "secret",
+			BucketName: "bucket",
+		},
+	}
+	require.NoError(t, db.Insert(location))
+	t.Cleanup(func() {
+		require.NoError(t, db.Delete(location))
+	})
+
+	vmdb := new(mockVictoriaMetricsService)
+	vmalert := new(mockHealthChecker)
+	alertmanager := new(mockHealthChecker)
+	s3 := new(mockS3Service)
+	registry := new(mockAgentsRegistry)
+	for _, m := range []interface {
+		Test(mock.TestingT)
+		AssertExpectations(mock.TestingT) bool
+	}{vmdb, vmalert, alertmanager, s3, registry} {
+		m := m
+		m.Test(t)
+		t.Cleanup(func() { m.AssertExpectations(t) })
+	}
+
+	s := &Service{
+		db:           db,
+		api:          &fakeAPI{},
+		vmdb:         vmdb,
+		vmalert:      vmalert,
+		alertmanager: alertmanager,
+		s3:           s3,
+		registry:     registry,
+		l:            logrus.WithField("test", t.Name()),
+	}
+
+	vmdb.On("IsReady", mock.Anything).Return(nil).Once()
+	vmdb.On("GenerateConfig", mock.Anything).Return([]byte("cfg"), nil).Once()
+	vmdb.On("ValidateConfig", mock.Anything, []byte("cfg")).Return(nil).Once()
+	vmalert.On("IsReady", mock.Anything).Return(nil).Once()
+	alertmanager.On("IsReady", mock.Anything).Return(assert.AnError).Once()
+	s3.On("BucketExists", mock.Anything, "https://s3.example.com", "access", "secret", "bucket").Return(true, nil).Once()
+	registry.On("IsConnected", models.PMMServerAgentID).Return(true)
+	registry.On("Ping", mock.Anything, models.PMMServerAgentID).Return(time.Millisecond, nil).Once()
+
+	report, err := s.RunSelfTest(context.Background(), &Params{})
+	require.NoError(t, err)
+	assert.False(t, report.Passed)
+
+	statuses := make(map[string]CheckStatus, len(report.Checks))
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	assert.Equal(t, map[string]CheckStatus{
+		"database":                             PassedCheckStatus,
+		"victoriametrics":                      PassedCheckStatus,
+		"victoriametrics_query":                PassedCheckStatus,
+		"victoriametrics_config":               PassedCheckStatus,
+		"vmalert":                              PassedCheckStatus,
+		"alertmanager":                         FailedCheckStatus,
+		"location s3":                          PassedCheckStatus,
+		"pmm-agent " + models.PMMServerAgentID: PassedCheckStatus,
+	}, statuses)
+}
@@ -25,12 +25,14 @@ import (
 	"github.com/percona/pmm/version"
 
 	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/backup"
 )
 
 //go:generate mockery -name=grafanaClient -case=snake -inpkg -testonly
 //go:generate mockery -name=prometheusService -case=snake -inpkg -testonly
 //go:generate mockery -name=alertmanagerService -case=snake -inpkg -testonly
 //go:generate mockery -name=checksService -case=snake -inpkg -testonly
+//go:generate mockery -name=vmAlertService -case=snake -inpkg -testonly
 //go:generate mockery -name=vmAlertExternalRules -case=snake -inpkg -testonly
 //go:generate mockery -name=supervisordService -case=snake -inpkg -testonly
 //go:generate mockery -name=telemetryService -case=snake -inpkg -testonly
@@ -63,6 +65,7 @@ type prometheusService interface {
 // We use it instead of real type for testing and to avoid dependency cycle.
 type alertmanagerService interface {
 	RequestConfigurationUpdate()
+	SetEndpoint(endpoint *models.AlertingEndpoint) error
 	healthChecker
 }
 
@@ -78,6 +81,7 @@ type checksService interface {
 // We use it instead of real type to avoid dependency cycle.
 type vmAlertService interface {
 	RequestConfigurationUpdate()
+	SetEndpoint(endpoint *models.AlertingEndpoint) error
 	healthChecker
 }
 
@@ -132,5 +136,5 @@ type rulesService interface {
 }
 
 type backupService interface {
-	PerformBackup(ctx context.Context, serviceID, locationID, name, scheduleID string) (string, error)
+	PerformBackup(ctx context.Context, params backup.PerformBackupParams) (string, error)
 }
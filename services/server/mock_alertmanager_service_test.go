@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	models "github.com/percona/pmm-managed/models"
 )
 
 // mockAlertmanagerService is an autogenerated mock type for the alertmanagerService type
@@ -31,3 +33,17 @@ func (_m *mockAlertmanagerService) IsReady(ctx context.Context) error {
 func (_m *mockAlertmanagerService) RequestConfigurationUpdate() {
 	_m.Called()
 }
+
+// SetEndpoint provides a mock function with given fields: endpoint
+func (_m *mockAlertmanagerService) SetEndpoint(endpoint *models.AlertingEndpoint) error {
+	ret := _m.Called(endpoint)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.AlertingEndpoint) error); ok {
+		r0 = rf(endpoint)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -95,7 +95,7 @@ type Params struct {
 	DB                   *reform.DB
 	AgentsStateUpdater   agentsStateUpdater
 	VMDB                 prometheusService
-	VMAlert              prometheusService
+	VMAlert              vmAlertService
 	Alertmanager         alertmanagerService
 	ChecksService        checksService
 	VMAlertExternalRules vmAlertExternalRules
@@ -222,6 +222,11 @@ func (s *Server) Version(ctx context.Context, req *serverpb.VersionRequest) (*se
 // Readiness returns an error when some PMM Server component is not ready yet or is being restarted.
 // It can be used as for Docker health check or Kubernetes readiness probe.
 func (s *Server) Readiness(ctx context.Context, req *serverpb.ReadinessRequest) (*serverpb.ReadinessResponse, error) {
+	if ms := models.GetMigrationStatus(); ms.Running {
+		return nil, status.Errorf(codes.Unavailable, "PMM Server is not ready yet: migrating database schema from version %d to %d (started %s ago).",
+			ms.CurrentVersion, ms.TargetVersion, time.Since(ms.StartedAt).Round(time.Second))
+	}
+
 	var notReady bool
 	for n, svc := range map[string]healthChecker{
 		"alertmanager":    s.alertmanager,
@@ -732,6 +737,276 @@ func (s *Server) ChangeSettings(ctx context.Context, req *serverpb.ChangeSetting
 	}, nil
 }
 
+// ChangeExternalVictoriaMetrics configures external metrics backend, or removes it if external is nil.
+func (s *Server) ChangeExternalVictoriaMetrics(ctx context.Context, external *models.ExternalVictoriaMetrics) error {
+	s.envRW.RLock()
+	defer s.envRW.RUnlock()
+
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		_, e := models.UpdateSettings(tx, &models.ChangeSettingsParams{
+			ExternalVictoriaMetrics:       external,
+			RemoveExternalVictoriaMetrics: external == nil,
+		})
+		if e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = s.UpdateConfigurations(); err != nil {
+		return err
+	}
+
+	// vmagents of pmm-agents in push mode should write to the new endpoint
+	return s.agentsState.UpdateAgentsState(ctx)
+}
+
+// ChangeRemoteWrite replaces remote storages receiving copies of collected metrics.
+// Metrics are not sent anywhere if endpoints are empty.
+func (s *Server) ChangeRemoteWrite(ctx context.Context, endpoints []*models.RemoteWriteEndpoint) error {
+	s.envRW.RLock()
+	defer s.envRW.RUnlock()
+
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		params := &models.ChangeSettingsParams{RemoveRemoteWriteEndpoints: true}
+		if len(endpoints) != 0 {
+			params = &models.ChangeSettingsParams{RemoteWriteEndpoints: endpoints}
+		}
+		if _, e := models.UpdateSettings(tx, params); e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateConfigurations()
+}
+
+// GetRetention returns data retention and downsampling periods of local VictoriaMetrics.
+func (s *Server) GetRetention(ctx context.Context) (time.Duration, []*models.DownsamplingPeriod, error) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return 0, nil, err
+	}
+	return settings.DataRetention, settings.VictoriaMetrics.Downsampling, nil
+}
+
+// ChangeRetention changes data retention and downsampling periods of local VictoriaMetrics,
+// and restarts it with new flags. Zero dataRetention keeps the current value; empty downsampling disables it.
+func (s *Server) ChangeRetention(ctx context.Context, dataRetention time.Duration, downsampling []*models.DownsamplingPeriod) error {
+	s.envRW.RLock()
+	defer s.envRW.RUnlock()
+
+	if dataRetention != 0 && s.envSettings.DataRetention != 0 {
+		return status.Error(codes.FailedPrecondition, "Data retention for queries is set via DATA_RETENTION environment variable.")
+	}
+
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		params := &models.ChangeSettingsParams{
+			DataRetention:      dataRetention,
+			RemoveDownsampling: len(downsampling) == 0,
+		}
+		if len(downsampling) != 0 {
+			params.Downsampling = downsampling
+		}
+		if _, e := models.UpdateSettings(tx, params); e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateConfigurations()
+}
+
+// ChangeFileSD enables or disables writing Agents' scrape targets to file_sd JSON files
+// instead of inlining them into VictoriaMetrics configuration.
+func (s *Server) ChangeFileSD(ctx context.Context, enabled bool) error {
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		_, e := models.UpdateSettings(tx, &models.ChangeSettingsParams{
+			EnableFileSD:  enabled,
+			DisableFileSD: !enabled,
+		})
+		if e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateConfigurations()
+}
+
+// ChangeExternalConfigPath enables read-only external mode: scrape configuration is written to the given path,
+// but never reloaded, as an external operator owns the server lifecycle. Empty path disables that mode.
+func (s *Server) ChangeExternalConfigPath(ctx context.Context, path string) error {
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		_, e := models.UpdateSettings(tx, &models.ChangeSettingsParams{
+			ExternalConfigPath:       path,
+			RemoveExternalConfigPath: path == "",
+		})
+		if e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateConfigurations()
+}
+
+// GetExternalLabels returns global external labels.
+func (s *Server) GetExternalLabels(ctx context.Context) (map[string]string, error) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return nil, err
+	}
+	return settings.VictoriaMetrics.ExternalLabels, nil
+}
+
+// ChangeExternalLabels replaces global external labels added to all scraped metrics and VMAlert results,
+// and updates VictoriaMetrics configuration and vmalert flags. Empty labels remove them.
+func (s *Server) ChangeExternalLabels(ctx context.Context, labels map[string]string) error {
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		params := &models.ChangeSettingsParams{
+			RemoveExternalLabels: len(labels) == 0,
+		}
+		if len(labels) != 0 {
+			params.ExternalLabels = labels
+		}
+		if _, e := models.UpdateSettings(tx, params); e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateConfigurations()
+}
+
+// BackupLimits contains concurrency limits and timeout of backup and restore jobs.
+type BackupLimits struct {
+	// Maximal numbers of backup jobs running at the same time: total, on a single pmm-agent,
+	// and (together with restore jobs) on a single Node; 0 means no limit.
+	MaxParallelJobs         int
+	MaxParallelJobsPerAgent int
+	MaxParallelJobsPerNode  int
+	// If true, backup and restore jobs may run at the same time on the same Node.
+	AllowConcurrentRestore bool
+	// Backup jobs not reporting progress for that duration are marked as failed.
+	JobTimeout time.Duration
+}
+
+// GetBackupLimits returns concurrency limits and timeout of backup and restore jobs.
+func (s *Server) GetBackupLimits(ctx context.Context) (*BackupLimits, error) {
+	settings, err := models.GetSettings(s.db.Querier)
+	if err != nil {
+		return nil, err
+	}
+
+	b := settings.BackupManagement
+	return &BackupLimits{
+		MaxParallelJobs:         b.MaxParallelJobs,
+		MaxParallelJobsPerAgent: b.MaxParallelJobsPerAgent,
+		MaxParallelJobsPerNode:  b.MaxParallelJobsPerNode,
+		AllowConcurrentRestore:  b.AllowConcurrentRestore,
+		JobTimeout:              b.JobTimeout,
+	}, nil
+}
+
+// ChangeBackupLimitsParams contains new limits of backup and restore jobs; nil values are not changed.
+type ChangeBackupLimitsParams struct {
+	MaxParallelJobs         *int
+	MaxParallelJobsPerAgent *int
+	MaxParallelJobsPerNode  *int
+	AllowConcurrentRestore  *bool
+	JobTimeout              *time.Duration
+}
+
+// ChangeBackupLimits changes concurrency limits and timeout of backup and restore jobs.
+// Jobs queued because of previous limits are started by jobs service when new limits allow that.
+func (s *Server) ChangeBackupLimits(ctx context.Context, params *ChangeBackupLimitsParams) error {
+	return s.db.InTransaction(func(tx *reform.TX) error {
+		_, e := models.UpdateSettings(tx, &models.ChangeSettingsParams{
+			BackupMaxParallelJobs:         params.MaxParallelJobs,
+			BackupMaxParallelJobsPerAgent: params.MaxParallelJobsPerAgent,
+			BackupMaxParallelJobsPerNode:  params.MaxParallelJobsPerNode,
+			BackupAllowConcurrentRestore:  params.AllowConcurrentRestore,
+			BackupJobTimeout:              params.JobTimeout,
+		})
+		if e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+}
+
+// ChangeSIEM configures SIEM collector receiving alert and audit events; nil settings disable forwarding.
+func (s *Server) ChangeSIEM(ctx context.Context, siem *models.SIEMSettings) error {
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		_, e := models.UpdateSettings(tx, &models.ChangeSettingsParams{
+			SIEM:       siem,
+			RemoveSIEM: siem == nil,
+		})
+		if e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.UpdateConfigurations()
+}
+
+// ChangeAlertingEndpoints configures remote VMAlert and Alertmanager used by Integrated Alerting.
+// Local VMAlert or Alertmanager is used if corresponding endpoint is nil.
+func (s *Server) ChangeAlertingEndpoints(ctx context.Context, vmAlert, alertmanager *models.AlertingEndpoint) error {
+	s.envRW.RLock()
+	defer s.envRW.RUnlock()
+
+	err := s.db.InTransaction(func(tx *reform.TX) error {
+		_, e := models.UpdateSettings(tx, &models.ChangeSettingsParams{
+			VMAlertEndpoint:            vmAlert,
+			RemoveVMAlertEndpoint:      vmAlert == nil,
+			AlertmanagerEndpoint:       alertmanager,
+			RemoveAlertmanagerEndpoint: alertmanager == nil,
+		})
+		if e != nil {
+			return status.Error(codes.InvalidArgument, e.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = s.vmalert.SetEndpoint(vmAlert); err != nil {
+		return err
+	}
+	if err = s.alertmanager.SetEndpoint(alertmanager); err != nil {
+		return err
+	}
+
+	s.vmalert.RequestConfigurationUpdate()
+	s.alertmanager.RequestConfigurationUpdate()
+	return nil
+}
+
 // UpdateConfigurations updates supervisor config and requests configuration update for VictoriaMetrics components.
 func (s *Server) UpdateConfigurations() error {
 	settings, err := models.GetSettings(s.db)
@@ -50,7 +50,7 @@ func TestServer(t *testing.T) {
 		mState.Test(t)
 		mState.On("UpdateAgentsState", context.TODO()).Return(nil)
 
-		mvmalert := new(mockPrometheusService)
+		mvmalert := new(mockVmAlertService)
 		mvmalert.Test(t)
 		mvmalert.On("RequestConfigurationUpdate").Return(nil)
 
@@ -0,0 +1,155 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package siem
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/percona/pmm/version"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// Syslog facilities (RFC 5424) used for events.
+const (
+	facilityLogAudit = 13
+	facilityLogAlert = 14
+)
+
+// Syslog severities (RFC 5424).
+const (
+	severityEmergency = iota
+	severityAlert
+	severityCritical
+	severityError
+	severityWarning
+	severityNotice
+	severityInfo
+	severityDebug
+)
+
+// Event kinds; used as syslog MSGID.
+const (
+	alertEventKind = "alert"
+	auditEventKind = "audit"
+)
+
+// cefKeyRE matches characters that can't be present in CEF extension keys.
+var cefKeyRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Event represents a single event forwarded to SIEM collector.
+type Event struct {
+	Kind        string
+	Time        time.Time
+	SignatureID string // for example, "alert_firing" or "audit_node_expired"
+	Name        string
+	Severity    int // syslog severity
+	Fields      map[string]string
+}
+
+// facility returns syslog facility of the event.
+func (e *Event) facility() int {
+	if e.Kind == auditEventKind {
+		return facilityLogAudit
+	}
+	return facilityLogAlert
+}
+
+// cefSeverity returns CEF severity (0-10) for syslog severity.
+func cefSeverity(severity int) int {
+	switch severity {
+	case severityEmergency, severityAlert:
+		return 10
+	case severityCritical:
+		return 9
+	case severityError:
+		return 7
+	case severityWarning:
+		return 5
+	case severityNotice:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// sortedKeys returns sorted keys of fields.
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cefHeaderEscaper escapes CEF header fields.
+var cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+
+// cefExtensionEscaper escapes CEF extension values.
+var cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+
+// syslogEscaper escapes quoted values of plain syslog messages.
+var syslogEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+// formatCEF returns CEF representation of the event.
+func formatCEF(e *Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CEF:0|Percona|PMM|%s|%s|%s|%d|",
+		cefHeaderEscaper.Replace(version.Version),
+		cefHeaderEscaper.Replace(e.SignatureID),
+		cefHeaderEscaper.Replace(e.Name),
+		cefSeverity(e.Severity),
+	)
+	sb.WriteString("rt=" + strconv.FormatInt(e.Time.UnixNano()/int64(time.Millisecond), 10))
+	for _, k := range sortedKeys(e.Fields) {
+		sb.WriteString(" " + cefKeyRE.ReplaceAllString(k, "_") + "=" + cefExtensionEscaper.Replace(e.Fields[k]))
+	}
+	return sb.String()
+}
+
+// formatPlain returns plain text representation of the event.
+func formatPlain(e *Event) string {
+	var sb strings.Builder
+	sb.WriteString(e.Name)
+	fields := make(map[string]string, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+	fields["signature_id"] = e.SignatureID
+	for _, k := range sortedKeys(fields) {
+		sb.WriteString(" " + k + `="` + syslogEscaper.Replace(fields[k]) + `"`)
+	}
+	return sb.String()
+}
+
+// formatSyslog returns RFC 5424 syslog message for the event in the given format.
+func formatSyslog(e *Event, hostname, format string) string {
+	msg := formatPlain(e)
+	if format == models.SIEMFormatCEF {
+		msg = formatCEF(e)
+	}
+
+	pri := e.facility()*8 + e.Severity
+	ts := e.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	return fmt.Sprintf("<%d>1 %s %s pmm-managed - %s - %s", pri, ts, hostname, e.Kind, msg)
+}
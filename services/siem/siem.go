@@ -0,0 +1,402 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package siem forwards alert and audit events to SIEM collector as syslog/CEF messages.
+package siem
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/automations"
+)
+
+const (
+	prometheusNamespace = "pmm_managed"
+	prometheusSubsystem = "siem"
+
+	queueSize    = 10000
+	pollInterval = 10 * time.Second
+	dialTimeout  = 10 * time.Second
+	writeTimeout = 10 * time.Second
+	sendAttempts = 3
+	retryDelay   = 3 * time.Second
+)
+
+// Forwarder sends alert firing/resolution events and audit log entries to SIEM collector.
+// Events are queued; they are dropped if the queue is full or can't be sent after several attempts.
+type Forwarder struct {
+	db       *reform.DB
+	l        *logrus.Entry
+	hostname string
+	queue    chan *Event
+
+	rw       sync.RWMutex
+	settings *models.SIEMSettings
+
+	conn net.Conn // used only by Run's goroutine
+
+	mSent    prom.Counter
+	mDropped *prom.CounterVec
+	mQueue   prom.GaugeFunc
+}
+
+// New creates new SIEM forwarder.
+func New(db *reform.DB) *Forwarder {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "pmm-server"
+	}
+
+	f := &Forwarder{
+		db:       db,
+		l:        logrus.WithField("component", "siem"),
+		hostname: hostname,
+		queue:    make(chan *Event, queueSize),
+
+		mSent: prom.NewCounter(prom.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "events_sent_total",
+			Help:      "A total number of events sent to SIEM collector.",
+		}),
+		mDropped: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "events_dropped_total",
+			Help:      "A total number of events dropped without sending to SIEM collector.",
+		}, []string{"reason"}),
+	}
+	f.mQueue = prom.NewGaugeFunc(prom.GaugeOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: prometheusSubsystem,
+		Name:      "queue_length",
+		Help:      "A number of events waiting to be sent to SIEM collector.",
+	}, func() float64 { return float64(len(f.queue)) })
+
+	// initialize labels
+	f.mDropped.WithLabelValues("queue_full")
+	f.mDropped.WithLabelValues("send_error")
+
+	return f
+}
+
+// Run sends queued events and polls audit log until context is canceled.
+func (f *Forwarder) Run(ctx context.Context) {
+	f.l.Info("Starting...")
+	defer f.l.Info("Done.")
+
+	defer f.closeConn()
+
+	f.reloadSettings()
+	auditCursor := models.Now()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if f.reloadSettings() {
+				f.closeConn()
+			}
+			auditCursor = f.pollAuditEvents(auditCursor)
+
+		case e := <-f.queue:
+			f.send(ctx, e)
+		}
+	}
+}
+
+// reloadSettings loads SIEM settings from the database and returns true if they were changed.
+func (f *Forwarder) reloadSettings() bool {
+	settings, err := models.GetSettings(f.db)
+	if err != nil {
+		f.l.Errorf("Failed to get settings: %s.", err)
+		return false
+	}
+
+	f.rw.Lock()
+	defer f.rw.Unlock()
+
+	if reflect.DeepEqual(f.settings, settings.SIEM) {
+		return false
+	}
+	f.settings = settings.SIEM
+	return true
+}
+
+// getSettings returns the current SIEM settings; nil if forwarding is disabled.
+func (f *Forwarder) getSettings() *models.SIEMSettings {
+	f.rw.RLock()
+	defer f.rw.RUnlock()
+	return f.settings
+}
+
+// Enqueue adds event to the sending queue. It does nothing if forwarding is disabled.
+func (f *Forwarder) Enqueue(e *Event) {
+	if f.getSettings() == nil {
+		return
+	}
+
+	select {
+	case f.queue <- e:
+	default:
+		f.mDropped.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// HandleAlertmanagerWebhook enqueues firing and resolution events of Alertmanager notification.
+func (f *Forwarder) HandleAlertmanagerWebhook(msg *automations.WebhookMessage) {
+	for _, a := range msg.Alerts {
+		f.Enqueue(alertEvent(a))
+	}
+}
+
+// alertEvent converts Alertmanager alert to event.
+func alertEvent(a *automations.WebhookAlert) *Event {
+	e := &Event{
+		Kind:        alertEventKind,
+		Time:        a.StartsAt,
+		SignatureID: "alert_" + a.Status,
+		Name:        a.Labels["alertname"],
+		Severity:    severityWarning,
+		Fields: map[string]string{
+			"status":      a.Status,
+			"fingerprint": a.Fingerprint,
+		},
+	}
+	if s := a.Annotations["summary"]; s != "" {
+		e.Name = s
+	}
+	if e.Name == "" {
+		e.Name = "Alert " + a.Status
+	}
+
+	switch strings.ToLower(a.Labels["severity"]) {
+	case "emergency":
+		e.Severity = severityEmergency
+	case "alert":
+		e.Severity = severityAlert
+	case "critical":
+		e.Severity = severityCritical
+	case "error":
+		e.Severity = severityError
+	case "notice":
+		e.Severity = severityNotice
+	case "info":
+		e.Severity = severityInfo
+	case "debug":
+		e.Severity = severityDebug
+	}
+
+	if a.Status == "resolved" {
+		e.Time = a.EndsAt
+		e.Severity = severityInfo
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	if d := a.Annotations["description"]; d != "" {
+		e.Fields["description"] = d
+	}
+	if a.GeneratorURL != "" {
+		e.Fields["generator_url"] = a.GeneratorURL
+	}
+	for k, v := range a.Labels {
+		e.Fields["label_"+k] = v
+	}
+	return e
+}
+
+// auditEvent converts audit log entry to event.
+func auditEvent(ae *models.AuditEvent) *Event {
+	e := &Event{
+		Kind:        auditEventKind,
+		Time:        ae.CreatedAt,
+		SignatureID: "audit_" + string(ae.Type),
+		Name:        ae.Message,
+		Severity:    severityNotice,
+		Fields: map[string]string{
+			"id":        ae.ID,
+			"type":      string(ae.Type),
+			"object_id": ae.ObjectID,
+		},
+	}
+	if ae.Actor != "" {
+		e.Fields["actor"] = ae.Actor
+	}
+	return e
+}
+
+// pollAuditEvents enqueues audit events created after cursor and returns the new cursor.
+func (f *Forwarder) pollAuditEvents(cursor time.Time) time.Time {
+	if f.getSettings() == nil {
+		// do not send events created while forwarding was disabled
+		return models.Now()
+	}
+
+	events, err := models.FindAuditEvents(f.db.Querier, models.AuditEventsFilter{CreatedAfter: cursor})
+	if err != nil {
+		f.l.Errorf("Failed to get audit events: %s.", err)
+		return cursor
+	}
+
+	// events are sorted latest first
+	for i := len(events) - 1; i >= 0; i-- {
+		f.Enqueue(auditEvent(events[i]))
+		cursor = events[i].CreatedAt
+	}
+	return cursor
+}
+
+// send sends event to the collector, retrying a few times.
+func (f *Forwarder) send(ctx context.Context, e *Event) {
+	settings := f.getSettings()
+	if settings == nil {
+		return
+	}
+
+	msg := formatSyslog(e, f.hostname, settings.Format)
+	var err error
+	for attempt := 1; attempt <= sendAttempts; attempt++ {
+		if err = f.write(settings, msg); err == nil {
+			f.mSent.Inc()
+			return
+		}
+
+		f.l.Warnf("Failed to send event to SIEM collector %s (attempt %d/%d): %s.", settings.Address, attempt, sendAttempts, err)
+		f.closeConn()
+
+		if attempt == sendAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			f.mDropped.WithLabelValues("send_error").Inc()
+			return
+		case <-time.After(retryDelay):
+		}
+	}
+
+	f.mDropped.WithLabelValues("send_error").Inc()
+}
+
+// write writes syslog message to the collector, connecting if needed.
+// Octet-counting framing (RFC 5425, RFC 6587) is used for tcp and tls protocols.
+func (f *Forwarder) write(settings *models.SIEMSettings, msg string) error {
+	if f.conn == nil {
+		conn, err := dial(settings)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+
+	b := []byte(msg)
+	if settings.Protocol != models.SIEMProtocolUDP {
+		b = []byte(fmt.Sprintf("%d %s", len(b), msg))
+	}
+
+	if err := f.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := f.conn.Write(b)
+	return errors.WithStack(err)
+}
+
+// closeConn closes the current connection, if any.
+func (f *Forwarder) closeConn() {
+	if f.conn == nil {
+		return
+	}
+	if err := f.conn.Close(); err != nil {
+		f.l.Debugf("Failed to close connection: %s.", err)
+	}
+	f.conn = nil
+}
+
+// dial connects to the collector with given settings.
+func dial(settings *models.SIEMSettings) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	switch settings.Protocol {
+	case models.SIEMProtocolUDP, models.SIEMProtocolTCP:
+		conn, err := dialer.Dial(settings.Protocol, settings.Address)
+		return conn, errors.WithStack(err)
+
+	case models.SIEMProtocolTLS:
+		host, _, err := net.SplitHostPort(settings.Address)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		config := &tls.Config{ //nolint:gosec
+			ServerName:         host,
+			InsecureSkipVerify: settings.InsecureSkipVerify,
+		}
+		if settings.ServerName != "" {
+			config.ServerName = settings.ServerName
+		}
+		if settings.CA != "" {
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM([]byte(settings.CA)) {
+				return nil, errors.New("invalid CA certificate")
+			}
+		}
+		conn, err := tls.DialWithDialer(dialer, "tcp", settings.Address, config)
+		return conn, errors.WithStack(err)
+
+	default:
+		return nil, errors.Errorf("unsupported protocol %q", settings.Protocol)
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (f *Forwarder) Describe(ch chan<- *prom.Desc) {
+	f.mSent.Describe(ch)
+	f.mDropped.Describe(ch)
+	f.mQueue.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (f *Forwarder) Collect(ch chan<- prom.Metric) {
+	f.mSent.Collect(ch)
+	f.mDropped.Collect(ch)
+	f.mQueue.Collect(ch)
+}
+
+// check interfaces.
+var (
+	_ prom.Collector = (*Forwarder)(nil)
+)
@@ -0,0 +1,159 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package siem
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/services/automations"
+)
+
+func TestFormat(t *testing.T) {
+	e := &Event{
+		Kind:        alertEventKind,
+		Time:        time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		SignatureID: "alert_firing",
+		Name:        "Disk | full",
+		Severity:    severityCritical,
+		Fields: map[string]string{
+			"status":      "firing",
+			"label_a.b":   "x=y",
+			"description": "line1\nline2 \"quoted\"",
+		},
+	}
+
+	t.Run("Syslog", func(t *testing.T) {
+		actual := formatSyslog(e, "pmm", models.SIEMFormatSyslog)
+		expected := `<114>1 2020-01-02T03:04:05.000000Z pmm pmm-managed - alert - Disk | full ` +
+			`description="line1\nline2 \"quoted\"" label_a.b="x=y" signature_id="alert_firing" status="firing"`
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("CEF", func(t *testing.T) {
+		actual := formatSyslog(e, "pmm", models.SIEMFormatCEF)
+		assert.Regexp(t, `^<114>1 2020-01-02T03:04:05.000000Z pmm pmm-managed - alert - CEF:0\|Percona\|PMM\|[^|]*\|alert_firing\|Disk \\\| full\|9\|`, actual)
+		assert.Contains(t, actual, `|rt=1577934245000 description=line1\nline2 "quoted" label_a_b=x\=y status=firing`)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		ae := &models.AuditEvent{
+			ID:        "/audit_event_id/1",
+			Type:      "node_expired",
+			ObjectID:  "/node_id/1",
+			Message:   "Node expired.",
+			CreatedAt: e.Time,
+		}
+		actual := formatSyslog(auditEvent(ae), "pmm", models.SIEMFormatSyslog)
+		expected := `<109>1 2020-01-02T03:04:05.000000Z pmm pmm-managed - audit - Node expired. ` +
+			`id="/audit_event_id/1" object_id="/node_id/1" signature_id="audit_node_expired" type="node_expired"`
+		assert.Equal(t, expected, actual)
+
+		ae.Actor = "admin"
+		actual = formatSyslog(auditEvent(ae), "pmm", models.SIEMFormatSyslog)
+		assert.Contains(t, actual, `actor="admin" id="/audit_event_id/1"`)
+	})
+}
+
+func TestAlertEvent(t *testing.T) {
+	startsAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	endsAt := startsAt.Add(time.Hour)
+
+	firing := alertEvent(&automations.WebhookAlert{
+		Status:      "firing",
+		Labels:      map[string]string{"alertname": "Down", "severity": "error"},
+		Annotations: map[string]string{"summary": "Service is down"},
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+	})
+	assert.Equal(t, "alert_firing", firing.SignatureID)
+	assert.Equal(t, "Service is down", firing.Name)
+	assert.Equal(t, severityError, firing.Severity)
+	assert.Equal(t, startsAt, firing.Time)
+	assert.Equal(t, "Down", firing.Fields["label_alertname"])
+
+	resolved := alertEvent(&automations.WebhookAlert{
+		Status:   "resolved",
+		Labels:   map[string]string{"alertname": "Down", "severity": "critical"},
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+	})
+	assert.Equal(t, "alert_resolved", resolved.SignatureID)
+	assert.Equal(t, "Down", resolved.Name)
+	assert.Equal(t, severityInfo, resolved.Severity)
+	assert.Equal(t, endsAt, resolved.Time)
+}
+
+func TestForwarder(t *testing.T) {
+	t.Run("TCP", func(t *testing.T) {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer lis.Close() //nolint:errcheck
+
+		f := New(nil)
+		f.settings = &models.SIEMSettings{
+			Address:  lis.Addr().String(),
+			Protocol: models.SIEMProtocolTCP,
+			Format:   models.SIEMFormatSyslog,
+		}
+		defer f.closeConn()
+
+		e := &Event{Kind: auditEventKind, Time: time.Now(), SignatureID: "audit_test", Name: "test"}
+		f.send(context.Background(), e)
+		assert.Equal(t, 1.0, testutil.ToFloat64(f.mSent))
+
+		conn, err := lis.Accept()
+		require.NoError(t, err)
+		defer conn.Close() //nolint:errcheck
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		msg := formatSyslog(e, f.hostname, models.SIEMFormatSyslog)
+		r := bufio.NewReader(conn)
+		length, err := r.ReadString(' ')
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(len(msg))+" ", length)
+		b := make([]byte, len(msg))
+		_, err = io.ReadFull(r, b)
+		require.NoError(t, err)
+		assert.Equal(t, msg, string(b))
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		f := New(nil)
+
+		// disabled
+		f.Enqueue(&Event{})
+		assert.Len(t, f.queue, 0)
+
+		f.settings = &models.SIEMSettings{Address: "127.0.0.1:514", Protocol: models.SIEMProtocolUDP}
+		for i := 0; i < queueSize+2; i++ {
+			f.Enqueue(&Event{})
+		}
+		assert.Len(t, f.queue, queueSize)
+		assert.Equal(t, 2.0, testutil.ToFloat64(f.mDropped.WithLabelValues("queue_full")))
+	})
+}
@@ -36,7 +36,9 @@ import (
 	"github.com/percona/pmm/utils/pdeathsig"
 	"github.com/pkg/errors"
 	"golang.org/x/sys/unix"
+	"gopkg.in/reform.v1"
 
+	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/utils/logger"
 )
 
@@ -57,14 +59,17 @@ type fileContent struct {
 type Logs struct {
 	pmmVersion       string
 	pmmUpdateChecker *PMMUpdateChecker
+	db               *reform.DB
 }
 
 // NewLogs creates a new Logs service.
 // n is a number of last lines of log to read.
-func NewLogs(pmmVersion string, pmmUpdateChecker *PMMUpdateChecker) *Logs {
+// db is used to add inventory information; it may be nil.
+func NewLogs(pmmVersion string, pmmUpdateChecker *PMMUpdateChecker, db *reform.DB) *Logs {
 	return &Logs{
 		pmmVersion:       pmmVersion,
 		pmmUpdateChecker: pmmUpdateChecker,
+		db:               db,
 	}
 }
 
@@ -214,10 +219,43 @@ func (l *Logs) files(ctx context.Context) []fileContent {
 		Err:  err,
 	})
 
+	// add Nodes OS facts
+	if l.db != nil {
+		b, err = l.nodesFacts()
+		files = append(files, fileContent{
+			Name: "nodes_facts.json",
+			Data: b,
+			Err:  err,
+		})
+	}
+
 	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
 	return files
 }
 
+// nodesFacts returns collected OS facts of all Nodes as JSON.
+func (l *Logs) nodesFacts() ([]byte, error) {
+	nodes, err := models.FindNodes(l.db.Querier, models.NodeFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	type nodeFacts struct {
+		NodeID   string            `json:"node_id"`
+		NodeName string            `json:"node_name"`
+		Facts    *models.NodeFacts `json:"facts"`
+	}
+	res := make([]nodeFacts, len(nodes))
+	for i, node := range nodes {
+		res[i] = nodeFacts{
+			NodeID:   node.NodeID,
+			NodeName: node.NodeName,
+			Facts:    node.Facts,
+		}
+	}
+	return json.MarshalIndent(res, "", "  ")
+}
+
 // readLog reads last lines (up to given number of lines and bytes) from given file,
 // and returns them together with modification time.
 func readLog(name string, maxLines int, maxBytes int64) ([]byte, time.Time, error) {
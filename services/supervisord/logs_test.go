@@ -122,7 +122,7 @@ func TestAddAdminSummary(t *testing.T) {
 
 func TestFiles(t *testing.T) {
 	checker := NewPMMUpdateChecker(logrus.WithField("test", t.Name()))
-	l := NewLogs("2.4.5", checker)
+	l := NewLogs("2.4.5", checker, nil)
 	ctx := logger.Set(context.Background(), t.Name())
 
 	files := l.files(ctx)
@@ -154,7 +154,7 @@ func TestFiles(t *testing.T) {
 
 func TestZip(t *testing.T) {
 	checker := NewPMMUpdateChecker(logrus.WithField("test", t.Name()))
-	l := NewLogs("2.4.5", checker)
+	l := NewLogs("2.4.5", checker, nil)
 	ctx := logger.Set(context.Background(), t.Name())
 
 	var buf bytes.Buffer
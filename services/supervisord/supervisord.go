@@ -29,6 +29,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,6 +40,7 @@ import (
 	"github.com/percona/pmm/utils/pdeathsig"
 	"github.com/percona/pmm/version"
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/codes"
@@ -62,6 +64,9 @@ type Service struct {
 	supervisordConfigsM  sync.Mutex
 
 	vmParams *models.VictoriaMetricsParams
+
+	vmDownsamplingOnce      sync.Once
+	vmDownsamplingSupported bool
 }
 
 type sub struct {
@@ -380,6 +385,12 @@ func (s *Service) UpdateLog(offset uint32) ([]string, uint32, error) {
 	}
 }
 
+// RestartProgram restarts supervisord program with given name.
+func (s *Service) RestartProgram(name string) error {
+	_, err := s.supervisorctl("restart", name)
+	return err
+}
+
 // reload asks supervisord to reload configuration.
 func (s *Service) reload(name string) error {
 	// See https://github.com/Supervisor/supervisor/issues/1264 for explanation
@@ -411,6 +422,17 @@ func (s *Service) marshalConfig(tmpl *template.Template, settings *models.Settin
 	if err := addAlertManagerParams(settings.AlertManagerURL, templateParams); err != nil {
 		return nil, errors.Wrap(err, "cannot add AlertManagerParams to supervisor template")
 	}
+	addExternalVictoriaMetricsParams(settings.VictoriaMetrics.External, templateParams)
+	templateParams["VMAlertExternalLabels"] = externalLabelFlags(settings.VictoriaMetrics.ExternalLabels)
+
+	templateParams["VMDownsampling"] = ""
+	if len(settings.VictoriaMetrics.Downsampling) != 0 {
+		if s.vmSupportsDownsampling() {
+			templateParams["VMDownsampling"] = downsamplingFlag(settings.VictoriaMetrics.Downsampling)
+		} else {
+			s.l.Warn("Downsampling is configured, but VictoriaMetrics does not support it; skipping.")
+		}
+	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, templateParams); err != nil {
@@ -420,6 +442,31 @@ func (s *Service) marshalConfig(tmpl *template.Template, settings *models.Settin
 	return b, nil
 }
 
+// vmSupportsDownsampling returns true if local VictoriaMetrics binary supports downsampling
+// (it is available only in enterprise builds).
+func (s *Service) vmSupportsDownsampling() bool {
+	s.vmDownsamplingOnce.Do(func() {
+		// flags usage is printed with non-zero exit code by some versions, so error is ignored
+		b, _ := exec.Command("victoriametrics", "-help").CombinedOutput() //nolint:gosec
+		s.vmDownsamplingSupported = bytes.Contains(b, []byte("downsampling.period"))
+	})
+	return s.vmDownsamplingSupported
+}
+
+// downsamplingFlag returns value of VictoriaMetrics -downsampling.period flag for given periods,
+// for example, "30d:5m,180d:1h".
+func downsamplingFlag(periods []*models.DownsamplingPeriod) string {
+	sorted := make([]*models.DownsamplingPeriod, len(periods))
+	copy(sorted, periods)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	res := make([]string, len(sorted))
+	for i, p := range sorted {
+		res[i] = model.Duration(p.Offset).String() + ":" + model.Duration(p.Interval).String()
+	}
+	return strings.Join(res, ",")
+}
+
 // addAlertManagerParams parses alertManagerURL
 // and extracts url, username and password from it to templateParams.
 func addAlertManagerParams(alertManagerURL string, templateParams map[string]interface{}) error {
@@ -451,6 +498,56 @@ func addAlertManagerParams(alertManagerURL string, templateParams map[string]int
 	return nil
 }
 
+// externalLabelFlags returns sorted values of vmalert -external.label flags for given labels.
+func externalLabelFlags(labels map[string]string) []string {
+	res := make([]string, 0, len(labels))
+	for name, value := range labels {
+		res = append(res, fmt.Sprintf("%s=%s", name, strconv.Quote(value)))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// addExternalVictoriaMetricsParams adds parameters of external metrics backend to templateParams.
+// If it is configured, local VictoriaMetrics database is replaced by vmagent that scrapes the same targets
+// and writes metrics to the external backend, and vmalert uses that backend as data source.
+// Local VictoriaMetrics address is then served by pmm-managed that proxies queries to the external backend
+// and other requests to vmagent (see victoriametrics package).
+func addExternalVictoriaMetricsParams(external *models.ExternalVictoriaMetrics, templateParams map[string]interface{}) {
+	templateParams["ExternalVM"] = external != nil
+	templateParams["VMReadURL"] = "http://127.0.0.1:9090/prometheus"
+	templateParams["VMWriteURL"] = "http://127.0.0.1:9090/prometheus"
+	templateParams["VMAlertWriteURL"] = "http://127.0.0.1:9090/prometheus"
+	templateParams["ExternalVMInsecureSkipVerify"] = false
+	templateParams["VMAgentEnvironment"] = ""
+	templateParams["VMAlertEnvironment"] = ""
+	if external == nil {
+		return
+	}
+
+	templateParams["VMReadURL"] = external.ReadURL
+	templateParams["VMWriteURL"] = external.WriteURL
+	templateParams["VMAlertWriteURL"] = strings.TrimSuffix(external.WriteURL, "/api/v1/write")
+	templateParams["ExternalVMInsecureSkipVerify"] = external.InsecureSkipVerify
+	if external.Username == "" {
+		return
+	}
+
+	// pass credentials via environment variables to hide them from process list
+	env := func(prefixes ...string) string {
+		var res []string
+		for _, p := range prefixes {
+			res = append(res,
+				fmt.Sprintf("%s_basicAuth_username=%s", p, strconv.Quote(external.Username)),
+				fmt.Sprintf("%s_basicAuth_password=%s", p, strconv.Quote(external.Password)),
+			)
+		}
+		return strings.Join(res, ",")
+	}
+	templateParams["VMAgentEnvironment"] = env("remoteWrite")
+	templateParams["VMAlertEnvironment"] = env("datasource", "remoteRead", "remoteWrite")
+}
+
 // saveConfigAndReload saves given supervisord program configuration to file and reloads it.
 // If configuration can't be reloaded for some reason, old file is restored, and configuration is reloaded again.
 // Returns true if configuration was changed.
@@ -571,9 +668,27 @@ redirect_stderr = true
 [program:victoriametrics]
 priority = 7
 command =
+{{- if .ExternalVM }}
+	/usr/local/percona/pmm2/exporters/vmagent
+		--promscrape.config=/etc/victoriametrics-promscrape.yml
+		--remoteWrite.url={{ .VMWriteURL }}
+		--remoteWrite.tlsInsecureSkipVerify={{ .ExternalVMInsecureSkipVerify }}
+		--remoteWrite.tmpDataPath=/srv/victoriametrics/vmagent-temp-dir
+		--remoteWrite.maxDiskUsagePerURL=1073741824
+		--httpListenAddr=127.0.0.1:8429
+		--promscrape.streamParse=true
+		--http.pathPrefix=/prometheus
+		--envflag.enable=true
+{{- if .VMAgentEnvironment }}
+environment = {{ .VMAgentEnvironment }}
+{{- end }}
+{{- else }}
 	/usr/sbin/victoriametrics
 		--promscrape.config=/etc/victoriametrics-promscrape.yml
 		--retentionPeriod={{ .DataRetentionDays }}d
+{{- if .VMDownsampling }}
+		--downsampling.period={{ .VMDownsampling }}
+{{- end }}
 		--storageDataPath=/srv/victoriametrics/data
 		--httpListenAddr=127.0.0.1:9090
 		--search.disableCache={{ .VMDBCacheDisable }}
@@ -586,6 +701,7 @@ command =
 		--promscrape.streamParse=true
 		--prometheusDataPath=/srv/prometheus/data
 		--http.pathPrefix=/prometheus
+{{- end }}
 user = pmm
 autorestart = true
 autostart = true
@@ -608,15 +724,31 @@ command =
 		--notifier.basicAuth.password='{{ .AlertManagerPassword }}'
 		--notifier.basicAuth.username="{{ .AlertManagerUser }}"
 		--external.url=http://localhost:9090/prometheus
+{{- if .ExternalVM }}
+		--datasource.url={{ .VMReadURL }}
+		--datasource.tlsInsecureSkipVerify={{ .ExternalVMInsecureSkipVerify }}
+		--remoteRead.url={{ .VMReadURL }}
+		--remoteRead.tlsInsecureSkipVerify={{ .ExternalVMInsecureSkipVerify }}
+		--remoteWrite.url={{ .VMAlertWriteURL }}
+		--remoteWrite.tlsInsecureSkipVerify={{ .ExternalVMInsecureSkipVerify }}
+		--envflag.enable=true
+{{- else }}
 		--datasource.url=http://127.0.0.1:9090/prometheus
 		--remoteRead.url=http://127.0.0.1:9090/prometheus
 		--remoteWrite.url=http://127.0.0.1:9090/prometheus
+{{- end }}
 		--rule=/srv/prometheus/rules/*.yml
 		--rule=/etc/ia/rules/*.yml
 		--httpListenAddr=127.0.0.1:8880
+{{- range $index, $label := .VMAlertExternalLabels }}
+		--external.label={{ $label }}
+{{- end }}
 {{- range $index, $param := .VMAlertFlags }}
 		{{ $param }}
 {{- end }}
+{{- if .VMAlertEnvironment }}
+environment = {{ .VMAlertEnvironment }}
+{{- end }}
 user = pmm
 autorestart = true
 autostart = true
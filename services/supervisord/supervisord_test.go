@@ -19,6 +19,7 @@ package supervisord
 import (
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"text/template"
 	"time"
@@ -61,6 +62,93 @@ func TestConfig(t *testing.T) {
 	}
 }
 
+func TestExternalVictoriaMetrics(t *testing.T) {
+	t.Parallel()
+
+	pmmUpdateCheck := NewPMMUpdateChecker(logrus.WithField("component", "supervisord/pmm-update-checker_logs"))
+	configDir := filepath.Join("..", "..", "testdata", "supervisord.d")
+	vmParams := &models.VictoriaMetricsParams{}
+	s := New(configDir, pmmUpdateCheck, vmParams)
+	settings := &models.Settings{
+		DataRetention: 30 * 24 * time.Hour,
+	}
+	settings.VictoriaMetrics.External = &models.ExternalVictoriaMetrics{
+		ReadURL:            "https://vmselect:8481/select/0/prometheus",
+		WriteURL:           "https://vminsert:8480/insert/0/prometheus/api/v1/write",
+		Username:           "user",
+		Password:           `pass"word`,
+		InsecureSkipVerify: true,
+	}
+
+	for _, tmpl := range templates.Templates() {
+		n := tmpl.Name()
+		if n != "victoriametrics" && n != "vmalert" {
+			continue
+		}
+
+		tmpl := tmpl
+		t.Run(tmpl.Name(), func(t *testing.T) {
+			expected, err := ioutil.ReadFile(filepath.Join(configDir, tmpl.Name()+"_external.ini")) //nolint:gosec
+			require.NoError(t, err)
+			actual, err := s.marshalConfig(tmpl, settings)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestDownsampling(t *testing.T) {
+	t.Parallel()
+
+	pmmUpdateCheck := NewPMMUpdateChecker(logrus.WithField("component", "supervisord/pmm-update-checker_logs"))
+	configDir := filepath.Join("..", "..", "testdata", "supervisord.d")
+	settings := &models.Settings{
+		DataRetention: 30 * 24 * time.Hour,
+	}
+	settings.VictoriaMetrics.Downsampling = []*models.DownsamplingPeriod{
+		{Offset: 180 * 24 * time.Hour, Interval: time.Hour},
+		{Offset: 30 * 24 * time.Hour, Interval: 5 * time.Minute},
+	}
+	tmpl := templates.Lookup("victoriametrics")
+
+	for _, supported := range []bool{true, false} {
+		supported := supported
+		t.Run(strconv.FormatBool(supported), func(t *testing.T) {
+			t.Parallel()
+
+			s := New(configDir, pmmUpdateCheck, &models.VictoriaMetricsParams{})
+			s.vmDownsamplingOnce.Do(func() { s.vmDownsamplingSupported = supported })
+
+			actual, err := s.marshalConfig(tmpl, settings)
+			require.NoError(t, err)
+			if supported {
+				assert.Contains(t, string(actual), "--downsampling.period=30d:5m,180d:1h\n")
+			} else {
+				assert.NotContains(t, string(actual), "downsampling")
+			}
+		})
+	}
+}
+
+func TestExternalLabels(t *testing.T) {
+	t.Parallel()
+
+	pmmUpdateCheck := NewPMMUpdateChecker(logrus.WithField("component", "supervisord/pmm-update-checker_logs"))
+	configDir := filepath.Join("..", "..", "testdata", "supervisord.d")
+	settings := &models.Settings{
+		DataRetention: 30 * 24 * time.Hour,
+	}
+	settings.VictoriaMetrics.ExternalLabels = map[string]string{
+		"pmm_server": "pmm-1",
+		"datacenter": "us east",
+	}
+
+	s := New(configDir, pmmUpdateCheck, &models.VictoriaMetricsParams{})
+	actual, err := s.marshalConfig(templates.Lookup("vmalert"), settings)
+	require.NoError(t, err)
+	assert.Contains(t, string(actual), "\t\t--external.label=datacenter=\"us east\"\n\t\t--external.label=pmm_server=\"pmm-1\"\n")
+}
+
 func TestDBaaSController(t *testing.T) {
 	t.Parallel()
 
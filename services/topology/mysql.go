@@ -0,0 +1,105 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package topology
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+// mysqld_exporter's slave_status collector metrics.
+const (
+	mysqlLagQuery        = `max by (service_id, master_host) (mysql_slave_status_seconds_behind_master)`
+	mysqlIORunningQuery  = `min by (service_id, master_host) (mysql_slave_status_slave_io_running)`
+	mysqlSQLRunningQuery = `min by (service_id, master_host) (mysql_slave_status_slave_sql_running)`
+)
+
+// mysqlLinks returns replication links reported by MySQL replicas.
+func (s *Service) mysqlLinks(ctx context.Context) ([]*replicationLink, error) {
+	now := time.Now()
+	links := make(map[[2]string]*replicationLink)
+	link := func(metric model.Metric) *replicationLink {
+		key := [2]string{string(metric["service_id"]), string(metric["master_host"])}
+		l := links[key]
+		if l == nil {
+			l = &replicationLink{
+				replicaServiceID: key[0],
+				primaryHost:      key[1],
+			}
+			links[key] = l
+		}
+		return l
+	}
+
+	ioRunning, err := s.queryVector(ctx, mysqlIORunningQuery, now)
+	if err != nil {
+		return nil, err
+	}
+	sqlRunning, err := s.queryVector(ctx, mysqlSQLRunningQuery, now)
+	if err != nil {
+		return nil, err
+	}
+	lag, err := s.queryVector(ctx, mysqlLagQuery, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// replication is running only if both threads are running
+	for _, sample := range ioRunning {
+		link(sample.Metric).running = sample.Value == 1
+	}
+	for _, sample := range sqlRunning {
+		l := link(sample.Metric)
+		l.running = l.running && sample.Value == 1
+	}
+	for _, sample := range lag {
+		// lag is unknown (NULL) if SQL thread is not running
+		if sample.Value >= 0 {
+			v := float64(sample.Value)
+			link(sample.Metric).lag = &v
+		}
+	}
+
+	res := make([]*replicationLink, 0, len(links))
+	for key, l := range links {
+		if key[0] == "" {
+			continue
+		}
+		res = append(res, l)
+	}
+	return res, nil
+}
+
+// queryVector runs instant query and returns vector result.
+func (s *Service) queryVector(ctx context.Context, query string, ts time.Time) (model.Vector, error) {
+	v, warnings, err := s.api.Query(ctx, query, ts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to execute query %q", query)
+	}
+	for _, w := range warnings {
+		s.l.Warnf("Query %q: %s.", query, w)
+	}
+
+	vector, ok := v.(model.Vector)
+	if !ok {
+		return nil, errors.Errorf("unexpected result type %s for query %q", v.Type(), query)
+	}
+	return vector, nil
+}
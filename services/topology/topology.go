@@ -0,0 +1,374 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package topology infers replication topology of monitored Services from their metrics.
+package topology
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	detectInterval = time.Minute
+	queryTimeout   = 30 * time.Second
+)
+
+// Role represents Service role in replication topology.
+type Role string
+
+// Known roles.
+const (
+	PrimaryRole Role = "primary"
+	ReplicaRole Role = "replica"
+)
+
+// Member represents a Service in replication topology.
+type Member struct {
+	ServiceID   string `json:"service_id"`
+	ServiceName string `json:"service_name"`
+	Role        Role   `json:"role"`
+
+	// Fields below are set for replicas only.
+
+	// Primary Service ID; empty if primary is not monitored by PMM.
+	PrimaryServiceID string `json:"primary_service_id,omitempty"`
+	// Primary address as reported by replica.
+	PrimaryHost string `json:"primary_host,omitempty"`
+	// Replication lag in seconds; nil if unknown.
+	LagSeconds *float64 `json:"lag_seconds,omitempty"`
+	// False if replication is stopped or broken.
+	Running bool `json:"running"`
+}
+
+// Cluster represents a group of Services replicating from each other.
+type Cluster struct {
+	ReplicationSet string             `json:"replication_set"`
+	ServiceType    models.ServiceType `json:"service_type"`
+	Members        []*Member          `json:"members"`
+}
+
+// replicationLink represents replication from primary to replica Service as reported by replica.
+type replicationLink struct {
+	replicaServiceID string
+	primaryHost      string
+	lag              *float64
+	running          bool
+}
+
+// Service periodically infers replication topology from metrics stored in VictoriaMetrics,
+// and fills empty replication sets of detected Services.
+type Service struct {
+	db   *reform.DB
+	api  v1.API
+	vmdb prometheusService
+	l    *logrus.Entry
+
+	rw         sync.RWMutex
+	clusters   []*Cluster
+	detectedAt time.Time
+}
+
+// New creates new topology service for VictoriaMetrics with given base URL.
+func New(db *reform.DB, victoriaMetricsURL string, vmdb prometheusService) (*Service, error) {
+	client, err := api.NewClient(api.Config{
+		Address: victoriaMetricsURL,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &Service{
+		db:   db,
+		api:  v1.NewAPI(client),
+		vmdb: vmdb,
+		l:    logrus.WithField("component", "topology"),
+	}, nil
+}
+
+// Run detects topology periodically until ctx is canceled.
+func (s *Service) Run(ctx context.Context) {
+	s.l.Info("Starting...")
+	defer s.l.Info("Done.")
+
+	ticker := time.NewTicker(detectInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.detect(ctx); err != nil {
+			s.l.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetReplicationTopology returns last detected replication topology.
+// If Service ID is given, only the cluster of that Service is returned.
+func (s *Service) GetReplicationTopology(ctx context.Context, serviceID string) ([]*Cluster, error) {
+	s.rw.RLock()
+	clusters, detectedAt := s.clusters, s.detectedAt
+	s.rw.RUnlock()
+
+	if detectedAt.IsZero() {
+		if err := s.detect(ctx); err != nil {
+			return nil, err
+		}
+		s.rw.RLock()
+		clusters = s.clusters
+		s.rw.RUnlock()
+	}
+
+	if serviceID == "" {
+		return clusters, nil
+	}
+
+	if _, err := models.FindServiceByID(s.db.Querier, serviceID); err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		for _, m := range c.Members {
+			if m.ServiceID == serviceID {
+				return []*Cluster{c}, nil
+			}
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "Replication topology of Service with ID %q not found.", serviceID)
+}
+
+// detect infers current topology, fills empty replication sets, and stores the result.
+func (s *Service) detect(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	links, err := s.mysqlLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var clusters []*Cluster
+	var changed bool
+	err = s.db.InTransaction(func(tx *reform.TX) error {
+		services, err := models.FindServices(tx.Querier, models.ServiceFilters{ServiceType: servicePointer(models.MySQLServiceType)})
+		if err != nil {
+			return err
+		}
+		nodes, err := models.FindNodes(tx.Querier, models.NodeFilters{})
+		if err != nil {
+			return err
+		}
+
+		clusters = buildClusters(models.MySQLServiceType, services, nodes, links)
+		changed, err = fillReplicationSets(tx.Querier, services, clusters)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		// replication_set is a label of scraped metrics
+		s.vmdb.RequestConfigurationUpdate()
+	}
+
+	s.rw.Lock()
+	s.clusters = clusters
+	s.detectedAt = time.Now()
+	s.rw.Unlock()
+	return nil
+}
+
+// fillReplicationSets sets replication set of Services in clusters if it is empty.
+func fillReplicationSets(q *reform.Querier, services []*models.Service, clusters []*Cluster) (bool, error) {
+	byID := make(map[string]*models.Service, len(services))
+	for _, service := range services {
+		byID[service.ServiceID] = service
+	}
+
+	var changed bool
+	for _, c := range clusters {
+		for _, m := range c.Members {
+			service := byID[m.ServiceID]
+			if service == nil || service.ReplicationSet != "" {
+				continue
+			}
+
+			service.ReplicationSet = c.ReplicationSet
+			if err := q.Update(service); err != nil {
+				return false, errors.WithStack(err)
+			}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// buildClusters groups Services connected by replication links into clusters.
+func buildClusters(serviceType models.ServiceType, services []*models.Service, nodes []*models.Node, links []*replicationLink) []*Cluster {
+	byID := make(map[string]*models.Service, len(services))
+	for _, service := range services {
+		byID[service.ServiceID] = service
+	}
+
+	// union-find of Service IDs
+	parents := make(map[string]string)
+	var find func(id string) string
+	find = func(id string) string {
+		p, ok := parents[id]
+		if !ok || p == id {
+			parents[id] = id
+			return id
+		}
+		root := find(p)
+		parents[id] = root
+		return root
+	}
+
+	members := make(map[string]*Member)
+	member := func(service *models.Service) *Member {
+		m := members[service.ServiceID]
+		if m == nil {
+			m = &Member{
+				ServiceID:   service.ServiceID,
+				ServiceName: service.ServiceName,
+				Role:        PrimaryRole,
+			}
+			members[service.ServiceID] = m
+			find(service.ServiceID)
+		}
+		return m
+	}
+
+	for _, link := range links {
+		replica := byID[link.replicaServiceID]
+		if replica == nil {
+			continue
+		}
+
+		m := member(replica)
+		m.Role = ReplicaRole
+		m.PrimaryHost = link.primaryHost
+		m.LagSeconds = link.lag
+		m.Running = link.running
+
+		primary := findPrimary(link.primaryHost, services, nodes)
+		if primary == nil || primary.ServiceID == replica.ServiceID {
+			continue
+		}
+		m.PrimaryServiceID = primary.ServiceID
+		member(primary)
+		parents[find(replica.ServiceID)] = find(primary.ServiceID)
+	}
+
+	groups := make(map[string][]*Member)
+	for id, m := range members {
+		root := find(id)
+		groups[root] = append(groups[root], m)
+	}
+
+	res := make([]*Cluster, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].Role != group[j].Role {
+				return group[i].Role == PrimaryRole
+			}
+			return group[i].ServiceName < group[j].ServiceName
+		})
+
+		// use existing replication set of any member, preferring primary's; otherwise, use primary's name
+		var name string
+		for _, m := range group {
+			if rs := byID[m.ServiceID].ReplicationSet; rs != "" {
+				name = rs
+				break
+			}
+		}
+		if name == "" {
+			name = group[0].ServiceName
+		}
+
+		res = append(res, &Cluster{
+			ReplicationSet: name,
+			ServiceType:    serviceType,
+			Members:        group,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ReplicationSet < res[j].ReplicationSet })
+	return res
+}
+
+// findPrimary returns Service listening on given host reported by replica, or nil if it can't be found unambiguously.
+func findPrimary(host string, services []*models.Service, nodes []*models.Node) *models.Service {
+	if host == "" {
+		return nil
+	}
+
+	nodeHosts := make(map[string]map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		nodeHosts[node.NodeID] = map[string]struct{}{
+			node.Address:  {},
+			node.NodeName: {},
+		}
+	}
+
+	var candidates []*models.Service
+	for _, service := range services {
+		_, onNode := nodeHosts[service.NodeID][host]
+		if onNode || (service.Address != nil && *service.Address == host) || service.ServiceName == host {
+			candidates = append(candidates, service)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		return candidates[0]
+	}
+
+	// several Services on the same host: prefer one on the default port
+	var res *models.Service
+	for _, service := range candidates {
+		if service.Port != nil && *service.Port == 3306 {
+			if res != nil {
+				return nil
+			}
+			res = service
+		}
+	}
+	return res
+}
+
+func servicePointer(t models.ServiceType) *models.ServiceType {
+	return &t
+}
@@ -0,0 +1,145 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package topology
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// fakeAPI implements Query method of v1.API.
+type fakeAPI struct {
+	v1.API
+}
+
+// Query returns replication status of two replicas: one healthy, one with stopped SQL thread.
+func (f *fakeAPI) Query(ctx context.Context, query string, ts time.Time) (model.Value, v1.Warnings, error) {
+	sample := func(serviceID string, v float64) *model.Sample {
+		return &model.Sample{
+			Metric: model.Metric{"service_id": model.LabelValue(serviceID), "master_host": "db1"},
+			Value:  model.SampleValue(v),
+		}
+	}
+
+	switch {
+	case strings.Contains(query, "seconds_behind_master"):
+		return model.Vector{sample("/service_id/replica1", 3), sample("/service_id/replica2", -1)}, nil, nil
+	case strings.Contains(query, "slave_sql_running"):
+		return model.Vector{sample("/service_id/replica1", 1), sample("/service_id/replica2", 0)}, nil, nil
+	default:
+		return model.Vector{sample("/service_id/replica1", 1), sample("/service_id/replica2", 1)}, nil, nil
+	}
+}
+
+func TestTopology(t *testing.T) {
+	nodes := []*models.Node{
+		{NodeID: "/node_id/1", NodeName: "db1", Address: "10.0.0.1"},
+		{NodeID: "/node_id/2", NodeName: "db2", Address: "10.0.0.2"},
+		{NodeID: "/node_id/3", NodeName: "db3", Address: "10.0.0.3"},
+		{NodeID: "/node_id/4", NodeName: "db4", Address: "10.0.0.4"},
+	}
+	services := []*models.Service{
+		{ServiceID: "/service_id/primary", ServiceName: "mysql-db1", NodeID: "/node_id/1", Port: pointer.ToUint16(3306)},
+		{ServiceID: "/service_id/replica1", ServiceName: "mysql-db2", NodeID: "/node_id/2", Port: pointer.ToUint16(3306)},
+		{ServiceID: "/service_id/replica2", ServiceName: "mysql-db3", NodeID: "/node_id/3", Port: pointer.ToUint16(3306), ReplicationSet: "rs1"},
+		{ServiceID: "/service_id/standalone", ServiceName: "mysql-db4", NodeID: "/node_id/4", Port: pointer.ToUint16(3306)},
+	}
+
+	t.Run("MySQLLinks", func(t *testing.T) {
+		s := &Service{
+			api: &fakeAPI{},
+			l:   logrus.WithField("test", t.Name()),
+		}
+
+		links, err := s.mysqlLinks(context.Background())
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+
+		byID := make(map[string]*replicationLink)
+		for _, l := range links {
+			byID[l.replicaServiceID] = l
+		}
+		assert.Equal(t, &replicationLink{
+			replicaServiceID: "/service_id/replica1",
+			primaryHost:      "db1",
+			lag:              pointer.ToFloat64(3),
+			running:          true,
+		}, byID["/service_id/replica1"])
+		assert.Equal(t, &replicationLink{
+			replicaServiceID: "/service_id/replica2",
+			primaryHost:      "db1",
+		}, byID["/service_id/replica2"])
+	})
+
+	t.Run("BuildClusters", func(t *testing.T) {
+		links := []*replicationLink{
+			{replicaServiceID: "/service_id/replica1", primaryHost: "10.0.0.1", lag: pointer.ToFloat64(3), running: true},
+			{replicaServiceID: "/service_id/replica2", primaryHost: "db1"},
+			{replicaServiceID: "/service_id/unknown", primaryHost: "db1"},
+		}
+
+		clusters := buildClusters(models.MySQLServiceType, services, nodes, links)
+		require.Len(t, clusters, 1)
+		assert.Equal(t, &Cluster{
+			ReplicationSet: "rs1",
+			ServiceType:    models.MySQLServiceType,
+			Members: []*Member{{
+				ServiceID:   "/service_id/primary",
+				ServiceName: "mysql-db1",
+				Role:        PrimaryRole,
+			}, {
+				ServiceID:        "/service_id/replica1",
+				ServiceName:      "mysql-db2",
+				Role:             ReplicaRole,
+				PrimaryServiceID: "/service_id/primary",
+				PrimaryHost:      "10.0.0.1",
+				LagSeconds:       pointer.ToFloat64(3),
+				Running:          true,
+			}, {
+				ServiceID:        "/service_id/replica2",
+				ServiceName:      "mysql-db3",
+				Role:             ReplicaRole,
+				PrimaryServiceID: "/service_id/primary",
+				PrimaryHost:      "db1",
+			}},
+		}, clusters[0])
+	})
+
+	t.Run("UnknownPrimary", func(t *testing.T) {
+		links := []*replicationLink{
+			{replicaServiceID: "/service_id/replica1", primaryHost: "external.example.com", running: true},
+		}
+
+		clusters := buildClusters(models.MySQLServiceType, services, nodes, links)
+		require.Len(t, clusters, 1)
+		assert.Equal(t, "mysql-db2", clusters[0].ReplicationSet)
+		require.Len(t, clusters[0].Members, 1)
+		assert.Equal(t, ReplicaRole, clusters[0].Members[0].Role)
+		assert.Empty(t, clusters[0].Members[0].PrimaryServiceID)
+	})
+}
@@ -0,0 +1,311 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package usage provides accounting of monitored Nodes and Services for licensing and chargeback.
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	prometheusNamespace = "pmm_managed"
+	prometheusSubsystem = "usage"
+
+	// snapshotInterval is an interval of current day's snapshot updates.
+	snapshotInterval = time.Hour
+
+	webhookTimeout = 10 * time.Second
+)
+
+// Resource represents accounted resource.
+type Resource string
+
+// Accounted resources.
+const (
+	NodesResource    Resource = "nodes"
+	ServicesResource Resource = "services"
+)
+
+// WebhookEvent is sent to the configured webhook when number of monitored Nodes or Services crosses the threshold.
+type WebhookEvent struct {
+	Resource  Resource  `json:"resource"`
+	Threshold int       `json:"threshold"`
+	Previous  int       `json:"previous"`
+	Current   int       `json:"current"`
+	Exceeded  bool      `json:"exceeded"` // true if number reached the threshold, false if it went below
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Service persists daily numbers of monitored Nodes and Services, exposes them as metrics,
+// and notifies webhook when they cross configured thresholds.
+type Service struct {
+	db     *reform.DB
+	l      *logrus.Entry
+	client *http.Client
+
+	rw   sync.RWMutex
+	last *models.UsageSnapshot
+
+	mNodes    prom.Gauge
+	mServices *prom.GaugeVec
+}
+
+// New creates new usage accounting service.
+func New(db *reform.DB) *Service {
+	return &Service{
+		db: db,
+		l:  logrus.WithField("component", "usage"),
+		client: &http.Client{
+			Timeout: webhookTimeout,
+		},
+
+		mNodes: prom.NewGauge(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "nodes",
+			Help:      "A number of monitored Nodes, excluding PMM Server.",
+		}),
+		mServices: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "services",
+			Help:      "A number of monitored Services, excluding PMM Server's own.",
+		}, []string{"service_type"}),
+	}
+}
+
+// Run updates current day's snapshot periodically until ctx is canceled.
+func (s *Service) Run(ctx context.Context) {
+	last, err := models.FindLatestUsageSnapshot(s.db.Querier)
+	if err != nil {
+		s.l.Error(err)
+	}
+	s.rw.Lock()
+	s.last = last
+	s.rw.Unlock()
+
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.update(ctx); err != nil {
+			s.l.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update counts monitored Nodes and Services, stores current day's snapshot, updates metrics,
+// and sends webhook events for crossed thresholds.
+func (s *Service) update(ctx context.Context) error {
+	var current *models.UsageSnapshot
+	var settings *models.Settings
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		if settings, err = models.GetSettings(tx.Querier); err != nil {
+			return err
+		}
+		if current, err = models.CountUsage(tx.Querier, time.Now()); err != nil {
+			return err
+		}
+		return models.SaveUsageSnapshot(tx.Querier, current)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mNodes.Set(float64(current.Nodes))
+	s.mServices.Reset()
+	for serviceType, n := range current.ServiceTypes {
+		s.mServices.WithLabelValues(string(serviceType)).Set(float64(n))
+	}
+
+	s.rw.Lock()
+	previous := s.last
+	s.last = current
+	s.rw.Unlock()
+
+	if settings.Usage.WebhookURL == "" {
+		return nil
+	}
+	for _, e := range crossedThresholds(previous, current, &settings.Usage) {
+		s.l.Infof("Number of monitored %s changed from %d to %d, crossing threshold %d.", e.Resource, e.Previous, e.Current, e.Threshold)
+		if err = s.sendWebhook(ctx, settings.Usage.WebhookURL, e); err != nil {
+			s.l.Warnf("Failed to send usage webhook: %s.", err)
+		}
+	}
+	return nil
+}
+
+// crossedThresholds returns events for thresholds crossed between previous and current snapshots.
+// Nothing is returned for the first snapshot.
+func crossedThresholds(previous, current *models.UsageSnapshot, settings *models.UsageSettings) []*WebhookEvent {
+	if previous == nil {
+		return nil
+	}
+
+	var res []*WebhookEvent
+	for _, c := range []struct {
+		resource          Resource
+		threshold         int
+		previous, current int
+	}{
+		{NodesResource, settings.NodesThreshold, previous.Nodes, current.Nodes},
+		{ServicesResource, settings.ServicesThreshold, previous.Services, current.Services},
+	} {
+		if c.threshold == 0 {
+			continue
+		}
+		wasExceeded, exceeded := c.previous >= c.threshold, c.current >= c.threshold
+		if wasExceeded == exceeded {
+			continue
+		}
+		res = append(res, &WebhookEvent{
+			Resource:  c.resource,
+			Threshold: c.threshold,
+			Previous:  c.previous,
+			Current:   c.current,
+			Exceeded:  exceeded,
+			Timestamp: current.UpdatedAt,
+		})
+	}
+	return res
+}
+
+// sendWebhook sends event to the webhook.
+func (s *Service) sendWebhook(ctx context.Context, url string, e *WebhookEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// GetUsageReport returns daily usage snapshots for the given period, oldest first.
+func (s *Service) GetUsageReport(ctx context.Context, from, to time.Time) ([]*models.UsageSnapshot, error) {
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, status.Error(codes.InvalidArgument, "Report end should be after its start.")
+	}
+
+	var res []*models.UsageSnapshot
+	err := s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var err error
+		res, err = models.FindUsageSnapshots(tx.Querier, models.UsageSnapshotsFilter{From: from, To: to})
+		return err
+	})
+	return res, err
+}
+
+// WriteCSV writes usage snapshots as CSV with a header row.
+// Each Service type present in any snapshot gets its own column.
+func WriteCSV(w io.Writer, snapshots []*models.UsageSnapshot) error {
+	typesSet := make(map[models.ServiceType]struct{})
+	for _, s := range snapshots {
+		for t := range s.ServiceTypes {
+			typesSet[t] = struct{}{}
+		}
+	}
+	types := make([]string, 0, len(typesSet))
+	for t := range typesSet {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"date", "nodes", "services"}, types...)); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, s := range snapshots {
+		record := []string{s.Date.Format("2006-01-02"), strconv.Itoa(s.Nodes), strconv.Itoa(s.Services)}
+		for _, t := range types {
+			record = append(record, strconv.Itoa(s.ServiceTypes[models.ServiceType(t)]))
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	cw.Flush()
+	return errors.WithStack(cw.Error())
+}
+
+// ChangeSettings replaces usage accounting settings.
+func (s *Service) ChangeSettings(ctx context.Context, settings *models.UsageSettings) error {
+	return s.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		if _, err := models.UpdateSettings(tx, &models.ChangeSettingsParams{UsageSettings: settings}); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	})
+}
+
+// Describe implements prometheus.Collector.
+func (s *Service) Describe(ch chan<- *prom.Desc) {
+	s.mNodes.Describe(ch)
+	s.mServices.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (s *Service) Collect(ch chan<- prom.Metric) {
+	s.mNodes.Collect(ch)
+	s.mServices.Collect(ch)
+}
+
+// Check interfaces.
+var (
+	_ prom.Collector = (*Service)(nil)
+)
@@ -0,0 +1,106 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package usage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestCrossedThresholds(t *testing.T) {
+	now := time.Date(2021, 8, 20, 12, 0, 0, 0, time.UTC)
+	settings := &models.UsageSettings{NodesThreshold: 10, ServicesThreshold: 20}
+	snapshot := func(nodes, services int) *models.UsageSnapshot {
+		return &models.UsageSnapshot{Nodes: nodes, Services: services, UpdatedAt: now}
+	}
+
+	t.Run("First", func(t *testing.T) {
+		assert.Empty(t, crossedThresholds(nil, snapshot(100, 100), settings))
+	})
+
+	t.Run("NotCrossed", func(t *testing.T) {
+		assert.Empty(t, crossedThresholds(snapshot(1, 2), snapshot(9, 19), settings))
+		assert.Empty(t, crossedThresholds(snapshot(10, 20), snapshot(15, 25), settings))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert.Empty(t, crossedThresholds(snapshot(1, 2), snapshot(100, 100), &models.UsageSettings{}))
+	})
+
+	t.Run("Exceeded", func(t *testing.T) {
+		expected := []*WebhookEvent{{
+			Resource:  NodesResource,
+			Threshold: 10,
+			Previous:  9,
+			Current:   10,
+			Exceeded:  true,
+			Timestamp: now,
+		}}
+		assert.Equal(t, expected, crossedThresholds(snapshot(9, 5), snapshot(10, 5), settings))
+	})
+
+	t.Run("Dropped", func(t *testing.T) {
+		expected := []*WebhookEvent{{
+			Resource:  NodesResource,
+			Threshold: 10,
+			Previous:  12,
+			Current:   3,
+			Exceeded:  false,
+			Timestamp: now,
+		}, {
+			Resource:  ServicesResource,
+			Threshold: 20,
+			Previous:  30,
+			Current:   19,
+			Exceeded:  false,
+			Timestamp: now,
+		}}
+		assert.Equal(t, expected, crossedThresholds(snapshot(12, 30), snapshot(3, 19), settings))
+	})
+}
+
+func TestWriteCSV(t *testing.T) {
+	snapshots := []*models.UsageSnapshot{{
+		Date:     time.Date(2021, 8, 19, 0, 0, 0, 0, time.UTC),
+		Nodes:    2,
+		Services: 3,
+		ServiceTypes: models.UsageServiceTypes{
+			models.MySQLServiceType: 3,
+		},
+	}, {
+		Date:     time.Date(2021, 8, 20, 0, 0, 0, 0, time.UTC),
+		Nodes:    3,
+		Services: 5,
+		ServiceTypes: models.UsageServiceTypes{
+			models.MySQLServiceType:   4,
+			models.MongoDBServiceType: 1,
+		},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, snapshots))
+	expected := "date,nodes,services,mongodb,mysql\n" +
+		"2021-08-19,2,3,0,3\n" +
+		"2021-08-20,3,5,1,4\n"
+	assert.Equal(t, expected, buf.String())
+}
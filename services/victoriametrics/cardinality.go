@@ -0,0 +1,344 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AlekSi/pointer"
+	config "github.com/percona/promconfig"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	// bytesPerSeries is an approximate VictoriaMetrics memory usage per active time series.
+	bytesPerSeries = 1024
+
+	// defaultTableCount is used for per-table collectors when table count is unknown.
+	defaultTableCount = 100
+
+	// defaultTablestatsGroupTableLimit is used for planned mysqld_exporters; keep in sync with management.AddMySQL.
+	defaultTablestatsGroupTableLimit = 1000
+
+	// unknownCollectorSeries is used for collectors without known time series count.
+	unknownCollectorSeries = 50
+)
+
+// collectorSeries contains known time series count per collector of the exporter (for typical instance).
+// Custom queries are user-defined, so they are not counted.
+var collectorSeries = map[models.AgentType]map[string]int{
+	models.NodeExporterType: {
+		"bonding":          3,
+		"buddyinfo":        33,
+		"cpu":              40,
+		"diskstats":        60,
+		"entropy":          2,
+		"filefd":           2,
+		"filesystem":       35,
+		"hwmon":            20,
+		"loadavg":          3,
+		"meminfo":          50,
+		"meminfo_numa":     60,
+		"netdev":           50,
+		"netstat":          80,
+		"processes":        10,
+		"standard.go":      35,
+		"standard.process": 10,
+		"stat":             8,
+		"textfile.hr":      1,
+		"textfile.mr":      1,
+		"textfile.lr":      1,
+		"time":             2,
+		"uname":            1,
+		"vmstat":           160,
+	},
+	models.MySQLdExporterType: {
+		"binlog_size":                     3,
+		"custom_query.hr":                 0,
+		"custom_query.mr":                 0,
+		"custom_query.lr":                 0,
+		"engine_innodb_status":            60,
+		"engine_tokudb_status":            0,
+		"global_status":                   450,
+		"global_variables":                600,
+		"heartbeat":                       2,
+		"info_schema.clientstats":         0,
+		"info_schema.innodb_cmp":          25,
+		"info_schema.innodb_cmpmem":       30,
+		"info_schema.innodb_metrics":      300,
+		"info_schema.processlist":         60,
+		"info_schema.query_response_time": 40,
+		"info_schema.userstats":           0,
+		"perf_schema.eventsstatements":    2000,
+		"perf_schema.eventswaits":         800,
+		"perf_schema.file_events":         30,
+		"perf_schema.file_instances":      300,
+		"slave_status":                    60,
+		"standard.go":                     35,
+		"standard.process":                10,
+	},
+	models.PostgresExporterType: {
+		"custom_query.hr":  0,
+		"custom_query.mr":  0,
+		"custom_query.lr":  0,
+		"exporter":         400,
+		"standard.go":      35,
+		"standard.process": 10,
+	},
+}
+
+// perTableCollectorSeries contains time series count per table for mysqld_exporter collectors.
+var perTableCollectorSeries = map[string]int{
+	"auto_increment.columns":         2,
+	"info_schema.innodb_tablespaces": 5,
+	"info_schema.tables":             6,
+	"info_schema.tablestats":         3,
+	"perf_schema.indexiowaits":       8,
+	"perf_schema.tableiowaits":       8,
+	"perf_schema.tablelocks":         20,
+}
+
+// exporterSeries contains known time series count for exporters without collectors.
+var exporterSeries = map[models.AgentType]int{
+	models.MongoDBExporterType:       800,
+	models.ProxySQLExporterType:      150,
+	models.RDSExporterType:           200,
+	models.AzureDatabaseExporterType: 100,
+	models.ExternalExporterType:      100,
+	models.VMAgentType:               300,
+}
+
+// AgentCardinality represents estimated active time series count for a single exporter.
+type AgentCardinality struct {
+	AgentID    string
+	AgentType  models.AgentType
+	ServiceID  string
+	NodeID     string
+	Series     int
+	Collectors map[string]int
+}
+
+// PlannedCardinality represents estimated active time series count for exporters that are not added yet.
+type PlannedCardinality struct {
+	AgentType models.AgentType
+	Count     int
+	Series    int // for all Count exporters
+}
+
+// CardinalityEstimate represents estimated active time series count.
+type CardinalityEstimate struct {
+	Agents      []*AgentCardinality
+	Planned     []*PlannedCardinality
+	TotalSeries int
+	MemoryBytes int64
+}
+
+// EstimateCardinality estimates active time series count for current inventory and given planned exporters
+// using known time series count per exporter type and enabled collectors.
+// Planned exporters use default collectors.
+func (svc *Service) EstimateCardinality(ctx context.Context, planned map[models.AgentType]int) (*CardinalityEstimate, error) {
+	for agentType, count := range planned {
+		if !isCardinalityEstimated(agentType) {
+			return nil, status.Errorf(codes.InvalidArgument, "Unsupported agent type %q.", agentType)
+		}
+		if count < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid count %d for agent type %q.", count, agentType)
+		}
+	}
+
+	res := new(CardinalityEstimate)
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		settings, err := models.GetSettings(tx.Querier)
+		if err != nil {
+			return err
+		}
+
+		res.Agents, err = estimateAgentsCardinality(tx.Querier, &settings.MetricsResolutions)
+		if err != nil {
+			return err
+		}
+
+		res.Planned, err = estimatePlannedCardinality(&settings.MetricsResolutions, planned)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range res.Agents {
+		res.TotalSeries += a.Series
+	}
+	for _, p := range res.Planned {
+		res.TotalSeries += p.Series
+	}
+	res.MemoryBytes = int64(res.TotalSeries) * bytesPerSeries
+	return res, nil
+}
+
+// isCardinalityEstimated returns true if time series count can be estimated for the given agent type.
+func isCardinalityEstimated(agentType models.AgentType) bool {
+	_, ok := collectorSeries[agentType]
+	if !ok {
+		_, ok = exporterSeries[agentType]
+	}
+	return ok
+}
+
+// estimateAgentsCardinality returns estimated time series count for all exporters scraped by PMM Server.
+func estimateAgentsCardinality(q *reform.Querier, s *models.MetricsResolutions) ([]*AgentCardinality, error) {
+	agents, err := models.FindAgentsForScrapeConfig(q, nil, false)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	refs, err := loadScrapeConfigsRefs(q, agents)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*AgentCardinality, 0, len(agents))
+	for _, agent := range agents {
+		if !isCardinalityEstimated(agent.AgentType) {
+			continue
+		}
+
+		// resolutions and host do not affect enabled collectors
+		params := &scrapeConfigParams{
+			host:  "127.0.0.1",
+			agent: agent,
+		}
+		if agent.ServiceID != nil {
+			if params.service, err = refs.service(*agent.ServiceID); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case agent.NodeID != nil:
+			params.node, err = refs.node(*agent.NodeID)
+		case params.service != nil:
+			params.node, err = refs.node(params.service.NodeID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		a, err := estimateAgentCardinality(s, params)
+		if err != nil {
+			return nil, err
+		}
+		a.ServiceID = pointer.GetString(agent.ServiceID)
+		if params.node != nil {
+			a.NodeID = params.node.NodeID
+		}
+		res = append(res, a)
+	}
+
+	return res, nil
+}
+
+// estimatePlannedCardinality returns estimated time series count for planned exporters with default collectors.
+func estimatePlannedCardinality(s *models.MetricsResolutions, planned map[models.AgentType]int) ([]*PlannedCardinality, error) {
+	res := make([]*PlannedCardinality, 0, len(planned))
+	for agentType, count := range planned {
+		if count == 0 {
+			continue
+		}
+
+		params := &scrapeConfigParams{
+			host: "127.0.0.1",
+			node: &models.Node{NodeID: "planned", NodeType: models.GenericNodeType},
+			agent: &models.Agent{
+				AgentID:                        "planned",
+				AgentType:                      agentType,
+				ListenPort:                     pointer.ToUint16(42000),
+				TableCountTablestatsGroupLimit: defaultTablestatsGroupTableLimit,
+			},
+		}
+		a, err := estimateAgentCardinality(s, params)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &PlannedCardinality{
+			AgentType: agentType,
+			Count:     count,
+			Series:    a.Series * count,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].AgentType < res[j].AgentType })
+	return res, nil
+}
+
+// estimateAgentCardinality returns estimated time series count for a single exporter.
+func estimateAgentCardinality(s *models.MetricsResolutions, params *scrapeConfigParams) (*AgentCardinality, error) {
+	agent := params.agent
+	res := &AgentCardinality{
+		AgentID:   agent.AgentID,
+		AgentType: agent.AgentType,
+	}
+
+	series, ok := exporterSeries[agent.AgentType]
+	if ok {
+		res.Series = series
+		return res, nil
+	}
+
+	// use the same collectors as actual scrape configs
+	var scfgs []*config.ScrapeConfig
+	var err error
+	switch agent.AgentType {
+	case models.NodeExporterType:
+		scfgs, err = scrapeConfigsForNodeExporter(s, params)
+	case models.MySQLdExporterType:
+		scfgs, err = scrapeConfigsForMySQLdExporter(s, params)
+	case models.PostgresExporterType:
+		scfgs, err = scrapeConfigsForPostgresExporter(s, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tableCount := defaultTableCount
+	if agent.TableCount != nil {
+		tableCount = int(*agent.TableCount)
+	}
+
+	known := collectorSeries[agent.AgentType]
+	res.Collectors = make(map[string]int)
+	for _, scfg := range scfgs {
+		for _, c := range scfg.Params["collect[]"] {
+			n, ok := known[c]
+			if !ok {
+				n = unknownCollectorSeries
+				if perTable, ok := perTableCollectorSeries[c]; ok {
+					n = perTable * tableCount
+				}
+			}
+			res.Collectors[c] = n
+			res.Series += n
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,114 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestEstimateCardinality(t *testing.T) {
+	s := &models.MetricsResolutions{
+		HR: 5 * time.Second,
+		MR: 10 * time.Second,
+		LR: 60 * time.Second,
+	}
+
+	t.Run("NodeExporter", func(t *testing.T) {
+		params := &scrapeConfigParams{
+			host: "1.2.3.4",
+			node: &models.Node{NodeID: "/node_id/1", NodeType: models.GenericNodeType, Distro: "darwin"},
+			agent: &models.Agent{
+				AgentID:            "/agent_id/1",
+				AgentType:          models.NodeExporterType,
+				ListenPort:         pointer.ToUint16(12345),
+				DisabledCollectors: []string{"cpu"},
+			},
+		}
+
+		actual, err := estimateAgentCardinality(s, params)
+		require.NoError(t, err)
+		expected := map[string]int{
+			"diskstats":  60,
+			"filesystem": 35,
+			"loadavg":    3,
+			"meminfo":    50,
+			"netdev":     50,
+			"time":       2,
+		}
+		assert.Equal(t, expected, actual.Collectors)
+		assert.Equal(t, 200, actual.Series)
+	})
+
+	t.Run("MySQLdExporterTables", func(t *testing.T) {
+		params := &scrapeConfigParams{
+			host:    "1.2.3.4",
+			node:    &models.Node{NodeID: "/node_id/1", NodeType: models.GenericNodeType},
+			service: &models.Service{ServiceID: "/service_id/1", NodeID: "/node_id/1"},
+			agent: &models.Agent{
+				AgentID:    "/agent_id/1",
+				AgentType:  models.MySQLdExporterType,
+				ListenPort: pointer.ToUint16(12345),
+				TableCount: pointer.ToInt32(10),
+			},
+		}
+
+		withTables, err := estimateAgentCardinality(s, params)
+		require.NoError(t, err)
+		assert.Equal(t, 200, withTables.Collectors["perf_schema.tablelocks"])
+		assert.Equal(t, 60, withTables.Collectors["info_schema.tables"])
+
+		params.agent.TableCountTablestatsGroupLimit = -1
+		withoutTables, err := estimateAgentCardinality(s, params)
+		require.NoError(t, err)
+		assert.NotContains(t, withoutTables.Collectors, "perf_schema.tablelocks")
+		assert.Equal(t, 10*(2+5+6+3+8+8+20), withTables.Series-withoutTables.Series)
+	})
+
+	t.Run("Planned", func(t *testing.T) {
+		planned := map[models.AgentType]int{
+			models.MySQLdExporterType:  2,
+			models.MongoDBExporterType: 3,
+			models.NodeExporterType:    0,
+		}
+		actual, err := estimatePlannedCardinality(s, planned)
+		require.NoError(t, err)
+		require.Len(t, actual, 2)
+
+		assert.Equal(t, models.MongoDBExporterType, actual[0].AgentType)
+		assert.Equal(t, 3, actual[0].Count)
+		assert.Equal(t, 3*800, actual[0].Series)
+
+		assert.Equal(t, models.MySQLdExporterType, actual[1].AgentType)
+		assert.Equal(t, 2, actual[1].Count)
+		assert.Equal(t, 0, actual[1].Series%2)
+		// 100 tables are assumed when table count is unknown
+		assert.Greater(t, actual[1].Series, 2*100*(2+5+6+3+8+8+20))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		assert.False(t, isCardinalityEstimated(models.PMMAgentType))
+		assert.False(t, isCardinalityEstimated(models.QANMySQLSlowlogAgentType))
+		assert.True(t, isCardinalityEstimated(models.ProxySQLExporterType))
+	})
+}
@@ -0,0 +1,177 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/percona/pmm/utils/pdeathsig"
+	config "github.com/percona/promconfig"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// ConfigValidator defines how scrape configuration is validated before it is saved.
+type ConfigValidator string
+
+// Available validators.
+const (
+	// AutoConfigValidator selects validator matching the scraper that runs:
+	// vmagent for external VictoriaMetrics, victoriametrics otherwise.
+	AutoConfigValidator ConfigValidator = "auto"
+	// VictoriaMetricsConfigValidator runs `victoriametrics -dryRun`.
+	VictoriaMetricsConfigValidator ConfigValidator = "victoriametrics"
+	// VMAgentConfigValidator runs `vmagent -dryRun`.
+	VMAgentConfigValidator ConfigValidator = "vmagent"
+	// EmbeddedConfigValidator parses configuration in-process without external binaries.
+	EmbeddedConfigValidator ConfigValidator = "embedded"
+)
+
+// vmagentPath is a path of vmagent binary used for external VictoriaMetrics.
+const vmagentPath = "/usr/local/percona/pmm2/exporters/vmagent"
+
+// ConfigValidators returns all available validators.
+func ConfigValidators() []string {
+	return []string{
+		string(AutoConfigValidator),
+		string(VictoriaMetricsConfigValidator),
+		string(VMAgentConfigValidator),
+		string(EmbeddedConfigValidator),
+	}
+}
+
+// parseConfigValidator returns validator with given name; empty name means AutoConfigValidator.
+func parseConfigValidator(name string) (ConfigValidator, error) {
+	if name == "" {
+		return AutoConfigValidator, nil
+	}
+	for _, v := range ConfigValidators() {
+		if v == name {
+			return ConfigValidator(name), nil
+		}
+	}
+	return "", errors.Errorf("unknown configuration validator %q", name)
+}
+
+// configValidator returns validator to use, resolving AutoConfigValidator.
+func (svc *Service) configValidator() ConfigValidator {
+	if svc.validator != AutoConfigValidator {
+		return svc.validator
+	}
+
+	settings, err := models.GetSettings(svc.db.Querier)
+	if err != nil {
+		svc.l.Warnf("Failed to get settings, using %s validator: %s.", VictoriaMetricsConfigValidator, err)
+		return VictoriaMetricsConfigValidator
+	}
+	if settings.VictoriaMetrics.External != nil {
+		return VMAgentConfigValidator
+	}
+	return VictoriaMetricsConfigValidator
+}
+
+// validateConfigWithBinary validates given configuration with `<binary> -dryRun`.
+func (svc *Service) validateConfigWithBinary(ctx context.Context, binary string, cfg []byte) error {
+	f, err := ioutil.TempFile("", "pmm-managed-config-victoriametrics-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = f.Write(cfg); err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	args := []string{"-dryRun", "-promscrape.config", f.Name()}
+	cmd := exec.CommandContext(ctx, binary, args...) //nolint:gosec
+	pdeathsig.Set(cmd, unix.SIGKILL)
+
+	b, err := cmd.CombinedOutput()
+	if err != nil {
+		svc.l.Errorf("%s", b)
+		s := string(b)
+		if m := checkFailedRE.FindStringSubmatch(s); len(m) == 2 {
+			return status.Error(codes.Aborted, m[1])
+		}
+
+		return errors.Wrap(err, s)
+	}
+	svc.l.Debugf("%s", b)
+
+	args = append(args, "-promscrape.config.strictParse", "true")
+	cmd = exec.CommandContext(ctx, binary, args...) //nolint:gosec
+	pdeathsig.Set(cmd, unix.SIGKILL)
+
+	b, err = cmd.CombinedOutput()
+	if err != nil {
+		s := string(b)
+		if m := checkFailedRE.FindStringSubmatch(s); len(m) == 2 {
+			svc.l.Warnf("VictoriaMetrics scrape configuration contains unsupported params: %s", m[1])
+		} else {
+			svc.l.Warnf("VictoriaMetrics scrape configuration contains unsupported params: %s", b)
+		}
+	}
+	svc.l.Debugf("%s", b)
+
+	return nil
+}
+
+// validateConfigEmbedded parses given configuration in-process.
+// Unknown (for example, VictoriaMetrics-specific) fields are only logged, as they are with -dryRun.
+func (svc *Service) validateConfigEmbedded(cfg []byte) error {
+	var c config.Config
+	if err := yaml.Unmarshal(cfg, &c); err != nil {
+		return status.Error(codes.Aborted, err.Error())
+	}
+
+	jobs := make(map[string]struct{}, len(c.ScrapeConfigs))
+	for i, sc := range c.ScrapeConfigs {
+		if sc == nil {
+			return status.Errorf(codes.Aborted, "empty scrape config #%d", i)
+		}
+		if sc.JobName == "" {
+			return status.Errorf(codes.Aborted, "missing job_name in scrape config #%d", i)
+		}
+		if _, ok := jobs[sc.JobName]; ok {
+			return status.Errorf(codes.Aborted, "duplicate job_name %q", sc.JobName)
+		}
+		jobs[sc.JobName] = struct{}{}
+
+		if sc.ScrapeInterval != 0 && sc.ScrapeTimeout > sc.ScrapeInterval {
+			return status.Errorf(codes.Aborted, "scrape_timeout is greater than scrape_interval for job_name %q", sc.JobName)
+		}
+	}
+
+	d := yaml.NewDecoder(bytes.NewReader(cfg))
+	d.KnownFields(true)
+	if err := d.Decode(new(config.Config)); err != nil {
+		svc.l.Warnf("VictoriaMetrics scrape configuration contains unsupported params: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/percona/pmm-managed/models"
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestEmbeddedConfigValidator(t *testing.T) {
+	svc, err := NewVictoriaMetrics("", nil, "http://127.0.0.1:9090/prometheus/", &models.VictoriaMetricsParams{
+		ConfigValidator: string(EmbeddedConfigValidator),
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Valid", func(t *testing.T) {
+		// stream_parse is VictoriaMetrics-specific
+		cfg := []byte(`
+scrape_configs:
+- job_name: node
+  scrape_interval: 10s
+  scrape_timeout: 9s
+  stream_parse: true
+  static_configs:
+  - targets: [127.0.0.1:9100]
+`)
+		assert.NoError(t, svc.ValidateConfig(ctx, cfg))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, cfg := range map[string]string{
+			"Syntax":    "scrape_configs: [",
+			"NoJobName": "scrape_configs:\n- scrape_interval: 10s\n",
+			"Duplicate": "scrape_configs:\n- job_name: a\n- job_name: a\n",
+			"Timeout":   "scrape_configs:\n- job_name: a\n  scrape_interval: 5s\n  scrape_timeout: 10s\n",
+		} {
+			err := svc.ValidateConfig(ctx, []byte(cfg))
+			tests.AssertGRPCErrorRE(t, codes.Aborted, ".+", err)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := NewVictoriaMetrics("", nil, "http://127.0.0.1:9090/prometheus/", &models.VictoriaMetricsParams{
+			ConfigValidator: "promtool",
+		})
+		assert.EqualError(t, err, `unknown configuration validator "promtool"`)
+	})
+}
@@ -0,0 +1,108 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+
+	config "github.com/percona/promconfig"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// scrapeConfigsForCustomJobs returns scrape configs for custom jobs registered by administrator.
+// Jobs without interval use global scrape interval and timeout.
+func scrapeConfigsForCustomJobs(custom []*models.CustomScrapeConfig) []*config.ScrapeConfig {
+	res := make([]*config.ScrapeConfig, 0, len(custom))
+	for _, c := range custom {
+		cfg := &config.ScrapeConfig{
+			JobName:     c.JobName,
+			MetricsPath: c.MetricsPath,
+			Scheme:      c.Scheme,
+			ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+				StaticConfigs: []*config.Group{{
+					Targets: c.Targets,
+					Labels:  c.Labels,
+				}},
+			},
+		}
+		if cfg.MetricsPath == "" {
+			cfg.MetricsPath = "/metrics"
+		}
+		if c.Interval != 0 {
+			cfg.ScrapeInterval = config.Duration(c.Interval)
+			cfg.ScrapeTimeout = scrapeTimeout(c.Interval)
+		}
+		if c.Username != "" {
+			cfg.HTTPClientConfig.BasicAuth = &config.BasicAuth{
+				Username: c.Username,
+				Password: c.Password,
+			}
+		}
+		res = append(res, cfg)
+	}
+	return res
+}
+
+// ListCustomScrapeConfigs returns all custom scrape jobs.
+func (svc *Service) ListCustomScrapeConfigs(ctx context.Context) ([]*models.CustomScrapeConfig, error) {
+	var res []*models.CustomScrapeConfig
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var e error
+		res, e = models.FindCustomScrapeConfigs(tx.Querier)
+		return e
+	})
+	return res, err
+}
+
+// AddCustomScrapeConfig registers custom scrape job and reloads configuration.
+// Job is not added if resulting configuration is rejected by VictoriaMetrics.
+func (svc *Service) AddCustomScrapeConfig(ctx context.Context, params *models.CreateCustomScrapeConfigParams) (*models.CustomScrapeConfig, error) {
+	var res *models.CustomScrapeConfig
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var e error
+		if res, e = models.CreateCustomScrapeConfig(tx.Querier, params); e != nil {
+			return e
+		}
+
+		b, _, e := svc.generateConfig(tx.Querier, svc.loadBaseConfig())
+		if e != nil {
+			return e
+		}
+		return svc.validateConfig(ctx, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	svc.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// RemoveCustomScrapeConfig removes custom scrape job and reloads configuration.
+func (svc *Service) RemoveCustomScrapeConfig(ctx context.Context, id string) error {
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		return models.RemoveCustomScrapeConfig(tx.Querier, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	svc.RequestConfigurationUpdate()
+	return nil
+}
@@ -0,0 +1,69 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"testing"
+	"time"
+
+	config "github.com/percona/promconfig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestScrapeConfigsForCustomJobs(t *testing.T) {
+	actual := scrapeConfigsForCustomJobs([]*models.CustomScrapeConfig{{
+		JobName:  "nginx",
+		Targets:  []string{"10.0.0.1:9113"},
+		Labels:   models.ScrapeLabels{"environment": "prod"},
+		Interval: 30 * time.Second,
+		Scheme:   "https",
+		Username: "pmm",
+		Password: "secret",
+	}, {
+		JobName:     "app",
+		Targets:     []string{"app:8080"},
+		MetricsPath: "/prometheus",
+	}})
+
+	expected := []*config.ScrapeConfig{{
+		JobName:        "nginx",
+		ScrapeInterval: config.Duration(30 * time.Second),
+		ScrapeTimeout:  config.Duration(27 * time.Second),
+		MetricsPath:    "/metrics",
+		Scheme:         "https",
+		HTTPClientConfig: config.HTTPClientConfig{
+			BasicAuth: &config.BasicAuth{Username: "pmm", Password: "secret"},
+		},
+		ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+			StaticConfigs: []*config.Group{{
+				Targets: []string{"10.0.0.1:9113"},
+				Labels:  map[string]string{"environment": "prod"},
+			}},
+		},
+	}, {
+		JobName:     "app",
+		MetricsPath: "/prometheus",
+		ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+			StaticConfigs: []*config.Group{{
+				Targets: []string{"app:8080"},
+			}},
+		},
+	}}
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,249 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExportFormat represents metrics data export format.
+type ExportFormat string
+
+// Supported export formats.
+const (
+	// NativeExportFormat is VictoriaMetrics native binary format, the most efficient one for migrations.
+	NativeExportFormat ExportFormat = "native"
+	CSVExportFormat    ExportFormat = "csv"
+)
+
+// defaultCSVFormat is a columns format of exported CSV data, see VictoriaMetrics /api/v1/export/csv documentation.
+const defaultCSVFormat = "__name__,__value__,__timestamp__:unix_ms"
+
+// ExportParams represents metrics data export parameters.
+type ExportParams struct {
+	// Series selectors, at least one is required.
+	Match []string
+	// Time range; zero values mean no limit.
+	Start time.Time
+	End   time.Time
+	// Data format; native by default.
+	Format ExportFormat
+	// Columns format for CSV format, see defaultCSVFormat.
+	CSVFormat string
+}
+
+// Validate validates export parameters and fills defaults.
+func (p *ExportParams) Validate() error {
+	if len(p.Match) == 0 {
+		return errors.New("at least one series selector is required")
+	}
+	if !p.Start.IsZero() && !p.End.IsZero() && !p.Start.Before(p.End) {
+		return errors.New("start should be before end")
+	}
+
+	switch p.Format {
+	case "":
+		p.Format = NativeExportFormat
+	case NativeExportFormat:
+	case CSVExportFormat:
+		if p.CSVFormat == "" {
+			p.CSVFormat = defaultCSVFormat
+		}
+	default:
+		return errors.Errorf("unsupported export format %q", p.Format)
+	}
+
+	return nil
+}
+
+// ImportParams represents metrics data import parameters.
+type ImportParams struct {
+	// Data format; native by default.
+	Format ExportFormat
+	// Columns format for CSV format, required by VictoriaMetrics: for example, "1:metric:cpu,2:time:unix_ms,3:label:instance".
+	CSVFormat string
+}
+
+// Validate validates import parameters and fills defaults.
+func (p *ImportParams) Validate() error {
+	switch p.Format {
+	case "":
+		p.Format = NativeExportFormat
+	case NativeExportFormat:
+	case CSVExportFormat:
+		if p.CSVFormat == "" {
+			return errors.New("CSV format is required for CSV import")
+		}
+	default:
+		return errors.Errorf("unsupported import format %q", p.Format)
+	}
+
+	return nil
+}
+
+// snapshotResponse represents VictoriaMetrics snapshot API response.
+type snapshotResponse struct {
+	Status    string   `json:"status"`
+	Msg       string   `json:"msg"`
+	Snapshot  string   `json:"snapshot"`
+	Snapshots []string `json:"snapshots"`
+}
+
+// CreateSnapshot creates instant snapshot of VictoriaMetrics data and returns its name.
+// Snapshots are stored in the snapshots subdirectory of VictoriaMetrics data directory.
+func (svc *Service) CreateSnapshot(ctx context.Context) (string, error) {
+	resp, err := svc.snapshotRequest(ctx, "create", nil)
+	if err != nil {
+		return "", err
+	}
+	svc.l.Infof("Snapshot %q created.", resp.Snapshot)
+	return resp.Snapshot, nil
+}
+
+// ListSnapshots returns names of existing VictoriaMetrics snapshots.
+func (svc *Service) ListSnapshots(ctx context.Context) ([]string, error) {
+	resp, err := svc.snapshotRequest(ctx, "list", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Snapshots, nil
+}
+
+// DeleteSnapshot removes VictoriaMetrics snapshot with given name.
+func (svc *Service) DeleteSnapshot(ctx context.Context, name string) error {
+	if _, err := svc.snapshotRequest(ctx, "delete", url.Values{"snapshot": []string{name}}); err != nil {
+		return err
+	}
+	svc.l.Infof("Snapshot %q removed.", name)
+	return nil
+}
+
+// snapshotRequest performs VictoriaMetrics snapshot API request with given action.
+func (svc *Service) snapshotRequest(ctx context.Context, action string, query url.Values) (*snapshotResponse, error) {
+	u := *svc.baseURL
+	u.Path = path.Join(u.Path, "snapshot", action)
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := ioutil.ReadAll(resp.Body)
+	svc.l.Debugf("VM snapshot %s: %s", action, b)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, errors.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var res snapshotResponse
+	if err = json.Unmarshal(b, &res); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse snapshot %s response", action)
+	}
+	if res.Status != "ok" {
+		return nil, errors.Errorf("failed to %s snapshot: %s", action, res.Msg)
+	}
+	return &res, nil
+}
+
+// Export writes series matching given parameters to w.
+// Data is streamed from VictoriaMetrics, so it does not have to fit in memory.
+func (svc *Service) Export(ctx context.Context, w io.Writer, params *ExportParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	q := url.Values{"match[]": params.Match}
+	if !params.Start.IsZero() {
+		q.Set("start", strconv.FormatInt(params.Start.Unix(), 10))
+	}
+	if !params.End.IsZero() {
+		q.Set("end", strconv.FormatInt(params.End.Unix(), 10))
+	}
+	if params.Format == CSVExportFormat {
+		q.Set("format", params.CSVFormat)
+	}
+
+	u := *svc.baseURL
+	u.Path = path.Join(u.Path, "api", "v1", "export", string(params.Format))
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != 200 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("expected 200, got %d: %s", resp.StatusCode, b)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return errors.WithStack(err)
+}
+
+// Import writes data previously exported by Export from r to VictoriaMetrics.
+func (svc *Service) Import(ctx context.Context, r io.Reader, params *ImportParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	u := *svc.baseURL
+	u.Path = path.Join(u.Path, "api", "v1", "import", string(params.Format))
+	if params.Format == CSVExportFormat {
+		u.RawQuery = url.Values{"format": []string{params.CSVFormat}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := ioutil.ReadAll(resp.Body)
+	svc.l.Debugf("VM import: %s", b)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if resp.StatusCode != 204 {
+		return errors.Errorf("expected 204, got %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
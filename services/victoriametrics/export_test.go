@@ -0,0 +1,108 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestExportImport(t *testing.T) {
+	var gotPath, gotQuery, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		gotQuery = req.URL.RawQuery
+		b, _ := ioutil.ReadAll(req.Body)
+		gotBody = string(b)
+
+		switch req.URL.Path {
+		case "/prometheus/snapshot/create":
+			_, _ = rw.Write([]byte(`{"status":"ok","snapshot":"20211020100000-16A0C5E2E5E0B0F4"}`))
+		case "/prometheus/snapshot/delete":
+			_, _ = rw.Write([]byte(`{"status":"error","msg":"cannot find snapshot"}`))
+		case "/prometheus/api/v1/export/native", "/prometheus/api/v1/export/csv":
+			_, _ = rw.Write([]byte("data"))
+		case "/prometheus/api/v1/import/native":
+			rw.WriteHeader(204)
+		default:
+			rw.WriteHeader(400)
+		}
+	}))
+	defer srv.Close()
+
+	svc, err := NewVictoriaMetrics("", nil, srv.URL+"/prometheus/", &models.VictoriaMetricsParams{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Snapshots", func(t *testing.T) {
+		name, err := svc.CreateSnapshot(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "20211020100000-16A0C5E2E5E0B0F4", name)
+
+		err = svc.DeleteSnapshot(ctx, "unknown")
+		assert.EqualError(t, err, "failed to delete snapshot: cannot find snapshot")
+		assert.Equal(t, "snapshot=unknown", gotQuery)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := svc.Export(ctx, &buf, &ExportParams{
+			Match: []string{`{__name__="up"}`},
+			Start: time.Unix(1600000000, 0),
+			End:   time.Unix(1600003600, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "data", buf.String())
+		assert.Equal(t, "/prometheus/api/v1/export/native", gotPath)
+		assert.Equal(t, "end=1600003600&match%5B%5D=%7B__name__%3D%22up%22%7D&start=1600000000", gotQuery)
+
+		buf.Reset()
+		err = svc.Export(ctx, &buf, &ExportParams{Match: []string{"up"}, Format: CSVExportFormat})
+		require.NoError(t, err)
+		assert.Equal(t, "/prometheus/api/v1/export/csv", gotPath)
+		assert.Equal(t, "format=__name__%2C__value__%2C__timestamp__%3Aunix_ms&match%5B%5D=up", gotQuery)
+	})
+
+	t.Run("ExportInvalid", func(t *testing.T) {
+		err := svc.Export(ctx, ioutil.Discard, &ExportParams{})
+		assert.EqualError(t, err, "at least one series selector is required")
+
+		err = svc.Export(ctx, ioutil.Discard, &ExportParams{Match: []string{"up"}, Format: "json"})
+		assert.EqualError(t, err, `unsupported export format "json"`)
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		err := svc.Import(ctx, strings.NewReader("data"), &ImportParams{})
+		require.NoError(t, err)
+		assert.Equal(t, "/prometheus/api/v1/import/native", gotPath)
+		assert.Equal(t, "data", gotBody)
+
+		err = svc.Import(ctx, strings.NewReader("data"), &ImportParams{Format: CSVExportFormat})
+		assert.EqualError(t, err, "CSV format is required for CSV import")
+	})
+}
@@ -0,0 +1,125 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	config "github.com/percona/promconfig"
+	"github.com/pkg/errors"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	// exporterTLSDir contains TLS files of exporters scraped by VictoriaMetrics, one directory per Agent.
+	exporterTLSDir = victoriametricsDir + "/tls"
+
+	exporterTLSFilePerm = os.FileMode(0o600)
+	exporterTLSDirPerm  = os.FileMode(0o700)
+)
+
+// exporterTLSFiles returns file names and PEM contents of non-empty exporter TLS options.
+func exporterTLSFiles(options *models.ExporterTLSOptions) map[string]string {
+	res := make(map[string]string, 3)
+	if options.CA != "" {
+		res["ca.crt"] = options.CA
+	}
+	if options.Cert != "" {
+		res["tls.crt"] = options.Cert
+	}
+	if options.Key != "" {
+		res["tls.key"] = options.Key
+	}
+	return res
+}
+
+// exporterTLSAgentDir returns directory with TLS files of given Agent.
+func exporterTLSAgentDir(dir, agentID string) string {
+	return filepath.Join(dir, strings.Map(jobNameMapping, agentID))
+}
+
+// setExporterTLS configures scraping of Agent over HTTPS if it has TLS options.
+// TLS files are used only if dir is not empty; they are not available for vmagent in push mode.
+func setExporterTLS(cfg *config.ScrapeConfig, agent *models.Agent, dir string) {
+	options := agent.ExporterTLS
+	if options == nil {
+		return
+	}
+
+	cfg.Scheme = "https"
+	cfg.HTTPClientConfig.TLSConfig = config.TLSConfig{
+		ServerName:         options.ServerName,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+	if dir == "" {
+		return
+	}
+
+	agentDir := exporterTLSAgentDir(dir, agent.AgentID)
+	files := exporterTLSFiles(options)
+	if _, ok := files["ca.crt"]; ok {
+		cfg.HTTPClientConfig.TLSConfig.CAFile = filepath.Join(agentDir, "ca.crt")
+	}
+	if _, ok := files["tls.crt"]; ok {
+		cfg.HTTPClientConfig.TLSConfig.CertFile = filepath.Join(agentDir, "tls.crt")
+		cfg.HTTPClientConfig.TLSConfig.KeyFile = filepath.Join(agentDir, "tls.key")
+	}
+}
+
+// writeExporterTLSFiles writes TLS files of given Agents to dir and removes files of other Agents.
+func writeExporterTLSFiles(dir string, agents []*models.Agent) error {
+	if err := os.MkdirAll(dir, exporterTLSDirPerm); err != nil {
+		return errors.WithStack(err)
+	}
+
+	keep := make(map[string]struct{}, len(agents))
+	for _, agent := range agents {
+		if agent.ExporterTLS == nil {
+			continue
+		}
+
+		agentDir := exporterTLSAgentDir(dir, agent.AgentID)
+		if err := os.MkdirAll(agentDir, exporterTLSDirPerm); err != nil {
+			return errors.WithStack(err)
+		}
+		for name, content := range exporterTLSFiles(agent.ExporterTLS) {
+			if err := ioutil.WriteFile(filepath.Join(agentDir, name), []byte(content), exporterTLSFilePerm); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		keep[filepath.Base(agentDir)] = struct{}{}
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, e := range entries {
+		if _, ok := keep[e.Name()]; ok {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	config "github.com/percona/promconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestExporterTLS(t *testing.T) {
+	options := &models.ExporterTLSOptions{
+		CA:                 "ca",
+		Cert:               "cert",
+		Key:                "key",
+		ServerName:         "mysql.example.com",
+		InsecureSkipVerify: true,
+	}
+	agent := &models.Agent{
+		AgentID:     "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+		AgentType:   models.MySQLdExporterType,
+		PMMAgentID:  pointer.ToString("pmm-agent"),
+		ExporterTLS: options,
+	}
+
+	t.Run("Pull", func(t *testing.T) {
+		cfg := &config.ScrapeConfig{
+			HTTPClientConfig: config.HTTPClientConfig{
+				BasicAuth: &config.BasicAuth{Username: "pmm", Password: "secret"},
+			},
+		}
+		setExporterTLS(cfg, agent, "/srv/victoriametrics/tls")
+
+		dir := "/srv/victoriametrics/tls/_agent_id_75bb30d3-ef4a-4147-97a8-621a996611dd"
+		assert.Equal(t, &config.ScrapeConfig{
+			Scheme: "https",
+			HTTPClientConfig: config.HTTPClientConfig{
+				BasicAuth: &config.BasicAuth{Username: "pmm", Password: "secret"},
+				TLSConfig: config.TLSConfig{
+					CAFile:             dir + "/ca.crt",
+					CertFile:           dir + "/tls.crt",
+					KeyFile:            dir + "/tls.key",
+					ServerName:         "mysql.example.com",
+					InsecureSkipVerify: true,
+				},
+			},
+		}, cfg)
+	})
+
+	t.Run("Push", func(t *testing.T) {
+		cfg := new(config.ScrapeConfig)
+		setExporterTLS(cfg, agent, "")
+		assert.Equal(t, &config.ScrapeConfig{
+			Scheme: "https",
+			HTTPClientConfig: config.HTTPClientConfig{
+				TLSConfig: config.TLSConfig{
+					ServerName:         "mysql.example.com",
+					InsecureSkipVerify: true,
+				},
+			},
+		}, cfg)
+	})
+
+	t.Run("NoTLS", func(t *testing.T) {
+		cfg := new(config.ScrapeConfig)
+		setExporterTLS(cfg, &models.Agent{AgentID: "A1"}, "/srv/victoriametrics/tls")
+		assert.Equal(t, new(config.ScrapeConfig), cfg)
+	})
+
+	t.Run("WriteFiles", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "pmm-managed-exporter-tls-")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, os.RemoveAll(dir))
+		})
+
+		// stale files of removed Agent
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "removed"), 0o700))
+
+		require.NoError(t, writeExporterTLSFiles(dir, []*models.Agent{agent, {AgentID: "A1"}}))
+
+		entries, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "_agent_id_75bb30d3-ef4a-4147-97a8-621a996611dd", entries[0].Name())
+
+		for name, expected := range map[string]string{"ca.crt": "ca", "tls.crt": "cert", "tls.key": "key"} {
+			path := filepath.Join(dir, entries[0].Name(), name)
+			b, err := ioutil.ReadFile(path) //nolint:gosec
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(b))
+
+			fi, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, exporterTLSFilePerm, fi.Mode().Perm())
+		}
+	})
+}
@@ -0,0 +1,171 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	externalConfigFilePerm = os.FileMode(0o644)
+	externalConfigDirPerm  = os.FileMode(0o755)
+)
+
+// configStatus contains results of the last configuration update.
+type configStatus struct {
+	path      string    // file written by the last update
+	readOnly  bool      // true if configuration was written to external path without reloading
+	updatedAt time.Time // time of the last successful update
+	err       error     // error of the last update, if any
+}
+
+// ConfigStatus represents freshness of the scrape configuration file.
+type ConfigStatus struct {
+	// True if configuration is written for externally managed server and never reloaded.
+	ReadOnly bool
+	// Path of configuration file.
+	Path string
+	// Time of the last successful configuration update; zero if there were none since start.
+	UpdatedAt time.Time
+	// Modification time of configuration file; zero if it does not exist.
+	ModifiedAt time.Time
+	// Error of the last configuration update, if any.
+	LastError string
+	// True if configuration file matches configuration generated for the current state.
+	Fresh bool
+}
+
+// externalConfigPath returns path for read-only external mode, or empty string if that mode is not enabled.
+func (svc *Service) externalConfigPath() (string, error) {
+	settings, err := models.GetSettings(svc.db)
+	if err != nil {
+		return "", err
+	}
+	return settings.VictoriaMetrics.ExternalConfigPath, nil
+}
+
+// configPath returns path of configuration file written by updates.
+func (svc *Service) configPath() (string, error) {
+	path, err := svc.externalConfigPath()
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = svc.scrapeConfigPath
+	}
+	return path, nil
+}
+
+// writeExternalConfig validates given configuration and writes it to path without reloading anything;
+// an external operator is responsible for applying it.
+func (svc *Service) writeExternalConfig(ctx context.Context, path string, b []byte) error {
+	if old, err := ioutil.ReadFile(path); err == nil && bytes.Equal(old, b) { //nolint:gosec
+		svc.l.Debug("External configuration not changed, doing nothing.")
+		return nil
+	}
+
+	if err := svc.validateConfig(ctx, b); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), externalConfigDirPerm); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// write atomically, so the external operator never reads partially written file
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, externalConfigFilePerm); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.WithStack(err)
+	}
+
+	svc.l.Infof("Configuration written to %s, reload skipped.", path)
+	return nil
+}
+
+// recordUpdate stores the result of configuration update for ConfigStatus.
+func (svc *Service) recordUpdate(externalPath string, err error) {
+	svc.statusRW.Lock()
+	defer svc.statusRW.Unlock()
+
+	svc.status.readOnly = externalPath != ""
+	svc.status.path = externalPath
+	if !svc.status.readOnly {
+		svc.status.path = svc.scrapeConfigPath
+	}
+	svc.status.err = err
+	if err == nil {
+		svc.status.updatedAt = time.Now()
+	}
+}
+
+// ConfigStatus returns freshness of the configuration file written by updates.
+func (svc *Service) ConfigStatus() (*ConfigStatus, error) {
+	path, err := svc.configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	svc.statusRW.RLock()
+	status := svc.status
+	svc.statusRW.RUnlock()
+
+	res := &ConfigStatus{
+		ReadOnly: path != svc.scrapeConfigPath,
+		Path:     path,
+	}
+	// results of the last update are reported only if it was done for the same file
+	if status.path == path {
+		res.UpdatedAt = status.updatedAt
+		if status.err != nil {
+			res.LastError = status.err.Error()
+		}
+	}
+
+	current, err := ioutil.ReadFile(path) //nolint:gosec
+	switch {
+	case err == nil:
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res.ModifiedAt = fi.ModTime().UTC()
+	case os.IsNotExist(err):
+		return res, nil
+	default:
+		return nil, errors.WithStack(err)
+	}
+
+	pending, _, err := svc.marshalConfig(svc.loadBaseConfig())
+	if err != nil {
+		return nil, err
+	}
+	res.Fresh = bytes.Equal(current, pending)
+
+	return res, nil
+}
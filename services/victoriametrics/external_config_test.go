@@ -0,0 +1,69 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteExternalConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	svc := &Service{
+		validator: EmbeddedConfigValidator,
+		l:         logrus.WithField("test", t.Name()),
+	}
+	path := filepath.Join(dir, "prometheus", "scrape.yml")
+	ctx := context.Background()
+
+	t.Run("Valid", func(t *testing.T) {
+		cfg := []byte("scrape_configs:\n  - job_name: test\n")
+		require.NoError(t, svc.writeExternalConfig(ctx, path, cfg))
+
+		b, err := ioutil.ReadFile(path) //nolint:gosec
+		require.NoError(t, err)
+		assert.Equal(t, cfg, b)
+		assert.NoFileExists(t, path+".tmp")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		cfg := []byte("scrape_configs:\n  - job_name: test\n  - job_name: test\n")
+		require.Error(t, svc.writeExternalConfig(ctx, path, cfg))
+
+		// previous configuration is kept
+		b, err := ioutil.ReadFile(path) //nolint:gosec
+		require.NoError(t, err)
+		assert.Equal(t, "scrape_configs:\n  - job_name: test\n", string(b))
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		svc.recordUpdate(path, nil)
+		assert.True(t, svc.status.readOnly)
+		assert.Equal(t, path, svc.status.path)
+		assert.False(t, svc.status.updatedAt.IsZero())
+	})
+}
@@ -0,0 +1,196 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	config "github.com/percona/promconfig"
+	"github.com/pkg/errors"
+)
+
+const (
+	// fileSDDir contains file_sd JSON files with scrape targets of Agents, one directory per service type.
+	fileSDDir = victoriametricsDir + "/file_sd"
+
+	fileSDFilePerm = os.FileMode(0o644)
+	fileSDDirPerm  = os.FileMode(0o755)
+
+	// fileSDNodeType is used instead of service type for Agents not related to any Service.
+	fileSDNodeType = "node"
+)
+
+// fileSDGroup is a target group in Prometheus file_sd JSON format.
+type fileSDGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// fileSDFiles maps file_sd file names relative to the file_sd directory to their contents.
+type fileSDFiles map[string][]byte
+
+// fileSDServiceType returns service type of Agent's scrape config,
+// or empty string if scrape config is not generated for an Agent.
+func fileSDServiceType(scfg *config.ScrapeConfig) string {
+	if len(scfg.ServiceDiscoveryConfig.StaticConfigs) == 0 {
+		return ""
+	}
+	for _, g := range scfg.ServiceDiscoveryConfig.StaticConfigs {
+		if g.Labels["agent_id"] == "" {
+			return ""
+		}
+	}
+
+	if t := scfg.ServiceDiscoveryConfig.StaticConfigs[0].Labels["service_type"]; t != "" {
+		return t
+	}
+	return fileSDNodeType
+}
+
+// convertToFileSD moves static targets of Agents' scrape configs to file_sd files in dir and returns them.
+// Each job gets its own <service_type>/<job_name>.json file referenced from the configuration.
+// Additionally, <service_type>.json files with targets of all jobs (with job label) are returned
+// for consumption by external tooling.
+// Scrape configs are replaced with copies, so cached scrape configs are not modified.
+func convertToFileSD(cfg *config.Config, dir string) (fileSDFiles, error) {
+	files := make(fileSDFiles)
+	byType := make(map[string][]*fileSDGroup)
+	for i, scfg := range cfg.ScrapeConfigs {
+		serviceType := fileSDServiceType(scfg)
+		if serviceType == "" {
+			continue
+		}
+
+		groups := make([]*fileSDGroup, len(scfg.ServiceDiscoveryConfig.StaticConfigs))
+		for j, g := range scfg.ServiceDiscoveryConfig.StaticConfigs {
+			groups[j] = &fileSDGroup{Targets: g.Targets, Labels: g.Labels}
+
+			labels := make(map[string]string, len(g.Labels)+1)
+			for k, v := range g.Labels {
+				labels[k] = v
+			}
+			labels["job"] = scfg.JobName
+			byType[serviceType] = append(byType[serviceType], &fileSDGroup{Targets: g.Targets, Labels: labels})
+		}
+
+		name := filepath.Join(serviceType, scfg.JobName+".json")
+		b, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files[name] = b
+
+		c := *scfg
+		c.ServiceDiscoveryConfig = config.ServiceDiscoveryConfig{
+			FileSDConfigs: []*config.FilesSDConfig{{
+				Files: []string{filepath.Join(dir, name)},
+			}},
+		}
+		cfg.ScrapeConfigs[i] = &c
+	}
+
+	for serviceType, groups := range byType {
+		b, err := json.MarshalIndent(groups, "", "  ")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files[serviceType+".json"] = b
+	}
+
+	return files, nil
+}
+
+// writeFileSDFiles writes given files to dir and removes all other files there.
+// Unchanged files are not rewritten; changed files are replaced atomically,
+// so VictoriaMetrics never reads partially written targets.
+func writeFileSDFiles(dir string, files fileSDFiles) error {
+	if len(files) == 0 {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, fileSDDirPerm); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if old, err := ioutil.ReadFile(path); err == nil && bytes.Equal(old, content) { //nolint:gosec
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), fileSDDirPerm); err != nil {
+			return errors.WithStack(err)
+		}
+		tmp := path + ".tmp"
+		if err := ioutil.WriteFile(tmp, content, fileSDFilePerm); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	// remove stale files first, then empty directories (deepest first)
+	var dirs []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if _, ok := files[rel]; ok {
+			return nil
+		}
+		return os.Remove(path)
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, d := range dirs {
+		entries, err := ioutil.ReadDir(d)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(entries) != 0 {
+			continue
+		}
+		if err = os.Remove(d); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	config "github.com/percona/promconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSD(t *testing.T) {
+	internal := &config.ScrapeConfig{
+		JobName: "victoriametrics",
+		ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+			StaticConfigs: []*config.Group{{
+				Targets: []string{"127.0.0.1:8428"},
+				Labels:  map[string]string{"instance": "pmm-server"},
+			}},
+		},
+	}
+	mysqld := &config.ScrapeConfig{
+		JobName: "mysqld_exporter_agent_id_1_hr-5s",
+		ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+			StaticConfigs: []*config.Group{{
+				Targets: []string{"1.2.3.4:12345"},
+				Labels:  map[string]string{"agent_id": "/agent_id/1", "service_type": "mysql"},
+			}},
+		},
+	}
+	node := &config.ScrapeConfig{
+		JobName: "node_exporter_agent_id_2_hr-5s",
+		ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+			StaticConfigs: []*config.Group{{
+				Targets: []string{"1.2.3.4:12346"},
+				Labels:  map[string]string{"agent_id": "/agent_id/2"},
+			}},
+		},
+	}
+
+	t.Run("Convert", func(t *testing.T) {
+		cfg := &config.Config{ScrapeConfigs: []*config.ScrapeConfig{internal, mysqld, node}}
+		files, err := convertToFileSD(cfg, "/srv/victoriametrics/file_sd")
+		require.NoError(t, err)
+
+		// internal and cached scrape configs are not modified
+		assert.Same(t, internal, cfg.ScrapeConfigs[0])
+		assert.Len(t, mysqld.ServiceDiscoveryConfig.StaticConfigs, 1)
+
+		assert.Equal(t, "mysqld_exporter_agent_id_1_hr-5s", cfg.ScrapeConfigs[1].JobName)
+		assert.Equal(t, config.ServiceDiscoveryConfig{
+			FileSDConfigs: []*config.FilesSDConfig{{
+				Files: []string{"/srv/victoriametrics/file_sd/mysql/mysqld_exporter_agent_id_1_hr-5s.json"},
+			}},
+		}, cfg.ScrapeConfigs[1].ServiceDiscoveryConfig)
+		assert.Equal(t, config.ServiceDiscoveryConfig{
+			FileSDConfigs: []*config.FilesSDConfig{{
+				Files: []string{"/srv/victoriametrics/file_sd/node/node_exporter_agent_id_2_hr-5s.json"},
+			}},
+		}, cfg.ScrapeConfigs[2].ServiceDiscoveryConfig)
+
+		require.Len(t, files, 4)
+		assert.JSONEq(t, `[{"targets": ["1.2.3.4:12345"], "labels": {"agent_id": "/agent_id/1", "service_type": "mysql"}}]`,
+			string(files["mysql/mysqld_exporter_agent_id_1_hr-5s.json"]))
+		assert.JSONEq(t, `[{"targets": ["1.2.3.4:12345"], "labels": {"agent_id": "/agent_id/1", "service_type": "mysql", "job": "mysqld_exporter_agent_id_1_hr-5s"}}]`,
+			string(files["mysql.json"]))
+		assert.JSONEq(t, `[{"targets": ["1.2.3.4:12346"], "labels": {"agent_id": "/agent_id/2", "job": "node_exporter_agent_id_2_hr-5s"}}]`,
+			string(files["node.json"]))
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "file_sd")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir) //nolint:errcheck
+
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "postgresql"), 0o755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "postgresql", "stale.json"), []byte("[]"), 0o644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "postgresql.json"), []byte("[]"), 0o644))
+
+		files := fileSDFiles{
+			"mysql.json":          []byte(`[]`),
+			"mysql/mysqld_1.json": []byte(`[{"targets":["1.2.3.4:12345"]}]`),
+		}
+		require.NoError(t, writeFileSDFiles(dir, files))
+
+		for name, content := range files {
+			b, err := ioutil.ReadFile(filepath.Join(dir, name)) //nolint:gosec
+			require.NoError(t, err)
+			assert.Equal(t, content, b)
+		}
+		assert.NoDirExists(t, filepath.Join(dir, "postgresql"))
+		assert.NoFileExists(t, filepath.Join(dir, "postgresql.json"))
+
+		require.NoError(t, writeFileSDFiles(dir, nil))
+		entries, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
@@ -0,0 +1,417 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	config "github.com/percona/promconfig"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// kubernetesSDDir contains TLS files used by scrape jobs with Kubernetes service discovery, one directory per job.
+const kubernetesSDDir = victoriametricsDir + "/kubernetes"
+
+// kubernetesLabelNameRE matches characters that are replaced in Kubernetes label names by service discovery.
+var kubernetesLabelNameRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// kubeConfig contains parts of kubeconfig file used for Kubernetes service discovery.
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// kubernetesAPIAccess contains Kubernetes API server address and credentials of the current kubeconfig context.
+type kubernetesAPIAccess struct {
+	server             string
+	insecureSkipVerify bool
+	token              string
+	files              map[string]string // api-ca.crt, api-tls.crt, api-tls.key
+}
+
+// parseKubeConfig extracts Kubernetes API server address and credentials from kubeconfig.
+// Only embedded certificates and static tokens are supported.
+func parseKubeConfig(s string) (*kubernetesAPIAccess, error) {
+	var kc kubeConfig
+	if err := yaml.Unmarshal([]byte(s), &kc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse kubeconfig")
+	}
+
+	var clusterName, userName string
+	for _, c := range kc.Contexts {
+		if c.Name == kc.CurrentContext || (kc.CurrentContext == "" && len(kc.Contexts) == 1) {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			break
+		}
+	}
+	if clusterName == "" {
+		return nil, errors.Errorf("kubeconfig context %q not found", kc.CurrentContext)
+	}
+
+	res := &kubernetesAPIAccess{
+		files: make(map[string]string),
+	}
+	decode := func(name, data string) error {
+		if data == "" {
+			return nil
+		}
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode %s", name)
+		}
+		res.files[name] = string(b)
+		return nil
+	}
+
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		res.server = c.Cluster.Server
+		res.insecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		if err := decode("api-ca.crt", c.Cluster.CertificateAuthorityData); err != nil {
+			return nil, err
+		}
+	}
+	if res.server == "" {
+		return nil, errors.Errorf("kubeconfig cluster %q not found", clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		res.token = u.User.Token
+		if err := decode("api-tls.crt", u.User.ClientCertificateData); err != nil {
+			return nil, err
+		}
+		if err := decode("api-tls.key", u.User.ClientKeyData); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// kubernetesSDFiles returns file names and contents of TLS files for given job:
+// Kubernetes API server access files and scraped targets TLS files.
+func kubernetesSDFiles(c *models.KubernetesScrapeConfig, api *kubernetesAPIAccess) map[string]string {
+	res := make(map[string]string, len(api.files)+3)
+	for name, content := range api.files {
+		res[name] = content
+	}
+	if c.TLS != nil {
+		for name, content := range exporterTLSFiles(c.TLS) {
+			res[name] = content
+		}
+	}
+	return res
+}
+
+// kubernetesLabelMetaPrefix returns prefix of Kubernetes labels meta labels for given role.
+func kubernetesLabelMetaPrefix(role string) string {
+	switch role {
+	case "endpoints", "endpointslice":
+		return "__meta_kubernetes_service_label"
+	default:
+		return "__meta_kubernetes_" + role + "_label"
+	}
+}
+
+// kubernetesRelabelConfigs returns relabeling rules that implement namespaces and label selector filtering
+// (promconfig does not support namespaces and selectors sections of kubernetes_sd_configs),
+// and add kubernetes_cluster and namespace labels.
+func kubernetesRelabelConfigs(c *models.KubernetesScrapeConfig, selector []*models.LabelSelectorRequirement) []*config.RelabelConfig {
+	var res []*config.RelabelConfig
+
+	if len(c.Namespaces) != 0 && c.Role != "node" {
+		quoted := make([]string, len(c.Namespaces))
+		for i, ns := range c.Namespaces {
+			quoted[i] = regexp.QuoteMeta(ns)
+		}
+		res = append(res, &config.RelabelConfig{
+			SourceLabels: []string{"__meta_kubernetes_namespace"},
+			Regex:        "(" + strings.Join(quoted, "|") + ")",
+			Action:       "keep",
+		})
+	}
+
+	prefix := kubernetesLabelMetaPrefix(c.Role)
+	for _, r := range selector {
+		name := kubernetesLabelNameRE.ReplaceAllString(r.Key, "_")
+		regex := "(" + regexp.QuoteMeta(r.Value) + ")"
+		switch r.Operator {
+		case models.LabelSelectorEquals:
+			res = append(res, &config.RelabelConfig{SourceLabels: []string{prefix + "_" + name}, Regex: regex, Action: "keep"})
+		case models.LabelSelectorNotEquals:
+			res = append(res, &config.RelabelConfig{SourceLabels: []string{prefix + "_" + name}, Regex: regex, Action: "drop"})
+		case models.LabelSelectorExists:
+			res = append(res, &config.RelabelConfig{SourceLabels: []string{prefix + "present_" + name}, Regex: "true", Action: "keep"})
+		case models.LabelSelectorDoesNotExist:
+			res = append(res, &config.RelabelConfig{SourceLabels: []string{prefix + "present_" + name}, Regex: "true", Action: "drop"})
+		}
+	}
+
+	res = append(res, &config.RelabelConfig{
+		TargetLabel: "kubernetes_cluster",
+		Replacement: c.KubernetesClusterName,
+		Action:      "replace",
+	})
+	if c.Role != "node" {
+		res = append(res, &config.RelabelConfig{
+			SourceLabels: []string{"__meta_kubernetes_namespace"},
+			TargetLabel:  "namespace",
+			Action:       "replace",
+		})
+	}
+
+	return res
+}
+
+// scrapeConfigForKubernetesJob returns scrape config for job with Kubernetes service discovery.
+// TLS files are expected in the job's subdirectory of dir.
+func scrapeConfigForKubernetesJob(c *models.KubernetesScrapeConfig, api *kubernetesAPIAccess, dir string) (*config.ScrapeConfig, error) {
+	selector, err := models.ParseLabelSelector(c.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	files := kubernetesSDFiles(c, api)
+	jobDir := filepath.Join(dir, c.JobName)
+	file := func(name string) string {
+		if _, ok := files[name]; !ok {
+			return ""
+		}
+		return filepath.Join(jobDir, name)
+	}
+
+	sd := &config.KubernetesSDConfig{
+		APIServer: api.server,
+		Role:      c.Role,
+		HTTPClientConfig: config.HTTPClientConfig{
+			BearerToken: api.token,
+			TLSConfig: config.TLSConfig{
+				CAFile:             file("api-ca.crt"),
+				CertFile:           file("api-tls.crt"),
+				KeyFile:            file("api-tls.key"),
+				InsecureSkipVerify: api.insecureSkipVerify,
+			},
+		},
+	}
+
+	cfg := &config.ScrapeConfig{
+		JobName:     c.JobName,
+		MetricsPath: c.MetricsPath,
+		Scheme:      c.Scheme,
+		ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+			KubernetesSDConfigs: []*config.KubernetesSDConfig{sd},
+		},
+		RelabelConfigs: kubernetesRelabelConfigs(c, selector),
+	}
+	if cfg.MetricsPath == "" {
+		cfg.MetricsPath = "/metrics"
+	}
+	if c.Interval != 0 {
+		cfg.ScrapeInterval = config.Duration(c.Interval)
+		cfg.ScrapeTimeout = scrapeTimeout(c.Interval)
+	}
+	if c.TLS != nil {
+		if cfg.Scheme == "" {
+			cfg.Scheme = "https"
+		}
+		cfg.HTTPClientConfig.TLSConfig = config.TLSConfig{
+			CAFile:             file("ca.crt"),
+			CertFile:           file("tls.crt"),
+			KeyFile:            file("tls.key"),
+			ServerName:         c.TLS.ServerName,
+			InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadKubernetesJobs returns scrape jobs with Kubernetes service discovery and API access of their clusters.
+// Jobs of clusters with unusable kubeconfig are skipped with a warning.
+func loadKubernetesJobs(l *logrus.Entry, q *reform.Querier) ([]*models.KubernetesScrapeConfig, map[string]*kubernetesAPIAccess, error) {
+	jobs, err := models.FindKubernetesScrapeConfigs(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res := make([]*models.KubernetesScrapeConfig, 0, len(jobs))
+	access := make(map[string]*kubernetesAPIAccess)
+	for _, j := range jobs {
+		if _, ok := access[j.KubernetesClusterName]; !ok {
+			cluster, err := models.FindKubernetesClusterByName(q, j.KubernetesClusterName)
+			if err != nil {
+				return nil, nil, err
+			}
+			api, err := parseKubeConfig(cluster.KubeConfig)
+			if err != nil {
+				l.Warnf("Skipping scrape job %q for Kubernetes cluster %q: %s.", j.JobName, j.KubernetesClusterName, err)
+				continue
+			}
+			access[j.KubernetesClusterName] = api
+		}
+		res = append(res, j)
+	}
+
+	return res, access, nil
+}
+
+// scrapeConfigsForKubernetesJobs returns scrape configs for jobs with Kubernetes service discovery.
+func scrapeConfigsForKubernetesJobs(l *logrus.Entry, q *reform.Querier, dir string) ([]*config.ScrapeConfig, error) {
+	jobs, access, err := loadKubernetesJobs(l, q)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*config.ScrapeConfig, 0, len(jobs))
+	for _, j := range jobs {
+		cfg, err := scrapeConfigForKubernetesJob(j, access[j.KubernetesClusterName], dir)
+		if err != nil {
+			l.Warnf("Skipping scrape job %q: %s.", j.JobName, err)
+			continue
+		}
+		res = append(res, cfg)
+	}
+	return res, nil
+}
+
+// writeKubernetesSDFiles writes TLS files of jobs with Kubernetes service discovery to dir
+// and removes files of other jobs.
+func writeKubernetesSDFiles(l *logrus.Entry, q *reform.Querier, dir string) error {
+	jobs, access, err := loadKubernetesJobs(l, q)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dir, exporterTLSDirPerm); err != nil {
+		return errors.WithStack(err)
+	}
+
+	keep := make(map[string]struct{}, len(jobs))
+	for _, j := range jobs {
+		jobDir := filepath.Join(dir, j.JobName)
+		if err = os.MkdirAll(jobDir, exporterTLSDirPerm); err != nil {
+			return errors.WithStack(err)
+		}
+		for name, content := range kubernetesSDFiles(j, access[j.KubernetesClusterName]) {
+			if err = ioutil.WriteFile(filepath.Join(jobDir, name), []byte(content), exporterTLSFilePerm); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		keep[j.JobName] = struct{}{}
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, e := range entries {
+		if _, ok := keep[e.Name()]; ok {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// ListKubernetesScrapeConfigs returns all scrape jobs with Kubernetes service discovery.
+func (svc *Service) ListKubernetesScrapeConfigs(ctx context.Context) ([]*models.KubernetesScrapeConfig, error) {
+	var res []*models.KubernetesScrapeConfig
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var e error
+		res, e = models.FindKubernetesScrapeConfigs(tx.Querier)
+		return e
+	})
+	return res, err
+}
+
+// AddKubernetesScrapeConfig registers scrape job with Kubernetes service discovery and reloads configuration.
+// Job is not added if resulting configuration is rejected by VictoriaMetrics.
+func (svc *Service) AddKubernetesScrapeConfig(ctx context.Context, params *models.CreateKubernetesScrapeConfigParams) (*models.KubernetesScrapeConfig, error) {
+	var res *models.KubernetesScrapeConfig
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		var e error
+		if res, e = models.CreateKubernetesScrapeConfig(tx.Querier, params); e != nil {
+			return e
+		}
+
+		b, _, e := svc.generateConfig(tx.Querier, svc.loadBaseConfig())
+		if e != nil {
+			return e
+		}
+		return svc.validateConfig(ctx, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	svc.RequestConfigurationUpdate()
+	return res, nil
+}
+
+// RemoveKubernetesScrapeConfig removes scrape job with Kubernetes service discovery and reloads configuration.
+func (svc *Service) RemoveKubernetesScrapeConfig(ctx context.Context, id string) error {
+	err := svc.db.InTransactionContext(ctx, nil, func(tx *reform.TX) error {
+		return models.RemoveKubernetesScrapeConfig(tx.Querier, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	svc.RequestConfigurationUpdate()
+	return nil
+}
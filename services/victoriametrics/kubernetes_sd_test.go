@@ -0,0 +1,138 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	config "github.com/percona/promconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestKubernetesSD(t *testing.T) {
+	kubeConfig := `
+apiVersion: v1
+kind: Config
+current-context: pmm
+clusters:
+  - name: other
+    cluster:
+      server: https://10.0.0.2:6443
+  - name: pmm
+    cluster:
+      server: https://10.0.0.1:6443
+      certificate-authority-data: ` + base64.StdEncoding.EncodeToString([]byte("CA")) + `
+contexts:
+  - name: pmm
+    context:
+      cluster: pmm
+      user: pmm-user
+users:
+  - name: pmm-user
+    user:
+      token: secret-token
+`
+
+	api, err := parseKubeConfig(kubeConfig)
+	require.NoError(t, err)
+	assert.Equal(t, &kubernetesAPIAccess{
+		server: "https://10.0.0.1:6443",
+		token:  "secret-token",
+		files:  map[string]string{"api-ca.crt": "CA"},
+	}, api)
+
+	t.Run("Pod", func(t *testing.T) {
+		c := &models.KubernetesScrapeConfig{
+			JobName:               "pxc",
+			KubernetesClusterName: "prod",
+			Role:                  "pod",
+			Namespaces:            []string{"db", "db.test"},
+			LabelSelector:         "app.kubernetes.io/name=pxc,tier!=proxy,backup,!canary",
+			Interval:              30 * time.Second,
+			TLS:                   &models.ExporterTLSOptions{CA: "target CA", InsecureSkipVerify: true},
+		}
+		actual, err := scrapeConfigForKubernetesJob(c, api, "/srv/victoriametrics/kubernetes")
+		require.NoError(t, err)
+
+		expected := &config.ScrapeConfig{
+			JobName:        "pxc",
+			ScrapeInterval: config.Duration(30 * time.Second),
+			ScrapeTimeout:  config.Duration(27 * time.Second),
+			MetricsPath:    "/metrics",
+			Scheme:         "https",
+			HTTPClientConfig: config.HTTPClientConfig{
+				TLSConfig: config.TLSConfig{
+					CAFile:             "/srv/victoriametrics/kubernetes/pxc/ca.crt",
+					InsecureSkipVerify: true,
+				},
+			},
+			ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+				KubernetesSDConfigs: []*config.KubernetesSDConfig{{
+					APIServer: "https://10.0.0.1:6443",
+					Role:      "pod",
+					HTTPClientConfig: config.HTTPClientConfig{
+						BearerToken: "secret-token",
+						TLSConfig: config.TLSConfig{
+							CAFile: "/srv/victoriametrics/kubernetes/pxc/api-ca.crt",
+						},
+					},
+				}},
+			},
+			RelabelConfigs: []*config.RelabelConfig{{
+				SourceLabels: []string{"__meta_kubernetes_namespace"},
+				Regex:        `(db|db\.test)`,
+				Action:       "keep",
+			}, {
+				SourceLabels: []string{"__meta_kubernetes_pod_label_app_kubernetes_io_name"},
+				Regex:        "(pxc)",
+				Action:       "keep",
+			}, {
+				SourceLabels: []string{"__meta_kubernetes_pod_label_tier"},
+				Regex:        "(proxy)",
+				Action:       "drop",
+			}, {
+				SourceLabels: []string{"__meta_kubernetes_pod_labelpresent_backup"},
+				Regex:        "true",
+				Action:       "keep",
+			}, {
+				SourceLabels: []string{"__meta_kubernetes_pod_labelpresent_canary"},
+				Regex:        "true",
+				Action:       "drop",
+			}, {
+				TargetLabel: "kubernetes_cluster",
+				Replacement: "prod",
+				Action:      "replace",
+			}, {
+				SourceLabels: []string{"__meta_kubernetes_namespace"},
+				TargetLabel:  "namespace",
+				Action:       "replace",
+			}},
+		}
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, map[string]string{"api-ca.crt": "CA", "ca.crt": "target CA"}, kubernetesSDFiles(c, api))
+	})
+
+	t.Run("InvalidKubeConfig", func(t *testing.T) {
+		_, err := parseKubeConfig("current-context: missing\n")
+		assert.EqualError(t, err, `kubeconfig context "missing" not found`)
+	})
+}
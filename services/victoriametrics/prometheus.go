@@ -29,12 +29,31 @@ import (
 // AddScrapeConfigs - adds agents scrape configuration to given scrape config,
 // pmm_agent_id and push_metrics used for filtering.
 func AddScrapeConfigs(l *logrus.Entry, cfg *config.Config, q *reform.Querier, s *models.MetricsResolutions, pmmAgentID *string, pushMetrics bool) error {
+	return addScrapeConfigs(l, cfg, q, s, pmmAgentID, pushMetrics, nil)
+}
+
+// addScrapeConfigs is AddScrapeConfigs that reuses scrape configs of unchanged Agents from the given cache;
+// cache may be nil.
+func addScrapeConfigs(l *logrus.Entry, cfg *config.Config, q *reform.Querier, s *models.MetricsResolutions, pmmAgentID *string, pushMetrics bool, cache *scrapeConfigsCache) error {
 	agents, err := models.FindAgentsForScrapeConfig(q, pmmAgentID, pushMetrics)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
+	// load all related Services, Nodes and pmm-agents at once instead of querying them for each Agent
+	refs, err := loadScrapeConfigsRefs(q, agents)
+	if err != nil {
+		return err
+	}
+
+	// TLS files are written only on PMM Server
+	tlsDir := exporterTLSDir
+	if pushMetrics {
+		tlsDir = ""
+	}
+
 	var rdsParams []*scrapeConfigParams
+	seen := make(map[scrapeConfigsCacheKey]struct{}, len(agents))
 	for _, agent := range agents {
 		if agent.AgentType == models.PMMAgentType {
 			// TODO https://jira.percona.com/browse/PMM-4087
@@ -49,19 +68,32 @@ func AddScrapeConfigs(l *logrus.Entry, cfg *config.Config, q *reform.Querier, s
 		// find Service for this Agent
 		var paramsService *models.Service
 		if agent.ServiceID != nil {
-			paramsService, err = models.FindServiceByID(q, pointer.GetString(agent.ServiceID))
+			paramsService, err = refs.service(pointer.GetString(agent.ServiceID))
 			if err != nil {
 				return err
 			}
 		}
 
+		// Service may override global resolutions
+		resolutions := s
+		if paramsService != nil && paramsService.MetricsResolutions != nil {
+			r := s.Override(paramsService.MetricsResolutions)
+			resolutions = &r
+		}
+
+		// Agent may override both of them
+		if agent.MetricsResolutions != nil || agent.MetricsResolutionTier != "" {
+			r := resolutions.Override(agent.MetricsResolutions).Tier(agent.MetricsResolutionTier)
+			resolutions = &r
+		}
+
 		// find Node for this Agent or Service
 		var paramsNode *models.Node
 		switch {
 		case agent.NodeID != nil:
-			paramsNode, err = models.FindNodeByID(q, pointer.GetString(agent.NodeID))
+			paramsNode, err = refs.node(pointer.GetString(agent.NodeID))
 		case paramsService != nil:
-			paramsNode, err = models.FindNodeByID(q, paramsService.NodeID)
+			paramsNode, err = refs.node(paramsService.NodeID)
 		}
 		if err != nil {
 			return err
@@ -69,6 +101,7 @@ func AddScrapeConfigs(l *logrus.Entry, cfg *config.Config, q *reform.Querier, s
 
 		// find Node address where the agent runs
 		var paramsHost string
+		var hostNode *models.Node
 		switch {
 		// special case for push metrics mode,
 		// vmagent scrapes it from localhost.
@@ -76,118 +109,44 @@ func AddScrapeConfigs(l *logrus.Entry, cfg *config.Config, q *reform.Querier, s
 			paramsHost = "127.0.0.1"
 		case agent.PMMAgentID != nil:
 			// extract node address through pmm-agent
-			pmmAgent, err := models.FindAgentByID(q, *agent.PMMAgentID)
+			pmmAgent, err := refs.agent(*agent.PMMAgentID)
 			if err != nil {
-				return errors.WithStack(err)
+				return err
 			}
-			pmmAgentNode := &models.Node{NodeID: pointer.GetString(pmmAgent.RunsOnNodeID)}
-			if err = q.Reload(pmmAgentNode); err != nil {
-				return errors.WithStack(err)
+			if hostNode, err = refs.node(pointer.GetString(pmmAgent.RunsOnNodeID)); err != nil {
+				return err
 			}
-			paramsHost = pmmAgentNode.Address
+			paramsHost = hostNode.Address
 		case agent.RunsOnNodeID != nil:
-			externalExporterNode := &models.Node{NodeID: pointer.GetString(agent.RunsOnNodeID)}
-			if err = q.Reload(externalExporterNode); err != nil {
-				return errors.WithStack(err)
+			if hostNode, err = refs.node(pointer.GetString(agent.RunsOnNodeID)); err != nil {
+				return err
 			}
-			paramsHost = externalExporterNode.Address
+			paramsHost = hostNode.Address
 		default:
 			l.Warnf("It's not possible to get host, skipping scrape config for %s.", agent)
 
 			continue
 		}
 
-		var scfgs []*config.ScrapeConfig
-		switch agent.AgentType {
-		case models.NodeExporterType:
-			scfgs, err = scrapeConfigsForNodeExporter(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: nil,
-				agent:   agent,
-			})
-
-		case models.MySQLdExporterType:
-			scfgs, err = scrapeConfigsForMySQLdExporter(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
-
-		case models.MongoDBExporterType:
-			scfgs, err = scrapeConfigsForMongoDBExporter(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
-
-		case models.PostgresExporterType:
-			scfgs, err = scrapeConfigsForPostgresExporter(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
-
-		case models.ProxySQLExporterType:
-			scfgs, err = scrapeConfigsForProxySQLExporter(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
-
-		case models.QANMySQLPerfSchemaAgentType, models.QANMySQLSlowlogAgentType:
-			continue
-		case models.QANMongoDBProfilerAgentType:
-			continue
-		case models.QANPostgreSQLPgStatementsAgentType, models.QANPostgreSQLPgStatMonitorAgentType:
-			continue
-
-		case models.RDSExporterType:
-			rdsParams = append(rdsParams, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
-			continue
-
-		case models.ExternalExporterType:
-			scfgs, err = scrapeConfigsForExternalExporter(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
-
-		case models.VMAgentType:
-			scfgs, err = scrapeConfigsForVMAgent(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: nil,
-				agent:   agent,
-			})
-
-		case models.AzureDatabaseExporterType:
-			scfgs, err = scrapeConfigsForAzureDatabase(s, &scrapeConfigParams{
-				host:    paramsHost,
-				node:    paramsNode,
-				service: paramsService,
-				agent:   agent,
-			})
+		params := &scrapeConfigParams{
+			host:    paramsHost,
+			node:    paramsNode,
+			service: paramsService,
+			agent:   agent,
+		}
 
-		default:
-			l.Warnf("Skipping scrape config for %s.", agent)
+		if agent.AgentType == models.RDSExporterType {
+			rdsParams = append(rdsParams, params)
 			continue
 		}
 
-		if err != nil {
-			l.Warnf("Failed to add %s %q, skipping: %s.", agent.AgentType, agent.AgentID, err)
-		}
-		cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scfgs...)
+		seen[newScrapeConfigsCacheKey(agent)] = struct{}{}
+		cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, cache.scrapeConfigs(l, resolutions, params, hostNode, tlsDir)...)
+	}
+
+	// cache is filled only with full configuration, so remove removed and disabled Agents
+	if pmmAgentID == nil {
+		cache.retain(seen)
 	}
 
 	scfgs := scrapeConfigsForRDSExporter(s, rdsParams)
@@ -196,6 +155,46 @@ func AddScrapeConfigs(l *logrus.Entry, cfg *config.Config, q *reform.Querier, s
 	return nil
 }
 
+// scrapeConfigsForAgent returns scrape configs for given non-RDS Agent.
+func scrapeConfigsForAgent(l *logrus.Entry, s *models.MetricsResolutions, params *scrapeConfigParams) ([]*config.ScrapeConfig, error) {
+	switch params.agent.AgentType {
+	case models.NodeExporterType:
+		return scrapeConfigsForNodeExporter(s, params)
+
+	case models.MySQLdExporterType:
+		return scrapeConfigsForMySQLdExporter(s, params)
+
+	case models.MongoDBExporterType:
+		return scrapeConfigsForMongoDBExporter(s, params)
+
+	case models.PostgresExporterType:
+		return scrapeConfigsForPostgresExporter(s, params)
+
+	case models.ProxySQLExporterType:
+		return scrapeConfigsForProxySQLExporter(s, params)
+
+	case models.QANMySQLPerfSchemaAgentType, models.QANMySQLSlowlogAgentType:
+		return nil, nil
+	case models.QANMongoDBProfilerAgentType:
+		return nil, nil
+	case models.QANPostgreSQLPgStatementsAgentType, models.QANPostgreSQLPgStatMonitorAgentType:
+		return nil, nil
+
+	case models.ExternalExporterType:
+		return scrapeConfigsForExternalExporter(s, params)
+
+	case models.VMAgentType:
+		return scrapeConfigsForVMAgent(s, params)
+
+	case models.AzureDatabaseExporterType:
+		return scrapeConfigsForAzureDatabase(s, params)
+
+	default:
+		l.Warnf("Skipping scrape config for %s.", params.agent)
+		return nil, nil
+	}
+}
+
 // AddInternalServicesToScrape adds internal services metrics to scrape targets.
 func AddInternalServicesToScrape(cfg *config.Config, s models.MetricsResolutions, dbaas bool) {
 	cfg.ScrapeConfigs = append(cfg.ScrapeConfigs,
@@ -0,0 +1,230 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+const (
+	// vmAgentURL is a base URL of vmagent replacing local VictoriaMetrics database
+	// when external metrics backend is configured; see supervisord package.
+	vmAgentURL = "http://127.0.0.1:8429"
+
+	// queryProxyRetryInterval is an interval of attempts to listen on local VictoriaMetrics address
+	// that is released when VictoriaMetrics database is stopped.
+	queryProxyRetryInterval = time.Second
+)
+
+// vmAgentPaths are paths (without path prefix) served by vmagent instead of external metrics backend.
+var vmAgentPaths = map[string]struct{}{
+	"/-/reload":          {},
+	"/-/healthy":         {},
+	"/-/ready":           {},
+	"/health":            {},
+	"/metrics":           {},
+	"/flags":             {},
+	"/config":            {},
+	"/targets":           {},
+	"/service-discovery": {},
+	"/api/v1/targets":    {},
+}
+
+// queryProxy serves local VictoriaMetrics address when external metrics backend is configured,
+// so Grafana, reports, capacity planning and other users of the local query API keep working.
+// Queries are proxied to the external backend; configuration reload, health and targets requests
+// are proxied to vmagent that replaces local VictoriaMetrics database in that mode.
+type queryProxy struct {
+	addr       string
+	pathPrefix string
+	vmAgentURL string
+	l          *logrus.Entry
+
+	rw       sync.RWMutex
+	external *models.ExternalVictoriaMetrics
+	handler  http.Handler // nil if external backend is not configured
+}
+
+// newQueryProxy creates new proxy for local VictoriaMetrics base URL.
+func newQueryProxy(baseURL *url.URL, vmAgentURL string) *queryProxy {
+	return &queryProxy{
+		addr:       baseURL.Host,
+		pathPrefix: strings.TrimSuffix(baseURL.Path, "/"),
+		vmAgentURL: vmAgentURL,
+		l:          logrus.WithField("component", "victoriametrics/query-proxy"),
+	}
+}
+
+// configure sets external metrics backend; nil stops serving.
+func (p *queryProxy) configure(external *models.ExternalVictoriaMetrics) error {
+	p.rw.Lock()
+	defer p.rw.Unlock()
+
+	if (external == nil && p.external == nil) || (external != nil && p.external != nil && *external == *p.external) {
+		return nil
+	}
+
+	if external == nil {
+		p.external = nil
+		p.handler = nil
+		return nil
+	}
+
+	handler, err := p.newHandler(external)
+	if err != nil {
+		return err
+	}
+	e := *external
+	p.external = &e
+	p.handler = handler
+	return nil
+}
+
+// newHandler returns handler routing requests to vmagent or to given external backend.
+func (p *queryProxy) newHandler(external *models.ExternalVictoriaMetrics) (http.Handler, error) {
+	readURL, err := url.Parse(external.ReadURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	agentURL, err := url.Parse(p.vmAgentURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	agent := httputil.NewSingleHostReverseProxy(agentURL)
+	backend := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = readURL.Scheme
+			req.URL.Host = readURL.Host
+			req.URL.Path = path.Join("/", readURL.Path, strings.TrimPrefix(req.URL.Path, p.pathPrefix))
+			req.URL.RawPath = ""
+			req.Host = readURL.Host
+
+			req.Header.Del("Authorization")
+			if external.Username != "" {
+				req.SetBasicAuth(external.Username, external.Password)
+			}
+		},
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: external.InsecureSkipVerify, //nolint:gosec
+			},
+		},
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if _, ok := vmAgentPaths[strings.TrimPrefix(req.URL.Path, p.pathPrefix)]; ok {
+			agent.ServeHTTP(rw, req)
+			return
+		}
+		backend.ServeHTTP(rw, req)
+	}), nil
+}
+
+// ServeHTTP implements http.Handler.
+func (p *queryProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	p.rw.RLock()
+	handler := p.handler
+	p.rw.RUnlock()
+
+	if handler == nil {
+		http.Error(rw, "External metrics backend is not configured.", http.StatusServiceUnavailable)
+		return
+	}
+	handler.ServeHTTP(rw, req)
+}
+
+// run serves local VictoriaMetrics address while external backend is configured, until ctx is canceled.
+// Address is taken over as soon as local VictoriaMetrics database releases it, and released when
+// external backend configuration is removed.
+func (p *queryProxy) run(ctx context.Context) {
+	ticker := time.NewTicker(queryProxyRetryInterval)
+	defer ticker.Stop()
+
+	var server *http.Server
+	for {
+		p.rw.RLock()
+		enabled := p.handler != nil
+		p.rw.RUnlock()
+
+		switch {
+		case enabled && server == nil:
+			server = p.listen()
+		case !enabled && server != nil:
+			p.shutdown(server)
+			server = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if server != nil {
+				p.shutdown(server)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listen starts serving local VictoriaMetrics address. It returns nil if address is still in use.
+func (p *queryProxy) listen() *http.Server {
+	lis, err := net.Listen("tcp", p.addr)
+	if err != nil {
+		p.l.Debugf("Waiting for local VictoriaMetrics to stop: %s.", err)
+		return nil
+	}
+
+	server := &http.Server{
+		Handler:  p,
+		ErrorLog: log.New(os.Stderr, "queryProxy: ", 0),
+	}
+	go func() {
+		if err := server.Serve(lis); err != nil && err != http.ErrServerClosed { //nolint:errorlint
+			p.l.Error(err)
+		}
+	}()
+
+	p.l.Infof("Serving queries on %s with external metrics backend.", p.addr)
+	return server
+}
+
+// shutdown gracefully stops serving local VictoriaMetrics address.
+func (p *queryProxy) shutdown(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		p.l.Warn(err)
+	}
+	p.l.Infof("Stopped serving queries on %s.", p.addr)
+}
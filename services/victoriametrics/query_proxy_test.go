@@ -0,0 +1,82 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestQueryProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		username, password, _ := req.BasicAuth()
+		_, _ = rw.Write([]byte("backend " + req.URL.RequestURI() + " " + username + ":" + password))
+	}))
+	defer backend.Close()
+
+	agent := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("vmagent " + req.URL.RequestURI()))
+	}))
+	defer agent.Close()
+
+	baseURL, err := url.Parse("http://127.0.0.1:9090/prometheus/")
+	require.NoError(t, err)
+	p := newQueryProxy(baseURL, agent.URL)
+
+	get := func(t *testing.T, uri string) (int, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest("GET", uri, nil))
+		b, err := ioutil.ReadAll(rec.Body)
+		require.NoError(t, err)
+		return rec.Code, string(b)
+	}
+
+	code, _ := get(t, "/prometheus/api/v1/query?query=up")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	require.NoError(t, p.configure(&models.ExternalVictoriaMetrics{
+		ReadURL:  backend.URL + "/select/0/prometheus",
+		WriteURL: backend.URL + "/insert/0/prometheus/api/v1/write",
+		Username: "pmm",
+		Password: "secret",
+	}))
+
+	code, body := get(t, "/prometheus/api/v1/query?query=up")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "backend /select/0/prometheus/api/v1/query?query=up pmm:secret", body)
+
+	code, body = get(t, "/prometheus/-/reload")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "vmagent /prometheus/-/reload", body)
+
+	code, body = get(t, "/prometheus/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "vmagent /prometheus/health", body)
+
+	require.NoError(t, p.configure(nil))
+	code, _ = get(t, "/prometheus/api/v1/query?query=up")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
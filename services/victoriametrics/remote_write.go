@@ -0,0 +1,57 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	config "github.com/percona/promconfig"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// addRemoteWriteConfigs adds remote_write sections for remote storages configured in settings.
+func addRemoteWriteConfigs(cfg *config.Config, endpoints []*models.RemoteWriteEndpoint) {
+	for _, e := range endpoints {
+		rwc := &config.RemoteWriteConfig{
+			URL:  e.URL,
+			Name: e.Name,
+			HTTPClientConfig: config.HTTPClientConfig{
+				BearerToken: e.BearerToken,
+				TLSConfig: config.TLSConfig{
+					InsecureSkipVerify: e.InsecureSkipVerify,
+				},
+			},
+		}
+		if e.Username != "" {
+			rwc.HTTPClientConfig.BasicAuth = &config.BasicAuth{
+				Username: e.Username,
+				Password: e.Password,
+			}
+		}
+		for _, r := range e.WriteRelabelConfigs {
+			rwc.WriteRelabelConfigs = append(rwc.WriteRelabelConfigs, &config.RelabelConfig{
+				SourceLabels: r.SourceLabels,
+				Separator:    r.Separator,
+				Regex:        r.Regex,
+				Modulus:      r.Modulus,
+				TargetLabel:  r.TargetLabel,
+				Replacement:  r.Replacement,
+				Action:       r.Action,
+			})
+		}
+		cfg.RemoteWriteConfigs = append(cfg.RemoteWriteConfigs, rwc)
+	}
+}
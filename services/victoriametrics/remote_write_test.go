@@ -0,0 +1,68 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"testing"
+
+	config "github.com/percona/promconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func TestAddRemoteWriteConfigs(t *testing.T) {
+	var cfg config.Config
+	addRemoteWriteConfigs(&cfg, []*models.RemoteWriteEndpoint{{
+		Name:     "mimir",
+		URL:      "https://mimir.example.com/api/v1/push",
+		Username: "pmm",
+		Password: "secret",
+		WriteRelabelConfigs: []*models.RemoteWriteRelabel{{
+			SourceLabels: []string{"__name__"},
+			Regex:        "go_.*",
+			Action:       "drop",
+		}},
+	}, {
+		Name:               "vm",
+		URL:                "http://vminsert:8480/insert/0/prometheus/api/v1/write",
+		BearerToken:        "token",
+		InsecureSkipVerify: true,
+	}})
+
+	b, err := yaml.Marshal(cfg.RemoteWriteConfigs)
+	require.NoError(t, err)
+	expected := `
+- url: https://mimir.example.com/api/v1/push
+  write_relabel_configs:
+  - source_labels: [__name__]
+    regex: go_.*
+    action: drop
+  name: mimir
+  basic_auth:
+    username: pmm
+    password: secret
+- url: http://vminsert:8480/insert/0/prometheus/api/v1/write
+  name: vm
+  bearer_token: token
+  tls_config:
+    insecure_skip_verify: true
+`
+	assert.YAMLEq(t, expected, string(b))
+}
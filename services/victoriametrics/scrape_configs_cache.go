@@ -0,0 +1,282 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"sync"
+
+	"github.com/AlekSi/pointer"
+	config "github.com/percona/promconfig"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+// scrapeConfigsCacheKey identifies scrape configs of a single Agent.
+type scrapeConfigsCacheKey struct {
+	agentID   string
+	serviceID string
+}
+
+// newScrapeConfigsCacheKey returns cache key for given Agent.
+func newScrapeConfigsCacheKey(agent *models.Agent) scrapeConfigsCacheKey {
+	return scrapeConfigsCacheKey{
+		agentID:   agent.AgentID,
+		serviceID: pointer.GetString(agent.ServiceID),
+	}
+}
+
+// scrapeConfigsFingerprint contains everything Agent's scrape configs are generated from.
+// Objects are compared by their update times: they are changed on every update.
+type scrapeConfigsFingerprint struct {
+	resolutions models.MetricsResolutions
+	host        string
+	tlsDir      string
+
+	agentUpdatedAt    int64
+	serviceUpdatedAt  int64
+	nodeUpdatedAt     int64
+	hostNodeUpdatedAt int64
+}
+
+// newScrapeConfigsFingerprint returns fingerprint of given scrape configs parameters.
+func newScrapeConfigsFingerprint(s *models.MetricsResolutions, params *scrapeConfigParams, hostNode *models.Node, tlsDir string) scrapeConfigsFingerprint {
+	fp := scrapeConfigsFingerprint{
+		resolutions:    *s,
+		host:           params.host,
+		tlsDir:         tlsDir,
+		agentUpdatedAt: params.agent.UpdatedAt.UnixNano(),
+	}
+	if params.service != nil {
+		fp.serviceUpdatedAt = params.service.UpdatedAt.UnixNano()
+	}
+	if params.node != nil {
+		fp.nodeUpdatedAt = params.node.UpdatedAt.UnixNano()
+	}
+	if hostNode != nil {
+		fp.hostNodeUpdatedAt = hostNode.UpdatedAt.UnixNano()
+	}
+	return fp
+}
+
+type scrapeConfigsCacheEntry struct {
+	fp    scrapeConfigsFingerprint
+	scfgs []*config.ScrapeConfig
+}
+
+// scrapeConfigsCache stores generated scrape configs of Agents,
+// so only scrape configs of changed Agents are regenerated on configuration update.
+// Cached scrape configs are shared between generated configurations and must not be modified.
+// nil cache is valid and does not store anything.
+type scrapeConfigsCache struct {
+	rw      sync.RWMutex
+	entries map[scrapeConfigsCacheKey]*scrapeConfigsCacheEntry
+}
+
+// newScrapeConfigsCache creates new empty cache.
+func newScrapeConfigsCache() *scrapeConfigsCache {
+	return &scrapeConfigsCache{
+		entries: make(map[scrapeConfigsCacheKey]*scrapeConfigsCacheEntry),
+	}
+}
+
+// scrapeConfigs returns scrape configs for given non-RDS Agent, reusing cached ones if parameters were not changed.
+func (c *scrapeConfigsCache) scrapeConfigs(l *logrus.Entry, s *models.MetricsResolutions, params *scrapeConfigParams, hostNode *models.Node, tlsDir string) []*config.ScrapeConfig {
+	key := newScrapeConfigsCacheKey(params.agent)
+	fp := newScrapeConfigsFingerprint(s, params, hostNode, tlsDir)
+	if scfgs, ok := c.get(key, fp); ok {
+		return scfgs
+	}
+
+	scfgs, err := scrapeConfigsForAgent(l, s, params)
+	if err != nil {
+		l.Warnf("Failed to add %s %q, skipping: %s.", params.agent.AgentType, params.agent.AgentID, err)
+	}
+	for _, scfg := range scfgs {
+		setExporterTLS(scfg, params.agent, tlsDir)
+	}
+
+	// do not cache failures, so they are logged again
+	if err == nil {
+		c.set(key, fp, scfgs)
+	}
+	return scfgs
+}
+
+// get returns cached scrape configs for given key if they were generated for the same fingerprint.
+func (c *scrapeConfigsCache) get(key scrapeConfigsCacheKey, fp scrapeConfigsFingerprint) ([]*config.ScrapeConfig, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	e := c.entries[key]
+	if e == nil || e.fp != fp {
+		return nil, false
+	}
+	return e.scfgs, true
+}
+
+// set stores scrape configs for given key and fingerprint.
+func (c *scrapeConfigsCache) set(key scrapeConfigsCacheKey, fp scrapeConfigsFingerprint, scfgs []*config.ScrapeConfig) {
+	if c == nil {
+		return
+	}
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	c.entries[key] = &scrapeConfigsCacheEntry{
+		fp:    fp,
+		scfgs: scfgs,
+	}
+}
+
+// retain removes all entries except given ones.
+func (c *scrapeConfigsCache) retain(keys map[scrapeConfigsCacheKey]struct{}) {
+	if c == nil {
+		return
+	}
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	for key := range c.entries {
+		if _, ok := keys[key]; !ok {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// scrapeConfigsRefs holds Services, Nodes and pmm-agents referenced by Agents.
+type scrapeConfigsRefs struct {
+	q        *reform.Querier
+	services map[string]*models.Service
+	nodes    map[string]*models.Node
+	agents   map[string]*models.Agent
+}
+
+// loadScrapeConfigsRefs loads Services, Nodes and pmm-agents referenced by given Agents with a few queries.
+func loadScrapeConfigsRefs(q *reform.Querier, agents []*models.Agent) (*scrapeConfigsRefs, error) {
+	var serviceIDs, pmmAgentIDs []string
+	for _, agent := range agents {
+		if agent.ServiceID != nil {
+			serviceIDs = append(serviceIDs, *agent.ServiceID)
+		}
+		if agent.PMMAgentID != nil {
+			pmmAgentIDs = append(pmmAgentIDs, *agent.PMMAgentID)
+		}
+	}
+
+	services, err := models.FindServicesByIDs(q, uniqueStrings(serviceIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	pmmAgents, err := models.FindAgentsByIDs(q, uniqueStrings(pmmAgentIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	refs := &scrapeConfigsRefs{
+		q:        q,
+		services: services,
+		nodes:    make(map[string]*models.Node),
+		agents:   make(map[string]*models.Agent, len(pmmAgents)),
+	}
+
+	var nodeIDs []string
+	for _, agent := range agents {
+		nodeIDs = append(nodeIDs, pointer.GetString(agent.NodeID), pointer.GetString(agent.RunsOnNodeID))
+	}
+	for _, service := range services {
+		nodeIDs = append(nodeIDs, service.NodeID)
+	}
+	for _, agent := range pmmAgents {
+		refs.agents[agent.AgentID] = agent
+		nodeIDs = append(nodeIDs, pointer.GetString(agent.RunsOnNodeID))
+	}
+
+	nodes, err := models.FindNodesByIDs(q, uniqueStrings(nodeIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		refs.nodes[node.NodeID] = node
+	}
+
+	return refs, nil
+}
+
+// service returns Service with given ID, querying it if it was not loaded.
+func (r *scrapeConfigsRefs) service(id string) (*models.Service, error) {
+	if s := r.services[id]; s != nil {
+		return s, nil
+	}
+
+	s, err := models.FindServiceByID(r.q, id)
+	if err != nil {
+		return nil, err
+	}
+	r.services[id] = s
+	return s, nil
+}
+
+// node returns Node with given ID, querying it if it was not loaded.
+func (r *scrapeConfigsRefs) node(id string) (*models.Node, error) {
+	if n := r.nodes[id]; n != nil {
+		return n, nil
+	}
+
+	n, err := models.FindNodeByID(r.q, id)
+	if err != nil {
+		return nil, err
+	}
+	r.nodes[id] = n
+	return n, nil
+}
+
+// agent returns Agent with given ID, querying it if it was not loaded.
+func (r *scrapeConfigsRefs) agent(id string) (*models.Agent, error) {
+	if a := r.agents[id]; a != nil {
+		return a, nil
+	}
+
+	a, err := models.FindAgentByID(r.q, id)
+	if err != nil {
+		return nil, err
+	}
+	r.agents[id] = a
+	return a, nil
+}
+
+// uniqueStrings returns non-empty unique strings from given slice.
+func uniqueStrings(s []string) []string {
+	set := make(map[string]struct{}, len(s))
+	res := make([]string, 0, len(s))
+	for _, e := range s {
+		if _, ok := set[e]; ok || e == "" {
+			continue
+		}
+		set[e] = struct{}{}
+		res = append(res, e)
+	}
+	return res
+}
@@ -0,0 +1,137 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package victoriametrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/pmm-managed/models"
+)
+
+func scrapeConfigsCacheTestParams(n int) []*scrapeConfigParams {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	res := make([]*scrapeConfigParams, n)
+	for i := range res {
+		node := &models.Node{
+			NodeID:    fmt.Sprintf("/node_id/%d", i),
+			NodeName:  fmt.Sprintf("node-%d", i),
+			Address:   "1.2.3.4",
+			UpdatedAt: now,
+		}
+		service := &models.Service{
+			ServiceID:   fmt.Sprintf("/service_id/%d", i),
+			ServiceName: fmt.Sprintf("mysql-%d", i),
+			NodeID:      node.NodeID,
+			UpdatedAt:   now,
+		}
+		agent := &models.Agent{
+			AgentID:    fmt.Sprintf("/agent_id/%d", i),
+			AgentType:  models.MySQLdExporterType,
+			ServiceID:  pointer.ToString(service.ServiceID),
+			ListenPort: pointer.ToUint16(12345),
+			UpdatedAt:  now,
+		}
+		res[i] = &scrapeConfigParams{
+			host:    node.Address,
+			node:    node,
+			service: service,
+			agent:   agent,
+		}
+	}
+	return res
+}
+
+func TestScrapeConfigsCache(t *testing.T) {
+	l := logrus.WithField("test", t.Name())
+	s := &models.MetricsResolutions{HR: 5 * time.Second, MR: 10 * time.Second, LR: time.Minute}
+	params := scrapeConfigsCacheTestParams(2)
+	c := newScrapeConfigsCache()
+
+	expected, err := scrapeConfigsForAgent(l, s, params[0])
+	require.NoError(t, err)
+	actual := c.scrapeConfigs(l, s, params[0], nil, "")
+	assert.Equal(t, expected, actual)
+
+	// the same scrape configs are returned for unchanged parameters
+	cached := c.scrapeConfigs(l, s, params[0], nil, "")
+	require.Len(t, cached, len(actual))
+	assert.Same(t, actual[0], cached[0])
+
+	// and regenerated for changed ones
+	params[0].service.UpdatedAt = params[0].service.UpdatedAt.Add(time.Second)
+	params[0].service.ServiceName = "renamed"
+	regenerated := c.scrapeConfigs(l, s, params[0], nil, "")
+	require.Len(t, regenerated, len(actual))
+	assert.NotSame(t, actual[0], regenerated[0])
+	assert.Equal(t, "renamed", regenerated[0].ServiceDiscoveryConfig.StaticConfigs[0].Labels["service_name"])
+
+	s2 := &models.MetricsResolutions{HR: time.Second, MR: 10 * time.Second, LR: time.Minute}
+	assert.NotSame(t, regenerated[0], c.scrapeConfigs(l, s2, params[0], nil, "")[0])
+
+	c.scrapeConfigs(l, s, params[1], nil, "")
+	assert.Len(t, c.entries, 2)
+	c.retain(map[scrapeConfigsCacheKey]struct{}{
+		newScrapeConfigsCacheKey(params[1].agent): {},
+	})
+	assert.Len(t, c.entries, 1)
+
+	// nil cache always regenerates
+	var nilCache *scrapeConfigsCache
+	assert.NotEmpty(t, nilCache.scrapeConfigs(l, s, params[1], nil, ""))
+}
+
+func BenchmarkScrapeConfigsCache(b *testing.B) {
+	l := logrus.NewEntry(logrus.New())
+	l.Logger.SetLevel(logrus.ErrorLevel)
+	s := &models.MetricsResolutions{HR: 5 * time.Second, MR: 10 * time.Second, LR: time.Minute}
+	params := scrapeConfigsCacheTestParams(5000)
+
+	b.Run("Regenerate", func(b *testing.B) {
+		var c *scrapeConfigsCache
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, p := range params {
+				c.scrapeConfigs(l, s, p, nil, "")
+			}
+		}
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		c := newScrapeConfigsCache()
+		for _, p := range params {
+			c.scrapeConfigs(l, s, p, nil, "")
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// one changed Agent per update
+			p := params[i%len(params)]
+			p.agent.UpdatedAt = p.agent.UpdatedAt.Add(time.Second)
+			for _, p := range params {
+				c.scrapeConfigs(l, s, p, nil, "")
+			}
+		}
+	})
+}
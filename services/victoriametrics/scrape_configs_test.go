@@ -913,6 +913,58 @@ func TestScrapeConfig(t *testing.T) {
 			})
 			require.EqualError(t, err, "failed to decode custom labels: unexpected end of JSON input")
 		})
+
+		t.Run("Labels", func(t *testing.T) {
+			node := &models.Node{
+				NodeID:       "/node_id/cc663f36-18ca-40a1-aea9-c6310bb4738d",
+				NodeName:     "node_name",
+				NodeType:     models.GenericNodeType,
+				Region:       pointer.ToString("us-east-1"),
+				AZ:           "us-east-1a",
+				CustomLabels: []byte(`{"team": "db"}`),
+			}
+			service := &models.Service{
+				ServiceID:    "/service_id/014647c3-b2f5-44eb-94f4-d943260a968c",
+				ServiceName:  "postgresql",
+				ServiceType:  models.PostgreSQLServiceType,
+				NodeID:       "/node_id/cc663f36-18ca-40a1-aea9-c6310bb4738d",
+				Environment:  "prod",
+				CustomLabels: []byte(`{"team": "dba"}`),
+			}
+			agent := &models.Agent{
+				AgentID:    "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+				AgentType:  models.PostgresExporterType,
+				ListenPort: pointer.ToUint16(12345),
+			}
+
+			actual, err := scrapeConfigsForPostgresExporter(s, &scrapeConfigParams{
+				host:    "4.5.6.7",
+				node:    node,
+				service: service,
+				agent:   agent,
+			})
+			require.NoError(t, err)
+			require.Len(t, actual, 3)
+
+			expected := map[string]string{
+				"agent_id":     "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+				"agent_type":   "postgres_exporter",
+				"az":           "us-east-1a",
+				"environment":  "prod",
+				"instance":     "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+				"node_id":      "/node_id/cc663f36-18ca-40a1-aea9-c6310bb4738d",
+				"node_name":    "node_name",
+				"node_type":    "generic",
+				"region":       "us-east-1",
+				"service_id":   "/service_id/014647c3-b2f5-44eb-94f4-d943260a968c",
+				"service_name": "postgresql",
+				"service_type": "postgresql",
+				"team":         "dba", // Service labels override Node labels
+			}
+			for _, cfg := range actual {
+				assert.Equal(t, expected, cfg.ServiceDiscoveryConfig.StaticConfigs[0].Labels, "%s", cfg.JobName)
+			}
+		})
 	})
 
 	t.Run("scrapeConfigsForProxySQLExporter", func(t *testing.T) {
@@ -1210,6 +1262,66 @@ func TestScrapeConfig(t *testing.T) {
 			}
 		})
 
+		t.Run("HAProxy", func(t *testing.T) {
+			service := &models.Service{
+				ServiceID:   "/service_id/014647c3-b2f5-44eb-94f4-d943260a968c",
+				ServiceName: "haproxy",
+				ServiceType: models.HAProxyServiceType,
+				NodeID:      "/node_id/cc663f36-18ca-40a1-aea9-c6310bb4738d",
+			}
+			agent := &models.Agent{
+				AgentID:       "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+				AgentType:     models.ExternalExporterType,
+				Username:      pointer.ToString("stats"),
+				Password:      pointer.ToString("secret"),
+				ListenPort:    pointer.ToUint16(8404),
+				MetricsPath:   pointer.ToString("/metrics"),
+				MetricsScheme: pointer.ToString("http"),
+			}
+
+			expected := []*config.ScrapeConfig{{
+				JobName:        "external-exporter_agent_id_75bb30d3-ef4a-4147-97a8-621a996611dd_mr-5s",
+				ScrapeInterval: config.Duration(s.MR),
+				ScrapeTimeout:  scrapeTimeout(s.MR),
+				MetricsPath:    "/metrics",
+				Scheme:         "http",
+				HTTPClientConfig: config.HTTPClientConfig{
+					BasicAuth: &config.BasicAuth{
+						Username: "stats",
+						Password: "secret",
+					},
+				},
+				ServiceDiscoveryConfig: config.ServiceDiscoveryConfig{
+					StaticConfigs: []*config.Group{{
+						Targets: []string{"4.5.6.7:8404"},
+						Labels: map[string]string{
+							"_some_node_label": "foo",
+							"agent_id":         "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+							"agent_type":       "external-exporter",
+							"instance":         "/agent_id/75bb30d3-ef4a-4147-97a8-621a996611dd",
+							"node_id":          "/node_id/cc663f36-18ca-40a1-aea9-c6310bb4738d",
+							"node_name":        "node_name",
+							"service_id":       "/service_id/014647c3-b2f5-44eb-94f4-d943260a968c",
+							"service_name":     "haproxy",
+							"service_type":     "haproxy",
+						},
+					}},
+				},
+			}}
+
+			actual, err := scrapeConfigsForExternalExporter(s, &scrapeConfigParams{
+				host:    "4.5.6.7",
+				node:    node,
+				service: service,
+				agent:   agent,
+			})
+			require.NoError(t, err)
+			require.Len(t, actual, len(expected))
+			for i := 0; i < len(expected); i++ {
+				assertScrapeConfigsEqual(t, expected[i], actual[i])
+			}
+		})
+
 		t.Run("BadCustomLabels", func(t *testing.T) {
 			agent := &models.Agent{
 				CustomLabels: []byte("{"),
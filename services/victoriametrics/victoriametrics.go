@@ -18,24 +18,22 @@
 package victoriametrics
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/AlekSi/pointer"
-	"github.com/percona/pmm/utils/pdeathsig"
 	config "github.com/percona/promconfig"
 	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sys/unix"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"gopkg.in/reform.v1"
 	"gopkg.in/yaml.v3"
 
@@ -67,6 +65,13 @@ type Service struct {
 
 	baseConfigPath string // for testing
 
+	scrapeConfigsCache *scrapeConfigsCache
+	validator          ConfigValidator
+	proxy              *queryProxy
+
+	statusRW sync.RWMutex
+	status   configStatus
+
 	l        *logrus.Entry
 	reloadCh chan struct{}
 }
@@ -78,14 +83,22 @@ func NewVictoriaMetrics(scrapeConfigPath string, db *reform.DB, baseURL string,
 		return nil, errors.WithStack(err)
 	}
 
+	validator, err := parseConfigValidator(params.ConfigValidator)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
-		scrapeConfigPath: scrapeConfigPath,
-		db:               db,
-		baseURL:          u,
-		client:           new(http.Client), // TODO instrument with utils/irt; see vmalert package https://jira.percona.com/browse/PMM-7229
-		baseConfigPath:   params.BaseConfigPath,
-		l:                logrus.WithField("component", "victoriametrics"),
-		reloadCh:         make(chan struct{}, 1),
+		scrapeConfigPath:   scrapeConfigPath,
+		db:                 db,
+		baseURL:            u,
+		client:             new(http.Client), // TODO instrument with utils/irt; see vmalert package https://jira.percona.com/browse/PMM-7229
+		baseConfigPath:     params.BaseConfigPath,
+		scrapeConfigsCache: newScrapeConfigsCache(),
+		validator:          validator,
+		proxy:              newQueryProxy(u, vmAgentURL),
+		l:                  logrus.WithField("component", "victoriametrics"),
+		reloadCh:           make(chan struct{}, 1),
 	}, nil
 }
 
@@ -111,6 +124,8 @@ func (svc *Service) Run(ctx context.Context) {
 		panic("reloadCh should have capacity 1")
 	}
 
+	go svc.proxy.run(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -144,8 +159,9 @@ func (svc *Service) RequestConfigurationUpdate() {
 	}
 }
 
-// updateConfiguration updates VictoriaMetrics configuration.
-func (svc *Service) updateConfiguration(ctx context.Context) error {
+// updateConfiguration updates VictoriaMetrics configuration and query proxy for external metrics backend.
+// In read-only external mode, configuration is written to the external path without reloading.
+func (svc *Service) updateConfiguration(ctx context.Context) (err error) {
 	start := time.Now()
 	defer func() {
 		if dur := time.Since(start); dur > time.Second {
@@ -153,15 +169,55 @@ func (svc *Service) updateConfiguration(ctx context.Context) error {
 		}
 	}()
 
+	settings, err := models.GetSettings(svc.db.Querier)
+	if err != nil {
+		return err
+	}
+	if err = svc.proxy.configure(settings.VictoriaMetrics.External); err != nil {
+		return err
+	}
+
+	externalPath, err := svc.externalConfigPath()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		svc.recordUpdate(externalPath, err)
+	}()
+
 	base := svc.loadBaseConfig()
-	cfg, err := svc.marshalConfig(base)
+	cfg, files, err := svc.marshalConfig(base)
 	if err != nil {
 		return err
 	}
 
+	if err = svc.updateExporterTLSFiles(); err != nil {
+		return err
+	}
+	if err = writeKubernetesSDFiles(svc.l, svc.db.Querier, kubernetesSDDir); err != nil {
+		return err
+	}
+
+	// file_sd files are written before configuration, so it never references missing files
+	if err = writeFileSDFiles(fileSDDir, files); err != nil {
+		return err
+	}
+
+	if externalPath != "" {
+		return svc.writeExternalConfig(ctx, externalPath, cfg)
+	}
 	return svc.configAndReload(ctx, cfg)
 }
 
+// updateExporterTLSFiles writes TLS files used in scrape configuration of exporters.
+func (svc *Service) updateExporterTLSFiles() error {
+	agents, err := models.FindAgentsForScrapeConfig(svc.db.Querier, nil, false)
+	if err != nil {
+		return err
+	}
+	return writeExporterTLSFiles(exporterTLSDir, agents)
+}
+
 // reload asks VictoriaMetrics to reload configuration.
 func (svc *Service) reload(ctx context.Context) error {
 	u := *svc.baseURL
@@ -188,6 +244,39 @@ func (svc *Service) reload(ctx context.Context) error {
 	return nil
 }
 
+// ForceMerge asks VictoriaMetrics to merge data parts of partitions with given name prefix
+// (monthly partitions are named like 2021_08), or of all partitions if prefix is empty.
+// Merging drops data outside of retention period and deleted series, freeing disk space.
+// VictoriaMetrics performs merge in the background after responding.
+func (svc *Service) ForceMerge(ctx context.Context, partitionPrefix string) error {
+	u := *svc.baseURL
+	u.Path = path.Join(u.Path, "internal", "force_merge")
+	if partitionPrefix != "" {
+		u.RawQuery = url.Values{"partition_prefix": []string{partitionPrefix}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	b, err := ioutil.ReadAll(resp.Body)
+	svc.l.Debugf("VM force merge: %s", b)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if resp.StatusCode != 200 {
+		return errors.Errorf("expected 200, got %d: %s", resp.StatusCode, b)
+	}
+	svc.l.Infof("Forced merge of partitions %q started.", partitionPrefix)
+	return nil
+}
+
 // loadBaseConfig returns parsed base configuration file, or empty configuration on error.
 func (svc *Service) loadBaseConfig() *config.Config {
 	buf, err := ioutil.ReadFile(svc.baseConfigPath)
@@ -210,68 +299,91 @@ func (svc *Service) loadBaseConfig() *config.Config {
 }
 
 // marshalConfig marshals VictoriaMetrics configuration.
-func (svc *Service) marshalConfig(base *config.Config) ([]byte, error) {
-	cfg := base
-	if err := svc.populateConfig(cfg); err != nil {
-		return nil, err
-	}
+// It also returns file_sd files referenced by it, if file-based service discovery is enabled.
+func (svc *Service) marshalConfig(base *config.Config) ([]byte, fileSDFiles, error) {
+	var b []byte
+	var files fileSDFiles
+	err := svc.db.InTransaction(func(tx *reform.TX) error {
+		var e error
+		b, files, e = svc.generateConfig(tx.Querier, base)
+		return e
+	})
+	return b, files, err
+}
 
-	b, err := yaml.Marshal(cfg)
+// GenerateConfig returns VictoriaMetrics configuration for the state visible by given querier
+// without writing it and reloading VictoriaMetrics.
+func (svc *Service) GenerateConfig(q *reform.Querier) ([]byte, error) {
+	b, _, err := svc.generateConfig(q, svc.loadBaseConfig())
+	return b, err
+}
+
+// ConfigDiff returns unified diff between the current configuration file
+// and configuration that would be written by the next update.
+// Empty string is returned if there are no pending changes.
+func (svc *Service) ConfigDiff() (string, error) {
+	path, err := svc.configPath()
 	if err != nil {
-		return nil, errors.Wrap(err, "can't marshal VictoriaMetrics configuration file")
+		return "", err
 	}
 
-	b = append([]byte("# Managed by pmm-managed. DO NOT EDIT.\n---\n"), b...)
-
-	return b, nil
-}
+	current, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.WithStack(err)
+	}
 
-// validateConfig validates given configuration with `victoriametrics -dryRun`.
-func (svc *Service) validateConfig(ctx context.Context, cfg []byte) error {
-	f, err := ioutil.TempFile("", "pmm-managed-config-victoriametrics-")
+	pending, _, err := svc.marshalConfig(svc.loadBaseConfig())
 	if err != nil {
-		return errors.WithStack(err)
+		return "", err
 	}
-	if _, err = f.Write(cfg); err != nil {
-		return errors.WithStack(err)
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(current)),
+		B:        difflib.SplitLines(string(pending)),
+		FromFile: path,
+		ToFile:   path + " (pending)",
+		Context:  3,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
 	}
-	defer func() {
-		_ = f.Close()
-		_ = os.Remove(f.Name())
-	}()
+	return diff, nil
+}
 
-	args := []string{"-dryRun", "-promscrape.config", f.Name()}
-	cmd := exec.CommandContext(ctx, "victoriametrics", args...) //nolint:gosec
-	pdeathsig.Set(cmd, unix.SIGKILL)
+// ValidateConfig validates given VictoriaMetrics configuration.
+func (svc *Service) ValidateConfig(ctx context.Context, cfg []byte) error {
+	return svc.validateConfig(ctx, cfg)
+}
 
-	b, err := cmd.CombinedOutput()
+// generateConfig populates base configuration from the database and marshals it.
+// It also returns file_sd files referenced by it, if file-based service discovery is enabled.
+func (svc *Service) generateConfig(q *reform.Querier, base *config.Config) ([]byte, fileSDFiles, error) {
+	cfg := base
+	files, err := svc.populateConfig(q, cfg)
 	if err != nil {
-		svc.l.Errorf("%s", b)
-		s := string(b)
-		if m := checkFailedRE.FindStringSubmatch(s); len(m) == 2 {
-			return status.Error(codes.Aborted, m[1])
-		}
-
-		return errors.Wrap(err, s)
+		return nil, nil, err
 	}
-	svc.l.Debugf("%s", b)
-
-	args = append(args, "-promscrape.config.strictParse", "true")
-	cmd = exec.CommandContext(ctx, "victoriametrics", args...) //nolint:gosec
-	pdeathsig.Set(cmd, unix.SIGKILL)
 
-	b, err = cmd.CombinedOutput()
+	b, err := yaml.Marshal(cfg)
 	if err != nil {
-		s := string(b)
-		if m := checkFailedRE.FindStringSubmatch(s); len(m) == 2 {
-			svc.l.Warnf("VictoriaMetrics scrape configuration contains unsupported params: %s", m[1])
-		} else {
-			svc.l.Warnf("VictoriaMetrics scrape configuration contains unsupported params: %s", b)
-		}
+		return nil, nil, errors.Wrap(err, "can't marshal VictoriaMetrics configuration file")
 	}
-	svc.l.Debugf("%s", b)
 
-	return nil
+	b = append([]byte("# Managed by pmm-managed. DO NOT EDIT.\n---\n"), b...)
+
+	return b, files, nil
+}
+
+// validateConfig validates given configuration with the validator selected for this service.
+func (svc *Service) validateConfig(ctx context.Context, cfg []byte) error {
+	switch svc.configValidator() {
+	case VMAgentConfigValidator:
+		return svc.validateConfigWithBinary(ctx, vmagentPath, cfg)
+	case EmbeddedConfigValidator:
+		return svc.validateConfigEmbedded(cfg)
+	default:
+		return svc.validateConfigWithBinary(ctx, "victoriametrics", cfg)
+	}
 }
 
 // configAndReload saves given VictoriaMetrics configuration to file and reloads VictoriaMetrics.
@@ -287,6 +399,13 @@ func (svc *Service) configAndReload(ctx context.Context, b []byte) error {
 		return errors.WithStack(err)
 	}
 
+	// with file-based service discovery, target changes don't change configuration;
+	// VictoriaMetrics re-reads file_sd files itself
+	if bytes.Equal(oldCfg, b) {
+		svc.l.Debug("Configuration not changed, doing nothing.")
+		return nil
+	}
+
 	// restore old content and reload in case of error
 	var restore bool
 	defer func() {
@@ -318,24 +437,60 @@ func (svc *Service) configAndReload(ctx context.Context, b []byte) error {
 }
 
 // populateConfig adds configuration from the database to cfg.
-func (svc *Service) populateConfig(cfg *config.Config) error {
-	return svc.db.InTransaction(func(tx *reform.TX) error {
-		settings, err := models.GetSettings(tx)
-		if err != nil {
-			return err
-		}
-		s := settings.MetricsResolutions
-		if cfg.GlobalConfig.ScrapeInterval == 0 {
-			cfg.GlobalConfig.ScrapeInterval = config.Duration(s.LR)
-		}
-		if cfg.GlobalConfig.ScrapeTimeout == 0 {
-			cfg.GlobalConfig.ScrapeTimeout = ScrapeTimeout(s.LR)
-		}
-		cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scrapeConfigForVictoriaMetrics(s.HR))
-		cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scrapeConfigForVMAlert(s.HR))
-		AddInternalServicesToScrape(cfg, s, settings.DBaaS.Enabled)
-		return AddScrapeConfigs(svc.l, cfg, tx.Querier, &s, nil, false)
-	})
+// If file-based service discovery is enabled, Agents' targets are moved to returned file_sd files.
+func (svc *Service) populateConfig(q *reform.Querier, cfg *config.Config) (fileSDFiles, error) {
+	settings, err := models.GetSettings(q)
+	if err != nil {
+		return nil, err
+	}
+	s := settings.MetricsResolutions
+	if cfg.GlobalConfig.ScrapeInterval == 0 {
+		cfg.GlobalConfig.ScrapeInterval = config.Duration(s.LR)
+	}
+	if cfg.GlobalConfig.ScrapeTimeout == 0 {
+		cfg.GlobalConfig.ScrapeTimeout = ScrapeTimeout(s.LR)
+	}
+	addExternalLabels(cfg, settings.VictoriaMetrics.ExternalLabels)
+	cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scrapeConfigForVictoriaMetrics(s.HR))
+	cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scrapeConfigForVMAlert(s.HR))
+	AddInternalServicesToScrape(cfg, s, settings.DBaaS.Enabled)
+
+	custom, err := models.FindCustomScrapeConfigs(q)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, scrapeConfigsForCustomJobs(custom)...)
+
+	kubernetes, err := scrapeConfigsForKubernetesJobs(svc.l, q, kubernetesSDDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, kubernetes...)
+
+	addRemoteWriteConfigs(cfg, settings.VictoriaMetrics.RemoteWrite)
+	if err = addScrapeConfigs(svc.l, cfg, q, &s, nil, false, svc.scrapeConfigsCache); err != nil {
+		return nil, err
+	}
+
+	if !settings.VictoriaMetrics.FileSD {
+		return nil, nil
+	}
+	return convertToFileSD(cfg, fileSDDir)
+}
+
+// addExternalLabels adds global external labels from settings to cfg;
+// they override external labels with the same names from the base configuration.
+func addExternalLabels(cfg *config.Config, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	if cfg.GlobalConfig.ExternalLabels == nil {
+		cfg.GlobalConfig.ExternalLabels = make(map[string]string, len(labels))
+	}
+	for name, value := range labels {
+		cfg.GlobalConfig.ExternalLabels[name] = value
+	}
 }
 
 // scrapeConfigForVictoriaMetrics returns scrape config for Victoria Metrics in Prometheus format.
@@ -383,6 +538,8 @@ func (svc *Service) BuildScrapeConfigForVMAgent(pmmAgentID string) ([]byte, erro
 			return err
 		}
 		s := settings.MetricsResolutions
+		// metrics pushed by vmagent should be distinguishable the same way as scraped ones
+		addExternalLabels(&cfg, settings.VictoriaMetrics.ExternalLabels)
 		return AddScrapeConfigs(svc.l, &cfg, tx.Querier, &s, pointer.ToString(pmmAgentID), true)
 	})
 	if e != nil {
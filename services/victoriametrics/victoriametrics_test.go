@@ -76,6 +76,24 @@ func TestVictoriaMetrics(t *testing.T) {
 		check.Equal(string(original), string(actual))
 	})
 
+	t.Run("ConfigDiff", func(t *testing.T) {
+		check := require.New(t)
+		db, svc, original := setup(t)
+		defer teardown(t, db, svc, original)
+
+		diff, err := svc.ConfigDiff()
+		check.NoError(err)
+		check.Empty(diff)
+
+		check.NoError(ioutil.WriteFile(configPath, []byte("# edited\n"), 0o600))
+		diff, err = svc.ConfigDiff()
+		check.NoError(err)
+		check.Contains(diff, "--- "+configPath+"\n")
+		check.Contains(diff, "+++ "+configPath+" (pending)\n")
+		check.Contains(diff, "-# edited\n")
+		check.Contains(diff, "+# Managed by pmm-managed. DO NOT EDIT.\n")
+	})
+
 	t.Run("Normal", func(t *testing.T) {
 		check := require.New(t)
 		db, svc, original := setup(t)
@@ -709,7 +727,7 @@ scrape_configs:
           labels:
             instance: pmm-server
 `) + "\n"
-	newcfg, err := svc.marshalConfig(svc.loadBaseConfig())
+	newcfg, _, err := svc.marshalConfig(svc.loadBaseConfig())
 	assert.NoError(t, err)
 	assert.Equal(t, expected, string(newcfg), "actual:\n%s", newcfg)
 }
@@ -28,17 +28,20 @@ import (
 	"github.com/percona/pmm-managed/utils/validators"
 )
 
-const externalRulesFile = "/srv/prometheus/rules/pmm.rules.yml"
+// ExternalRulesFile is a path of user-provided VMAlert rules file.
+const ExternalRulesFile = "/srv/prometheus/rules/pmm.rules.yml"
 
 // ExternalRules contains all logic related to alerting rules files.
 type ExternalRules struct {
-	l *logrus.Entry
+	l   *logrus.Entry
+	dir string
 }
 
 // NewExternalRules creates new ExternalRules instance.
 func NewExternalRules() *ExternalRules {
 	return &ExternalRules{
-		l: logrus.WithField("component", "external_rules"),
+		l:   logrus.WithField("component", "external_rules"),
+		dir: ExternalRulesDir,
 	}
 }
 
@@ -53,7 +56,7 @@ func (s *ExternalRules) ValidateRules(ctx context.Context, rules string) error {
 
 // ReadRules reads current rules from FS.
 func (s *ExternalRules) ReadRules() (string, error) {
-	b, err := ioutil.ReadFile(externalRulesFile)
+	b, err := ioutil.ReadFile(ExternalRulesFile)
 	if err != nil && !os.IsNotExist(err) {
 		return "", err
 	}
@@ -62,10 +65,10 @@ func (s *ExternalRules) ReadRules() (string, error) {
 
 // RemoveRulesFile removes rules file from FS.
 func (s *ExternalRules) RemoveRulesFile() error {
-	return os.Remove(externalRulesFile)
+	return os.Remove(ExternalRulesFile)
 }
 
 // WriteRules writes rules to file.
 func (s *ExternalRules) WriteRules(rules string) error {
-	return ioutil.WriteFile(externalRulesFile, []byte(rules), 0o644) //nolint:gosec
+	return ioutil.WriteFile(ExternalRulesFile, []byte(rules), 0o644) //nolint:gosec
 }
@@ -0,0 +1,182 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package vmalert
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExternalRulesDir is a directory of user-provided VMAlert rules files.
+// VMAlert loads all *.yml files from it.
+const ExternalRulesDir = "/srv/prometheus/rules"
+
+const rulesFileExt = ".yml"
+
+// rulesFileNameRE matches valid rules file names (without extension).
+// Dots are not allowed, so names can't clash with ExternalRulesFile managed by settings.
+var rulesFileNameRE = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,100}$`)
+
+// RulesFile represents user-provided rules file.
+type RulesFile struct {
+	Name       string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// rulesFilePath returns path of the rules file with given name.
+func (s *ExternalRules) rulesFilePath(name string) (string, error) {
+	if !rulesFileNameRE.MatchString(name) {
+		return "", status.Errorf(codes.InvalidArgument, "Invalid rules file name %q: only letters, digits, '_' and '-' are allowed.", name)
+	}
+	return filepath.Join(s.dir, name+rulesFileExt), nil
+}
+
+// ListRulesFiles returns all user-provided rules files sorted by name.
+func (s *ExternalRules) ListRulesFiles() ([]*RulesFile, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*RulesFile{}, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]*RulesFile, 0, len(infos))
+	for _, info := range infos {
+		name := strings.TrimSuffix(info.Name(), rulesFileExt)
+		if !info.Mode().IsRegular() || name == info.Name() || !rulesFileNameRE.MatchString(name) {
+			continue
+		}
+
+		res = append(res, &RulesFile{
+			Name:       name,
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime().UTC(),
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// ReadRulesFile returns content of the rules file with given name.
+func (s *ExternalRules) ReadRulesFile(name string) (string, error) {
+	path, err := s.rulesFilePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", status.Errorf(codes.NotFound, "Rules file %q not found.", name)
+		}
+		return "", errors.WithStack(err)
+	}
+	return string(b), nil
+}
+
+// WriteRulesFile validates rules and writes them to the rules file with given name,
+// replacing existing file, if any.
+func (s *ExternalRules) WriteRulesFile(ctx context.Context, name, rules string) error {
+	path, err := s.rulesFilePath(name)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(rules) == "" {
+		return status.Error(codes.InvalidArgument, "Empty rules.")
+	}
+	if err = s.ValidateRules(ctx, rules); err != nil {
+		return err
+	}
+
+	// write to temporary file and rename it to avoid loading partially written file
+	f, err := ioutil.TempFile(s.dir, "."+name+"-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	_, err = f.WriteString(rules)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = os.Chmod(f.Name(), 0o644); err != nil { //nolint:gosec
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(f.Name(), path))
+}
+
+// DeleteRulesFile removes the rules file with given name.
+func (s *ExternalRules) DeleteRulesFile(name string) error {
+	path, err := s.rulesFilePath(name)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return status.Errorf(codes.NotFound, "Rules file %q not found.", name)
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ListRulesFiles returns all user-provided rules files.
+func (svc *Service) ListRulesFiles() ([]*RulesFile, error) {
+	return svc.externalRules.ListRulesFiles()
+}
+
+// GetRulesFile returns content of the user-provided rules file.
+func (svc *Service) GetRulesFile(name string) (string, error) {
+	return svc.externalRules.ReadRulesFile(name)
+}
+
+// PutRulesFile validates and uploads the user-provided rules file, then requests VMAlert reload.
+func (svc *Service) PutRulesFile(ctx context.Context, name, rules string) error {
+	if err := svc.externalRules.WriteRulesFile(ctx, name, rules); err != nil {
+		return err
+	}
+	svc.RequestConfigurationUpdate()
+	return nil
+}
+
+// DeleteRulesFile removes the user-provided rules file, then requests VMAlert reload.
+func (svc *Service) DeleteRulesFile(name string) error {
+	if err := svc.externalRules.DeleteRulesFile(name); err != nil {
+		return err
+	}
+	svc.RequestConfigurationUpdate()
+	return nil
+}
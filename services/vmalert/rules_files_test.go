@@ -0,0 +1,92 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package vmalert
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/utils/tests"
+)
+
+func TestRulesFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vmalert-rules-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	rules := NewExternalRules()
+	rules.dir = dir
+
+	t.Run("Empty", func(t *testing.T) {
+		files, err := rules.ListRulesFiles()
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		for _, name := range []string{"b.yml", "a-1.yml", "pmm.rules.yml", "notes.txt", ".tmp-123"} {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("groups: []\n"), 0o644)) //nolint:gosec
+		}
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "c.yml"), 0o755)) //nolint:gosec
+
+		files, err := rules.ListRulesFiles()
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		assert.Equal(t, "a-1", files[0].Name)
+		assert.Equal(t, "b", files[1].Name)
+		assert.Equal(t, int64(11), files[1].Size)
+
+		content, err := rules.ReadRulesFile("b")
+		require.NoError(t, err)
+		assert.Equal(t, "groups: []\n", content)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, rules.DeleteRulesFile("b"))
+		_, err := os.Stat(filepath.Join(dir, "b.yml"))
+		assert.True(t, os.IsNotExist(err))
+
+		err = rules.DeleteRulesFile("b")
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `Rules file "b" not found.`), err)
+
+		_, err = rules.ReadRulesFile("b")
+		tests.AssertGRPCError(t, status.New(codes.NotFound, `Rules file "b" not found.`), err)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "pmm.rules", "../etc", "a/b"} {
+			_, err := rules.ReadRulesFile(name)
+			tests.AssertGRPCErrorRE(t, codes.InvalidArgument, `Invalid rules file name`, err)
+
+			err = rules.WriteRulesFile(context.Background(), name, "groups: []")
+			tests.AssertGRPCErrorRE(t, codes.InvalidArgument, `Invalid rules file name`, err)
+		}
+	})
+
+	t.Run("EmptyRules", func(t *testing.T) {
+		err := rules.WriteRulesFile(context.Background(), "empty", " \n")
+		tests.AssertGRPCError(t, status.New(codes.InvalidArgument, "Empty rules."), err)
+	})
+}
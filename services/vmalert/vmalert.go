@@ -19,17 +19,20 @@ package vmalert
 
 import (
 	"context"
+	"crypto/tls"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 
+	"github.com/percona/pmm-managed/models"
 	"github.com/percona/pmm-managed/utils/irt"
 )
 
@@ -40,13 +43,19 @@ const (
 
 // Service is responsible for interactions with victoria metrics.
 type Service struct {
-	baseURL       *url.URL
+	defaultURL    *url.URL
 	client        *http.Client
 	externalRules *ExternalRules
 	irtm          prom.Collector
 
 	l        *logrus.Entry
 	reloadCh chan struct{}
+
+	rw        sync.RWMutex
+	baseURL   *url.URL
+	transport http.RoundTripper
+	username  string
+	password  string
 }
 
 // NewVMAlert creates new Victoria Metrics Alert service.
@@ -56,30 +65,100 @@ func NewVMAlert(externalRules *ExternalRules, baseURL string) (*Service, error)
 		return nil, errors.WithStack(err)
 	}
 
-	var t http.RoundTripper = &http.Transport{
+	svc := &Service{
+		defaultURL:    u,
+		externalRules: externalRules,
+		l:             logrus.WithField("component", "vmalert"),
+		reloadCh:      make(chan struct{}, 1),
+		baseURL:       u,
+		transport:     newTransport(nil),
+	}
+
+	var t http.RoundTripper = roundTripperFunc(svc.roundTrip)
+	if logrus.GetLevel() >= logrus.TraceLevel {
+		t = irt.WithLogger(t, logrus.WithField("component", "vmalert/client").Tracef)
+	}
+	t, svc.irtm = irt.WithMetrics(t, "vmalert")
+	svc.client = &http.Client{
+		Transport: t,
+	}
+
+	return svc, nil
+}
+
+// newTransport returns HTTP transport with given TLS configuration.
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   3 * time.Second,
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
+		TLSClientConfig:       tlsConfig,
 		MaxIdleConns:          1,
 		IdleConnTimeout:       90 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
-	if logrus.GetLevel() >= logrus.TraceLevel {
-		t = irt.WithLogger(t, logrus.WithField("component", "vmalert/client").Tracef)
+}
+
+// roundTripperFunc is an adapter to allow the use of ordinary functions as HTTP round trippers.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// roundTrip sends request using current endpoint transport and credentials.
+func (svc *Service) roundTrip(req *http.Request) (*http.Response, error) {
+	svc.rw.RLock()
+	t, username, password := svc.transport, svc.username, svc.password
+	svc.rw.RUnlock()
+
+	if username != "" {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(username, password)
 	}
-	t, irtm := irt.WithMetrics(t, "vmalert")
+	return t.RoundTrip(req)
+}
 
-	return &Service{
-		externalRules: externalRules,
-		baseURL:       u,
-		client: &http.Client{
-			Transport: t,
-		},
-		l:        logrus.WithField("component", "vmalert"),
-		irtm:     irtm,
-		reloadCh: make(chan struct{}, 1),
-	}, nil
+// SetEndpoint configures remote VMAlert endpoint, or switches back to the local one if endpoint is nil.
+func (svc *Service) SetEndpoint(endpoint *models.AlertingEndpoint) error {
+	u := svc.defaultURL
+	t := newTransport(nil)
+	var username, password string
+	if endpoint != nil {
+		var err error
+		if u, err = url.Parse(endpoint.URL); err != nil {
+			return errors.WithStack(err)
+		}
+		tlsConfig, err := endpoint.TLSConfig()
+		if err != nil {
+			return err
+		}
+		t = newTransport(tlsConfig)
+		username, password = endpoint.Username, endpoint.Password
+	}
+
+	svc.rw.Lock()
+	old := svc.transport
+	svc.baseURL, svc.transport, svc.username, svc.password = u, t, username, password
+	svc.rw.Unlock()
+
+	if old, ok := old.(*http.Transport); ok {
+		old.CloseIdleConnections()
+	}
+	svc.l.Infof("Using VMAlert at %s.", u.Redacted())
+	return nil
+}
+
+// endpointURL returns URL of given path relative to the current base URL.
+func (svc *Service) endpointURL(elem ...string) string {
+	svc.rw.RLock()
+	u := *svc.baseURL
+	svc.rw.RUnlock()
+
+	u.Path = path.Join(append([]string{u.Path}, elem...)...)
+	return u.String()
 }
 
 // Run runs VMAlert configuration update loop until ctx is canceled.
@@ -150,9 +229,7 @@ func (svc *Service) updateConfiguration(ctx context.Context) error {
 
 // reload asks VMAlert to reload configuration.
 func (svc *Service) reload(ctx context.Context) error {
-	u := *svc.baseURL
-	u.Path = path.Join(u.Path, "-", "reload")
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", svc.endpointURL("-", "reload"), nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -176,9 +253,7 @@ func (svc *Service) reload(ctx context.Context) error {
 
 // IsReady verifies that VMAlert works.
 func (svc *Service) IsReady(ctx context.Context) error {
-	u := *svc.baseURL
-	u.Path = path.Join(u.Path, "health")
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", svc.endpointURL("health"), nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -19,6 +19,9 @@ package vmalert
 import (
 	"context"
 	"database/sql"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -82,3 +85,65 @@ groups:
 		check.NoError(svc.updateConfiguration(context.Background()))
 	})
 }
+
+func TestSetEndpoint(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if req.URL.Path != "/vmalert/health" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = rw.Write([]byte("OK"))
+	}))
+	defer ts.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+
+	svc, err := NewVMAlert(NewExternalRules(), "http://127.0.0.1:8880/")
+	require.NoError(t, err)
+
+	t.Run("Normal", func(t *testing.T) {
+		require.NoError(t, svc.SetEndpoint(&models.AlertingEndpoint{
+			URL:      ts.URL + "/vmalert/",
+			Username: "user",
+			Password: "secret",
+			CACert:   caCert,
+		}))
+		assert.NoError(t, svc.IsReady(context.Background()))
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		require.NoError(t, svc.SetEndpoint(&models.AlertingEndpoint{
+			URL:      ts.URL + "/vmalert/",
+			Username: "user",
+			Password: "wrong",
+			CACert:   caCert,
+		}))
+		assert.EqualError(t, svc.IsReady(context.Background()), "expected 200, got 401")
+	})
+
+	t.Run("UnknownCA", func(t *testing.T) {
+		require.NoError(t, svc.SetEndpoint(&models.AlertingEndpoint{
+			URL:      ts.URL + "/vmalert/",
+			Username: "user",
+			Password: "secret",
+		}))
+		assert.Error(t, svc.IsReady(context.Background()))
+	})
+
+	t.Run("InvalidCA", func(t *testing.T) {
+		err := svc.SetEndpoint(&models.AlertingEndpoint{
+			URL:    ts.URL,
+			CACert: "invalid",
+		})
+		assert.EqualError(t, err, "failed to parse CA certificate")
+	})
+
+	t.Run("Local", func(t *testing.T) {
+		require.NoError(t, svc.SetEndpoint(nil))
+		assert.Equal(t, "http://127.0.0.1:8880/health", svc.endpointURL("health"))
+	})
+}
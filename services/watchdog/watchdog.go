@@ -0,0 +1,233 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package watchdog restarts PMM Server components failing health checks.
+package watchdog
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	checkTimeout = 10 * time.Second
+
+	// At most maxRestarts restarts of a single component are performed during restartWindow.
+	restartWindow = time.Hour
+	maxRestarts   = 3
+
+	// maximal number of stored incidents
+	maxHistory = 1000
+)
+
+// IncidentType represents watchdog incident type.
+type IncidentType string
+
+// Incident types.
+const (
+	CheckFailedIncident        IncidentType = "check_failed"
+	RecoveredIncident          IncidentType = "recovered"
+	RestartedIncident          IncidentType = "restarted"
+	RestartFailedIncident      IncidentType = "restart_failed"
+	RestartRateLimitedIncident IncidentType = "restart_rate_limited"
+)
+
+// Incident represents a single component health incident.
+type Incident struct {
+	Time      time.Time    `json:"time"`
+	Component string       `json:"component"`
+	Type      IncidentType `json:"type"`
+	Error     string       `json:"error,omitempty"`
+	// Number of consecutive failed checks.
+	Failures int `json:"failures,omitempty"`
+}
+
+// Component represents PMM Server component checked by watchdog.
+type Component struct {
+	// Component and supervisord program name.
+	Name string
+	// Check returns an error if component is not healthy, e.g. IsReady method.
+	Check func(ctx context.Context) error
+}
+
+// componentState contains component's checks and restarts state.
+type componentState struct {
+	failures int
+	restarts []time.Time
+}
+
+// Watchdog periodically checks PMM Server components and restarts them via supervisord
+// after a given number of consecutive failed checks.
+type Watchdog struct {
+	supervisord      supervisordService
+	components       []Component
+	failureThreshold int
+	l                *logrus.Entry
+
+	states map[string]*componentState
+
+	rw      sync.RWMutex
+	history []*Incident
+}
+
+// New creates new watchdog for given components.
+// Component is restarted after failureThreshold consecutive failed checks.
+func New(supervisord supervisordService, failureThreshold int, components ...Component) *Watchdog {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	states := make(map[string]*componentState, len(components))
+	for _, c := range components {
+		states[c.Name] = new(componentState)
+	}
+
+	return &Watchdog{
+		supervisord:      supervisord,
+		components:       components,
+		failureThreshold: failureThreshold,
+		l:                logrus.WithField("component", "watchdog"),
+		states:           states,
+	}
+}
+
+// Run checks components with given interval until context is canceled.
+// Zero interval disables watchdog.
+func (w *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		w.l.Info("Watchdog is disabled.")
+		return
+	}
+
+	w.l.Info("Starting...")
+	defer w.l.Info("Done.")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// pmm-update restarts components itself
+		if w.supervisord.UpdateRunning() {
+			w.l.Debug("Update is running, skipping checks.")
+			continue
+		}
+
+		w.check(ctx, time.Now())
+	}
+}
+
+// check checks all components and restarts unhealthy ones.
+func (w *Watchdog) check(ctx context.Context, now time.Time) {
+	for _, c := range w.components {
+		if ctx.Err() != nil {
+			return
+		}
+
+		cCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := c.Check(cCtx)
+		cancel()
+
+		w.handleCheckResult(c.Name, err, now)
+	}
+}
+
+// handleCheckResult updates component state with check result, records incidents,
+// and restarts component if needed.
+func (w *Watchdog) handleCheckResult(name string, err error, now time.Time) {
+	st := w.states[name]
+
+	if err == nil {
+		if st.failures != 0 {
+			w.l.Infof("%s recovered after %d failed check(s).", name, st.failures)
+			w.record(&Incident{Time: now, Component: name, Type: RecoveredIncident, Failures: st.failures})
+			st.failures = 0
+		}
+		return
+	}
+
+	st.failures++
+	w.l.Warnf("%s check failed (%d/%d): %s.", name, st.failures, w.failureThreshold, err)
+	if st.failures == 1 {
+		w.record(&Incident{Time: now, Component: name, Type: CheckFailedIncident, Error: err.Error(), Failures: st.failures})
+	}
+	if st.failures < w.failureThreshold {
+		return
+	}
+
+	// give component time to start after restart (or after rate limit record) before the next attempt
+	failures := st.failures
+	st.failures = 0
+
+	restarts := st.restarts[:0]
+	for _, t := range st.restarts {
+		if now.Sub(t) < restartWindow {
+			restarts = append(restarts, t)
+		}
+	}
+	st.restarts = restarts
+	if len(st.restarts) >= maxRestarts {
+		w.l.Errorf("%s was restarted %d times during the last %s, not restarting it again.", name, len(st.restarts), restartWindow)
+		w.record(&Incident{Time: now, Component: name, Type: RestartRateLimitedIncident, Error: err.Error(), Failures: failures})
+		return
+	}
+
+	st.restarts = append(st.restarts, now)
+	if e := w.supervisord.RestartProgram(name); e != nil {
+		w.l.Errorf("Failed to restart %s: %+v.", name, e)
+		w.record(&Incident{Time: now, Component: name, Type: RestartFailedIncident, Error: e.Error(), Failures: failures})
+		return
+	}
+	w.l.Warnf("%s restarted after %d failed checks.", name, failures)
+	w.record(&Incident{Time: now, Component: name, Type: RestartedIncident, Error: err.Error(), Failures: failures})
+}
+
+// record adds incident to the history.
+func (w *Watchdog) record(incident *Incident) {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+
+	w.history = append(w.history, incident)
+	if len(w.history) > maxHistory {
+		w.history = w.history[len(w.history)-maxHistory:]
+	}
+}
+
+// History returns recorded incidents, optionally filtered by component name and time, oldest first.
+func (w *Watchdog) History(component string, since time.Time) []*Incident {
+	w.rw.RLock()
+	defer w.rw.RUnlock()
+
+	res := make([]*Incident, 0, len(w.history))
+	for _, incident := range w.history {
+		if component != "" && incident.Component != component {
+			continue
+		}
+		if incident.Time.Before(since) {
+			continue
+		}
+		res = append(res, incident)
+	}
+	return res
+}
@@ -0,0 +1,121 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package watchdog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func incidentTypes(incidents []*Incident) []IncidentType {
+	res := make([]IncidentType, len(incidents))
+	for i, incident := range incidents {
+		res[i] = incident.Type
+	}
+	return res
+}
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("RestartAndRecover", func(t *testing.T) {
+		var checkErr error
+		s := new(mockSupervisordService)
+		s.Test(t)
+		defer s.AssertExpectations(t)
+		s.On("RestartProgram", "vmalert").Return(nil).Once()
+
+		w := New(s, 2, Component{
+			Name:  "vmalert",
+			Check: func(context.Context) error { return checkErr },
+		})
+
+		w.check(ctx, now)
+		assert.Empty(t, w.History("", time.Time{}))
+
+		checkErr = errors.New("connection refused")
+		w.check(ctx, now.Add(time.Minute))
+		w.check(ctx, now.Add(2*time.Minute))
+
+		checkErr = nil
+		w.check(ctx, now.Add(3*time.Minute))
+
+		checkErr = errors.New("connection refused")
+		w.check(ctx, now.Add(4*time.Minute))
+		checkErr = nil
+		w.check(ctx, now.Add(5*time.Minute))
+
+		history := w.History("", time.Time{})
+		assert.Equal(t, []IncidentType{
+			CheckFailedIncident, RestartedIncident,
+			CheckFailedIncident, RecoveredIncident,
+		}, incidentTypes(history))
+		assert.Equal(t, "connection refused", history[1].Error)
+		assert.Equal(t, 2, history[1].Failures)
+
+		assert.Len(t, w.History("vmalert", now.Add(4*time.Minute)), 2)
+		assert.Empty(t, w.History("alertmanager", time.Time{}))
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		s := new(mockSupervisordService)
+		s.Test(t)
+		defer s.AssertExpectations(t)
+		s.On("RestartProgram", "alertmanager").Return(nil).Times(maxRestarts + 1)
+
+		w := New(s, 1, Component{
+			Name:  "alertmanager",
+			Check: func(context.Context) error { return errors.New("not ready") },
+		})
+
+		for i := 0; i < maxRestarts+1; i++ {
+			w.check(ctx, now.Add(time.Duration(i)*time.Minute))
+		}
+		history := w.History("", time.Time{})
+		// each failed check after restart is a new incident
+		require.Len(t, history, 2*(maxRestarts+1))
+		assert.Equal(t, RestartRateLimitedIncident, history[len(history)-1].Type)
+
+		// old restarts are out of the window
+		w.check(ctx, now.Add(restartWindow+time.Minute))
+		history = w.History("", time.Time{})
+		assert.Equal(t, RestartedIncident, history[len(history)-1].Type)
+	})
+
+	t.Run("RestartFailed", func(t *testing.T) {
+		s := new(mockSupervisordService)
+		s.Test(t)
+		defer s.AssertExpectations(t)
+		s.On("RestartProgram", "grafana").Return(errors.New("supervisorctl not found")).Once()
+
+		w := New(s, 1, Component{
+			Name:  "grafana",
+			Check: func(context.Context) error { return errors.New("not ready") },
+		})
+
+		w.check(ctx, now)
+		history := w.History("", time.Time{})
+		assert.Equal(t, []IncidentType{CheckFailedIncident, RestartFailedIncident}, incidentTypes(history))
+		assert.Equal(t, "supervisorctl not found", history[1].Error)
+	})
+}
@@ -0,0 +1,57 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+func init() {
+	register("de", map[string]string{
+		"Internal server error.":      "Interner Serverfehler.",
+		"Authorization error.":        "Autorisierungsfehler.",
+		"Service %s is disabled.":     "Dienst %s ist deaktiviert.",
+		"Update is already running.":  "Eine Aktualisierung läuft bereits.",
+		"Unknown type: %s":            "Unbekannter Typ: %s",
+		"Invalid label name %q.":      "Ungültiger Labelname %q.",
+		"Invalid cron expression: %v": "Ungültiger Cron-Ausdruck: %v",
+
+		"Empty Node ID.":    "Leere Node-ID.",
+		"Empty Node name.":  "Leerer Node-Name.",
+		"Empty Service ID.": "Leere Service-ID.",
+		"Empty Agent ID.":   "Leere Agent-ID.",
+
+		"Node with ID %q not found.":          "Node mit ID %q nicht gefunden.",
+		"Service with ID %q not found.":       "Service mit ID %q nicht gefunden.",
+		"Agent with ID %q not found.":         "Agent mit ID %q nicht gefunden.",
+		"Artifact with ID %q not found.":      "Artefakt mit ID %q nicht gefunden.",
+		"ScheduledTask with ID %q not found.": "Geplante Aufgabe mit ID %q nicht gefunden.",
+		"Operation with ID %q not found.":     "Vorgang mit ID %q nicht gefunden.",
+		"Restore with ID %q not found.":       "Wiederherstellung mit ID %q nicht gefunden.",
+		"Channel with ID %q not found.":       "Kanal mit ID %q nicht gefunden.",
+		"Rule with ID %q not found.":          "Regel mit ID %q nicht gefunden.",
+
+		"Node with name %q already exists.":     "Node mit Namen %q existiert bereits.",
+		"Service with name %q already exists.":  "Service mit Namen %q existiert bereits.",
+		"Artifact with name %q already exists.": "Artefakt mit Namen %q existiert bereits.",
+		"Location with name %q already exists.": "Speicherort mit Namen %q existiert bereits.",
+
+		"Node with ID %q has agents.":    "Node mit ID %q hat Agenten.",
+		"Node with ID %q has services.":  "Node mit ID %q hat Services.",
+		"Service with ID %q has agents.": "Service mit ID %q hat Agenten.",
+
+		"Artifact with ID %q is on legal hold.":                         "Artefakt mit ID %q unterliegt einer Aufbewahrungssperre.",
+		"Previous run is still executing.":                              "Die vorherige Ausführung läuft noch.",
+		"Scheduled tasks are executed by another pmm-managed instance.": "Geplante Aufgaben werden von einer anderen pmm-managed-Instanz ausgeführt.",
+	})
+}
@@ -0,0 +1,57 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+func init() {
+	register("es", map[string]string{
+		"Internal server error.":      "Error interno del servidor.",
+		"Authorization error.":        "Error de autorización.",
+		"Service %s is disabled.":     "El servicio %s está deshabilitado.",
+		"Update is already running.":  "Ya hay una actualización en curso.",
+		"Unknown type: %s":            "Tipo desconocido: %s",
+		"Invalid label name %q.":      "Nombre de etiqueta no válido %q.",
+		"Invalid cron expression: %v": "Expresión cron no válida: %v",
+
+		"Empty Node ID.":    "ID de nodo vacío.",
+		"Empty Node name.":  "Nombre de nodo vacío.",
+		"Empty Service ID.": "ID de servicio vacío.",
+		"Empty Agent ID.":   "ID de agente vacío.",
+
+		"Node with ID %q not found.":          "No se encontró el nodo con ID %q.",
+		"Service with ID %q not found.":       "No se encontró el servicio con ID %q.",
+		"Agent with ID %q not found.":         "No se encontró el agente con ID %q.",
+		"Artifact with ID %q not found.":      "No se encontró el artefacto con ID %q.",
+		"ScheduledTask with ID %q not found.": "No se encontró la tarea programada con ID %q.",
+		"Operation with ID %q not found.":     "No se encontró la operación con ID %q.",
+		"Restore with ID %q not found.":       "No se encontró la restauración con ID %q.",
+		"Channel with ID %q not found.":       "No se encontró el canal con ID %q.",
+		"Rule with ID %q not found.":          "No se encontró la regla con ID %q.",
+
+		"Node with name %q already exists.":     "Ya existe un nodo con el nombre %q.",
+		"Service with name %q already exists.":  "Ya existe un servicio con el nombre %q.",
+		"Artifact with name %q already exists.": "Ya existe un artefacto con el nombre %q.",
+		"Location with name %q already exists.": "Ya existe una ubicación con el nombre %q.",
+
+		"Node with ID %q has agents.":    "El nodo con ID %q tiene agentes.",
+		"Node with ID %q has services.":  "El nodo con ID %q tiene servicios.",
+		"Service with ID %q has agents.": "El servicio con ID %q tiene agentes.",
+
+		"Artifact with ID %q is on legal hold.":                         "El artefacto con ID %q está bajo retención legal.",
+		"Previous run is still executing.":                              "La ejecución anterior todavía está en curso.",
+		"Scheduled tasks are executed by another pmm-managed instance.": "Las tareas programadas las ejecuta otra instancia de pmm-managed.",
+	})
+}
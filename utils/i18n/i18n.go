@@ -0,0 +1,220 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// Package i18n provides localization of user-facing error and status messages.
+//
+// Messages are translated by message catalogs keyed by English format strings, as they are passed
+// to status.Errorf and similar functions. Formatted values are preserved as is. Messages without
+// translation to the requested language are returned in English.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultLanguage is a language of original messages.
+const DefaultLanguage = "en"
+
+// verbRE matches fmt verbs used in message format strings.
+var verbRE = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z]`)
+
+// entry is a compiled catalog entry.
+type entry struct {
+	re          *regexp.Regexp
+	translation []string // translated format split by verbs; len(translation) == number of verbs + 1
+}
+
+// catalog is a compiled message catalog of a single language.
+type catalog struct {
+	exact    map[string]string
+	patterns []entry
+}
+
+var catalogs = make(map[string]*catalog)
+
+// register compiles messages of the given language and adds them to catalogs.
+// It panics on invalid messages, so it should be called only from init functions.
+func register(lang string, messages map[string]string) {
+	c := &catalog{
+		exact: make(map[string]string),
+	}
+
+	formats := make([]string, 0, len(messages))
+	for format := range messages {
+		formats = append(formats, format)
+	}
+	// longer formats are more specific, try them first
+	sort.Slice(formats, func(i, j int) bool {
+		if len(formats[i]) != len(formats[j]) {
+			return len(formats[i]) > len(formats[j])
+		}
+		return formats[i] < formats[j]
+	})
+
+	for _, format := range formats {
+		translation := messages[format]
+		verbs := verbRE.FindAllStringIndex(format, -1)
+		if len(verbs) == 0 {
+			c.exact[format] = translation
+			continue
+		}
+
+		if n := len(verbRE.FindAllStringIndex(translation, -1)); n != len(verbs) {
+			panic(fmt.Sprintf("i18n: %s: %q has %d verbs, but translation has %d", lang, format, len(verbs), n))
+		}
+
+		var pattern strings.Builder
+		pattern.WriteString("^")
+		var start int
+		for _, v := range verbs {
+			pattern.WriteString(regexp.QuoteMeta(format[start:v[0]]))
+			pattern.WriteString("(.*?)")
+			start = v[1]
+		}
+		pattern.WriteString(regexp.QuoteMeta(format[start:]))
+		pattern.WriteString("$")
+
+		c.patterns = append(c.patterns, entry{
+			re:          regexp.MustCompile(pattern.String()),
+			translation: verbRE.Split(translation, -1),
+		})
+	}
+
+	catalogs[lang] = c
+}
+
+// Languages returns supported languages, including default one, sorted.
+func Languages() []string {
+	res := []string{DefaultLanguage}
+	for lang := range catalogs {
+		res = append(res, lang)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Localize returns message translated to the given language,
+// or the original message if language or message translation is not supported.
+func Localize(lang, msg string) string {
+	c := catalogs[lang]
+	if c == nil {
+		return msg
+	}
+
+	if t, ok := c.exact[msg]; ok {
+		return t
+	}
+
+	for _, e := range c.patterns {
+		m := e.re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+
+		// m[0] is the whole message, m[i] is the value formatted by i-th verb
+		var res strings.Builder
+		res.WriteString(e.translation[0])
+		for i, s := range e.translation[1:] {
+			res.WriteString(m[i+1])
+			res.WriteString(s)
+		}
+		return res.String()
+	}
+
+	return msg
+}
+
+// Negotiate returns the best supported language for the given Accept-Language header value,
+// or DefaultLanguage if none of the requested languages is supported.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				q = v
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{lang: lang, q: q})
+	}
+
+	// stable sort keeps the client's order for equal weights
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.lang == "*" {
+			return DefaultLanguage
+		}
+
+		// match both exact (pt-br) and base (pt) language
+		base := strings.SplitN(c.lang, "-", 2)[0]
+		for _, lang := range []string{c.lang, base} {
+			if lang == DefaultLanguage {
+				return DefaultLanguage
+			}
+			if _, ok := catalogs[lang]; ok {
+				return lang
+			}
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// metadataKeys are gRPC metadata keys with requested languages:
+// set by direct gRPC clients, and forwarded by grpc-gateway from the HTTP header.
+var metadataKeys = []string{"accept-language", "grpcgateway-accept-language"}
+
+// FromContext returns the best supported language requested by incoming gRPC request metadata.
+func FromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return DefaultLanguage
+	}
+
+	for _, key := range metadataKeys {
+		if v := md.Get(key); len(v) != 0 {
+			return Negotiate(strings.Join(v, ","))
+		}
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,87 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLocalize(t *testing.T) {
+	for _, tc := range []struct {
+		lang     string
+		msg      string
+		expected string
+	}{
+		{"de", "Internal server error.", "Interner Serverfehler."},
+		{"de", fmt.Sprintf("Node with ID %q not found.", "/node_id/1"), `Node mit ID "/node_id/1" nicht gefunden.`},
+		{"es", fmt.Sprintf("Service with name %q already exists.", "mysql"), `Ya existe un servicio con el nombre "mysql".`},
+		{"es", fmt.Sprintf("Invalid cron expression: %v", "bad expression"), "Expresión cron no válida: bad expression"},
+
+		// fallbacks
+		{"de", "Some new message.", "Some new message."},
+		{"fr", "Internal server error.", "Internal server error."},
+		{"en", "Internal server error.", "Internal server error."},
+	} {
+		t.Run(tc.lang+"/"+tc.msg, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Localize(tc.lang, tc.msg))
+		})
+	}
+}
+
+func TestCatalogs(t *testing.T) {
+	assert.Equal(t, []string{"de", "en", "es"}, Languages())
+
+	// all languages should translate the same messages
+	for lang, c := range catalogs {
+		assert.Len(t, c.exact, len(catalogs["de"].exact), "%s", lang)
+		assert.Len(t, c.patterns, len(catalogs["de"].patterns), "%s", lang)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                          "en",
+		"de":                        "de",
+		"de-AT":                     "de",
+		"ES-mx, en;q=0.5":           "es",
+		"fr, es;q=0.8, de;q=0.9":    "de",
+		"fr, en;q=0.8, de;q=0.5":    "en",
+		"de;q=0, es;q=0.1":          "es",
+		"fr, *;q=0.5":               "en",
+		"fr, it":                    "en",
+		"es;q=invalid, de;q=0.5":    "es",
+		" de ; q=0.7 , es ; q=0.8 ": "es",
+	} {
+		assert.Equal(t, expected, Negotiate(header), "%q", header)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "en", FromContext(ctx))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("grpcgateway-accept-language", "es-ES,es;q=0.9"))
+	assert.Equal(t, "es", FromContext(ctx))
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "de"))
+	assert.Equal(t, "de", FromContext(ctx))
+}
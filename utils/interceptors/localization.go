@@ -0,0 +1,73 @@
+// pmm-managed
+// Copyright (C) 2017 Percona LLC
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+package interceptors
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/percona/pmm-managed/utils/i18n"
+)
+
+// localize returns gRPC error with message translated to the language requested by the client.
+// Error code and details are preserved. Other errors are returned as is.
+func localize(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	lang := i18n.FromContext(ctx)
+	if lang == i18n.DefaultLanguage {
+		return err
+	}
+
+	s, ok := status.FromError(errors.Cause(err))
+	if !ok {
+		return err
+	}
+
+	msg := i18n.Localize(lang, s.Message())
+	if msg == s.Message() {
+		return err
+	}
+
+	p := s.Proto()
+	p.Message = msg
+	return status.ErrorProto(p)
+}
+
+// UnaryLocalizationInterceptor returns a new unary server interceptor that translates error messages
+// to the language requested by Accept-Language metadata.
+//
+// It should be the first interceptor in the chain, so errors are logged in English.
+func UnaryLocalizationInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		return res, localize(ctx, err)
+	}
+}
+
+// StreamLocalizationInterceptor returns a new stream server interceptor that translates error messages
+// to the language requested by Accept-Language metadata.
+func StreamLocalizationInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return localize(stream.Context(), handler(srv, stream))
+	}
+}